@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/storage"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+)
+
+// runBench replays recorded or synthetic blocks through the parser and
+// in-memory storage as fast as possible and reports throughput and
+// allocations, so that performance regressions can be measured.
+func runBench(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	fixtures := fs.String("fixtures", "", "directory of recorded eth_getBlockByNumber results (*.json) to replay instead of synthetic blocks")
+	blocks := fs.Int("blocks", 1000, "number of synthetic blocks")
+	txs := fs.Int("txs", 150, "transactions per synthetic block")
+	addresses := fs.Int("addresses", 10000, "distinct addresses used by synthetic transactions")
+	asJSON := fs.Bool("json", false, "print the results as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var chain []rpc.Block
+	if *fixtures != "" {
+		var err error
+		if chain, err = loadBlockFixtures(*fixtures); err != nil {
+			return fmt.Errorf("bench: %w", err)
+		}
+	} else {
+		if *blocks <= 0 || *txs < 0 || *addresses < 2 {
+			return errors.New("bench: --blocks must be positive, --txs non-negative and --addresses at least 2")
+		}
+		chain = syntheticBlocks(*blocks, *txs, *addresses)
+	}
+
+	res, err := bench(context.Background(), chain)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return json.NewEncoder(stdout).Encode(res)
+	}
+	res.print(stdout)
+	return nil
+}
+
+// benchResult summarizes a bench run.
+type benchResult struct {
+	Blocks       int           `json:"blocks"`
+	Transactions int           `json:"transactions"`
+	Elapsed      time.Duration `json:"elapsed_ns"`
+	BlocksPerSec float64       `json:"blocks_per_sec"`
+	TxsPerSec    float64       `json:"txs_per_sec"`
+	AllocsPerTx  float64       `json:"allocs_per_tx"`
+	BytesPerTx   float64       `json:"bytes_per_tx"`
+	GCCycles     uint32        `json:"gc_cycles"`
+}
+
+func (r benchResult) print(w io.Writer) {
+	fmt.Fprintf(w, "blocks        %d\n", r.Blocks)
+	fmt.Fprintf(w, "transactions  %d\n", r.Transactions)
+	fmt.Fprintf(w, "elapsed       %s\n", r.Elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "blocks/s      %.1f\n", r.BlocksPerSec)
+	fmt.Fprintf(w, "txs/s         %.0f\n", r.TxsPerSec)
+	fmt.Fprintf(w, "allocs/tx     %.1f\n", r.AllocsPerTx)
+	fmt.Fprintf(w, "bytes/tx      %.0f\n", r.BytesPerTx)
+	fmt.Fprintf(w, "gc cycles     %d\n", r.GCCycles)
+}
+
+// bench processes chain, served as blocks 1..len(chain), into fresh
+// storage and measures the run. Logging is discarded so that it doesn't
+// dominate the profile.
+func bench(ctx context.Context, chain []rpc.Block) (benchResult, error) {
+	store := storage.NewMemoryStorage()
+	p := parser.NewParserWithInterval(&benchClient{blocks: chain}, store, time.Second, parser.Options{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	scanner, ok := p.(parser.RangeScanner)
+	if !ok {
+		return benchResult{}, errors.New("parser does not implement RangeScanner")
+	}
+
+	res := benchResult{Blocks: len(chain)}
+	for _, b := range chain {
+		res.Transactions += len(b.Transactions)
+	}
+
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	if err := scanner.ScanRange(ctx, 1, len(chain)); err != nil {
+		return benchResult{}, fmt.Errorf("bench: %w", err)
+	}
+	res.Elapsed = time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	secs := res.Elapsed.Seconds()
+	res.BlocksPerSec = float64(res.Blocks) / secs
+	res.TxsPerSec = float64(res.Transactions) / secs
+	if res.Transactions > 0 {
+		res.AllocsPerTx = float64(after.Mallocs-before.Mallocs) / float64(res.Transactions)
+		res.BytesPerTx = float64(after.TotalAlloc-before.TotalAlloc) / float64(res.Transactions)
+	}
+	res.GCCycles = after.NumGC - before.NumGC
+	return res, nil
+}
+
+// benchClient serves chain[n-1] as block n from memory.
+type benchClient struct {
+	blocks []rpc.Block
+}
+
+func (c *benchClient) Call(ctx context.Context, method string, params []interface{}, result interface{}) error {
+	return fmt.Errorf("bench: unsupported method %s", method)
+}
+
+func (c *benchClient) GetBlockNumber(ctx context.Context) (string, error) {
+	return fmt.Sprintf("0x%x", len(c.blocks)), nil
+}
+
+func (c *benchClient) GetBlockByNumber(ctx context.Context, blockNumber string, includeTransactions bool) (*rpc.Block, error) {
+	return nil, fmt.Errorf("bench: unsupported block %s", blockNumber)
+}
+
+func (c *benchClient) GetBlockByNumberInt(ctx context.Context, blockNumber int, includeTransactions bool) (*rpc.Block, error) {
+	if blockNumber < 1 || blockNumber > len(c.blocks) {
+		return &rpc.Block{}, nil
+	}
+	return &c.blocks[blockNumber-1], nil
+}
+
+func (c *benchClient) GetTransactionByHash(ctx context.Context, hash string) (*rpc.Transaction, error) {
+	return nil, rpc.ErrNotFound
+}
+
+// syntheticBlocks generates n blocks of txs value transfers each between
+// addresses distinct accounts.
+func syntheticBlocks(n, txs, addresses int) []rpc.Block {
+	account := func(i int) string { return fmt.Sprintf("0x%040x", i%addresses+1) }
+	blocks := make([]rpc.Block, n)
+	seq := 0
+	for b := range blocks {
+		blocks[b].Number = fmt.Sprintf("0x%x", b+1)
+		blocks[b].Transactions = make([]rpc.Transaction, txs)
+		for i := range blocks[b].Transactions {
+			seq++
+			blocks[b].Transactions[i] = rpc.Transaction{
+				Hash:  fmt.Sprintf("0x%064x", seq),
+				From:  account(seq * 7),
+				To:    account(seq*13 + 1),
+				Value: fmt.Sprintf("0x%x", uint64(seq)*1_000_000_007),
+			}
+		}
+	}
+	return blocks
+}
+
+// loadBlockFixtures reads the block fixtures in dir, as rpc.LoadBlockFixtures
+// does, ordered by number.
+func loadBlockFixtures(dir string) ([]rpc.Block, error) {
+	blocks, err := rpc.LoadBlockFixtures(dir)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(blocks, func(i, j int) bool {
+		return hexNumber(blocks[i].Number) < hexNumber(blocks[j].Number)
+	})
+	return blocks, nil
+}
+
+// hexNumber decodes a hex quantity, treating malformed ones as zero.
+func hexNumber(s string) uint64 {
+	n, _ := strconv.ParseUint(strings.TrimPrefix(s, "0x"), 16, 64)
+	return n
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/client"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+func TestRun_UnknownCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"bogus"}, &stdout, &stderr); err == nil {
+		t.Error("Expected error for unknown command")
+	}
+	if !strings.Contains(stderr.String(), "Usage: txparser") {
+		t.Error("Expected usage to be printed")
+	}
+}
+
+func TestRun_ScanRequiresRange(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"scan", "--from", "10"}, &stdout, &stderr); err == nil {
+		t.Error("Expected error when --to is missing")
+	}
+}
+
+func TestRun_ScanRequiresOutput(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	err := run([]string{"scan", "--from", "10", "--to", "20", "--rpc", "http://127.0.0.1:1"}, &stdout, &stderr)
+	if err == nil || !strings.Contains(err.Error(), "--address or --dry-run") {
+		t.Errorf("Expected an error without --address or --dry-run, got %v", err)
+	}
+}
+
+func TestRun_ScanRejectsInvalidShard(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"scan", "--from", "10", "--to", "20", "--shard-count", "4", "--shard-index", "4"}, &stdout, &stderr); err == nil {
+		t.Error("Expected error for a shard index past the shard count")
+	}
+}
+
+func TestScan_PrintsAddressTransactions(t *testing.T) {
+	client := NewMockRPCClient()
+	var out bytes.Buffer
+
+	if err := scan(context.Background(), client, 100, 101, "0xto1", parser.Options{}, &out); err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	var tx transaction.Transaction
+	if err := json.Unmarshal(bytes.Split(out.Bytes(), []byte("\n"))[0], &tx); err != nil {
+		t.Fatalf("Expected NDJSON output, got %q: %v", out.String(), err)
+	}
+	if tx.Hash != "0xhash1" || !tx.Inbound() {
+		t.Errorf("Unexpected transaction: %+v", tx)
+	}
+}
+
+func TestScan_DryRun(t *testing.T) {
+	client := NewMockRPCClient()
+	var out bytes.Buffer
+
+	if err := scan(context.Background(), client, 100, 101, "0xto1", parser.Options{DryRun: true}, &out); err != nil {
+		t.Fatalf("scan failed: %v", err)
+	}
+
+	var stats map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &stats); err != nil {
+		t.Fatalf("Expected JSON stats, got %q: %v", out.String(), err)
+	}
+	if stats["blocks"] != 2.0 || stats["records"] != 4.0 || stats["matched"] != 2.0 {
+		t.Errorf("Unexpected stats %v", stats)
+	}
+	if _, ok := stats["blocks_per_second"]; !ok {
+		t.Errorf("Expected the rate in %v", stats)
+	}
+}
+
+func TestRunExport(t *testing.T) {
+	address := "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/transactions" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("address") != address || r.URL.Query().Get("format") != "csv" {
+			t.Errorf("Unexpected query %s", r.URL.RawQuery)
+		}
+		w.Write([]byte("hash,from,to,value,block,inbound\n"))
+	}))
+	defer ts.Close()
+
+	var out bytes.Buffer
+	err := runExport([]string{"--server", ts.URL, "--address", "0x742d35Cc6634C0532925A3B8D4C9dB96C4B4d8B6", "--format", "csv"}, &out)
+	if err != nil {
+		t.Fatalf("runExport failed: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "hash,from") {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+}
+
+func TestRunSubscribe(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/subscribe" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		json.NewEncoder(w).Encode(map[string]bool{"subscribed": true})
+	}))
+	defer ts.Close()
+
+	var out bytes.Buffer
+	err := runSubscribe([]string{"--server", ts.URL, "--address", "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"}, &out)
+	if err != nil {
+		t.Fatalf("runSubscribe failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "subscribed 0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6") {
+		t.Errorf("Unexpected output: %q", out.String())
+	}
+}
+
+func TestRunSubscribe_ServerError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	var out bytes.Buffer
+	err := runSubscribe([]string{"--server", ts.URL, "--address", "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"}, &out)
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Expected server error to be surfaced, got %v", err)
+	}
+}
+
+func TestRun_Ctl(t *testing.T) {
+	const addr = "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-API-Key"); got != "key" {
+			t.Errorf("Expected the API key, got %q", got)
+		}
+		switch r.URL.Path {
+		case "/v1/subscribe":
+			json.NewEncoder(w).Encode(map[string]bool{"subscribed": true})
+		case "/v1/unsubscribe":
+			json.NewEncoder(w).Encode(map[string]bool{"unsubscribed": false})
+		case "/v1/current":
+			json.NewEncoder(w).Encode(parser.Status{Head: 120, Block: 100, Lag: 20})
+		case "/v1/transactions":
+			if got := r.URL.Query().Get("category"); got != "transfer" {
+				t.Errorf("Expected the category filter, got %q", got)
+			}
+			if r.URL.Query().Get("offset") == "" {
+				w.Header().Set("X-Next-Offset", "2")
+				w.Write([]byte(`[{"hash":"0x1","from":"0xa","to":"0xb","value":"10","block":1,"direction":"out"},{"hash":"0x2","value":"20","block":2}]`))
+				return
+			}
+			w.Write([]byte(`[{"hash":"0x3","value":"30","block":3}]`))
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"subscribe", []string{"subscribe", addr}, "subscribed " + addr + "\n"},
+		{"unsubscribe", []string{"unsubscribe", addr}, addr + " was not subscribed\n"},
+		{"status", []string{"status"}, "block\t100\nhead\t120\nlag\t20\n"},
+		{"status as JSON", []string{"status", "--json"}, `{"head":120,"block":100,"lag":20}` + "\n"},
+		{"txs", []string{"txs", addr, "--category", "transfer"}, "0x1\t1\tout\t0xa\t0xb\t10\n0x2\t2\t\t\t\t20\n0x3\t3\t\t\t\t30\n"},
+		{"txs with a limit", []string{"txs", "--category", "transfer", "--limit", "1", addr}, "0x1\t1\tout\t0xa\t0xb\t10\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			args := append([]string{"ctl"}, tt.args...)
+			if err := run(append(args, "--server", ts.URL, "--api-key", "key"), &stdout, &stderr); err != nil {
+				t.Fatalf("ctl %s failed: %v", tt.args[0], err)
+			}
+			if stdout.String() != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, stdout.String())
+			}
+		})
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"ctl", "txs", "--server", ts.URL}, &stdout, &stderr); err == nil {
+		t.Error("Expected an error without an address")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/events" || r.URL.Query().Get("address") != "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6" {
+			t.Errorf("Unexpected request %s", r.URL)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("event: transaction\nid: 0x1\ndata: {\"hash\":\"0x1\",\"value\":\"10\",\"block\":1,\"direction\":\"in\"}\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name   string
+		asJSON bool
+		want   string
+	}{
+		{"text", false, "0x1\t1\tin\t\t\t10\n"},
+		{"NDJSON", true, `{"hash":"0x1","from":"","to":"","value":"10","block":1,"direction":"in","inbound":true}` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := client.New(ts.URL, client.Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			out := &notifyWriter{written: cancel}
+			if err := watch(ctx, c, "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6", tt.asJSON, out); err != nil {
+				t.Fatalf("watch failed: %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, out.String())
+			}
+		})
+	}
+}
+
+// notifyWriter is a buffer calling written after each write.
+type notifyWriter struct {
+	bytes.Buffer
+	written func()
+}
+
+func (w *notifyWriter) Write(p []byte) (int, error) {
+	defer w.written()
+	return w.Buffer.Write(p)
+}
+
+func TestRun_Healthcheck(t *testing.T) {
+	ready := true
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/readyz" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if !ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"healthcheck", "--url", ts.URL + "/readyz"}, &stdout, &stderr); err != nil {
+		t.Errorf("Expected healthy instance to pass, got %v", err)
+	}
+
+	ready = false
+	if err := run([]string{"healthcheck", "--url", ts.URL + "/readyz"}, &stdout, &stderr); err == nil {
+		t.Error("Expected error when instance is not ready")
+	}
+}
+
+func TestReadyzURL(t *testing.T) {
+	tests := map[string]string{
+		":8080":          "http://127.0.0.1:8080/readyz",
+		"0.0.0.0:9090":   "http://127.0.0.1:9090/readyz",
+		"10.0.0.5:8080":  "http://10.0.0.5:8080/readyz",
+		"[::]:8080":      "http://127.0.0.1:8080/readyz",
+		"not-an-address": "http://localhost:8080/readyz",
+	}
+	for in, want := range tests {
+		if got := readyzURL(in); got != want {
+			t.Errorf("readyzURL(%q) = %q, expected %q", in, got, want)
+		}
+	}
+}
+
+func TestRun_BenchSynthetic(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"bench", "--blocks", "5", "--txs", "10", "--addresses", "4", "--json"}, &stdout, &stderr); err != nil {
+		t.Fatalf("bench failed: %v", err)
+	}
+	var res benchResult
+	if err := json.Unmarshal(stdout.Bytes(), &res); err != nil {
+		t.Fatalf("Expected JSON output, got %q: %v", stdout.String(), err)
+	}
+	if res.Blocks != 5 || res.Transactions != 50 || res.TxsPerSec <= 0 {
+		t.Errorf("Unexpected result: %+v", res)
+	}
+}
+
+func TestLoadBlockFixtures(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		// A bare block and one wrapped in its JSON-RPC response.
+		"b.json": `{"number":"0x2","transactions":[{"hash":"0x2a","from":"0x1","to":"0x2","value":"0x1"}]}`,
+		"a.json": `{"jsonrpc":"2.0","id":1,"result":{"number":"0x10","transactions":[]}}`,
+		"notes":  `ignored`,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	blocks, err := loadBlockFixtures(dir)
+	if err != nil {
+		t.Fatalf("loadBlockFixtures failed: %v", err)
+	}
+	if len(blocks) != 2 || blocks[0].Number != "0x2" || blocks[1].Number != "0x10" {
+		t.Fatalf("Expected blocks 0x2 and 0x10 in order, got %+v", blocks)
+	}
+	if len(blocks[0].Transactions) != 1 || blocks[0].Transactions[0].Hash != "0x2a" {
+		t.Errorf("Unexpected transactions: %+v", blocks[0].Transactions)
+	}
+
+	if _, err := loadBlockFixtures(t.TempDir()); err == nil {
+		t.Error("Expected an error for a directory without fixtures")
+	}
+}
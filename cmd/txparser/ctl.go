@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/client"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// ctlUsage describes the ctl subcommands.
+const ctlUsage = `Usage: txparser ctl <command> [flags] [address]
+
+Commands:
+  subscribe    Subscribe an address
+  unsubscribe  Unsubscribe an address
+  txs          List an address's transactions
+  status       Show the indexing progress
+
+Flags common to all commands:
+  --server URL   base URL of a running txparser (default ` + defaultServer + `)
+  --api-key KEY  API key (default $TXPARSER_API_KEY)
+  --chain NAME   chain of a multi-chain server
+  --json         print JSON instead of text
+`
+
+// ctlFlags are the flags common to the ctl subcommands.
+type ctlFlags struct {
+	server, apiKey, chain string
+	json                  bool
+}
+
+// register adds the common flags to fs.
+func (f *ctlFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.server, "server", defaultServer, "base URL of a running txparser")
+	fs.StringVar(&f.apiKey, "api-key", os.Getenv("TXPARSER_API_KEY"), "API key (default $TXPARSER_API_KEY)")
+	fs.StringVar(&f.chain, "chain", "", "chain of a multi-chain server")
+	fs.BoolVar(&f.json, "json", false, "print JSON instead of text")
+}
+
+// client returns a client of the server.
+func (f *ctlFlags) client() (*client.Client, error) {
+	return client.New(f.server, client.Options{APIKey: f.apiKey, Chain: f.chain, HTTPClient: remoteClient})
+}
+
+// runCtl runs a ctl subcommand, calling the HTTP API of a running instance
+// for scripts that would otherwise need curl and jq.
+func runCtl(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprint(stdout, ctlUsage)
+		return nil
+	}
+	cmd, args := args[0], args[1:]
+	// Each request is bounded by remoteClient's timeout.
+	ctx := context.Background()
+	var err error
+	switch cmd {
+	case "subscribe":
+		err = ctlSubscribe(ctx, args, stdout)
+	case "unsubscribe":
+		err = ctlUnsubscribe(ctx, args, stdout)
+	case "txs":
+		err = ctlTransactions(ctx, args, stdout)
+	case "status":
+		err = ctlStatus(ctx, args, stdout)
+	default:
+		fmt.Fprint(stderr, ctlUsage)
+		return fmt.Errorf("unknown ctl command %q", cmd)
+	}
+	if err != nil {
+		return fmt.Errorf("ctl %s: %w", cmd, err)
+	}
+	return nil
+}
+
+// parseArgs parses args with fs, allowing flags after the positional
+// arguments, and returns the positional ones.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// addressArg returns the single address among args.
+func addressArg(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("expected one address, got %d arguments", len(args))
+	}
+	return args[0], nil
+}
+
+// ctlSubscribe subscribes an address.
+func ctlSubscribe(ctx context.Context, args []string, stdout io.Writer) error {
+	var f ctlFlags
+	var opts client.SubscribeOptions
+	fs := flag.NewFlagSet("ctl subscribe", flag.ContinueOnError)
+	f.register(fs)
+	fs.DurationVar(&opts.TTL, "ttl", 0, "unsubscribe after this long (0 never does)")
+	fs.BoolVar(&opts.PurgeOnExpiry, "purge-on-expiry", false, "delete the address's data when its TTL expires")
+	fs.StringVar(&opts.CallbackURL, "callback-url", "", "URL receiving the address's transactions")
+	fs.StringVar(&opts.CallbackSecret, "callback-secret", "", "secret signing callbacks")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	addr, err := addressArg(positional)
+	if err != nil {
+		return err
+	}
+	c, err := f.client()
+	if err != nil {
+		return err
+	}
+	sub, err := c.Subscribe(ctx, addr, opts)
+	if err != nil {
+		return err
+	}
+	if f.json {
+		return json.NewEncoder(stdout).Encode(sub)
+	}
+	if !sub.Subscribed {
+		fmt.Fprintf(stdout, "%s was already subscribed\n", addr)
+		return nil
+	}
+	fmt.Fprintf(stdout, "subscribed %s", addr)
+	if !sub.ExpiresAt.IsZero() {
+		fmt.Fprintf(stdout, " until %s", sub.ExpiresAt.Format(time.RFC3339))
+	}
+	fmt.Fprintln(stdout)
+	if sub.Webhook != nil {
+		fmt.Fprintf(stdout, "webhook %s\n", sub.Webhook.ID)
+	}
+	return nil
+}
+
+// ctlUnsubscribe unsubscribes an address.
+func ctlUnsubscribe(ctx context.Context, args []string, stdout io.Writer) error {
+	var f ctlFlags
+	fs := flag.NewFlagSet("ctl unsubscribe", flag.ContinueOnError)
+	f.register(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	addr, err := addressArg(positional)
+	if err != nil {
+		return err
+	}
+	c, err := f.client()
+	if err != nil {
+		return err
+	}
+	ok, err := c.Unsubscribe(ctx, addr)
+	if err != nil {
+		return err
+	}
+	switch {
+	case f.json:
+		return json.NewEncoder(stdout).Encode(map[string]bool{"unsubscribed": ok})
+	case ok:
+		fmt.Fprintf(stdout, "unsubscribed %s\n", addr)
+	default:
+		fmt.Fprintf(stdout, "%s was not subscribed\n", addr)
+	}
+	return nil
+}
+
+// ctlTransactions lists an address's transactions, one per line: as
+// tab-separated hash, block, direction, from, to and value in wei, or with
+// --json as NDJSON.
+func ctlTransactions(ctx context.Context, args []string, stdout io.Writer) error {
+	var f ctlFlags
+	var opts client.TransactionsOptions
+	var categories string
+	var limit int
+	fs := flag.NewFlagSet("ctl txs", flag.ContinueOnError)
+	f.register(fs)
+	fs.StringVar(&categories, "category", "", "comma-separated categories to list")
+	fs.Func("since", "list transactions mined at or after this RFC 3339 time", timeFlag(&opts.Since))
+	fs.Func("until", "list transactions mined before this RFC 3339 time", timeFlag(&opts.Until))
+	fs.Func("indexed-since", "list transactions indexed after this RFC 3339 time", timeFlag(&opts.IndexedSince))
+	fs.IntVar(&limit, "limit", 0, "list at most this many transactions (0 lists all)")
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	addr, err := addressArg(positional)
+	if err != nil {
+		return err
+	}
+	if categories != "" {
+		for _, name := range strings.Split(categories, ",") {
+			c, err := transaction.ParseCategory(strings.TrimSpace(name))
+			if err != nil {
+				return err
+			}
+			opts.Categories = append(opts.Categories, c)
+		}
+	}
+	if limit > 0 {
+		opts.Limit = min(limit, 1000)
+	}
+	c, err := f.client()
+	if err != nil {
+		return err
+	}
+
+	var enc *json.Encoder
+	if f.json {
+		enc = json.NewEncoder(stdout)
+	}
+	n := 0
+	for tx, err := range c.AllTransactions(ctx, addr, opts) {
+		if err != nil {
+			return err
+		}
+		if err := printTransaction(stdout, enc, tx); err != nil {
+			return err
+		}
+		if n++; n == limit {
+			break
+		}
+	}
+	return nil
+}
+
+// printTransaction prints tx as a line of tab-separated hash, block,
+// direction, from, to and value in wei, or with enc as JSON.
+func printTransaction(w io.Writer, enc *json.Encoder, tx transaction.Transaction) error {
+	if enc != nil {
+		return enc.Encode(tx)
+	}
+	_, err := fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\n", tx.Hash, tx.Block, tx.Direction, tx.From, tx.To, tx.Value)
+	return err
+}
+
+// timeFlag returns a flag.Func parsing an RFC 3339 time into t.
+func timeFlag(t *time.Time) func(string) error {
+	return func(v string) error {
+		parsed, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return fmt.Errorf("expected an RFC 3339 time: %w", err)
+		}
+		*t = parsed
+		return nil
+	}
+}
+
+// ctlStatus shows the indexing progress.
+func ctlStatus(ctx context.Context, args []string, stdout io.Writer) error {
+	var f ctlFlags
+	fs := flag.NewFlagSet("ctl status", flag.ContinueOnError)
+	f.register(fs)
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return fmt.Errorf("unexpected arguments %v", positional)
+	}
+	c, err := f.client()
+	if err != nil {
+		return err
+	}
+	status, err := c.CurrentBlock(ctx)
+	if err != nil {
+		return err
+	}
+	if f.json {
+		return json.NewEncoder(stdout).Encode(status)
+	}
+	fmt.Fprintf(stdout, "block\t%d\nhead\t%d\nlag\t%d\n", status.Block, status.Head, status.Lag)
+	if bs := status.BackwardScan; bs != nil {
+		fmt.Fprintf(stdout, "backward_scan\t%d..%d at %d, %d remaining\n", bs.From, bs.To, bs.Block, bs.Remaining)
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// dumpSignals is empty: this platform has no signal for state dumps.
+var dumpSignals []os.Signal
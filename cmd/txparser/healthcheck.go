@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/config"
+)
+
+// runHealthcheck probes the local instance's /readyz endpoint and returns an
+// error unless it reports ready. It lets container images use the binary
+// itself as their HEALTHCHECK.
+func runHealthcheck(args []string, stdout io.Writer) error {
+	cfg := config.FromEnv()
+	fs := flag.NewFlagSet("healthcheck", flag.ContinueOnError)
+	target := fs.String("url", readyzURL(cfg.ListenAddr), "readiness endpoint to probe")
+	timeout := fs.Duration("timeout", 5*time.Second, "probe timeout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: *timeout}
+	resp, err := client.Get(*target)
+	if err != nil {
+		return fmt.Errorf("healthcheck: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return fmt.Errorf("healthcheck: %w", err)
+	}
+	fmt.Fprintln(stdout, "ready")
+	return nil
+}
+
+// readyzURL derives the local readiness URL from a listen address such as
+// ":8080" or "0.0.0.0:8080".
+func readyzURL(listenAddr string) string {
+	host, port, err := net.SplitHostPort(listenAddr)
+	if err != nil {
+		return defaultServer + "/readyz"
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	return "http://" + net.JoinHostPort(host, port) + "/readyz"
+}
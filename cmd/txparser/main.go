@@ -1,87 +1,73 @@
-// Package main wires the RPC client, in-memory storage, parser/poller, and HTTP server.
+// Package main wires the RPC client, in-memory storage, parser/poller, and HTTP server,
+// and exposes them through a small set of subcommands.
 package main
 
 import (
-	"context"
+	"fmt"
+	"io"
 	"log"
 	"os"
-	"os/signal"
-	"strconv"
-	"syscall"
-	"time"
+	"strings"
 
-	"github.com/danieloluwadare/tw-txparser/internal/server"
-	"github.com/danieloluwadare/tw-txparser/internal/storage"
-	"github.com/danieloluwadare/tw-txparser/pkg/parser"
-	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+	"github.com/danieloluwadare/tw-txparser/internal/config"
 )
 
-// main is the entry point. It starts the block poller and the HTTP server,
-// and performs a graceful shutdown on SIGINT/SIGTERM.
-func main() {
-	// RPC client - get URL from environment variable with fallback
-	rpcURL := os.Getenv("ETHEREUM_RPC_URL")
-	if rpcURL == "" {
-		rpcURL = "https://ethereum-rpc.publicnode.com"
-	}
-	log.Printf("Using Ethereum RPC URL: %s", rpcURL)
-	client := rpc.NewClient(rpcURL)
+// usage describes the available subcommands.
+const usage = `Usage: txparser <command> [flags]
+
+Commands:
+  serve       Run the poller and HTTP API (default)
+  scan        Backfill a block range once and exit
+  export      Dump an address's history from a running instance
+  subscribe   Subscribe an address on a running instance
+  ctl         Manage and query a running instance
+  watch       Print an address's transactions as a running instance indexes them
+  healthcheck Exit non-zero unless the local instance is ready
+  bench       Measure parser throughput on recorded or synthetic blocks
 
-	// In-memory storage
-	store := storage.NewMemoryStorage()
+Run "txparser <command> -h" for command flags.
+`
 
-	// Config from environment with defaults
-	backwardEnabled := true
-	if v := os.Getenv("BACKWARD_SCAN_ENABLED"); v != "" {
-		if b, err := strconv.ParseBool(v); err == nil {
-			backwardEnabled = b
-		}
+// main is the entry point. It loads a local .env file if present and
+// dispatches to the requested subcommand, defaulting to serve when none is given.
+func main() {
+	if err := config.LoadDotEnv(".env"); err != nil {
+		log.Fatal(err)
 	}
-	backwardDepth := 10000
-	if v := os.Getenv("BACKWARD_SCAN_DEPTH"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil && n > 0 {
-			backwardDepth = n
-		}
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		log.Fatal(err)
 	}
+}
 
-	// Parser with options
-	p := parser.NewParserWithInterval(client, store, 5*time.Second, parser.Options{
-		BackwardScanEnabled: backwardEnabled,
-		BackwardScanDepth:   backwardDepth,
-	})
-
-	// Cast parserImpl back to Poller
-	poller, ok := p.(parser.Poller)
-	if !ok {
-		log.Fatal("parser does not implement Poller")
+// run executes the subcommand named by args[0].
+func run(args []string, stdout, stderr io.Writer) error {
+	cmd := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		cmd, args = args[0], args[1:]
 	}
 
-	// Create root context with cancel
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// Start polling
-	log.Println("Starting Poller")
-	poller.Start(ctx)
-
-	// Start HTTP API
-	s := server.New(p)
-	go func() {
-		log.Println("Starting server on :8080")
-		if err := s.Start(":8080"); err != nil {
-			log.Fatal(err)
-		}
-	}()
-
-	// Graceful shutdown on SIGINT/SIGTERM
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
-	<-sigCh
-	log.Println("Shutting down...")
-
-	// Cancel context to signal all goroutines to stop
-	cancel()
-
-	// Wait for all parser goroutines to complete gracefully
-	poller.Stop()
+	switch cmd {
+	case "serve":
+		return runServe(args)
+	case "scan":
+		return runScan(args, stdout)
+	case "export":
+		return runExport(args, stdout)
+	case "subscribe":
+		return runSubscribe(args, stdout)
+	case "ctl":
+		return runCtl(args, stdout, stderr)
+	case "watch":
+		return runWatch(args, stdout)
+	case "healthcheck":
+		return runHealthcheck(args, stdout)
+	case "bench":
+		return runBench(args, stdout)
+	case "help":
+		fmt.Fprint(stdout, usage)
+		return nil
+	default:
+		fmt.Fprint(stderr, usage)
+		return fmt.Errorf("unknown command %q", cmd)
+	}
 }
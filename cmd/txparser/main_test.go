@@ -66,6 +66,10 @@ func (m *MockRPCClient) GetBlockByNumberInt(ctx context.Context, blockNumber int
 	return &m.blockResponse, nil
 }
 
+func (m *MockRPCClient) GetTransactionByHash(ctx context.Context, hash string) (*rpc.Transaction, error) {
+	return nil, rpc.ErrNotFound
+}
+
 func TestIntegration_SubscribeAndGetTransactions(t *testing.T) {
 	// Create mock RPC client
 	client := NewMockRPCClient()
@@ -80,7 +84,7 @@ func TestIntegration_SubscribeAndGetTransactions(t *testing.T) {
 	s := server.New(p)
 
 	// Test HTTP endpoints using the actual HTTP handlers
-	address := "0x1234567890abcdef"
+	address := "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"
 
 	// 1. Subscribe to address
 	subscribeBody := map[string]string{"address": address}
@@ -181,7 +185,7 @@ func TestIntegration_StorageOperations(t *testing.T) {
 	store := storage.NewMemoryStorage()
 
 	// Test subscription
-	address := "0x1234567890abcdef"
+	address := "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"
 	if !store.Subscribe(address) {
 		t.Error("Expected first subscription to succeed")
 	}
@@ -225,7 +229,7 @@ func TestIntegration_ConcurrentAccess(t *testing.T) {
 	store := storage.NewMemoryStorage()
 
 	// Test concurrent subscription attempts
-	address := "0x1234567890abcdef"
+	address := "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"
 	done := make(chan bool, 10)
 
 	for i := 0; i < 10; i++ {
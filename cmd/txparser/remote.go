@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/address"
+	"github.com/danieloluwadare/tw-txparser/pkg/ens"
+)
+
+// defaultServer is the API base URL used by client subcommands.
+const defaultServer = "http://localhost:8080"
+
+// remoteClient is used by subcommands that talk to a running instance.
+var remoteClient = &http.Client{Timeout: 60 * time.Second}
+
+// runExport dumps an address's transaction history from a running instance.
+func runExport(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	server := fs.String("server", defaultServer, "base URL of a running txparser")
+	addr := fs.String("address", "", "address to export (required)")
+	format := fs.String("format", "ndjson", "output format: json, csv or ndjson")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	normalized, err := address.Normalize(*addr)
+	if err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+
+	q := url.Values{"address": {normalized}, "format": {*format}}
+	resp, err := remoteClient.Get(strings.TrimRight(*server, "/") + "/v1/transactions?" + q.Encode())
+	if err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+	_, err = io.Copy(stdout, resp.Body)
+	return err
+}
+
+// runSubscribe subscribes an address on a running instance.
+func runSubscribe(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("subscribe", flag.ContinueOnError)
+	server := fs.String("server", defaultServer, "base URL of a running txparser")
+	addr := fs.String("address", "", "address or ENS name to subscribe (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	// ENS names are resolved by the server, if it has resolution enabled.
+	normalized := ens.Normalize(*addr)
+	if !ens.IsName(normalized) {
+		var err error
+		if normalized, err = address.Normalize(*addr); err != nil {
+			return fmt.Errorf("subscribe: %w", err)
+		}
+	}
+
+	body, _ := json.Marshal(map[string]string{"address": normalized})
+	resp, err := remoteClient.Post(strings.TrimRight(*server, "/")+"/v1/subscribe", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("subscribe: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return fmt.Errorf("subscribe: %w", err)
+	}
+
+	var result struct {
+		Subscribed bool `json:"subscribed"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("subscribe: failed to decode response: %w", err)
+	}
+	if resolved := resp.Header.Get("X-Resolved-Address"); resolved != "" {
+		normalized += " (" + resolved + ")"
+	}
+	if result.Subscribed {
+		fmt.Fprintf(stdout, "subscribed %s\n", normalized)
+	} else {
+		fmt.Fprintf(stdout, "%s was already subscribed\n", normalized)
+	}
+	return nil
+}
+
+// checkResponse turns non-2xx responses into errors carrying the server's message.
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if len(bytes.TrimSpace(msg)) == 0 {
+		return errors.New(resp.Status)
+	}
+	return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+}
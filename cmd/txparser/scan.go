@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/danieloluwadare/tw-txparser/internal/config"
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/internal/storage"
+	"github.com/danieloluwadare/tw-txparser/pkg/address"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+)
+
+// runScan backfills a block range once and exits. With --address, the
+// transactions found for that address are written to stdout as NDJSON. With
+// --dry-run, nothing is stored and the scan's stats are written instead. One
+// of them is required, as the scan's storage is gone once it exits.
+// With --shard-count, only the blocks of shard --shard-index are scanned, so
+// that several processes can split a long range.
+func runScan(args []string, stdout io.Writer) error {
+	cfg := config.FromEnv()
+	fs := flag.NewFlagSet("scan", flag.ContinueOnError)
+	from := fs.Int("from", 0, "first block to scan (required)")
+	to := fs.Int("to", 0, "last block to scan, inclusive (required)")
+	addr := fs.String("address", "", "print transactions found for this address as NDJSON")
+	chain := fs.String("chain", "", "scan this configured chain (see CHAINS)")
+	rpcURL := fs.String("rpc", "", "Ethereum JSON-RPC endpoint, overriding the chain's")
+	dryRun := fs.Bool("dry-run", false, "parse without storing and print the scan's stats as JSON")
+	shardCount := fs.Int("shard-count", cfg.ShardCount, "number of processes splitting the range")
+	shardIndex := fs.Int("shard-index", cfg.ShardIndex, "blocks scanned by this process: those whose number modulo --shard-count equals it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := config.CheckShard(*shardIndex, *shardCount); err != nil {
+		return fmt.Errorf("scan: %w", err)
+	}
+	if *rpcURL == "" {
+		*rpcURL = cfg.Chains[0].RPCURL
+		if *chain != "" {
+			ch, ok := cfg.FindChain(*chain)
+			if !ok {
+				return fmt.Errorf("scan: unknown chain %q", *chain)
+			}
+			*rpcURL = ch.RPCURL
+		}
+	}
+	if *from <= 0 || *to < *from {
+		return errors.New("scan: --from and --to must describe a non-empty block range")
+	}
+	if *addr == "" && !*dryRun {
+		return errors.New("scan: --address or --dry-run is required, as nothing else is kept once the scan exits")
+	}
+	if *addr != "" {
+		normalized, err := address.Normalize(*addr)
+		if err != nil {
+			return fmt.Errorf("scan: %w", err)
+		}
+		*addr = normalized
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client := rpc.NewClient(*rpcURL)
+	opts := parser.Options{DryRun: *dryRun, Shards: *shardCount, Shard: *shardIndex}
+	return scan(ctx, client, *from, *to, *addr, opts, stdout)
+}
+
+// scan processes from..to with client and writes addr's transactions to out.
+// In dry-run mode it writes the scan's stats instead, with addr's records
+// counted as matched.
+func scan(ctx context.Context, client rpc.RPCClient, from, to int, addr string, opts parser.Options, out io.Writer) error {
+	dryRun := opts.DryRun
+	store := storage.NewMemoryStorage()
+	p := parser.NewParserWithInterval(client, store, config.Default().PollInterval, opts)
+	scanner, ok := p.(parser.RangeScanner)
+	if !ok {
+		return errors.New("parser does not implement RangeScanner")
+	}
+
+	if addr != "" {
+		store.Subscribe(addr)
+	}
+	scanErr := scanner.ScanRange(ctx, from, to)
+	logging.Component("scan").Info("scanned blocks", "from", from, "to", to, "shard", opts.Shard, "shards", opts.Shards)
+	if dryRun {
+		stats, _ := p.(parser.DryRunner).DryRunStats()
+		if err := json.NewEncoder(out).Encode(struct {
+			parser.DryRunStats
+			BlocksPerSecond float64 `json:"blocks_per_second"`
+		}{stats, stats.BlocksPerSecond()}); err != nil {
+			return err
+		}
+		return scanErr
+	}
+	enc := json.NewEncoder(out)
+	for _, tx := range store.GetTransactions(addr) {
+		if err := enc.Encode(tx); err != nil {
+			return err
+		}
+	}
+	return scanErr
+}
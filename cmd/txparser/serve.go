@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/danieloluwadare/tw-txparser/internal/config"
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/app"
+)
+
+// runServe starts the block poller and the HTTP server, and performs a
+// coordinated graceful shutdown on SIGINT/SIGTERM or when the server fails.
+// SIGUSR1 dumps the state of the instance.
+func runServe(args []string) error {
+	cfg := config.FromEnv()
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.StringVar(&cfg.ListenAddr, "listen", cfg.ListenAddr, "HTTP listen address")
+	fs.DurationVar(&cfg.ShutdownTimeout, "shutdown-timeout", cfg.ShutdownTimeout, "overall deadline for graceful shutdown")
+	fs.StringVar(&cfg.ConfigFile, "config", cfg.ConfigFile, "JSON file declaring notification sinks")
+	fs.BoolVar(&cfg.DryRun, "dry-run", cfg.DryRun, "fetch and parse blocks without storing or delivering anything")
+	fs.StringVar(&cfg.ReplayDir, "replay", cfg.ReplayDir, "serve blocks from the recorded fixtures in this directory instead of the RPC endpoint")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := logging.Setup(os.Stderr, cfg.LogFormat, cfg.LogLevel); err != nil {
+		return err
+	}
+	logging.SetTxSampling(logging.TxSampling{Every: cfg.LogTxSample, MatchedOnly: cfg.LogTxMatchedOnly})
+
+	a, err := app.New(cfg)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go dumpOnSignal(ctx, a)
+	return a.Run(ctx)
+}
+
+// dumpOnSignal dumps the state of a on each of dumpSignals until ctx is
+// cancelled.
+func dumpOnSignal(ctx context.Context, a *app.App) {
+	if len(dumpSignals) == 0 {
+		return
+	}
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, dumpSignals...)
+	defer signal.Stop(sigCh)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigCh:
+			if _, err := a.Dump(); err != nil {
+				logging.Component("serve").Error("failed to dump state", logging.KeyError, err)
+			}
+		}
+	}
+}
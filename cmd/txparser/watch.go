@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/address"
+	"github.com/danieloluwadare/tw-txparser/pkg/client"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// runWatch prints an address's transactions as a running instance indexes
+// them, until interrupted.
+func runWatch(args []string, stdout io.Writer) error {
+	var f ctlFlags
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	f.register(fs)
+	addr := fs.String("address", "", "address to watch (required)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	normalized, err := address.Normalize(*addr)
+	if err != nil {
+		return fmt.Errorf("watch: %w", err)
+	}
+	c, err := f.client()
+	if err != nil {
+		return fmt.Errorf("watch: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return watch(ctx, c, normalized, f.json, stdout)
+}
+
+// watch prints addr's transactions to out as they arrive, one per line,
+// until ctx is cancelled, which is not an error.
+func watch(ctx context.Context, c *client.Client, addr string, asJSON bool, out io.Writer) error {
+	var enc *json.Encoder
+	if asJSON {
+		enc = json.NewEncoder(out)
+	}
+	var printErr error
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	err := c.Watch(ctx, addr, func(tx transaction.Transaction) {
+		// A closed pipe, as when piped into head, ends the command.
+		if err := printTransaction(out, enc, tx); err != nil && printErr == nil {
+			printErr = err
+			cancel()
+		}
+	})
+	if printErr != nil {
+		return fmt.Errorf("watch: %w", printErr)
+	}
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return fmt.Errorf("watch: %w", err)
+}
@@ -0,0 +1,158 @@
+// Package audit records changes to the address watchlist in an append-only
+// log so that every subscribe and unsubscribe can be attributed to a caller.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Actions recorded in the log.
+const (
+	ActionSubscribe   = "subscribe"
+	ActionUnsubscribe = "unsubscribe"
+)
+
+// Entry is one recorded watchlist change.
+type Entry struct {
+	Time    time.Time `json:"time"`
+	Action  string    `json:"action"`
+	Chain   string    `json:"chain,omitempty"`
+	Address string    `json:"address"`
+	// Changed is false when the request was a no-op, e.g. subscribing an
+	// address that was already subscribed.
+	Changed bool `json:"changed"`
+	// Source names the API that made the change: "subscribe", "webhook" or
+	// "import", or "expiry" when a subscription's TTL elapsed.
+	Source string `json:"source"`
+	// RemoteIP is the peer address of the request. ForwardedFor is the
+	// caller-supplied X-Forwarded-For header and is not verified.
+	RemoteIP     string `json:"remote_ip,omitempty"`
+	ForwardedFor string `json:"forwarded_for,omitempty"`
+	RequestID    string `json:"request_id,omitempty"`
+	// Tenant names the API key that made the change, when API keys are
+	// enabled.
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// Query selects entries. Zero fields match everything.
+type Query struct {
+	Chain   string
+	Address string
+	Action  string
+	Since   time.Time
+	// Limit keeps only the most recent matching entries when positive.
+	Limit int
+}
+
+func (q Query) matches(e Entry) bool {
+	return (q.Chain == "" || e.Chain == q.Chain) &&
+		(q.Address == "" || e.Address == q.Address) &&
+		(q.Action == "" || e.Action == q.Action) &&
+		!e.Time.Before(q.Since)
+}
+
+// Log is a thread-safe append-only audit log. Entries are kept in memory
+// for queries and, when opened from a file, also appended to it as JSON
+// lines.
+type Log struct {
+	mu      sync.Mutex
+	entries []Entry
+	file    *os.File // nil for in-memory logs
+	now     func() time.Time
+}
+
+// New creates an in-memory Log.
+func New() *Log {
+	return &Log{now: time.Now}
+}
+
+// Open creates a Log persisted to path, loading the entries already in it.
+func Open(path string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	entries, err := readEntries(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to read audit log %s: %w", path, err)
+	}
+	return &Log{entries: entries, file: f, now: time.Now}, nil
+}
+
+// readEntries decodes the JSON lines in r.
+func readEntries(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
+
+// Record appends e, stamping its time if unset. The entry is kept in memory
+// even if writing it to the file fails.
+func (l *Log) Record(e Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e.Time.IsZero() {
+		e.Time = l.now().UTC()
+	}
+	l.entries = append(l.entries, e)
+	if l.file == nil {
+		return nil
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
+
+// Query returns the entries matching q, oldest first.
+func (l *Log) Query(q Query) []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := []Entry{}
+	for _, e := range l.entries {
+		if q.matches(e) {
+			out = append(out, e)
+		}
+	}
+	if q.Limit > 0 && len(out) > q.Limit {
+		out = out[len(out)-q.Limit:]
+	}
+	return out
+}
+
+// Close syncs and closes the backing file, if any.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Sync()
+	if cerr := l.file.Close(); err == nil {
+		err = cerr
+	}
+	l.file = nil
+	return err
+}
@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLog_Query(t *testing.T) {
+	l := New()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Time: base, Action: ActionSubscribe, Chain: "ethereum", Address: "0xaaa", Changed: true},
+		{Time: base.Add(time.Minute), Action: ActionSubscribe, Chain: "sepolia", Address: "0xbbb", Changed: true},
+		{Time: base.Add(2 * time.Minute), Action: ActionUnsubscribe, Chain: "ethereum", Address: "0xaaa", Changed: true},
+	}
+	for _, e := range entries {
+		if err := l.Record(e); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		query    Query
+		expected []string // addresses, oldest first
+	}{
+		{name: "all", expected: []string{"0xaaa", "0xbbb", "0xaaa"}},
+		{name: "address", query: Query{Address: "0xaaa"}, expected: []string{"0xaaa", "0xaaa"}},
+		{name: "chain", query: Query{Chain: "sepolia"}, expected: []string{"0xbbb"}},
+		{name: "action", query: Query{Action: ActionUnsubscribe}, expected: []string{"0xaaa"}},
+		{name: "since", query: Query{Since: base.Add(time.Minute)}, expected: []string{"0xbbb", "0xaaa"}},
+		{name: "limit keeps newest", query: Query{Limit: 1}, expected: []string{"0xaaa"}},
+		{name: "no match", query: Query{Address: "0xccc"}, expected: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := l.Query(tt.query)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Expected %d entries, got %d", len(tt.expected), len(got))
+			}
+			for i, e := range got {
+				if e.Address != tt.expected[i] {
+					t.Errorf("Entry %d: expected %s, got %s", i, tt.expected[i], e.Address)
+				}
+			}
+		})
+	}
+	if got := l.Query(Query{Limit: 1})[0]; got.Action != ActionUnsubscribe {
+		t.Errorf("Expected the newest entry, got %+v", got)
+	}
+}
+
+func TestLog_StampsTime(t *testing.T) {
+	l := New()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	l.Record(Entry{Action: ActionSubscribe, Address: "0xaaa"})
+	if got := l.Query(Query{})[0].Time; !got.Equal(now) {
+		t.Errorf("Expected time %v, got %v", now, got)
+	}
+}
+
+func TestOpen_Persists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	l.Record(Entry{Action: ActionSubscribe, Address: "0xaaa", RemoteIP: "10.0.0.1"})
+	l.Record(Entry{Action: ActionUnsubscribe, Address: "0xaaa", RemoteIP: "10.0.0.2"})
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("Expected 2 lines, got %d", lines)
+	}
+
+	// Reopening loads the history and appends after it.
+	l, err = Open(path)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	defer l.Close()
+	l.Record(Entry{Action: ActionSubscribe, Address: "0xbbb"})
+	got := l.Query(Query{})
+	if len(got) != 3 || got[1].RemoteIP != "10.0.0.2" || got[2].Address != "0xbbb" {
+		t.Errorf("Unexpected entries after reopen: %+v", got)
+	}
+}
+
+func TestOpen_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	if err := os.WriteFile(path, []byte("not json\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path); err == nil {
+		t.Error("Expected error for corrupt log")
+	}
+}
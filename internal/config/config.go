@@ -0,0 +1,820 @@
+// Package config loads runtime configuration from the environment.
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/address"
+)
+
+// Config holds the settings shared by all txparser subcommands.
+type Config struct {
+	// RPCURL is the Ethereum JSON-RPC endpoint, or a comma-separated list
+	// of endpoints each optionally suffixed with |<weight>
+	// (ETHEREUM_RPC_URL).
+	RPCURL string
+	// WSURL is a WebSocket endpoint of the same node, over which token
+	// transfers of subscribed addresses are streamed as the node sees them
+	// (ETHEREUM_WS_URL). It only applies with IndexTokens.
+	WSURL string
+	// RPCStrategy is how calls are spread across several endpoints,
+	// "failover" or "round-robin" (RPC_STRATEGY).
+	RPCStrategy string
+	// RPCHealthInterval is how often several endpoints are probed
+	// (RPC_HEALTH_INTERVAL).
+	RPCHealthInterval time.Duration
+	// RPCMaxLag is how many blocks an endpoint may trail the others before
+	// it is taken out of rotation (RPC_MAX_LAG).
+	RPCMaxLag int
+	// RPCMaxIdleConnsPerHost is how many idle connections to each endpoint
+	// are kept for reuse (RPC_MAX_IDLE_CONNS_PER_HOST).
+	RPCMaxIdleConnsPerHost int
+	// RPCKeepAlive is the interval of TCP keep-alive probes; 0 disables
+	// them (RPC_KEEP_ALIVE).
+	RPCKeepAlive time.Duration
+	// RPCDialTimeout and RPCTLSHandshakeTimeout bound connecting to an
+	// endpoint (RPC_DIAL_TIMEOUT, RPC_TLS_HANDSHAKE_TIMEOUT).
+	RPCDialTimeout         time.Duration
+	RPCTLSHandshakeTimeout time.Duration
+	// RPCHTTP2 lets HTTPS endpoints multiplex calls over HTTP/2 (RPC_HTTP2).
+	RPCHTTP2 bool
+	// Chain names the indexed network, reported by /version (CHAIN). Naming
+	// a built-in network, see Presets, selects its chain ID, public
+	// endpoint and block time.
+	Chain string
+	// BackwardScanEnabled toggles the historical scan at startup (BACKWARD_SCAN_ENABLED).
+	BackwardScanEnabled bool
+	// BackwardScanDepth is how many blocks to scan backward (BACKWARD_SCAN_DEPTH).
+	BackwardScanDepth int
+	// PollInterval is the forward polling interval.
+	PollInterval time.Duration
+	// ListenAddr is the HTTP listen address (LISTEN_ADDR).
+	ListenAddr string
+	// AdminToken protects admin endpoints; empty disables them (ADMIN_TOKEN).
+	AdminToken string
+	// APIKeys scopes subscriptions to API keys, given as comma-separated
+	// tenant:key pairs, e.g. "payments:k1,risk:k2"; empty leaves the API
+	// open (API_KEYS).
+	APIKeys string
+	// WebhookAllowedNetworks lists comma-separated networks, in CIDR
+	// notation, that webhooks may be delivered to even though they are
+	// loopback, private or link-local, e.g. "127.0.0.0/8" for local testing
+	// (WEBHOOK_ALLOWED_NETWORKS).
+	WebhookAllowedNetworks string
+	// ConfigFile is an optional JSON file declaring notification sinks (CONFIG_FILE).
+	ConfigFile string
+	// AuditLogFile persists the subscription audit log as JSON lines; it is
+	// kept in memory only when empty (AUDIT_LOG_FILE).
+	AuditLogFile string
+	// DumpDir is where state dumps triggered by SIGUSR1 are written, as
+	// timestamped JSON files; they are logged when empty (DUMP_DIR).
+	DumpDir string
+	// FetchReceipts fetches receipts of transactions sent by subscribed
+	// addresses to record their fees (FETCH_RECEIPTS).
+	FetchReceipts bool
+	// EnrichReceipts fetches receipts of transactions involving subscribed
+	// addresses in the background instead, recording their status and gas
+	// used along with their fee (ENRICH_RECEIPTS). ReceiptWorkers and
+	// ReceiptBatchSize bound its concurrent requests and the receipts each
+	// fetches (RECEIPT_WORKERS, RECEIPT_BATCH_SIZE).
+	EnrichReceipts   bool
+	ReceiptWorkers   int
+	ReceiptBatchSize int
+	// BlockRetries is how many more times a failed block is tried before it
+	// is moved to the dead-letter queue (BLOCK_RETRIES). DeadLetterFile
+	// persists the queue as JSON; it is kept in memory only when empty
+	// (DEAD_LETTER_FILE).
+	BlockRetries   int
+	DeadLetterFile string
+	// OutboxFile persists the notifications handed to sinks and webhooks
+	// until they are delivered, so deliveries interrupted by a crash are
+	// resumed on restart; delivery is best-effort when empty (OUTBOX_FILE).
+	OutboxFile string
+	// BlockTimeout bounds each attempt at processing a block, so a hung RPC
+	// response can't stall polling; 0 disables it (BLOCK_TIMEOUT).
+	BlockTimeout time.Duration
+	// MaxTransactionsPerAddress caps the transactions kept per address,
+	// dropping those of the oldest blocks past it; 0 keeps everything
+	// (MAX_TRANSACTIONS_PER_ADDRESS).
+	MaxTransactionsPerAddress int
+	// CompactionInterval is how often the stored records are compacted,
+	// reclaiming the spare capacity left by appends; 0 disables compaction
+	// (COMPACTION_INTERVAL).
+	CompactionInterval time.Duration
+	// UnsubscribedRetention is how long the records of addresses that were
+	// never subscribed are kept after the last one was stored; 0 keeps them
+	// forever (UNSUBSCRIBED_RETENTION). GCInterval is how often they are
+	// looked for (GC_INTERVAL).
+	UnsubscribedRetention time.Duration
+	GCInterval            time.Duration
+	// TrackBalances keeps a running native balance of subscribed addresses
+	// for /balance (TRACK_BALANCES).
+	TrackBalances bool
+	// DryRun fetches and parses blocks without storing or delivering
+	// anything, logging what would have been stored instead (DRY_RUN).
+	DryRun bool
+	// ReplayDir, if set, serves blocks from the recorded fixtures in this
+	// directory instead of the chain's RPC endpoint, reproducing a run
+	// offline. With several chains each replays the subdirectory named after
+	// it (REPLAY_DIR).
+	ReplayDir string
+	// BlockCacheSize is how many recently fetched blocks each chain's parser
+	// keeps in memory; 0 disables the cache (BLOCK_CACHE_SIZE).
+	BlockCacheSize int
+	// ReorgDepth is how many of the latest blocks each chain's parser
+	// tracks to detect reorganizations; 0 disables detection (REORG_DEPTH).
+	ReorgDepth int
+	// ActivityFeedSize is how many of the latest transactions of subscribed
+	// addresses each chain keeps for /activity; 0 disables the feed
+	// (ACTIVITY_FEED_SIZE).
+	ActivityFeedSize int
+	// CatchUpWorkers is how many blocks are fetched concurrently while a
+	// chain is more than CatchUpThreshold blocks behind the head; below 2
+	// catch-up is serial (CATCHUP_WORKERS, CATCHUP_THRESHOLD).
+	// CatchUpQueueSize is how many fetched blocks may wait to be stored
+	// before fetching pauses (CATCHUP_QUEUE_SIZE).
+	CatchUpWorkers   int
+	CatchUpThreshold int
+	CatchUpQueueSize int
+	// ShardCount splits backfills across instances, each processing the
+	// blocks whose number modulo ShardCount is its ShardIndex; 1 disables
+	// sharding (SHARD_COUNT, SHARD_INDEX).
+	ShardCount int
+	ShardIndex int
+	// IgnoreAddresses lists addresses, such as the zero address or known
+	// spam airdroppers, whose transactions are never stored or delivered.
+	// Malformed entries are skipped (IGNORE_ADDRESSES).
+	IgnoreAddresses []string
+	// SkipZeroValueCalls drops contract calls that transfer no ether unless
+	// their sender is subscribed (SKIP_ZERO_VALUE_CALLS).
+	SkipZeroValueCalls bool
+	// MaxBlockLag is the largest acceptable number of blocks a chain may be
+	// behind the node's head before an alert is raised; 0 disables lag
+	// alerting (MAX_BLOCK_LAG).
+	MaxBlockLag int
+	// LagAlertURL receives lag alerts and recoveries: a Slack or Discord
+	// webhook, or any URL accepting JSON (LAG_ALERT_URL).
+	LagAlertURL string
+	// ENSResolution accepts ENS names in place of addresses and enables
+	// reverse resolution of counterparties on request (ENS_RESOLUTION).
+	ENSResolution bool
+	// ENSCacheTTL is how long ENS lookups are cached (ENS_CACHE_TTL).
+	ENSCacheTTL time.Duration
+	// LabelsFile is a JSON or CSV file of address labels that annotate
+	// counterparties in API responses (LABELS_FILE).
+	LabelsFile string
+	// LabelsBuiltin enables the built-in labels of well-known exchanges,
+	// bridges and mixers (LABELS_BUILTIN).
+	LabelsBuiltin bool
+	// ABIDecoding decodes the input data of contract calls with the
+	// built-in ERC-20 and ERC-721 methods (ABI_DECODING).
+	ABIDecoding bool
+	// ABIFiles lists JSON contract ABIs whose methods are decoded too,
+	// taking precedence over the built-in ones. Setting it enables
+	// decoding (ABI_FILES, comma-separated).
+	ABIFiles []string
+	// StoreInput keeps the raw input data of transactions, cut to
+	// MaxInputBytes bytes (STORE_INPUT, MAX_INPUT_BYTES).
+	StoreInput    bool
+	MaxInputBytes int
+	// IndexTokens indexes ERC-20 and ERC-721 transfers from event logs,
+	// attaching the symbol, name and decimals of their token (INDEX_TOKENS).
+	IndexTokens bool
+	// TokenMetadataTTL is how long token metadata is cached
+	// (TOKEN_METADATA_TTL).
+	TokenMetadataTTL time.Duration
+	// EtherscanURL is an Etherscan-compatible API, e.g.
+	// https://api.etherscan.io/v2/api; when set, the history of newly
+	// subscribed addresses is backfilled from it rather than waiting for
+	// the block scans (ETHERSCAN_URL). EtherscanAPIKey authenticates with
+	// it (ETHERSCAN_API_KEY).
+	EtherscanURL    string
+	EtherscanAPIKey string
+	// EtherscanRateLimit is how many requests are made to it per second at
+	// most (ETHERSCAN_RATE_LIMIT).
+	EtherscanRateLimit int
+	// GasCacheTTL is how long /gas fee statistics are cached
+	// (GAS_CACHE_TTL).
+	GasCacheTTL time.Duration
+	// ShutdownTimeout bounds the whole graceful shutdown (SHUTDOWN_TIMEOUT).
+	ShutdownTimeout time.Duration
+	// LeaderLockFile enables leader election: of the instances sharing this
+	// lock file, only the one holding it polls, and the others take over
+	// when it dies (LEADER_LOCK_FILE). LeaderRetryInterval is how often
+	// standbys try to (LEADER_RETRY_INTERVAL).
+	LeaderLockFile      string
+	LeaderRetryInterval time.Duration
+	// NATSURL enables publishing transactions to NATS when set (NATS_URL).
+	NATSURL string
+	// NATSSubjectPrefix starts every NATS subject (NATS_SUBJECT_PREFIX).
+	NATSSubjectPrefix string
+	// NATSJetStream publishes through JetStream with acks (NATS_JETSTREAM).
+	NATSJetStream bool
+	// MQTTURL enables publishing transactions to an MQTT broker when set (MQTT_URL).
+	MQTTURL string
+	// MQTTTopic is the topic template; {chain}, {address} and {direction}
+	// are replaced per event (MQTT_TOPIC).
+	MQTTTopic string
+	// MQTTQoS is the MQTT quality of service level, 0 to 2 (MQTT_QOS).
+	MQTTQoS int
+	// MQTTUsername and MQTTPassword authenticate with the broker (MQTT_USERNAME, MQTT_PASSWORD).
+	MQTTUsername string
+	MQTTPassword string
+	// ClickHouseURL is the HTTP interface of a ClickHouse server; indexed
+	// transactions are inserted into ClickHouseTable when set
+	// (CLICKHOUSE_URL, CLICKHOUSE_TABLE).
+	ClickHouseURL   string
+	ClickHouseTable string
+	// ClickHouseUsername and ClickHousePassword authenticate with the server
+	// (CLICKHOUSE_USERNAME, CLICKHOUSE_PASSWORD).
+	ClickHouseUsername string
+	ClickHousePassword string
+	// ClickHouseBatchSize is how many rows are inserted at once, and
+	// ClickHouseFlushInterval how long a partial batch waits
+	// (CLICKHOUSE_BATCH_SIZE, CLICKHOUSE_FLUSH_INTERVAL).
+	ClickHouseBatchSize     int
+	ClickHouseFlushInterval time.Duration
+	// ChatWebhookURL enables Slack/Discord notifications when set (CHAT_WEBHOOK_URL).
+	ChatWebhookURL string
+	// ChatMinValue is the smallest inbound value, in ether, that is
+	// notified (CHAT_MIN_VALUE).
+	ChatMinValue string
+	// SMTPHost enables email notifications together with EmailRecipients (SMTP_HOST).
+	SMTPHost string
+	// SMTPPort is the SMTP submission port (SMTP_PORT).
+	SMTPPort int
+	// SMTPUsername and SMTPPassword enable SMTP auth (SMTP_USERNAME, SMTP_PASSWORD).
+	SMTPUsername string
+	SMTPPassword string
+	// EmailFrom is the sender address (EMAIL_FROM).
+	EmailFrom string
+	// EmailRecipients maps addresses to recipients, e.g.
+	// "0xabc...=ops@example.com;cfo@example.com,*=alerts@example.com" (EMAIL_RECIPIENTS).
+	EmailRecipients string
+	// EmailBatchWindow groups events into one email per recipient (EMAIL_BATCH_WINDOW).
+	EmailBatchWindow time.Duration
+	// EmailTemplate is an optional path to a text/template body (EMAIL_TEMPLATE).
+	EmailTemplate string
+	// MetricsBackend selects where metrics go: "prometheus", served on
+	// /metrics, "statsd" or "none" (METRICS_BACKEND).
+	MetricsBackend string
+	// StatsDAddr is the UDP address metrics are sent to with the statsd
+	// backend (STATSD_ADDR).
+	StatsDAddr string
+	// StatsDTags are comma-separated tags added to every StatsD metric,
+	// e.g. "env:prod,team:payments" (STATSD_TAGS).
+	StatsDTags []string
+	// TracingEndpoint is the OTLP/HTTP collector URL; spans are exported
+	// when set (OTEL_EXPORTER_OTLP_ENDPOINT).
+	TracingEndpoint string
+	// TracingSampleRatio is the fraction of new traces recorded (TRACING_SAMPLE_RATIO).
+	TracingSampleRatio float64
+	// LogFormat selects "text" or "json" log output (LOG_FORMAT).
+	LogFormat string
+	// LogLevel is the minimum level logged: debug, info, warn or error (LOG_LEVEL).
+	LogLevel string
+	// LogTxSample logs one in LogTxSample processed transactions at debug
+	// level (LOG_TX_SAMPLE).
+	LogTxSample int
+	// LogTxMatchedOnly logs only processed transactions of subscribed
+	// addresses (LOG_TX_MATCHED_ONLY).
+	LogTxMatchedOnly bool
+	// Chains lists every indexed network. The first entry is the default chain
+	// served on the unscoped routes. When CHAINS is unset it holds a single
+	// chain built from the settings above.
+	Chains []ChainConfig
+}
+
+// ChainConfig describes one indexed network.
+type ChainConfig struct {
+	// Name identifies the chain in API routes, e.g. /v1/{name}/transactions.
+	Name string
+	// ChainID is the EIP-155 chain ID of a built-in network, or 0.
+	ChainID int64
+	// RPCURL is the chain's JSON-RPC endpoint or endpoints, in the same
+	// format as Config.RPCURL (CHAIN_<NAME>_RPC_URL).
+	RPCURL string
+	// WSURL is the chain's WebSocket endpoint, see Config.WSURL
+	// (CHAIN_<NAME>_WS_URL). Unlike other settings it isn't inherited from
+	// the top level, which names another network's endpoint.
+	WSURL string
+	// PollInterval is the forward polling interval (CHAIN_<NAME>_POLL_INTERVAL).
+	PollInterval time.Duration
+	// BackwardScanEnabled toggles the historical scan (CHAIN_<NAME>_BACKWARD_SCAN_ENABLED).
+	BackwardScanEnabled bool
+	// BackwardScanDepth is how many blocks to scan backward (CHAIN_<NAME>_BACKWARD_SCAN_DEPTH).
+	BackwardScanDepth int
+}
+
+// chainNamePattern restricts chain names to values that are safe in URL paths.
+var chainNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// FindChain returns the configuration for the named chain.
+func (c Config) FindChain(name string) (ChainConfig, bool) {
+	for _, ch := range c.Chains {
+		if ch.Name == name {
+			return ch, true
+		}
+	}
+	return ChainConfig{}, false
+}
+
+// CheckShard rejects a shard index outside 0..count-1.
+func CheckShard(index, count int) error {
+	if count < 1 || index < 0 || index >= count {
+		return fmt.Errorf("shard index %d is outside 0..%d", index, count-1)
+	}
+	return nil
+}
+
+// Default returns the built-in configuration.
+func Default() Config {
+	cfg := Config{
+		RPCURL:                  "https://ethereum-rpc.publicnode.com",
+		RPCStrategy:             "failover",
+		RPCHealthInterval:       15 * time.Second,
+		RPCMaxLag:               5,
+		RPCMaxIdleConnsPerHost:  64,
+		RPCKeepAlive:            30 * time.Second,
+		RPCDialTimeout:          10 * time.Second,
+		RPCTLSHandshakeTimeout:  10 * time.Second,
+		RPCHTTP2:                true,
+		Chain:                   "ethereum",
+		BackwardScanEnabled:     true,
+		BackwardScanDepth:       10000,
+		PollInterval:            5 * time.Second,
+		ListenAddr:              ":8080",
+		ShutdownTimeout:         30 * time.Second,
+		LeaderRetryInterval:     5 * time.Second,
+		ENSCacheTTL:             10 * time.Minute,
+		TokenMetadataTTL:        24 * time.Hour,
+		EtherscanRateLimit:      5,
+		GasCacheTTL:             10 * time.Second,
+		MaxInputBytes:           4096,
+		CompactionInterval:      10 * time.Minute,
+		GCInterval:              10 * time.Minute,
+		LabelsBuiltin:           true,
+		BlockCacheSize:          128,
+		ReorgDepth:              64,
+		ActivityFeedSize:        1000,
+		CatchUpWorkers:          8,
+		ReceiptWorkers:          4,
+		BlockRetries:            3,
+		BlockTimeout:            30 * time.Second,
+		ReceiptBatchSize:        50,
+		CatchUpThreshold:        32,
+		CatchUpQueueSize:        16,
+		ShardCount:              1,
+		NATSSubjectPrefix:       "txs",
+		MQTTTopic:               "txparser/{chain}/{address}",
+		MQTTQoS:                 1,
+		ClickHouseTable:         "transactions",
+		ClickHouseBatchSize:     1000,
+		ClickHouseFlushInterval: 5 * time.Second,
+		ChatMinValue:            "0",
+		SMTPPort:                587,
+		EmailBatchWindow:        time.Minute,
+		MetricsBackend:          "prometheus",
+		StatsDAddr:              "127.0.0.1:8125",
+		TracingSampleRatio:      1,
+		LogFormat:               "text",
+		LogLevel:                "info",
+		LogTxSample:             1,
+	}
+	cfg.Chains = []ChainConfig{withPreset(cfg.defaultChain(), true)}
+	return cfg
+}
+
+// defaultChain builds a chain from the top-level settings.
+func (c Config) defaultChain() ChainConfig {
+	return ChainConfig{
+		Name:                c.Chain,
+		RPCURL:              c.RPCURL,
+		WSURL:               c.WSURL,
+		PollInterval:        c.PollInterval,
+		BackwardScanEnabled: c.BackwardScanEnabled,
+		BackwardScanDepth:   c.BackwardScanDepth,
+	}
+}
+
+// FromEnv returns the default configuration overridden by environment
+// variables. Malformed values are ignored in favor of the defaults.
+//
+// Multiple chains are declared with CHAINS, a comma-separated list of names.
+// Each chain reads CHAIN_<NAME>_RPC_URL, CHAIN_<NAME>_WS_URL,
+// CHAIN_<NAME>_POLL_INTERVAL, CHAIN_<NAME>_BACKWARD_SCAN_ENABLED and
+// CHAIN_<NAME>_BACKWARD_SCAN_DEPTH, where <NAME> is upper-cased with dashes
+// replaced by underscores, and falls back to the top-level settings other
+// than ETHEREUM_WS_URL for anything unset. Invalid or duplicate chain names
+// are skipped.
+//
+// Chains named after a built-in network take its endpoint and a poll
+// interval matching its block time, unless their RPC URL is set through
+// CHAIN_<NAME>_RPC_URL or ETHEREUM_RPC_URL.
+func FromEnv() Config {
+	cfg := Default()
+	if v := os.Getenv("ETHEREUM_RPC_URL"); v != "" {
+		cfg.RPCURL = v
+	}
+	cfg.WSURL = os.Getenv("ETHEREUM_WS_URL")
+	if v := os.Getenv("RPC_STRATEGY"); v == "failover" || v == "round-robin" {
+		cfg.RPCStrategy = v
+	}
+	if v := os.Getenv("RPC_HEALTH_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.RPCHealthInterval = d
+		}
+	}
+	if v := os.Getenv("RPC_MAX_LAG"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.RPCMaxLag = n
+		}
+	}
+	if v := os.Getenv("RPC_MAX_IDLE_CONNS_PER_HOST"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.RPCMaxIdleConnsPerHost = n
+		}
+	}
+	if v := os.Getenv("RPC_KEEP_ALIVE"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			cfg.RPCKeepAlive = d
+		}
+	}
+	if v := os.Getenv("RPC_DIAL_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.RPCDialTimeout = d
+		}
+	}
+	if v := os.Getenv("RPC_TLS_HANDSHAKE_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.RPCTLSHandshakeTimeout = d
+		}
+	}
+	if v := os.Getenv("RPC_HTTP2"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.RPCHTTP2 = b
+		}
+	}
+	if v := os.Getenv("CHAIN"); v != "" {
+		cfg.Chain = v
+	}
+	if v := os.Getenv("BACKWARD_SCAN_ENABLED"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.BackwardScanEnabled = b
+		}
+	}
+	if v := os.Getenv("BACKWARD_SCAN_DEPTH"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.BackwardScanDepth = n
+		}
+	}
+	if v := os.Getenv("LISTEN_ADDR"); v != "" {
+		cfg.ListenAddr = v
+	}
+	cfg.AdminToken = os.Getenv("ADMIN_TOKEN")
+	cfg.APIKeys = os.Getenv("API_KEYS")
+	cfg.WebhookAllowedNetworks = os.Getenv("WEBHOOK_ALLOWED_NETWORKS")
+	cfg.ConfigFile = os.Getenv("CONFIG_FILE")
+	cfg.AuditLogFile = os.Getenv("AUDIT_LOG_FILE")
+	cfg.DumpDir = os.Getenv("DUMP_DIR")
+	if v := os.Getenv("FETCH_RECEIPTS"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.FetchReceipts = b
+		}
+	}
+	if v := os.Getenv("ENRICH_RECEIPTS"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.EnrichReceipts = b
+		}
+	}
+	if v := os.Getenv("RECEIPT_WORKERS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.ReceiptWorkers = n
+		}
+	}
+	if v := os.Getenv("RECEIPT_BATCH_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.ReceiptBatchSize = n
+		}
+	}
+	if v := os.Getenv("BLOCK_RETRIES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.BlockRetries = n
+		}
+	}
+	cfg.DeadLetterFile = os.Getenv("DEAD_LETTER_FILE")
+	cfg.OutboxFile = os.Getenv("OUTBOX_FILE")
+	if v := os.Getenv("BLOCK_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			cfg.BlockTimeout = d
+		}
+	}
+	if v := os.Getenv("MAX_TRANSACTIONS_PER_ADDRESS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.MaxTransactionsPerAddress = n
+		}
+	}
+	if v := os.Getenv("COMPACTION_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			cfg.CompactionInterval = d
+		}
+	}
+	if v := os.Getenv("UNSUBSCRIBED_RETENTION"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			cfg.UnsubscribedRetention = d
+		}
+	}
+	if v := os.Getenv("GC_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.GCInterval = d
+		}
+	}
+	if v := os.Getenv("TRACK_BALANCES"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.TrackBalances = b
+		}
+	}
+	if v := os.Getenv("DRY_RUN"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.DryRun = b
+		}
+	}
+	if v := os.Getenv("REPLAY_DIR"); v != "" {
+		cfg.ReplayDir = v
+	}
+	if v := os.Getenv("BLOCK_CACHE_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.BlockCacheSize = n
+		}
+	}
+	if v := os.Getenv("REORG_DEPTH"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.ReorgDepth = n
+		}
+	}
+	if v := os.Getenv("ACTIVITY_FEED_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.ActivityFeedSize = n
+		}
+	}
+	if v := os.Getenv("CATCHUP_WORKERS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.CatchUpWorkers = n
+		}
+	}
+	if v := os.Getenv("CATCHUP_THRESHOLD"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.CatchUpThreshold = n
+		}
+	}
+	if v := os.Getenv("CATCHUP_QUEUE_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.CatchUpQueueSize = n
+		}
+	}
+	if v := os.Getenv("SHARD_COUNT"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.ShardCount = n
+		}
+	}
+	if v := os.Getenv("SHARD_INDEX"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.ShardIndex = n
+		}
+	}
+	for _, a := range strings.Split(os.Getenv("IGNORE_ADDRESSES"), ",") {
+		if addr, err := address.Normalize(a); err == nil {
+			cfg.IgnoreAddresses = append(cfg.IgnoreAddresses, addr)
+		}
+	}
+	if v := os.Getenv("SKIP_ZERO_VALUE_CALLS"); v != "" {
+		cfg.SkipZeroValueCalls = v == "true" || v == "1"
+	}
+	if v := os.Getenv("MAX_BLOCK_LAG"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			cfg.MaxBlockLag = n
+		}
+	}
+	cfg.LagAlertURL = os.Getenv("LAG_ALERT_URL")
+	if v := os.Getenv("ENS_RESOLUTION"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.ENSResolution = b
+		}
+	}
+	if v := os.Getenv("ENS_CACHE_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.ENSCacheTTL = d
+		}
+	}
+	cfg.LabelsFile = os.Getenv("LABELS_FILE")
+	if v := os.Getenv("LABELS_BUILTIN"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.LabelsBuiltin = b
+		}
+	}
+	if v := os.Getenv("ABI_DECODING"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.ABIDecoding = b
+		}
+	}
+	for _, path := range strings.Split(os.Getenv("ABI_FILES"), ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			cfg.ABIFiles = append(cfg.ABIFiles, path)
+		}
+	}
+	if v := os.Getenv("STORE_INPUT"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.StoreInput = b
+		}
+	}
+	if v := os.Getenv("MAX_INPUT_BYTES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.MaxInputBytes = n
+		}
+	}
+	if v := os.Getenv("INDEX_TOKENS"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.IndexTokens = b
+		}
+	}
+	if v := os.Getenv("TOKEN_METADATA_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.TokenMetadataTTL = d
+		}
+	}
+	if v := os.Getenv("ETHERSCAN_URL"); v != "" {
+		cfg.EtherscanURL = v
+	}
+	if v := os.Getenv("ETHERSCAN_API_KEY"); v != "" {
+		cfg.EtherscanAPIKey = v
+	}
+	if v := os.Getenv("ETHERSCAN_RATE_LIMIT"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.EtherscanRateLimit = n
+		}
+	}
+	if v := os.Getenv("GAS_CACHE_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.GasCacheTTL = d
+		}
+	}
+	if v := os.Getenv("SHUTDOWN_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.ShutdownTimeout = d
+		}
+	}
+	cfg.LeaderLockFile = os.Getenv("LEADER_LOCK_FILE")
+	if v := os.Getenv("LEADER_RETRY_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.LeaderRetryInterval = d
+		}
+	}
+	cfg.NATSURL = os.Getenv("NATS_URL")
+	if v := os.Getenv("NATS_SUBJECT_PREFIX"); v != "" {
+		cfg.NATSSubjectPrefix = v
+	}
+	if v := os.Getenv("NATS_JETSTREAM"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.NATSJetStream = b
+		}
+	}
+	cfg.MQTTURL = os.Getenv("MQTT_URL")
+	if v := os.Getenv("MQTT_TOPIC"); v != "" {
+		cfg.MQTTTopic = v
+	}
+	if v := os.Getenv("MQTT_QOS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 && n <= 2 {
+			cfg.MQTTQoS = n
+		}
+	}
+	cfg.MQTTUsername = os.Getenv("MQTT_USERNAME")
+	cfg.MQTTPassword = os.Getenv("MQTT_PASSWORD")
+	cfg.ClickHouseURL = os.Getenv("CLICKHOUSE_URL")
+	if v := os.Getenv("CLICKHOUSE_TABLE"); v != "" {
+		cfg.ClickHouseTable = v
+	}
+	cfg.ClickHouseUsername = os.Getenv("CLICKHOUSE_USERNAME")
+	cfg.ClickHousePassword = os.Getenv("CLICKHOUSE_PASSWORD")
+	if v := os.Getenv("CLICKHOUSE_BATCH_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.ClickHouseBatchSize = n
+		}
+	}
+	if v := os.Getenv("CLICKHOUSE_FLUSH_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.ClickHouseFlushInterval = d
+		}
+	}
+	cfg.ChatWebhookURL = os.Getenv("CHAT_WEBHOOK_URL")
+	if v := os.Getenv("CHAT_MIN_VALUE"); v != "" {
+		cfg.ChatMinValue = v
+	}
+	cfg.SMTPHost = os.Getenv("SMTP_HOST")
+	if v := os.Getenv("SMTP_PORT"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n < 65536 {
+			cfg.SMTPPort = n
+		}
+	}
+	cfg.SMTPUsername = os.Getenv("SMTP_USERNAME")
+	cfg.SMTPPassword = os.Getenv("SMTP_PASSWORD")
+	cfg.EmailFrom = os.Getenv("EMAIL_FROM")
+	cfg.EmailRecipients = os.Getenv("EMAIL_RECIPIENTS")
+	if v := os.Getenv("EMAIL_BATCH_WINDOW"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.EmailBatchWindow = d
+		}
+	}
+	cfg.EmailTemplate = os.Getenv("EMAIL_TEMPLATE")
+	switch v := strings.ToLower(os.Getenv("METRICS_BACKEND")); v {
+	case "prometheus", "statsd", "none":
+		cfg.MetricsBackend = v
+	}
+	if v := os.Getenv("STATSD_ADDR"); v != "" {
+		cfg.StatsDAddr = v
+	}
+	for _, tag := range strings.Split(os.Getenv("STATSD_TAGS"), ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			cfg.StatsDTags = append(cfg.StatsDTags, tag)
+		}
+	}
+	cfg.TracingEndpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	if v := os.Getenv("TRACING_SAMPLE_RATIO"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 && f <= 1 {
+			cfg.TracingSampleRatio = f
+		}
+	}
+	switch v := strings.ToLower(os.Getenv("LOG_FORMAT")); v {
+	case "text", "json":
+		cfg.LogFormat = v
+	}
+	switch v := strings.ToLower(os.Getenv("LOG_LEVEL")); v {
+	case "debug", "info", "warn", "error":
+		cfg.LogLevel = v
+	}
+	if v := os.Getenv("LOG_TX_SAMPLE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.LogTxSample = n
+		}
+	}
+	if v := os.Getenv("LOG_TX_MATCHED_ONLY"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.LogTxMatchedOnly = b
+		}
+	}
+
+	rpcSet := os.Getenv("ETHEREUM_RPC_URL") != ""
+	cfg.Chains = []ChainConfig{withPreset(cfg.defaultChain(), rpcSet)}
+	if v := os.Getenv("CHAINS"); v != "" {
+		if chains := chainsFromEnv(v, cfg.defaultChain(), rpcSet); len(chains) > 0 {
+			cfg.Chains = chains
+			cfg.Chain = chains[0].Name
+		}
+	}
+	return cfg
+}
+
+// chainsFromEnv parses the CHAINS list, deriving per-chain settings from
+// CHAIN_<NAME>_* variables, presets and base. base's RPC URL wins over
+// presets if rpcSet.
+func chainsFromEnv(list string, base ChainConfig, rpcSet bool) []ChainConfig {
+	var chains []ChainConfig
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !chainNamePattern.MatchString(name) || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		ch := base
+		ch.Name = name
+		ch = withPreset(ch, rpcSet)
+		prefix := fmt.Sprintf("CHAIN_%s_", strings.ToUpper(strings.ReplaceAll(name, "-", "_")))
+		if v := os.Getenv(prefix + "RPC_URL"); v != "" {
+			ch.RPCURL = v
+		}
+		ch.WSURL = os.Getenv(prefix + "WS_URL")
+		if v := os.Getenv(prefix + "POLL_INTERVAL"); v != "" {
+			if d, err := time.ParseDuration(v); err == nil && d > 0 {
+				ch.PollInterval = d
+			}
+		}
+		if v := os.Getenv(prefix + "BACKWARD_SCAN_ENABLED"); v != "" {
+			if b, err := strconv.ParseBool(v); err == nil {
+				ch.BackwardScanEnabled = b
+			}
+		}
+		if v := os.Getenv(prefix + "BACKWARD_SCAN_DEPTH"); v != "" {
+			if n, err := strconv.Atoi(v); err == nil && n > 0 {
+				ch.BackwardScanDepth = n
+			}
+		}
+		chains = append(chains, ch)
+	}
+	return chains
+}
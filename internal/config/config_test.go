@@ -0,0 +1,361 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFromEnv_Defaults(t *testing.T) {
+	for _, k := range []string{"ETHEREUM_RPC_URL", "ETHEREUM_WS_URL", "RPC_STRATEGY", "RPC_HEALTH_INTERVAL", "RPC_MAX_LAG", "RPC_MAX_IDLE_CONNS_PER_HOST", "RPC_KEEP_ALIVE", "RPC_DIAL_TIMEOUT", "RPC_TLS_HANDSHAKE_TIMEOUT", "RPC_HTTP2", "CHAIN", "BACKWARD_SCAN_ENABLED", "BACKWARD_SCAN_DEPTH", "LISTEN_ADDR", "ADMIN_TOKEN", "API_KEYS", "CONFIG_FILE", "AUDIT_LOG_FILE", "DUMP_DIR", "FETCH_RECEIPTS", "ENRICH_RECEIPTS", "RECEIPT_WORKERS", "RECEIPT_BATCH_SIZE", "BLOCK_RETRIES", "BLOCK_TIMEOUT", "DEAD_LETTER_FILE", "OUTBOX_FILE", "MAX_TRANSACTIONS_PER_ADDRESS", "COMPACTION_INTERVAL", "UNSUBSCRIBED_RETENTION", "GC_INTERVAL", "TRACK_BALANCES", "DRY_RUN", "REPLAY_DIR", "BLOCK_CACHE_SIZE", "REORG_DEPTH", "ACTIVITY_FEED_SIZE", "CATCHUP_WORKERS", "CATCHUP_THRESHOLD", "CATCHUP_QUEUE_SIZE", "SHARD_COUNT", "SHARD_INDEX", "IGNORE_ADDRESSES", "SKIP_ZERO_VALUE_CALLS", "LOG_FORMAT", "LOG_LEVEL", "LOG_TX_SAMPLE", "LOG_TX_MATCHED_ONLY", "CHAINS", "SHUTDOWN_TIMEOUT", "LEADER_LOCK_FILE", "LEADER_RETRY_INTERVAL", "MAX_BLOCK_LAG", "LAG_ALERT_URL", "ENS_RESOLUTION", "ENS_CACHE_TTL", "LABELS_FILE", "LABELS_BUILTIN", "ABI_DECODING", "ABI_FILES", "STORE_INPUT", "MAX_INPUT_BYTES", "INDEX_TOKENS", "TOKEN_METADATA_TTL", "ETHERSCAN_URL", "ETHERSCAN_API_KEY", "ETHERSCAN_RATE_LIMIT", "GAS_CACHE_TTL", "NATS_URL", "NATS_SUBJECT_PREFIX", "NATS_JETSTREAM", "MQTT_URL", "MQTT_TOPIC", "MQTT_QOS", "MQTT_USERNAME", "MQTT_PASSWORD", "CLICKHOUSE_URL", "CLICKHOUSE_TABLE", "CLICKHOUSE_USERNAME", "CLICKHOUSE_PASSWORD", "CLICKHOUSE_BATCH_SIZE", "CLICKHOUSE_FLUSH_INTERVAL", "CHAT_WEBHOOK_URL", "CHAT_MIN_VALUE", "SMTP_HOST", "SMTP_PORT", "SMTP_USERNAME", "SMTP_PASSWORD", "EMAIL_FROM", "EMAIL_RECIPIENTS", "EMAIL_BATCH_WINDOW", "EMAIL_TEMPLATE", "OTEL_EXPORTER_OTLP_ENDPOINT", "TRACING_SAMPLE_RATIO", "METRICS_BACKEND", "STATSD_ADDR", "STATSD_TAGS"} {
+		t.Setenv(k, "")
+	}
+
+	cfg := FromEnv()
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Errorf("Expected defaults, got %+v", cfg)
+	}
+}
+
+func TestFromEnv_Overrides(t *testing.T) {
+	t.Setenv("ETHEREUM_RPC_URL", "http://localhost:8545")
+	t.Setenv("RPC_STRATEGY", "round-robin")
+	t.Setenv("RPC_HEALTH_INTERVAL", "1m")
+	t.Setenv("RPC_MAX_LAG", "12")
+	t.Setenv("RPC_MAX_IDLE_CONNS_PER_HOST", "128")
+	t.Setenv("RPC_KEEP_ALIVE", "0")
+	t.Setenv("RPC_DIAL_TIMEOUT", "3s")
+	t.Setenv("RPC_TLS_HANDSHAKE_TIMEOUT", "4s")
+	t.Setenv("RPC_HTTP2", "false")
+	t.Setenv("CHAIN", "sepolia")
+	t.Setenv("BACKWARD_SCAN_ENABLED", "false")
+	t.Setenv("BACKWARD_SCAN_DEPTH", "500")
+	t.Setenv("LISTEN_ADDR", ":9090")
+	t.Setenv("ADMIN_TOKEN", "secret")
+	t.Setenv("LOG_FORMAT", "JSON")
+	t.Setenv("SHUTDOWN_TIMEOUT", "5s")
+	t.Setenv("NATS_URL", "nats://localhost:4222")
+	t.Setenv("NATS_SUBJECT_PREFIX", "chain")
+	t.Setenv("NATS_JETSTREAM", "true")
+	t.Setenv("MQTT_URL", "tcp://localhost:1883")
+	t.Setenv("MQTT_TOPIC", "home/{address}")
+	t.Setenv("MQTT_QOS", "2")
+	t.Setenv("CLICKHOUSE_URL", "http://localhost:8123")
+	t.Setenv("CLICKHOUSE_TABLE", "analytics.txs")
+	t.Setenv("CLICKHOUSE_BATCH_SIZE", "5000")
+	t.Setenv("CLICKHOUSE_FLUSH_INTERVAL", "30s")
+	t.Setenv("CHAT_WEBHOOK_URL", "https://hooks.slack.com/services/T/B/X")
+	t.Setenv("CHAT_MIN_VALUE", "2.5")
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("SMTP_PORT", "465")
+	t.Setenv("EMAIL_BATCH_WINDOW", "5m")
+	t.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("LOG_TX_SAMPLE", "100")
+	t.Setenv("LOG_TX_MATCHED_ONLY", "true")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318")
+	t.Setenv("TRACING_SAMPLE_RATIO", "0.25")
+	t.Setenv("METRICS_BACKEND", "StatsD")
+	t.Setenv("STATSD_ADDR", "dd-agent:8125")
+	t.Setenv("STATSD_TAGS", "env:prod, team:payments,")
+	t.Setenv("AUDIT_LOG_FILE", "/var/lib/txparser/audit.log")
+	t.Setenv("DUMP_DIR", "/var/lib/txparser/dumps")
+	t.Setenv("MAX_BLOCK_LAG", "20")
+	t.Setenv("FETCH_RECEIPTS", "true")
+	t.Setenv("ENRICH_RECEIPTS", "true")
+	t.Setenv("BLOCK_RETRIES", "0")
+	t.Setenv("BLOCK_TIMEOUT", "0")
+	t.Setenv("DEAD_LETTER_FILE", "/var/lib/txparser/deadletters.json")
+	t.Setenv("OUTBOX_FILE", "/var/lib/txparser/outbox.jsonl")
+	t.Setenv("MAX_TRANSACTIONS_PER_ADDRESS", "5000")
+	t.Setenv("COMPACTION_INTERVAL", "0")
+	t.Setenv("UNSUBSCRIBED_RETENTION", "24h")
+	t.Setenv("GC_INTERVAL", "1h")
+	t.Setenv("LEADER_LOCK_FILE", "/shared/txparser.lock")
+	t.Setenv("LEADER_RETRY_INTERVAL", "2s")
+	t.Setenv("RECEIPT_WORKERS", "8")
+	t.Setenv("RECEIPT_BATCH_SIZE", "100")
+	t.Setenv("TRACK_BALANCES", "true")
+	t.Setenv("DRY_RUN", "true")
+	t.Setenv("REPLAY_DIR", "testdata/blocks")
+	t.Setenv("API_KEYS", "payments:k1,risk:k2")
+	t.Setenv("BLOCK_CACHE_SIZE", "0")
+	t.Setenv("REORG_DEPTH", "0")
+	t.Setenv("ACTIVITY_FEED_SIZE", "0")
+	t.Setenv("CATCHUP_WORKERS", "16")
+	t.Setenv("CATCHUP_THRESHOLD", "100")
+	t.Setenv("CATCHUP_QUEUE_SIZE", "64")
+	t.Setenv("SHARD_COUNT", "4")
+	t.Setenv("SHARD_INDEX", "3")
+	t.Setenv("IGNORE_ADDRESSES", "0x0000000000000000000000000000000000000000, 0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed,nope")
+	t.Setenv("SKIP_ZERO_VALUE_CALLS", "true")
+	t.Setenv("LAG_ALERT_URL", "https://alerts.example.com/lag")
+	t.Setenv("ENS_RESOLUTION", "true")
+	t.Setenv("ENS_CACHE_TTL", "1h")
+	t.Setenv("LABELS_FILE", "/etc/txparser/labels.csv")
+	t.Setenv("LABELS_BUILTIN", "false")
+	t.Setenv("ABI_DECODING", "true")
+	t.Setenv("ABI_FILES", "router.json, ,vault.json")
+	t.Setenv("STORE_INPUT", "true")
+	t.Setenv("MAX_INPUT_BYTES", "1024")
+	t.Setenv("INDEX_TOKENS", "true")
+	t.Setenv("TOKEN_METADATA_TTL", "168h")
+	t.Setenv("ETHERSCAN_URL", "https://api.etherscan.io/v2/api")
+	t.Setenv("ETHERSCAN_API_KEY", "key")
+	t.Setenv("ETHERSCAN_RATE_LIMIT", "2")
+	t.Setenv("GAS_CACHE_TTL", "30s")
+
+	cfg := FromEnv()
+	if cfg.RPCURL != "http://localhost:8545" {
+		t.Errorf("Unexpected RPC URL: %s", cfg.RPCURL)
+	}
+	if cfg.RPCStrategy != "round-robin" || cfg.RPCHealthInterval != time.Minute || cfg.RPCMaxLag != 12 {
+		t.Errorf("Unexpected RPC settings: %s %v %d", cfg.RPCStrategy, cfg.RPCHealthInterval, cfg.RPCMaxLag)
+	}
+	if cfg.RPCMaxIdleConnsPerHost != 128 || cfg.RPCKeepAlive != 0 || cfg.RPCDialTimeout != 3*time.Second || cfg.RPCTLSHandshakeTimeout != 4*time.Second || cfg.RPCHTTP2 {
+		t.Errorf("Unexpected RPC transport settings: %d %v %v %v %t", cfg.RPCMaxIdleConnsPerHost, cfg.RPCKeepAlive, cfg.RPCDialTimeout, cfg.RPCTLSHandshakeTimeout, cfg.RPCHTTP2)
+	}
+	if cfg.Chain != "sepolia" {
+		t.Errorf("Unexpected chain: %s", cfg.Chain)
+	}
+	if cfg.BackwardScanEnabled {
+		t.Error("Expected backward scan to be disabled")
+	}
+	if cfg.BackwardScanDepth != 500 {
+		t.Errorf("Unexpected depth: %d", cfg.BackwardScanDepth)
+	}
+	if cfg.ListenAddr != ":9090" {
+		t.Errorf("Unexpected listen address: %s", cfg.ListenAddr)
+	}
+	if cfg.AdminToken != "secret" {
+		t.Errorf("Unexpected admin token: %s", cfg.AdminToken)
+	}
+	if cfg.NATSURL != "nats://localhost:4222" || cfg.NATSSubjectPrefix != "chain" || !cfg.NATSJetStream {
+		t.Errorf("Unexpected NATS settings: %s %s %v", cfg.NATSURL, cfg.NATSSubjectPrefix, cfg.NATSJetStream)
+	}
+	if cfg.MQTTURL != "tcp://localhost:1883" || cfg.MQTTTopic != "home/{address}" || cfg.MQTTQoS != 2 {
+		t.Errorf("Unexpected MQTT settings: %s %s %d", cfg.MQTTURL, cfg.MQTTTopic, cfg.MQTTQoS)
+	}
+	if cfg.ClickHouseURL != "http://localhost:8123" || cfg.ClickHouseTable != "analytics.txs" || cfg.ClickHouseBatchSize != 5000 || cfg.ClickHouseFlushInterval != 30*time.Second {
+		t.Errorf("Unexpected ClickHouse settings: %s %s %d %s", cfg.ClickHouseURL, cfg.ClickHouseTable, cfg.ClickHouseBatchSize, cfg.ClickHouseFlushInterval)
+	}
+	if cfg.ChatWebhookURL != "https://hooks.slack.com/services/T/B/X" || cfg.ChatMinValue != "2.5" {
+		t.Errorf("Unexpected chat settings: %s %s", cfg.ChatWebhookURL, cfg.ChatMinValue)
+	}
+	if cfg.SMTPHost != "smtp.example.com" || cfg.SMTPPort != 465 || cfg.EmailBatchWindow != 5*time.Minute {
+		t.Errorf("Unexpected email settings: %s %d %v", cfg.SMTPHost, cfg.SMTPPort, cfg.EmailBatchWindow)
+	}
+	if cfg.ShutdownTimeout != 5*time.Second {
+		t.Errorf("Unexpected shutdown timeout: %v", cfg.ShutdownTimeout)
+	}
+	if cfg.LogFormat != "json" {
+		t.Errorf("Unexpected log format: %s", cfg.LogFormat)
+	}
+	if cfg.LogLevel != "debug" {
+		t.Errorf("Unexpected log level: %s", cfg.LogLevel)
+	}
+	if cfg.LogTxSample != 100 || !cfg.LogTxMatchedOnly {
+		t.Errorf("Unexpected transaction log sampling: %d/%v", cfg.LogTxSample, cfg.LogTxMatchedOnly)
+	}
+	if cfg.TracingEndpoint != "http://localhost:4318" || cfg.TracingSampleRatio != 0.25 {
+		t.Errorf("Unexpected tracing settings: %s %v", cfg.TracingEndpoint, cfg.TracingSampleRatio)
+	}
+	if cfg.MetricsBackend != "statsd" || cfg.StatsDAddr != "dd-agent:8125" ||
+		!reflect.DeepEqual(cfg.StatsDTags, []string{"env:prod", "team:payments"}) {
+		t.Errorf("Unexpected metrics settings: %s %s %v", cfg.MetricsBackend, cfg.StatsDAddr, cfg.StatsDTags)
+	}
+	if cfg.MaxBlockLag != 20 || cfg.LagAlertURL != "https://alerts.example.com/lag" {
+		t.Errorf("Unexpected lag alert settings: %d %s", cfg.MaxBlockLag, cfg.LagAlertURL)
+	}
+	if !cfg.ENSResolution || cfg.ENSCacheTTL != time.Hour {
+		t.Errorf("Unexpected ENS settings: %v %v", cfg.ENSResolution, cfg.ENSCacheTTL)
+	}
+	if cfg.LabelsFile != "/etc/txparser/labels.csv" || cfg.LabelsBuiltin {
+		t.Errorf("Unexpected label settings: %s %v", cfg.LabelsFile, cfg.LabelsBuiltin)
+	}
+	if !cfg.ABIDecoding || !reflect.DeepEqual(cfg.ABIFiles, []string{"router.json", "vault.json"}) {
+		t.Errorf("Unexpected ABI settings: %v %v", cfg.ABIDecoding, cfg.ABIFiles)
+	}
+	if !cfg.StoreInput || cfg.MaxInputBytes != 1024 {
+		t.Errorf("Unexpected input settings: %v %d", cfg.StoreInput, cfg.MaxInputBytes)
+	}
+	if !cfg.IndexTokens || cfg.TokenMetadataTTL != 168*time.Hour {
+		t.Errorf("Unexpected token settings: %v %v", cfg.IndexTokens, cfg.TokenMetadataTTL)
+	}
+	if cfg.EtherscanURL != "https://api.etherscan.io/v2/api" || cfg.EtherscanAPIKey != "key" || cfg.EtherscanRateLimit != 2 {
+		t.Errorf("Unexpected Etherscan settings: %q %q %d", cfg.EtherscanURL, cfg.EtherscanAPIKey, cfg.EtherscanRateLimit)
+	}
+	if cfg.GasCacheTTL != 30*time.Second {
+		t.Errorf("Expected GasCacheTTL 30s, got %v", cfg.GasCacheTTL)
+	}
+	if !cfg.FetchReceipts {
+		t.Error("Expected receipt fetching to be enabled")
+	}
+	if cfg.BlockRetries != 0 || cfg.DeadLetterFile != "/var/lib/txparser/deadletters.json" {
+		t.Errorf("Unexpected dead-letter settings: %d %q", cfg.BlockRetries, cfg.DeadLetterFile)
+	}
+	if cfg.OutboxFile != "/var/lib/txparser/outbox.jsonl" {
+		t.Errorf("Unexpected outbox file: %q", cfg.OutboxFile)
+	}
+	if cfg.BlockTimeout != 0 {
+		t.Errorf("Expected BLOCK_TIMEOUT=0 to disable the block timeout, got %v", cfg.BlockTimeout)
+	}
+	if cfg.MaxTransactionsPerAddress != 5000 {
+		t.Errorf("Expected MaxTransactionsPerAddress 5000, got %d", cfg.MaxTransactionsPerAddress)
+	}
+	if cfg.CompactionInterval != 0 {
+		t.Errorf("Expected compaction to be disabled, got %s", cfg.CompactionInterval)
+	}
+	if cfg.UnsubscribedRetention != 24*time.Hour || cfg.GCInterval != time.Hour {
+		t.Errorf("Unexpected garbage collection settings: %s every %s", cfg.UnsubscribedRetention, cfg.GCInterval)
+	}
+	if cfg.LeaderLockFile != "/shared/txparser.lock" || cfg.LeaderRetryInterval != 2*time.Second {
+		t.Errorf("Unexpected leader election settings: %q every %s", cfg.LeaderLockFile, cfg.LeaderRetryInterval)
+	}
+	if !cfg.EnrichReceipts || cfg.ReceiptWorkers != 8 || cfg.ReceiptBatchSize != 100 {
+		t.Errorf("Unexpected receipt enrichment settings: %v %d %d", cfg.EnrichReceipts, cfg.ReceiptWorkers, cfg.ReceiptBatchSize)
+	}
+	if !cfg.TrackBalances {
+		t.Error("Expected balance tracking to be enabled")
+	}
+	if !cfg.DryRun {
+		t.Error("Expected dry-run mode to be enabled")
+	}
+	if cfg.ReplayDir != "testdata/blocks" {
+		t.Errorf("Expected replay dir testdata/blocks, got %q", cfg.ReplayDir)
+	}
+	if cfg.BlockCacheSize != 0 {
+		t.Errorf("Expected the block cache to be disabled, got size %d", cfg.BlockCacheSize)
+	}
+	if cfg.ReorgDepth != 0 {
+		t.Errorf("Expected reorg detection to be disabled, got depth %d", cfg.ReorgDepth)
+	}
+	if cfg.ActivityFeedSize != 0 {
+		t.Errorf("Expected the activity feed to be disabled, got size %d", cfg.ActivityFeedSize)
+	}
+	if cfg.APIKeys != "payments:k1,risk:k2" {
+		t.Errorf("Unexpected API keys: %s", cfg.APIKeys)
+	}
+	if cfg.CatchUpWorkers != 16 || cfg.CatchUpThreshold != 100 || cfg.CatchUpQueueSize != 64 {
+		t.Errorf("Unexpected catch-up settings: %d workers above %d blocks, queue %d", cfg.CatchUpWorkers, cfg.CatchUpThreshold, cfg.CatchUpQueueSize)
+	}
+	if cfg.ShardCount != 4 || cfg.ShardIndex != 3 {
+		t.Errorf("Unexpected shard %d of %d", cfg.ShardIndex, cfg.ShardCount)
+	}
+	wantIgnored := []string{"0x0000000000000000000000000000000000000000", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"}
+	if !reflect.DeepEqual(cfg.IgnoreAddresses, wantIgnored) {
+		t.Errorf("Unexpected ignored addresses: %v", cfg.IgnoreAddresses)
+	}
+	if !cfg.SkipZeroValueCalls {
+		t.Error("Expected zero-value calls to be skipped")
+	}
+	if cfg.AuditLogFile != "/var/lib/txparser/audit.log" {
+		t.Errorf("Unexpected audit log file: %s", cfg.AuditLogFile)
+	}
+	if cfg.DumpDir != "/var/lib/txparser/dumps" {
+		t.Errorf("Unexpected dump dir: %s", cfg.DumpDir)
+	}
+}
+
+func TestFromEnv_InvalidValuesIgnored(t *testing.T) {
+	t.Setenv("BACKWARD_SCAN_ENABLED", "maybe")
+	t.Setenv("BACKWARD_SCAN_DEPTH", "-5")
+	t.Setenv("LOG_FORMAT", "xml")
+	t.Setenv("SHUTDOWN_TIMEOUT", "soon")
+	t.Setenv("LOG_LEVEL", "verbose")
+	t.Setenv("MQTT_QOS", "3")
+	t.Setenv("TRACING_SAMPLE_RATIO", "2")
+
+	cfg := FromEnv()
+	if !cfg.BackwardScanEnabled {
+		t.Error("Expected invalid bool to fall back to default")
+	}
+	if cfg.BackwardScanDepth != 10000 {
+		t.Errorf("Expected invalid depth to fall back to default, got %d", cfg.BackwardScanDepth)
+	}
+	if cfg.ShutdownTimeout != 30*time.Second {
+		t.Errorf("Expected invalid shutdown timeout to fall back to default, got %v", cfg.ShutdownTimeout)
+	}
+	if cfg.LogFormat != "text" || cfg.LogLevel != "info" {
+		t.Errorf("Expected invalid log settings to fall back to defaults, got %s/%s", cfg.LogFormat, cfg.LogLevel)
+	}
+	if cfg.MQTTQoS != 1 {
+		t.Errorf("Expected invalid QoS to fall back to default, got %d", cfg.MQTTQoS)
+	}
+	if cfg.TracingSampleRatio != 1 {
+		t.Errorf("Expected invalid sample ratio to fall back to default, got %v", cfg.TracingSampleRatio)
+	}
+}
+
+func TestFromEnv_SingleChainFromTopLevel(t *testing.T) {
+	t.Setenv("CHAINS", "")
+	t.Setenv("CHAIN", "sepolia")
+	t.Setenv("ETHEREUM_RPC_URL", "http://localhost:8545")
+	t.Setenv("ETHEREUM_WS_URL", "ws://localhost:8546")
+
+	cfg := FromEnv()
+	if len(cfg.Chains) != 1 {
+		t.Fatalf("Expected 1 chain, got %d", len(cfg.Chains))
+	}
+	if ch := cfg.Chains[0]; ch.Name != "sepolia" || ch.RPCURL != "http://localhost:8545" || ch.WSURL != "ws://localhost:8546" {
+		t.Errorf("Unexpected chain: %+v", ch)
+	}
+}
+
+func TestFromEnv_MultipleChains(t *testing.T) {
+	t.Setenv("ETHEREUM_RPC_URL", "http://mainnet:8545")
+	t.Setenv("BACKWARD_SCAN_DEPTH", "500")
+	t.Setenv("CHAINS", "Ethereum, base-sepolia, bad_name, ethereum")
+	t.Setenv("CHAIN_BASE_SEPOLIA_RPC_URL", "http://base:8545")
+	t.Setenv("CHAIN_BASE_SEPOLIA_WS_URL", "ws://base:8546")
+	t.Setenv("ETHEREUM_WS_URL", "ws://mainnet:8546")
+	t.Setenv("CHAIN_BASE_SEPOLIA_POLL_INTERVAL", "2s")
+	t.Setenv("CHAIN_BASE_SEPOLIA_BACKWARD_SCAN_ENABLED", "false")
+	t.Setenv("CHAIN_BASE_SEPOLIA_BACKWARD_SCAN_DEPTH", "nope")
+
+	cfg := FromEnv()
+	want := []ChainConfig{
+		{Name: "ethereum", ChainID: 1, RPCURL: "http://mainnet:8545", PollInterval: 5 * time.Second, BackwardScanEnabled: true, BackwardScanDepth: 500},
+		{Name: "base-sepolia", RPCURL: "http://base:8545", WSURL: "ws://base:8546", PollInterval: 2 * time.Second, BackwardScanEnabled: false, BackwardScanDepth: 500},
+	}
+	if !reflect.DeepEqual(cfg.Chains, want) {
+		t.Errorf("Unexpected chains:\n got %+v\nwant %+v", cfg.Chains, want)
+	}
+	if cfg.Chain != "ethereum" {
+		t.Errorf("Expected default chain ethereum, got %s", cfg.Chain)
+	}
+	if _, ok := cfg.FindChain("base-sepolia"); !ok {
+		t.Error("Expected base-sepolia to be found")
+	}
+}
+
+func TestFromEnv_ChainPresets(t *testing.T) {
+	t.Setenv("ETHEREUM_RPC_URL", "")
+	t.Setenv("CHAINS", "")
+	t.Setenv("CHAIN", "polygon")
+
+	cfg := FromEnv()
+	want := ChainConfig{Name: "polygon", ChainID: 137, RPCURL: "https://polygon-bor-rpc.publicnode.com", PollInterval: 2 * time.Second, BackwardScanEnabled: true, BackwardScanDepth: 10000}
+	if !reflect.DeepEqual(cfg.Chains, []ChainConfig{want}) {
+		t.Errorf("Unexpected chains:\n got %+v\nwant %+v", cfg.Chains, want)
+	}
+
+	t.Setenv("CHAINS", "mainnet,arbitrum,base,devnet")
+	t.Setenv("CHAIN_BASE_RPC_URL", "http://base:8545")
+	cfg = FromEnv()
+	if len(cfg.Chains) != 4 {
+		t.Fatalf("Expected 4 chains, got %d", len(cfg.Chains))
+	}
+	if ch := cfg.Chains[0]; ch.Name != "mainnet" || ch.ChainID != 1 || ch.RPCURL != "https://ethereum-rpc.publicnode.com" || ch.PollInterval != 5*time.Second {
+		t.Errorf("Unexpected mainnet chain: %+v", ch)
+	}
+	// Sub-second block times are polled at most once a second.
+	if ch := cfg.Chains[1]; ch.ChainID != 42161 || ch.PollInterval != time.Second {
+		t.Errorf("Unexpected arbitrum chain: %+v", ch)
+	}
+	if ch := cfg.Chains[2]; ch.ChainID != 8453 || ch.RPCURL != "http://base:8545" {
+		t.Errorf("Expected the explicit RPC URL to win over the preset, got %+v", ch)
+	}
+	if ch := cfg.Chains[3]; ch.ChainID != 0 || ch.RPCURL != Default().RPCURL {
+		t.Errorf("Expected an unknown chain to use the top-level settings, got %+v", ch)
+	}
+}
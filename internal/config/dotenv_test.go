@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadDotEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := `# local settings
+ETHEREUM_RPC_URL=http://localhost:8545
+export CHAIN="sepolia"
+ADMIN_TOKEN='s3cr=t'
+
+LISTEN_ADDR=:9999
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write .env: %v", err)
+	}
+
+	t.Setenv("ETHEREUM_RPC_URL", "")
+	t.Setenv("CHAIN", "")
+	t.Setenv("ADMIN_TOKEN", "")
+	os.Unsetenv("ETHEREUM_RPC_URL")
+	os.Unsetenv("CHAIN")
+	os.Unsetenv("ADMIN_TOKEN")
+	t.Setenv("LISTEN_ADDR", ":8081") // already set, must win over the file
+
+	if err := LoadDotEnv(path); err != nil {
+		t.Fatalf("LoadDotEnv failed: %v", err)
+	}
+
+	tests := map[string]string{
+		"ETHEREUM_RPC_URL": "http://localhost:8545",
+		"CHAIN":            "sepolia",
+		"ADMIN_TOKEN":      "s3cr=t",
+		"LISTEN_ADDR":      ":8081",
+	}
+	for key, want := range tests {
+		if got := os.Getenv(key); got != want {
+			t.Errorf("%s = %q, expected %q", key, got, want)
+		}
+	}
+}
+
+func TestLoadDotEnv_Missing(t *testing.T) {
+	if err := LoadDotEnv(filepath.Join(t.TempDir(), "nope.env")); err != nil {
+		t.Errorf("Expected missing file to be ignored, got %v", err)
+	}
+}
+
+func TestLoadDotEnv_Malformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("NOT_A_PAIR\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write .env: %v", err)
+	}
+	if err := LoadDotEnv(path); err == nil {
+		t.Error("Expected error for malformed line")
+	}
+}
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/address"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// Sink types accepted in the config file.
+const (
+	SinkWebhook    = "webhook"
+	SinkNATS       = "nats"
+	SinkMQTT       = "mqtt"
+	SinkChat       = "chat"
+	SinkEmail      = "email"
+	SinkClickHouse = "clickhouse"
+)
+
+// File is the JSON config file named by CONFIG_FILE. It declares settings
+// that don't fit in environment variables, such as lists of sinks.
+type File struct {
+	Sinks []SinkConfig `json:"sinks"`
+	// Ignore lists addresses whose transactions are never stored or
+	// delivered, in addition to IGNORE_ADDRESSES.
+	Ignore []string `json:"ignore,omitempty"`
+}
+
+// SinkConfig declares one notification sink. Which fields apply depends on
+// Type; unrelated fields are ignored.
+type SinkConfig struct {
+	// Type is one of webhook, nats, mqtt, chat, email or clickhouse.
+	Type string `json:"type"`
+	// Name identifies the sink in logs and stats. Defaults to "<type>-<n>",
+	// where n is the sink's position in the file.
+	Name string `json:"name,omitempty"`
+	// URL is the webhook, NATS server, MQTT broker, chat webhook or
+	// ClickHouse HTTP interface URL.
+	URL string `json:"url,omitempty"`
+
+	// Secret signs webhook deliveries; required for webhook sinks.
+	Secret string `json:"secret,omitempty"`
+
+	// SubjectPrefix and JetStream configure NATS publishing.
+	SubjectPrefix string `json:"subject_prefix,omitempty"`
+	JetStream     bool   `json:"jetstream,omitempty"`
+
+	// Topic and QoS configure MQTT publishing. QoS defaults to 1.
+	Topic string `json:"topic,omitempty"`
+	QoS   *int   `json:"qos,omitempty"`
+
+	// Username and Password authenticate with the MQTT broker, SMTP server
+	// or ClickHouse.
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+
+	// Host, Port and From configure the SMTP server for email sinks.
+	Host string `json:"host,omitempty"`
+	Port int    `json:"port,omitempty"`
+	From string `json:"from,omitempty"`
+	// Recipients maps addresses, or "*" for every address, to email recipients.
+	Recipients map[string][]string `json:"recipients,omitempty"`
+	// BatchWindow is a duration such as "5m" grouping events into one email.
+	BatchWindow string `json:"batch_window,omitempty"`
+	// Template is an optional path to a text/template email body.
+	Template string `json:"template,omitempty"`
+
+	// Table, BatchSize and FlushInterval configure ClickHouse inserts;
+	// FlushInterval is a duration such as "10s".
+	Table         string `json:"table,omitempty"`
+	BatchSize     int    `json:"batch_size,omitempty"`
+	FlushInterval string `json:"flush_interval,omitempty"`
+
+	// Filter restricts which events the sink receives.
+	Filter SinkFilter `json:"filter"`
+}
+
+// SinkFilter selects events for a sink. Empty fields match everything.
+type SinkFilter struct {
+	// Chains limits the sink to the named chains.
+	Chains []string `json:"chains,omitempty"`
+	// Addresses limits the sink to these addresses, which are subscribed at startup.
+	Addresses []string `json:"addresses,omitempty"`
+	// Direction is "in" or "out"; self-transfers match both. "self" matches
+	// only self-transfers.
+	Direction string `json:"direction,omitempty"`
+	// MinValue is the smallest transaction value, in ether.
+	MinValue string `json:"min_value,omitempty"`
+}
+
+// AppliesTo reports whether the sink is enabled for the named chain.
+func (s SinkConfig) AppliesTo(chain string) bool {
+	if len(s.Filter.Chains) == 0 {
+		return true
+	}
+	for _, c := range s.Filter.Chains {
+		if c == chain {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadFile reads and validates the config file at path. Addresses are
+// normalized and default sink names are filled in.
+func LoadFile(path string) (File, error) {
+	r, err := os.Open(path)
+	if err != nil {
+		return File{}, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer r.Close()
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var f File
+	if err := dec.Decode(&f); err != nil {
+		return File{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	names := make(map[string]bool, len(f.Sinks))
+	for i := range f.Sinks {
+		s := &f.Sinks[i]
+		if s.Name == "" {
+			s.Name = fmt.Sprintf("%s-%d", s.Type, i+1)
+		}
+		if names[s.Name] {
+			return File{}, fmt.Errorf("%s: duplicate sink name %q", path, s.Name)
+		}
+		names[s.Name] = true
+		if err := s.validate(); err != nil {
+			return File{}, fmt.Errorf("%s: sink %q: %w", path, s.Name, err)
+		}
+	}
+	for i, a := range f.Ignore {
+		addr, err := address.Normalize(a)
+		if err != nil {
+			return File{}, fmt.Errorf("%s: invalid ignored address %q: %w", path, a, err)
+		}
+		f.Ignore[i] = addr
+	}
+	return f, nil
+}
+
+// validate checks the fields required by the sink type and normalizes addresses.
+func (s *SinkConfig) validate() error {
+	switch s.Type {
+	case SinkWebhook, SinkNATS, SinkMQTT, SinkChat, SinkClickHouse:
+		if s.URL == "" {
+			return errors.New("missing url")
+		}
+		if s.Type == SinkWebhook && s.Secret == "" {
+			return errors.New("webhook sinks need a secret to sign deliveries")
+		}
+	case SinkEmail:
+		if s.Host == "" || s.From == "" || len(s.Recipients) == 0 {
+			return errors.New("email sinks need host, from and recipients")
+		}
+		recipients := make(map[string][]string, len(s.Recipients))
+		for a, to := range s.Recipients {
+			if a != "*" {
+				addr, err := address.Normalize(a)
+				if err != nil {
+					return fmt.Errorf("invalid recipient address: %w", err)
+				}
+				a = addr
+			}
+			recipients[a] = append(recipients[a], to...)
+		}
+		s.Recipients = recipients
+		if s.BatchWindow != "" {
+			if d, err := time.ParseDuration(s.BatchWindow); err != nil || d <= 0 {
+				return fmt.Errorf("invalid batch_window %q", s.BatchWindow)
+			}
+		}
+	case "kafka":
+		return errors.New("kafka sinks are not supported, use a nats sink with jetstream instead")
+	default:
+		return fmt.Errorf("unknown sink type %q", s.Type)
+	}
+	if s.FlushInterval != "" {
+		if d, err := time.ParseDuration(s.FlushInterval); err != nil || d <= 0 {
+			return fmt.Errorf("invalid flush_interval %q", s.FlushInterval)
+		}
+	}
+	if s.QoS != nil && (*s.QoS < 0 || *s.QoS > 2) {
+		return fmt.Errorf("invalid qos %d", *s.QoS)
+	}
+
+	for i, a := range s.Filter.Addresses {
+		addr, err := address.Normalize(a)
+		if err != nil {
+			return fmt.Errorf("invalid filter address: %w", err)
+		}
+		s.Filter.Addresses[i] = addr
+	}
+	if s.Filter.Direction != "" {
+		if _, err := transaction.ParseDirection(s.Filter.Direction); err != nil {
+			return fmt.Errorf("invalid filter direction %q", s.Filter.Direction)
+		}
+	}
+	if s.Filter.MinValue != "" {
+		if _, err := transaction.ParseEther(s.Filter.MinValue); err != nil {
+			return fmt.Errorf("invalid filter min_value: %w", err)
+		}
+	}
+	// Webhooks keep their own address matching and delivery queue.
+	if s.Type == SinkWebhook && (s.Filter.Direction != "" || s.Filter.MinValue != "") {
+		return errors.New("webhook sinks only support address and chain filters")
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfigFile writes content to a temporary config file.
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "txparser.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	path := writeConfigFile(t, `{
+  "sinks": [
+    {"type": "nats", "url": "nats://localhost:4222", "filter": {"direction": "in", "min_value": "1.5"}},
+    {"type": "webhook", "name": "treasury", "url": "https://example.com/hook", "secret": "0123456789abcdef",
+     "filter": {"chains": ["ethereum"], "addresses": ["0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"]}},
+    {"type": "email", "host": "smtp.example.com", "from": "txparser@example.com",
+     "recipients": {"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed": ["ops@example.com"], "*": ["alerts@example.com"]}}
+  ],
+  "ignore": ["0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"]
+}`)
+
+	f, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if len(f.Sinks) != 3 {
+		t.Fatalf("Expected 3 sinks, got %d", len(f.Sinks))
+	}
+	if f.Sinks[0].Name != "nats-1" || f.Sinks[1].Name != "treasury" || f.Sinks[2].Name != "email-3" {
+		t.Errorf("Unexpected sink names: %s %s %s", f.Sinks[0].Name, f.Sinks[1].Name, f.Sinks[2].Name)
+	}
+	const addr = "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"
+	if f.Sinks[1].Filter.Addresses[0] != addr {
+		t.Errorf("Expected normalized filter address, got %s", f.Sinks[1].Filter.Addresses[0])
+	}
+	if len(f.Sinks[2].Recipients[addr]) != 1 || len(f.Sinks[2].Recipients["*"]) != 1 {
+		t.Errorf("Expected normalized recipients, got %v", f.Sinks[2].Recipients)
+	}
+	if len(f.Ignore) != 1 || f.Ignore[0] != addr {
+		t.Errorf("Expected normalized ignored addresses, got %v", f.Ignore)
+	}
+	if !f.Sinks[1].AppliesTo("ethereum") || f.Sinks[1].AppliesTo("sepolia") || !f.Sinks[0].AppliesTo("sepolia") {
+		t.Error("Unexpected chain filtering")
+	}
+}
+
+func TestLoadFile_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "unknown type", content: `{"sinks":[{"type":"amqp","url":"amqp://x"}]}`, wantErr: "unknown sink type"},
+		{name: "kafka", content: `{"sinks":[{"type":"kafka","url":"kafka:9092"}]}`, wantErr: "kafka sinks are not supported"},
+		{name: "unknown field", content: `{"sinks":[{"type":"nats","url":"nats://x","subject":"txs"}]}`, wantErr: "unknown field"},
+		{name: "missing url", content: `{"sinks":[{"type":"mqtt"}]}`, wantErr: "missing url"},
+		{name: "webhook without secret", content: `{"sinks":[{"type":"webhook","url":"https://example.com"}]}`, wantErr: "secret"},
+		{name: "duplicate name", content: `{"sinks":[{"type":"chat","name":"a","url":"https://x"},{"type":"chat","name":"a","url":"https://y"}]}`, wantErr: "duplicate sink name"},
+		{name: "bad address", content: `{"sinks":[{"type":"nats","url":"nats://x","filter":{"addresses":["0x123"]}}]}`, wantErr: "invalid filter address"},
+		{name: "bad min value", content: `{"sinks":[{"type":"chat","url":"https://x","filter":{"min_value":"lots"}}]}`, wantErr: "invalid filter min_value"},
+		{name: "negative min value", content: `{"sinks":[{"type":"nats","url":"nats://x","filter":{"min_value":"-1"}}]}`, wantErr: "invalid filter min_value"},
+		{name: "bad direction", content: `{"sinks":[{"type":"nats","url":"nats://x","filter":{"direction":"both"}}]}`, wantErr: "invalid filter direction"},
+		{name: "bad flush interval", content: `{"sinks":[{"type":"clickhouse","url":"http://x:8123","flush_interval":"soon"}]}`, wantErr: "invalid flush_interval"},
+		{name: "bad qos", content: `{"sinks":[{"type":"mqtt","url":"tcp://x:1883","qos":3}]}`, wantErr: "invalid qos"},
+		{name: "bad ignored address", content: `{"sinks":[],"ignore":["0x0"]}`, wantErr: "invalid ignored address"},
+		{name: "bad batch window", content: `{"sinks":[{"type":"email","host":"smtp","from":"a@b.c","recipients":{"*":["x@y.z"]},"batch_window":"soon"}]}`, wantErr: "invalid batch_window"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFile(writeConfigFile(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package config
+
+import "time"
+
+// Preset describes a well-known EVM network, so that naming it in CHAIN or
+// CHAINS is enough to index it.
+type Preset struct {
+	// Name is the chain name selecting the preset; Aliases select it too.
+	Name    string
+	Aliases []string
+	// ChainID is the EIP-155 chain ID, as returned by eth_chainId.
+	ChainID int64
+	// RPCURL is a free public endpoint. Public endpoints are rate limited,
+	// so production deployments should configure their own.
+	RPCURL string
+	// BlockTime is the expected time between blocks.
+	BlockTime time.Duration
+}
+
+// minPresetPollInterval keeps chains with sub-second blocks from hammering
+// public endpoints.
+const minPresetPollInterval = time.Second
+
+var presets = []Preset{
+	{Name: "ethereum", Aliases: []string{"mainnet"}, ChainID: 1, RPCURL: "https://ethereum-rpc.publicnode.com", BlockTime: 12 * time.Second},
+	{Name: "sepolia", ChainID: 11155111, RPCURL: "https://ethereum-sepolia-rpc.publicnode.com", BlockTime: 12 * time.Second},
+	{Name: "holesky", ChainID: 17000, RPCURL: "https://ethereum-holesky-rpc.publicnode.com", BlockTime: 12 * time.Second},
+	{Name: "polygon", ChainID: 137, RPCURL: "https://polygon-bor-rpc.publicnode.com", BlockTime: 2 * time.Second},
+	{Name: "arbitrum", ChainID: 42161, RPCURL: "https://arbitrum-one-rpc.publicnode.com", BlockTime: 250 * time.Millisecond},
+	{Name: "base", ChainID: 8453, RPCURL: "https://base-rpc.publicnode.com", BlockTime: 2 * time.Second},
+	{Name: "bsc", ChainID: 56, RPCURL: "https://bsc-rpc.publicnode.com", BlockTime: 3 * time.Second},
+}
+
+// Presets returns the built-in networks.
+func Presets() []Preset {
+	return append([]Preset(nil), presets...)
+}
+
+// LookupPreset returns the built-in network named name or one of its
+// aliases.
+func LookupPreset(name string) (Preset, bool) {
+	for _, p := range presets {
+		if p.Name == name {
+			return p, true
+		}
+		for _, a := range p.Aliases {
+			if a == name {
+				return p, true
+			}
+		}
+	}
+	return Preset{}, false
+}
+
+// pollInterval returns how often a chain of the preset is polled: once per
+// block, but no less often than fallback and, unless fallback is shorter,
+// no more than once a second.
+func (p Preset) pollInterval(fallback time.Duration) time.Duration {
+	if p.BlockTime >= fallback {
+		return fallback
+	}
+	return max(p.BlockTime, min(minPresetPollInterval, fallback))
+}
+
+// withPreset fills in ch from the preset named like it, if any: its chain
+// ID, its public endpoint unless keepRPC is set, and a poll interval
+// matching its block time.
+func withPreset(ch ChainConfig, keepRPC bool) ChainConfig {
+	p, ok := LookupPreset(ch.Name)
+	if !ok {
+		return ch
+	}
+	ch.ChainID = p.ChainID
+	if !keepRPC {
+		ch.RPCURL = p.RPCURL
+	}
+	ch.PollInterval = p.pollInterval(ch.PollInterval)
+	return ch
+}
@@ -0,0 +1,185 @@
+// Package deadletter keeps the blocks that still failed to process once
+// their retries were used up, so they can be inspected and re-driven
+// instead of leaving silent gaps in the index.
+package deadletter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Entry is a dead-lettered block.
+type Entry struct {
+	Chain string `json:"chain,omitempty"`
+	Block int    `json:"block"`
+	// Error is the error of the last attempt.
+	Error string `json:"error"`
+	// Attempts counts the attempts made to process the block, including
+	// those of earlier re-drives.
+	Attempts int       `json:"attempts"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// Queue is a thread-safe list of dead-lettered blocks, at most one entry
+// per chain and block. When opened from a file, the file is rewritten on
+// every change so the list survives restarts.
+type Queue struct {
+	mu      sync.Mutex
+	entries []Entry // ordered by chain and block
+	path    string  // empty for in-memory queues
+	now     func() time.Time
+}
+
+// New creates an in-memory Queue.
+func New() *Queue {
+	return &Queue{now: time.Now}
+}
+
+// Open creates a Queue persisted to path, loading the entries already in
+// it. A missing file starts an empty queue.
+func Open(path string) (*Queue, error) {
+	q := &Queue{path: path, now: time.Now}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dead-letter file: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &q.entries); err != nil {
+			return nil, fmt.Errorf("failed to read dead-letter file %s: %w", path, err)
+		}
+	}
+	q.sort()
+	return q, nil
+}
+
+// Add records that block of chain failed after attempts more attempts with
+// err. Attempts add up with those of an entry already recorded for the
+// block, which is replaced. The entry is kept in memory even if writing
+// the file fails.
+func (q *Queue) Add(chain string, block, attempts int, err error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	e := Entry{Chain: chain, Block: block, Error: err.Error(), Attempts: attempts, FailedAt: q.now().UTC()}
+	if i, ok := q.find(chain, block); ok {
+		e.Attempts += q.entries[i].Attempts
+		q.entries[i] = e
+	} else {
+		q.entries = append(q.entries, e)
+		q.sort()
+	}
+	return q.save()
+}
+
+// List returns the entries of chain ordered by block, or of every chain
+// if chain is empty.
+func (q *Queue) List(chain string) []Entry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := []Entry{}
+	for _, e := range q.entries {
+		if chain == "" || e.Chain == chain {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Remove deletes the entry of block of chain, returning it and whether it
+// existed.
+func (q *Queue) Remove(chain string, block int) (Entry, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	i, ok := q.find(chain, block)
+	if !ok {
+		return Entry{}, false, nil
+	}
+	e := q.entries[i]
+	q.entries = append(q.entries[:i], q.entries[i+1:]...)
+	return e, true, q.save()
+}
+
+// Restore puts back an entry taken out by Remove, e.g. when re-driving
+// it couldn't be started. An entry recorded for the block meanwhile wins.
+func (q *Queue) Restore(e Entry) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.find(e.Chain, e.Block); ok {
+		return nil
+	}
+	q.entries = append(q.entries, e)
+	q.sort()
+	return q.save()
+}
+
+// Chain binds q to chain, e.g. for a parser's parser.DeadLetters.
+func (q *Queue) Chain(chain string) ChainQueue {
+	return ChainQueue{queue: q, chain: chain}
+}
+
+// ChainQueue records the dead-lettered blocks of one chain.
+type ChainQueue struct {
+	queue *Queue
+	chain string
+}
+
+// AddBlock records that block failed after attempts attempts with err.
+func (c ChainQueue) AddBlock(block, attempts int, err error) error {
+	return c.queue.Add(c.chain, block, attempts, err)
+}
+
+func (q *Queue) find(chain string, block int) (int, bool) {
+	for i, e := range q.entries {
+		if e.Chain == chain && e.Block == block {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (q *Queue) sort() {
+	sort.Slice(q.entries, func(i, j int) bool {
+		a, b := q.entries[i], q.entries[j]
+		if a.Chain != b.Chain {
+			return a.Chain < b.Chain
+		}
+		return a.Block < b.Block
+	})
+}
+
+// save writes the entries to the file, if any, replacing it atomically.
+// q.mu must be held.
+func (q *Queue) save() error {
+	if q.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(q.entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead-letter entries: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(q.path), filepath.Base(q.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write dead-letter file: %w", err)
+	}
+	_, err = tmp.Write(append(data, '\n'))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), q.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write dead-letter file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package deadletter
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQueue(t *testing.T) {
+	q := New()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	q.now = func() time.Time { return now }
+
+	eth := q.Chain("ethereum")
+	eth.AddBlock(200, 4, errors.New("timeout"))
+	eth.AddBlock(100, 4, errors.New("connection refused"))
+	q.Add("sepolia", 50, 1, errors.New("boom"))
+
+	got := q.List("ethereum")
+	if len(got) != 2 || got[0].Block != 100 || got[1].Block != 200 {
+		t.Fatalf("Expected the chain's blocks in order, got %+v", got)
+	}
+	if got[0].Error != "connection refused" || got[0].Attempts != 4 || !got[0].FailedAt.Equal(now) {
+		t.Errorf("Unexpected entry %+v", got[0])
+	}
+	if all := q.List(""); len(all) != 3 {
+		t.Errorf("Expected entries of every chain, got %+v", all)
+	}
+
+	// A block failing again replaces its entry, adding up attempts.
+	eth.AddBlock(100, 2, errors.New("still failing"))
+	if got := q.List("ethereum")[0]; got.Attempts != 6 || got.Error != "still failing" {
+		t.Errorf("Expected the entry to be replaced, got %+v", got)
+	}
+
+	e, ok, _ := q.Remove("ethereum", 100)
+	if !ok || e.Block != 100 {
+		t.Fatalf("Expected block 100 to be removed, got %+v %v", e, ok)
+	}
+	if _, ok, _ := q.Remove("ethereum", 100); ok {
+		t.Error("Expected the second remove to fail")
+	}
+	q.Restore(e)
+	if got := q.List("ethereum"); len(got) != 2 || got[0].Attempts != 6 {
+		t.Errorf("Expected the entry to be restored, got %+v", got)
+	}
+}
+
+func TestOpen_Persists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deadletters.json")
+	q, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if err := q.Add("ethereum", 100, 4, errors.New("timeout")); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	q.Add("ethereum", 101, 4, errors.New("timeout"))
+	if _, _, err := q.Remove("ethereum", 101); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if got := reopened.List(""); len(got) != 1 || got[0].Block != 100 || got[0].Error != "timeout" {
+		t.Errorf("Expected the entries to survive a reopen, got %+v", got)
+	}
+
+	os.WriteFile(path, []byte("not json"), 0o600)
+	if _, err := Open(path); err == nil {
+		t.Error("Expected an error for a corrupt file")
+	}
+}
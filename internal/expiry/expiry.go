@@ -0,0 +1,102 @@
+// Package expiry schedules the automatic removal of subscriptions created
+// with a time to live, e.g. a deposit address watched for 24 hours.
+package expiry
+
+import (
+	"sync"
+	"time"
+)
+
+// Scheduler runs a function once a subscription's TTL elapses, unless the
+// subscription was cancelled or rescheduled first. Subscriptions are keyed
+// by owner, the tenant that created them or "" without API keys, and
+// address. It is safe for concurrent use.
+type Scheduler struct {
+	mu      sync.Mutex
+	pending map[key]*entry
+	now     func() time.Time
+}
+
+type key struct{ owner, addr string }
+
+type entry struct {
+	at    time.Time
+	timer *time.Timer
+}
+
+// New creates an empty Scheduler.
+func New() *Scheduler {
+	return &Scheduler{pending: make(map[key]*entry), now: time.Now}
+}
+
+// Schedule arranges for fn to run in its own goroutine after ttl, replacing
+// any expiry already scheduled for owner and addr, and returns the time it
+// will run.
+func (s *Scheduler) Schedule(owner, addr string, ttl time.Duration, fn func()) time.Time {
+	k := key{owner, addr}
+	e := &entry{at: s.now().Add(ttl)}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cancel(k)
+	e.timer = time.AfterFunc(ttl, func() {
+		s.mu.Lock()
+		current := s.pending[k] == e
+		if current {
+			delete(s.pending, k)
+		}
+		s.mu.Unlock()
+		// A concurrent Cancel or Schedule may have won the race against
+		// the timer; the subscription then no longer expires at e.at.
+		if current {
+			fn()
+		}
+	})
+	s.pending[k] = e
+	return e.at
+}
+
+// Cancel drops the expiry scheduled for owner and addr, making the
+// subscription permanent, and reports whether there was one.
+func (s *Scheduler) Cancel(owner, addr string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cancel(key{owner, addr})
+}
+
+// cancel is Cancel with s.mu held.
+func (s *Scheduler) cancel(k key) bool {
+	e, ok := s.pending[k]
+	if !ok {
+		return false
+	}
+	e.timer.Stop()
+	delete(s.pending, k)
+	return true
+}
+
+// ExpiresAt returns when the subscription of owner to addr expires.
+func (s *Scheduler) ExpiresAt(owner, addr string) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.pending[key{owner, addr}]
+	if !ok {
+		return time.Time{}, false
+	}
+	return e.at, true
+}
+
+// Len returns the number of pending expiries.
+func (s *Scheduler) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pending)
+}
+
+// Stop cancels every pending expiry, e.g. during shutdown.
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k := range s.pending {
+		s.cancel(k)
+	}
+}
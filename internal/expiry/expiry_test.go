@@ -0,0 +1,59 @@
+package expiry
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestScheduler_Expires(t *testing.T) {
+	s := New()
+	fired := make(chan string, 1)
+	at := s.Schedule("payments", "0xaaa", 10*time.Millisecond, func() { fired <- "0xaaa" })
+	if got, ok := s.ExpiresAt("payments", "0xaaa"); !ok || !got.Equal(at) {
+		t.Errorf("Expected expiry at %v, got %v %v", at, got, ok)
+	}
+	if _, ok := s.ExpiresAt("risk", "0xaaa"); ok {
+		t.Error("Expected expiries to be scoped to their owner")
+	}
+
+	select {
+	case addr := <-fired:
+		if addr != "0xaaa" {
+			t.Errorf("Unexpected expiry of %s", addr)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the subscription to expire")
+	}
+	if s.Len() != 0 {
+		t.Errorf("Expected no pending expiries, got %d", s.Len())
+	}
+}
+
+func TestScheduler_CancelAndReschedule(t *testing.T) {
+	s := New()
+	var fired atomic.Int32
+	inc := func() { fired.Add(1) }
+
+	s.Schedule("", "0xaaa", 10*time.Millisecond, inc)
+	if !s.Cancel("", "0xaaa") || s.Cancel("", "0xaaa") {
+		t.Error("Expected only the first cancel to succeed")
+	}
+
+	// Rescheduling replaces the earlier expiry rather than adding one.
+	s.Schedule("", "0xbbb", 10*time.Millisecond, inc)
+	s.Schedule("", "0xbbb", time.Hour, inc)
+	s.Schedule("", "0xccc", time.Hour, inc)
+	time.Sleep(50 * time.Millisecond)
+	if n := fired.Load(); n != 0 {
+		t.Errorf("Expected no expiry to fire, got %d", n)
+	}
+	if s.Len() != 2 {
+		t.Errorf("Expected 2 pending expiries, got %d", s.Len())
+	}
+
+	s.Stop()
+	if s.Len() != 0 {
+		t.Errorf("Expected Stop to cancel every expiry, got %d", s.Len())
+	}
+}
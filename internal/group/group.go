@@ -0,0 +1,123 @@
+// Package group keeps named groups of addresses, such as a treasury's
+// wallets, so they can be subscribed and queried as one portfolio.
+package group
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when a group name is unknown.
+var ErrNotFound = errors.New("group not found")
+
+// MaxAddresses caps the members of a single group, keeping merged queries
+// bounded.
+const MaxAddresses = 1000
+
+// namePattern restricts group names to what reads well in a URL.
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// Group is a named set of addresses.
+type Group struct {
+	Name string `json:"name"`
+	// Addresses are lowercase, deduplicated and sorted.
+	Addresses []string  `json:"addresses"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ValidateName reports whether name is a valid group name: lowercase
+// letters, digits, '-' and '_', starting with a letter or digit and at most
+// 64 characters long.
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid group name %q: expected up to 64 lowercase letters, digits, '-' or '_'", name)
+	}
+	return nil
+}
+
+// Registry is a thread-safe in-memory store of groups. Groups are keyed by
+// owner, the tenant that created them or "" without API keys, and name, so
+// tenants can use the same names without seeing each other's groups.
+type Registry struct {
+	mu     sync.Mutex
+	groups map[key]Group
+	now    func() time.Time
+}
+
+type key struct{ owner, name string }
+
+// New creates an empty Registry.
+func New() *Registry {
+	return &Registry{groups: make(map[key]Group), now: time.Now}
+}
+
+// Set creates the group name of owner with addresses, which must already
+// be normalized, or replaces the members of an existing one. It reports
+// whether the group was created.
+func (r *Registry) Set(owner, name string, addresses []string) (Group, bool, error) {
+	if err := ValidateName(name); err != nil {
+		return Group{}, false, err
+	}
+	addrs := slices.Clone(addresses)
+	sort.Strings(addrs)
+	addrs = slices.Compact(addrs)
+	if len(addrs) > MaxAddresses {
+		return Group{}, false, fmt.Errorf("group %s has %d addresses: at most %d are allowed", name, len(addrs), MaxAddresses)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k := key{owner, name}
+	now := r.now()
+	g, ok := r.groups[k]
+	if !ok {
+		g = Group{Name: name, CreatedAt: now}
+	}
+	g.Addresses, g.UpdatedAt = addrs, now
+	r.groups[k] = g
+	return g, !ok, nil
+}
+
+// Get returns the group name of owner.
+func (r *Registry) Get(owner, name string) (Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	g, ok := r.groups[key{owner, name}]
+	if !ok {
+		return Group{}, ErrNotFound
+	}
+	return g, nil
+}
+
+// List returns the groups of owner ordered by name.
+func (r *Registry) List(owner string) []Group {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := []Group{}
+	for k, g := range r.groups {
+		if k.owner == owner {
+			out = append(out, g)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Delete removes the group name of owner and reports whether it existed.
+// The subscriptions of its members are left alone.
+func (r *Registry) Delete(owner, name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k := key{owner, name}
+	if _, ok := r.groups[k]; !ok {
+		return false
+	}
+	delete(r.groups, k)
+	return true
+}
@@ -0,0 +1,57 @@
+package group
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestRegistry_SetAndGet(t *testing.T) {
+	r := New()
+	g, created, err := r.Set("payments", "treasury", []string{"0xbbb", "0xaaa", "0xbbb"})
+	if err != nil || !created {
+		t.Fatalf("Expected the group to be created, got %v %v", created, err)
+	}
+	if want := []string{"0xaaa", "0xbbb"}; !slices.Equal(g.Addresses, want) {
+		t.Errorf("Expected addresses %v, got %v", want, g.Addresses)
+	}
+
+	// Setting it again replaces the members but keeps the creation time.
+	g2, created, err := r.Set("payments", "treasury", []string{"0xccc"})
+	if err != nil || created {
+		t.Fatalf("Expected the group to be replaced, got %v %v", created, err)
+	}
+	if !g2.CreatedAt.Equal(g.CreatedAt) || !slices.Equal(g2.Addresses, []string{"0xccc"}) {
+		t.Errorf("Unexpected replaced group %+v", g2)
+	}
+
+	if _, err := r.Get("risk", "treasury"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected groups to be scoped to their owner, got %v", err)
+	}
+	if got := r.List("payments"); len(got) != 1 || got[0].Name != "treasury" {
+		t.Errorf("Unexpected groups %+v", got)
+	}
+	if !r.Delete("payments", "treasury") || r.Delete("payments", "treasury") {
+		t.Error("Expected only the first delete to succeed")
+	}
+	if got := r.List("payments"); len(got) != 0 {
+		t.Errorf("Expected no groups after delete, got %+v", got)
+	}
+}
+
+func TestRegistry_SetValidates(t *testing.T) {
+	r := New()
+	for _, name := range []string{"", "Treasury", "-ops", "a/b"} {
+		if _, _, err := r.Set("", name, nil); err == nil {
+			t.Errorf("Expected name %q to be rejected", name)
+		}
+	}
+	addrs := make([]string, MaxAddresses+1)
+	for i := range addrs {
+		addrs[i] = fmt.Sprintf("0x%040x", i)
+	}
+	if _, _, err := r.Set("", "big", addrs); err == nil {
+		t.Error("Expected groups over MaxAddresses to be rejected")
+	}
+}
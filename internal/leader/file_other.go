@@ -0,0 +1,23 @@
+//go:build !unix
+
+package leader
+
+import "errors"
+
+// FileLock is unavailable on this platform.
+type FileLock struct{}
+
+// NewFileLock reports that file locks are unsupported on this platform.
+func NewFileLock(path string) (*FileLock, error) {
+	return nil, errors.New("leader election with a lock file is not supported on this platform")
+}
+
+// TryLock never takes the lock.
+func (l *FileLock) TryLock() (bool, error) {
+	return false, errors.New("file locks are not supported on this platform")
+}
+
+// Unlock does nothing.
+func (l *FileLock) Unlock() error {
+	return nil
+}
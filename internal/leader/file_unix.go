@@ -0,0 +1,59 @@
+//go:build unix
+
+package leader
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// FileLock is a Lock on an advisory flock(2) lock of a file. The kernel
+// releases it when its holder exits, however it exits. Replicas on other
+// hosts must see the file on a shared filesystem that supports flock.
+type FileLock struct {
+	path string
+	f    *os.File // open while held
+}
+
+// NewFileLock creates a FileLock on path, which is created if missing.
+func NewFileLock(path string) (*FileLock, error) {
+	return &FileLock{path: path}, nil
+}
+
+// TryLock takes the lock without blocking and records the holder's host
+// and PID in the file for operators.
+func (l *FileLock) TryLock() (bool, error) {
+	if l.f != nil {
+		return true, nil
+	}
+	f, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return false, fmt.Errorf("failed to open leader lock file: %w", err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to lock %s: %w", l.path, err)
+	}
+	host, _ := os.Hostname()
+	if err := f.Truncate(0); err == nil {
+		fmt.Fprintf(f, "%s %d\n", host, os.Getpid())
+	}
+	l.f = f
+	return true, nil
+}
+
+// Unlock releases the lock.
+func (l *FileLock) Unlock() error {
+	if l.f == nil {
+		return nil
+	}
+	err := syscall.Flock(int(l.f.Fd()), syscall.LOCK_UN)
+	err = errors.Join(err, l.f.Close())
+	l.f = nil
+	return err
+}
@@ -0,0 +1,119 @@
+// Package leader elects one active instance among identical replicas, so
+// that several can run for availability while exactly one polls and writes.
+package leader
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
+)
+
+// Lock is a lock shared by the replicas. It must be released when its
+// holder dies, so that a standby can take over.
+type Lock interface {
+	// TryLock takes the lock if it is free, reporting whether it did.
+	TryLock() (bool, error)
+	// Unlock releases the lock.
+	Unlock() error
+}
+
+// Options configures an Elector.
+type Options struct {
+	// RetryInterval is how often a standby tries to take the lock.
+	// Defaults to 5s.
+	RetryInterval time.Duration
+	// Logger receives election events. Defaults to the "leader" component
+	// logger.
+	Logger *slog.Logger
+	// Metrics records whether this instance leads. Defaults to metrics.Nop.
+	Metrics metrics.Recorder
+}
+
+// Elector campaigns for a Lock and keeps it once taken, until Resign.
+type Elector struct {
+	lock   Lock
+	opts   Options
+	leader atomic.Bool
+
+	mu   sync.Mutex
+	held bool // guarded by mu
+}
+
+// New creates an Elector campaigning for lock.
+func New(lock Lock, opts Options) *Elector {
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = 5 * time.Second
+	}
+	if opts.Logger == nil {
+		opts.Logger = logging.Component("leader")
+	}
+	opts.Metrics = metrics.OrNop(opts.Metrics)
+	return &Elector{lock: lock, opts: opts}
+}
+
+// Run tries to take the lock every RetryInterval until it succeeds, then
+// calls elected and returns. It returns without calling elected if ctx is
+// cancelled first.
+func (e *Elector) Run(ctx context.Context, elected func()) {
+	e.opts.Metrics.Set(metrics.Leader, 0)
+	e.opts.Logger.Info("waiting for leadership")
+	ticker := time.NewTicker(e.opts.RetryInterval)
+	defer ticker.Stop()
+	for {
+		if e.campaign(ctx) {
+			e.opts.Metrics.Set(metrics.Leader, 1)
+			e.opts.Logger.Info("acquired leadership")
+			elected()
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// campaign tries to take the lock once, unless ctx is cancelled.
+func (e *Elector) campaign(ctx context.Context) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if ctx.Err() != nil {
+		return false
+	}
+	ok, err := e.lock.TryLock()
+	if err != nil {
+		e.opts.Logger.Error("failed to take leader lock", logging.KeyError, err)
+		return false
+	}
+	if ok {
+		e.held = true
+		e.leader.Store(true)
+	}
+	return ok
+}
+
+// IsLeader reports whether this instance holds the lock.
+func (e *Elector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Resign releases the lock if held, letting a standby take over. Call it
+// once the leader has stopped writing.
+func (e *Elector) Resign() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.held {
+		return nil
+	}
+	e.held = false
+	e.leader.Store(false)
+	e.opts.Metrics.Set(metrics.Leader, 0)
+	e.opts.Logger.Info("resigned leadership")
+	return e.lock.Unlock()
+}
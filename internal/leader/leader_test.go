@@ -0,0 +1,70 @@
+package leader
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestElector_Takeover(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "txparser.lock")
+	newElector := func() *Elector {
+		lock, err := NewFileLock(path)
+		if err != nil {
+			t.Fatalf("NewFileLock failed: %v", err)
+		}
+		return New(lock, Options{RetryInterval: 10 * time.Millisecond})
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first := newElector()
+	first.Run(ctx, func() {})
+	if !first.IsLeader() {
+		t.Fatal("Expected the first instance to be elected")
+	}
+
+	second := newElector()
+	elected := make(chan struct{})
+	go second.Run(ctx, func() { close(elected) })
+	select {
+	case <-elected:
+		t.Fatal("Expected the second instance to stand by while the lock is held")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err := first.Resign(); err != nil {
+		t.Fatalf("Resign failed: %v", err)
+	}
+	if first.IsLeader() {
+		t.Error("Expected the first instance to stand down")
+	}
+	select {
+	case <-elected:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the second instance to take over")
+	}
+	if !second.IsLeader() {
+		t.Error("Expected the second instance to lead")
+	}
+	second.Resign()
+}
+
+func TestElector_Cancelled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "txparser.lock")
+	held, _ := NewFileLock(path)
+	if ok, err := held.TryLock(); !ok || err != nil {
+		t.Fatalf("TryLock failed: %v %v", ok, err)
+	}
+	defer held.Unlock()
+
+	lock, _ := NewFileLock(path)
+	e := New(lock, Options{RetryInterval: 10 * time.Millisecond})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	e.Run(ctx, func() { t.Error("Expected no election while the lock is held") })
+	if e.IsLeader() {
+		t.Error("Expected to remain on standby")
+	}
+}
@@ -0,0 +1,105 @@
+// Package logging configures structured logging and defines the field names
+// shared by every component, so log pipelines can rely on a stable schema.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Field keys used consistently across components.
+const (
+	KeyComponent = "component"
+	KeyBlock     = "block"
+	KeyAddress   = "address"
+	KeyRequestID = "request_id"
+	KeyError     = "error"
+)
+
+// Supported output formats.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Setup installs a process-wide slog logger writing to w in the given format
+// ("text" or "json") at the given level ("debug", "info", "warn", "error").
+// The standard library log package is routed through the same handler.
+// The level can be changed afterwards with SetLevel.
+func Setup(w io.Writer, format, level string) error {
+	lvl, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+	opts := &slog.HandlerOptions{Level: &currentLevel}
+
+	var h slog.Handler
+	switch strings.ToLower(format) {
+	case FormatText, "":
+		h = slog.NewTextHandler(w, opts)
+	case FormatJSON:
+		h = slog.NewJSONHandler(w, opts)
+	default:
+		return fmt.Errorf("invalid log format %q: expected text or json", format)
+	}
+	currentLevel.Set(lvl)
+	slog.SetDefault(slog.New(h))
+	return nil
+}
+
+// currentLevel is the minimum level of the logger installed by Setup.
+var currentLevel slog.LevelVar
+
+// SetLevel changes the minimum level of the logger installed by Setup to
+// level ("debug", "info", "warn" or "error") while it runs.
+func SetLevel(level string) error {
+	lvl, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+	currentLevel.Set(lvl)
+	return nil
+}
+
+func parseLevel(level string) (slog.Level, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return lvl, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+	return lvl, nil
+}
+
+// Level returns the minimum level of the logger installed by Setup.
+func Level() string {
+	return strings.ToLower(currentLevel.Level().String())
+}
+
+// Component returns the default logger tagged with a component name.
+func Component(name string) *slog.Logger {
+	return slog.Default().With(KeyComponent, name)
+}
+
+// requestIDKey is the context key for request IDs.
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID stored in ctx, if any.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// FromContext returns logger annotated with the request ID carried by ctx.
+func FromContext(ctx context.Context, logger *slog.Logger) *slog.Logger {
+	if id := RequestID(ctx); id != "" {
+		return logger.With(KeyRequestID, id)
+	}
+	return logger
+}
@@ -0,0 +1,113 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestSetup_JSON(t *testing.T) {
+	prev := slog.Default()
+	defer slog.SetDefault(prev)
+
+	var buf bytes.Buffer
+	if err := Setup(&buf, FormatJSON, "info"); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	FromContext(ctx, Component("parser")).Info("processed block", KeyBlock, 42)
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Expected JSON log line, got %q: %v", buf.String(), err)
+	}
+	if entry[KeyComponent] != "parser" {
+		t.Errorf("Expected component parser, got %v", entry[KeyComponent])
+	}
+	if entry[KeyRequestID] != "req-1" {
+		t.Errorf("Expected request_id req-1, got %v", entry[KeyRequestID])
+	}
+	if entry[KeyBlock] != float64(42) {
+		t.Errorf("Expected block 42, got %v", entry[KeyBlock])
+	}
+}
+
+func TestSetup_Level(t *testing.T) {
+	prev := slog.Default()
+	defer slog.SetDefault(prev)
+
+	var buf bytes.Buffer
+	if err := Setup(&buf, FormatText, "warn"); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	slog.Info("hidden")
+	if buf.Len() != 0 {
+		t.Errorf("Expected info to be filtered at warn level, got %q", buf.String())
+	}
+}
+
+func TestSetup_Invalid(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Setup(&buf, "xml", "info"); err == nil {
+		t.Error("Expected error for unknown format")
+	}
+	if err := Setup(&buf, FormatText, "loud"); err == nil {
+		t.Error("Expected error for unknown level")
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	prev := slog.Default()
+	defer slog.SetDefault(prev)
+
+	var buf bytes.Buffer
+	if err := Setup(&buf, FormatText, "warn"); err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := SetLevel("debug"); err != nil {
+		t.Fatalf("SetLevel failed: %v", err)
+	}
+	slog.Debug("shown")
+	if buf.Len() == 0 {
+		t.Error("Expected debug to be logged after lowering the level")
+	}
+	if got := Level(); got != "debug" {
+		t.Errorf("Expected level debug, got %s", got)
+	}
+	if err := SetLevel("loud"); err == nil || Level() != "debug" {
+		t.Errorf("Expected an invalid level to be rejected, got %v (level %s)", err, Level())
+	}
+}
+
+func TestSampleTx(t *testing.T) {
+	defer SetTxSampling(TxSampling{})
+
+	tests := []struct {
+		name     string
+		sampling TxSampling
+		matched  bool
+		expected int
+	}{
+		{name: "all", sampling: TxSampling{}, expected: 12},
+		{name: "one in four", sampling: TxSampling{Every: 4}, expected: 3},
+		{name: "matched only", sampling: TxSampling{MatchedOnly: true}, expected: 0},
+		{name: "matched", sampling: TxSampling{MatchedOnly: true}, matched: true, expected: 12},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetTxSampling(tt.sampling)
+			logged := 0
+			for range 12 {
+				if SampleTx(func() bool { return tt.matched }) {
+					logged++
+				}
+			}
+			if logged != tt.expected {
+				t.Errorf("Expected %d transactions logged, got %d", tt.expected, logged)
+			}
+		})
+	}
+}
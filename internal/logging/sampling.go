@@ -0,0 +1,48 @@
+package logging
+
+import (
+	"sync/atomic"
+)
+
+// TxSampling selects which processed transactions are logged at debug
+// level. At mainnet volume logging every one of them dominates CPU and disk.
+type TxSampling struct {
+	// Every logs one in Every transactions; 0 or 1 logs all of them.
+	Every int `json:"every"`
+	// MatchedOnly logs only transactions of subscribed addresses.
+	MatchedOnly bool `json:"matched_only"`
+}
+
+var (
+	txSampling atomic.Pointer[TxSampling]
+	// txSeen counts the transactions eligible for logging, to pick one in
+	// Every of them.
+	txSeen atomic.Uint64
+)
+
+// SetTxSampling changes which processed transactions are logged. It is
+// safe to call while transactions are being processed.
+func SetTxSampling(s TxSampling) {
+	s.Every = max(s.Every, 1)
+	txSampling.Store(&s)
+}
+
+// CurrentTxSampling returns the sampling set by SetTxSampling, which logs
+// every transaction until it is called.
+func CurrentTxSampling() TxSampling {
+	if s := txSampling.Load(); s != nil {
+		return *s
+	}
+	return TxSampling{Every: 1}
+}
+
+// SampleTx reports whether a processed transaction should be logged.
+// matched reports whether it involves a subscribed address and is only
+// called when the sampling depends on it.
+func SampleTx(matched func() bool) bool {
+	s := CurrentTxSampling()
+	if s.MatchedOnly && !matched() {
+		return false
+	}
+	return s.Every <= 1 || txSeen.Add(1)%uint64(s.Every) == 0
+}
@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+)
+
+// LagAlert is the JSON payload posted by LagAlerter to generic webhooks.
+type LagAlert struct {
+	Chain string `json:"chain"`
+	// Status is "lagging" when the lag exceeds the threshold and
+	// "recovered" once it is back within it.
+	Status string    `json:"status"`
+	Lag    int       `json:"lag"`
+	MaxLag int       `json:"max_lag"`
+	Head   int       `json:"head"`
+	Block  int       `json:"block"`
+	Time   time.Time `json:"time"`
+}
+
+// LagAlerter posts block lag alerts to a webhook. Slack and Discord webhook
+// URLs receive a chat message; other URLs receive a LagAlert as JSON.
+type LagAlerter struct {
+	url     string
+	chain   string
+	chat    bool
+	discord bool
+	client  *http.Client
+}
+
+// NewLagAlerter validates webhookURL and creates a LagAlerter for chain.
+func NewLagAlerter(webhookURL, chain string) (*LagAlerter, error) {
+	u, err := url.Parse(webhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid lag alert URL %q", webhookURL)
+	}
+	return &LagAlerter{
+		url:     webhookURL,
+		chain:   chain,
+		chat:    isSlack(u) || isDiscord(u),
+		discord: isDiscord(u),
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Alert posts s.
+func (a *LagAlerter) Alert(ctx context.Context, s parser.LagStatus) error {
+	alert := LagAlert{
+		Chain:  a.chain,
+		Status: "recovered",
+		Lag:    s.Lag,
+		MaxLag: s.MaxLag,
+		Head:   s.Head,
+		Block:  s.Block,
+		Time:   time.Now().UTC(),
+	}
+	if s.Lagging {
+		alert.Status = "lagging"
+	}
+	if !a.chat {
+		return postJSON(ctx, a.client, a.url, alert)
+	}
+	return postJSON(ctx, a.client, a.url, chatPayload(a.discord, lagText(alert)))
+}
+
+// lagText formats a as a single chat line.
+func lagText(a LagAlert) string {
+	if a.Status == "lagging" {
+		return fmt.Sprintf(":warning: txparser on %s is %d blocks behind the node (head %d, processed %d, threshold %d)",
+			a.Chain, a.Lag, a.Head, a.Block, a.MaxLag)
+	}
+	return fmt.Sprintf(":white_check_mark: txparser on %s has caught up: %d blocks behind (head %d, processed %d)",
+		a.Chain, a.Lag, a.Head, a.Block)
+}
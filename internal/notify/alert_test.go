@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+)
+
+func TestLagAlerter_JSON(t *testing.T) {
+	var got LagAlert
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("Failed to decode alert: %v", err)
+		}
+	}))
+	defer ts.Close()
+
+	a, err := NewLagAlerter(ts.URL, "ethereum")
+	if err != nil {
+		t.Fatalf("NewLagAlerter failed: %v", err)
+	}
+	if err := a.Alert(context.Background(), parser.LagStatus{Lagging: true, Lag: 30, Head: 130, Block: 100, MaxLag: 20}); err != nil {
+		t.Fatalf("Alert failed: %v", err)
+	}
+	if got.Chain != "ethereum" || got.Status != "lagging" || got.Lag != 30 || got.MaxLag != 20 || got.Head != 130 || got.Block != 100 || got.Time.IsZero() {
+		t.Errorf("Unexpected alert: %+v", got)
+	}
+
+	if err := a.Alert(context.Background(), parser.LagStatus{Lag: 1, Head: 131, Block: 130, MaxLag: 20}); err != nil {
+		t.Fatalf("Alert failed: %v", err)
+	}
+	if got.Status != "recovered" {
+		t.Errorf("Expected recovered status, got %s", got.Status)
+	}
+}
+
+func TestLagAlerter_Chat(t *testing.T) {
+	var got map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer ts.Close()
+
+	a, err := NewLagAlerter(ts.URL, "ethereum")
+	if err != nil {
+		t.Fatalf("NewLagAlerter failed: %v", err)
+	}
+	a.chat = true // as for a hooks.slack.com URL
+	a.Alert(context.Background(), parser.LagStatus{Lagging: true, Lag: 30, Head: 130, Block: 100, MaxLag: 20})
+	if !strings.Contains(got["text"], "30 blocks behind") {
+		t.Errorf("Unexpected chat message: %v", got)
+	}
+}
+
+func TestNewLagAlerter_InvalidURL(t *testing.T) {
+	if _, err := NewLagAlerter("ftp://example.com", "ethereum"); err == nil {
+		t.Error("Expected error for non-HTTP URL")
+	}
+}
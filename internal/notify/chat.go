@@ -0,0 +1,123 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// ChatOptions configures a ChatNotifier.
+type ChatOptions struct {
+	// WebhookURL is a Slack incoming webhook or a Discord webhook URL. The
+	// payload format is chosen from the host name.
+	WebhookURL string
+	// Chain is included in messages.
+	Chain string
+	// MinValue is the smallest value that triggers a message. Zero notifies
+	// on every inbound transaction.
+	MinValue transaction.Value
+	// HTTPClient posts messages. Defaults to a client with a 10s timeout.
+	HTTPClient *http.Client
+}
+
+// ChatNotifier posts a message to Slack or Discord when a subscribed address
+// receives a transaction at or above a value threshold.
+type ChatNotifier struct {
+	opts    ChatOptions
+	discord bool
+}
+
+// NewChatNotifier validates opts and creates a ChatNotifier.
+func NewChatNotifier(opts ChatOptions) (*ChatNotifier, error) {
+	u, err := url.Parse(opts.WebhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid chat webhook URL %q", opts.WebhookURL)
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &ChatNotifier{opts: opts, discord: isDiscord(u)}, nil
+}
+
+// isDiscord reports whether u is a Discord webhook URL.
+func isDiscord(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	return host == "discord.com" || host == "discordapp.com" || strings.HasSuffix(host, ".discord.com")
+}
+
+// isSlack reports whether u is a Slack incoming webhook URL.
+func isSlack(u *url.URL) bool {
+	return strings.ToLower(u.Hostname()) == "hooks.slack.com"
+}
+
+// Notify posts a message if ev is an inbound transaction at or above the
+// threshold, and ignores it otherwise.
+func (n *ChatNotifier) Notify(ctx context.Context, ev parser.Event) error {
+	if !n.wants(ev) {
+		return nil
+	}
+	return n.post(ctx, ev)
+}
+
+// wants reports whether ev is an inbound transaction at or above the threshold.
+func (n *ChatNotifier) wants(ev parser.Event) bool {
+	if !ev.Transaction.Inbound() {
+		return false
+	}
+	return ev.Transaction.Value.Cmp(n.opts.MinValue) >= 0
+}
+
+// text formats ev as a single chat line.
+func (n *ChatNotifier) text(ev parser.Event) string {
+	tx := ev.Transaction
+	if ev.Removed {
+		return fmt.Sprintf("%s: %s ETH from %s on %s was removed by a chain reorganization (block %d, tx %s)",
+			ev.Address, tx.Value.Ether(), tx.From, n.opts.Chain, tx.Block, tx.Hash)
+	}
+	return fmt.Sprintf("%s received %s ETH from %s on %s (block %d, tx %s)",
+		ev.Address, tx.Value.Ether(), tx.From, n.opts.Chain, tx.Block, tx.Hash)
+}
+
+// post sends a single message in the Slack or Discord payload format.
+func (n *ChatNotifier) post(ctx context.Context, ev parser.Event) error {
+	return postJSON(ctx, n.opts.HTTPClient, n.opts.WebhookURL, chatPayload(n.discord, n.text(ev)))
+}
+
+// chatPayload wraps text in the Slack or Discord message format.
+func chatPayload(discord bool, text string) map[string]string {
+	if discord {
+		return map[string]string{"content": text}
+	}
+	return map[string]string{"text": text}
+}
+
+// postJSON posts payload to url and expects a 2xx response.
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
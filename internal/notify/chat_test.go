@@ -0,0 +1,80 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+func TestChatNotifier_Notify(t *testing.T) {
+	var bodies []map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode body: %v", err)
+		}
+		bodies = append(bodies, body)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name    string
+		discord bool
+		key     string
+	}{
+		{name: "slack", discord: false, key: "text"},
+		{name: "discord", discord: true, key: "content"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bodies = nil
+			n, err := NewChatNotifier(ChatOptions{WebhookURL: ts.URL, Chain: "ethereum", MinValue: transaction.WeiValue(1000)})
+			if err != nil {
+				t.Fatalf("NewChatNotifier failed: %v", err)
+			}
+			n.discord = tt.discord
+
+			events := []parser.Event{
+				{Address: "0xaaa", Transaction: transaction.Transaction{Hash: "0xsmall", Value: transaction.WeiValue(999), Direction: transaction.DirectionIn}},
+				{Address: "0xaaa", Transaction: transaction.Transaction{Hash: "0xout", Value: transaction.WeiValue(5000), Direction: transaction.DirectionOut}},
+				{Address: "0xaaa", Transaction: transaction.Transaction{Hash: "0xbig", From: "0xbbb", Value: transaction.WeiValue(1500000000000000000), Block: 7, Direction: transaction.DirectionIn}},
+			}
+			for _, ev := range events {
+				if err := n.Notify(context.Background(), ev); err != nil {
+					t.Fatalf("Notify failed: %v", err)
+				}
+			}
+
+			if len(bodies) != 1 {
+				t.Fatalf("Expected 1 message, got %d", len(bodies))
+			}
+			msg := bodies[0][tt.key]
+			for _, want := range []string{"0xaaa", "1.5 ETH", "0xbbb", "ethereum", "block 7", "0xbig"} {
+				if !strings.Contains(msg, want) {
+					t.Errorf("Expected message to contain %q, got %q", want, msg)
+				}
+			}
+
+			removed := events[2]
+			removed.Removed = true
+			if err := n.Notify(context.Background(), removed); err != nil {
+				t.Fatalf("Notify failed: %v", err)
+			}
+			if len(bodies) != 2 || !strings.Contains(bodies[1][tt.key], "removed by a chain reorganization") {
+				t.Errorf("Expected a message retracting the transaction, got %v", bodies)
+			}
+		})
+	}
+}
+
+func TestNewChatNotifier_InvalidURL(t *testing.T) {
+	if _, err := NewChatNotifier(ChatOptions{WebhookURL: "not a url"}); err == nil {
+		t.Error("Expected error for invalid URL")
+	}
+}
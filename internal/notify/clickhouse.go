@@ -0,0 +1,233 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+)
+
+// clickHouseTablePattern accepts a table name, optionally qualified by its
+// database, that is safe to splice into an INSERT statement.
+var clickHouseTablePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// ClickHouseOptions configures a ClickHouseWriter.
+type ClickHouseOptions struct {
+	// URL is the ClickHouse HTTP interface, e.g. http://localhost:8123.
+	URL string
+	// Table receives the rows, optionally qualified as database.table.
+	// Defaults to "transactions".
+	Table string
+	// Username and Password authenticate with basic auth when Username is set.
+	Username string
+	Password string
+	// Chain is written to every row.
+	Chain string
+	// BatchSize is how many rows are inserted at once. Defaults to 1000.
+	BatchSize int
+	// FlushInterval bounds how long a row waits for its batch to fill.
+	// Defaults to 5s.
+	FlushInterval time.Duration
+	// HTTPClient posts inserts. Defaults to a client with a 30s timeout.
+	HTTPClient *http.Client
+}
+
+// clickHouseRow is one transaction as inserted with FORMAT JSONEachRow.
+// Amounts are decimal strings, which ClickHouse parses into UInt256.
+type clickHouseRow struct {
+	Chain     string    `json:"chain"`
+	Address   string    `json:"address"`
+	Hash      string    `json:"hash"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Value     string    `json:"value"`
+	Block     int       `json:"block"`
+	Direction string    `json:"direction"`
+	Category  string    `json:"category"`
+	Fee       *string   `json:"fee"`
+	IndexedAt time.Time `json:"indexed_at"`
+	// Removed is left out unless set, so that tables without the column
+	// keep accepting rows until a reorg removes a transaction.
+	Removed bool `json:"removed,omitempty"`
+}
+
+// ClickHouseWriter inserts every event it receives into a ClickHouse table
+// in batches, for analytical queries over volumes that in-memory storage
+// can't serve. A batch is inserted once it holds BatchSize rows or its
+// oldest row is FlushInterval old. A failed batch is kept and retried with
+// the next one, up to ten batches' worth of rows, after which the oldest
+// rows are dropped.
+type ClickHouseWriter struct {
+	opts   ClickHouseOptions
+	insert string
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	pending []clickHouseRow // guarded by mu
+	timer   *time.Timer     // pending flush, guarded by mu
+	flushMu sync.Mutex      // serializes inserts
+}
+
+// NewClickHouseWriter validates opts and creates a ClickHouseWriter.
+func NewClickHouseWriter(opts ClickHouseOptions) (*ClickHouseWriter, error) {
+	u, err := url.Parse(opts.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid ClickHouse URL %q", opts.URL)
+	}
+	if opts.Table == "" {
+		opts.Table = "transactions"
+	}
+	if !clickHouseTablePattern.MatchString(opts.Table) {
+		return nil, fmt.Errorf("invalid ClickHouse table %q", opts.Table)
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 1000
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = 5 * time.Second
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	// Timestamps are RFC 3339, which DateTime64 columns only accept with
+	// best-effort parsing.
+	q := u.Query()
+	q.Set("query", "INSERT INTO "+opts.Table+" FORMAT JSONEachRow")
+	q.Set("date_time_input_format", "best_effort")
+	u.RawQuery = q.Encode()
+	return &ClickHouseWriter{
+		opts:   opts,
+		insert: u.String(),
+		logger: logging.Component("clickhouse").With("chain", opts.Chain),
+	}, nil
+}
+
+// Notify queues ev for insertion. It inserts the batch right away once it is
+// full, returning the insert's error, and otherwise schedules a flush.
+func (w *ClickHouseWriter) Notify(ctx context.Context, ev parser.Event) error {
+	tx := ev.Transaction
+	row := clickHouseRow{
+		Chain:     w.opts.Chain,
+		Address:   ev.Address,
+		Hash:      tx.Hash,
+		From:      tx.From,
+		To:        tx.To,
+		Value:     tx.Value.String(),
+		Block:     tx.Block,
+		Direction: string(tx.Direction),
+		Category:  string(tx.Category),
+		IndexedAt: tx.IndexedAt,
+		Removed:   ev.Removed,
+	}
+	if tx.Fee != nil {
+		fee := tx.Fee.String()
+		row.Fee = &fee
+	}
+
+	w.mu.Lock()
+	w.pending = append(w.pending, row)
+	full := len(w.pending) >= w.opts.BatchSize
+	if !full {
+		w.scheduleFlush()
+	}
+	w.mu.Unlock()
+
+	if full {
+		return w.flush(ctx)
+	}
+	return nil
+}
+
+// Close inserts whatever is pending.
+func (w *ClickHouseWriter) Close() error {
+	return w.flush(context.Background())
+}
+
+// flush inserts the pending rows. On failure they are put back in front of
+// rows queued in the meantime, keeping at most ten batches.
+func (w *ClickHouseWriter) flush(ctx context.Context) error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	rows := w.pending
+	w.pending = nil
+	if w.timer != nil {
+		w.timer.Stop()
+		w.timer = nil
+	}
+	w.mu.Unlock()
+	if len(rows) == 0 {
+		return nil
+	}
+
+	// Inserts happen outside the lock so Notify never waits on ClickHouse.
+	err := w.post(ctx, rows)
+	if err == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending = append(rows, w.pending...)
+	if limit := 10 * w.opts.BatchSize; len(w.pending) > limit {
+		dropped := len(w.pending) - limit
+		w.pending = append([]clickHouseRow(nil), w.pending[dropped:]...)
+		w.logger.Warn("dropping rows after failed inserts", "rows", dropped)
+	}
+	w.scheduleFlush()
+	return err
+}
+
+// scheduleFlush inserts the pending rows after the flush interval unless a
+// flush is already scheduled. w.mu must be held.
+func (w *ClickHouseWriter) scheduleFlush() {
+	if w.timer != nil {
+		return
+	}
+	w.timer = time.AfterFunc(w.opts.FlushInterval, func() {
+		if err := w.flush(context.Background()); err != nil {
+			w.logger.Error("failed to insert rows", logging.KeyError, err)
+		}
+	})
+}
+
+// post inserts rows with a single request.
+func (w *ClickHouseWriter) post(ctx context.Context, rows []clickHouseRow) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, row := range rows {
+		if err := enc.Encode(row); err != nil {
+			return fmt.Errorf("failed to encode row: %w", err)
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.insert, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if w.opts.Username != "" {
+		req.SetBasicAuth(w.opts.Username, w.opts.Password)
+	}
+	resp, err := w.opts.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to insert %d rows: %w", len(rows), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to insert %d rows: %s: %s", len(rows), resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
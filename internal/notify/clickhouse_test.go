@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+func TestClickHouseWriter_Batches(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		batches [][]map[string]interface{}
+		fail    = true
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("query"); got != "INSERT INTO analytics.txs FORMAT JSONEachRow" {
+			t.Errorf("Unexpected query %q", got)
+		}
+		if user, pass, _ := r.BasicAuth(); user != "default" || pass != "secret" {
+			t.Errorf("Unexpected credentials %s:%s", user, pass)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			fail = false
+			http.Error(w, "Code: 60. DB::Exception: Table analytics.txs does not exist", http.StatusNotFound)
+			return
+		}
+		var rows []map[string]interface{}
+		sc := bufio.NewScanner(r.Body)
+		for sc.Scan() {
+			var row map[string]interface{}
+			if err := json.Unmarshal(sc.Bytes(), &row); err != nil {
+				t.Errorf("Failed to decode row: %v", err)
+			}
+			rows = append(rows, row)
+		}
+		batches = append(batches, rows)
+	}))
+	defer ts.Close()
+
+	w, err := NewClickHouseWriter(ClickHouseOptions{
+		URL:           ts.URL,
+		Table:         "analytics.txs",
+		Username:      "default",
+		Password:      "secret",
+		Chain:         "ethereum",
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewClickHouseWriter failed: %v", err)
+	}
+	fee := transaction.WeiValue(21000)
+	notify := func(hash string) error {
+		return w.Notify(context.Background(), parser.Event{Address: "0xaaa", Removed: hash == "0x03", Transaction: transaction.Transaction{
+			Hash: hash, From: "0xaaa", To: "0xbbb", Value: transaction.WeiValue(1000), Block: 7, Direction: transaction.DirectionOut, Fee: &fee,
+		}})
+	}
+
+	// The first full batch fails and is kept for the next insert.
+	if err := notify("0x01"); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if err := notify("0x02"); err == nil {
+		t.Fatal("Expected the failed insert to be reported")
+	}
+	if err := notify("0x03"); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batches) != 1 || len(batches[0]) != 3 {
+		t.Fatalf("Expected one batch of 3 rows, got %v", batches)
+	}
+	row := batches[0][0]
+	if row["hash"] != "0x01" || row["chain"] != "ethereum" || row["value"] != "1000" || row["fee"] != "21000" || row["direction"] != "out" {
+		t.Errorf("Unexpected row %v", row)
+	}
+	if _, ok := row["removed"]; ok {
+		t.Errorf("Expected no removed column for a stored transaction, got %v", row)
+	}
+	if row := batches[0][2]; row["removed"] != true {
+		t.Errorf("Expected the removed transaction to be marked, got %v", row)
+	}
+}
+
+func TestNewClickHouseWriter_Invalid(t *testing.T) {
+	for _, opts := range []ClickHouseOptions{
+		{URL: "localhost:8123"},
+		{URL: "http://localhost:8123", Table: "txs; DROP TABLE txs"},
+	} {
+		if _, err := NewClickHouseWriter(opts); err == nil {
+			t.Errorf("Expected %+v to be rejected", opts)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/address"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// AnyAddress maps recipients to every subscribed address.
+const AnyAddress = "*"
+
+// defaultEmailTemplate renders one line per transaction, flagging those a
+// chain reorganization removed.
+const defaultEmailTemplate = `{{len .Events}} new transaction(s) on {{.Chain}}:
+{{range .Events}}
+- {{if .Removed}}REMOVED by a chain reorganization: {{end}}{{.Transaction.Direction}} {{.Address}}: {{ether .Transaction.Value}} ETH
+  from {{.Transaction.From}} to {{.Transaction.To}}
+  block {{.Transaction.Block}}, tx {{.Transaction.Hash}}
+{{end}}`
+
+// EmailData is passed to the body template.
+type EmailData struct {
+	Chain     string
+	Recipient string
+	Events    []parser.Event
+}
+
+// EmailOptions configures an EmailNotifier.
+type EmailOptions struct {
+	// Host and Port locate the SMTP server. Port defaults to 587.
+	Host string
+	Port int
+	// Username and Password enable PLAIN auth when Username is set.
+	Username string
+	Password string
+	// From is the sender address.
+	From string
+	// Recipients maps lowercase addresses, or AnyAddress, to email recipients.
+	Recipients map[string][]string
+	// Chain is passed to the template and used in the subject.
+	Chain string
+	// BatchWindow collects events per recipient into one email. Defaults to 1m.
+	BatchWindow time.Duration
+	// Template is a text/template for the body; the default lists each
+	// transaction. The template receives EmailData and an "ether" function,
+	// which formats a transaction.Value in ether.
+	Template string
+}
+
+// sendFunc matches smtp.SendMail.
+type sendFunc func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+// EmailNotifier emails address activity to per-address recipients, batching
+// events that arrive within a window into a single message per recipient.
+// Failed sends are logged rather than returned, since they happen after
+// Notify has accepted the event.
+type EmailNotifier struct {
+	opts   EmailOptions
+	tmpl   *template.Template
+	send   sendFunc
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	pending map[string][]parser.Event // recipient -> events, guarded by mu
+	timer   *time.Timer               // pending flush, guarded by mu
+	sendMu  sync.Mutex                // serializes flushes
+}
+
+// NewEmailNotifier validates opts and creates an EmailNotifier.
+func NewEmailNotifier(opts EmailOptions) (*EmailNotifier, error) {
+	if opts.Host == "" {
+		return nil, fmt.Errorf("smtp host is required")
+	}
+	if _, err := mail.ParseAddress(opts.From); err != nil {
+		return nil, fmt.Errorf("invalid sender %q: %w", opts.From, err)
+	}
+	if len(opts.Recipients) == 0 {
+		return nil, fmt.Errorf("at least one recipient mapping is required")
+	}
+	if opts.Port == 0 {
+		opts.Port = 587
+	}
+	if opts.BatchWindow <= 0 {
+		opts.BatchWindow = time.Minute
+	}
+	if opts.Template == "" {
+		opts.Template = defaultEmailTemplate
+	}
+	tmpl, err := template.New("email").Funcs(template.FuncMap{"ether": transaction.Value.Ether}).Parse(opts.Template)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email template: %w", err)
+	}
+	return &EmailNotifier{
+		opts:    opts,
+		tmpl:    tmpl,
+		send:    smtp.SendMail,
+		logger:  logging.Component("email").With("chain", opts.Chain),
+		pending: make(map[string][]parser.Event),
+	}, nil
+}
+
+// LoadTemplate reads an email body template from path.
+func LoadTemplate(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read email template: %w", err)
+	}
+	return string(b), nil
+}
+
+// ParseRecipients parses a mapping of the form
+// "0xabc...=ops@example.com;cfo@example.com,*=alerts@example.com": entries
+// are comma-separated, recipients within an entry semicolon-separated, and
+// "*" matches every subscribed address.
+func ParseRecipients(s string) (map[string][]string, error) {
+	out := make(map[string][]string)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		key, list, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid recipient entry %q: expected address=email", entry)
+		}
+		key = strings.TrimSpace(key)
+		if key != AnyAddress {
+			normalized, err := address.Normalize(key)
+			if err != nil {
+				return nil, fmt.Errorf("invalid recipient entry %q: %w", entry, err)
+			}
+			key = normalized
+		}
+		for _, raw := range strings.Split(list, ";") {
+			addr, err := mail.ParseAddress(strings.TrimSpace(raw))
+			if err != nil {
+				return nil, fmt.Errorf("invalid email %q: %w", raw, err)
+			}
+			out[key] = append(out[key], addr.Address)
+		}
+	}
+	return out, nil
+}
+
+// Recipients returns the configured address to recipient mapping.
+func (n *EmailNotifier) Recipients() map[string][]string {
+	return n.opts.Recipients
+}
+
+// recipientsFor returns the recipients interested in addr.
+func (n *EmailNotifier) recipientsFor(addr string) []string {
+	return append(append([]string(nil), n.opts.Recipients[addr]...), n.opts.Recipients[AnyAddress]...)
+}
+
+// Notify queues ev for its recipients. The first event of a batch schedules
+// a flush after the batch window.
+func (n *EmailNotifier) Notify(_ context.Context, ev parser.Event) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, rcpt := range n.recipientsFor(ev.Address) {
+		n.pending[rcpt] = append(n.pending[rcpt], ev)
+	}
+	if len(n.pending) > 0 && n.timer == nil {
+		n.timer = time.AfterFunc(n.opts.BatchWindow, n.flush)
+	}
+	return nil
+}
+
+// Close sends whatever is pending.
+func (n *EmailNotifier) Close() error {
+	n.flush()
+	return nil
+}
+
+// flush sends one email per recipient with pending events. Send failures
+// are logged; the events are not retried.
+func (n *EmailNotifier) flush() {
+	n.mu.Lock()
+	pending := n.pending
+	n.pending = make(map[string][]parser.Event)
+	if n.timer != nil {
+		n.timer.Stop()
+		n.timer = nil
+	}
+	n.mu.Unlock()
+
+	// Sends happen outside the lock so Notify never waits on SMTP.
+	n.sendMu.Lock()
+	defer n.sendMu.Unlock()
+	rcpts := make([]string, 0, len(pending))
+	for rcpt := range pending {
+		rcpts = append(rcpts, rcpt)
+	}
+	sort.Strings(rcpts)
+	for _, rcpt := range rcpts {
+		if err := n.sendBatch(rcpt, pending[rcpt]); err != nil {
+			n.logger.Error("failed to send email", "recipient", rcpt, "events", len(pending[rcpt]), logging.KeyError, err)
+		}
+	}
+}
+
+// sendBatch renders and sends a single email.
+func (n *EmailNotifier) sendBatch(rcpt string, events []parser.Event) error {
+	var body bytes.Buffer
+	if err := n.tmpl.Execute(&body, EmailData{Chain: n.opts.Chain, Recipient: rcpt, Events: events}); err != nil {
+		return fmt.Errorf("failed to render template: %w", err)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.opts.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", rcpt)
+	fmt.Fprintf(&msg, "Subject: [txparser] %d new transaction(s) on %s\r\n", len(events), n.opts.Chain)
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body.String(), "\n", "\r\n"))
+
+	var auth smtp.Auth
+	if n.opts.Username != "" {
+		auth = smtp.PlainAuth("", n.opts.Username, n.opts.Password, n.opts.Host)
+	}
+	addr := net.JoinHostPort(n.opts.Host, strconv.Itoa(n.opts.Port))
+	return n.send(addr, auth, n.opts.From, []string{rcpt}, msg.Bytes())
+}
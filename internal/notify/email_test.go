@@ -0,0 +1,157 @@
+package notify
+
+import (
+	"context"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+const (
+	emailAddrA = "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"
+	emailAddrB = "0xfb6916095ca1df60bb79ce92ce3ea74c37c5d359"
+)
+
+func TestParseRecipients(t *testing.T) {
+	got, err := ParseRecipients("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed=ops@example.com; cfo@example.com, *=alerts@example.com")
+	if err != nil {
+		t.Fatalf("ParseRecipients failed: %v", err)
+	}
+	if r := got[emailAddrA]; len(r) != 2 || r[0] != "ops@example.com" || r[1] != "cfo@example.com" {
+		t.Errorf("Unexpected recipients for address: %v", r)
+	}
+	if r := got[AnyAddress]; len(r) != 1 || r[0] != "alerts@example.com" {
+		t.Errorf("Unexpected wildcard recipients: %v", r)
+	}
+
+	for _, bad := range []string{"0x123=ops@example.com", "*=not-an-email", "missing-separator"} {
+		if _, err := ParseRecipients(bad); err == nil {
+			t.Errorf("Expected error for %q", bad)
+		}
+	}
+}
+
+type sentMail struct {
+	addr string
+	to   []string
+	msg  string
+}
+
+func TestEmailNotifier_Batches(t *testing.T) {
+	n, err := NewEmailNotifier(EmailOptions{
+		Host: "smtp.example.com",
+		From: "txparser@example.com",
+		Recipients: map[string][]string{
+			emailAddrA: {"ops@example.com"},
+			AnyAddress: {"alerts@example.com"},
+		},
+		Chain:       "ethereum",
+		BatchWindow: time.Hour, // only Close sends
+	})
+	if err != nil {
+		t.Fatalf("NewEmailNotifier failed: %v", err)
+	}
+	var sent []sentMail
+	n.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sent = append(sent, sentMail{addr: addr, to: to, msg: string(msg)})
+		return nil
+	}
+
+	events := []parser.Event{
+		{Address: emailAddrA, Transaction: transaction.Transaction{Hash: "0xhash1", Value: transaction.WeiValue(1000000000000000000), Direction: transaction.DirectionIn}},
+		{Address: emailAddrA, Transaction: transaction.Transaction{Hash: "0xhash2", Value: transaction.WeiValue(0), Direction: transaction.DirectionOut}},
+		{Address: emailAddrB, Transaction: transaction.Transaction{Hash: "0xhash3", Value: transaction.WeiValue(0)}},
+		{Address: emailAddrA, Removed: true, Transaction: transaction.Transaction{Hash: "0xhash4", Value: transaction.WeiValue(0), Direction: transaction.DirectionIn}},
+	}
+	for _, ev := range events {
+		if err := n.Notify(context.Background(), ev); err != nil {
+			t.Fatalf("Notify failed: %v", err)
+		}
+	}
+	if len(sent) != 0 {
+		t.Fatalf("Expected no emails before the window ends, got %d", len(sent))
+	}
+	n.Close()
+
+	if len(sent) != 2 {
+		t.Fatalf("Expected 2 emails, got %d", len(sent))
+	}
+	// Recipients are flushed in sorted order.
+	alerts, ops := sent[0], sent[1]
+	if alerts.to[0] != "alerts@example.com" || ops.to[0] != "ops@example.com" {
+		t.Fatalf("Unexpected recipients: %v, %v", alerts.to, ops.to)
+	}
+	if alerts.addr != "smtp.example.com:587" {
+		t.Errorf("Unexpected SMTP address: %s", alerts.addr)
+	}
+	if !strings.Contains(alerts.msg, "Subject: [txparser] 4 new transaction(s) on ethereum") {
+		t.Errorf("Unexpected subject in %q", alerts.msg)
+	}
+	if !strings.Contains(ops.msg, "0xhash1") || !strings.Contains(ops.msg, "0xhash2") || strings.Contains(ops.msg, "0xhash3") {
+		t.Errorf("Unexpected ops body: %q", ops.msg)
+	}
+	if !strings.Contains(ops.msg, "- in "+emailAddrA+": 1 ETH") || !strings.Contains(ops.msg, "- out "+emailAddrA) {
+		t.Errorf("Expected formatted inbound line in %q", ops.msg)
+	}
+	if !strings.Contains(ops.msg, "- REMOVED by a chain reorganization: in "+emailAddrA) {
+		t.Errorf("Expected the removed transaction to be flagged in %q", ops.msg)
+	}
+}
+
+func TestEmailNotifier_CustomTemplate(t *testing.T) {
+	n, err := NewEmailNotifier(EmailOptions{
+		Host:       "smtp.example.com",
+		From:       "txparser@example.com",
+		Recipients: map[string][]string{AnyAddress: {"alerts@example.com"}},
+		Template:   "{{.Recipient}}:{{range .Events}} {{.Transaction.Hash}}{{end}}",
+	})
+	if err != nil {
+		t.Fatalf("NewEmailNotifier failed: %v", err)
+	}
+	var body string
+	n.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		body = string(msg)
+		return nil
+	}
+	if err := n.sendBatch("alerts@example.com", []parser.Event{{Transaction: transaction.Transaction{Hash: "0xabc"}}}); err != nil {
+		t.Fatalf("sendBatch failed: %v", err)
+	}
+	if !strings.HasSuffix(body, "\r\n\r\nalerts@example.com: 0xabc") {
+		t.Errorf("Unexpected body: %q", body)
+	}
+
+	if _, err := NewEmailNotifier(EmailOptions{Host: "h", From: "a@b.c", Recipients: map[string][]string{AnyAddress: {"x@y.z"}}, Template: "{{"}); err == nil {
+		t.Error("Expected error for invalid template")
+	}
+}
+
+func TestEmailNotifier_WindowFlush(t *testing.T) {
+	n, err := NewEmailNotifier(EmailOptions{
+		Host:        "smtp.example.com",
+		From:        "txparser@example.com",
+		Recipients:  map[string][]string{AnyAddress: {"alerts@example.com"}},
+		BatchWindow: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewEmailNotifier failed: %v", err)
+	}
+	sent := make(chan string, 1)
+	n.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sent <- string(msg)
+		return nil
+	}
+
+	n.Notify(context.Background(), parser.Event{Address: emailAddrA, Transaction: transaction.Transaction{Hash: "0xhash1"}})
+	select {
+	case msg := <-sent:
+		if !strings.Contains(msg, "0xhash1") {
+			t.Errorf("Unexpected email: %q", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected batch to be sent after the window")
+	}
+}
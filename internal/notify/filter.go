@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// Filter selects the events a notifier receives. Zero fields match everything.
+type Filter struct {
+	// Addresses limits events to these lowercase addresses.
+	Addresses []string
+	// Direction selects inbound (DirectionIn) or outbound (DirectionOut)
+	// transactions; self-transfers match both, and DirectionSelf matches only
+	// self-transfers.
+	Direction transaction.Direction
+	// MinValue is the smallest transaction value.
+	MinValue transaction.Value
+}
+
+// Match reports whether ev passes the filter.
+func (f Filter) Match(ev parser.Event) bool {
+	if len(f.Addresses) > 0 {
+		found := false
+		for _, a := range f.Addresses {
+			if a == ev.Address {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	switch f.Direction {
+	case transaction.DirectionIn:
+		if !ev.Transaction.Inbound() {
+			return false
+		}
+	case transaction.DirectionOut:
+		if !ev.Transaction.Outbound() {
+			return false
+		}
+	case transaction.DirectionSelf:
+		if ev.Transaction.Direction != transaction.DirectionSelf {
+			return false
+		}
+	}
+	return ev.Transaction.Value.Cmp(f.MinValue) >= 0
+}
+
+// filtered passes only matching events to its notifier.
+type filtered struct {
+	Notifier
+	filter Filter
+}
+
+// WithFilter wraps n so that it only receives events matching f. Closing the
+// result closes n if it implements Closer.
+func WithFilter(n Notifier, f Filter) Notifier {
+	return &filtered{Notifier: n, filter: f}
+}
+
+func (f *filtered) Notify(ctx context.Context, ev parser.Event) error {
+	if !f.filter.Match(ev) {
+		return nil
+	}
+	return f.Notifier.Notify(ctx, ev)
+}
+
+func (f *filtered) Close() error {
+	if c, ok := f.Notifier.(Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
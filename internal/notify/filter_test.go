@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"context"
+	"testing"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+func TestFilter_Match(t *testing.T) {
+	in := parser.Event{Address: "0xaaa", Transaction: transaction.Transaction{Value: transaction.WeiValue(2000), Direction: transaction.DirectionIn}}
+	out := parser.Event{Address: "0xbbb", Transaction: transaction.Transaction{Value: transaction.WeiValue(500), Direction: transaction.DirectionOut}}
+	self := parser.Event{Address: "0xaaa", Transaction: transaction.Transaction{Value: transaction.WeiValue(500), Direction: transaction.DirectionSelf}}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		ev     parser.Event
+		match  bool
+	}{
+		{name: "empty matches all", ev: out, match: true},
+		{name: "address match", filter: Filter{Addresses: []string{"0xaaa"}}, ev: in, match: true},
+		{name: "address mismatch", filter: Filter{Addresses: []string{"0xaaa"}}, ev: out, match: false},
+		{name: "inbound only", filter: Filter{Direction: "in"}, ev: out, match: false},
+		{name: "outbound only", filter: Filter{Direction: "out"}, ev: out, match: true},
+		{name: "self is inbound", filter: Filter{Direction: "in"}, ev: self, match: true},
+		{name: "self is outbound", filter: Filter{Direction: "out"}, ev: self, match: true},
+		{name: "self only", filter: Filter{Direction: "self"}, ev: in, match: false},
+		{name: "above min value", filter: Filter{MinValue: transaction.WeiValue(1000)}, ev: in, match: true},
+		{name: "below min value", filter: Filter{MinValue: transaction.WeiValue(1000)}, ev: out, match: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(tt.ev); got != tt.match {
+				t.Errorf("Expected match=%v, got %v", tt.match, got)
+			}
+		})
+	}
+}
+
+func TestWithFilter(t *testing.T) {
+	inner := &fakeNotifier{}
+	n := WithFilter(inner, Filter{Direction: "in"})
+
+	n.Notify(context.Background(), parser.Event{Address: "0xaaa", Transaction: transaction.Transaction{Direction: transaction.DirectionIn}})
+	n.Notify(context.Background(), parser.Event{Address: "0xaaa"})
+	if len(inner.events) != 1 {
+		t.Errorf("Expected 1 event to pass the filter, got %d", len(inner.events))
+	}
+
+	n.(Closer).Close()
+	if !inner.closed {
+		t.Error("Expected the wrapped notifier to be closed")
+	}
+}
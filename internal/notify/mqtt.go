@@ -0,0 +1,116 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+)
+
+// DefaultMQTTTopic is the topic template used when none is configured.
+const DefaultMQTTTopic = "txparser/{chain}/{address}"
+
+// MQTTOptions configures an MQTTPublisher.
+type MQTTOptions struct {
+	// URL is the broker URL, e.g. tcp://localhost:1883 or ssl://broker:8883.
+	URL string
+	// Chain is used in topics and messages.
+	Chain string
+	// Topic is a template expanded per event; {chain}, {address} and
+	// {direction} ("in", "out" or "self") are replaced. Defaults to
+	// DefaultMQTTTopic.
+	Topic string
+	// QoS is the MQTT quality of service level, 0 to 2.
+	QoS byte
+	// Username and Password authenticate with the broker when set.
+	Username string
+	Password string
+	// ConnectTimeout bounds the initial connection. Defaults to 10s.
+	ConnectTimeout time.Duration
+}
+
+// mqttClient is the part of mqtt.Client the publisher uses.
+type mqttClient interface {
+	Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token
+	Disconnect(quiesce uint)
+}
+
+// MQTTPublisher publishes events to an MQTT broker so that lightweight edge
+// devices and home-automation setups can react to payments.
+type MQTTPublisher struct {
+	opts   MQTTOptions
+	client mqttClient
+}
+
+// NewMQTTPublisher connects to the broker described by opts. The client
+// reconnects automatically if the connection drops later.
+func NewMQTTPublisher(opts MQTTOptions) (*MQTTPublisher, error) {
+	if opts.QoS > 2 {
+		return nil, fmt.Errorf("invalid MQTT QoS %d", opts.QoS)
+	}
+	if opts.ConnectTimeout <= 0 {
+		opts.ConnectTimeout = 10 * time.Second
+	}
+	co := mqtt.NewClientOptions().
+		AddBroker(opts.URL).
+		SetClientID(fmt.Sprintf("txparser-%s-%d", opts.Chain, time.Now().UnixNano())).
+		SetUsername(opts.Username).
+		SetPassword(opts.Password).
+		SetAutoReconnect(true).
+		SetConnectTimeout(opts.ConnectTimeout)
+	client := mqtt.NewClient(co)
+	token := client.Connect()
+	if !token.WaitTimeout(opts.ConnectTimeout) {
+		// Stop the connection attempt still running in the background.
+		client.Disconnect(0)
+		return nil, fmt.Errorf("timed out connecting to MQTT broker %s", opts.URL)
+	}
+	if err := token.Error(); err != nil {
+		return nil, fmt.Errorf("failed to connect to MQTT broker: %w", err)
+	}
+	return newMQTTPublisher(opts, client), nil
+}
+
+func newMQTTPublisher(opts MQTTOptions, client mqttClient) *MQTTPublisher {
+	if opts.Topic == "" {
+		opts.Topic = DefaultMQTTTopic
+	}
+	return &MQTTPublisher{opts: opts, client: client}
+}
+
+// Topic returns the topic ev is published on.
+func (p *MQTTPublisher) Topic(ev parser.Event) string {
+	return strings.NewReplacer(
+		"{chain}", p.opts.Chain,
+		"{address}", ev.Address,
+		"{direction}", string(ev.Transaction.Direction),
+	).Replace(p.opts.Topic)
+}
+
+// Notify publishes a single event and, for QoS 1 and 2, waits for the
+// broker's acknowledgement or ctx to be cancelled.
+func (p *MQTTPublisher) Notify(ctx context.Context, ev parser.Event) error {
+	data, err := json.Marshal(newMessage(p.opts.Chain, ev))
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	token := p.client.Publish(p.Topic(ev), p.opts.QoS, false, data)
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close disconnects from the broker, giving in-flight messages a moment to
+// complete.
+func (p *MQTTPublisher) Close() error {
+	p.client.Disconnect(250)
+	return nil
+}
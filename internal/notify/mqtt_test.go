@@ -0,0 +1,119 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// fakeToken is a completed publish token.
+type fakeToken struct {
+	err error
+}
+
+func (t fakeToken) Wait() bool                     { return true }
+func (t fakeToken) WaitTimeout(time.Duration) bool { return true }
+func (t fakeToken) Error() error                   { return t.err }
+
+func (t fakeToken) Done() <-chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}
+
+// publishedMQTT is a message recorded by fakeMQTTClient.
+type publishedMQTT struct {
+	topic   string
+	qos     byte
+	payload []byte
+}
+
+type fakeMQTTClient struct {
+	msgs         []publishedMQTT
+	err          error
+	disconnected bool
+}
+
+func (c *fakeMQTTClient) Publish(topic string, qos byte, _ bool, payload interface{}) mqtt.Token {
+	c.msgs = append(c.msgs, publishedMQTT{topic: topic, qos: qos, payload: payload.([]byte)})
+	return fakeToken{err: c.err}
+}
+
+func (c *fakeMQTTClient) Disconnect(uint) {
+	c.disconnected = true
+}
+
+func TestMQTTPublisher_Topic(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		dir      transaction.Direction
+		expected string
+	}{
+		{name: "default", expected: "txparser/ethereum/0xaaa"},
+		{name: "direction", template: "home/{chain}/{address}/{direction}", dir: transaction.DirectionIn, expected: "home/ethereum/0xaaa/in"},
+		{name: "outbound", template: "{direction}/{address}", dir: transaction.DirectionOut, expected: "out/0xaaa"},
+		{name: "self", template: "{direction}/{address}", dir: transaction.DirectionSelf, expected: "self/0xaaa"},
+		{name: "static", template: "payments", expected: "payments"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newMQTTPublisher(MQTTOptions{Chain: "ethereum", Topic: tt.template}, &fakeMQTTClient{})
+			got := p.Topic(parser.Event{Address: "0xaaa", Transaction: transaction.Transaction{Direction: tt.dir}})
+			if got != tt.expected {
+				t.Errorf("Expected topic %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestMQTTPublisher_Notify(t *testing.T) {
+	client := &fakeMQTTClient{}
+	p := newMQTTPublisher(MQTTOptions{Chain: "ethereum", QoS: 1}, client)
+
+	ev := parser.Event{
+		Address:     "0xaaa",
+		Transaction: transaction.Transaction{Hash: "0xhash1", Value: transaction.WeiValue(10), Direction: transaction.DirectionIn},
+	}
+	if err := p.Notify(context.Background(), ev); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if len(client.msgs) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(client.msgs))
+	}
+	msg := client.msgs[0]
+	if msg.topic != "txparser/ethereum/0xaaa" || msg.qos != 1 {
+		t.Errorf("Unexpected topic or QoS: %s %d", msg.topic, msg.qos)
+	}
+	var body Message
+	if err := json.Unmarshal(msg.payload, &body); err != nil {
+		t.Fatalf("Failed to decode message: %v", err)
+	}
+	if body.Chain != "ethereum" || body.Transaction.Hash != "0xhash1" {
+		t.Errorf("Unexpected message: %+v", body)
+	}
+
+	client.err = errors.New("not connected")
+	if err := p.Notify(context.Background(), ev); err == nil {
+		t.Error("Expected publish error to be returned")
+	}
+
+	p.Close()
+	if !client.disconnected {
+		t.Error("Expected client to be disconnected")
+	}
+}
+
+func TestNewMQTTPublisher_InvalidQoS(t *testing.T) {
+	if _, err := NewMQTTPublisher(MQTTOptions{URL: "tcp://localhost:1883", QoS: 3}); err == nil {
+		t.Error("Expected error for QoS 3")
+	}
+}
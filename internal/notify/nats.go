@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// Message is the JSON body published for each transaction, and for each
+// one a chain reorganization removed.
+type Message struct {
+	Chain       string                  `json:"chain"`
+	Address     string                  `json:"address"`
+	Transaction transaction.Transaction `json:"transaction"`
+	Removed     bool                    `json:"removed,omitempty"`
+	Reorg       *parser.Reorg           `json:"reorg,omitempty"`
+}
+
+// newMessage returns the message announcing ev on chain.
+func newMessage(chain string, ev parser.Event) Message {
+	return Message{Chain: chain, Address: ev.Address, Transaction: ev.Transaction, Removed: ev.Removed, Reorg: ev.Reorg}
+}
+
+// NATSOptions configures a NATSPublisher.
+type NATSOptions struct {
+	// URL is the NATS server URL, e.g. nats://localhost:4222.
+	URL string
+	// Chain is used in subjects and messages.
+	Chain string
+	// SubjectPrefix starts every subject. Defaults to "txs".
+	SubjectPrefix string
+	// JetStream publishes through JetStream and waits for the stream's ack,
+	// instead of fire-and-forget core NATS publishes.
+	JetStream bool
+}
+
+// msgPublisher abstracts core NATS and JetStream publishing.
+type msgPublisher interface {
+	publish(ctx context.Context, msg *nats.Msg) error
+}
+
+type corePublisher struct{ conn *nats.Conn }
+
+func (p corePublisher) publish(_ context.Context, msg *nats.Msg) error {
+	return p.conn.PublishMsg(msg)
+}
+
+type jetStreamPublisher struct{ js jetstream.JetStream }
+
+func (p jetStreamPublisher) publish(ctx context.Context, msg *nats.Msg) error {
+	_, err := p.js.PublishMsg(ctx, msg)
+	return err
+}
+
+// NATSPublisher publishes events on per-address subjects of the form
+// <prefix>.<chain>.<address>, e.g. txs.ethereum.0xabc..., so consumers can
+// subscribe to one address or wildcard a whole chain (txs.ethereum.>).
+type NATSPublisher struct {
+	opts NATSOptions
+	conn *nats.Conn
+	pub  msgPublisher
+}
+
+// NewNATSPublisher connects to the NATS server described by opts.
+func NewNATSPublisher(opts NATSOptions) (*NATSPublisher, error) {
+	if opts.SubjectPrefix == "" {
+		opts.SubjectPrefix = "txs"
+	}
+	conn, err := nats.Connect(opts.URL, nats.Name("txparser"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	var pub msgPublisher = corePublisher{conn: conn}
+	if opts.JetStream {
+		js, err := jetstream.New(conn)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+		}
+		pub = jetStreamPublisher{js: js}
+	}
+	return newNATSPublisher(opts, conn, pub), nil
+}
+
+func newNATSPublisher(opts NATSOptions, conn *nats.Conn, pub msgPublisher) *NATSPublisher {
+	return &NATSPublisher{opts: opts, conn: conn, pub: pub}
+}
+
+// Subject returns the subject events for addr are published on.
+func (p *NATSPublisher) Subject(addr string) string {
+	return strings.Join([]string{p.opts.SubjectPrefix, p.opts.Chain, addr}, ".")
+}
+
+// Notify publishes a single event. The Nats-Msg-Id header lets JetStream
+// streams deduplicate re-indexed transactions.
+func (p *NATSPublisher) Notify(ctx context.Context, ev parser.Event) error {
+	data, err := json.Marshal(newMessage(p.opts.Chain, ev))
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+	msg := nats.NewMsg(p.Subject(ev.Address))
+	msg.Data = data
+	msg.Header.Set(jetstream.MsgIDHeader, messageID(p.opts.Chain, ev))
+	return p.pub.publish(ctx, msg)
+}
+
+// Close flushes buffered messages and closes the connection.
+func (p *NATSPublisher) Close() error {
+	if p.conn == nil {
+		return nil
+	}
+	defer p.conn.Close()
+	if err := p.conn.Flush(); err != nil {
+		return fmt.Errorf("failed to flush NATS connection: %w", err)
+	}
+	return nil
+}
+
+// messageID identifies an event uniquely per chain by its idempotency key.
+func messageID(chain string, ev parser.Event) string {
+	return chain + ":" + ev.IdempotencyKey()
+}
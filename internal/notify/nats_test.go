@@ -0,0 +1,85 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// fakePublisher records published messages.
+type fakePublisher struct {
+	msgs []*nats.Msg
+	err  error
+}
+
+func (f *fakePublisher) publish(_ context.Context, msg *nats.Msg) error {
+	f.msgs = append(f.msgs, msg)
+	return f.err
+}
+
+func TestNATSPublisher_Notify(t *testing.T) {
+	fake := &fakePublisher{}
+	p := newNATSPublisher(NATSOptions{Chain: "ethereum", SubjectPrefix: "txs"}, nil, fake)
+
+	ev := parser.Event{
+		Address:     "0xaaa",
+		Transaction: transaction.Transaction{Hash: "0xhash1", From: "0xbbb", To: "0xaaa", Value: transaction.WeiValue(10), Block: 5, Direction: transaction.DirectionIn},
+	}
+	if err := p.Notify(context.Background(), ev); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if len(fake.msgs) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(fake.msgs))
+	}
+	msg := fake.msgs[0]
+	if msg.Subject != "txs.ethereum.0xaaa" {
+		t.Errorf("Unexpected subject: %s", msg.Subject)
+	}
+	if id := msg.Header.Get(jetstream.MsgIDHeader); id != "ethereum:0xaaa:0xhash1:in" {
+		t.Errorf("Unexpected message ID: %s", id)
+	}
+	var body Message
+	if err := json.Unmarshal(msg.Data, &body); err != nil {
+		t.Fatalf("Failed to decode message: %v", err)
+	}
+	if body.Chain != "ethereum" || body.Address != "0xaaa" || body.Transaction.Hash != "0xhash1" || body.Removed {
+		t.Errorf("Unexpected message: %+v", body)
+	}
+
+	// A removal is published with the reorg under an ID of its own.
+	ev.Removed = true
+	ev.Reorg = &parser.Reorg{Depth: 1, Blocks: []parser.BlockRef{{Number: 5, Hash: "0xb5"}, {Number: 6, Hash: "0xb6"}}}
+	if err := p.Notify(context.Background(), ev); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	msg = fake.msgs[1]
+	if id := msg.Header.Get(jetstream.MsgIDHeader); id != "ethereum:0xaaa:0xhash1:in:removed:5" {
+		t.Errorf("Unexpected message ID: %s", id)
+	}
+	body = Message{}
+	if err := json.Unmarshal(msg.Data, &body); err != nil {
+		t.Fatalf("Failed to decode message: %v", err)
+	}
+	if !body.Removed || body.Reorg == nil || body.Reorg.Depth != 1 || len(body.Reorg.Blocks) != 2 {
+		t.Errorf("Expected the removal with its reorg, got %+v", body)
+	}
+}
+
+func TestNATSPublisher_NotifyError(t *testing.T) {
+	fake := &fakePublisher{err: errors.New("no responders")}
+	p := newNATSPublisher(NATSOptions{Chain: "sepolia", SubjectPrefix: "txs"}, nil, fake)
+
+	if err := p.Notify(context.Background(), parser.Event{Address: "0xbbb"}); err == nil {
+		t.Error("Expected publish error to be returned")
+	}
+	if fake.msgs[0].Subject != "txs.sepolia.0xbbb" {
+		t.Errorf("Unexpected subject: %s", fake.msgs[0].Subject)
+	}
+}
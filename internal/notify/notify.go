@@ -0,0 +1,244 @@
+// Package notify pushes indexed transactions to external systems such as
+// message brokers, chat services and email.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/internal/outbox"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+)
+
+// sinkQueueSize is the per-sink buffer. A sink that falls further behind
+// misses events instead of delaying the others, unless an outbox is used.
+const sinkQueueSize = 256
+
+// Notifier delivers a single transaction event to an external system.
+type Notifier interface {
+	Notify(ctx context.Context, ev parser.Event) error
+}
+
+// Closer is implemented by notifiers that hold connections or buffered state.
+// Close is called once the dispatcher stops feeding the notifier.
+type Closer interface {
+	Close() error
+}
+
+// SinkStats reports delivery counters for one sink.
+type SinkStats struct {
+	Name string `json:"name"`
+	// Delivered counts events the notifier handled without error, including
+	// events it chose to ignore (e.g. below a value threshold).
+	Delivered uint64 `json:"delivered"`
+	Failed    uint64 `json:"failed"`
+	Dropped   uint64 `json:"dropped"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// queued is an event waiting in a sink's queue, with the ID of its outbox
+// entry, if any.
+type queued struct {
+	id string
+	ev parser.Event
+}
+
+// sink is a registered notifier with its queue and counters.
+type sink struct {
+	name      string
+	notifier  Notifier
+	queue     chan queued
+	delivered atomic.Uint64
+	failed    atomic.Uint64
+	dropped   atomic.Uint64
+	lastErr   atomic.Value // string
+}
+
+// Dispatcher fans each event out to every registered notifier. Each sink runs
+// in its own goroutine with its own queue, so a failing or panicking sink
+// does not affect the others, nor does a slow one without an outbox.
+type Dispatcher struct {
+	sinks  []*sink
+	outbox outbox.ChainOutbox
+	logger *slog.Logger
+}
+
+// NewDispatcher creates a Dispatcher without sinks.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{logger: logging.Component("notify")}
+}
+
+// Add registers n under name. It must be called before Run.
+func (d *Dispatcher) Add(name string, n Notifier) {
+	d.sinks = append(d.sinks, &sink{name: name, notifier: n, queue: make(chan queued, sinkQueueSize)})
+}
+
+// UseOutbox records each event handed to a sink in o until the sink has
+// handled it, so that events still queued or in flight when the process
+// stops are delivered again by the next Run. Events are then never dropped:
+// a sink that falls behind holds up the others instead. It must be called
+// before Run.
+func (d *Dispatcher) UseOutbox(o outbox.ChainOutbox) {
+	d.outbox = o
+}
+
+// Len returns the number of registered sinks.
+func (d *Dispatcher) Len() int {
+	return len(d.sinks)
+}
+
+// Stats returns the delivery counters of every sink in registration order.
+func (d *Dispatcher) Stats() []SinkStats {
+	out := make([]SinkStats, 0, len(d.sinks))
+	for _, s := range d.sinks {
+		st := SinkStats{
+			Name:      s.name,
+			Delivered: s.delivered.Load(),
+			Failed:    s.failed.Load(),
+			Dropped:   s.dropped.Load(),
+		}
+		st.LastError, _ = s.lastErr.Load().(string)
+		out = append(out, st)
+	}
+	return out
+}
+
+// Run fans events out until ctx is cancelled or events is closed. It returns
+// once every sink has drained its queue and been closed. With an outbox,
+// each sink first delivers the events left pending by an earlier run.
+func (d *Dispatcher) Run(ctx context.Context, events <-chan parser.Event) {
+	resumed := make(map[string][]queued)
+	for _, e := range d.outbox.Pending() {
+		resumed[e.Sink] = append(resumed[e.Sink], queued{id: e.ID, ev: e.Event})
+	}
+	var wg sync.WaitGroup
+	for _, s := range d.sinks {
+		wg.Add(1)
+		go func(s *sink, pending []queued) {
+			defer wg.Done()
+			d.runSink(ctx, s, pending)
+		}(s, resumed[s.name])
+		delete(resumed, s.name)
+	}
+	for name, pending := range resumed {
+		// The sink was removed from the configuration.
+		d.logger.Warn("dropping outbox entries of unknown sink", "sink", name, "count", len(pending))
+		for _, q := range pending {
+			d.settle(q.id)
+		}
+	}
+	defer wg.Wait()
+	defer func() {
+		for _, s := range d.sinks {
+			close(s.queue)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			if !d.fanOut(ctx, ev) {
+				return
+			}
+		}
+	}
+}
+
+// fanOut queues ev for every sink and reports whether ctx is still live.
+// Without an outbox, sinks whose queue is full miss ev. With one, ev is
+// recorded for every sink first, and fanOut waits for room in their queues,
+// so that it is either delivered or left pending for the next Run.
+func (d *Dispatcher) fanOut(ctx context.Context, ev parser.Event) bool {
+	ids := make([]string, len(d.sinks))
+	for i, s := range d.sinks {
+		id, err := d.outbox.Add(s.name, "", ev)
+		if err != nil {
+			d.logger.Error("failed to record event in outbox", "sink", s.name, logging.KeyError, err)
+		}
+		ids[i] = id
+	}
+	for i, s := range d.sinks {
+		q := queued{id: ids[i], ev: ev}
+		if d.outbox.Enabled() {
+			select {
+			case s.queue <- q:
+			case <-ctx.Done():
+				return false
+			}
+			continue
+		}
+		select {
+		case s.queue <- q:
+		default:
+			s.dropped.Add(1)
+			d.logger.Warn("sink queue full, dropping event", "sink", s.name, logging.KeyAddress, ev.Address)
+		}
+	}
+	return true
+}
+
+// runSink delivers the resumed events and then the queued ones to one sink,
+// and closes it afterwards.
+func (d *Dispatcher) runSink(ctx context.Context, s *sink, resumed []queued) {
+	logger := d.logger.With("sink", s.name)
+	if len(resumed) > 0 {
+		logger.Info("resuming deliveries from outbox", "count", len(resumed))
+	}
+	deliver := func(q queued) {
+		err := d.notify(ctx, s, q.ev)
+		if err != nil && ctx.Err() != nil {
+			// Interrupted by shutdown; the outbox entry is left for the
+			// next run.
+			return
+		}
+		d.settle(q.id)
+		if err != nil {
+			s.failed.Add(1)
+			s.lastErr.Store(err.Error())
+			logger.Error("notification failed",
+				logging.KeyAddress, q.ev.Address,
+				logging.KeyBlock, q.ev.Transaction.Block,
+				logging.KeyError, err,
+			)
+			return
+		}
+		s.delivered.Add(1)
+	}
+	for _, q := range resumed {
+		deliver(q)
+	}
+	for q := range s.queue {
+		deliver(q)
+	}
+	if c, ok := s.notifier.(Closer); ok {
+		if err := c.Close(); err != nil {
+			logger.Error("failed to close sink", logging.KeyError, err)
+		}
+	}
+}
+
+// settle marks the outbox entry id as handled.
+func (d *Dispatcher) settle(id string) {
+	if err := d.outbox.Done(id); err != nil {
+		d.logger.Error("failed to update outbox", logging.KeyError, err)
+	}
+}
+
+// notify calls the sink, converting a panic into an error.
+func (d *Dispatcher) notify(ctx context.Context, s *sink, ev parser.Event) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("notifier panicked: %v", rec)
+		}
+	}()
+	return s.notifier.Notify(ctx, ev)
+}
@@ -0,0 +1,218 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/outbox"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// fakeNotifier records events and can be told to fail or panic.
+type fakeNotifier struct {
+	mu     sync.Mutex
+	events []parser.Event
+	err    error
+	panics bool
+	closed bool
+}
+
+func (f *fakeNotifier) Notify(_ context.Context, ev parser.Event) error {
+	if f.panics {
+		panic("boom")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, ev)
+	return f.err
+}
+
+func (f *fakeNotifier) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return nil
+}
+
+func TestDispatcher_FanOut(t *testing.T) {
+	healthy := &fakeNotifier{}
+	failing := &fakeNotifier{err: errors.New("unavailable")}
+	panicking := &fakeNotifier{panics: true}
+
+	d := NewDispatcher()
+	d.Add("healthy", healthy)
+	d.Add("failing", failing)
+	d.Add("panicking", panicking)
+	if d.Len() != 3 {
+		t.Fatalf("Expected 3 sinks, got %d", d.Len())
+	}
+
+	events := make(chan parser.Event, 2)
+	events <- parser.Event{Address: "0xaaa"}
+	events <- parser.Event{Address: "0xbbb"}
+	close(events)
+	d.Run(context.Background(), events)
+
+	if len(healthy.events) != 2 || len(failing.events) != 2 {
+		t.Errorf("Expected every sink to receive both events, got %d and %d", len(healthy.events), len(failing.events))
+	}
+	if !healthy.closed || !failing.closed || !panicking.closed {
+		t.Error("Expected every sink to be closed")
+	}
+
+	stats := d.Stats()
+	want := []SinkStats{
+		{Name: "healthy", Delivered: 2},
+		{Name: "failing", Failed: 2, LastError: "unavailable"},
+		{Name: "panicking", Failed: 2, LastError: "notifier panicked: boom"},
+	}
+	for i, w := range want {
+		if stats[i] != w {
+			t.Errorf("Unexpected stats for %s: %+v", w.Name, stats[i])
+		}
+	}
+}
+
+// blockingNotifier blocks until released so that its queue fills up.
+type blockingNotifier struct {
+	release chan struct{}
+}
+
+func (b *blockingNotifier) Notify(context.Context, parser.Event) error {
+	<-b.release
+	return nil
+}
+
+func TestDispatcher_SlowSinkIsolated(t *testing.T) {
+	slow := &blockingNotifier{release: make(chan struct{})}
+	fast := &fakeNotifier{}
+
+	d := NewDispatcher()
+	d.Add("slow", slow)
+	d.Add("fast", fast)
+
+	n := sinkQueueSize + 10
+	events := make(chan parser.Event, n)
+	for i := 0; i < n; i++ {
+		events <- parser.Event{Address: "0xaaa"}
+	}
+	close(events)
+
+	done := make(chan struct{})
+	go func() {
+		d.Run(context.Background(), events)
+		close(done)
+	}()
+
+	// Run keeps fanning out while the slow sink is stuck, and waits for it.
+	select {
+	case <-done:
+		t.Fatal("Run returned before the slow sink drained")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(slow.release)
+	<-done
+
+	stats := d.Stats()
+	if stats[0].Dropped == 0 {
+		t.Error("Expected the slow sink to drop events")
+	}
+	if got := stats[1].Delivered + stats[1].Dropped; got != uint64(n) {
+		t.Errorf("Expected the fast sink to account for %d events, got %d", n, got)
+	}
+}
+
+func TestDispatcher_Outbox(t *testing.T) {
+	box := outbox.New()
+	eth := box.Chain("ethereum")
+	// Left pending by an earlier run.
+	eth.Add("healthy", "", parser.Event{Address: "0xaaa"})
+	eth.Add("removed", "", parser.Event{Address: "0xaaa"})
+
+	healthy := &fakeNotifier{}
+	d := NewDispatcher()
+	d.Add("healthy", healthy)
+	d.UseOutbox(eth)
+	events := make(chan parser.Event, 1)
+	events <- parser.Event{Address: "0xbbb"}
+	close(events)
+	d.Run(context.Background(), events)
+
+	if len(healthy.events) != 2 || healthy.events[0].Address != "0xaaa" || healthy.events[1].Address != "0xbbb" {
+		t.Errorf("Expected the resumed event before the new one, got %+v", healthy.events)
+	}
+	if got := box.Len(); got != 0 {
+		t.Errorf("Expected every delivery to be settled, got %d pending", got)
+	}
+
+	// A delivery failing because of shutdown stays pending.
+	eth.Add("failing", "", parser.Event{Address: "0xccc"})
+	d = NewDispatcher()
+	d.Add("failing", &fakeNotifier{err: context.Canceled})
+	d.UseOutbox(eth)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	d.Run(ctx, make(chan parser.Event))
+	if got := box.Len(); got != 1 {
+		t.Errorf("Expected the interrupted delivery to stay pending, got %d", got)
+	}
+}
+
+func TestDispatcher_OutboxFullQueue(t *testing.T) {
+	box := outbox.New()
+	eth := box.Chain("ethereum")
+	slow := &blockingNotifier{release: make(chan struct{})}
+	d := NewDispatcher()
+	d.Add("slow", slow)
+	d.UseOutbox(eth)
+
+	n := sinkQueueSize + 10
+	events := make(chan parser.Event, n)
+	for i := 0; i < n; i++ {
+		events <- parser.Event{Address: "0xaaa", Transaction: transaction.Transaction{Block: i}}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx, events)
+		close(done)
+	}()
+
+	// One event in flight and a full queue: the next one waits in the
+	// outbox instead of being dropped.
+	deadline := time.Now().Add(2 * time.Second)
+	for box.Len() < sinkQueueSize+2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the queue to fill up, got %d pending", box.Len())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	close(slow.release)
+	<-done
+	if got := d.Stats()[0]; got.Dropped != 0 || got.Delivered != sinkQueueSize+1 {
+		t.Errorf("Expected the queued events to be delivered and none dropped, got %+v", got)
+	}
+	if got := box.Len(); got != 1 {
+		t.Fatalf("Expected the event that didn't fit to stay pending, got %d", got)
+	}
+
+	// It is delivered after a restart.
+	resumed := &fakeNotifier{}
+	d = NewDispatcher()
+	d.Add("slow", resumed)
+	d.UseOutbox(eth)
+	closed := make(chan parser.Event)
+	close(closed)
+	d.Run(context.Background(), closed)
+	if len(resumed.events) != 1 || resumed.events[0].Transaction.Block != sinkQueueSize+1 {
+		t.Errorf("Expected the pending event to be delivered, got %+v", resumed.events)
+	}
+	if got := box.Len(); got != 0 {
+		t.Errorf("Expected every delivery to be settled, got %d pending", got)
+	}
+}
@@ -0,0 +1,318 @@
+// Package outbox persists the events handed to notification sinks and
+// webhooks until their delivery is settled, so that deliveries still in
+// flight when the process stops, crashes included, are resumed when it
+// restarts. This turns best-effort delivery into at-least-once delivery.
+package outbox
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+)
+
+// compactAfter is the number of journal records below which the file is
+// never compacted.
+const compactAfter = 1024
+
+// Entry is an event whose delivery to one destination isn't settled yet.
+type Entry struct {
+	ID    string `json:"id"`
+	Chain string `json:"chain,omitempty"`
+	// Sink names the destination: a notification sink, or "webhook:<id>"
+	// for a webhook.
+	Sink string `json:"sink"`
+	// Target identifies the destination in a way that survives restarts
+	// when Sink may not, such as a webhook's URL.
+	Target    string       `json:"target,omitempty"`
+	Event     parser.Event `json:"event"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// record is a line of the journal: an added entry, or the ID of an entry
+// whose delivery was settled.
+type record struct {
+	Add  *Entry `json:"add,omitempty"`
+	Done string `json:"done,omitempty"`
+}
+
+// Outbox is a thread-safe set of unsettled deliveries. When opened from a
+// file, every change is appended to it as a JSON line, and the file is
+// rewritten with only the unsettled entries once settled ones make up most
+// of it.
+type Outbox struct {
+	mu      sync.Mutex
+	pending map[string]Entry
+	order   []string // IDs in the order they were added; may hold settled ones
+	file    *os.File // nil for in-memory outboxes
+	path    string
+	records int // lines in the file
+	now     func() time.Time
+}
+
+// New creates an in-memory Outbox.
+func New() *Outbox {
+	return &Outbox{pending: make(map[string]Entry), now: time.Now}
+}
+
+// Open creates an Outbox persisted to path, loading the entries still
+// unsettled in it. A line cut short by a crash at the end of the file is
+// ignored.
+func Open(path string) (*Outbox, error) {
+	o := New()
+	o.path = path
+	f, err := os.OpenFile(path, os.O_RDONLY|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open outbox: %w", err)
+	}
+	err = o.load(f)
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read outbox %s: %w", path, err)
+	}
+	if err := o.compact(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// load replays the journal in r.
+func (o *Outbox) load(r io.Reader) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	var bad error
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var rec record
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			bad = fmt.Errorf("line %d: %w", line, err)
+			continue
+		}
+		if bad != nil {
+			// Only the last line can have been cut short.
+			return bad
+		}
+		switch {
+		case rec.Add != nil:
+			o.add(*rec.Add)
+		case rec.Done != "":
+			delete(o.pending, rec.Done)
+		}
+	}
+	return sc.Err()
+}
+
+// Add records that ev is to be delivered to sink of chain and returns the
+// ID of the entry, to be passed to Done once the delivery is settled. The
+// entry is kept in memory even if writing it to the file fails.
+func (o *Outbox) Add(chain, sink, target string, ev parser.Event) (string, error) {
+	id, err := newID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate outbox ID: %w", err)
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	e := Entry{ID: id, Chain: chain, Sink: sink, Target: target, Event: ev, CreatedAt: o.now().UTC()}
+	o.add(e)
+	return id, o.write(record{Add: &e})
+}
+
+// add tracks e. o.mu must be held.
+func (o *Outbox) add(e Entry) {
+	o.pending[e.ID] = e
+	o.order = append(o.order, e.ID)
+}
+
+// Done records that the delivery of entry id was settled, successfully or
+// not, so it won't be resumed. Unknown IDs are ignored.
+func (o *Outbox) Done(id string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, ok := o.pending[id]; !ok {
+		return nil
+	}
+	delete(o.pending, id)
+	if len(o.order) > 2*len(o.pending)+64 {
+		o.order = o.pendingIDs()
+	}
+	if err := o.write(record{Done: id}); err != nil {
+		return err
+	}
+	if o.records > compactAfter && o.records > 4*len(o.pending) {
+		return o.compact()
+	}
+	return nil
+}
+
+// Pending returns the unsettled entries of chain in the order they were
+// added, or of every chain if chain is empty.
+func (o *Outbox) Pending(chain string) []Entry {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	out := []Entry{}
+	for _, id := range o.order {
+		if e, ok := o.pending[id]; ok && (chain == "" || e.Chain == chain) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Len returns the number of unsettled entries.
+func (o *Outbox) Len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.pending)
+}
+
+// pendingIDs returns the IDs of the unsettled entries in the order they
+// were added. o.mu must be held.
+func (o *Outbox) pendingIDs() []string {
+	ids := make([]string, 0, len(o.pending))
+	for _, id := range o.order {
+		if _, ok := o.pending[id]; ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// write appends rec to the file, if any. o.mu must be held.
+func (o *Outbox) write(rec record) error {
+	if o.file == nil {
+		return nil
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox record: %w", err)
+	}
+	if _, err := o.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write outbox: %w", err)
+	}
+	o.records++
+	return nil
+}
+
+// compact replaces the file with one holding only the unsettled entries
+// and reopens it for appending. o.mu must be held, unless o isn't shared
+// yet.
+func (o *Outbox) compact() error {
+	o.order = o.pendingIDs()
+	tmp, err := os.CreateTemp(filepath.Dir(o.path), filepath.Base(o.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to compact outbox: %w", err)
+	}
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, id := range o.order {
+		e := o.pending[id]
+		if err = enc.Encode(record{Add: &e}); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), o.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to compact outbox: %w", err)
+	}
+
+	f, err := os.OpenFile(o.path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to reopen outbox: %w", err)
+	}
+	if o.file != nil {
+		o.file.Close()
+	}
+	o.file = f
+	o.records = len(o.order)
+	return nil
+}
+
+// Close syncs and closes the backing file, if any. Entries still pending
+// are resumed when the file is opened again.
+func (o *Outbox) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.file == nil {
+		return nil
+	}
+	err := o.file.Sync()
+	if cerr := o.file.Close(); err == nil {
+		err = cerr
+	}
+	o.file = nil
+	return err
+}
+
+// Chain binds o to chain, for the dispatchers of one chain. A nil o gives
+// a disabled ChainOutbox.
+func (o *Outbox) Chain(chain string) ChainOutbox {
+	return ChainOutbox{outbox: o, chain: chain}
+}
+
+// ChainOutbox records the deliveries of one chain. Its zero value is
+// disabled: Add returns no ID and the other methods do nothing.
+type ChainOutbox struct {
+	outbox *Outbox
+	chain  string
+}
+
+// Enabled reports whether deliveries are recorded.
+func (c ChainOutbox) Enabled() bool {
+	return c.outbox != nil
+}
+
+// Add records that ev is to be delivered to sink, see Outbox.Add.
+func (c ChainOutbox) Add(sink, target string, ev parser.Event) (string, error) {
+	if c.outbox == nil {
+		return "", nil
+	}
+	return c.outbox.Add(c.chain, sink, target, ev)
+}
+
+// Done records that the delivery of entry id was settled. An empty id is
+// ignored.
+func (c ChainOutbox) Done(id string) error {
+	if c.outbox == nil || id == "" {
+		return nil
+	}
+	return c.outbox.Done(id)
+}
+
+// Pending returns the chain's unsettled entries in the order they were
+// added.
+func (c ChainOutbox) Pending() []Entry {
+	if c.outbox == nil {
+		return nil
+	}
+	return c.outbox.Pending(c.chain)
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
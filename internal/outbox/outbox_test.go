@@ -0,0 +1,114 @@
+package outbox
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+func event(hash string) parser.Event {
+	return parser.Event{Address: "0xaaa", Transaction: transaction.Transaction{Hash: hash}}
+}
+
+func TestOutbox_Persisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+	o, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	eth := o.Chain("ethereum")
+	first, _ := eth.Add("nats", "", event("0x1"))
+	second, _ := eth.Add("webhook:abc", "https://example.com/hook", event("0x2"))
+	if _, err := o.Chain("sepolia").Add("nats", "", event("0x3")); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := eth.Done(first); err != nil {
+		t.Fatalf("Done failed: %v", err)
+	}
+	if err := o.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	defer reopened.Close()
+	pending := reopened.Chain("ethereum").Pending()
+	if len(pending) != 1 || pending[0].ID != second || pending[0].Sink != "webhook:abc" ||
+		pending[0].Target != "https://example.com/hook" || pending[0].Event.Transaction.Hash != "0x2" {
+		t.Errorf("Expected only the unsettled delivery of the chain, got %+v", pending)
+	}
+	if got := reopened.Len(); got != 2 {
+		t.Errorf("Expected 2 pending entries, got %d", got)
+	}
+	// Opening compacts the journal down to the pending entries.
+	data, _ := os.ReadFile(path)
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("Expected a compacted file of 2 lines, got %d", lines)
+	}
+}
+
+func TestOpen_TruncatedLastLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+	o, _ := Open(path)
+	o.Add("ethereum", "nats", "", event("0x1"))
+	o.Close()
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	f.WriteString(`{"add":{"id":"cut`)
+	f.Close()
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Expected a line cut short by a crash to be ignored, got %v", err)
+	}
+	defer reopened.Close()
+	if got := reopened.Len(); got != 1 {
+		t.Errorf("Expected 1 pending entry, got %d", got)
+	}
+
+	// Corruption before the end isn't a crash artifact.
+	os.WriteFile(path, []byte("nope\n"+`{"done":"x"}`+"\n"), 0o600)
+	if _, err := Open(path); err == nil {
+		t.Error("Expected an error for a corrupt line followed by valid ones")
+	}
+}
+
+func TestOutbox_Compacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+	o, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer o.Close()
+	kept, _ := o.Add("ethereum", "nats", "", event("0xkept"))
+	for range compactAfter {
+		id, _ := o.Add("ethereum", "nats", "", event("0x1"))
+		o.Done(id)
+	}
+
+	data, _ := os.ReadFile(path)
+	if lines := strings.Count(string(data), "\n"); lines > compactAfter {
+		t.Errorf("Expected the file to be compacted, got %d lines", lines)
+	}
+	if pending := o.Pending(""); len(pending) != 1 || pending[0].ID != kept {
+		t.Errorf("Expected the unsettled entry to survive compaction, got %+v", pending)
+	}
+}
+
+func TestChainOutbox_Disabled(t *testing.T) {
+	var c ChainOutbox
+	if c.Enabled() {
+		t.Error("Expected the zero ChainOutbox to be disabled")
+	}
+	if id, err := c.Add("nats", "", event("0x1")); id != "" || err != nil {
+		t.Errorf("Expected no ID, got %q %v", id, err)
+	}
+	if c.Done("x") != nil || c.Pending() != nil {
+		t.Error("Expected a disabled outbox to do nothing")
+	}
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+)
+
+// defaultActivityLimit is the number of events /activity returns without a
+// limit query parameter.
+const defaultActivityLimit = 100
+
+// HandleActivity returns the latest transactions across all subscribed
+// addresses via GET /activity?limit=N, newest first, so dashboards can show
+// recent activity without querying each address. With API keys, only the
+// caller's addresses are included.
+func (s *Server) HandleActivity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit := defaultActivityLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageSize {
+			http.Error(w, "invalid limit: expected an integer from 1 to "+strconv.Itoa(maxPageSize), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	reader, ok := s.parser.(parser.ActivityReader)
+	if !ok {
+		http.Error(w, parser.ErrActivityDisabled.Error(), http.StatusNotImplemented)
+		return
+	}
+
+	events, err := reader.RecentActivity()
+	if errors.Is(err, parser.ErrActivityDisabled) {
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	} else if err != nil {
+		requestLogger(r).Error("failed to get recent activity", logging.KeyError, err)
+		http.Error(w, "failed to get recent activity", http.StatusInternalServerError)
+		return
+	}
+	out := []parser.Event{}
+	for _, e := range events {
+		if len(out) == limit {
+			break
+		}
+		if s.owns(r, e.Address) {
+			out = append(out, e)
+		}
+	}
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"activity": out}); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
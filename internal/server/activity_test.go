@@ -0,0 +1,92 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danieloluwadare/tw-txparser/internal/tenant"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// activityParser is a MockParser with a fixed activity feed.
+type activityParser struct {
+	*MockParser
+	activity []parser.Event
+}
+
+func (p *activityParser) RecentActivity() ([]parser.Event, error) {
+	return p.activity, nil
+}
+
+func TestServer_HandleActivity(t *testing.T) {
+	const (
+		a = "0x1111111111111111111111111111111111111111"
+		b = "0x2222222222222222222222222222222222222222"
+	)
+	mock := &activityParser{MockParser: NewMockParser(), activity: []parser.Event{
+		{Address: a, Transaction: transaction.Transaction{Hash: "0xa2", Block: 20}},
+		{Address: b, Transaction: transaction.Transaction{Hash: "0xb1", Block: 15}},
+		{Address: a, Transaction: transaction.Transaction{Hash: "0xa1", Block: 10}},
+	}}
+	tenants, err := tenant.New(map[string]string{"payments": "pk", "risk": "rk"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := NewWithOptions(mock, Options{Tenants: tenants}).Handler()
+	do := func(method, path, key, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(apiKeyHeader, key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	do(http.MethodPost, "/v1/subscribe", "pk", `{"address":"`+a+`"}`)
+	do(http.MethodPost, "/v1/subscribe", "rk", `{"address":"`+b+`"}`)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedHashes []string
+	}{
+		{name: "own addresses only", query: "", expectedStatus: http.StatusOK, expectedHashes: []string{"0xa2", "0xa1"}},
+		{name: "limit", query: "?limit=1", expectedStatus: http.StatusOK, expectedHashes: []string{"0xa2"}},
+		{name: "invalid limit", query: "?limit=0", expectedStatus: http.StatusBadRequest},
+		{name: "limit too large", query: "?limit=1001", expectedStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(http.MethodGet, "/v1/activity"+tt.query, "pk", "")
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var resp struct {
+				Activity []parser.Event `json:"activity"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			var hashes []string
+			for _, e := range resp.Activity {
+				hashes = append(hashes, e.Transaction.Hash)
+			}
+			if strings.Join(hashes, ",") != strings.Join(tt.expectedHashes, ",") {
+				t.Errorf("Expected %v, got %v", tt.expectedHashes, hashes)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	NewWithOptions(NewMockParser(), Options{}).Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/activity", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("Expected 501 without an activity feed, got %d", w.Code)
+	}
+}
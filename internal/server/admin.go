@@ -0,0 +1,232 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/audit"
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/address"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+)
+
+// requireAdmin rejects requests that don't carry the configured admin bearer token.
+func (s *Server) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.opts.AdminToken == "" {
+			http.Error(w, "admin API disabled", http.StatusForbidden)
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.opts.AdminToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// HandleRescan starts a background rescan via POST {"from":N,"to":M}.
+func (s *Server) HandleRescan(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var body struct {
+		From int `json:"from"`
+		To   int `json:"to"`
+	}
+	if !decodeJSON(w, r, &body) {
+		return
+	}
+
+	err := s.parser.Rescan(body.From, body.To)
+	switch {
+	case errors.Is(err, parser.ErrInvalidRange):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, parser.ErrNotRunning):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	case err != nil:
+		requestLogger(r).Error("failed to start rescan", logging.KeyError, err)
+		http.Error(w, "failed to start rescan", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"accepted": true, "from": body.From, "to": body.To}); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
+
+// HandleDeadLetters lists the chain's dead-lettered blocks in order.
+func (s *Server) HandleDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"blocks": s.opts.DeadLetters.List(s.opts.Chain)}); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
+
+// HandleRedrive takes dead-lettered blocks out of the queue and rescans
+// them in the background via POST {"blocks":[N,...]}, or all of them when
+// blocks is omitted. Blocks failing again return to the queue.
+func (s *Server) HandleRedrive(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var body struct {
+		Blocks []int `json:"blocks"`
+	}
+	if !decodeJSON(w, r, &body) {
+		return
+	}
+	if body.Blocks == nil {
+		for _, e := range s.opts.DeadLetters.List(s.opts.Chain) {
+			body.Blocks = append(body.Blocks, e.Block)
+		}
+	}
+
+	redriven := []int{}
+	for _, block := range body.Blocks {
+		e, ok, err := s.opts.DeadLetters.Remove(s.opts.Chain, block)
+		if err != nil {
+			requestLogger(r).Error("failed to persist dead-letter queue", logging.KeyBlock, block, logging.KeyError, err)
+		}
+		if !ok {
+			continue
+		}
+		if err := s.parser.Rescan(block, block); err != nil {
+			if rerr := s.opts.DeadLetters.Restore(e); rerr != nil {
+				requestLogger(r).Error("failed to persist dead-letter queue", logging.KeyBlock, block, logging.KeyError, rerr)
+			}
+			if errors.Is(err, parser.ErrNotRunning) {
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+			requestLogger(r).Error("failed to start rescan", logging.KeyBlock, block, logging.KeyError, err)
+			http.Error(w, "failed to start rescan", http.StatusInternalServerError)
+			return
+		}
+		redriven = append(redriven, block)
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"redriven": redriven}); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
+
+// HandleSinks reports per-sink delivery counters for the notification sinks.
+func (s *Server) HandleSinks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"sinks": s.opts.Sinks.Stats()}); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
+
+// HandleDump serves a diagnostic snapshot of the instance.
+func (s *Server) HandleDump(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(s.opts.Snapshot()); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
+
+// logSettings is the body of /admin/logging.
+type logSettings struct {
+	Level        string              `json:"level,omitempty"`
+	Transactions *logging.TxSampling `json:"transactions,omitempty"`
+}
+
+// HandleLogging reports the log level and transaction log sampling on GET
+// and changes them via PUT {"level":"debug","transactions":{"every":100,
+// "matched_only":true}}, where either field may be omitted. Both respond
+// with the settings in effect.
+func (s *Server) HandleLogging(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var body logSettings
+		if !decodeJSON(w, r, &body) {
+			return
+		}
+		if body.Transactions != nil && body.Transactions.Every < 0 {
+			http.Error(w, "transactions.every must not be negative", http.StatusBadRequest)
+			return
+		}
+		if body.Level != "" {
+			if err := logging.SetLevel(body.Level); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if body.Transactions != nil {
+			logging.SetTxSampling(*body.Transactions)
+		}
+		requestLogger(r).Info("changed log settings", "level", logging.Level(), "tx_sampling", logging.CurrentTxSampling())
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sampling := logging.CurrentTxSampling()
+	if err := json.NewEncoder(w).Encode(logSettings{Level: logging.Level(), Transactions: &sampling}); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
+
+// HandleAudit lists recorded subscription changes, oldest first. The
+// address, chain, action and since (RFC 3339) query params filter entries
+// and limit keeps only the most recent ones.
+func (s *Server) HandleAudit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	params := r.URL.Query()
+	q := audit.Query{Chain: params.Get("chain"), Action: params.Get("action")}
+	if raw := params.Get("address"); raw != "" {
+		addr, err := address.Normalize(raw)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		q.Address = addr
+	}
+	if raw := params.Get("since"); raw != "" {
+		since, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			http.Error(w, "invalid since: expected RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		q.Since = since
+	}
+	if raw := params.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			http.Error(w, "invalid limit: expected positive integer", http.StatusBadRequest)
+			return
+		}
+		q.Limit = limit
+	}
+
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"entries": s.opts.Audit.Query(q)}); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
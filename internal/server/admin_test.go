@@ -0,0 +1,282 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danieloluwadare/tw-txparser/internal/audit"
+	"github.com/danieloluwadare/tw-txparser/internal/deadletter"
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/internal/notify"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+)
+
+func TestServer_HandleRescan(t *testing.T) {
+	tests := []struct {
+		name           string
+		token          string
+		authHeader     string
+		body           string
+		rescanErr      error
+		expectedStatus int
+	}{
+		{name: "accepted", token: "secret", authHeader: "Bearer secret", body: `{"from":10,"to":20}`, expectedStatus: http.StatusAccepted},
+		{name: "admin disabled", token: "", authHeader: "Bearer secret", body: `{"from":10,"to":20}`, expectedStatus: http.StatusForbidden},
+		{name: "missing token", token: "secret", body: `{"from":10,"to":20}`, expectedStatus: http.StatusUnauthorized},
+		{name: "wrong token", token: "secret", authHeader: "Bearer nope", body: `{"from":10,"to":20}`, expectedStatus: http.StatusUnauthorized},
+		{name: "invalid JSON", token: "secret", authHeader: "Bearer secret", body: `nope`, expectedStatus: http.StatusBadRequest},
+		{name: "invalid range", token: "secret", authHeader: "Bearer secret", body: `{"from":20,"to":10}`, rescanErr: fmt.Errorf("%w: from=20 to=10", parser.ErrInvalidRange), expectedStatus: http.StatusBadRequest},
+		{name: "parser not running", token: "secret", authHeader: "Bearer secret", body: `{"from":10,"to":20}`, rescanErr: parser.ErrNotRunning, expectedStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := NewMockParser()
+			mock.rescanErr = tt.rescanErr
+			handler := NewWithOptions(mock, Options{AdminToken: tt.token}).Handler()
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/admin/rescan", bytes.NewReader([]byte(tt.body)))
+			if tt.authHeader != "" {
+				req.Header.Set("Authorization", tt.authHeader)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus == http.StatusAccepted {
+				if len(mock.rescans) != 1 || mock.rescans[0] != [2]int{10, 20} {
+					t.Errorf("Expected rescan of 10-20, got %v", mock.rescans)
+				}
+			}
+		})
+	}
+}
+
+// countingNotifier accepts every event.
+type countingNotifier struct{}
+
+func (countingNotifier) Notify(context.Context, parser.Event) error { return nil }
+
+func TestServer_HandleSinks(t *testing.T) {
+	sinks := notify.NewDispatcher()
+	sinks.Add("nats", countingNotifier{})
+	events := make(chan parser.Event, 1)
+	events <- parser.Event{Address: "0xaaa"}
+	close(events)
+	sinks.Run(context.Background(), events)
+
+	handler := NewWithOptions(NewMockParser(), Options{AdminToken: "secret", Sinks: sinks}).Handler()
+	req := httptest.NewRequest(http.MethodGet, "/v1/admin/sinks", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	var resp struct {
+		Sinks []notify.SinkStats `json:"sinks"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(resp.Sinks) != 1 || resp.Sinks[0].Name != "nats" || resp.Sinks[0].Delivered != 1 {
+		t.Errorf("Unexpected sinks: %+v", resp.Sinks)
+	}
+}
+
+func TestServer_HandleDump(t *testing.T) {
+	snapshot := func() interface{} { return map[string]int{"goroutines": 42} }
+	handler := NewWithOptions(NewMockParser(), Options{AdminToken: "secret", Snapshot: snapshot}).Handler()
+
+	for _, tt := range []struct {
+		token string
+		want  int
+	}{
+		{"", http.StatusUnauthorized},
+		{"secret", http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/dump", nil)
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Fatalf("Expected status %d, got %d", tt.want, w.Code)
+		}
+		if tt.want == http.StatusOK && strings.TrimSpace(w.Body.String()) != `{"goroutines":42}` {
+			t.Errorf("Unexpected snapshot %s", w.Body.String())
+		}
+	}
+}
+
+func TestServer_HandleLogging(t *testing.T) {
+	defer logging.SetLevel(logging.Level())
+	defer logging.SetTxSampling(logging.CurrentTxSampling())
+	handler := NewWithOptions(NewMockParser(), Options{AdminToken: "secret", LogControl: true}).Handler()
+	do := func(method, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/v1/admin/logging", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPut, `{"level":"debug","transactions":{"every":100,"matched_only":true}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body)
+	}
+	if got := logging.CurrentTxSampling(); got != (logging.TxSampling{Every: 100, MatchedOnly: true}) || logging.Level() != "debug" {
+		t.Errorf("Unexpected settings: %s %+v", logging.Level(), got)
+	}
+
+	// Omitted fields are left unchanged.
+	w = do(http.MethodPut, `{"level":"warn"}`)
+	if expected := `{"level":"warn","transactions":{"every":100,"matched_only":true}}`; strings.TrimSpace(w.Body.String()) != expected {
+		t.Errorf("Expected %s, got %s", expected, w.Body)
+	}
+	if w := do(http.MethodGet, ""); !strings.Contains(w.Body.String(), `"level":"warn"`) {
+		t.Errorf("Expected the current settings, got %s", w.Body)
+	}
+
+	for _, body := range []string{`{"level":"loud"}`, `{"transactions":{"every":-1}}`} {
+		if w := do(http.MethodPut, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", body, w.Code)
+		}
+	}
+	if w := do(http.MethodDelete, ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405, got %d", w.Code)
+	}
+}
+
+func TestServer_HandleDeadLetters(t *testing.T) {
+	queue := deadletter.New()
+	queue.Add("ethereum", 100, 4, errors.New("timeout"))
+	queue.Add("ethereum", 200, 4, errors.New("timeout"))
+	queue.Add("ethereum", 300, 4, errors.New("timeout"))
+	queue.Add("sepolia", 100, 4, errors.New("timeout"))
+	mock := NewMockParser()
+	handler := NewWithOptions(mock, Options{AdminToken: "secret", Chain: "ethereum", DeadLetters: queue}).Handler()
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodGet, "/v1/admin/deadletters", "")
+	var list struct {
+		Blocks []deadletter.Entry `json:"blocks"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(list.Blocks) != 3 || list.Blocks[0].Block != 100 || list.Blocks[0].Error != "timeout" {
+		t.Errorf("Expected the chain's 3 blocks, got %+v", list.Blocks)
+	}
+
+	// Unknown blocks are skipped.
+	w = do(http.MethodPost, "/v1/admin/deadletters/redrive", `{"blocks":[200,999]}`)
+	if w.Code != http.StatusAccepted || !bytes.Contains(w.Body.Bytes(), []byte(`"redriven":[200]`)) {
+		t.Errorf("Expected block 200 to be redriven, got %d %s", w.Code, w.Body)
+	}
+	if len(mock.rescans) != 1 || mock.rescans[0] != [2]int{200, 200} {
+		t.Errorf("Expected a rescan of block 200, got %v", mock.rescans)
+	}
+
+	mock.rescanErr = parser.ErrNotRunning
+	if w := do(http.MethodPost, "/v1/admin/deadletters/redrive", `{}`); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 while the parser isn't running, got %d", w.Code)
+	}
+	if got := queue.List("ethereum"); len(got) != 2 {
+		t.Errorf("Expected blocks that couldn't be redriven to stay queued, got %+v", got)
+	}
+
+	mock.rescanErr = nil
+	w = do(http.MethodPost, "/v1/admin/deadletters/redrive", `{}`)
+	if w.Code != http.StatusAccepted || !bytes.Contains(w.Body.Bytes(), []byte(`"redriven":[100,300]`)) {
+		t.Errorf("Expected all remaining blocks to be redriven, got %d %s", w.Code, w.Body)
+	}
+	if got := queue.List(""); len(got) != 1 || got[0].Chain != "sepolia" {
+		t.Errorf("Expected only the other chain's block to be left, got %+v", got)
+	}
+	if w := do(http.MethodGet, "/v1/admin/deadletters/redrive", ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405, got %d", w.Code)
+	}
+}
+
+func TestServer_HandleAudit(t *testing.T) {
+	log := audit.New()
+	handler := NewWithOptions(NewMockParser(), Options{AdminToken: "secret", Chain: "ethereum", Audit: log}).Handler()
+	const addr = "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"
+
+	for _, path := range []string{"/v1/subscribe", "/v1/subscribe", "/v1/unsubscribe"} {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader([]byte(`{"address":"`+addr+`"}`)))
+		req.RemoteAddr = "10.1.2.3:4567"
+		req.Header.Set("X-Request-ID", "req-1")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d", path, w.Code)
+		}
+	}
+
+	get := func(query string) (int, []audit.Entry) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/admin/audit"+query, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		var resp struct {
+			Entries []audit.Entry `json:"entries"`
+		}
+		if w.Code == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+		}
+		return w.Code, resp.Entries
+	}
+
+	code, entries := get("")
+	if code != http.StatusOK || len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d (status %d)", len(entries), code)
+	}
+	first := entries[0]
+	if first.Action != audit.ActionSubscribe || first.Address != addr || !first.Changed || first.Chain != "ethereum" ||
+		first.RemoteIP != "10.1.2.3" || first.RequestID != "req-1" || first.Source != "subscribe" {
+		t.Errorf("Unexpected entry: %+v", first)
+	}
+	if entries[1].Changed {
+		t.Error("Expected duplicate subscription to be recorded as unchanged")
+	}
+
+	if _, entries := get("?action=unsubscribe&address=0x742D35CC6634C0532925A3B8D4C9DB96C4B4D8B6"); len(entries) != 1 {
+		t.Errorf("Expected 1 unsubscribe entry, got %d", len(entries))
+	}
+	if _, entries := get("?limit=2"); len(entries) != 2 || entries[1].Action != audit.ActionUnsubscribe {
+		t.Errorf("Expected the 2 most recent entries, got %+v", entries)
+	}
+	for _, query := range []string{"?since=yesterday", "?limit=0", "?address=nope"} {
+		if code, _ := get(query); code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", query, code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/admin/audit", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without token, got %d", w.Code)
+	}
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/address"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// HandleAllowances returns the token allowances granted by an address via
+// GET /allowances?address=..., optionally narrowed to one token contract
+// and, with unlimited=true, to unlimited approvals. Like /transactions,
+// unsubscribed addresses have none.
+func (s *Server) HandleAllowances(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	raw := q.Get("address")
+	if raw == "" {
+		http.Error(w, "missing address", http.StatusBadRequest)
+		return
+	}
+	var contract string
+	if c := q.Get("contract"); c != "" {
+		var err error
+		if contract, err = address.Normalize(c); err != nil {
+			http.Error(w, "invalid contract: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	var unlimited bool
+	if v := q.Get("unlimited"); v != "" {
+		var err error
+		if unlimited, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, "invalid unlimited: expected true or false", http.StatusBadRequest)
+			return
+		}
+	}
+	reader, ok := s.parser.(parser.AllowanceReader)
+	if !ok {
+		http.Error(w, parser.ErrAllowancesUnsupported.Error(), http.StatusNotImplemented)
+		return
+	}
+	addr, ok := s.resolveAddress(w, r, raw)
+	if !ok || !s.requireOwner(w, r, addr) {
+		return
+	}
+
+	allowances, err := reader.Allowances(addr)
+	if errors.Is(err, parser.ErrAllowancesUnsupported) {
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	} else if err != nil {
+		requestLogger(r).Error("failed to get allowances", logging.KeyAddress, addr, logging.KeyError, err)
+		http.Error(w, "failed to get allowances", http.StatusInternalServerError)
+		return
+	}
+
+	out := []transaction.Allowance{}
+	for _, a := range allowances {
+		if (contract == "" || a.Contract == contract) && (!unlimited || a.Unlimited()) {
+			out = append(out, a)
+		}
+	}
+	if err := json.NewEncoder(w).Encode(out); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
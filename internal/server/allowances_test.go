@@ -0,0 +1,74 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// allowanceParser is a MockParser keeping fixed allowances for subscribed
+// addresses.
+type allowanceParser struct {
+	*MockParser
+}
+
+func (p *allowanceParser) Allowances(address string) ([]transaction.Allowance, error) {
+	if !p.subscriptions[address] {
+		return []transaction.Allowance{}, nil
+	}
+	return []transaction.Allowance{
+		{Owner: address, Spender: "0x3333333333333333333333333333333333333333", Contract: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", Symbol: "USDC", Decimals: 6, Amount: transaction.MustParseValue("0x" + "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")},
+		{Owner: address, Spender: "0x3333333333333333333333333333333333333333", Contract: "0xdac17f958d2ee523a2206206994597c13d831ec7", Symbol: "USDT", Decimals: 6, Amount: transaction.WeiValue(2_500_000)},
+	}, nil
+}
+
+func TestServer_HandleAllowances(t *testing.T) {
+	const addr = "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"
+	mock := &allowanceParser{MockParser: NewMockParser()}
+	mock.Subscribe(addr)
+	handler := NewWithOptions(mock, Options{}).Handler()
+	get := func(query string) (*httptest.ResponseRecorder, []map[string]interface{}) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/allowances"+query, nil))
+		var out []map[string]interface{}
+		if w.Code == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&out); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+		}
+		return w, out
+	}
+
+	w, out := get("?address=" + addr)
+	if w.Code != http.StatusOK || len(out) != 2 {
+		t.Fatalf("Expected 2 allowances, got %d: %v", w.Code, out)
+	}
+	if out[0]["unlimited"] != true || out[1]["normalized_amount"] != "2.5" || out[1]["unlimited"] != false {
+		t.Errorf("Unexpected allowances %v", out)
+	}
+	if _, out = get("?address=" + addr + "&unlimited=true"); len(out) != 1 || out[0]["symbol"] != "USDC" {
+		t.Errorf("Expected only the unlimited approval, got %v", out)
+	}
+	if _, out = get("?address=" + addr + "&contract=0xdAC17F958D2ee523a2206206994597C13D831ec7"); len(out) != 1 || out[0]["symbol"] != "USDT" {
+		t.Errorf("Expected only the USDT approval, got %v", out)
+	}
+	if _, out = get("?address=0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"); len(out) != 0 {
+		t.Errorf("Expected no allowances for an unsubscribed address, got %v", out)
+	}
+
+	for _, query := range []string{"", "?address=" + addr + "&unlimited=maybe", "?address=" + addr + "&contract=0x12"} {
+		if w, _ := get(query); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %q, got %d", query, w.Code)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	New(NewMockParser()).Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/allowances?address="+addr, nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("Expected 501 without allowance support, got %d", w.Code)
+	}
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+)
+
+// HandleBalance returns the running native balance of a subscribed address
+// via GET /balance?address=.... units=ether adds the balance in ether, and
+// refresh=true fetches it from the node again.
+func (s *Server) HandleBalance(w http.ResponseWriter, r *http.Request) {
+	req, ok := s.balanceRequest(w, r)
+	if !ok {
+		return
+	}
+	tracker, ok := s.parser.(parser.BalanceTracker)
+	if !ok {
+		http.Error(w, parser.ErrBalancesDisabled.Error(), http.StatusNotImplemented)
+		return
+	}
+
+	b, err := tracker.Balance(r.Context(), req.addr, req.refresh)
+	if err != nil {
+		writeBalanceError(w, r, req.addr, err)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(req.response(b)); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
+
+// HandleBalances returns the native balance of a subscribed address along
+// with its balances in the tokens it has sent or received via GET
+// /balances?address=..., taking the same parameters as HandleBalance.
+func (s *Server) HandleBalances(w http.ResponseWriter, r *http.Request) {
+	req, ok := s.balanceRequest(w, r)
+	if !ok {
+		return
+	}
+	tracker, ok := s.parser.(parser.PortfolioTracker)
+	if !ok {
+		http.Error(w, parser.ErrBalancesDisabled.Error(), http.StatusNotImplemented)
+		return
+	}
+
+	pf, err := tracker.Portfolio(r.Context(), req.addr, req.refresh)
+	if err != nil {
+		writeBalanceError(w, r, req.addr, err)
+		return
+	}
+	resp := struct {
+		balanceResponse
+		Tokens []parser.TokenBalance `json:"tokens"`
+	}{req.response(pf.Balance), pf.Tokens}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
+
+// balanceRequest holds the parameters shared by the balance endpoints.
+type balanceRequest struct {
+	addr    string
+	ether   bool
+	refresh bool
+}
+
+// balanceRequest parses the parameters of a balance request and resolves
+// its address, writing an error response if they are invalid or the caller
+// doesn't own the address.
+func (s *Server) balanceRequest(w http.ResponseWriter, r *http.Request) (balanceRequest, bool) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return balanceRequest{}, false
+	}
+	q := r.URL.Query()
+	raw := q.Get("address")
+	if raw == "" {
+		http.Error(w, "missing address", http.StatusBadRequest)
+		return balanceRequest{}, false
+	}
+	var req balanceRequest
+	var err error
+	if req.ether, err = parseUnits(r); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return balanceRequest{}, false
+	}
+	if v := q.Get("refresh"); v != "" {
+		if req.refresh, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, "invalid refresh: expected true or false", http.StatusBadRequest)
+			return balanceRequest{}, false
+		}
+	}
+	addr, ok := s.resolveAddress(w, r, raw)
+	if !ok || !s.requireOwner(w, r, addr) {
+		return balanceRequest{}, false
+	}
+	req.addr = addr
+	return req, true
+}
+
+// balanceResponse is a native balance, in ether too if requested.
+type balanceResponse struct {
+	parser.Balance
+	BalanceEther string `json:"balance_ether,omitempty"`
+}
+
+func (req balanceRequest) response(b parser.Balance) balanceResponse {
+	resp := balanceResponse{Balance: b}
+	if req.ether {
+		resp.BalanceEther = b.Balance.Ether()
+	}
+	return resp
+}
+
+// writeBalanceError maps an error getting the balance of addr to a
+// response.
+func writeBalanceError(w http.ResponseWriter, r *http.Request, addr string, err error) {
+	switch {
+	case errors.Is(err, parser.ErrBalancesDisabled):
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+	case errors.Is(err, parser.ErrNotSubscribed):
+		http.Error(w, "address not subscribed", http.StatusNotFound)
+	case errors.Is(err, parser.ErrNoBlock):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		requestLogger(r).Error("failed to get balance", logging.KeyAddress, addr, logging.KeyError, err)
+		http.Error(w, "failed to get balance", http.StatusBadGateway)
+	}
+}
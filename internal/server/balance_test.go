@@ -0,0 +1,157 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// balanceParser is a MockParser tracking fixed balances.
+type balanceParser struct {
+	*MockParser
+	err       error
+	refreshed bool
+}
+
+func (p *balanceParser) Balance(ctx context.Context, address string, refresh bool) (parser.Balance, error) {
+	if p.err != nil {
+		return parser.Balance{}, p.err
+	}
+	if !p.subscriptions[address] {
+		return parser.Balance{}, parser.ErrNotSubscribed
+	}
+	p.refreshed = refresh
+	return parser.Balance{Address: address, Balance: transaction.WeiValue(1_500_000_000_000_000_000), Block: 1234}, nil
+}
+
+func TestServer_HandleBalance(t *testing.T) {
+	const addr = "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"
+	mock := &balanceParser{MockParser: NewMockParser()}
+	mock.Subscribe(addr)
+	handler := NewWithOptions(mock, Options{}).Handler()
+	get := func(query string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/balance"+query, nil))
+		return w
+	}
+
+	w := get("?address=" + addr + "&units=ether&refresh=true")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body)
+	}
+	var resp map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp["address"] != addr || resp["balance"] != "1500000000000000000" || resp["balance_ether"] != "1.5" || resp["block"] != 1234.0 {
+		t.Errorf("Unexpected response %v", resp)
+	}
+	if !mock.refreshed {
+		t.Error("Expected refresh to be passed on")
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		err   error
+		want  int
+	}{
+		{name: "missing address", query: "", want: http.StatusBadRequest},
+		{name: "bad refresh", query: "?address=" + addr + "&refresh=maybe", want: http.StatusBadRequest},
+		{name: "not subscribed", query: "?address=0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", want: http.StatusNotFound},
+		{name: "disabled", query: "?address=" + addr, err: parser.ErrBalancesDisabled, want: http.StatusNotImplemented},
+		{name: "no block", query: "?address=" + addr, err: parser.ErrNoBlock, want: http.StatusServiceUnavailable},
+		{name: "node failure", query: "?address=" + addr, err: errors.New("connection refused"), want: http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock.err = tt.err
+			if w := get(tt.query); w.Code != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, w.Code)
+			}
+		})
+	}
+}
+
+func TestServer_HandleBalance_Unsupported(t *testing.T) {
+	w := httptest.NewRecorder()
+	New(NewMockParser()).Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/balance?address=0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("Expected 501, got %d", w.Code)
+	}
+}
+
+// portfolioParser adds token balances to balanceParser.
+type portfolioParser struct {
+	*balanceParser
+}
+
+func (p portfolioParser) Portfolio(ctx context.Context, address string, refresh bool) (parser.Portfolio, error) {
+	b, err := p.Balance(ctx, address, refresh)
+	if err != nil {
+		return parser.Portfolio{}, err
+	}
+	return parser.Portfolio{Balance: b, Tokens: []parser.TokenBalance{{
+		Contract:          "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+		Standard:          transaction.StandardERC20,
+		Symbol:            "USDC",
+		Decimals:          6,
+		Balance:           transaction.WeiValue(1_500_000),
+		NormalizedBalance: "1.5",
+	}}}, nil
+}
+
+func TestServer_HandleBalances(t *testing.T) {
+	const addr = "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"
+	mock := portfolioParser{&balanceParser{MockParser: NewMockParser()}}
+	mock.Subscribe(addr)
+	handler := NewWithOptions(mock, Options{}).Handler()
+	get := func(query string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/balances"+query, nil))
+		return w
+	}
+
+	w := get("?address=" + addr + "&units=ether")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Address      string `json:"address"`
+		Balance      string `json:"balance"`
+		BalanceEther string `json:"balance_ether"`
+		Tokens       []map[string]interface{}
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Address != addr || resp.Balance != "1500000000000000000" || resp.BalanceEther != "1.5" {
+		t.Errorf("Unexpected native balance %+v", resp)
+	}
+	if len(resp.Tokens) != 1 || resp.Tokens[0]["symbol"] != "USDC" || resp.Tokens[0]["balance"] != "1500000" || resp.Tokens[0]["normalized_balance"] != "1.5" {
+		t.Errorf("Unexpected tokens %v", resp.Tokens)
+	}
+
+	if w := get("?address=0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unsubscribed address, got %d", w.Code)
+	}
+	mock.err = errors.New("connection refused")
+	if w := get("?address=" + addr); w.Code != http.StatusBadGateway {
+		t.Errorf("Expected 502, got %d", w.Code)
+	}
+
+	// Parsers without token balances don't serve the endpoint.
+	w = httptest.NewRecorder()
+	New(NewMockParser()).Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/balances?address="+addr, nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("Expected 501, got %d", w.Code)
+	}
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// maxBlockRange caps the number of blocks a single block range query may
+// span, keeping responses bounded.
+const maxBlockRange = 10_000
+
+// HandleBlockTransactions returns the stored transactions of all subscribed
+// addresses in a block range via GET /blocks/transactions?from=...&to=...,
+// both inclusive. With API keys, only the caller's addresses are listed.
+func (s *Server) HandleBlockTransactions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	from, to, err := parseBlockRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	reader, ok := s.parser.(parser.RangeReader)
+	if !ok {
+		http.Error(w, parser.ErrRangeQueryUnsupported.Error(), http.StatusNotImplemented)
+		return
+	}
+
+	byAddr, err := reader.TransactionsInRange(from, to)
+	switch {
+	case errors.Is(err, parser.ErrInvalidRange):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, parser.ErrRangeQueryUnsupported):
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	case err != nil:
+		requestLogger(r).Error("failed to get transactions", "from", from, "to", to, logging.KeyError, err)
+		http.Error(w, "failed to get transactions", http.StatusInternalServerError)
+		return
+	}
+	count := 0
+	for addr, txs := range byAddr {
+		if !s.owns(r, addr) {
+			delete(byAddr, addr)
+			continue
+		}
+		count += len(txs)
+	}
+
+	resp := struct {
+		From         int                                  `json:"from"`
+		To           int                                  `json:"to"`
+		Count        int                                  `json:"count"`
+		Transactions map[string][]transaction.Transaction `json:"transactions"`
+	}{From: from, To: to, Count: count, Transactions: byAddr}
+	if resp.Transactions == nil {
+		resp.Transactions = map[string][]transaction.Transaction{}
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
+
+// parseBlockRange parses the required from and to query parameters, block
+// numbers spanning at most maxBlockRange blocks.
+func parseBlockRange(r *http.Request) (from, to int, err error) {
+	q := r.URL.Query()
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{{"from", &from}, {"to", &to}} {
+		v := q.Get(p.name)
+		if v == "" {
+			return 0, 0, fmt.Errorf("missing %s", p.name)
+		}
+		if *p.dst, err = strconv.Atoi(v); err != nil || *p.dst < 1 {
+			return 0, 0, fmt.Errorf("invalid %s %q: expected a positive block number", p.name, v)
+		}
+	}
+	if to < from {
+		return 0, 0, fmt.Errorf("invalid block range: to=%d is before from=%d", to, from)
+	}
+	if to-from+1 > maxBlockRange {
+		return 0, 0, fmt.Errorf("invalid block range: at most %d blocks may be queried at once", maxBlockRange)
+	}
+	return from, to, nil
+}
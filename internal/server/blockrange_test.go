@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danieloluwadare/tw-txparser/internal/tenant"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// rangeParser is a MockParser listing the transactions of subscribed
+// addresses by block.
+type rangeParser struct {
+	*MockParser
+}
+
+func (p *rangeParser) TransactionsInRange(from, to int) (map[string][]transaction.Transaction, error) {
+	out := make(map[string][]transaction.Transaction)
+	for addr, txs := range p.transactions {
+		for _, tx := range txs {
+			if p.subscriptions[addr] && tx.Block >= from && tx.Block <= to {
+				out[addr] = append(out[addr], tx)
+			}
+		}
+	}
+	return out, nil
+}
+
+func TestServer_HandleBlockTransactions(t *testing.T) {
+	const (
+		a = "0x1111111111111111111111111111111111111111"
+		b = "0x2222222222222222222222222222222222222222"
+	)
+	mock := &rangeParser{MockParser: NewMockParser()}
+	mock.transactions[a] = []transaction.Transaction{{Hash: "0xa1", To: a, Block: 10, Direction: transaction.DirectionIn}, {Hash: "0xa2", To: a, Block: 20, Direction: transaction.DirectionIn}}
+	mock.transactions[b] = []transaction.Transaction{{Hash: "0xb1", To: b, Block: 11, Direction: transaction.DirectionIn}}
+	tenants, err := tenant.New(map[string]string{"payments": "pk", "risk": "rk"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := NewWithOptions(mock, Options{Tenants: tenants}).Handler()
+	do := func(method, path, key, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(apiKeyHeader, key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	do(http.MethodPost, "/v1/subscribe", "pk", `{"address":"`+a+`"}`)
+	do(http.MethodPost, "/v1/subscribe", "rk", `{"address":"`+b+`"}`)
+
+	w := do(http.MethodGet, "/v1/blocks/transactions?from=10&to=15", "pk", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body)
+	}
+	var resp struct {
+		From, To, Count int
+		Transactions    map[string][]transaction.Transaction
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.From != 10 || resp.To != 15 || resp.Count != 1 || len(resp.Transactions) != 1 || resp.Transactions[a][0].Hash != "0xa1" {
+		t.Errorf("Expected only the caller's transaction in range, got %+v", resp)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{name: "missing to", query: "?from=10", want: http.StatusBadRequest},
+		{name: "invalid from", query: "?from=ten&to=15", want: http.StatusBadRequest},
+		{name: "zero", query: "?from=0&to=15", want: http.StatusBadRequest},
+		{name: "inverted", query: "?from=15&to=10", want: http.StatusBadRequest},
+		{name: "too wide", query: "?from=1&to=10001", want: http.StatusBadRequest},
+		{name: "widest", query: "?from=1&to=10000", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := do(http.MethodGet, "/v1/blocks/transactions"+tt.query, "rk", ""); w.Code != tt.want {
+				t.Errorf("Expected %d, got %d: %s", tt.want, w.Code, w.Body)
+			}
+		})
+	}
+}
+
+func TestServer_HandleBlockTransactions_Unsupported(t *testing.T) {
+	w := httptest.NewRecorder()
+	New(NewMockParser()).Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/blocks/transactions?from=1&to=2", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("Expected 501, got %d", w.Code)
+	}
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/internal/tenant"
+	"github.com/danieloluwadare/tw-txparser/pkg/address"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// contractWatcher returns the parser as a parser.ContractWatcher, writing a
+// 501 response if it isn't one.
+func (s *Server) contractWatcher(w http.ResponseWriter) (parser.ContractWatcher, bool) {
+	watcher, ok := s.parser.(parser.ContractWatcher)
+	if !ok {
+		http.Error(w, parser.ErrContractWatchUnsupported.Error(), http.StatusNotImplemented)
+	}
+	return watcher, ok
+}
+
+// writeContractWatchError writes the response for a failed ContractWatcher
+// call.
+func writeContractWatchError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, parser.ErrContractWatchUnsupported) {
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	}
+	requestLogger(r).Error("failed to access contract watches", logging.KeyError, err)
+	http.Error(w, "failed to access contract watches", http.StatusInternalServerError)
+}
+
+// HandleWatchContract starts recording every transaction sent to a
+// contract via POST {"contract":"..."}. With API keys, the caller's tenant
+// claims the watch like an address.
+func (s *Server) HandleWatchContract(w http.ResponseWriter, r *http.Request) {
+	s.handleContractWatch(w, r, "watched", s.claimShared, parser.ContractWatcher.WatchContract)
+}
+
+// HandleUnwatchContract stops recording the transactions sent to a contract
+// via POST {"contract":"..."}, once no tenant owns the watch. Calls already
+// stored are kept.
+func (s *Server) HandleUnwatchContract(w http.ResponseWriter, r *http.Request) {
+	s.handleContractWatch(w, r, "unwatched", s.releaseShared, parser.ContractWatcher.UnwatchContract)
+}
+
+func (s *Server) handleContractWatch(w http.ResponseWriter, r *http.Request, field string, share func(*http.Request, string, func() (bool, error)) (bool, error), apply func(parser.ContractWatcher, string) (bool, error)) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var body struct {
+		Contract string `json:"contract"`
+	}
+	if !decodeJSON(w, r, &body) {
+		return
+	}
+	if body.Contract == "" {
+		http.Error(w, "missing contract", http.StatusBadRequest)
+		return
+	}
+	contract, err := address.Normalize(body.Contract)
+	if err != nil {
+		http.Error(w, "invalid contract: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	watcher, ok := s.contractWatcher(w)
+	if !ok {
+		return
+	}
+
+	changed, err := share(r, tenant.ContractKey(contract), func() (bool, error) {
+		return apply(watcher, contract)
+	})
+	if err != nil {
+		writeContractWatchError(w, r, err)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(map[string]bool{field: changed}); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
+
+// HandleWatchedContracts lists the caller's watched contracts via GET
+// /contracts.
+func (s *Server) HandleWatchedContracts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	watcher, ok := s.contractWatcher(w)
+	if !ok {
+		return
+	}
+	all, err := watcher.WatchedContracts()
+	if err != nil {
+		writeContractWatchError(w, r, err)
+		return
+	}
+	contracts := make([]string, 0, len(all))
+	for _, c := range all {
+		if s.owns(r, tenant.ContractKey(c)) {
+			contracts = append(contracts, c)
+		}
+	}
+	if err := json.NewEncoder(w).Encode(contracts); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
+
+// HandleContractCalls returns the transactions sent to a watched contract
+// via GET /contracts/calls?contract=..., optionally narrowed to one method
+// by name or selector, e.g. method=transfer or method=0xa9059cbb. Contracts
+// watched by other tenants have none.
+func (s *Server) HandleContractCalls(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	raw := q.Get("contract")
+	if raw == "" {
+		http.Error(w, "missing contract", http.StatusBadRequest)
+		return
+	}
+	contract, err := address.Normalize(raw)
+	if err != nil {
+		http.Error(w, "invalid contract: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	method := q.Get("method")
+	watcher, ok := s.contractWatcher(w)
+	if !ok {
+		return
+	}
+
+	var calls []transaction.Transaction
+	if s.owns(r, tenant.ContractKey(contract)) {
+		if calls, err = watcher.ContractCalls(contract); err != nil {
+			writeContractWatchError(w, r, err)
+			return
+		}
+	}
+	out := make([]transaction.Transaction, 0, len(calls))
+	for _, tx := range calls {
+		if method == "" || tx.Call != nil && (tx.Call.Method == method || strings.EqualFold(tx.Call.Selector, method)) {
+			out = append(out, tx)
+		}
+	}
+	if err := json.NewEncoder(w).Encode(out); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danieloluwadare/tw-txparser/internal/tenant"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// watchParser is a MockParser keeping contract watches in memory, with
+// fixed calls for each.
+type watchParser struct {
+	*MockParser
+	watched map[string]bool
+}
+
+func (p *watchParser) WatchContract(contract string) (bool, error) {
+	if p.watched[contract] {
+		return false, nil
+	}
+	p.watched[contract] = true
+	return true, nil
+}
+
+func (p *watchParser) UnwatchContract(contract string) (bool, error) {
+	ok := p.watched[contract]
+	delete(p.watched, contract)
+	return ok, nil
+}
+
+func (p *watchParser) WatchedContracts() ([]string, error) {
+	out := []string{}
+	for contract := range p.watched {
+		out = append(out, contract)
+	}
+	return out, nil
+}
+
+func (p *watchParser) ContractCalls(contract string) ([]transaction.Transaction, error) {
+	if !p.watched[contract] {
+		return []transaction.Transaction{}, nil
+	}
+	return []transaction.Transaction{
+		{Hash: "0xcall1", To: contract, Block: 10, Call: &transaction.Call{Selector: "0xa9059cbb", Method: "transfer", Signature: "transfer(address,uint256)"}},
+		{Hash: "0xcall2", To: contract, Block: 11, Call: &transaction.Call{Selector: "0x022c0d9f"}},
+		{Hash: "0xcall3", To: contract, Block: 12},
+	}, nil
+}
+
+func TestServer_ContractWatch(t *testing.T) {
+	const contract = "0xb4e16d0168e52d35cacd2c6185b44281ec28c9dc"
+	mock := &watchParser{MockParser: NewMockParser(), watched: make(map[string]bool)}
+	handler := NewWithOptions(mock, Options{}).Handler()
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+	calls := func(query string) []transaction.Transaction {
+		t.Helper()
+		w := do(http.MethodGet, "/v1/contracts/calls"+query, "")
+		var out []transaction.Transaction
+		if err := json.NewDecoder(w.Body).Decode(&out); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return out
+	}
+
+	body := `{"contract":"0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc"}`
+	if w := do(http.MethodPost, "/v1/contracts/watch", body); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"watched":true`) {
+		t.Fatalf("Expected the contract to be watched, got %d: %s", w.Code, w.Body)
+	}
+	if w := do(http.MethodPost, "/v1/contracts/watch", body); !strings.Contains(w.Body.String(), `"watched":false`) {
+		t.Errorf("Expected a repeated watch to report false, got %s", w.Body)
+	}
+	if w := do(http.MethodGet, "/v1/contracts", ""); strings.TrimSpace(w.Body.String()) != `["`+contract+`"]` {
+		t.Errorf("Unexpected watched contracts %s", w.Body)
+	}
+
+	if out := calls("?contract=" + contract); len(out) != 3 {
+		t.Errorf("Expected 3 calls, got %+v", out)
+	}
+	if out := calls("?contract=" + contract + "&method=transfer"); len(out) != 1 || out[0].Hash != "0xcall1" {
+		t.Errorf("Expected the transfer call, got %+v", out)
+	}
+	if out := calls("?contract=" + contract + "&method=0x022C0D9F"); len(out) != 1 || out[0].Hash != "0xcall2" {
+		t.Errorf("Expected the call matching the selector, got %+v", out)
+	}
+
+	if w := do(http.MethodPost, "/v1/contracts/unwatch", body); !strings.Contains(w.Body.String(), `"unwatched":true`) {
+		t.Errorf("Expected the contract to be unwatched, got %s", w.Body)
+	}
+	if out := calls("?contract=" + contract); len(out) != 0 {
+		t.Errorf("Expected no calls once unwatched, got %+v", out)
+	}
+
+	for _, tt := range []struct{ method, path, body string }{
+		{http.MethodPost, "/v1/contracts/watch", `{}`},
+		{http.MethodPost, "/v1/contracts/watch", `{"contract":"0x12"}`},
+		{http.MethodGet, "/v1/contracts/calls", ""},
+		{http.MethodGet, "/v1/contracts/calls?contract=0x12", ""},
+	} {
+		if w := do(tt.method, tt.path, tt.body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s %s %s, got %d", tt.method, tt.path, tt.body, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	New(NewMockParser()).Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/contracts", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("Expected 501 without contract watch support, got %d", w.Code)
+	}
+}
+
+func TestServer_ContractWatch_Tenants(t *testing.T) {
+	const contract = "0xb4e16d0168e52d35cacd2c6185b44281ec28c9dc"
+	tenants, err := tenant.New(map[string]string{"payments": "pk", "risk": "rk"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := &watchParser{MockParser: NewMockParser(), watched: make(map[string]bool)}
+	handler := NewWithOptions(mock, Options{Tenants: tenants}).Handler()
+	do := func(method, path, key, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(apiKeyHeader, key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	body := `{"contract":"` + contract + `"}`
+
+	do(http.MethodPost, "/v1/contracts/watch", "pk", body)
+	if w := do(http.MethodGet, "/v1/contracts", "rk", ""); strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("Expected risk to see no watched contracts, got %s", w.Body)
+	}
+	if w := do(http.MethodGet, "/v1/contracts/calls?contract="+contract, "rk", ""); strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("Expected risk to see no calls, got %s", w.Body)
+	}
+	if w := do(http.MethodPost, "/v1/contracts/unwatch", "rk", body); !strings.Contains(w.Body.String(), `"unwatched":false`) || !mock.watched[contract] {
+		t.Errorf("Expected risk not to stop payments' watch, got %s", w.Body)
+	}
+	if w := do(http.MethodGet, "/v1/contracts", "pk", ""); strings.TrimSpace(w.Body.String()) != `["`+contract+`"]` {
+		t.Errorf("Expected payments to see its watch, got %s", w.Body)
+	}
+
+	do(http.MethodPost, "/v1/contracts/watch", "rk", body)
+	do(http.MethodPost, "/v1/contracts/unwatch", "pk", body)
+	if !mock.watched[contract] {
+		t.Error("Expected the watch to continue while risk owns it")
+	}
+	do(http.MethodPost, "/v1/contracts/unwatch", "rk", body)
+	if mock.watched[contract] {
+		t.Error("Expected the watch to stop once no tenant owns it")
+	}
+}
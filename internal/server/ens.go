@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/address"
+	"github.com/danieloluwadare/tw-txparser/pkg/ens"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// NameResolver resolves ENS names to addresses and back; *ens.Resolver
+// implements it. Both methods return ens.ErrNotFound for unknown entries.
+type NameResolver interface {
+	Resolve(ctx context.Context, name string) (string, error)
+	Lookup(ctx context.Context, addr string) (string, error)
+}
+
+// maxNameLookups bounds concurrent reverse ENS lookups per request.
+const maxNameLookups = 8
+
+// resolveAddress normalizes raw, which may also be an ENS name when ENS
+// resolution is enabled. Resolved names are reported in the
+// X-Resolved-Address response header. On failure it writes the error
+// response and returns false.
+func (s *Server) resolveAddress(w http.ResponseWriter, r *http.Request, raw string) (string, bool) {
+	if s.opts.ENS == nil || !ens.IsName(raw) {
+		addr, err := address.Normalize(raw)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return "", false
+		}
+		return addr, true
+	}
+	addr, err := s.opts.ENS.Resolve(r.Context(), raw)
+	if errors.Is(err, ens.ErrNotFound) {
+		http.Error(w, "ENS name "+ens.Normalize(raw)+" does not resolve to an address", http.StatusBadRequest)
+		return "", false
+	}
+	if err != nil {
+		requestLogger(r).Error("failed to resolve ENS name", "name", raw, logging.KeyError, err)
+		http.Error(w, "failed to resolve ENS name", http.StatusBadGateway)
+		return "", false
+	}
+	w.Header().Set("X-Resolved-Address", addr)
+	return addr, true
+}
+
+// wantNames reports whether the ens query parameter asks for the ENS names
+// of counterparties.
+func (s *Server) wantNames(r *http.Request) (bool, error) {
+	v := r.URL.Query().Get("ens")
+	if v == "" {
+		return false, nil
+	}
+	want, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid ens %q: expected true or false", v)
+	}
+	if want && s.opts.ENS == nil {
+		return false, errors.New("ENS resolution is not enabled")
+	}
+	return want, nil
+}
+
+// lookupNames reverse-resolves the counterparties of txs, returning the
+// names found by address. Failed lookups are logged and skipped so that a
+// flaky node never fails the response.
+func (s *Server) lookupNames(r *http.Request, txs []transaction.Transaction) map[string]string {
+	addrs := make(map[string]struct{})
+	for _, tx := range txs {
+		for _, a := range []string{tx.From, tx.To} {
+			if a != "" {
+				addrs[a] = struct{}{}
+			}
+		}
+	}
+
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		names = make(map[string]string)
+		sem   = make(chan struct{}, maxNameLookups)
+	)
+	logger := requestLogger(r)
+	for a := range addrs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(a string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			name, err := s.opts.ENS.Lookup(r.Context(), a)
+			if err != nil {
+				if !errors.Is(err, ens.ErrNotFound) {
+					logger.Warn("failed to look up ENS name", logging.KeyAddress, a, logging.KeyError, err)
+				}
+				return
+			}
+			mu.Lock()
+			names[a] = name
+			mu.Unlock()
+		}(a)
+	}
+	wg.Wait()
+	return names
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/ens"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// fakeResolver resolves names from fixed tables.
+type fakeResolver struct {
+	addrs map[string]string // name -> address
+	names map[string]string // address -> name
+	err   error
+}
+
+func (f *fakeResolver) Resolve(_ context.Context, name string) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	if a, ok := f.addrs[ens.Normalize(name)]; ok {
+		return a, nil
+	}
+	return "", ens.ErrNotFound
+}
+
+func (f *fakeResolver) Lookup(_ context.Context, addr string) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	if n, ok := f.names[addr]; ok {
+		return n, nil
+	}
+	return "", ens.ErrNotFound
+}
+
+const vitalik = "0xd8da6bf26964af9d7eed9e03e53415d37aa96045"
+
+func newENSServer(mock *MockParser) (*Server, *fakeResolver) {
+	resolver := &fakeResolver{
+		addrs: map[string]string{"vitalik.eth": vitalik},
+		names: map[string]string{vitalik: "vitalik.eth"},
+	}
+	return NewWithOptions(mock, Options{ENS: resolver}), resolver
+}
+
+func TestServer_ENS_Subscribe(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		rpcErr         error
+		expectedStatus int
+		expectedHeader string
+	}{
+		{name: "name", body: `{"address":"Vitalik.eth"}`, expectedStatus: http.StatusOK, expectedHeader: vitalik},
+		{name: "address", body: `{"address":"` + vitalik + `"}`, expectedStatus: http.StatusOK},
+		{name: "unknown name", body: `{"address":"nobody.eth"}`, expectedStatus: http.StatusBadRequest},
+		{name: "node failure", body: `{"address":"vitalik.eth"}`, rpcErr: errors.New("connection refused"), expectedStatus: http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := NewMockParser()
+			server, resolver := newENSServer(mock)
+			resolver.err = tt.rpcErr
+
+			req := httptest.NewRequest(http.MethodPost, "/subscribe", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			server.HandleSubscribe(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body)
+			}
+			if got := w.Header().Get("X-Resolved-Address"); got != tt.expectedHeader {
+				t.Errorf("Expected X-Resolved-Address %q, got %q", tt.expectedHeader, got)
+			}
+			if tt.expectedStatus == http.StatusOK && !mock.subscriptions[vitalik] {
+				t.Error("Expected the resolved address to be subscribed")
+			}
+		})
+	}
+}
+
+func TestServer_ENS_Disabled(t *testing.T) {
+	server := New(NewMockParser())
+
+	req := httptest.NewRequest(http.MethodPost, "/subscribe", strings.NewReader(`{"address":"vitalik.eth"}`))
+	w := httptest.NewRecorder()
+	server.HandleSubscribe(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected names to be rejected without ENS, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/transactions?address="+vitalik+"&ens=true", nil)
+	w = httptest.NewRecorder()
+	server.HandleTransactions(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected ens=true to be rejected without ENS, got %d", w.Code)
+	}
+}
+
+func TestServer_ENS_Transactions(t *testing.T) {
+	mock := NewMockParser()
+	other := "0x1111111111111111111111111111111111111111"
+	mock.transactions[vitalik] = []transaction.Transaction{
+		{Hash: "0xhash1", From: other, To: vitalik, Value: transaction.WeiValue(1), Block: 1, Direction: transaction.DirectionIn},
+	}
+	server, _ := newENSServer(mock)
+
+	tests := []struct {
+		name     string
+		query    string
+		fromName string
+		toName   string
+	}{
+		{name: "by name", query: "?address=vitalik.eth"},
+		{name: "reverse resolved", query: "?address=vitalik.eth&ens=true", toName: "vitalik.eth"},
+		{name: "explicitly off", query: "?address=" + vitalik + "&ens=false"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/transactions"+tt.query, nil)
+			w := httptest.NewRecorder()
+			server.HandleTransactions(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body)
+			}
+			var txs []map[string]any
+			if err := json.NewDecoder(w.Body).Decode(&txs); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if len(txs) != 1 {
+				t.Fatalf("Expected 1 transaction, got %d", len(txs))
+			}
+			from, _ := txs[0]["from_name"].(string)
+			to, _ := txs[0]["to_name"].(string)
+			if from != tt.fromName || to != tt.toName {
+				t.Errorf("Expected names %q/%q, got %q/%q", tt.fromName, tt.toName, from, to)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/address"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// sseKeepAlive is how often a comment line is sent on idle streams so that
+// proxies and clients don't time the connection out.
+const sseKeepAlive = 15 * time.Second
+
+// removedEvent is the data of a removed event, announcing a transaction
+// removed by a chain reorganization.
+type removedEvent struct {
+	Transaction transaction.Transaction `json:"transaction"`
+	Reorg       *parser.Reorg           `json:"reorg,omitempty"`
+}
+
+// HandleEvents streams new transactions for an address as Server-Sent
+// Events, and those removed by chain reorganizations.
+func (s *Server) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	raw := r.URL.Query().Get("address")
+	if raw == "" {
+		http.Error(w, "missing address", http.StatusBadRequest)
+		return
+	}
+	addr, err := address.Normalize(raw)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !s.requireOwner(w, r, addr) {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	events := s.parser.Watch(r.Context(), addr)
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			name, payload := "transaction", any(ev.Transaction)
+			if ev.Removed {
+				name, payload = "removed", removedEvent{Transaction: ev.Transaction, Reorg: ev.Reorg}
+			}
+			data, err := json.Marshal(payload)
+			if err != nil {
+				requestLogger(r).Error("failed to encode event", logging.KeyAddress, addr, logging.KeyError, err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\nid: %s\ndata: %s\n\n", name, ev.Transaction.Hash, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+func TestServer_HandleEvents(t *testing.T) {
+	mock := NewMockParser()
+	ts := httptest.NewServer(New(mock).Handler())
+	defer ts.Close()
+
+	address := "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"
+	resp, err := http.Get(ts.URL + "/v1/events?address=" + address)
+	if err != nil {
+		t.Fatalf("Failed to open stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Expected text/event-stream content type, got %s", ct)
+	}
+
+	mock.events <- parser.Event{
+		Address:     address,
+		Transaction: transaction.Transaction{Hash: "0xhash1", From: "0xfrom1", To: address, Value: transaction.WeiValue(1000), Block: 1, Direction: transaction.DirectionIn},
+	}
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	next := func(prefix string) string {
+		t.Helper()
+		timeout := time.After(2 * time.Second)
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					t.Fatal("Stream closed before event arrived")
+				}
+				if strings.HasPrefix(line, prefix) {
+					return line
+				}
+			case <-timeout:
+				t.Fatal("Timed out waiting for event")
+			}
+		}
+	}
+	if line := next("data: "); !strings.Contains(line, `"hash":"0xhash1"`) {
+		t.Errorf("Unexpected event payload: %s", line)
+	}
+
+	mock.events <- parser.Event{
+		Address:     address,
+		Transaction: transaction.Transaction{Hash: "0xhash1", From: "0xfrom1", To: address, Value: transaction.WeiValue(1000), Block: 1, Direction: transaction.DirectionIn},
+		Removed:     true,
+		Reorg:       &parser.Reorg{Depth: 1, Blocks: []parser.BlockRef{{Number: 1, Hash: "0xb1"}, {Number: 2, Hash: "0xb2"}}},
+	}
+	if line := next("event: "); line != "event: removed" {
+		t.Errorf("Expected a removed event, got %s", line)
+	}
+	if line := next("data: "); !strings.Contains(line, `"transaction":{"hash":"0xhash1"`) || !strings.Contains(line, `"reorg":{"depth":1`) {
+		t.Errorf("Unexpected removed event payload: %s", line)
+	}
+}
+
+func TestServer_HandleEvents_InvalidAddress(t *testing.T) {
+	server := New(NewMockParser())
+
+	req := httptest.NewRequest(http.MethodGet, "/events?address=garbage", nil)
+	w := httptest.NewRecorder()
+	server.HandleEvents(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/audit"
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+)
+
+// expireAfter schedules the caller's subscription to addr to be removed
+// after ttl, along with its stored data when purge is set, and returns when
+// it expires.
+func (s *Server) expireAfter(r *http.Request, addr string, ttl time.Duration, purge bool) time.Time {
+	owner := tenantFrom(r)
+	return s.opts.Expiry.Schedule(owner, addr, ttl, func() { s.expire(owner, addr, purge) })
+}
+
+// expire removes owner's expired subscription to addr as an unsubscribe
+// would. Stored data is only purged once no tenant is subscribed to addr
+// anymore, so that another tenant's history is never deleted.
+func (s *Server) expire(owner, addr string, purge bool) {
+	logger := logging.Component("server").With("chain", s.opts.Chain, logging.KeyAddress, addr)
+	if owner != "" {
+		logger = logger.With("tenant", owner)
+	}
+
+	released, orphaned := s.release(owner, addr)
+	if s.opts.Webhooks != nil {
+		s.opts.Webhooks.RemoveCallback(addr, owner)
+	}
+	if s.opts.Audit != nil {
+		err := s.opts.Audit.Record(audit.Entry{
+			Action:  audit.ActionUnsubscribe,
+			Chain:   s.opts.Chain,
+			Address: addr,
+			Changed: released,
+			Source:  "expiry",
+			Tenant:  owner,
+		})
+		if err != nil {
+			logger.Error("failed to record audit entry", logging.KeyError, err)
+		}
+	}
+	if !purge || !orphaned {
+		logger.Info("subscription expired")
+		return
+	}
+
+	purger, ok := s.parser.(parser.Purger)
+	if !ok {
+		logger.Warn("subscription expired, but its data can't be purged")
+		return
+	}
+	n, err := purger.Purge(addr)
+	if err != nil {
+		logger.Error("subscription expired, but its data couldn't be purged", logging.KeyError, err)
+		return
+	}
+	logger.Info("subscription expired", "purged", n)
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/audit"
+	"github.com/danieloluwadare/tw-txparser/internal/tenant"
+)
+
+// purgingParser is a MockParser that records purged addresses.
+type purgingParser struct {
+	*MockParser
+	purged []string
+}
+
+func (p *purgingParser) Purge(address string) (int, error) {
+	p.purged = append(p.purged, address)
+	n := len(p.transactions[address])
+	delete(p.transactions, address)
+	return n, nil
+}
+
+func TestServer_SubscribeTTL(t *testing.T) {
+	const addr = "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"
+	mock := &purgingParser{MockParser: NewMockParser()}
+	s := NewWithOptions(mock, Options{})
+	handler := s.Handler()
+	post := func(path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return w
+	}
+
+	for _, body := range []string{
+		`{"address":"` + addr + `","ttl":"soon"}`,
+		`{"address":"` + addr + `","ttl":"-1h"}`,
+		`{"address":"` + addr + `","purge_on_expiry":true}`,
+	} {
+		if w := post("/v1/subscribe", body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", body, w.Code)
+		}
+	}
+
+	before := time.Now()
+	w := post("/v1/subscribe", `{"address":"`+addr+`","ttl":"24h","purge_on_expiry":true}`)
+	var resp struct {
+		Subscribed bool      `json:"subscribed"`
+		ExpiresAt  time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !resp.Subscribed || resp.ExpiresAt.Before(before.Add(24*time.Hour)) {
+		t.Errorf("Unexpected response %+v", resp)
+	}
+	if at, ok := s.opts.Expiry.ExpiresAt("", addr); !ok || !at.Equal(resp.ExpiresAt) {
+		t.Errorf("Expected an expiry at %v, got %v %v", resp.ExpiresAt, at, ok)
+	}
+
+	// Subscribing again without a ttl makes the subscription permanent, as
+	// does unsubscribing.
+	if w := post("/v1/subscribe", `{"address":"`+addr+`"}`); strings.Contains(w.Body.String(), "expires_at") {
+		t.Errorf("Expected no expiry in %s", w.Body)
+	}
+	if s.opts.Expiry.Len() != 0 {
+		t.Error("Expected resubscribing without a ttl to cancel the expiry")
+	}
+	post("/v1/subscribe", `{"address":"`+addr+`","ttl":"1h"}`)
+	post("/v1/unsubscribe", `{"address":"`+addr+`"}`)
+	if s.opts.Expiry.Len() != 0 {
+		t.Error("Expected unsubscribing to cancel the expiry")
+	}
+}
+
+func TestServer_Expire(t *testing.T) {
+	const addr = "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"
+	tenants, err := tenant.New(map[string]string{"payments": "pk", "risk": "rk"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := &purgingParser{MockParser: NewMockParser()}
+	log := audit.New()
+	s := NewWithOptions(mock, Options{Tenants: tenants, Audit: log})
+	for _, name := range []string{"payments", "risk"} {
+		tenants.Claim(name, addr)
+	}
+	mock.Subscribe(addr)
+
+	// Another tenant still watches addr, so its data is kept.
+	s.expire("payments", addr, true)
+	if !mock.subscriptions[addr] || len(mock.purged) != 0 {
+		t.Fatalf("Expected addr to stay subscribed for risk, purged %v", mock.purged)
+	}
+	if tenants.Owns("payments", addr) {
+		t.Error("Expected payments' subscription to be removed")
+	}
+
+	s.expire("risk", addr, true)
+	if mock.subscriptions[addr] {
+		t.Error("Expected the parser subscription to be removed")
+	}
+	if len(mock.purged) != 1 || mock.purged[0] != addr {
+		t.Errorf("Expected addr to be purged, got %v", mock.purged)
+	}
+
+	entries := log.Query(audit.Query{Action: audit.ActionUnsubscribe})
+	if len(entries) != 2 || entries[1].Source != "expiry" || entries[1].Tenant != "risk" || !entries[1].Changed {
+		t.Errorf("Unexpected audit entries %+v", entries)
+	}
+}
@@ -0,0 +1,218 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/labels"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// Supported response formats for transaction listings.
+const (
+	formatJSON   = "json"
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+)
+
+// Supported units for transaction values. Values are always in wei; ether
+// adds a formatted value_ether field or column alongside.
+const (
+	unitsWei   = "wei"
+	unitsEther = "ether"
+)
+
+// csvHeader is the column order used for CSV exports.
+var csvHeader = []string{"hash", "from", "to", "value", "block", "inbound", "direction", "indexed_at", "fee"}
+
+// view selects the optional annotations of transaction responses.
+type view struct {
+	// ether adds values formatted in ether.
+	ether bool
+	// names holds ENS names of counterparties by address when requested.
+	names map[string]string
+	// labels identifies well-known counterparties when configured.
+	labels labels.Registry
+}
+
+// plain reports whether v adds nothing to transactions.
+func (v view) plain() bool {
+	return !v.ether && v.names == nil && v.labels == nil
+}
+
+// annotate applies v to tx.
+func (v view) annotate(tx transaction.Transaction) transaction.Annotated {
+	a := transaction.Annotated{
+		Transaction: tx,
+		FromName:    v.names[tx.From],
+		ToName:      v.names[tx.To],
+		FromLabel:   v.label(tx.From),
+		ToLabel:     v.label(tx.To),
+	}
+	if v.ether {
+		a.ValueEther = tx.Value.Ether()
+	}
+	return a
+}
+
+// label returns the label of addr, without the redundant address, or nil.
+func (v view) label(addr string) *labels.Label {
+	if v.labels == nil || addr == "" {
+		return nil
+	}
+	l, ok := v.labels.Lookup(addr)
+	if !ok {
+		return nil
+	}
+	l.Address = ""
+	return &l
+}
+
+// labelName returns the name of the label of addr, or "".
+func (v view) labelName(addr string) string {
+	if l := v.label(addr); l != nil {
+		return l.Name
+	}
+	return ""
+}
+
+// parseUnits reports whether the units query parameter asks for values
+// formatted in ether.
+func parseUnits(r *http.Request) (bool, error) {
+	switch u := strings.ToLower(r.URL.Query().Get("units")); u {
+	case "", unitsWei:
+		return false, nil
+	case unitsEther:
+		return true, nil
+	default:
+		return false, fmt.Errorf("unsupported units %q: expected wei or ether", u)
+	}
+}
+
+// negotiateFormat picks the response format from the format query parameter,
+// falling back to the Accept header and finally JSON.
+func negotiateFormat(r *http.Request) (string, error) {
+	if f := strings.ToLower(r.URL.Query().Get("format")); f != "" {
+		switch f {
+		case formatJSON, formatCSV, formatNDJSON:
+			return f, nil
+		}
+		return "", fmt.Errorf("unsupported format %q: expected json, csv or ndjson", f)
+	}
+
+	accept := r.Header.Get("Accept")
+	switch {
+	case strings.Contains(accept, "text/csv"):
+		return formatCSV, nil
+	case strings.Contains(accept, "application/x-ndjson"):
+		return formatNDJSON, nil
+	}
+	return formatJSON, nil
+}
+
+// writeTransactions encodes txs to w in the requested format, annotated as
+// selected by v.
+func writeTransactions(w http.ResponseWriter, format, filename string, txs []transaction.Transaction, v view) error {
+	switch format {
+	case formatCSV:
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".csv"))
+		return writeCSV(w, txs, v)
+	case formatNDJSON:
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		return writeNDJSON(w, txs, v)
+	default:
+		if v.plain() {
+			return json.NewEncoder(w).Encode(txs)
+		}
+		out := make([]transaction.Annotated, len(txs))
+		for i, tx := range txs {
+			out[i] = v.annotate(tx)
+		}
+		return json.NewEncoder(w).Encode(out)
+	}
+}
+
+// writeCSV writes txs as CSV with a header row. Annotations selected by v
+// are appended as value_ether, from_name, to_name, from_label and to_label
+// columns.
+func writeCSV(w io.Writer, txs []transaction.Transaction, v view) error {
+	cw := csv.NewWriter(w)
+	header := csvHeader[:len(csvHeader):len(csvHeader)]
+	if v.ether {
+		header = append(header, "value_ether")
+	}
+	if v.names != nil {
+		header = append(header, "from_name", "to_name")
+	}
+	if v.labels != nil {
+		header = append(header, "from_label", "to_label")
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, tx := range txs {
+		record := []string{
+			tx.Hash,
+			tx.From,
+			tx.To,
+			tx.Value.String(),
+			strconv.Itoa(tx.Block),
+			strconv.FormatBool(tx.Inbound()),
+			string(tx.Direction),
+			formatIndexedAt(tx.IndexedAt),
+			formatFee(tx.Fee),
+		}
+		if v.ether {
+			record = append(record, tx.Value.Ether())
+		}
+		if v.names != nil {
+			record = append(record, v.names[tx.From], v.names[tx.To])
+		}
+		if v.labels != nil {
+			record = append(record, v.labelName(tx.From), v.labelName(tx.To))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatIndexedAt renders t for CSV, leaving unknown times empty.
+func formatIndexedAt(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// formatFee renders fee for CSV, leaving unknown fees empty.
+func formatFee(fee *transaction.Value) string {
+	if fee == nil {
+		return ""
+	}
+	return fee.String()
+}
+
+// writeNDJSON writes one JSON object per line.
+func writeNDJSON(w io.Writer, txs []transaction.Transaction, v view) error {
+	enc := json.NewEncoder(w)
+	for _, tx := range txs {
+		var out any = tx
+		if !v.plain() {
+			out = v.annotate(tx)
+		}
+		if err := enc.Encode(out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+func TestServer_HandleTransactions_Formats(t *testing.T) {
+	mock := NewMockParser()
+	address := "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"
+	mock.transactions[address] = []transaction.Transaction{
+		{Hash: "0xhash1", From: "0xfrom1", To: address, Value: transaction.WeiValue(1000), Block: 1, Direction: transaction.DirectionIn},
+		{Hash: "0xhash2", From: address, To: "0xto2", Value: transaction.WeiValue(2000), Block: 2, Direction: transaction.DirectionOut},
+	}
+	server := New(mock)
+
+	tests := []struct {
+		name            string
+		query           string
+		accept          string
+		expectedStatus  int
+		expectedContent string
+	}{
+		{name: "csv via accept", accept: "text/csv", expectedStatus: http.StatusOK, expectedContent: "text/csv"},
+		{name: "ndjson via accept", accept: "application/x-ndjson", expectedStatus: http.StatusOK, expectedContent: "application/x-ndjson"},
+		{name: "csv via query", query: "&format=csv", expectedStatus: http.StatusOK, expectedContent: "text/csv"},
+		{name: "query overrides accept", query: "&format=ndjson", accept: "text/csv", expectedStatus: http.StatusOK, expectedContent: "application/x-ndjson"},
+		{name: "unknown format", query: "&format=xml", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/transactions?address="+address+tt.query, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			server.HandleTransactions(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedContent != "" && w.Header().Get("Content-Type") != tt.expectedContent {
+				t.Errorf("Expected content type %s, got %s", tt.expectedContent, w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	w := httptest.NewRecorder()
+	txs := []transaction.Transaction{{Hash: "0xhash1", From: "0xfrom1", To: "0xto1", Value: transaction.WeiValue(1000), Block: 7, Direction: transaction.DirectionIn}}
+	if err := writeTransactions(w, formatCSV, "export", txs, view{}); err != nil {
+		t.Fatalf("writeTransactions failed: %v", err)
+	}
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse CSV: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected header and 1 row, got %d records", len(records))
+	}
+	if records[0][0] != "hash" || records[1][0] != "0xhash1" || records[1][4] != "7" || records[1][5] != "true" {
+		t.Errorf("Unexpected CSV content: %v", records)
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename="export.csv"` {
+		t.Errorf("Unexpected Content-Disposition: %s", cd)
+	}
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	txs := []transaction.Transaction{
+		{Hash: "0xhash1", Block: 1},
+		{Hash: "0xhash2", Block: 2},
+	}
+	if err := writeTransactions(w, formatNDJSON, "export", txs, view{}); err != nil {
+		t.Fatalf("writeTransactions failed: %v", err)
+	}
+
+	scanner := bufio.NewScanner(w.Body)
+	var lines int
+	for scanner.Scan() {
+		var tx transaction.Transaction
+		if err := json.Unmarshal(scanner.Bytes(), &tx); err != nil {
+			t.Fatalf("Line %d is not valid JSON: %v", lines, err)
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Errorf("Expected 2 lines, got %d", lines)
+	}
+}
+
+func TestServer_HandleTransactions_Units(t *testing.T) {
+	mock := NewMockParser()
+	address := "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"
+	mock.transactions[address] = []transaction.Transaction{
+		{Hash: "0xhash1", From: "0xfrom1", To: address, Value: transaction.MustParseValue("1500000000000000000"), Block: 1, Direction: transaction.DirectionIn},
+	}
+	server := New(mock)
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedEther  string
+	}{
+		{name: "default wei", expectedStatus: http.StatusOK},
+		{name: "explicit wei", query: "&units=wei", expectedStatus: http.StatusOK},
+		{name: "ether", query: "&units=ether", expectedStatus: http.StatusOK, expectedEther: "1.5"},
+		{name: "ether csv", query: "&units=ether&format=csv", expectedStatus: http.StatusOK, expectedEther: "1.5"},
+		{name: "unknown units", query: "&units=gwei", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/transactions?address="+address+tt.query, nil)
+			w := httptest.NewRecorder()
+			server.HandleTransactions(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var value, ether string
+			if w.Header().Get("Content-Type") == "text/csv" {
+				records, err := csv.NewReader(w.Body).ReadAll()
+				if err != nil {
+					t.Fatalf("Failed to parse CSV: %v", err)
+				}
+				header := records[0]
+				if header[len(header)-1] != "value_ether" {
+					t.Fatalf("Expected a value_ether column, got %v", header)
+				}
+				value, ether = records[1][3], records[1][len(header)-1]
+			} else {
+				var txs []map[string]any
+				if err := json.NewDecoder(w.Body).Decode(&txs); err != nil {
+					t.Fatalf("Failed to decode response: %v", err)
+				}
+				value, _ = txs[0]["value"].(string)
+				ether, _ = txs[0]["value_ether"].(string)
+			}
+			if value != "1500000000000000000" {
+				t.Errorf("Expected value in wei, got %q", value)
+			}
+			if ether != tt.expectedEther {
+				t.Errorf("Expected value_ether %q, got %q", tt.expectedEther, ether)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+)
+
+// HandleGas returns the base fee and priority fee percentiles of the
+// latest blocks via GET /gas, for clients sending transactions alongside
+// monitoring.
+func (s *Server) HandleGas(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	oracle, ok := s.parser.(parser.GasOracle)
+	if !ok {
+		http.Error(w, parser.ErrGasUnsupported.Error(), http.StatusNotImplemented)
+		return
+	}
+
+	stats, err := oracle.GasStats(r.Context())
+	if errors.Is(err, parser.ErrGasUnsupported) {
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	} else if err != nil {
+		requestLogger(r).Error("failed to get gas statistics", logging.KeyError, err)
+		http.Error(w, "failed to get gas statistics", http.StatusBadGateway)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// gasParser is a MockParser reporting fixed gas statistics.
+type gasParser struct {
+	*MockParser
+	err error
+}
+
+func (p *gasParser) GasStats(context.Context) (parser.GasStats, error) {
+	return parser.GasStats{
+		Block:        18500000,
+		Blocks:       20,
+		BaseFee:      transaction.WeiValue(20_000_000_000),
+		NextBaseFee:  transaction.WeiValue(21_000_000_000),
+		PriorityFees: []parser.PriorityFee{{Percentile: 50, Fee: transaction.WeiValue(1_000_000_000)}},
+	}, p.err
+}
+
+func TestServer_HandleGas(t *testing.T) {
+	mock := &gasParser{MockParser: NewMockParser()}
+	handler := New(mock).Handler()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/gas", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	var out map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&out); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	fees, _ := out["priority_fees"].([]interface{})
+	if out["base_fee"] != "20000000000" || out["next_base_fee"] != "21000000000" || len(fees) != 1 {
+		t.Errorf("Unexpected response %v", out)
+	}
+
+	mock.err = errors.New("boom")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/gas", nil))
+	if w.Code != http.StatusBadGateway {
+		t.Errorf("Expected 502 when the node fails, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	New(NewMockParser()).Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/gas", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("Expected 501 without gas support, got %d", w.Code)
+	}
+}
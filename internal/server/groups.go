@@ -0,0 +1,208 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/audit"
+	"github.com/danieloluwadare/tw-txparser/internal/group"
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/address"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// HandleGroups lists the caller's address groups via GET /groups, or
+// creates one via POST {"name":"...","addresses":[...]}. Posting an
+// existing name replaces its members. With subscribe=true, the members are
+// subscribed as well.
+func (s *Server) HandleGroups(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{"groups": s.opts.Groups.List(tenantFrom(r))}); err != nil {
+			requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+		}
+	case http.MethodPost:
+		s.createGroup(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) createGroup(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name      string   `json:"name"`
+		Addresses []string `json:"addresses"`
+		Subscribe bool     `json:"subscribe"`
+	}
+	if !decodeJSON(w, r, &body) {
+		return
+	}
+	if body.Name == "" {
+		http.Error(w, "missing name", http.StatusBadRequest)
+		return
+	}
+	if err := group.ValidateName(body.Name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	addrs := make([]string, len(body.Addresses))
+	for i, raw := range body.Addresses {
+		addr, err := address.Normalize(raw)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("addresses[%d]: %v", i, err), http.StatusBadRequest)
+			return
+		}
+		addrs[i] = addr
+	}
+
+	g, created, err := s.opts.Groups.Set(tenantFrom(r), body.Name, addrs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp := struct {
+		group.Group
+		Subscribed int `json:"subscribed,omitempty"`
+	}{Group: g}
+	if body.Subscribe {
+		resp.Subscribed = s.subscribeGroup(r, g)
+	}
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
+
+// HandleGroup returns the group named by the {name} path value via GET, or
+// deletes it via DELETE. Deleting a group leaves its members subscribed.
+func (s *Server) HandleGroup(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	switch r.Method {
+	case http.MethodGet:
+		g, ok := s.lookupGroup(w, r, name)
+		if !ok {
+			return
+		}
+		if err := json.NewEncoder(w).Encode(g); err != nil {
+			requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+		}
+	case http.MethodDelete:
+		if !s.opts.Groups.Delete(tenantFrom(r), name) {
+			http.Error(w, group.ErrNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleSubscribeGroup subscribes every member of the group named by the
+// {name} path value via POST, as /subscribe would.
+func (s *Server) HandleSubscribeGroup(w http.ResponseWriter, r *http.Request) {
+	s.handleGroupSubscription(w, r, "subscribed", s.subscribeGroup)
+}
+
+// HandleUnsubscribeGroup unsubscribes every member of the group named by
+// the {name} path value via POST, as /unsubscribe would. The group itself
+// is kept.
+func (s *Server) HandleUnsubscribeGroup(w http.ResponseWriter, r *http.Request) {
+	s.handleGroupSubscription(w, r, "unsubscribed", s.unsubscribeGroup)
+}
+
+func (s *Server) handleGroupSubscription(w http.ResponseWriter, r *http.Request, field string, apply func(*http.Request, group.Group) int) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	g, ok := s.lookupGroup(w, r, r.PathValue("name"))
+	if !ok {
+		return
+	}
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"group": g.Name, field: apply(r, g)}); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
+
+// subscribeGroup subscribes the members of g for the caller and returns
+// how many weren't subscribed already.
+func (s *Server) subscribeGroup(r *http.Request, g group.Group) int {
+	n := 0
+	for _, addr := range g.Addresses {
+		ok := s.subscribe(r, addr)
+		s.opts.Expiry.Cancel(tenantFrom(r), addr)
+		s.recordAudit(r, audit.ActionSubscribe, "group", addr, ok)
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+// unsubscribeGroup unsubscribes the members of g for the caller and
+// returns how many were subscribed.
+func (s *Server) unsubscribeGroup(r *http.Request, g group.Group) int {
+	n := 0
+	for _, addr := range g.Addresses {
+		ok := s.unsubscribe(r, addr)
+		s.opts.Expiry.Cancel(tenantFrom(r), addr)
+		if s.opts.Webhooks != nil {
+			s.opts.Webhooks.RemoveCallback(addr, tenantFrom(r))
+		}
+		s.recordAudit(r, audit.ActionUnsubscribe, "group", addr, ok)
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+// lookupGroup returns the caller's group name, writing a 404 response if it
+// doesn't exist.
+func (s *Server) lookupGroup(w http.ResponseWriter, r *http.Request, name string) (group.Group, bool) {
+	g, err := s.opts.Groups.Get(tenantFrom(r), name)
+	if errors.Is(err, group.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return group.Group{}, false
+	}
+	return g, err == nil
+}
+
+// groupTransactions merges the transactions of the members of g the caller
+// may see, ordered by block. A transaction between two members is listed
+// once for each of them, with its direction relative to that member.
+func (s *Server) groupTransactions(r *http.Request, g group.Group, fetch func(addr string) ([]transaction.Transaction, error)) ([]transaction.Transaction, error) {
+	out := []transaction.Transaction{}
+	for _, addr := range g.Addresses {
+		if !s.owns(r, addr) {
+			continue
+		}
+		txs, err := fetch(addr)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, txs...)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Block < out[j].Block })
+	return out, nil
+}
+
+// addressTransactions returns a function fetching the transactions of an
+// address mined in [since, until), or all of them when both are zero.
+func (s *Server) addressTransactions(ranger parser.TimeRanger, since, until time.Time) func(string) ([]transaction.Transaction, error) {
+	if since.IsZero() && until.IsZero() {
+		return func(addr string) ([]transaction.Transaction, error) {
+			return s.parser.GetTransactions(addr), nil
+		}
+	}
+	return func(addr string) ([]transaction.Transaction, error) {
+		return ranger.TransactionsBetween(addr, since, until)
+	}
+}
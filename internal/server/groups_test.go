@@ -0,0 +1,145 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danieloluwadare/tw-txparser/internal/tenant"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+func TestServer_Groups(t *testing.T) {
+	const (
+		hot  = "0x1111111111111111111111111111111111111111"
+		cold = "0x2222222222222222222222222222222222222222"
+	)
+	mock := NewMockParser()
+	mock.transactions[hot] = []transaction.Transaction{
+		{Hash: "0x01", From: hot, Block: 1, Direction: transaction.DirectionOut},
+		{Hash: "0x03", From: hot, Block: 3, Direction: transaction.DirectionOut},
+	}
+	mock.transactions[cold] = []transaction.Transaction{{Hash: "0x02", To: cold, Block: 2, Direction: transaction.DirectionIn}}
+	handler := New(mock).Handler()
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do(http.MethodPost, "/v1/groups", `{"name":"treasury","addresses":["0xnope"]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid member, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/v1/groups", `{"name":"Treasury!","addresses":[]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid name, got %d", w.Code)
+	}
+	w := do(http.MethodPost, "/v1/groups", `{"name":"treasury","addresses":["`+cold+`","`+hot+`","`+hot+`"],"subscribe":true}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body)
+	}
+	var created struct {
+		Addresses  []string `json:"addresses"`
+		Subscribed int      `json:"subscribed"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if created.Subscribed != 2 || len(created.Addresses) != 2 || created.Addresses[0] != hot {
+		t.Errorf("Unexpected group %+v", created)
+	}
+	if !mock.subscriptions[hot] || !mock.subscriptions[cold] {
+		t.Error("Expected the members to be subscribed")
+	}
+
+	// Transactions of all members are merged in block order.
+	w = do(http.MethodGet, "/v1/transactions?group=treasury", "")
+	var txs []transaction.Transaction
+	if err := json.NewDecoder(w.Body).Decode(&txs); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(txs) != 3 || txs[0].Hash != "0x01" || txs[1].Hash != "0x02" || txs[2].Hash != "0x03" {
+		t.Errorf("Unexpected merged transactions %+v", txs)
+	}
+
+	// Pages carry the total and the offset of the next page.
+	w = do(http.MethodGet, "/v1/transactions?group=treasury&limit=2", "")
+	if got := w.Header().Get("X-Total-Count"); got != "3" {
+		t.Errorf("Expected X-Total-Count 3, got %q", got)
+	}
+	if got := w.Header().Get("X-Next-Offset"); got != "2" {
+		t.Errorf("Expected X-Next-Offset 2, got %q", got)
+	}
+	w = do(http.MethodGet, "/v1/transactions?group=treasury&limit=2&offset=2", "")
+	txs = nil
+	if err := json.NewDecoder(w.Body).Decode(&txs); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(txs) != 1 || txs[0].Hash != "0x03" || w.Header().Get("X-Next-Offset") != "" {
+		t.Errorf("Unexpected last page %+v", txs)
+	}
+
+	if w := do(http.MethodGet, "/v1/transactions?group=treasury&address="+hot, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for both address and group, got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/v1/transactions?group=ops", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown group, got %d", w.Code)
+	}
+
+	w = do(http.MethodPost, "/v1/groups/treasury/unsubscribe", "")
+	if w.Code != http.StatusOK || mock.subscriptions[hot] || mock.subscriptions[cold] {
+		t.Errorf("Expected the members to be unsubscribed, got %d", w.Code)
+	}
+	if w := do(http.MethodDelete, "/v1/groups/treasury", ""); w.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/v1/groups/treasury", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 after delete, got %d", w.Code)
+	}
+}
+
+func TestServer_GroupsScopedToTenants(t *testing.T) {
+	const addr = "0x1111111111111111111111111111111111111111"
+	tenants, err := tenant.New(map[string]string{"payments": "pk", "risk": "rk"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := NewMockParser()
+	mock.transactions[addr] = []transaction.Transaction{{Hash: "0x01", From: addr, Block: 1}}
+	handler := NewWithOptions(mock, Options{Tenants: tenants}).Handler()
+	do := func(method, path, key, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(apiKeyHeader, key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	do(http.MethodPost, "/v1/groups", "pk", `{"name":"treasury","addresses":["`+addr+`"]}`)
+	if w := do(http.MethodGet, "/v1/groups/treasury", "rk", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for another tenant's group, got %d", w.Code)
+	}
+
+	// Members the tenant doesn't own are left out of merged results.
+	w := do(http.MethodGet, "/v1/transactions?group=treasury", "pk", "")
+	var txs []transaction.Transaction
+	if err := json.NewDecoder(w.Body).Decode(&txs); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(txs) != 0 {
+		t.Errorf("Expected no transactions before subscribing, got %+v", txs)
+	}
+	do(http.MethodPost, "/v1/groups/treasury/subscribe", "pk", "")
+	w = do(http.MethodGet, "/v1/transactions?group=treasury", "pk", "")
+	if err := json.NewDecoder(w.Body).Decode(&txs); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(txs) != 1 {
+		t.Errorf("Expected the owned member's transaction, got %+v", txs)
+	}
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+)
+
+// HandleHealthz is a liveness probe: it reports ok whenever the process is
+// serving HTTP. Chains with several RPC providers also get the health of
+// each, and with leader election the instance's role, for information only.
+func (s *Server) HandleHealthz(w http.ResponseWriter, r *http.Request) {
+	resp := struct {
+		Status    string                          `json:"status"`
+		Role      string                          `json:"role,omitempty"`
+		Providers map[string][]rpc.ProviderStatus `json:"providers,omitempty"`
+	}{Status: "ok"}
+	if s.opts.Leader != nil {
+		resp.Role = "standby"
+		if s.opts.Leader() {
+			resp.Role = "leader"
+		}
+	}
+
+	chains := s.opts.Chains
+	if len(chains) == 0 {
+		chains = map[string]*Server{s.opts.Chain: s}
+	}
+	for name, cs := range chains {
+		if cs.opts.Providers == nil {
+			continue
+		}
+		if resp.Providers == nil {
+			resp.Providers = make(map[string][]rpc.ProviderStatus)
+		}
+		resp.Providers[name] = cs.opts.Providers()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
+
+// HandleReadyz is a readiness probe: it reports ready once every indexed chain
+// has processed its first block, and 503 with per-chain details otherwise.
+func (s *Server) HandleReadyz(w http.ResponseWriter, r *http.Request) {
+	resp := struct {
+		Status string          `json:"status"`
+		Chains map[string]bool `json:"chains,omitempty"`
+	}{Status: "ready"}
+
+	ready := s.parser.GetCurrentBlock() > 0
+	if len(s.opts.Chains) > 0 {
+		resp.Chains = make(map[string]bool, len(s.opts.Chains))
+		for name, cs := range s.opts.Chains {
+			ok := cs.parser.GetCurrentBlock() > 0
+			resp.Chains[name] = ok
+			ready = ready && ok
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !ready {
+		resp.Status = "not ready"
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+)
+
+func TestServer_HandleHealthz(t *testing.T) {
+	handler := New(NewMockParser()).Handler()
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if body := w.Body.String(); strings.Contains(body, "providers") {
+		t.Errorf("Expected no provider details without providers, got %s", body)
+	}
+}
+
+func TestServer_HandleHealthz_Providers(t *testing.T) {
+	s := NewWithOptions(NewMockParser(), Options{Chain: "ethereum", Providers: func() []rpc.ProviderStatus {
+		return []rpc.ProviderStatus{
+			{Provider: "a.example", Healthy: true, Head: 100, ChainID: 1},
+			{Provider: "b.example", Reason: "10 blocks behind", Head: 90, ChainID: 1},
+		}
+	}})
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected an unhealthy provider not to fail liveness, got %d", w.Code)
+	}
+	var resp struct {
+		Status    string                          `json:"status"`
+		Providers map[string][]rpc.ProviderStatus `json:"providers"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if got := resp.Providers["ethereum"]; len(got) != 2 || got[1].Healthy || got[1].Reason != "10 blocks behind" {
+		t.Errorf("Unexpected providers %+v", resp.Providers)
+	}
+}
+
+func TestServer_HandleHealthz_Role(t *testing.T) {
+	var leader bool
+	s := NewWithOptions(NewMockParser(), Options{Leader: func() bool { return leader }})
+	role := func() string {
+		w := httptest.NewRecorder()
+		s.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		var resp struct {
+			Role string `json:"role"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return resp.Role
+	}
+	if got := role(); got != "standby" {
+		t.Errorf("Expected standby, got %q", got)
+	}
+	leader = true
+	if got := role(); got != "leader" {
+		t.Errorf("Expected leader, got %q", got)
+	}
+}
+
+func TestServer_HandleReadyz(t *testing.T) {
+	tests := []struct {
+		name           string
+		mainBlock      int
+		otherBlock     int
+		expectedStatus int
+	}{
+		{name: "all chains indexed", mainBlock: 10, otherBlock: 5, expectedStatus: http.StatusOK},
+		{name: "default chain not started", mainBlock: 0, otherBlock: 5, expectedStatus: http.StatusServiceUnavailable},
+		{name: "other chain not started", mainBlock: 10, otherBlock: 0, expectedStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mainnet := NewMockParser()
+			mainnet.currentBlock = tt.mainBlock
+			other := NewMockParser()
+			other.currentBlock = tt.otherBlock
+
+			root := NewWithOptions(mainnet, Options{})
+			root.opts.Chains = map[string]*Server{
+				"ethereum": root,
+				"sepolia":  New(other),
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+			w := httptest.NewRecorder()
+			root.Handler().ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			var response struct {
+				Status string          `json:"status"`
+				Chains map[string]bool `json:"chains"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response.Chains["sepolia"] != (tt.otherBlock > 0) {
+				t.Errorf("Unexpected sepolia readiness: %v", response.Chains)
+			}
+		})
+	}
+}
@@ -2,32 +2,272 @@
 package server
 
 import (
+	"context"
+	"encoding/hex"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/danieloluwadare/tw-txparser/internal/audit"
+	"github.com/danieloluwadare/tw-txparser/internal/deadletter"
+	"github.com/danieloluwadare/tw-txparser/internal/expiry"
+	"github.com/danieloluwadare/tw-txparser/internal/group"
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/internal/notify"
+	"github.com/danieloluwadare/tw-txparser/internal/tenant"
+	"github.com/danieloluwadare/tw-txparser/internal/version"
+	"github.com/danieloluwadare/tw-txparser/internal/webhook"
+	"github.com/danieloluwadare/tw-txparser/pkg/labels"
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
 	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
 )
 
 // Server hosts HTTP handlers that proxy to a parser.Parser.
 type Server struct {
 	parser parser.Parser
+	opts   Options
+
+	mu  sync.Mutex
+	srv *http.Server // set by Start
+
+	inFlightMu sync.Mutex
+	inFlight   map[string]int // requests being served by route
+}
+
+// Options configures optional Server behavior.
+type Options struct {
+	// AdminToken protects /admin endpoints via "Authorization: Bearer <token>".
+	// Admin endpoints are disabled when empty.
+	AdminToken string
+	// Chain names the network this instance indexes, reported by /version.
+	Chain string
+	// ChainID is the chain's EIP-155 ID, reported by /version when known.
+	ChainID int64
+	// BackwardScanEnabled and BackwardScanDepth mirror the parser settings for /version.
+	BackwardScanEnabled bool
+	BackwardScanDepth   int
+	// Webhooks enables the /webhooks registration API when non-nil.
+	Webhooks *webhook.Registry
+	// WebhookTargets restricts the addresses webhook URLs may resolve to;
+	// nil refuses loopback, private and link-local ones. Webhooks should
+	// be delivered with the same restriction.
+	WebhookTargets *webhook.Targets
+	// Metrics records API request counts. Defaults to metrics.Nop.
+	Metrics metrics.Recorder
+	// MetricsHandler is served on /metrics when non-nil.
+	MetricsHandler http.Handler
+	// ENS resolves names given in place of addresses and, on request, the
+	// names of counterparties when non-nil.
+	ENS NameResolver
+	// Labels annotates well-known counterparties in transaction responses
+	// when non-nil.
+	Labels labels.Registry
+	// Audit records subscription changes and enables /admin/audit when non-nil.
+	Audit *audit.Log
+	// DeadLetters enables /admin/deadletters, listing and re-driving the
+	// chain's dead-lettered blocks, when non-nil.
+	DeadLetters *deadletter.Queue
+	// Sinks enables /admin/sinks, reporting notification sink stats, when non-nil.
+	Sinks *notify.Dispatcher
+	// Snapshot enables /admin/dump, serving the diagnostic snapshot it
+	// returns as JSON, when non-nil.
+	Snapshot func() interface{}
+	// LogControl enables /admin/logging, reading and changing the log level
+	// and transaction log sampling of the process while it runs.
+	LogControl bool
+	// MaxBodyBytes caps request body size. Defaults to 1 MiB.
+	MaxBodyBytes int64
+	// RequestTimeout bounds non-streaming handlers. Defaults to 30s.
+	RequestTimeout time.Duration
+	// Tenants scopes subscriptions to API keys when non-nil: data endpoints
+	// require a key, and each key only sees the addresses it subscribed.
+	Tenants *tenant.Registry
+	// Chains mounts a server per indexed chain under /v1/{name}/. The
+	// unscoped routes keep serving this server's own parser.
+	Chains map[string]*Server
+	// Providers reports the health of the chain's RPC providers on
+	// /healthz when non-nil, e.g. (*rpc.Balancer).Providers.
+	Providers func() []rpc.ProviderStatus
+	// Leader reports on /healthz whether this instance is the elected
+	// leader when non-nil, e.g. (*leader.Elector).IsLeader.
+	Leader func() bool
+	// Groups keeps the named address groups served on /groups. Servers
+	// sharing a parser should share it too. Defaults to a new registry.
+	Groups *group.Registry
+	// Expiry schedules the removal of subscriptions created with a TTL.
+	// Servers sharing a parser should share it too, so that unsubscribing
+	// through either cancels the expiry. Defaults to a new scheduler.
+	Expiry *expiry.Scheduler
 }
 
 // New constructs a Server with the provided parser.
 func New(p parser.Parser) *Server {
-	return &Server{parser: p}
+	return NewWithOptions(p, Options{})
+}
+
+// NewWithOptions constructs a Server with the provided parser and options.
+func NewWithOptions(p parser.Parser, opts Options) *Server {
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = 1 << 20
+	}
+	if opts.RequestTimeout <= 0 {
+		opts.RequestTimeout = 30 * time.Second
+	}
+	opts.Metrics = metrics.OrNop(opts.Metrics)
+	if opts.Expiry == nil {
+		opts.Expiry = expiry.New()
+	}
+	if opts.Groups == nil {
+		opts.Groups = group.New()
+	}
+	return &Server{parser: p, opts: opts, inFlight: make(map[string]int)}
 }
 
-// Start binds handlers and starts listening on addr.
+// Start binds handlers and starts listening on addr. It blocks until the
+// server fails or Shutdown is called, in which case it returns nil.
+// Header and body reads are bounded to protect against slow-loris clients;
+// no write timeout is set so event streams can stay open.
 func (s *Server) Start(addr string) error {
-	http.HandleFunc("/subscribe", s.HandleSubscribe)
-	http.HandleFunc("/current", s.HandleCurrentBlock)
-	http.HandleFunc("/transactions", s.HandleTransactions)
-	return http.ListenAndServe(addr, nil)
+	// Request contexts derive from base, which Shutdown cancels so that
+	// long-lived event streams end instead of holding up the drain.
+	base, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return base },
+	}
+	srv.RegisterOnShutdown(cancel)
+
+	s.mu.Lock()
+	s.srv = srv
+	s.mu.Unlock()
+
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests to
+// finish or ctx to expire. It is a no-op if the server was never started.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	srv := s.srv
+	s.mu.Unlock()
+	if srv == nil {
+		return nil
+	}
+	return srv.Shutdown(ctx)
+}
+
+// Handler returns the HTTP routing layer. Each API version is mounted under
+// its own prefix so future versions can be served alongside existing ones.
+// The unversioned paths are kept as aliases of v1 for existing integrations.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	s.registerV1(mux, "/v1")
+	s.registerV1(mux, "")
+	for _, name := range s.chainNames() {
+		s.opts.Chains[name].registerV1(mux, "/v1/"+name)
+	}
+	// Probes are unversioned so orchestrator configs never need to change.
+	mux.Handle("/healthz", s.withTimeout(http.HandlerFunc(s.HandleHealthz)))
+	mux.Handle("/readyz", s.withTimeout(http.HandlerFunc(s.HandleReadyz)))
+	if s.opts.MetricsHandler != nil {
+		mux.Handle("/metrics", s.opts.MetricsHandler)
+	}
+	return requestID(traceRequests(s.instrument(mux, recoverer(s.limitBody(mux)))))
+}
+
+// chainNames returns the names of the mounted chains in sorted order.
+func (s *Server) chainNames() []string {
+	names := make([]string, 0, len(s.opts.Chains))
+	for name := range s.opts.Chains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// registerV1 mounts the v1 API endpoints under prefix.
+func (s *Server) registerV1(mux *http.ServeMux, prefix string) {
+	handle := func(pattern string, h http.Handler) {
+		mux.Handle(prefix+pattern, s.withTimeout(h))
+	}
+	handle("/subscribe", s.requireKey(http.HandlerFunc(s.HandleSubscribe)))
+	handle("/unsubscribe", s.requireKey(http.HandlerFunc(s.HandleUnsubscribe)))
+	handle("/subscriptions/export", s.requireKey(http.HandlerFunc(s.HandleExportSubscriptions)))
+	handle("/subscriptions/import", s.requireKey(http.HandlerFunc(s.HandleImportSubscriptions)))
+	handle("/current", http.HandlerFunc(s.HandleCurrentBlock))
+	handle("/transactions", s.requireKey(http.HandlerFunc(s.HandleTransactions)))
+	handle("/transactions/{hash}", s.requireKey(http.HandlerFunc(s.HandleTransaction)))
+	handle("/token-transfers", s.requireKey(http.HandlerFunc(s.HandleTokenTransfers)))
+	handle("/activity", s.requireKey(http.HandlerFunc(s.HandleActivity)))
+	handle("/balance", s.requireKey(http.HandlerFunc(s.HandleBalance)))
+	handle("/balances", s.requireKey(http.HandlerFunc(s.HandleBalances)))
+	handle("/allowances", s.requireKey(http.HandlerFunc(s.HandleAllowances)))
+	handle("/logs", s.requireKey(http.HandlerFunc(s.HandleLogs)))
+	handle("/logs/subscribe", s.requireKey(http.HandlerFunc(s.HandleSubscribeEvents)))
+	handle("/logs/unsubscribe", s.requireKey(http.HandlerFunc(s.HandleUnsubscribeEvents)))
+	handle("/logs/subscriptions", s.requireKey(http.HandlerFunc(s.HandleEventSubscriptions)))
+	handle("/contracts", s.requireKey(http.HandlerFunc(s.HandleWatchedContracts)))
+	handle("/contracts/watch", s.requireKey(http.HandlerFunc(s.HandleWatchContract)))
+	handle("/contracts/unwatch", s.requireKey(http.HandlerFunc(s.HandleUnwatchContract)))
+	handle("/contracts/calls", s.requireKey(http.HandlerFunc(s.HandleContractCalls)))
+	handle("/groups", s.requireKey(http.HandlerFunc(s.HandleGroups)))
+	handle("/groups/{name}", s.requireKey(http.HandlerFunc(s.HandleGroup)))
+	handle("/groups/{name}/subscribe", s.requireKey(http.HandlerFunc(s.HandleSubscribeGroup)))
+	handle("/groups/{name}/unsubscribe", s.requireKey(http.HandlerFunc(s.HandleUnsubscribeGroup)))
+	handle("/stats", s.requireKey(http.HandlerFunc(s.HandleStats)))
+	handle("/gas", s.requireKey(http.HandlerFunc(s.HandleGas)))
+	handle("/blocks/transactions", s.requireKey(http.HandlerFunc(s.HandleBlockTransactions)))
+	handle("/version", http.HandlerFunc(s.HandleVersion))
+	if s.opts.Webhooks != nil {
+		handle("/webhooks", s.requireKey(http.HandlerFunc(s.HandleWebhooks)))
+		handle("/webhooks/{id}", s.requireKey(http.HandlerFunc(s.HandleWebhook)))
+	}
+	handle("/admin/rescan", s.requireAdmin(http.HandlerFunc(s.HandleRescan)))
+	if s.opts.Audit != nil {
+		handle("/admin/audit", s.requireAdmin(http.HandlerFunc(s.HandleAudit)))
+	}
+	if s.opts.DeadLetters != nil {
+		handle("/admin/deadletters", s.requireAdmin(http.HandlerFunc(s.HandleDeadLetters)))
+		handle("/admin/deadletters/redrive", s.requireAdmin(http.HandlerFunc(s.HandleRedrive)))
+	}
+	if s.opts.Sinks != nil {
+		handle("/admin/sinks", s.requireAdmin(http.HandlerFunc(s.HandleSinks)))
+	}
+	if s.opts.Snapshot != nil {
+		handle("/admin/dump", s.requireAdmin(http.HandlerFunc(s.HandleDump)))
+	}
+	if s.opts.LogControl {
+		handle("/admin/logging", s.requireAdmin(http.HandlerFunc(s.HandleLogging)))
+	}
+
+	// Streaming responses are exempt from the request timeout.
+	mux.Handle(prefix+"/events", s.requireKey(http.HandlerFunc(s.HandleEvents)))
+	mux.Handle(prefix+"/logs/events", s.requireKey(http.HandlerFunc(s.HandleLogEvents)))
 }
 
-// HandleSubscribe subscribes an address via POST {"address":"..."}.
+// HandleSubscribe subscribes an address via POST {"address":"..."}. An
+// optional callback_url, with an optional callback_secret, registers a
+// webhook receiving the address's transactions; the response then includes
+// it with its signing secret. An optional ttl, e.g. "24h", unsubscribes the
+// address once it elapses, and purge_on_expiry then also deletes its stored
+// data; subscribing again without a ttl makes the subscription permanent.
 func (s *Server) HandleSubscribe(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
@@ -35,38 +275,544 @@ func (s *Server) HandleSubscribe(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var body struct {
-		Address string `json:"address"`
+		Address        string `json:"address"`
+		CallbackURL    string `json:"callback_url"`
+		CallbackSecret string `json:"callback_secret"`
+		TTL            string `json:"ttl"`
+		PurgeOnExpiry  bool   `json:"purge_on_expiry"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+	if !decodeJSON(w, r, &body) {
 		return
 	}
 	if body.Address == "" {
 		http.Error(w, "missing address", http.StatusBadRequest)
 		return
 	}
+	var ttl time.Duration
+	if body.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(body.TTL); err != nil || ttl <= 0 {
+			http.Error(w, "invalid ttl: expected a positive duration such as 24h", http.StatusBadRequest)
+			return
+		}
+	} else if body.PurgeOnExpiry {
+		http.Error(w, "purge_on_expiry requires a ttl", http.StatusBadRequest)
+		return
+	}
+	var callbackURL string
+	if body.CallbackURL != "" {
+		if s.opts.Webhooks == nil {
+			http.Error(w, "callback_url is not supported: webhooks are disabled", http.StatusBadRequest)
+			return
+		}
+		var err error
+		if callbackURL, err = s.parseWebhookURL(r.Context(), body.CallbackURL); err != nil {
+			http.Error(w, "invalid callback_url: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := checkWebhookSecret(body.CallbackSecret); err != nil {
+			http.Error(w, "invalid callback_secret: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	addr, ok := s.resolveAddress(w, r, body.Address)
+	if !ok {
+		return
+	}
 
-	ok := s.parser.Subscribe(body.Address)
-	if err := json.NewEncoder(w).Encode(map[string]bool{"subscribed": ok}); err != nil {
-		log.Println("failed to encode response:", err)
+	resp := struct {
+		Subscribed bool             `json:"subscribed"`
+		ExpiresAt  time.Time        `json:"expires_at,omitzero"`
+		Webhook    *webhook.Webhook `json:"webhook,omitempty"`
+	}{Subscribed: s.subscribe(r, addr)}
+	s.recordAudit(r, audit.ActionSubscribe, "subscribe", addr, resp.Subscribed)
+	if ttl > 0 {
+		resp.ExpiresAt = s.expireAfter(r, addr, ttl, body.PurgeOnExpiry)
+	} else {
+		s.opts.Expiry.Cancel(tenantFrom(r), addr)
+	}
+	if callbackURL != "" {
+		hook, err := s.opts.Webhooks.RegisterCallback(callbackURL, body.CallbackSecret, addr, tenantFrom(r))
+		if err != nil {
+			requestLogger(r).Error("failed to register callback", logging.KeyAddress, addr, logging.KeyError, err)
+			http.Error(w, "failed to register callback", http.StatusInternalServerError)
+			return
+		}
+		resp.Webhook = &hook
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
 	}
 }
 
-// HandleCurrentBlock returns the latest known block as {"block":N}.
+// HandleUnsubscribe stops tracking an address via POST {"address":"..."}
+// and removes its callback webhook and pending expiry, if any. Transactions
+// already stored for it are kept.
+func (s *Server) HandleUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		Address string `json:"address"`
+	}
+	if !decodeJSON(w, r, &body) {
+		return
+	}
+	if body.Address == "" {
+		http.Error(w, "missing address", http.StatusBadRequest)
+		return
+	}
+	addr, ok := s.resolveAddress(w, r, body.Address)
+	if !ok {
+		return
+	}
+
+	ok = s.unsubscribe(r, addr)
+	s.opts.Expiry.Cancel(tenantFrom(r), addr)
+	if s.opts.Webhooks != nil {
+		s.opts.Webhooks.RemoveCallback(addr, tenantFrom(r))
+	}
+	s.recordAudit(r, audit.ActionUnsubscribe, "subscribe", addr, ok)
+	if err := json.NewEncoder(w).Encode(map[string]bool{"unsubscribed": ok}); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
+
+// recordAudit adds a watchlist change made by r to the audit log, if enabled.
+// A failure to persist the entry is logged but doesn't fail the request, as
+// the change has already been applied.
+func (s *Server) recordAudit(r *http.Request, action, source, addr string, changed bool) {
+	if s.opts.Audit == nil {
+		return
+	}
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		ip = host
+	}
+	err := s.opts.Audit.Record(audit.Entry{
+		Action:       action,
+		Chain:        s.opts.Chain,
+		Address:      addr,
+		Changed:      changed,
+		Source:       source,
+		RemoteIP:     ip,
+		ForwardedFor: r.Header.Get("X-Forwarded-For"),
+		RequestID:    logging.RequestID(r.Context()),
+		Tenant:       tenantFrom(r),
+	})
+	if err != nil {
+		requestLogger(r).Error("failed to record audit entry", logging.KeyAddress, addr, logging.KeyError, err)
+	}
+}
+
+// HandleCurrentBlock returns the last processed block as {"block":N}, along
+// with the node's head, the lag between them and the backward scan's
+// progress when the parser reports them.
 func (s *Server) HandleCurrentBlock(w http.ResponseWriter, _ *http.Request) {
+	if sr, ok := s.parser.(parser.StatusReporter); ok {
+		json.NewEncoder(w).Encode(sr.Status())
+		return
+	}
 	json.NewEncoder(w).Encode(map[string]int{"block": s.parser.GetCurrentBlock()})
 }
 
-// HandleTransactions returns transactions associated with a given address query param.
+// HandleVersion reports build metadata and the indexing configuration.
+func (s *Server) HandleVersion(w http.ResponseWriter, r *http.Request) {
+	resp := struct {
+		version.Info
+		Chain        string   `json:"chain"`
+		ChainID      int64    `json:"chain_id,omitempty"`
+		Chains       []string `json:"chains,omitempty"`
+		BackwardScan struct {
+			Enabled bool `json:"enabled"`
+			Depth   int  `json:"depth"`
+		} `json:"backward_scan"`
+	}{Info: version.Get(), Chain: s.opts.Chain, ChainID: s.opts.ChainID, Chains: s.chainNames()}
+	resp.BackwardScan.Enabled = s.opts.BackwardScanEnabled
+	resp.BackwardScan.Depth = s.opts.BackwardScanDepth
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
+
+// HandleTransactions returns transactions associated with a given address query param,
+// or merged across the members of an address group with group=name.
+// The response is JSON by default; CSV and NDJSON are selected via the Accept
+// header or the format query param. units=ether adds values formatted in
+// ether, ens=true the ENS names of counterparties, and indexed_since limits
+// the response to transactions indexed after the given time. since and until
+// limit it to transactions mined in [since, until), and category to the
+// given comma-separated categories. limit and offset page through it.
 func (s *Server) HandleTransactions(w http.ResponseWriter, r *http.Request) {
-	addr := r.URL.Query().Get("address")
-	if addr == "" {
+	raw, groupName := r.URL.Query().Get("address"), r.URL.Query().Get("group")
+	if raw == "" && groupName == "" {
 		http.Error(w, "missing address", http.StatusBadRequest)
 		return
 	}
-	txs := s.parser.GetTransactions(addr)
-	if err := json.NewEncoder(w).Encode(txs); err != nil {
-		log.Println("failed to encode response:", err)
+	if raw != "" && groupName != "" {
+		http.Error(w, "address and group are mutually exclusive", http.StatusBadRequest)
+		return
+	}
+	format, err := negotiateFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ether, err := parseUnits(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	names, err := s.wantNames(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	since, err := parseIndexedSince(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	minedSince, minedUntil, err := parseTimeRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	categories, err := parseCategories(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	pg, err := parsePage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ranger, ok := s.parser.(parser.TimeRanger)
+	if !ok && (!minedSince.IsZero() || !minedUntil.IsZero()) {
+		http.Error(w, parser.ErrTimeRangeUnsupported.Error(), http.StatusNotImplemented)
+		return
+	}
+	fetch := s.addressTransactions(ranger, minedSince, minedUntil)
+
+	var txs []transaction.Transaction
+	var name string
+	// The revision is read before the transactions, so that an update in
+	// between makes the ETag stale rather than hiding the update.
+	var revision uint64
+	if groupName != "" {
+		g, ok := s.lookupGroup(w, r, groupName)
+		if !ok {
+			return
+		}
+		name = g.Name
+		revision = s.revision(g.Addresses...)
+		txs, err = s.groupTransactions(r, g, fetch)
+	} else {
+		addr, ok := s.resolveAddress(w, r, raw)
+		if !ok || !s.requireOwner(w, r, addr) {
+			return
+		}
+		name = addr
+		revision = s.revision(addr)
+		txs, err = fetch(addr)
+		s.setTruncation(w, addr)
+	}
+	if errors.Is(err, parser.ErrTimeRangeUnsupported) {
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	} else if err != nil {
+		requestLogger(r).Error("failed to get transactions", "target", name, logging.KeyError, err)
+		http.Error(w, "failed to get transactions", http.StatusInternalServerError)
+		return
+	}
+	txs = pg.apply(w, inCategories(indexedSince(txs, since), categories))
+	variant := format
+	if ether {
+		variant += "-" + unitsEther
+	}
+	if names {
+		variant += "-ens"
+	}
+	if len(categories) > 0 {
+		variant += "-" + r.URL.Query().Get("category")
+	}
+	if pg.limit > 0 {
+		variant += fmt.Sprintf("-%d-%d", pg.offset, pg.limit)
+	}
+	etag := transactionsETag(variant, revision, txs)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Vary", "Accept")
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	v := view{ether: ether, labels: s.opts.Labels}
+	if names {
+		v.names = s.lookupNames(r, txs)
+	}
+	if err := writeTransactions(w, format, "transactions-"+name, txs, v); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
+
+// maxPageSize caps the limit query parameter.
+const maxPageSize = 1000
+
+// page is a window into a list of transactions, selected by the limit and
+// offset query parameters. A zero limit selects everything.
+type page struct {
+	offset, limit int
+}
+
+// parsePage parses the optional limit and offset query parameters.
+func parsePage(r *http.Request) (page, error) {
+	q := r.URL.Query()
+	var pg page
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageSize {
+			return page{}, fmt.Errorf("invalid limit %q: expected an integer from 1 to %d", v, maxPageSize)
+		}
+		pg.limit = n
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return page{}, fmt.Errorf("invalid offset %q: expected a non-negative integer", v)
+		}
+		if pg.limit == 0 {
+			return page{}, errors.New("offset requires a limit")
+		}
+		pg.offset = n
+	}
+	return pg, nil
+}
+
+// apply returns the window of txs selected by pg. When paginating, it sets
+// X-Total-Count and, unless this is the last page, X-Next-Offset.
+func (pg page) apply(w http.ResponseWriter, txs []transaction.Transaction) []transaction.Transaction {
+	if pg.limit == 0 {
+		return txs
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(txs)))
+	start := min(pg.offset, len(txs))
+	end := min(start+pg.limit, len(txs))
+	if end < len(txs) {
+		w.Header().Set("X-Next-Offset", strconv.Itoa(end))
+	}
+	return txs[start:end]
+}
+
+// setTruncation sets the X-Truncated and X-Earliest-Block headers when the
+// stored history of addr was cut down to the storage's per-address cap.
+func (s *Server) setTruncation(w http.ResponseWriter, addr string) {
+	reporter, ok := s.parser.(parser.TruncationReporter)
+	if !ok {
+		return
+	}
+	if t := reporter.Truncation(addr); t.Truncated {
+		w.Header().Set("X-Truncated", "true")
+		w.Header().Set("X-Earliest-Block", strconv.Itoa(t.EarliestBlock))
+	}
+}
+
+// parseIndexedSince parses the indexed_since query parameter, an RFC 3339
+// time. The zero time is returned when it is absent.
+func parseIndexedSince(r *http.Request) (time.Time, error) {
+	v := r.URL.Query().Get("indexed_since")
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid indexed_since %q: expected an RFC 3339 time", v)
+	}
+	return t, nil
+}
+
+// parseTimeRange parses the since and until query parameters, RFC 3339
+// times or Unix seconds. Absent parameters are returned as the zero time.
+func parseTimeRange(r *http.Request) (since, until time.Time, err error) {
+	q := r.URL.Query()
+	if since, err = parseTimestamp("since", q.Get("since")); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if until, err = parseTimestamp("until", q.Get("until")); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if !since.IsZero() && !until.IsZero() && !until.After(since) {
+		return time.Time{}, time.Time{}, errors.New("invalid time range: until must be after since")
+	}
+	return since, until, nil
+}
+
+// parseTimestamp parses v, the value of query parameter name, as an RFC
+// 3339 time or Unix seconds.
+func parseTimestamp(name, v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil && secs > 0 {
+		return time.Unix(secs, 0).UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s %q: expected an RFC 3339 time or Unix seconds", name, v)
+	}
+	return t, nil
+}
+
+// indexedSince returns the transactions indexed strictly after t, or txs
+// itself if t is zero.
+func indexedSince(txs []transaction.Transaction, t time.Time) []transaction.Transaction {
+	if t.IsZero() {
+		return txs
+	}
+	out := make([]transaction.Transaction, 0, len(txs))
+	for _, tx := range txs {
+		if tx.IndexedAt.After(t) {
+			out = append(out, tx)
+		}
+	}
+	return out
+}
+
+// parseCategories parses the category query parameter, a comma-separated
+// list of transaction categories. nil is returned when it is absent.
+func parseCategories(r *http.Request) (map[transaction.Category]bool, error) {
+	v := r.URL.Query().Get("category")
+	if v == "" {
+		return nil, nil
+	}
+	out := make(map[transaction.Category]bool)
+	for _, name := range strings.Split(v, ",") {
+		c, err := transaction.ParseCategory(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		out[c] = true
+	}
+	return out, nil
+}
+
+// inCategories returns the transactions in one of categories, or txs
+// itself if categories is empty.
+func inCategories(txs []transaction.Transaction, categories map[transaction.Category]bool) []transaction.Transaction {
+	if len(categories) == 0 {
+		return txs
+	}
+	out := make([]transaction.Transaction, 0, len(txs))
+	for _, tx := range txs {
+		if categories[tx.Category] {
+			out = append(out, tx)
+		}
+	}
+	return out
+}
+
+// transactionsETag derives a cheap version token for an address's history
+// from its revision, its size and the highest block seen, so unchanged
+// histories can be answered with 304 Not Modified. The revision catches
+// updates that leave the size and blocks alone, such as receipts and
+// categories filled in after a transaction was stored. variant
+// distinguishes response encodings.
+func transactionsETag(variant string, revision uint64, txs []transaction.Transaction) string {
+	lastBlock := 0
+	for _, tx := range txs {
+		if tx.Block > lastBlock {
+			lastBlock = tx.Block
+		}
+	}
+	return fmt.Sprintf(`W/"%s-%d-%d-%d"`, variant, revision, len(txs), lastBlock)
+}
+
+// revision sums the revisions of the stored transactions of addrs, which
+// changes whenever any of them does, or returns 0 if the parser doesn't
+// report revisions.
+func (s *Server) revision(addrs ...string) uint64 {
+	reporter, ok := s.parser.(parser.RevisionReporter)
+	if !ok {
+		return 0
+	}
+	var sum uint64
+	for _, addr := range addrs {
+		sum += reporter.Revision(addr)
+	}
+	return sum
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// HandleTransaction returns a single transaction by the {hash} path value.
+// units=ether adds the value formatted in ether and ens=true the ENS names of
+// the counterparties.
+func (s *Server) HandleTransaction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ether, err := parseUnits(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	names, err := s.wantNames(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	hash := strings.ToLower(r.PathValue("hash"))
+	if !isTxHash(hash) {
+		http.Error(w, "invalid transaction hash: expected 0x-prefixed 64 character hex string", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := s.parser.GetTransaction(r.Context(), hash)
+	if errors.Is(err, parser.ErrTransactionNotFound) || (err == nil && !s.visibleTransaction(r, tx)) {
+		http.Error(w, "transaction not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		requestLogger(r).Error("failed to look up transaction", "hash", hash, logging.KeyError, err)
+		http.Error(w, "failed to look up transaction", http.StatusBadGateway)
+		return
+	}
+	v := view{ether: ether, labels: s.opts.Labels}
+	if names {
+		v.names = s.lookupNames(r, []transaction.Transaction{tx})
+	}
+	var resp any = tx
+	if !v.plain() {
+		resp = v.annotate(tx)
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
+
+// isTxHash reports whether h is a 0x-prefixed 32-byte hex string.
+func isTxHash(h string) bool {
+	if len(h) != 66 || !strings.HasPrefix(h, "0x") {
+		return false
 	}
+	_, err := hex.DecodeString(h[2:])
+	return err == nil
 }
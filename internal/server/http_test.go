@@ -2,11 +2,17 @@ package server
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
 	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
 )
 
@@ -14,13 +20,19 @@ import (
 type MockParser struct {
 	currentBlock  int
 	transactions  map[string][]transaction.Transaction
+	tokens        map[string][]transaction.TokenTransfer
 	subscriptions map[string]bool
+	events        chan parser.Event
+	rescans       [][2]int
+	rescanErr     error
 }
 
 func NewMockParser() *MockParser {
 	return &MockParser{
 		transactions:  make(map[string][]transaction.Transaction),
+		tokens:        make(map[string][]transaction.TokenTransfer),
 		subscriptions: make(map[string]bool),
+		events:        make(chan parser.Event, 1),
 	}
 }
 
@@ -36,10 +48,45 @@ func (m *MockParser) Subscribe(address string) bool {
 	return true
 }
 
+func (m *MockParser) Unsubscribe(address string) bool {
+	if !m.subscriptions[address] {
+		return false
+	}
+	delete(m.subscriptions, address)
+	return true
+}
+
 func (m *MockParser) GetTransactions(address string) []transaction.Transaction {
 	return m.transactions[address]
 }
 
+func (m *MockParser) GetTokenTransfers(address string) []transaction.TokenTransfer {
+	return m.tokens[address]
+}
+
+func (m *MockParser) GetTransaction(ctx context.Context, hash string) (transaction.Transaction, error) {
+	for _, txs := range m.transactions {
+		for _, tx := range txs {
+			if tx.Hash == hash {
+				return tx, nil
+			}
+		}
+	}
+	return transaction.Transaction{}, parser.ErrTransactionNotFound
+}
+
+func (m *MockParser) Rescan(from, to int) error {
+	if m.rescanErr != nil {
+		return m.rescanErr
+	}
+	m.rescans = append(m.rescans, [2]int{from, to})
+	return nil
+}
+
+func (m *MockParser) Watch(ctx context.Context, addresses ...string) <-chan parser.Event {
+	return m.events
+}
+
 func TestServer_New(t *testing.T) {
 	parser := NewMockParser()
 	server := New(parser)
@@ -67,7 +114,7 @@ func TestServer_HandleSubscribe(t *testing.T) {
 			name:   "successful subscription",
 			method: http.MethodPost,
 			body: map[string]string{
-				"address": "0x1234567890abcdef",
+				"address": "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6",
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody:   map[string]bool{"subscribed": true},
@@ -76,7 +123,7 @@ func TestServer_HandleSubscribe(t *testing.T) {
 			name:   "duplicate subscription",
 			method: http.MethodPost,
 			body: map[string]string{
-				"address": "0x1234567890abcdef",
+				"address": "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6",
 			},
 			expectedStatus: http.StatusOK,
 			expectedBody:   map[string]bool{"subscribed": false},
@@ -104,6 +151,24 @@ func TestServer_HandleSubscribe(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   nil,
 		},
+		{
+			name:   "malformed address",
+			method: http.MethodPost,
+			body: map[string]string{
+				"address": "not-an-address",
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   nil,
+		},
+		{
+			name:   "checksummed address is normalized",
+			method: http.MethodPost,
+			body: map[string]string{
+				"address": "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   map[string]bool{"subscribed": true},
+		},
 	}
 
 	for _, tt := range tests {
@@ -136,6 +201,51 @@ func TestServer_HandleSubscribe(t *testing.T) {
 	}
 }
 
+func TestServer_HandleUnsubscribe(t *testing.T) {
+	parser := NewMockParser()
+	parser.Subscribe("0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6")
+	server := New(parser)
+
+	tests := []struct {
+		name           string
+		method         string
+		body           string
+		expectedStatus int
+		expected       bool
+	}{
+		{name: "subscribed address", method: http.MethodPost, body: `{"address":"0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"}`, expectedStatus: http.StatusOK, expected: true},
+		{name: "already unsubscribed", method: http.MethodPost, body: `{"address":"0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"}`, expectedStatus: http.StatusOK, expected: false},
+		{name: "wrong method", method: http.MethodGet, expectedStatus: http.StatusMethodNotAllowed},
+		{name: "missing address", method: http.MethodPost, body: `{}`, expectedStatus: http.StatusBadRequest},
+		{name: "malformed address", method: http.MethodPost, body: `{"address":"nope"}`, expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/unsubscribe", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			server.HandleUnsubscribe(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var response map[string]bool
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response["unsubscribed"] != tt.expected {
+				t.Errorf("Expected unsubscribed %t, got %t", tt.expected, response["unsubscribed"])
+			}
+		})
+	}
+	if parser.subscriptions["0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"] {
+		t.Error("Expected address to be unsubscribed")
+	}
+}
+
 func TestServer_HandleCurrentBlock(t *testing.T) {
 	parser := NewMockParser()
 	parser.currentBlock = 12345
@@ -160,15 +270,40 @@ func TestServer_HandleCurrentBlock(t *testing.T) {
 	}
 }
 
+// statusParser reports a Status.
+type statusParser struct {
+	*MockParser
+	status parser.Status
+}
+
+func (p *statusParser) Status() parser.Status { return p.status }
+
+func TestServer_HandleCurrentBlock_Status(t *testing.T) {
+	mock := &statusParser{MockParser: NewMockParser(), status: parser.Status{
+		Head: 110, Block: 100, Lag: 10,
+		BackwardScan: &parser.ScanProgress{From: 99, To: 50, Block: 80, Remaining: 30},
+	}}
+	w := httptest.NewRecorder()
+	New(mock).Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/current", nil))
+
+	var got parser.Status
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if got.Head != 110 || got.Block != 100 || got.Lag != 10 || got.BackwardScan == nil || got.BackwardScan.Remaining != 30 {
+		t.Errorf("Unexpected status %+v", got)
+	}
+}
+
 func TestServer_HandleTransactions(t *testing.T) {
 	parser := NewMockParser()
 	server := New(parser)
 
 	// Add some test transactions
-	address := "0x1234567890abcdef"
+	address := "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"
 	transactions := []transaction.Transaction{
-		{Hash: "0xhash1", From: "0xfrom1", To: address, Value: "1000", Block: 1, Inbound: true},
-		{Hash: "0xhash2", From: "0xfrom2", To: address, Value: "2000", Block: 2, Inbound: true},
+		{Hash: "0xhash1", From: "0xfrom1", To: address, Value: transaction.WeiValue(1000), Block: 1, Direction: transaction.DirectionIn},
+		{Hash: "0xhash2", From: "0xfrom2", To: address, Value: transaction.WeiValue(2000), Block: 2, Direction: transaction.DirectionIn},
 	}
 	parser.transactions[address] = transactions
 
@@ -180,7 +315,7 @@ func TestServer_HandleTransactions(t *testing.T) {
 	}{
 		{
 			name:           "valid address",
-			queryParams:    "?address=0x1234567890abcdef",
+			queryParams:    "?address=0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6",
 			expectedStatus: http.StatusOK,
 			expectedCount:  2,
 		},
@@ -196,9 +331,15 @@ func TestServer_HandleTransactions(t *testing.T) {
 			expectedStatus: http.StatusBadRequest,
 			expectedCount:  0,
 		},
+		{
+			name:           "malformed address",
+			queryParams:    "?address=0x1234",
+			expectedStatus: http.StatusBadRequest,
+			expectedCount:  0,
+		},
 		{
 			name:           "non-existent address",
-			queryParams:    "?address=0xnonexistent",
+			queryParams:    "?address=0x0000000000000000000000000000000000000001",
 			expectedStatus: http.StatusOK,
 			expectedCount:  0,
 		},
@@ -249,12 +390,60 @@ func TestServer_Start(t *testing.T) {
 	}
 }
 
+func TestServer_Shutdown(t *testing.T) {
+	// Reserve a free port for Start, which takes an address rather than a listener.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to reserve port: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	server := New(NewMockParser())
+	startErr := make(chan error, 1)
+	go func() { startErr <- server.Start(addr) }()
+
+	// Open an event stream, which would block a plain drain indefinitely.
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		resp, err = http.Get("http://" + addr + "/v1/events?address=0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6")
+		if err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("Server did not come up: %v", err)
+	}
+	defer resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	select {
+	case err := <-startErr:
+		if err != nil {
+			t.Errorf("Expected Start to return nil after Shutdown, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Start did not return after Shutdown")
+	}
+}
+
+func TestServer_Shutdown_NotStarted(t *testing.T) {
+	if err := New(NewMockParser()).Shutdown(context.Background()); err != nil {
+		t.Errorf("Expected no error for unstarted server, got %v", err)
+	}
+}
+
 func TestServer_Integration(t *testing.T) {
 	parser := NewMockParser()
 	server := New(parser)
 
 	// Test full flow: subscribe -> get current block -> get transactions
-	address := "0x1234567890abcdef"
+	address := "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"
 
 	// 1. Subscribe to address
 	subscribeBody := map[string]string{"address": address}
@@ -311,3 +500,475 @@ func TestServer_ErrorHandling(t *testing.T) {
 		t.Errorf("Expected status %d for invalid JSON, got %d", http.StatusBadRequest, w.Code)
 	}
 }
+
+func TestServer_Handler_Versioning(t *testing.T) {
+	parser := NewMockParser()
+	parser.currentBlock = 42
+	handler := New(parser).Handler()
+
+	for _, path := range []string{"/v1/current", "/current"} {
+		t.Run(path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+			}
+			var response map[string]int
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response["block"] != 42 {
+				t.Errorf("Expected block 42, got %d", response["block"])
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v2/current", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for unknown version, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestServer_Handler_Chains(t *testing.T) {
+	mainnet := NewMockParser()
+	mainnet.currentBlock = 100
+	sepolia := NewMockParser()
+	sepolia.currentBlock = 7
+
+	root := NewWithOptions(mainnet, Options{Chain: "ethereum"})
+	root.opts.Chains = map[string]*Server{
+		"ethereum": root,
+		"sepolia":  NewWithOptions(sepolia, Options{Chain: "sepolia"}),
+	}
+	handler := root.Handler()
+
+	tests := []struct {
+		path          string
+		expectedBlock int
+	}{
+		{path: "/v1/current", expectedBlock: 100},
+		{path: "/v1/ethereum/current", expectedBlock: 100},
+		{path: "/v1/sepolia/current", expectedBlock: 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+			}
+			var response map[string]int
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response["block"] != tt.expectedBlock {
+				t.Errorf("Expected block %d, got %d", tt.expectedBlock, response["block"])
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/polygon/current", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for unknown chain, got %d", http.StatusNotFound, w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/version", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	var version struct {
+		Chains []string `json:"chains"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&version); err != nil {
+		t.Fatalf("Failed to decode version: %v", err)
+	}
+	if len(version.Chains) != 2 || version.Chains[0] != "ethereum" || version.Chains[1] != "sepolia" {
+		t.Errorf("Unexpected chains in version: %v", version.Chains)
+	}
+}
+
+func TestServer_HandleTransaction(t *testing.T) {
+	mock := NewMockParser()
+	hash := "0x88df016429689c079f3b2f6ad39fa052532c56795b733da78a91ebe6a713944b"
+	mock.transactions["0xfrom1"] = []transaction.Transaction{{Hash: hash, From: "0xfrom1", To: "0xto1", Value: transaction.WeiValue(1000), Block: 1}}
+	handler := New(mock).Handler()
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+	}{
+		{name: "known hash", path: "/v1/transactions/" + hash, expectedStatus: http.StatusOK},
+		{name: "uppercase hash", path: "/v1/transactions/" + "0x88DF016429689C079F3B2F6AD39FA052532C56795B733DA78A91EBE6A713944B", expectedStatus: http.StatusOK},
+		{name: "unknown hash", path: "/v1/transactions/0x" + strings.Repeat("0", 64), expectedStatus: http.StatusNotFound},
+		{name: "malformed hash", path: "/v1/transactions/0x1234", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus == http.StatusOK {
+				var tx transaction.Transaction
+				if err := json.NewDecoder(w.Body).Decode(&tx); err != nil {
+					t.Fatalf("Failed to decode response: %v", err)
+				}
+				if tx.Hash != hash {
+					t.Errorf("Expected hash %s, got %s", hash, tx.Hash)
+				}
+			}
+		})
+	}
+}
+
+func TestServer_HandleVersion(t *testing.T) {
+	server := NewWithOptions(NewMockParser(), Options{Chain: "sepolia", ChainID: 11155111, BackwardScanEnabled: true, BackwardScanDepth: 500})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/version", nil)
+	w := httptest.NewRecorder()
+	server.Handler().ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var response struct {
+		Version      string `json:"version"`
+		GoVersion    string `json:"go_version"`
+		Chain        string `json:"chain"`
+		ChainID      int64  `json:"chain_id"`
+		BackwardScan struct {
+			Enabled bool `json:"enabled"`
+			Depth   int  `json:"depth"`
+		} `json:"backward_scan"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Version == "" || response.GoVersion == "" {
+		t.Errorf("Expected version fields to be populated: %+v", response)
+	}
+	if response.Chain != "sepolia" || response.ChainID != 11155111 {
+		t.Errorf("Expected chain sepolia (11155111), got %s (%d)", response.Chain, response.ChainID)
+	}
+	if !response.BackwardScan.Enabled || response.BackwardScan.Depth != 500 {
+		t.Errorf("Unexpected backward scan settings: %+v", response.BackwardScan)
+	}
+}
+
+func TestServer_HandleTransactions_ETag(t *testing.T) {
+	mock := NewMockParser()
+	address := "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"
+	mock.transactions[address] = []transaction.Transaction{
+		{Hash: "0xhash1", From: "0xfrom1", To: address, Value: transaction.WeiValue(1000), Block: 1, Direction: transaction.DirectionIn},
+	}
+	server := New(mock)
+
+	req := httptest.NewRequest(http.MethodGet, "/transactions?address="+address, nil)
+	w := httptest.NewRecorder()
+	server.HandleTransactions(w, req)
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("Expected ETag header")
+	}
+
+	// Unchanged history yields 304 with no body.
+	req = httptest.NewRequest(http.MethodGet, "/transactions?address="+address, nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	server.HandleTransactions(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("Expected status %d, got %d", http.StatusNotModified, w.Code)
+	}
+	if w.Body.Len() != 0 {
+		t.Errorf("Expected empty body for 304, got %q", w.Body.String())
+	}
+
+	// A new transaction changes the token.
+	mock.transactions[address] = append(mock.transactions[address],
+		transaction.Transaction{Hash: "0xhash2", From: "0xfrom2", To: address, Value: transaction.WeiValue(2000), Block: 2, Direction: transaction.DirectionIn})
+	w = httptest.NewRecorder()
+	server.HandleTransactions(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d after change, got %d", http.StatusOK, w.Code)
+	}
+	if w.Header().Get("ETag") == etag {
+		t.Error("Expected ETag to change after new transaction")
+	}
+
+	// Tokens are per representation.
+	req = httptest.NewRequest(http.MethodGet, "/transactions?address="+address+"&format=csv", nil)
+	req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	server.HandleTransactions(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected JSON ETag not to match CSV representation, got %d", w.Code)
+	}
+}
+
+// revisionParser is a MockParser reporting a revision per address.
+type revisionParser struct {
+	*MockParser
+	revisions map[string]uint64
+}
+
+func (p *revisionParser) Revision(address string) uint64 {
+	return p.revisions[address]
+}
+
+func TestServer_HandleTransactions_ETag_Revision(t *testing.T) {
+	mock := &revisionParser{NewMockParser(), map[string]uint64{}}
+	address := "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"
+	mock.transactions[address] = []transaction.Transaction{
+		{Hash: "0xhash1", From: "0xfrom1", To: address, Value: transaction.WeiValue(1000), Block: 1, Direction: transaction.DirectionIn},
+	}
+	mock.revisions[address] = 1
+	server := New(mock)
+
+	req := httptest.NewRequest(http.MethodGet, "/transactions?address="+address, nil)
+	w := httptest.NewRecorder()
+	server.HandleTransactions(w, req)
+	req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+
+	// A receipt filled in place keeps the size and blocks but not the
+	// revision.
+	mock.transactions[address] = []transaction.Transaction{
+		{Hash: "0xhash1", From: "0xfrom1", To: address, Value: transaction.WeiValue(1000), Block: 1, Direction: transaction.DirectionIn, Status: transaction.StatusSuccess},
+	}
+	mock.revisions[address] = 2
+	w = httptest.NewRecorder()
+	server.HandleTransactions(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d after an in-place update, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestServer_HandleTransactions_IndexedSince(t *testing.T) {
+	mock := NewMockParser()
+	address := "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mock.transactions[address] = []transaction.Transaction{
+		{Hash: "0xhash1", From: "0xfrom1", To: address, Value: transaction.WeiValue(1), Block: 1, Direction: transaction.DirectionIn, IndexedAt: base},
+		{Hash: "0xhash2", From: "0xfrom2", To: address, Value: transaction.WeiValue(2), Block: 2, Direction: transaction.DirectionIn, IndexedAt: base.Add(time.Second)},
+	}
+	server := New(mock)
+
+	tests := []struct {
+		name           string
+		since          string
+		expectedStatus int
+		expectedHashes []string
+	}{
+		{name: "absent", expectedStatus: http.StatusOK, expectedHashes: []string{"0xhash1", "0xhash2"}},
+		{name: "exclusive", since: "2024-05-01T12:00:00Z", expectedStatus: http.StatusOK, expectedHashes: []string{"0xhash2"}},
+		{name: "offset", since: "2024-05-01T14:00:00.5%2B02:00", expectedStatus: http.StatusOK, expectedHashes: []string{"0xhash2"}},
+		{name: "after all", since: "2024-05-01T12:00:01Z", expectedStatus: http.StatusOK, expectedHashes: []string{}},
+		{name: "invalid", since: "yesterday", expectedStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := "/transactions?address=" + address
+			if tt.since != "" {
+				url += "&indexed_since=" + tt.since
+			}
+			req := httptest.NewRequest(http.MethodGet, url, nil)
+			w := httptest.NewRecorder()
+			server.HandleTransactions(w, req)
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var txs []transaction.Transaction
+			if err := json.NewDecoder(w.Body).Decode(&txs); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			hashes := []string{}
+			for _, tx := range txs {
+				hashes = append(hashes, tx.Hash)
+			}
+			if strings.Join(hashes, ",") != strings.Join(tt.expectedHashes, ",") {
+				t.Errorf("Expected %v, got %v", tt.expectedHashes, hashes)
+			}
+		})
+	}
+}
+
+func TestServer_HandleTransactions_Category(t *testing.T) {
+	mock := NewMockParser()
+	address := "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"
+	mock.transactions[address] = []transaction.Transaction{
+		{Hash: "0xhash1", From: address, To: "0xto", Block: 1, Category: transaction.CategoryTransfer},
+		{Hash: "0xhash2", From: address, To: "0xtoken", Block: 2, Category: transaction.CategoryTokenTransfer},
+		{Hash: "0xhash3", From: address, Block: 3, Category: transaction.CategoryDeployment},
+	}
+	server := New(mock)
+
+	tests := []struct {
+		name           string
+		category       string
+		expectedStatus int
+		expectedHashes []string
+	}{
+		{name: "single", category: "token_transfer", expectedStatus: http.StatusOK, expectedHashes: []string{"0xhash2"}},
+		{name: "several", category: "transfer,deployment", expectedStatus: http.StatusOK, expectedHashes: []string{"0xhash1", "0xhash3"}},
+		{name: "none matching", category: "contract_call", expectedStatus: http.StatusOK, expectedHashes: []string{}},
+		{name: "invalid", category: "swap", expectedStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/transactions?address="+address+"&category="+tt.category, nil)
+			w := httptest.NewRecorder()
+			server.HandleTransactions(w, req)
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var txs []transaction.Transaction
+			if err := json.NewDecoder(w.Body).Decode(&txs); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			hashes := []string{}
+			for _, tx := range txs {
+				hashes = append(hashes, tx.Hash)
+			}
+			if strings.Join(hashes, ",") != strings.Join(tt.expectedHashes, ",") {
+				t.Errorf("Expected %v, got %v", tt.expectedHashes, hashes)
+			}
+		})
+	}
+}
+
+// timeParser is a MockParser whose transactions were mined a day apart,
+// starting on 2024-05-31, block 1 first.
+type timeParser struct {
+	*MockParser
+}
+
+func (p *timeParser) TransactionsBetween(address string, since, until time.Time) ([]transaction.Transaction, error) {
+	out := []transaction.Transaction{}
+	for _, tx := range p.transactions[address] {
+		mined := time.Date(2024, 5, 30+tx.Block, 0, 0, 0, 0, time.UTC)
+		if !mined.Before(since) && (until.IsZero() || mined.Before(until)) {
+			out = append(out, tx)
+		}
+	}
+	return out, nil
+}
+
+func TestServer_HandleTransactions_TimeRange(t *testing.T) {
+	mock := &timeParser{MockParser: NewMockParser()}
+	address := "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"
+	for block := 1; block <= 3; block++ {
+		mock.transactions[address] = append(mock.transactions[address], transaction.Transaction{
+			Hash: fmt.Sprintf("0xhash%d", block), To: address, Value: transaction.WeiValue(1), Block: block, Direction: transaction.DirectionIn,
+		})
+	}
+	handler := NewWithOptions(mock, Options{}).Handler()
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedHashes []string
+	}{
+		{name: "absent", expectedStatus: http.StatusOK, expectedHashes: []string{"0xhash1", "0xhash2", "0xhash3"}},
+		{name: "this month", query: "&since=2024-06-01T00:00:00Z&until=2024-07-01T00:00:00Z", expectedStatus: http.StatusOK, expectedHashes: []string{"0xhash2", "0xhash3"}},
+		{name: "until is exclusive", query: "&until=2024-06-01T00:00:00Z", expectedStatus: http.StatusOK, expectedHashes: []string{"0xhash1"}},
+		{name: "unix seconds", query: "&since=1717286400", expectedStatus: http.StatusOK, expectedHashes: []string{"0xhash3"}},
+		{name: "invalid since", query: "&since=last+month", expectedStatus: http.StatusBadRequest},
+		{name: "inverted", query: "&since=2024-06-02T00:00:00Z&until=2024-06-01T00:00:00Z", expectedStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/transactions?address="+address+tt.query, nil))
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, w.Code, w.Body)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+			var txs []transaction.Transaction
+			if err := json.NewDecoder(w.Body).Decode(&txs); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			hashes := []string{}
+			for _, tx := range txs {
+				hashes = append(hashes, tx.Hash)
+			}
+			if strings.Join(hashes, ",") != strings.Join(tt.expectedHashes, ",") {
+				t.Errorf("Expected %v, got %v", tt.expectedHashes, hashes)
+			}
+		})
+	}
+
+	// Parsers that can't filter by time reject time ranges.
+	w := httptest.NewRecorder()
+	New(NewMockParser()).Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/transactions?address="+address+"&since=1717286400", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("Expected 501, got %d", w.Code)
+	}
+}
+
+// truncatedParser is a statsParser whose stored history was truncated for
+// every subscribed address, keeping block 2 onwards.
+type truncatedParser struct {
+	*statsParser
+}
+
+func (p *truncatedParser) Truncation(address string) parser.Truncation {
+	if !p.subscriptions[address] {
+		return parser.Truncation{}
+	}
+	return parser.Truncation{Truncated: true, EarliestBlock: 2}
+}
+
+func TestServer_Truncation(t *testing.T) {
+	const address = "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"
+	mock := &truncatedParser{&statsParser{NewMockParser()}}
+	handler := New(mock).Handler()
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := get("/v1/transactions?address=" + address)
+	if w.Header().Get("X-Truncated") != "" {
+		t.Errorf("Expected no truncation headers for a complete history, got %v", w.Header())
+	}
+
+	mock.Subscribe(address)
+	for _, format := range []string{"json", "csv"} {
+		w = get("/v1/transactions?address=" + address + "&format=" + format)
+		if w.Header().Get("X-Truncated") != "true" || w.Header().Get("X-Earliest-Block") != "2" {
+			t.Errorf("%s: expected truncation headers, got %v", format, w.Header())
+		}
+	}
+
+	var stats struct {
+		Truncated     bool `json:"truncated"`
+		EarliestBlock int  `json:"earliest_block"`
+	}
+	if err := json.NewDecoder(get("/v1/stats?address=" + address).Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !stats.Truncated || stats.EarliestBlock != 2 {
+		t.Errorf("Expected stats to report the truncation, got %+v", stats)
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/labels"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+func TestServer_Labels(t *testing.T) {
+	const (
+		user     = "0x1111111111111111111111111111111111111111"
+		exchange = "0x28c6c06298d514db089934071355e5743bf21d60"
+	)
+	mock := NewMockParser()
+	mock.transactions[user] = []transaction.Transaction{
+		{Hash: "0xhash1", From: exchange, To: user, Value: transaction.WeiValue(1), Block: 1, Direction: transaction.DirectionIn},
+	}
+	registry, err := labels.NewStatic([]labels.Label{{Address: exchange, Name: "Binance 14", Category: labels.CategoryExchange}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewWithOptions(mock, Options{Labels: registry})
+
+	t.Run("json", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/transactions?address="+user, nil)
+		w := httptest.NewRecorder()
+		server.HandleTransactions(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body)
+		}
+		var txs []struct {
+			FromLabel *labels.Label `json:"from_label"`
+			ToLabel   *labels.Label `json:"to_label"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&txs); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(txs) != 1 {
+			t.Fatalf("Expected 1 transaction, got %d", len(txs))
+		}
+		want := labels.Label{Name: "Binance 14", Category: labels.CategoryExchange}
+		if txs[0].FromLabel == nil || *txs[0].FromLabel != want {
+			t.Errorf("Expected from_label %+v, got %+v", want, txs[0].FromLabel)
+		}
+		if txs[0].ToLabel != nil {
+			t.Errorf("Expected no to_label, got %+v", txs[0].ToLabel)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/transactions?format=csv&address="+user, nil)
+		w := httptest.NewRecorder()
+		server.HandleTransactions(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body)
+		}
+		records, err := csv.NewReader(w.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("Failed to read CSV: %v", err)
+		}
+		if len(records) != 2 {
+			t.Fatalf("Expected header and 1 row, got %d records", len(records))
+		}
+		header, row := records[0], records[1]
+		n := len(header)
+		if header[n-2] != "from_label" || header[n-1] != "to_label" {
+			t.Errorf("Expected label columns last, got %v", header)
+		}
+		if row[n-2] != "Binance 14" || row[n-1] != "" {
+			t.Errorf("Unexpected label columns: %v", row[n-2:])
+		}
+	})
+}
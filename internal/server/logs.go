@@ -0,0 +1,230 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/internal/tenant"
+	"github.com/danieloluwadare/tw-txparser/pkg/address"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// topicPattern matches a 32-byte event topic.
+var topicPattern = regexp.MustCompile(`^0x[0-9a-f]{64}$`)
+
+// parseEventSubscription validates a contract and topic0 pair.
+func parseEventSubscription(contract, topic0 string) (transaction.EventSubscription, error) {
+	if contract == "" || topic0 == "" {
+		return transaction.EventSubscription{}, errors.New("missing contract or topic0")
+	}
+	c, err := address.Normalize(contract)
+	if err != nil {
+		return transaction.EventSubscription{}, fmt.Errorf("invalid contract: %w", err)
+	}
+	topic0 = strings.ToLower(topic0)
+	if !topicPattern.MatchString(topic0) {
+		return transaction.EventSubscription{}, errors.New("invalid topic0: expected 0x followed by 64 hex digits")
+	}
+	return transaction.EventSubscription{Contract: c, Topic0: topic0}, nil
+}
+
+// eventIndexer returns the parser as a parser.EventIndexer, writing a 501
+// response if it isn't one.
+func (s *Server) eventIndexer(w http.ResponseWriter) (parser.EventIndexer, bool) {
+	indexer, ok := s.parser.(parser.EventIndexer)
+	if !ok {
+		http.Error(w, parser.ErrEventsUnsupported.Error(), http.StatusNotImplemented)
+	}
+	return indexer, ok
+}
+
+// writeEventsError writes the response for a failed EventIndexer call.
+func writeEventsError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, parser.ErrEventsUnsupported) {
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	}
+	requestLogger(r).Error("failed to access event subscriptions", logging.KeyError, err)
+	http.Error(w, "failed to access event subscriptions", http.StatusInternalServerError)
+}
+
+// HandleSubscribeEvents starts indexing the events of a contract with a
+// given topic0 via POST {"contract":"...","topic0":"..."}. With API keys,
+// the caller's tenant claims the subscription like an address.
+func (s *Server) HandleSubscribeEvents(w http.ResponseWriter, r *http.Request) {
+	s.handleEventSubscription(w, r, "subscribed", s.claimShared, parser.EventIndexer.SubscribeEvents)
+}
+
+// HandleUnsubscribeEvents stops indexing the events of a contract with a
+// given topic0 via POST {"contract":"...","topic0":"..."}, once no tenant
+// owns the subscription. Logs already stored are kept.
+func (s *Server) HandleUnsubscribeEvents(w http.ResponseWriter, r *http.Request) {
+	s.handleEventSubscription(w, r, "unsubscribed", s.releaseShared, parser.EventIndexer.UnsubscribeEvents)
+}
+
+func (s *Server) handleEventSubscription(w http.ResponseWriter, r *http.Request, field string, share func(*http.Request, string, func() (bool, error)) (bool, error), apply func(parser.EventIndexer, string, string) (bool, error)) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var body struct {
+		Contract string `json:"contract"`
+		Topic0   string `json:"topic0"`
+	}
+	if !decodeJSON(w, r, &body) {
+		return
+	}
+	sub, err := parseEventSubscription(body.Contract, body.Topic0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	indexer, ok := s.eventIndexer(w)
+	if !ok {
+		return
+	}
+
+	changed, err := share(r, tenant.EventKey(sub.Contract, sub.Topic0), func() (bool, error) {
+		return apply(indexer, sub.Contract, sub.Topic0)
+	})
+	if err != nil {
+		writeEventsError(w, r, err)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(map[string]bool{field: changed}); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
+
+// HandleEventSubscriptions lists the caller's event subscriptions via GET
+// /logs/subscriptions.
+func (s *Server) HandleEventSubscriptions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	indexer, ok := s.eventIndexer(w)
+	if !ok {
+		return
+	}
+	all, err := indexer.EventSubscriptions()
+	if err != nil {
+		writeEventsError(w, r, err)
+		return
+	}
+	subs := make([]transaction.EventSubscription, 0, len(all))
+	for _, sub := range all {
+		if s.owns(r, tenant.EventKey(sub.Contract, sub.Topic0)) {
+			subs = append(subs, sub)
+		}
+	}
+	if err := json.NewEncoder(w).Encode(subs); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
+
+// HandleLogs returns the logs stored for an event subscription via GET
+// /logs?contract=...&topic0=.... Like /transactions, unsubscribed pairs
+// have none, and neither do those of other tenants.
+func (s *Server) HandleLogs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	sub, err := parseEventSubscription(q.Get("contract"), q.Get("topic0"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	indexer, ok := s.eventIndexer(w)
+	if !ok {
+		return
+	}
+	var logs []transaction.Log
+	if s.owns(r, tenant.EventKey(sub.Contract, sub.Topic0)) {
+		if logs, err = indexer.GetLogs(sub.Contract, sub.Topic0); err != nil {
+			writeEventsError(w, r, err)
+			return
+		}
+	}
+	if logs == nil {
+		logs = []transaction.Log{}
+	}
+	if err := json.NewEncoder(w).Encode(logs); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
+
+// HandleLogEvents streams newly stored logs of a contract, or of all
+// subscribed contracts without the contract param, as Server-Sent Events.
+// With API keys, only the logs of the caller's subscriptions are streamed.
+func (s *Server) HandleLogEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var contracts []string
+	if raw := r.URL.Query().Get("contract"); raw != "" {
+		c, err := address.Normalize(raw)
+		if err != nil {
+			http.Error(w, "invalid contract: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		contracts = append(contracts, c)
+	}
+	indexer, ok := s.eventIndexer(w)
+	if !ok {
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	logs := indexer.WatchLogs(r.Context(), contracts...)
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case l, ok := <-logs:
+			if !ok {
+				return
+			}
+			if sub := l.Subscription(); !s.owns(r, tenant.EventKey(sub.Contract, sub.Topic0)) {
+				continue
+			}
+			data, err := json.Marshal(l)
+			if err != nil {
+				requestLogger(r).Error("failed to encode log", logging.KeyError, err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: log\nid: %s:%d\ndata: %s\n\n", l.Hash, l.LogIndex, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
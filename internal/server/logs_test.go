@@ -0,0 +1,180 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danieloluwadare/tw-txparser/internal/tenant"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// eventParser is a MockParser keeping event subscriptions in memory, with a
+// fixed log for each.
+type eventParser struct {
+	*MockParser
+	subs map[transaction.EventSubscription]bool
+}
+
+func (p *eventParser) SubscribeEvents(contract, topic0 string) (bool, error) {
+	sub := transaction.EventSubscription{Contract: contract, Topic0: topic0}
+	if p.subs[sub] {
+		return false, nil
+	}
+	p.subs[sub] = true
+	return true, nil
+}
+
+func (p *eventParser) UnsubscribeEvents(contract, topic0 string) (bool, error) {
+	sub := transaction.EventSubscription{Contract: contract, Topic0: topic0}
+	ok := p.subs[sub]
+	delete(p.subs, sub)
+	return ok, nil
+}
+
+func (p *eventParser) EventSubscriptions() ([]transaction.EventSubscription, error) {
+	out := []transaction.EventSubscription{}
+	for sub := range p.subs {
+		out = append(out, sub)
+	}
+	return out, nil
+}
+
+func (p *eventParser) GetLogs(contract, topic0 string) ([]transaction.Log, error) {
+	if !p.subs[transaction.EventSubscription{Contract: contract, Topic0: topic0}] {
+		return nil, nil
+	}
+	return []transaction.Log{{Contract: contract, Topics: []string{topic0}, Data: "0x", Block: 1234, Hash: "0xabc"}}, nil
+}
+
+func (p *eventParser) WatchLogs(ctx context.Context, _ ...string) <-chan transaction.Log {
+	ch := make(chan transaction.Log)
+	go func() {
+		<-ctx.Done()
+		close(ch)
+	}()
+	return ch
+}
+
+func TestServer_EventSubscriptions(t *testing.T) {
+	const (
+		contract = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+		topic0   = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	)
+	mock := &eventParser{MockParser: NewMockParser(), subs: make(map[transaction.EventSubscription]bool)}
+	handler := NewWithOptions(mock, Options{}).Handler()
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	// Mixed-case input is normalized.
+	body := `{"contract":"0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48","topic0":"` + strings.ToUpper(topic0[:10]) + topic0[10:] + `"}`
+	if w := do(http.MethodPost, "/v1/logs/subscribe", body); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"subscribed":true`) {
+		t.Fatalf("Expected the subscription to be added, got %d: %s", w.Code, w.Body)
+	}
+	if w := do(http.MethodPost, "/v1/logs/subscribe", body); !strings.Contains(w.Body.String(), `"subscribed":false`) {
+		t.Errorf("Expected a repeated subscription to report false, got %s", w.Body)
+	}
+
+	w := do(http.MethodGet, "/v1/logs/subscriptions", "")
+	var subs []transaction.EventSubscription
+	if err := json.NewDecoder(w.Body).Decode(&subs); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(subs) != 1 || subs[0].Contract != contract || subs[0].Topic0 != topic0 {
+		t.Errorf("Unexpected subscriptions %+v", subs)
+	}
+
+	w = do(http.MethodGet, "/v1/logs?contract="+contract+"&topic0="+topic0, "")
+	var logs []transaction.Log
+	if err := json.NewDecoder(w.Body).Decode(&logs); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(logs) != 1 || logs[0].Block != 1234 {
+		t.Errorf("Expected the stored log, got %+v", logs)
+	}
+
+	if w := do(http.MethodPost, "/v1/logs/unsubscribe", body); !strings.Contains(w.Body.String(), `"unsubscribed":true`) {
+		t.Errorf("Expected the subscription to be removed, got %s", w.Body)
+	}
+	if w := do(http.MethodGet, "/v1/logs?contract="+contract+"&topic0="+topic0, ""); strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("Expected no logs once unsubscribed, got %s", w.Body)
+	}
+
+	for _, tt := range []struct{ method, path, body string }{
+		{http.MethodPost, "/v1/logs/subscribe", `{"contract":"` + contract + `"}`},
+		{http.MethodPost, "/v1/logs/subscribe", `{"contract":"0x12","topic0":"` + topic0 + `"}`},
+		{http.MethodPost, "/v1/logs/subscribe", `{"contract":"` + contract + `","topic0":"0xddf252ad"}`},
+		{http.MethodGet, "/v1/logs?contract=" + contract, ""},
+		{http.MethodGet, "/v1/logs/events?contract=0x12", ""},
+	} {
+		if w := do(tt.method, tt.path, tt.body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s %s %s, got %d", tt.method, tt.path, tt.body, w.Code)
+		}
+	}
+	if w := do(http.MethodGet, "/v1/logs/subscribe", ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	New(NewMockParser()).Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/logs/subscriptions", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("Expected 501 without event support, got %d", w.Code)
+	}
+}
+
+func TestServer_EventSubscriptions_Tenants(t *testing.T) {
+	const (
+		contract = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+		topic0   = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	)
+	tenants, err := tenant.New(map[string]string{"payments": "pk", "risk": "rk"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := &eventParser{MockParser: NewMockParser(), subs: make(map[transaction.EventSubscription]bool)}
+	handler := NewWithOptions(mock, Options{Tenants: tenants}).Handler()
+	do := func(method, path, key, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(apiKeyHeader, key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	body := `{"contract":"` + contract + `","topic0":"` + topic0 + `"}`
+	logsPath := "/v1/logs?contract=" + contract + "&topic0=" + topic0
+
+	do(http.MethodPost, "/v1/logs/subscribe", "pk", body)
+	if w := do(http.MethodGet, "/v1/logs/subscriptions", "rk", ""); strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("Expected risk to see no subscriptions, got %s", w.Body)
+	}
+	if w := do(http.MethodGet, logsPath, "rk", ""); strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("Expected risk to see no logs, got %s", w.Body)
+	}
+	if w := do(http.MethodPost, "/v1/logs/unsubscribe", "rk", body); !strings.Contains(w.Body.String(), `"unsubscribed":false`) || len(mock.subs) != 1 {
+		t.Errorf("Expected risk not to stop payments' indexing, got %s", w.Body)
+	}
+
+	// Indexing stops once the last owner unsubscribes.
+	if w := do(http.MethodPost, "/v1/logs/subscribe", "rk", body); !strings.Contains(w.Body.String(), `"subscribed":true`) {
+		t.Errorf("Expected risk's subscription to be added, got %s", w.Body)
+	}
+	if w := do(http.MethodGet, logsPath, "rk", ""); !strings.Contains(w.Body.String(), "0xabc") {
+		t.Errorf("Expected risk to see the logs once subscribed, got %s", w.Body)
+	}
+	do(http.MethodPost, "/v1/logs/unsubscribe", "pk", body)
+	if len(mock.subs) != 1 {
+		t.Error("Expected indexing to continue while risk is subscribed")
+	}
+	do(http.MethodPost, "/v1/logs/unsubscribe", "rk", body)
+	if len(mock.subs) != 0 {
+		t.Error("Expected indexing to stop once no tenant is subscribed")
+	}
+}
@@ -0,0 +1,237 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
+)
+
+// tracer creates a server span per request.
+var tracer = otel.Tracer("github.com/danieloluwadare/tw-txparser/internal/server")
+
+// requestIDHeader carries the request ID in both directions.
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen bounds caller-supplied request IDs.
+const maxRequestIDLen = 128
+
+// requestLogger returns the server logger annotated with r's request ID.
+func requestLogger(r *http.Request) *slog.Logger {
+	return logging.FromContext(r.Context(), logging.Component("server"))
+}
+
+// requestID tags each request with an ID, taken from X-Request-ID when the
+// caller supplies a sane one and generated otherwise. The ID is echoed in the
+// response and attached to every log line, ending with an access log entry.
+func requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		r = r.WithContext(logging.WithRequestID(r.Context(), id))
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		requestLogger(r).Info("request served",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	})
+}
+
+// traceRequests starts a server span for each request, continuing the
+// caller's trace when a traceparent header is present. Handlers pass the
+// request context down, so parser, storage and RPC spans nest under it.
+func traceRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path),
+		))
+		defer span.End()
+
+		r = r.WithContext(ctx)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		// The mux records the matched route on the request it was given.
+		if r.Pattern != "" {
+			span.SetName(r.Method + " " + r.Pattern)
+			span.SetAttributes(attribute.String("http.route", r.Pattern))
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	})
+}
+
+// unmatchedRoute labels requests that match no route, keeping the label
+// set bounded regardless of the paths clients send.
+const unmatchedRoute = "unmatched"
+
+// instrument records request counts, latency and in-flight requests
+// labeled by the mux route that serves them, e.g. "/v1/transactions/{hash}".
+func (s *Server) instrument(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := unmatchedRoute
+		if _, pattern := mux.Handler(r); pattern != "" {
+			route = pattern
+		}
+		s.trackInFlight(route, 1)
+		defer s.trackInFlight(route, -1)
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		labels := []metrics.Label{metrics.L("method", r.Method), metrics.L("route", route), metrics.L("status", strconv.Itoa(rec.status))}
+		s.opts.Metrics.Add(metrics.HTTPRequests, 1, labels...)
+		metrics.ObserveSince(s.opts.Metrics, metrics.HTTPDuration, start, labels...)
+	})
+}
+
+// trackInFlight adjusts the in-flight gauge for route. The update and the
+// gauge write happen under one lock so concurrent requests never publish
+// stale values.
+func (s *Server) trackInFlight(route string, delta int) {
+	s.inFlightMu.Lock()
+	defer s.inFlightMu.Unlock()
+	s.inFlight[route] += delta
+	s.opts.Metrics.Set(metrics.HTTPInFlight, float64(s.inFlight[route]), metrics.L("route", route))
+}
+
+// validRequestID reports whether id is non-empty, short and printable ASCII.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random 16 hex character ID.
+func newRequestID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// statusRecorder captures the response status for access logging.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (rec *statusRecorder) WriteHeader(code int) {
+	if !rec.wroteHeader {
+		rec.status = code
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+// Flush passes through to the underlying writer so event streams keep working.
+func (rec *statusRecorder) Flush() {
+	if f, ok := rec.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// recoverer converts handler panics into a logged stack trace and a 500 JSON
+// response, so a single bad request can't take down the connection or process.
+func recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				// Deliberate aborts are handled by net/http itself.
+				panic(rec)
+			}
+			requestLogger(r).Error("panic serving request",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"panic", fmt.Sprint(rec),
+				"stack", string(debug.Stack()),
+			)
+			writeJSONError(w, http.StatusInternalServerError, "internal server error")
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeJSONError writes {"error": msg} with the given status code.
+func writeJSONError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]string{"error": msg}); err != nil {
+		logging.Component("server").Error("failed to encode error response", logging.KeyError, err)
+	}
+}
+
+// limitBody caps request bodies at the configured size.
+func (s *Server) limitBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, s.opts.MaxBodyBytes)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withTimeout bounds a handler's run time and cancels its context on expiry.
+func (s *Server) withTimeout(next http.Handler) http.Handler {
+	return http.TimeoutHandler(next, s.opts.RequestTimeout, "request timed out")
+}
+
+// decodeJSON decodes the request body into v. On failure it writes a 413 for
+// oversized bodies or a 400 otherwise, and returns false.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == nil {
+		return true
+	}
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		http.Error(w, fmt.Sprintf("request body exceeds %d bytes", maxErr.Limit), http.StatusRequestEntityTooLarge)
+		return false
+	}
+	http.Error(w, "invalid JSON body", http.StatusBadRequest)
+	return false
+}
@@ -0,0 +1,246 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
+)
+
+func TestRecoverer(t *testing.T) {
+	handler := recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/current", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected application/json content type, got %s", ct)
+	}
+	var response map[string]string
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response["error"] != "internal server error" {
+		t.Errorf("Unexpected error message: %s", response["error"])
+	}
+}
+
+func TestRecoverer_PassThrough(t *testing.T) {
+	handler := recoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/current", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusTeapot {
+		t.Errorf("Expected status %d, got %d", http.StatusTeapot, w.Code)
+	}
+}
+
+func TestServer_BodyLimit(t *testing.T) {
+	handler := NewWithOptions(NewMockParser(), Options{MaxBodyBytes: 64}).Handler()
+
+	body := `{"address":"` + strings.Repeat("a", 128) + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/subscribe", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status %d, got %d", http.StatusRequestEntityTooLarge, w.Code)
+	}
+}
+
+func TestServer_WithTimeout(t *testing.T) {
+	s := NewWithOptions(NewMockParser(), Options{RequestTimeout: 10 * time.Millisecond})
+	handler := s.withTimeout(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+			t.Error("Expected handler context to be cancelled")
+		}
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/current", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	handler := requestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = logging.RequestID(r.Context())
+	}))
+
+	tests := []struct {
+		name     string
+		header   string
+		expectID string // empty means a generated ID is expected
+	}{
+		{name: "propagates caller ID", header: "abc-123", expectID: "abc-123"},
+		{name: "generates when missing", header: ""},
+		{name: "replaces invalid ID", header: "has space"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/current", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Request-ID", tt.header)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			got := w.Header().Get("X-Request-ID")
+			if got == "" || got != seen {
+				t.Fatalf("Expected response ID to match context ID, got %q and %q", got, seen)
+			}
+			if tt.expectID != "" && got != tt.expectID {
+				t.Errorf("Expected ID %q, got %q", tt.expectID, got)
+			}
+			if tt.expectID == "" && got == tt.header {
+				t.Errorf("Expected a generated ID, got %q", got)
+			}
+		})
+	}
+}
+
+func TestTraceRequests(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var handlerSpan trace.SpanContext
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/transactions/{hash}", func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan = trace.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/transactions/0xabc", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	traceRequests(mux).ServeHTTP(httptest.NewRecorder(), req)
+
+	ended := spans.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(ended))
+	}
+	span := ended[0]
+	if span.Name() != "GET /v1/transactions/{hash}" {
+		t.Errorf("Unexpected span name: %s", span.Name())
+	}
+	if got := span.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected the caller's trace to be continued, got trace %s", got)
+	}
+	if handlerSpan.SpanID() != span.SpanContext().SpanID() {
+		t.Error("Expected the handler context to carry the server span")
+	}
+	if span.Status().Code.String() != "Error" {
+		t.Errorf("Expected 5xx to mark the span as failed, got %v", span.Status())
+	}
+}
+
+// labelRecorder records measurements keyed by name and labels: counters are
+// summed, gauges keep every value set and observations are counted.
+type labelRecorder struct {
+	mu           sync.Mutex
+	counts       map[string]float64
+	gauges       map[string][]float64
+	observations map[string]int
+}
+
+func newLabelRecorder() *labelRecorder {
+	return &labelRecorder{counts: map[string]float64{}, gauges: map[string][]float64{}, observations: map[string]int{}}
+}
+
+func labelKey(name string, labels []metrics.Label) string {
+	for _, l := range labels {
+		name += " " + l.Name + "=" + l.Value
+	}
+	return name
+}
+
+func (r *labelRecorder) Add(name string, delta float64, labels ...metrics.Label) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counts[labelKey(name, labels)] += delta
+}
+
+func (r *labelRecorder) Set(name string, value float64, labels ...metrics.Label) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := labelKey(name, labels)
+	r.gauges[key] = append(r.gauges[key], value)
+}
+
+func (r *labelRecorder) Observe(name string, _ float64, labels ...metrics.Label) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.observations[labelKey(name, labels)]++
+}
+
+func TestServer_Metrics(t *testing.T) {
+	rec := newLabelRecorder()
+	exposition := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("# metrics"))
+	})
+	handler := NewWithOptions(NewMockParser(), Options{Metrics: rec, MetricsHandler: exposition}).Handler()
+
+	for _, path := range []string{"/v1/current", "/v1/current", "/v1/missing", "/metrics", "/v1/transactions/0x1"} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if path == "/metrics" && w.Body.String() != "# metrics" {
+			t.Errorf("Expected metrics handler to be served, got %q", w.Body.String())
+		}
+	}
+
+	// Routes are the matched patterns, so path values don't add label values.
+	expected := map[string]float64{
+		"http_requests_total method=GET route=/v1/current status=200":             2,
+		"http_requests_total method=GET route=unmatched status=404":               1,
+		"http_requests_total method=GET route=/metrics status=200":                1,
+		"http_requests_total method=GET route=/v1/transactions/{hash} status=400": 1,
+	}
+	for key, want := range expected {
+		if got := rec.counts[key]; got != want {
+			t.Errorf("Expected %s = %v, got %v", key, want, got)
+		}
+	}
+	if got := rec.observations["http_request_duration_seconds method=GET route=/v1/current status=200"]; got != 2 {
+		t.Errorf("Expected 2 latency observations, got %d", got)
+	}
+	if got := rec.gauges["http_requests_in_flight route=/v1/current"]; !reflect.DeepEqual(got, []float64{1, 0, 1, 0}) {
+		t.Errorf("Unexpected in-flight gauge values: %v", got)
+	}
+
+	// Without a handler /metrics is not routed.
+	w := httptest.NewRecorder()
+	New(NewMockParser()).Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d without a metrics handler, got %d", http.StatusNotFound, w.Code)
+	}
+}
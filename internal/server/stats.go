@@ -0,0 +1,72 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// HandleStats returns the value an address received and sent, natively and
+// per token, via GET /stats?address=.... units=ether adds the native totals
+// in ether. Like /transactions, unsubscribed addresses have zero totals.
+func (s *Server) HandleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	raw := r.URL.Query().Get("address")
+	if raw == "" {
+		http.Error(w, "missing address", http.StatusBadRequest)
+		return
+	}
+	ether, err := parseUnits(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	agg, ok := s.parser.(parser.Aggregator)
+	if !ok {
+		http.Error(w, parser.ErrTotalsUnsupported.Error(), http.StatusNotImplemented)
+		return
+	}
+	addr, ok := s.resolveAddress(w, r, raw)
+	if !ok || !s.requireOwner(w, r, addr) {
+		return
+	}
+
+	totals, err := agg.Totals(addr)
+	if errors.Is(err, parser.ErrTotalsUnsupported) {
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	} else if err != nil {
+		requestLogger(r).Error("failed to get totals", logging.KeyAddress, addr, logging.KeyError, err)
+		http.Error(w, "failed to get totals", http.StatusInternalServerError)
+		return
+	}
+
+	resp := struct {
+		Address string `json:"address"`
+		transaction.Totals
+		InEther   string `json:"in_ether,omitempty"`
+		OutEther  string `json:"out_ether,omitempty"`
+		FeesEther string `json:"fees_ether,omitempty"`
+		// Totals cover every transaction indexed, including those dropped
+		// from a truncated history.
+		Truncated     bool `json:"truncated,omitempty"`
+		EarliestBlock int  `json:"earliest_block,omitempty"`
+	}{Address: addr, Totals: totals}
+	if ether {
+		resp.InEther, resp.OutEther, resp.FeesEther = totals.In.Ether(), totals.Out.Ether(), totals.Fees.Ether()
+	}
+	if reporter, ok := s.parser.(parser.TruncationReporter); ok {
+		t := reporter.Truncation(addr)
+		resp.Truncated, resp.EarliestBlock = t.Truncated, t.EarliestBlock
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
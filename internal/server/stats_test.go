@@ -0,0 +1,93 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// statsParser is a MockParser keeping fixed totals for subscribed addresses.
+type statsParser struct {
+	*MockParser
+}
+
+func (p *statsParser) Totals(address string) (transaction.Totals, error) {
+	if !p.subscriptions[address] {
+		return transaction.Totals{}.Clone(), nil
+	}
+	fee := transaction.WeiValue(21_000_000_000_000)
+	var totals transaction.Totals
+	totals.Add(transaction.Transaction{Hash: "0x1", Value: transaction.WeiValue(2_000_000_000_000_000_000), Direction: transaction.DirectionIn})
+	totals.Add(transaction.Transaction{Hash: "0x2", Value: transaction.WeiValue(500_000_000_000_000_000), Direction: transaction.DirectionOut, Fee: &fee})
+	totals.AddTokenTransfer(transaction.TokenTransfer{Contract: "0xa0b8", Standard: transaction.StandardERC20, Symbol: "USDC", Decimals: 6, Amount: transaction.WeiValue(1_250_000), Direction: transaction.DirectionIn})
+	return totals.Clone(), nil
+}
+
+func TestServer_HandleStats(t *testing.T) {
+	const addr = "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"
+	mock := &statsParser{MockParser: NewMockParser()}
+	mock.Subscribe(addr)
+	handler := NewWithOptions(mock, Options{}).Handler()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/stats?address="+addr+"&units=ether", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Address      string `json:"address"`
+		Transactions int    `json:"transactions"`
+		In           string `json:"in"`
+		InEther      string `json:"in_ether"`
+		OutEther     string `json:"out_ether"`
+		FeesEther    string `json:"fees_ether"`
+		Tokens       []struct {
+			Symbol       string `json:"symbol"`
+			In           string `json:"in"`
+			NormalizedIn string `json:"normalized_in"`
+		} `json:"tokens"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Address != addr || resp.Transactions != 2 || resp.In != "2000000000000000000" {
+		t.Errorf("Unexpected native totals %+v", resp)
+	}
+	if resp.InEther != "2" || resp.OutEther != "0.5" || resp.FeesEther != "0.000021" {
+		t.Errorf("Unexpected ether totals %+v", resp)
+	}
+	if len(resp.Tokens) != 1 || resp.Tokens[0].Symbol != "USDC" || resp.Tokens[0].In != "1250000" || resp.Tokens[0].NormalizedIn != "1.25" {
+		t.Errorf("Unexpected token totals %+v", resp.Tokens)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{name: "missing address", query: "", want: http.StatusBadRequest},
+		{name: "bad units", query: "?address=" + addr + "&units=gwei", want: http.StatusBadRequest},
+		{name: "invalid address", query: "?address=0xzz", want: http.StatusBadRequest},
+		{name: "not subscribed", query: "?address=0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/stats"+tt.query, nil))
+			if w.Code != tt.want {
+				t.Errorf("Expected %d, got %d: %s", tt.want, w.Code, w.Body)
+			}
+		})
+	}
+}
+
+func TestServer_HandleStats_Unsupported(t *testing.T) {
+	w := httptest.NewRecorder()
+	New(NewMockParser()).Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/stats?address=0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("Expected 501, got %d", w.Code)
+	}
+}
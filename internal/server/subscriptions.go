@@ -0,0 +1,243 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/audit"
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/address"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+)
+
+// exportedSubscription is one watchlist entry in exports and imports.
+type exportedSubscription struct {
+	Address string `json:"address"`
+	// ExpiresAt is set for subscriptions created with a ttl.
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+}
+
+// importRow is one watchlist entry to import. At most one of TTL and
+// ExpiresAt should be set; TTL wins if both are.
+type importRow struct {
+	Address   string `json:"address"`
+	TTL       string `json:"ttl"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// importResult reports the outcome of one imported row.
+type importResult struct {
+	// Row numbers start at 1 and don't count the CSV header.
+	Row        int       `json:"row"`
+	Address    string    `json:"address"`
+	Subscribed bool      `json:"subscribed"`
+	ExpiresAt  time.Time `json:"expires_at,omitzero"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// HandleExportSubscriptions lists the caller's subscriptions via GET
+// /subscriptions/export, as JSON by default or CSV when selected via the
+// Accept header or format=csv. The output can be fed to
+// /subscriptions/import as is.
+func (s *Server) HandleExportSubscriptions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	format, err := negotiateFormat(r)
+	if err == nil && format == formatNDJSON {
+		err = errors.New("unsupported format \"ndjson\": expected json or csv")
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var addrs []string
+	if s.opts.Tenants != nil {
+		addrs = s.opts.Tenants.Owned(tenantFrom(r))
+	} else if lister, ok := s.parser.(parser.Lister); !ok {
+		http.Error(w, parser.ErrListUnsupported.Error(), http.StatusNotImplemented)
+		return
+	} else if addrs, err = lister.Subscriptions(); errors.Is(err, parser.ErrListUnsupported) {
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+		return
+	} else if err != nil {
+		requestLogger(r).Error("failed to list subscriptions", logging.KeyError, err)
+		http.Error(w, "failed to list subscriptions", http.StatusInternalServerError)
+		return
+	}
+	subs := make([]exportedSubscription, len(addrs))
+	for i, addr := range addrs {
+		subs[i].Address = addr
+		subs[i].ExpiresAt, _ = s.opts.Expiry.ExpiresAt(tenantFrom(r), addr)
+	}
+
+	if format == formatCSV {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="subscriptions.csv"`)
+		err = writeSubscriptionsCSV(w, subs)
+	} else {
+		err = json.NewEncoder(w).Encode(map[string]interface{}{"subscriptions": subs})
+	}
+	if err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
+
+// writeSubscriptionsCSV writes subs with an address,expires_at header.
+func writeSubscriptionsCSV(w io.Writer, subs []exportedSubscription) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"address", "expires_at"}); err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		if err := cw.Write([]string{sub.Address, formatIndexedAt(sub.ExpiresAt)}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// HandleImportSubscriptions subscribes the caller to a watchlist via POST
+// /subscriptions/import. The body is JSON, {"subscriptions":[...]} as
+// exported, or CSV with Content-Type text/csv and a header naming an
+// address column and optional ttl and expires_at columns. Each row is
+// applied on its own and reported in the response; a bad row doesn't stop
+// the others.
+func (s *Server) HandleImportSubscriptions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var rows []importRow
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "text/csv" {
+		var err error
+		if rows, err = readImportCSV(r.Body); err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				http.Error(w, fmt.Sprintf("request body exceeds %d bytes", maxErr.Limit), http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "invalid CSV body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		var body struct {
+			Subscriptions []importRow `json:"subscriptions"`
+		}
+		if !decodeJSON(w, r, &body) {
+			return
+		}
+		rows = body.Subscriptions
+	}
+
+	resp := struct {
+		Imported int            `json:"imported"`
+		Failed   int            `json:"failed"`
+		Results  []importResult `json:"results"`
+	}{Results: make([]importResult, len(rows))}
+	for i, row := range rows {
+		res := s.importSubscription(r, row)
+		res.Row = i + 1
+		if res.Error != "" {
+			resp.Failed++
+		} else {
+			resp.Imported++
+		}
+		resp.Results[i] = res
+	}
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
+
+// importSubscription subscribes the caller to the address of row, as
+// HandleSubscribe would.
+func (s *Server) importSubscription(r *http.Request, row importRow) importResult {
+	res := importResult{Address: strings.TrimSpace(row.Address)}
+	addr, err := address.Normalize(res.Address)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.Address = addr
+	var ttl time.Duration
+	switch {
+	case row.TTL != "":
+		if ttl, err = time.ParseDuration(row.TTL); err != nil || ttl <= 0 {
+			res.Error = "invalid ttl: expected a positive duration such as 24h"
+			return res
+		}
+	case row.ExpiresAt != "":
+		at, err := time.Parse(time.RFC3339Nano, row.ExpiresAt)
+		if err != nil {
+			res.Error = "invalid expires_at: expected an RFC 3339 time"
+			return res
+		}
+		if ttl = time.Until(at); ttl <= 0 {
+			res.Error = "subscription already expired"
+			return res
+		}
+	}
+
+	res.Subscribed = s.subscribe(r, addr)
+	s.recordAudit(r, audit.ActionSubscribe, "import", addr, res.Subscribed)
+	if ttl > 0 {
+		res.ExpiresAt = s.expireAfter(r, addr, ttl, false)
+	} else {
+		s.opts.Expiry.Cancel(tenantFrom(r), addr)
+	}
+	return res
+}
+
+// readImportCSV reads import rows from a CSV document whose header names
+// its columns. Unknown columns are ignored.
+func readImportCSV(body io.Reader) ([]importRow, error) {
+	cr := csv.NewReader(body)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cols := map[string]int{"address": -1, "ttl": -1, "expires_at": -1}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := cols[name]; ok {
+			cols[name] = i
+		}
+	}
+	if cols["address"] < 0 {
+		return nil, errors.New("header has no address column")
+	}
+	field := func(record []string, name string) string {
+		if i := cols[name]; i >= 0 && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var rows []importRow
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, importRow{Address: field(record, "address"), TTL: field(record, "ttl"), ExpiresAt: field(record, "expires_at")})
+	}
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/audit"
+	"github.com/danieloluwadare/tw-txparser/internal/tenant"
+)
+
+// listingParser is a MockParser that can list its subscriptions.
+type listingParser struct {
+	*MockParser
+}
+
+func (p *listingParser) Subscriptions() ([]string, error) {
+	var out []string
+	for addr, ok := range p.subscriptions {
+		if ok {
+			out = append(out, addr)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func TestServer_ImportExportSubscriptions(t *testing.T) {
+	const (
+		a = "0x1111111111111111111111111111111111111111"
+		b = "0x2222222222222222222222222222222222222222"
+		c = "0x3333333333333333333333333333333333333333"
+	)
+	mock := &listingParser{MockParser: NewMockParser()}
+	log := audit.New()
+	s := NewWithOptions(mock, Options{Audit: log})
+	defer s.opts.Expiry.Stop()
+	handler := s.Handler()
+	do := func(method, path, contentType, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/v1/subscriptions/import", "application/json", `{"subscriptions":[
+		{"address":" `+a+` "},
+		{"address":"`+b+`","ttl":"24h"},
+		{"address":"not-an-address"},
+		{"address":"`+c+`","expires_at":"2001-01-01T00:00:00Z"},
+		{"address":"`+c+`","ttl":"-1h"}
+	]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Imported, Failed int
+		Results          []importResult
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.Imported != 2 || resp.Failed != 3 || len(resp.Results) != 5 {
+		t.Fatalf("Unexpected import summary %+v", resp)
+	}
+	if r := resp.Results[0]; r.Row != 1 || r.Address != a || !r.Subscribed || r.Error != "" {
+		t.Errorf("Expected the first row to be trimmed and subscribed, got %+v", r)
+	}
+	if r := resp.Results[1]; !r.Subscribed || r.ExpiresAt.IsZero() {
+		t.Errorf("Expected the second row to expire, got %+v", r)
+	}
+	for _, r := range resp.Results[2:] {
+		if r.Error == "" || r.Subscribed {
+			t.Errorf("Expected row %d to fail, got %+v", r.Row, r)
+		}
+	}
+	if mock.subscriptions[c] {
+		t.Error("Expected failed rows not to subscribe")
+	}
+	if entries := log.Query(audit.Query{}); len(entries) != 2 || entries[0].Source != "import" {
+		t.Errorf("Expected 2 audited imports, got %+v", entries)
+	}
+
+	// The export lists both subscriptions, with b's expiry.
+	w = do(http.MethodGet, "/v1/subscriptions/export", "", "")
+	var exported struct {
+		Subscriptions []exportedSubscription
+	}
+	if err := json.NewDecoder(w.Body).Decode(&exported); err != nil {
+		t.Fatalf("Failed to decode export: %v", err)
+	}
+	if len(exported.Subscriptions) != 2 || exported.Subscriptions[0].Address != a || !exported.Subscriptions[0].ExpiresAt.IsZero() ||
+		exported.Subscriptions[1].Address != b || !exported.Subscriptions[1].ExpiresAt.Equal(resp.Results[1].ExpiresAt) {
+		t.Errorf("Unexpected export %+v", exported)
+	}
+
+	w = do(http.MethodGet, "/v1/subscriptions/export?format=csv", "", "")
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if w.Header().Get("Content-Type") != "text/csv" || len(lines) != 3 || lines[0] != "address,expires_at" || lines[1] != a+"," || !strings.HasPrefix(lines[2], b+",") {
+		t.Errorf("Unexpected CSV export %q", w.Body)
+	}
+	if w := do(http.MethodGet, "/v1/subscriptions/export?format=ndjson", "", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for ndjson, got %d", w.Code)
+	}
+
+	// The CSV export imports as is elsewhere.
+	other := &listingParser{MockParser: NewMockParser()}
+	otherServer := NewWithOptions(other, Options{})
+	defer otherServer.opts.Expiry.Stop()
+	req := httptest.NewRequest(http.MethodPost, "/v1/subscriptions/import", strings.NewReader(strings.Join(lines, "\n")))
+	req.Header.Set("Content-Type", "text/csv; charset=utf-8")
+	w = httptest.NewRecorder()
+	otherServer.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !other.subscriptions[a] || !other.subscriptions[b] {
+		t.Fatalf("Expected the CSV export to import, got %d: %s", w.Code, w.Body)
+	}
+	if at, ok := otherServer.opts.Expiry.ExpiresAt("", b); !ok || at.Sub(resp.Results[1].ExpiresAt).Abs() > time.Second {
+		t.Errorf("Expected b's expiry to carry over, got %v %v", at, ok)
+	}
+
+	for _, body := range []string{"hash,block\n0x1,1\n", "address\n\"unterminated\n"} {
+		if w := do(http.MethodPost, "/v1/subscriptions/import", "text/csv", body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %q, got %d", body, w.Code)
+		}
+	}
+}
+
+func TestServer_ExportSubscriptions_Tenants(t *testing.T) {
+	const (
+		a = "0x1111111111111111111111111111111111111111"
+		b = "0x2222222222222222222222222222222222222222"
+	)
+	tenants, err := tenant.New(map[string]string{"payments": "pk", "risk": "rk"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Listing tenants' subscriptions doesn't need the parser's support.
+	handler := NewWithOptions(NewMockParser(), Options{Tenants: tenants}).Handler()
+	do := func(method, path, key, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(apiKeyHeader, key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	do(http.MethodPost, "/v1/subscriptions/import", "pk", `{"subscriptions":[{"address":"`+a+`"}]}`)
+	do(http.MethodPost, "/v1/subscribe", "rk", `{"address":"`+b+`"}`)
+
+	w := do(http.MethodGet, "/v1/subscriptions/export", "pk", "")
+	var exported struct {
+		Subscriptions []exportedSubscription
+	}
+	if err := json.NewDecoder(w.Body).Decode(&exported); err != nil {
+		t.Fatalf("Failed to decode export: %v", err)
+	}
+	if len(exported.Subscriptions) != 1 || exported.Subscriptions[0].Address != a {
+		t.Errorf("Expected only payments' subscription, got %+v", exported)
+	}
+}
+
+func TestServer_ExportSubscriptions_Unsupported(t *testing.T) {
+	w := httptest.NewRecorder()
+	New(NewMockParser()).Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/subscriptions/export", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("Expected 501, got %d", w.Code)
+	}
+}
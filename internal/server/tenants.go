@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// apiKeyHeader carries a tenant's API key. "Authorization: Bearer <key>" is
+// accepted as well.
+const apiKeyHeader = "X-API-Key"
+
+type tenantKey struct{}
+
+// tenantFrom returns the tenant that authenticated r, or "" when API keys
+// are disabled.
+func tenantFrom(r *http.Request) string {
+	name, _ := r.Context().Value(tenantKey{}).(string)
+	return name
+}
+
+// requireKey authenticates the caller's API key and attaches its tenant to
+// the request. It passes requests through when API keys are disabled.
+func (s *Server) requireKey(next http.Handler) http.Handler {
+	if s.opts.Tenants == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(apiKeyHeader)
+		if key == "" {
+			key, _ = strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		}
+		name, ok := s.opts.Tenants.Authenticate(key)
+		if key == "" || !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tenantKey{}, name)))
+	})
+}
+
+// subscribe subscribes addr for the caller and reports whether that changed
+// anything. With API keys, the caller's tenant claims addr; the parser
+// subscription is shared by all tenants owning it.
+func (s *Server) subscribe(r *http.Request, addr string) bool {
+	if s.opts.Tenants == nil {
+		return s.parser.Subscribe(addr)
+	}
+	claimed := s.opts.Tenants.Claim(tenantFrom(r), addr)
+	s.parser.Subscribe(addr)
+	return claimed
+}
+
+// unsubscribe is the inverse of subscribe. With API keys, the parser
+// subscription is only dropped once no tenant owns addr.
+func (s *Server) unsubscribe(r *http.Request, addr string) bool {
+	released, _ := s.release(tenantFrom(r), addr)
+	return released
+}
+
+// release drops owner's subscription to addr. released reports whether
+// owner was subscribed; orphaned whether the parser stopped tracking addr
+// as a result.
+func (s *Server) release(owner, addr string) (released, orphaned bool) {
+	if s.opts.Tenants == nil {
+		ok := s.parser.Unsubscribe(addr)
+		return ok, ok
+	}
+	released, orphaned = s.opts.Tenants.Release(owner, addr)
+	if orphaned {
+		s.parser.Unsubscribe(addr)
+	}
+	return released, orphaned
+}
+
+// claimShared claims key, an event subscription or contract watch, for the
+// caller once start has started indexing it, and reports whether that
+// changed anything for the caller. Without API keys it just runs start.
+func (s *Server) claimShared(r *http.Request, key string, start func() (bool, error)) (bool, error) {
+	if s.opts.Tenants == nil {
+		return start()
+	}
+	if _, err := start(); err != nil {
+		return false, err
+	}
+	return s.opts.Tenants.Claim(tenantFrom(r), key), nil
+}
+
+// releaseShared is the inverse of claimShared: it releases the caller's
+// claim on key and runs stop once no tenant owns key, so that one tenant
+// can't stop another's indexing.
+func (s *Server) releaseShared(r *http.Request, key string, stop func() (bool, error)) (bool, error) {
+	if s.opts.Tenants == nil {
+		return stop()
+	}
+	released, orphaned := s.opts.Tenants.Release(tenantFrom(r), key)
+	if orphaned {
+		if _, err := stop(); err != nil {
+			return released, err
+		}
+	}
+	return released, nil
+}
+
+// owns reports whether the caller may see addr, or the event subscription
+// or contract watch of that key: API keys are disabled or its tenant
+// subscribed it.
+func (s *Server) owns(r *http.Request, addr string) bool {
+	return s.opts.Tenants == nil || s.opts.Tenants.Owns(tenantFrom(r), addr)
+}
+
+// requireOwner responds 404 unless the caller may see addr, so tenants
+// can't probe each other's watchlists.
+func (s *Server) requireOwner(w http.ResponseWriter, r *http.Request, addr string) bool {
+	if !s.owns(r, addr) {
+		http.Error(w, "address not subscribed", http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+// visibleTransaction reports whether the caller may see tx: it owns its
+// sender or receiver.
+func (s *Server) visibleTransaction(r *http.Request, tx transaction.Transaction) bool {
+	return s.owns(r, strings.ToLower(tx.From)) || s.owns(r, strings.ToLower(tx.To))
+}
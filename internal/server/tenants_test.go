@@ -0,0 +1,92 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danieloluwadare/tw-txparser/internal/tenant"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+func TestServer_Tenants(t *testing.T) {
+	const (
+		shared  = "0x1111111111111111111111111111111111111111"
+		private = "0x2222222222222222222222222222222222222222"
+	)
+	hash := "0x" + strings.Repeat("ab", 32)
+	tenants, err := tenant.New(map[string]string{"payments": "pk", "risk": "rk"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := NewMockParser()
+	mock.transactions[private] = []transaction.Transaction{{Hash: hash, From: private, To: "0x3333333333333333333333333333333333333333", Block: 1}}
+	handler := NewWithOptions(mock, Options{Tenants: tenants}).Handler()
+
+	do := func(method, path, key, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(apiKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	subscribe := func(key, addr string) bool {
+		t.Helper()
+		w := do(http.MethodPost, "/v1/subscribe", key, `{"address":"`+addr+`"}`)
+		var resp map[string]bool
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return resp["subscribed"]
+	}
+
+	if w := do(http.MethodPost, "/v1/subscribe", "", `{"address":"`+shared+`"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a key, got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/v1/transactions?address="+shared, "bogus", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for an unknown key, got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/v1/current", "", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected /current to stay public, got %d", w.Code)
+	}
+
+	if !subscribe("pk", shared) || !subscribe("rk", shared) || subscribe("rk", shared) {
+		t.Fatal("Expected each tenant to subscribe the shared address once")
+	}
+	subscribe("pk", private)
+
+	// Tenants only see their own addresses and transactions.
+	if w := do(http.MethodGet, "/v1/transactions?address="+private, "rk", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for another tenant's address, got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/v1/transactions?address="+private, "pk", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected 200 for an owned address, got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/v1/transactions/"+hash, "rk", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for another tenant's transaction, got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/v1/transactions/"+hash, "pk", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected 200 for an owned transaction, got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/v1/token-transfers?address="+private, "rk", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for another tenant's token transfers, got %d", w.Code)
+	}
+
+	// The parser subscription outlives all but the last owner.
+	if w := do(http.MethodPost, "/v1/unsubscribe", "rk", `{"address":"`+private+`"}`); !strings.Contains(w.Body.String(), `"unsubscribed":false`) {
+		t.Errorf("Expected unsubscribing another tenant's address to be a no-op, got %s", w.Body)
+	}
+	do(http.MethodPost, "/v1/unsubscribe", "pk", `{"address":"`+shared+`"}`)
+	if !mock.subscriptions[shared] {
+		t.Error("Expected the shared address to stay subscribed for risk")
+	}
+	do(http.MethodPost, "/v1/unsubscribe", "rk", `{"address":"`+shared+`"}`)
+	if mock.subscriptions[shared] {
+		t.Error("Expected the shared address to be unsubscribed with its last owner")
+	}
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/address"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// HandleTokenTransfers returns token transfers for the address query param,
+// optionally narrowed to one token contract and standard.
+func (s *Server) HandleTokenTransfers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	raw := q.Get("address")
+	if raw == "" {
+		http.Error(w, "missing address", http.StatusBadRequest)
+		return
+	}
+	var contract string
+	if c := q.Get("contract"); c != "" {
+		var err error
+		if contract, err = address.Normalize(c); err != nil {
+			http.Error(w, "invalid contract: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	standard := transaction.TokenStandard(strings.ToLower(q.Get("standard")))
+	switch standard {
+	case "", transaction.StandardERC20, transaction.StandardERC721, transaction.StandardERC1155:
+	default:
+		http.Error(w, "invalid standard: expected erc20, erc721 or erc1155", http.StatusBadRequest)
+		return
+	}
+	addr, ok := s.resolveAddress(w, r, raw)
+	if !ok || !s.requireOwner(w, r, addr) {
+		return
+	}
+
+	out := []transaction.TokenTransfer{}
+	for _, tt := range s.parser.GetTokenTransfers(addr) {
+		if (contract == "" || tt.Contract == contract) && (standard == "" || tt.Standard == standard) {
+			out = append(out, tt)
+		}
+	}
+	if err := json.NewEncoder(w).Encode(out); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
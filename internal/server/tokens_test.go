@@ -0,0 +1,72 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+func TestServer_HandleTokenTransfers(t *testing.T) {
+	mock := NewMockParser()
+	address := "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"
+	usdc := "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+	mock.tokens[address] = []transaction.TokenTransfer{
+		{Hash: "0xhash1", Contract: usdc, Standard: transaction.StandardERC20, Symbol: "USDC", Decimals: 6, Amount: transaction.WeiValue(1500000)},
+		{Hash: "0xhash2", Contract: "0x00000000000000000000000000000000000000aa", Standard: transaction.StandardERC721, TokenID: "42", Amount: transaction.WeiValue(1)},
+	}
+	server := New(mock)
+
+	tests := []struct {
+		name           string
+		method         string
+		query          string
+		expectedStatus int
+		expectedHashes []string
+	}{
+		{name: "all", query: "?address=" + address, expectedStatus: http.StatusOK, expectedHashes: []string{"0xhash1", "0xhash2"}},
+		{name: "by contract", query: "?address=" + address + "&contract=0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", expectedStatus: http.StatusOK, expectedHashes: []string{"0xhash1"}},
+		{name: "by standard", query: "?address=" + address + "&standard=ERC721", expectedStatus: http.StatusOK, expectedHashes: []string{"0xhash2"}},
+		{name: "no transfers", query: "?address=0x0000000000000000000000000000000000000001", expectedStatus: http.StatusOK, expectedHashes: []string{}},
+		{name: "missing address", expectedStatus: http.StatusBadRequest},
+		{name: "invalid contract", query: "?address=" + address + "&contract=0x123", expectedStatus: http.StatusBadRequest},
+		{name: "invalid standard", query: "?address=" + address + "&standard=bep20", expectedStatus: http.StatusBadRequest},
+		{name: "wrong method", method: http.MethodPost, query: "?address=" + address, expectedStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/token-transfers"+tt.query, nil)
+			w := httptest.NewRecorder()
+			server.HandleTokenTransfers(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var got []map[string]any
+			if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if len(got) != len(tt.expectedHashes) {
+				t.Fatalf("Expected %d transfers, got %d", len(tt.expectedHashes), len(got))
+			}
+			for i, h := range tt.expectedHashes {
+				if got[i]["hash"] != h {
+					t.Errorf("Transfer %d: expected %s, got %v", i, h, got[i]["hash"])
+				}
+			}
+			if tt.name == "by contract" && got[0]["normalized_amount"] != "1.5" {
+				t.Errorf("Expected normalized amount 1.5, got %v", got[0]["normalized_amount"])
+			}
+		})
+	}
+}
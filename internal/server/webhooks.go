@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/danieloluwadare/tw-txparser/internal/audit"
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/internal/webhook"
+	"github.com/danieloluwadare/tw-txparser/pkg/address"
+)
+
+// minWebhookSecretLen is the shortest caller-supplied signing secret accepted.
+const minWebhookSecretLen = 16
+
+// HandleWebhooks registers a webhook via POST {"url":"...","addresses":[...],"secret":"..."}
+// and lists the caller's webhooks with their delivery status via GET.
+func (s *Server) HandleWebhooks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		if err := json.NewEncoder(w).Encode(s.opts.Webhooks.List(tenantFrom(r))); err != nil {
+			requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+		}
+	case http.MethodPost:
+		s.registerWebhook(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// registerWebhook validates and stores a new webhook. Addresses it lists are
+// subscribed so that their transactions are indexed and delivered. The
+// signing secret is generated unless provided, and is only returned here.
+func (s *Server) registerWebhook(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		URL       string   `json:"url"`
+		Addresses []string `json:"addresses"`
+		Secret    string   `json:"secret"`
+	}
+	if !decodeJSON(w, r, &body) {
+		return
+	}
+	hookURL, err := s.parseWebhookURL(r.Context(), body.URL)
+	if err != nil {
+		http.Error(w, "invalid url: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	addrs := make([]string, 0, len(body.Addresses))
+	for _, raw := range body.Addresses {
+		addr, err := address.Normalize(raw)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		addrs = append(addrs, addr)
+	}
+
+	if err := checkWebhookSecret(body.Secret); err != nil {
+		http.Error(w, "invalid secret: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hook, err := s.opts.Webhooks.Register(tenantFrom(r), hookURL, body.Secret, addrs)
+	if err != nil {
+		requestLogger(r).Error("failed to register webhook", logging.KeyError, err)
+		http.Error(w, "failed to register webhook", http.StatusInternalServerError)
+		return
+	}
+	for _, addr := range addrs {
+		// Webhook subscriptions aren't owned by a tenant, so releasing the
+		// address through /unsubscribe mustn't drop them.
+		if s.opts.Tenants != nil {
+			s.opts.Tenants.Pin(addr)
+		}
+		ok := s.parser.Subscribe(addr)
+		s.recordAudit(r, audit.ActionSubscribe, "webhook", addr, ok)
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(hook); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
+
+// parseWebhookURL validates raw as an absolute http(s) URL whose host
+// resolves to addresses webhooks may be delivered to.
+func (s *Server) parseWebhookURL(ctx context.Context, raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", errors.New("expected absolute http(s) URL")
+	}
+	if err := s.opts.WebhookTargets.CheckHost(ctx, u.Hostname()); err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// checkWebhookSecret rejects caller-supplied signing secrets that are too
+// short. An empty secret is fine: one is generated.
+func checkWebhookSecret(secret string) error {
+	if secret != "" && len(secret) < minWebhookSecretLen {
+		return fmt.Errorf("must be at least %d characters", minWebhookSecretLen)
+	}
+	return nil
+}
+
+// HandleWebhook returns (GET) or deletes (DELETE) the webhook identified by
+// the {id} path value. Other tenants' webhooks respond 404.
+func (s *Server) HandleWebhook(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.getWebhook(w, r)
+	case http.MethodDelete:
+		s.deleteWebhook(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// getWebhook writes a webhook with its delivery status.
+func (s *Server) getWebhook(w http.ResponseWriter, r *http.Request) {
+	hook, err := s.opts.Webhooks.Get(tenantFrom(r), r.PathValue("id"))
+	if errors.Is(err, webhook.ErrNotFound) {
+		http.Error(w, "webhook not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		requestLogger(r).Error("failed to look up webhook", logging.KeyError, err)
+		http.Error(w, "failed to look up webhook", http.StatusInternalServerError)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(hook); err != nil {
+		requestLogger(r).Error("failed to encode response", logging.KeyError, err)
+	}
+}
+
+// deleteWebhook removes a webhook.
+func (s *Server) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	err := s.opts.Webhooks.Delete(tenantFrom(r), r.PathValue("id"))
+	if errors.Is(err, webhook.ErrNotFound) {
+		http.Error(w, "webhook not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		requestLogger(r).Error("failed to delete webhook", logging.KeyError, err)
+		http.Error(w, "failed to delete webhook", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,246 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danieloluwadare/tw-txparser/internal/tenant"
+	"github.com/danieloluwadare/tw-txparser/internal/webhook"
+)
+
+func TestServer_Webhooks(t *testing.T) {
+	mock := NewMockParser()
+	registry := webhook.NewRegistry()
+	handler := NewWithOptions(mock, Options{Webhooks: registry}).Handler()
+
+	// Register
+	body := `{"url":"https://example.com/hook","addresses":["0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/webhooks", bytes.NewReader([]byte(body)))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	var hook webhook.Webhook
+	if err := json.NewDecoder(w.Body).Decode(&hook); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if hook.ID == "" || hook.Addresses[0] != "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed" {
+		t.Errorf("Unexpected webhook: %+v", hook)
+	}
+	if hook.Secret == "" {
+		t.Error("Expected generated secret in create response")
+	}
+	if !mock.subscriptions["0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"] {
+		t.Error("Expected webhook address to be subscribed")
+	}
+
+	// List
+	req = httptest.NewRequest(http.MethodGet, "/v1/webhooks", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	var hooks []webhook.Webhook
+	if err := json.NewDecoder(w.Body).Decode(&hooks); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(hooks) != 1 {
+		t.Fatalf("Expected 1 webhook, got %d", len(hooks))
+	}
+	if hooks[0].Secret != "" || hooks[0].Status == nil {
+		t.Errorf("Expected listed webhook with status and without secret, got %+v", hooks[0])
+	}
+
+	// Get
+	req = httptest.NewRequest(http.MethodGet, "/v1/webhooks/"+hook.ID, nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var got webhook.Webhook
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if got.ID != hook.ID || got.Secret != "" || got.Status == nil {
+		t.Errorf("Unexpected webhook: %+v", got)
+	}
+
+	// Delete
+	req = httptest.NewRequest(http.MethodDelete, "/v1/webhooks/"+hook.ID, nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/v1/webhooks/"+hook.ID, nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestServer_Webhooks_Tenants(t *testing.T) {
+	tenants, err := tenant.New(map[string]string{"payments": "pk", "risk": "rk"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := NewWithOptions(NewMockParser(), Options{Webhooks: webhook.NewRegistry(), Tenants: tenants}).Handler()
+	do := func(method, path, key, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(apiKeyHeader, key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+	list := func(key string) []webhook.Webhook {
+		t.Helper()
+		var hooks []webhook.Webhook
+		if err := json.NewDecoder(do(http.MethodGet, "/v1/webhooks", key, "").Body).Decode(&hooks); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return hooks
+	}
+
+	w := do(http.MethodPost, "/v1/webhooks", "pk", `{"url":"https://example.com/payments"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	var hook webhook.Webhook
+	if err := json.NewDecoder(w.Body).Decode(&hook); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	if hooks := list("rk"); len(hooks) != 0 {
+		t.Errorf("Expected another tenant's webhooks to be hidden, got %+v", hooks)
+	}
+	if w := do(http.MethodGet, "/v1/webhooks/"+hook.ID, "rk", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for another tenant's webhook, got %d", w.Code)
+	}
+	if w := do(http.MethodDelete, "/v1/webhooks/"+hook.ID, "rk", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 deleting another tenant's webhook, got %d", w.Code)
+	}
+	if hooks := list("pk"); len(hooks) != 1 || hooks[0].ID != hook.ID {
+		t.Errorf("Expected the owner to still see its webhook, got %+v", hooks)
+	}
+	if w := do(http.MethodDelete, "/v1/webhooks/"+hook.ID, "pk", ""); w.Code != http.StatusNoContent {
+		t.Errorf("Expected the owner to delete its webhook, got %d", w.Code)
+	}
+}
+
+func TestServer_Webhooks_Validation(t *testing.T) {
+	handler := NewWithOptions(NewMockParser(), Options{Webhooks: webhook.NewRegistry()}).Handler()
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "invalid JSON", body: `nope`},
+		{name: "relative url", body: `{"url":"/hook"}`},
+		{name: "unsupported scheme", body: `{"url":"ftp://example.com/hook"}`},
+		{name: "invalid address", body: `{"url":"https://example.com/hook","addresses":["0x123"]}`},
+		{name: "short secret", body: `{"url":"https://example.com/hook","secret":"abc"}`},
+		{name: "loopback url", body: `{"url":"http://127.0.0.1:8080/hook"}`},
+		{name: "IPv6 loopback url", body: `{"url":"http://[::1]/hook"}`},
+		{name: "private url", body: `{"url":"http://10.0.0.1/hook"}`},
+		{name: "metadata url", body: `{"url":"http://169.254.169.254/latest/meta-data"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/webhooks", bytes.NewReader([]byte(tt.body)))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+			}
+		})
+	}
+}
+
+func TestServer_Webhooks_AllowedNetworks(t *testing.T) {
+	targets, err := webhook.NewTargets([]string{"127.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := NewWithOptions(NewMockParser(), Options{Webhooks: webhook.NewRegistry(), WebhookTargets: targets}).Handler()
+	for body, want := range map[string]int{
+		`{"url":"http://127.0.0.1:8080/hook"}`: http.StatusCreated,
+		`{"url":"http://10.0.0.1/hook"}`:       http.StatusBadRequest,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/webhooks", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("Expected status %d for %s, got %d", want, body, w.Code)
+		}
+	}
+}
+
+func TestServer_SubscribeCallback(t *testing.T) {
+	const addr = "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"
+	mock := NewMockParser()
+	registry := webhook.NewRegistry()
+	handler := NewWithOptions(mock, Options{Webhooks: registry}).Handler()
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader([]byte(body)))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post("/v1/subscribe", `{"address":"`+addr+`","callback_url":"https://example.com/cb"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Subscribed bool             `json:"subscribed"`
+		Webhook    *webhook.Webhook `json:"webhook"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !resp.Subscribed || resp.Webhook == nil || resp.Webhook.Secret == "" || !resp.Webhook.Callback {
+		t.Fatalf("Expected a subscription with a callback webhook, got %+v", resp)
+	}
+	if hooks := registry.Match(addr); len(hooks) != 1 || hooks[0].URL != "https://example.com/cb" {
+		t.Errorf("Expected the callback to receive the address's events, got %+v", hooks)
+	}
+	if hooks := registry.Match("0x0000000000000000000000000000000000000001"); len(hooks) != 0 {
+		t.Errorf("Expected the callback to be limited to its address, got %+v", hooks)
+	}
+
+	// Subscribing again moves the callback.
+	post("/v1/subscribe", `{"address":"`+addr+`","callback_url":"https://example.com/cb2"}`)
+	if hooks := registry.Match(addr); len(hooks) != 1 || hooks[0].URL != "https://example.com/cb2" {
+		t.Errorf("Expected the callback to be replaced, got %+v", hooks)
+	}
+
+	post("/v1/unsubscribe", `{"address":"`+addr+`"}`)
+	if hooks := registry.Match(addr); len(hooks) != 0 {
+		t.Errorf("Expected unsubscribing to remove the callback, got %+v", hooks)
+	}
+
+	for _, body := range []string{
+		`{"address":"` + addr + `","callback_url":"ftp://example.com"}`,
+		`{"address":"` + addr + `","callback_url":"https://example.com/cb","callback_secret":"short"}`,
+		`{"address":"` + addr + `","callback_url":"http://169.254.169.254/cb"}`,
+	} {
+		if w := post("/v1/subscribe", body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", body, w.Code)
+		}
+	}
+	noHooks := NewWithOptions(NewMockParser(), Options{}).Handler()
+	req := httptest.NewRequest(http.MethodPost, "/v1/subscribe", bytes.NewReader([]byte(`{"address":"`+addr+`","callback_url":"https://example.com/cb"}`)))
+	w = httptest.NewRecorder()
+	noHooks.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 without webhooks, got %d", w.Code)
+	}
+}
@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// Compact rewrites the transaction, token transfer, contract call and log
+// lists that hold duplicates, are out of block order, as after a backward
+// scan, or have more than a quarter of their capacity unused. Lists are
+// compacted one at a time so writers are never held up for long, and into
+// new backing arrays as slices returned by earlier reads may still be in
+// use.
+func (m *MemoryStorage) Compact() CompactionStats {
+	var stats CompactionStats
+	txKey := func(tx transaction.Transaction) string { return tx.Hash + "|" + string(tx.Direction) }
+	byBlock := func(a, b transaction.Transaction) int { return a.Block - b.Block }
+	dropTx := func(tx transaction.Transaction) {
+		m.txCount--
+		m.bytes -= transactionSize(tx)
+	}
+	compactMap(&m.mu, m.txs, txKey, byBlock, dropTx, m.bump, &stats)
+	compactMap(&m.mu, m.calls, txKey, byBlock, nil, nil, &stats)
+	compactMap(&m.mu, m.tokens,
+		func(tt transaction.TokenTransfer) string {
+			return fmt.Sprintf("%s|%d|%s|%s", tt.Hash, tt.LogIndex, tt.TokenID, tt.Direction)
+		},
+		func(a, b transaction.TokenTransfer) int {
+			if a.Block != b.Block {
+				return a.Block - b.Block
+			}
+			return a.LogIndex - b.LogIndex
+		},
+		func(tt transaction.TokenTransfer) { m.bytes -= tokenTransferSize(tt) }, nil, &stats)
+	compactMap(&m.mu, m.logs,
+		func(l transaction.Log) string { return fmt.Sprintf("%s|%d", l.Hash, l.LogIndex) },
+		func(a, b transaction.Log) int {
+			if a.Block != b.Block {
+				return a.Block - b.Block
+			}
+			return a.LogIndex - b.LogIndex
+		}, nil, nil, &stats)
+
+	m.mu.Lock()
+	m.reportFootprint()
+	m.mu.Unlock()
+	m.metrics.Add(metrics.CompactionReclaimed, float64(stats.ReclaimedBytes))
+	return stats
+}
+
+// compactMap compacts the lists of lists one at a time, holding mu for
+// each, and adds to stats. drop, if not nil, is called with mu held for
+// every duplicate removed, and rewritten, if not nil, with the key of every
+// list rewritten.
+func compactMap[K comparable, T any](mu *sync.Mutex, lists map[K][]T, key func(T) string, cmp func(a, b T) int, drop func(T), rewritten func(K), stats *CompactionStats) {
+	mu.Lock()
+	keys := make([]K, 0, len(lists))
+	for k := range lists {
+		keys = append(keys, k)
+	}
+	mu.Unlock()
+
+	for _, k := range keys {
+		mu.Lock()
+		// The list may have been purged in the meantime.
+		if recs, ok := lists[k]; ok {
+			if out, ok := compactList(recs, key, cmp, drop, stats); ok {
+				lists[k] = out
+				if rewritten != nil {
+					rewritten(k)
+				}
+			}
+		}
+		mu.Unlock()
+	}
+}
+
+// compactList returns a copy of recs without duplicates by key, passing them
+// to drop if not nil, stably sorted by cmp and without spare capacity, and
+// adds to stats. It reports false, leaving recs alone, when recs needs no
+// compaction.
+func compactList[T any](recs []T, key func(T) string, cmp func(a, b T) int, drop func(T), stats *CompactionStats) ([]T, bool) {
+	seen := make(map[string]struct{}, len(recs))
+	dups := 0
+	for _, r := range recs {
+		k := key(r)
+		if _, ok := seen[k]; ok {
+			dups++
+		}
+		seen[k] = struct{}{}
+	}
+	sorted := slices.IsSortedFunc(recs, cmp)
+	if dups == 0 && sorted && cap(recs)-len(recs) <= len(recs)/4 {
+		return recs, false
+	}
+
+	out := make([]T, 0, len(recs)-dups)
+	clear(seen)
+	for _, r := range recs {
+		k := key(r)
+		if _, ok := seen[k]; ok {
+			if drop != nil {
+				drop(r)
+			}
+			continue
+		}
+		seen[k] = struct{}{}
+		out = append(out, r)
+	}
+	if !sorted {
+		slices.SortStableFunc(out, cmp)
+	}
+	stats.Lists++
+	stats.Duplicates += dups
+	stats.ReclaimedBytes += int64(cap(recs)-len(out)) * int64(reflect.TypeFor[T]().Size())
+	return out, true
+}
@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"unsafe"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// Sizes of the record structs, excluding what their fields point to.
+var (
+	transactionStructSize   = int64(unsafe.Sizeof(transaction.Transaction{}))
+	tokenTransferStructSize = int64(unsafe.Sizeof(transaction.TokenTransfer{}))
+)
+
+// transactionSize estimates the memory held by a stored transaction: the
+// struct and its strings. Amounts, fees and decoded calls are left out, as
+// are strings shared with the records of the counterparty.
+func transactionSize(tx transaction.Transaction) int64 {
+	return transactionStructSize + int64(len(tx.Hash)+len(tx.From)+len(tx.To)+len(tx.Input))
+}
+
+// tokenTransferSize estimates the memory held by a stored token transfer
+// like transactionSize.
+func tokenTransferSize(tt transaction.TokenTransfer) int64 {
+	return tokenTransferStructSize + int64(len(tt.Hash)+len(tt.Contract)+len(tt.Symbol)+len(tt.Name)+len(tt.From)+len(tt.To)+len(tt.TokenID))
+}
+
+// reportFootprint publishes the number of addresses with records, of
+// transaction records and their estimated size. m.mu must be held.
+func (m *MemoryStorage) reportFootprint() {
+	m.metrics.Set(metrics.StoredAddresses, float64(len(m.touched)))
+	m.metrics.Set(metrics.StoredTransactions, float64(m.txCount))
+	m.metrics.Set(metrics.StoredBytes, float64(m.bytes))
+}
@@ -2,23 +2,104 @@
 package storage
 
 import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
 	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
 )
 
 // MemoryStorage is a thread-safe in-memory implementation of Storage.
 type MemoryStorage struct {
-	mu   sync.Mutex
-	subs map[string]bool
-	txs  map[string][]transaction.Transaction
+	mu     sync.Mutex
+	subs   map[string]bool
+	txs    map[string][]transaction.Transaction
+	byHash map[string]transaction.Transaction
+	seen   map[string]struct{} // idempotency keys of stored records
+	tokens map[string][]transaction.TokenTransfer
+	// allowances holds the allowances granted by each owner, by
+	// contract|spender; revoked ones are kept so older events can't
+	// restore them
+	allowances map[string]map[string]transaction.Allowance
+	eventSubs  map[transaction.EventSubscription]bool
+	logs       map[transaction.EventSubscription][]transaction.Log
+	watched    map[string]bool
+	calls      map[string][]transaction.Transaction // by watched contract
+	totals     map[string]*transaction.Totals
+	times      map[int]time.Time // block -> timestamp
+
+	// revisions holds the value of revision when the transactions of each
+	// address last changed; revision only ever grows, so an address that
+	// is purged and stored again never goes back to an earlier revision
+	revisions map[string]uint64
+	revision  uint64
+
+	// truncated holds the earliest retained block of addresses that had
+	// transactions dropped to stay within maxPerAddress; older transactions
+	// are no longer stored for them
+	truncated     map[string]int
+	maxPerAddress int
+
+	// everSubscribed holds every address subscribed since startup, whose
+	// records are never garbage collected
+	everSubscribed map[string]bool
+	// touched holds when a record was last stored for each address
+	touched map[string]time.Time
+	now     func() time.Time
+
+	// txCount and bytes track the footprint of per-address records as they
+	// are added and removed
+	txCount int
+	bytes   int64
+
+	metrics metrics.Recorder
+}
+
+// MemoryOptions configures optional MemoryStorage behavior.
+type MemoryOptions struct {
+	// Metrics records stored transactions and subscriptions. Defaults to metrics.Nop.
+	Metrics metrics.Recorder
+	// MaxTransactionsPerAddress caps the transactions kept per address. Past
+	// it, those of the oldest blocks are dropped, down to 90% of the cap,
+	// and the address is reported as truncated. 0 keeps everything.
+	MaxTransactionsPerAddress int
 }
 
 // NewMemoryStorage creates a fresh MemoryStorage.
 func NewMemoryStorage() Storage {
+	return NewMemoryStorageWithOptions(MemoryOptions{})
+}
+
+// NewMemoryStorageWithOptions creates a fresh MemoryStorage with the provided options.
+func NewMemoryStorageWithOptions(opts MemoryOptions) Storage {
 	return &MemoryStorage{
-		subs: make(map[string]bool),
-		txs:  make(map[string][]transaction.Transaction),
+		subs:   make(map[string]bool),
+		txs:    make(map[string][]transaction.Transaction),
+		byHash: make(map[string]transaction.Transaction),
+		seen:   make(map[string]struct{}),
+		tokens: make(map[string][]transaction.TokenTransfer),
+		totals: make(map[string]*transaction.Totals),
+
+		revisions: make(map[string]uint64),
+
+		truncated:     make(map[string]int),
+		maxPerAddress: opts.MaxTransactionsPerAddress,
+
+		everSubscribed: make(map[string]bool),
+		touched:        make(map[string]time.Time),
+		now:            time.Now,
+
+		allowances: make(map[string]map[string]transaction.Allowance),
+		eventSubs:  make(map[transaction.EventSubscription]bool),
+		logs:       make(map[transaction.EventSubscription][]transaction.Log),
+		watched:    make(map[string]bool),
+		calls:      make(map[string][]transaction.Transaction),
+		times:      make(map[int]time.Time),
+		metrics:    metrics.OrNop(opts.Metrics),
 	}
 }
 
@@ -30,14 +111,182 @@ func (m *MemoryStorage) Subscribe(address string) bool {
 		return false
 	}
 	m.subs[address] = true
+	m.everSubscribed[address] = true
+	m.metrics.Set(metrics.Subscriptions, float64(len(m.subs)))
 	return true
 }
 
-// AddTransaction appends a transaction to an address's list.
+// Subscriptions returns the subscribed addresses in sorted order.
+func (m *MemoryStorage) Subscriptions() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]string, 0, len(m.subs))
+	for addr := range m.subs {
+		out = append(out, addr)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Unsubscribe removes an address. Returns false if it wasn't subscribed.
+// Its stored transactions are kept and become visible again on resubscribe.
+func (m *MemoryStorage) Unsubscribe(address string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.subs[address] {
+		return false
+	}
+	delete(m.subs, address)
+	m.metrics.Set(metrics.Subscriptions, float64(len(m.subs)))
+	return true
+}
+
+// AddTransaction appends a transaction to an address's list. Re-adding a
+// transaction with the same idempotency key (e.g. during a rescan) is a
+// no-op, as is adding one older than the earliest block retained for a
+// truncated address.
 func (m *MemoryStorage) AddTransaction(addr string, tx transaction.Transaction) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if earliest, ok := m.truncated[addr]; ok && tx.Block < earliest {
+		return
+	}
+	key := tx.IdempotencyKey(addr)
+	if _, dup := m.seen[key]; dup {
+		return
+	}
+	m.seen[key] = struct{}{}
+	m.metrics.Add(metrics.TransactionsStored, 1)
 	m.txs[addr] = append(m.txs[addr], tx)
+	m.bump(addr)
+	m.touched[addr] = m.now()
+	m.txCount++
+	m.bytes += transactionSize(tx)
+	m.totalsOf(addr).Add(tx)
+	if _, ok := m.byHash[tx.Hash]; !ok {
+		m.byHash[tx.Hash] = tx
+	}
+	if m.maxPerAddress > 0 && len(m.txs[addr]) > m.maxPerAddress {
+		m.truncate(addr)
+	}
+	m.reportFootprint()
+}
+
+// truncate drops the transactions of the oldest blocks stored for addr
+// until at most 90% of maxPerAddress are left, so that it runs once per
+// tenth of the cap rather than on every insert, and records the earliest
+// retained block, below which AddTransaction stores nothing more. Totals
+// keep accounting for the dropped transactions. m.mu must be held.
+func (m *MemoryStorage) truncate(addr string) {
+	txs := m.txs[addr]
+	keep := max(m.maxPerAddress-m.maxPerAddress/10, 1)
+	blocks := make([]int, len(txs))
+	for i, tx := range txs {
+		blocks[i] = tx.Block
+	}
+	slices.Sort(blocks)
+	// Whole blocks are dropped, so that the earliest retained block tells
+	// which transactions are gone.
+	cutoff := blocks[len(blocks)-keep]
+	if i, _ := slices.BinarySearch(blocks, cutoff); len(blocks)-i > m.maxPerAddress {
+		cutoff++
+	}
+
+	// A new backing array keeps slices returned by earlier reads intact.
+	kept := make([]transaction.Transaction, 0, keep)
+	for _, tx := range txs {
+		if tx.Block >= cutoff {
+			kept = append(kept, tx)
+			continue
+		}
+		m.txCount--
+		m.bytes -= transactionSize(tx)
+		delete(m.seen, tx.IdempotencyKey(addr))
+		m.forgetHash(addr, tx)
+	}
+	m.txs[addr] = kept
+	m.truncated[addr] = cutoff
+}
+
+// forgetHash drops tx, just removed from addr's list, from hash lookups
+// unless its counterparty still has it. m.mu must be held.
+func (m *MemoryStorage) forgetHash(addr string, tx transaction.Transaction) {
+	other := tx.To
+	if other == addr {
+		other = tx.From
+	}
+	for _, dir := range []transaction.Direction{transaction.DirectionIn, transaction.DirectionOut, transaction.DirectionSelf} {
+		tx.Direction = dir
+		if _, ok := m.seen[tx.IdempotencyKey(other)]; ok {
+			return
+		}
+	}
+	delete(m.byHash, tx.Hash)
+}
+
+// RevertBlock removes the transactions and token transfers stored for addr
+// from block, as if they had never been added, and returns the
+// transactions removed.
+func (m *MemoryStorage) RevertBlock(addr string, block int) []transaction.Transaction {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var removed []transaction.Transaction
+	if slices.ContainsFunc(m.txs[addr], func(tx transaction.Transaction) bool { return tx.Block == block }) {
+		// A new backing array keeps slices returned by earlier reads intact.
+		kept := make([]transaction.Transaction, 0, len(m.txs[addr]))
+		for _, tx := range m.txs[addr] {
+			if tx.Block != block {
+				kept = append(kept, tx)
+				continue
+			}
+			removed = append(removed, tx)
+			m.txCount--
+			m.bytes -= transactionSize(tx)
+			m.totalsOf(addr).Remove(tx)
+			delete(m.seen, tx.IdempotencyKey(addr))
+			m.forgetHash(addr, tx)
+		}
+		m.txs[addr] = kept
+		m.bump(addr)
+	}
+	if slices.ContainsFunc(m.tokens[addr], func(tt transaction.TokenTransfer) bool { return tt.Block == block }) {
+		kept := make([]transaction.TokenTransfer, 0, len(m.tokens[addr]))
+		for _, tt := range m.tokens[addr] {
+			if tt.Block != block {
+				kept = append(kept, tt)
+				continue
+			}
+			m.bytes -= tokenTransferSize(tt)
+			m.totalsOf(addr).RemoveTokenTransfer(tt)
+			delete(m.seen, tt.IdempotencyKey(addr))
+		}
+		m.tokens[addr] = kept
+	}
+	m.reportFootprint()
+	return removed
+}
+
+// Revision returns the revision of the transactions stored for an address,
+// which changes whenever one is added or updated, or 0 if none ever was.
+func (m *MemoryStorage) Revision(addr string) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.revisions[addr]
+}
+
+// bump moves the transactions of addr to a new revision. m.mu must be held.
+func (m *MemoryStorage) bump(addr string) {
+	m.revision++
+	m.revisions[addr] = m.revision
+}
+
+// Truncation reports whether transactions of an address were dropped to
+// stay within MaxTransactionsPerAddress, and the earliest block retained.
+func (m *MemoryStorage) Truncation(addr string) (int, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	earliest, ok := m.truncated[addr]
+	return earliest, ok
 }
 
 // GetTransactions returns the transactions associated with an address.
@@ -53,9 +302,423 @@ func (m *MemoryStorage) GetTransactions(addr string) []transaction.Transaction {
 	return m.txs[addr]
 }
 
+// GetTransactionByHash returns the stored transaction with the given hash.
+func (m *MemoryStorage) GetTransactionByHash(hash string) (transaction.Transaction, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tx, ok := m.byHash[hash]
+	return tx, ok
+}
+
 // IsSubscribed checks if an address is registered.
 func (m *MemoryStorage) IsSubscribed(addr string) bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	return m.subs[addr]
 }
+
+// AddTokenTransfer appends a token transfer to an address's list. Re-adding
+// a transfer with the same idempotency key is a no-op.
+func (m *MemoryStorage) AddTokenTransfer(addr string, tt transaction.TokenTransfer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := tt.IdempotencyKey(addr)
+	if _, dup := m.seen[key]; dup {
+		return
+	}
+	m.seen[key] = struct{}{}
+	m.tokens[addr] = append(m.tokens[addr], tt)
+	m.touched[addr] = m.now()
+	m.bytes += tokenTransferSize(tt)
+	m.reportFootprint()
+	m.totalsOf(addr).AddTokenTransfer(tt)
+}
+
+// GetTokenTransfers returns the token transfers associated with an address.
+// Only returns transfers if the address is subscribed.
+func (m *MemoryStorage) GetTokenTransfers(addr string) []transaction.TokenTransfer {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.subs[addr] {
+		return []transaction.TokenTransfer{}
+	}
+	return m.tokens[addr]
+}
+
+// Counts returns the number of transactions and token transfers stored for
+// an address, subscribed or not.
+func (m *MemoryStorage) Counts(addr string) (txs, tokens int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.txs[addr]), len(m.tokens[addr])
+}
+
+// SetAllowance records an allowance for its owner unless a later event set
+// it already.
+func (m *MemoryStorage) SetAllowance(a transaction.Allowance) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	byKey := m.allowances[a.Owner]
+	if byKey == nil {
+		byKey = make(map[string]transaction.Allowance)
+		m.allowances[a.Owner] = byKey
+	}
+	key := a.Contract + "|" + a.Spender
+	if old, ok := byKey[key]; ok && !a.After(old) {
+		return
+	}
+	byKey[key] = a
+}
+
+// Allowances returns the allowances granted by an address, leaving out
+// revoked ones. Only returns allowances if the address is subscribed.
+func (m *MemoryStorage) Allowances(owner string) []transaction.Allowance {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := []transaction.Allowance{}
+	if !m.subs[owner] {
+		return out
+	}
+	for _, a := range m.allowances[owner] {
+		if a.Amount.Sign() > 0 {
+			out = append(out, a)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Contract != out[j].Contract {
+			return out[i].Contract < out[j].Contract
+		}
+		return out[i].Spender < out[j].Spender
+	})
+	return out
+}
+
+// SubscribeEvents registers an event subscription. Returns false if it
+// already exists.
+func (m *MemoryStorage) SubscribeEvents(sub transaction.EventSubscription) bool {
+	sub = sub.Normalize()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.eventSubs[sub] {
+		return false
+	}
+	m.eventSubs[sub] = true
+	return true
+}
+
+// UnsubscribeEvents removes an event subscription, keeping its logs.
+// Returns false if it wasn't registered.
+func (m *MemoryStorage) UnsubscribeEvents(sub transaction.EventSubscription) bool {
+	sub = sub.Normalize()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.eventSubs[sub] {
+		return false
+	}
+	delete(m.eventSubs, sub)
+	return true
+}
+
+// EventSubscriptions returns the event subscriptions in sorted order.
+func (m *MemoryStorage) EventSubscriptions() []transaction.EventSubscription {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]transaction.EventSubscription, 0, len(m.eventSubs))
+	for sub := range m.eventSubs {
+		out = append(out, sub)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Contract != out[j].Contract {
+			return out[i].Contract < out[j].Contract
+		}
+		return out[i].Topic0 < out[j].Topic0
+	})
+	return out
+}
+
+// AddLog appends a log to the list of the subscription it matches.
+// Re-adding the same log is a no-op.
+func (m *MemoryStorage) AddLog(l transaction.Log) {
+	sub := l.Subscription()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := fmt.Sprintf("log|%s|%s|%s|%d", sub.Contract, sub.Topic0, l.Hash, l.LogIndex)
+	if _, dup := m.seen[key]; dup {
+		return
+	}
+	m.seen[key] = struct{}{}
+	m.logs[sub] = append(m.logs[sub], l)
+}
+
+// GetLogs returns the logs stored for an event subscription. Only returns
+// logs if it is registered.
+func (m *MemoryStorage) GetLogs(sub transaction.EventSubscription) []transaction.Log {
+	sub = sub.Normalize()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.eventSubs[sub] {
+		return []transaction.Log{}
+	}
+	return m.logs[sub]
+}
+
+// WatchContract registers a contract watch. Returns false if the contract
+// is already watched.
+func (m *MemoryStorage) WatchContract(contract string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.watched[contract] {
+		return false
+	}
+	m.watched[contract] = true
+	return true
+}
+
+// UnwatchContract removes a contract watch, keeping its calls. Returns false
+// if the contract wasn't watched.
+func (m *MemoryStorage) UnwatchContract(contract string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.watched[contract] {
+		return false
+	}
+	delete(m.watched, contract)
+	return true
+}
+
+// WatchedContracts returns the watched contracts in sorted order.
+func (m *MemoryStorage) WatchedContracts() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]string, 0, len(m.watched))
+	for contract := range m.watched {
+		out = append(out, contract)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// IsWatched checks if a contract is watched.
+func (m *MemoryStorage) IsWatched(contract string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.watched[contract]
+}
+
+// AddContractCall appends a transaction to a contract's calls. Re-adding
+// the same transaction is a no-op.
+func (m *MemoryStorage) AddContractCall(contract string, tx transaction.Transaction) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := "call|" + contract + "|" + tx.Hash
+	if _, dup := m.seen[key]; dup {
+		return
+	}
+	m.seen[key] = struct{}{}
+	m.calls[contract] = append(m.calls[contract], tx)
+}
+
+// GetContractCalls returns the calls stored for a contract. Only returns
+// calls if it is watched.
+func (m *MemoryStorage) GetContractCalls(contract string) []transaction.Transaction {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.watched[contract] {
+		return []transaction.Transaction{}
+	}
+	return m.calls[contract]
+}
+
+// SetReceipt fills in the receipt fields of the records of a transaction.
+func (m *MemoryStorage) SetReceipt(hash string, r transaction.Receipt) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.updateRecords(hash, func(addr string, tx *transaction.Transaction) {
+		// Fees fetched with the block are already in the totals.
+		if addr != "" && tx.Outbound() && tx.Fee == nil {
+			t := m.totalsOf(addr)
+			t.Fees = t.Fees.Add(r.Fee)
+		}
+		fee := r.Fee
+		tx.Status, tx.GasUsed, tx.Fee = r.Status, r.GasUsed, &fee
+	})
+}
+
+// SetCategory sets the category of the records of a transaction.
+func (m *MemoryStorage) SetCategory(hash string, c transaction.Category) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.updateRecords(hash, func(_ string, tx *transaction.Transaction) {
+		tx.Category = c
+	})
+}
+
+// updateRecords applies fn to every record of the transaction hash and
+// returns the number of records updated, not counting the hash index. fn is
+// passed the address a record is stored for, or "" for the hash index and
+// contract watch calls. Records are replaced rather than updated in place,
+// as slices returned by earlier reads may still be in use. m.mu must be
+// held.
+func (m *MemoryStorage) updateRecords(hash string, fn func(addr string, tx *transaction.Transaction)) int {
+	tx, ok := m.byHash[hash]
+	if !ok {
+		return 0
+	}
+	fn("", &tx)
+	m.byHash[hash] = tx
+
+	n := 0
+	update := func(addr string, txs []transaction.Transaction) []transaction.Transaction {
+		txs = slices.Clone(txs)
+		for i := range txs {
+			if txs[i].Hash == hash {
+				fn(addr, &txs[i])
+				n++
+			}
+		}
+		return txs
+	}
+	addrs := []string{tx.From}
+	if tx.To != "" && tx.To != tx.From {
+		addrs = append(addrs, tx.To)
+	}
+	for _, addr := range addrs {
+		if _, ok := m.txs[addr]; ok {
+			m.txs[addr] = update(addr, m.txs[addr])
+			m.bump(addr)
+		}
+	}
+	if contract := strings.ToLower(tx.To); m.calls[contract] != nil {
+		m.calls[contract] = update("", m.calls[contract])
+	}
+	return n
+}
+
+// Purge deletes the transactions, token transfers and allowances stored for
+// an address. Hash lookups keep working for transactions still stored for
+// another address.
+func (m *MemoryStorage) Purge(addr string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.purge(map[string]bool{addr: true})
+}
+
+// CollectGarbage purges the addresses that were never subscribed and had
+// nothing stored for longer than idle.
+func (m *MemoryStorage) CollectGarbage(idle time.Duration) (addresses, records int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cutoff := m.now().Add(-idle)
+	stale := make(map[string]bool)
+	for addr, t := range m.touched {
+		if !m.everSubscribed[addr] && t.Before(cutoff) {
+			stale[addr] = true
+		}
+	}
+	if len(stale) == 0 {
+		return 0, 0
+	}
+	records = m.purge(stale)
+	m.metrics.Add(metrics.GarbageCollected, float64(records))
+	return len(stale), records
+}
+
+// purge deletes the records of addrs in a single pass over the indexes and
+// returns the number removed. m.mu must be held.
+func (m *MemoryStorage) purge(addrs map[string]bool) int {
+	n := 0
+	purged := make(map[string]bool)
+	for addr := range addrs {
+		n += len(m.txs[addr]) + len(m.tokens[addr]) + len(m.allowances[addr])
+		for _, tx := range m.txs[addr] {
+			purged[tx.Hash] = true
+			m.txCount--
+			m.bytes -= transactionSize(tx)
+		}
+		for _, tt := range m.tokens[addr] {
+			m.bytes -= tokenTransferSize(tt)
+		}
+		delete(m.txs, addr)
+		delete(m.tokens, addr)
+		delete(m.allowances, addr)
+		delete(m.totals, addr)
+		delete(m.revisions, addr)
+		delete(m.truncated, addr)
+		delete(m.touched, addr)
+	}
+	for key := range m.seen {
+		if addr, _, _ := strings.Cut(key, ":"); addrs[addr] {
+			delete(m.seen, key)
+		}
+	}
+	for _, txs := range m.txs {
+		for _, tx := range txs {
+			delete(purged, tx.Hash)
+		}
+	}
+	for hash := range purged {
+		delete(m.byHash, hash)
+	}
+	m.reportFootprint()
+	return n
+}
+
+// Totals returns the value totals of an address. Only returns non-zero
+// totals if the address is subscribed.
+func (m *MemoryStorage) Totals(addr string) transaction.Totals {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.subs[addr] || m.totals[addr] == nil {
+		return transaction.Totals{}.Clone()
+	}
+	return m.totals[addr].Clone()
+}
+
+// totalsOf returns the totals of addr, creating them if needed. m.mu must
+// be held.
+func (m *MemoryStorage) totalsOf(addr string) *transaction.Totals {
+	t := m.totals[addr]
+	if t == nil {
+		t = &transaction.Totals{}
+		m.totals[addr] = t
+	}
+	return t
+}
+
+// SetBlockTime records the timestamp of a processed block.
+func (m *MemoryStorage) SetBlockTime(block int, t time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.times[block] = t
+}
+
+// BlockTime returns the recorded timestamp of a block.
+func (m *MemoryStorage) BlockTime(block int) (time.Time, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.times[block]
+	return t, ok
+}
+
+// TransactionsInRange returns the transactions of subscribed addresses in
+// blocks from through to.
+func (m *MemoryStorage) TransactionsInRange(from, to int) map[string][]transaction.Transaction {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string][]transaction.Transaction)
+	for addr := range m.subs {
+		var txs []transaction.Transaction
+		for _, tx := range m.txs[addr] {
+			if tx.Block >= from && tx.Block <= to {
+				txs = append(txs, tx)
+			}
+		}
+		if len(txs) == 0 {
+			continue
+		}
+		// Backward scans and rescans store older blocks late.
+		sort.SliceStable(txs, func(i, j int) bool { return txs[i].Block < txs[j].Block })
+		out[addr] = txs
+	}
+	return out
+}
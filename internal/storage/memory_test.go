@@ -1,8 +1,11 @@
 package storage
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
 	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
 )
 
@@ -52,12 +55,12 @@ func TestMemoryStorage_AddTransaction(t *testing.T) {
 
 	// Add first transaction
 	tx1 := transaction.Transaction{
-		Hash:    "0xhash1",
-		From:    "0xfrom1",
-		To:      address,
-		Value:   "1000",
-		Block:   1,
-		Inbound: true,
+		Hash:      "0xhash1",
+		From:      "0xfrom1",
+		To:        address,
+		Value:     transaction.WeiValue(1000),
+		Block:     1,
+		Direction: transaction.DirectionIn,
 	}
 	store.AddTransaction(address, tx1)
 
@@ -72,12 +75,12 @@ func TestMemoryStorage_AddTransaction(t *testing.T) {
 
 	// Add second transaction
 	tx2 := transaction.Transaction{
-		Hash:    "0xhash2",
-		From:    "0xfrom2",
-		To:      address,
-		Value:   "2000",
-		Block:   2,
-		Inbound: true,
+		Hash:      "0xhash2",
+		From:      "0xfrom2",
+		To:        address,
+		Value:     transaction.WeiValue(2000),
+		Block:     2,
+		Direction: transaction.DirectionIn,
 	}
 	store.AddTransaction(address, tx2)
 
@@ -110,8 +113,8 @@ func TestMemoryStorage_GetTransactions(t *testing.T) {
 	store.Subscribe(address)
 
 	// Add some transactions
-	tx1 := transaction.Transaction{Hash: "0xhash1", From: "0xfrom1", To: address, Value: "1000", Block: 1, Inbound: true}
-	tx2 := transaction.Transaction{Hash: "0xhash2", From: "0xfrom2", To: address, Value: "2000", Block: 2, Inbound: true}
+	tx1 := transaction.Transaction{Hash: "0xhash1", From: "0xfrom1", To: address, Value: transaction.WeiValue(1000), Block: 1, Direction: transaction.DirectionIn}
+	tx2 := transaction.Transaction{Hash: "0xhash2", From: "0xfrom2", To: address, Value: transaction.WeiValue(2000), Block: 2, Direction: transaction.DirectionIn}
 
 	store.AddTransaction(address, tx1)
 	store.AddTransaction(address, tx2)
@@ -136,8 +139,8 @@ func TestMemoryStorage_GetTransactions_SubscriptionRequired(t *testing.T) {
 	address := "0x1234567890abcdef"
 
 	// Add transactions without subscribing
-	tx1 := transaction.Transaction{Hash: "0xhash1", From: "0xfrom1", To: address, Value: "1000", Block: 1, Inbound: true}
-	tx2 := transaction.Transaction{Hash: "0xhash2", From: "0xfrom2", To: address, Value: "2000", Block: 2, Inbound: true}
+	tx1 := transaction.Transaction{Hash: "0xhash1", From: "0xfrom1", To: address, Value: transaction.WeiValue(1000), Block: 1, Direction: transaction.DirectionIn}
+	tx2 := transaction.Transaction{Hash: "0xhash2", From: "0xfrom2", To: address, Value: transaction.WeiValue(2000), Block: 2, Direction: transaction.DirectionIn}
 
 	store.AddTransaction(address, tx1)
 	store.AddTransaction(address, tx2)
@@ -158,6 +161,113 @@ func TestMemoryStorage_GetTransactions_SubscriptionRequired(t *testing.T) {
 	}
 }
 
+func TestMemoryStorage_Subscriptions(t *testing.T) {
+	store := NewMemoryStorage()
+	lister := store.(Lister)
+	if subs := lister.Subscriptions(); len(subs) != 0 {
+		t.Errorf("Expected no subscriptions, got %v", subs)
+	}
+	store.Subscribe("0xbbb")
+	store.Subscribe("0xaaa")
+	store.Subscribe("0xccc")
+	store.Unsubscribe("0xccc")
+	if subs := lister.Subscriptions(); fmt.Sprint(subs) != "[0xaaa 0xbbb]" {
+		t.Errorf("Expected [0xaaa 0xbbb], got %v", subs)
+	}
+}
+
+func TestMemoryStorage_Counts(t *testing.T) {
+	store := NewMemoryStorage()
+	counter := store.(Counter)
+	store.AddTransaction("0xaaa", transaction.Transaction{Hash: "0x1", Direction: transaction.DirectionIn})
+	store.AddTransaction("0xaaa", transaction.Transaction{Hash: "0x2", Direction: transaction.DirectionIn})
+	store.AddTokenTransfer("0xaaa", transaction.TokenTransfer{Hash: "0x3", Direction: transaction.DirectionIn})
+	if txs, tokens := counter.Counts("0xaaa"); txs != 2 || tokens != 1 {
+		t.Errorf("Expected 2 transactions and 1 token transfer, got %d and %d", txs, tokens)
+	}
+	if txs, tokens := counter.Counts("0xbbb"); txs != 0 || tokens != 0 {
+		t.Errorf("Expected no records, got %d and %d", txs, tokens)
+	}
+}
+
+func TestMemoryStorage_RevertBlock(t *testing.T) {
+	store := NewMemoryStorage()
+	reverter := store.(Reverter)
+	const alice, bob = "0xaaa", "0xbbb"
+	store.Subscribe(alice)
+	store.Subscribe(bob)
+	kept := transaction.Transaction{Hash: "0x1", From: bob, To: alice, Value: transaction.WeiValue(5), Block: 10}
+	orphaned := transaction.Transaction{Hash: "0x2", From: bob, To: alice, Value: transaction.WeiValue(7), Block: 11}
+	for _, tx := range []transaction.Transaction{kept, orphaned} {
+		in, out := tx, tx
+		in.Direction, out.Direction = transaction.DirectionIn, transaction.DirectionOut
+		store.AddTransaction(alice, in)
+		store.AddTransaction(bob, out)
+	}
+	store.AddTokenTransfer(alice, transaction.TokenTransfer{Hash: "0x2", Contract: "0xc", Amount: transaction.WeiValue(1), Block: 11, Direction: transaction.DirectionIn})
+	before := store.(Revisioner).Revision(alice)
+
+	removed := reverter.RevertBlock(alice, 11)
+	if len(removed) != 1 || removed[0].Hash != "0x2" {
+		t.Fatalf("Expected the transaction of block 11 to be removed, got %+v", removed)
+	}
+	if got := store.GetTransactions(alice); len(got) != 1 || got[0].Hash != "0x1" {
+		t.Errorf("Expected only the transaction of block 10 to be left, got %+v", got)
+	}
+	if got := store.GetTokenTransfers(alice); len(got) != 0 {
+		t.Errorf("Expected the token transfer of block 11 to be removed, got %+v", got)
+	}
+	if totals := store.(Aggregator).Totals(alice); totals.Transactions != 1 || totals.In.String() != "5" || len(totals.Tokens) != 0 {
+		t.Errorf("Expected the totals to drop the removed records, got %+v", totals)
+	}
+	if store.(Revisioner).Revision(alice) == before {
+		t.Error("Expected the revision to change")
+	}
+	if _, ok := store.GetTransactionByHash("0x2"); !ok {
+		t.Error("Expected the hash lookup to be kept while bob still has the transaction")
+	}
+	reverter.RevertBlock(bob, 11)
+	if _, ok := store.GetTransactionByHash("0x2"); ok {
+		t.Error("Expected the hash lookup to be dropped once no address has the transaction")
+	}
+
+	// The transaction can be stored again, e.g. once mined in another block.
+	orphaned.Block, orphaned.Direction = 12, transaction.DirectionIn
+	store.AddTransaction(alice, orphaned)
+	if got := store.GetTransactions(alice); len(got) != 2 || got[1].Block != 12 {
+		t.Errorf("Expected the transaction to be stored again, got %+v", got)
+	}
+	if removed := reverter.RevertBlock(alice, 99); len(removed) != 0 {
+		t.Errorf("Expected nothing to be removed from an unknown block, got %+v", removed)
+	}
+}
+
+func TestMemoryStorage_Unsubscribe(t *testing.T) {
+	store := NewMemoryStorage()
+	address := "0x1234567890abcdef"
+	store.Subscribe(address)
+	store.AddTransaction(address, transaction.Transaction{Hash: "0xhash1"})
+
+	if !store.Unsubscribe(address) {
+		t.Error("Expected Unsubscribe to return true for subscribed address")
+	}
+	if store.IsSubscribed(address) {
+		t.Error("Expected address to be unsubscribed")
+	}
+	if store.Unsubscribe(address) {
+		t.Error("Expected Unsubscribe to return false for unsubscribed address")
+	}
+	if got := store.GetTransactions(address); len(got) != 0 {
+		t.Errorf("Expected no transactions while unsubscribed, got %d", len(got))
+	}
+
+	// Stored transactions are visible again after resubscribing.
+	store.Subscribe(address)
+	if got := store.GetTransactions(address); len(got) != 1 {
+		t.Errorf("Expected 1 transaction after resubscribing, got %d", len(got))
+	}
+}
+
 func TestMemoryStorage_IsSubscribed(t *testing.T) {
 	store := NewMemoryStorage()
 	address := "0x1234567890abcdef"
@@ -196,12 +306,12 @@ func TestMemoryStorage_Concurrency(t *testing.T) {
 	for i := 0; i < 5; i++ {
 		go func(i int) {
 			tx := transaction.Transaction{
-				Hash:    "0xhash" + string(rune(i)),
-				From:    "0xfrom",
-				To:      address,
-				Value:   "1000",
-				Block:   i,
-				Inbound: true,
+				Hash:      "0xhash" + string(rune(i)),
+				From:      "0xfrom",
+				To:        address,
+				Value:     transaction.WeiValue(1000),
+				Block:     i,
+				Direction: transaction.DirectionIn,
 			}
 			store.AddTransaction(address, tx)
 			done <- true
@@ -238,8 +348,8 @@ func TestMemoryStorage_MultipleAddresses(t *testing.T) {
 	store.Subscribe(address2)
 
 	// Add transactions for different addresses
-	tx1 := transaction.Transaction{Hash: "0xhash1", From: "0xfrom1", To: address1, Value: "1000", Block: 1, Inbound: true}
-	tx2 := transaction.Transaction{Hash: "0xhash2", From: "0xfrom2", To: address2, Value: "2000", Block: 2, Inbound: true}
+	tx1 := transaction.Transaction{Hash: "0xhash1", From: "0xfrom1", To: address1, Value: transaction.WeiValue(1000), Block: 1, Direction: transaction.DirectionIn}
+	tx2 := transaction.Transaction{Hash: "0xhash2", From: "0xfrom2", To: address2, Value: transaction.WeiValue(2000), Block: 2, Direction: transaction.DirectionIn}
 
 	store.AddTransaction(address1, tx1)
 	store.AddTransaction(address2, tx2)
@@ -262,3 +372,575 @@ func TestMemoryStorage_MultipleAddresses(t *testing.T) {
 		t.Errorf("Expected transaction2 hash %s, got %s", tx2.Hash, transactions2[0].Hash)
 	}
 }
+
+func TestMemoryStorage_GetTransactionByHash(t *testing.T) {
+	store := NewMemoryStorage()
+
+	if _, ok := store.GetTransactionByHash("0xhash1"); ok {
+		t.Error("Expected unknown hash to be missing")
+	}
+
+	tx := transaction.Transaction{Hash: "0xhash1", From: "0xfrom1", To: "0xto1", Value: transaction.WeiValue(1000), Block: 1}
+	store.AddTransaction("0xfrom1", tx)
+	tx.Direction = transaction.DirectionIn
+	store.AddTransaction("0xto1", tx)
+
+	got, ok := store.GetTransactionByHash("0xhash1")
+	if !ok {
+		t.Fatal("Expected stored hash to be found")
+	}
+	if got.Hash != "0xhash1" || got.Block != 1 {
+		t.Errorf("Unexpected transaction: %+v", got)
+	}
+}
+
+func TestMemoryStorage_AddTransaction_Duplicate(t *testing.T) {
+	store := NewMemoryStorage()
+	address := "0x1234567890abcdef"
+	store.Subscribe(address)
+
+	tx := transaction.Transaction{Hash: "0xhash1", From: "0xfrom1", To: address, Value: transaction.WeiValue(1000), Block: 1, Direction: transaction.DirectionIn}
+	store.AddTransaction(address, tx)
+	store.AddTransaction(address, tx)
+
+	if got := len(store.GetTransactions(address)); got != 1 {
+		t.Errorf("Expected duplicate to be ignored, got %d transactions", got)
+	}
+
+	// The same hash in the other direction (a self-transfer) is distinct.
+	tx.Direction = transaction.DirectionOut
+	store.AddTransaction(address, tx)
+	if got := len(store.GetTransactions(address)); got != 2 {
+		t.Errorf("Expected 2 transactions, got %d", got)
+	}
+}
+
+// countingRecorder sums counters and keeps the last gauge values.
+type countingRecorder struct {
+	counters map[string]float64
+	gauges   map[string]float64
+}
+
+func (c *countingRecorder) Add(name string, v float64, _ ...metrics.Label) { c.counters[name] += v }
+func (c *countingRecorder) Set(name string, v float64, _ ...metrics.Label) { c.gauges[name] = v }
+func (c *countingRecorder) Observe(string, float64, ...metrics.Label)      {}
+
+func TestMemoryStorage_Metrics(t *testing.T) {
+	rec := &countingRecorder{counters: map[string]float64{}, gauges: map[string]float64{}}
+	store := NewMemoryStorageWithOptions(MemoryOptions{Metrics: rec})
+
+	store.Subscribe("0xaaa")
+	store.Subscribe("0xaaa")
+	store.Subscribe("0xbbb")
+	tx := transaction.Transaction{Hash: "0xhash1", Direction: transaction.DirectionIn}
+	store.AddTransaction("0xaaa", tx)
+	store.AddTransaction("0xaaa", tx) // duplicate
+	store.AddTransaction("0xbbb", tx)
+
+	if got := rec.gauges[metrics.Subscriptions]; got != 2 {
+		t.Errorf("Expected 2 subscriptions, got %v", got)
+	}
+	if got := rec.counters[metrics.TransactionsStored]; got != 2 {
+		t.Errorf("Expected 2 stored transactions, got %v", got)
+	}
+}
+
+func TestMemoryStorage_Footprint(t *testing.T) {
+	rec := &countingRecorder{counters: map[string]float64{}, gauges: map[string]float64{}}
+	store := NewMemoryStorageWithOptions(MemoryOptions{Metrics: rec})
+
+	store.AddTransaction("0xaaa", transaction.Transaction{Hash: "0x01", Block: 1})
+	store.AddTransaction("0xaaa", transaction.Transaction{Hash: "0x02", Block: 2})
+	store.AddTransaction("0xbbb", transaction.Transaction{Hash: "0x01", Block: 1})
+	if got := rec.gauges[metrics.StoredAddresses]; got != 2 {
+		t.Errorf("Expected 2 addresses, got %v", got)
+	}
+	if got := rec.gauges[metrics.StoredTransactions]; got != 3 {
+		t.Errorf("Expected 3 transactions, got %v", got)
+	}
+	if got := rec.gauges[metrics.StoredBytes]; got <= 0 {
+		t.Errorf("Expected a positive byte estimate, got %v", got)
+	}
+
+	store.(*MemoryStorage).Purge("0xaaa")
+	if got := rec.gauges[metrics.StoredAddresses]; got != 1 {
+		t.Errorf("Expected 1 address after purge, got %v", got)
+	}
+	if got := rec.gauges[metrics.StoredTransactions]; got != 1 {
+		t.Errorf("Expected 1 transaction after purge, got %v", got)
+	}
+	store.(*MemoryStorage).Purge("0xbbb")
+	if got := rec.gauges[metrics.StoredBytes]; got != 0 {
+		t.Errorf("Expected 0 bytes once empty, got %v", got)
+	}
+}
+
+func TestMemoryStorage_TokenTransfers(t *testing.T) {
+	store := NewMemoryStorage()
+	address := "0x1234567890abcdef"
+	tt := transaction.TokenTransfer{
+		Hash: "0xhash1", LogIndex: 3, Block: 1, Contract: "0xtoken", Standard: transaction.StandardERC20,
+		From: "0xfrom1", To: address, Amount: transaction.WeiValue(1000), Direction: transaction.DirectionIn,
+	}
+	store.AddTokenTransfer(address, tt)
+	if got := store.GetTokenTransfers(address); len(got) != 0 {
+		t.Errorf("Expected no transfers before subscribing, got %d", len(got))
+	}
+
+	store.Subscribe(address)
+	store.AddTokenTransfer(address, tt) // duplicate
+	second := tt
+	second.LogIndex = 4
+	store.AddTokenTransfer(address, second)
+
+	got := store.GetTokenTransfers(address)
+	if len(got) != 2 || got[0].LogIndex != 3 || got[1].LogIndex != 4 {
+		t.Errorf("Unexpected token transfers: %+v", got)
+	}
+	if txs := store.GetTransactions(address); len(txs) != 0 {
+		t.Errorf("Expected token transfers to be kept apart from transactions, got %d", len(txs))
+	}
+}
+
+func TestMemoryStorage_Allowances(t *testing.T) {
+	store := NewMemoryStorage()
+	allowances := store.(AllowanceStore)
+	owner := "0xowner"
+	usdc := transaction.Allowance{Owner: owner, Spender: "0xrouter", Contract: "0xusdc", Amount: transaction.WeiValue(100), Block: 10, LogIndex: 1}
+	allowances.SetAllowance(usdc)
+	if got := allowances.Allowances(owner); len(got) != 0 {
+		t.Errorf("Expected no allowances before subscribing, got %+v", got)
+	}
+	store.Subscribe(owner)
+
+	// A later approval replaces the allowance; an earlier one, as seen on
+	// a rescan, doesn't.
+	raised := usdc
+	raised.Amount, raised.Block = transaction.WeiValue(500), 12
+	allowances.SetAllowance(raised)
+	allowances.SetAllowance(usdc)
+	dai := transaction.Allowance{Owner: owner, Spender: "0xrouter", Contract: "0xdai", Amount: transaction.WeiValue(1), Block: 11}
+	allowances.SetAllowance(dai)
+
+	got := allowances.Allowances(owner)
+	if len(got) != 2 || got[0].Contract != "0xdai" || got[1].Amount.String() != "500" {
+		t.Fatalf("Unexpected allowances %+v", got)
+	}
+
+	revoked := raised
+	revoked.Amount, revoked.LogIndex = transaction.Value{}, 2
+	allowances.SetAllowance(revoked)
+	allowances.SetAllowance(raised)
+	if got := allowances.Allowances(owner); len(got) != 1 || got[0].Contract != "0xdai" {
+		t.Errorf("Expected the revoked allowance to stay revoked, got %+v", got)
+	}
+
+	if n := store.(Purger).Purge(owner); n != 2 {
+		t.Errorf("Expected both allowances to be purged, got %d", n)
+	}
+	if got := allowances.Allowances(owner); len(got) != 0 {
+		t.Errorf("Expected no allowances after purge, got %+v", got)
+	}
+}
+
+func TestMemoryStorage_Logs(t *testing.T) {
+	store := NewMemoryStorage()
+	events := store.(EventStore)
+	sub := transaction.EventSubscription{Contract: "0xPool", Topic0: "0xSwap"}
+	swap := transaction.Log{Contract: "0xpool", Topics: []string{"0xswap", "0xsender"}, Data: "0x01", Block: 10, Hash: "0xhash1", LogIndex: 2}
+	events.AddLog(swap)
+	if got := events.GetLogs(sub); len(got) != 0 {
+		t.Errorf("Expected no logs before subscribing, got %+v", got)
+	}
+
+	if !events.SubscribeEvents(sub) || events.SubscribeEvents(sub.Normalize()) {
+		t.Error("Expected only the first subscription to be new")
+	}
+	events.SubscribeEvents(transaction.EventSubscription{Contract: "0xpool", Topic0: "0xmint"})
+	if got := events.EventSubscriptions(); len(got) != 2 || got[0].Topic0 != "0xmint" || got[1] != sub.Normalize() {
+		t.Errorf("Unexpected subscriptions %+v", got)
+	}
+
+	events.AddLog(swap) // duplicate
+	second := swap
+	second.LogIndex = 3
+	events.AddLog(second)
+	if got := events.GetLogs(sub); len(got) != 2 || got[0].LogIndex != 2 || got[1].LogIndex != 3 {
+		t.Errorf("Unexpected logs %+v", got)
+	}
+
+	if !events.UnsubscribeEvents(sub) || events.UnsubscribeEvents(sub) {
+		t.Error("Expected only the first unsubscribe to succeed")
+	}
+	if got := events.GetLogs(sub); len(got) != 0 {
+		t.Errorf("Expected no logs after unsubscribing, got %+v", got)
+	}
+}
+
+func TestMemoryStorage_ContractCalls(t *testing.T) {
+	store := NewMemoryStorage()
+	watches := store.(ContractWatchStore)
+	call := transaction.Transaction{Hash: "0xhash1", From: "0xuser", To: "0xpool", Block: 10, Direction: transaction.DirectionIn}
+	watches.AddContractCall("0xpool", call)
+	if got := watches.GetContractCalls("0xpool"); len(got) != 0 {
+		t.Errorf("Expected no calls before watching, got %+v", got)
+	}
+
+	if !watches.WatchContract("0xpool") || watches.WatchContract("0xpool") {
+		t.Error("Expected only the first watch to be new")
+	}
+	watches.WatchContract("0xamm")
+	if got := watches.WatchedContracts(); len(got) != 2 || got[0] != "0xamm" || !watches.IsWatched("0xpool") {
+		t.Errorf("Unexpected watched contracts %v", got)
+	}
+	if store.IsSubscribed("0xpool") {
+		t.Error("Expected a contract watch not to subscribe the address")
+	}
+
+	watches.AddContractCall("0xpool", call) // duplicate
+	second := call
+	second.Hash = "0xhash2"
+	watches.AddContractCall("0xpool", second)
+	if got := watches.GetContractCalls("0xpool"); len(got) != 2 || got[1].Hash != "0xhash2" {
+		t.Errorf("Unexpected calls %+v", got)
+	}
+
+	if !watches.UnwatchContract("0xpool") || watches.UnwatchContract("0xpool") {
+		t.Error("Expected only the first unwatch to succeed")
+	}
+	if got := watches.GetContractCalls("0xpool"); len(got) != 0 {
+		t.Errorf("Expected no calls after unwatching, got %+v", got)
+	}
+}
+
+func TestMemoryStorage_SetReceipt(t *testing.T) {
+	store := NewMemoryStorage()
+	a, b := "0xaaa", "0xbbb"
+	store.Subscribe(a)
+	store.Subscribe(b)
+	tx := transaction.Transaction{Hash: "0xhash", From: a, To: b, Value: transaction.WeiValue(100), Direction: transaction.DirectionOut}
+	store.AddTransaction(a, tx)
+	in := tx
+	in.Direction = transaction.DirectionIn
+	store.AddTransaction(b, in)
+	before := store.GetTransactions(a)
+
+	r := transaction.Receipt{Status: transaction.StatusReverted, GasUsed: 21000, Fee: transaction.WeiValue(42)}
+	if n := store.(ReceiptStore).SetReceipt("0xhash", r); n != 2 {
+		t.Errorf("Expected both records to be updated, got %d", n)
+	}
+	for _, addr := range []string{a, b} {
+		got := store.GetTransactions(addr)[0]
+		if got.Status != transaction.StatusReverted || got.GasUsed != 21000 || got.Fee == nil || got.Fee.String() != "42" {
+			t.Errorf("Unexpected record for %s: %+v", addr, got)
+		}
+	}
+	if got, _ := store.GetTransactionByHash("0xhash"); got.Status != transaction.StatusReverted {
+		t.Errorf("Expected the hash lookup to be updated, got %+v", got)
+	}
+	if before[0].Status != "" {
+		t.Error("Expected earlier reads not to change")
+	}
+	// Only the sender pays the fee.
+	agg := store.(Aggregator)
+	if fees := agg.Totals(a).Fees.String(); fees != "42" {
+		t.Errorf("Expected the sender's fees to include the fee, got %s", fees)
+	}
+	if fees := agg.Totals(b).Fees.String(); fees != "0" {
+		t.Errorf("Expected the receiver's fees to be unchanged, got %s", fees)
+	}
+	// Fetching a receipt again doesn't count its fee twice.
+	store.(ReceiptStore).SetReceipt("0xhash", r)
+	if fees := agg.Totals(a).Fees.String(); fees != "42" {
+		t.Errorf("Expected the fee to be counted once, got %s", fees)
+	}
+
+	if n := store.(ReceiptStore).SetReceipt("0xunknown", r); n != 0 {
+		t.Errorf("Expected no records for an unknown hash, got %d", n)
+	}
+}
+
+func TestMemoryStorage_Revision(t *testing.T) {
+	store := NewMemoryStorage()
+	rev := store.(Revisioner)
+	a, b := "0xaaa", "0xbbb"
+	store.Subscribe(a)
+	if got := rev.Revision(a); got != 0 {
+		t.Errorf("Expected revision 0 before any transaction, got %d", got)
+	}
+
+	tx := transaction.Transaction{Hash: "0xhash", From: a, To: b, Value: transaction.WeiValue(100), Direction: transaction.DirectionOut}
+	store.AddTransaction(a, tx)
+	added := rev.Revision(a)
+	if added == 0 {
+		t.Fatal("Expected the revision to change when a transaction is added")
+	}
+	store.AddTransaction(a, tx)
+	if got := rev.Revision(a); got != added {
+		t.Errorf("Expected a duplicate to leave revision %d alone, got %d", added, got)
+	}
+
+	store.(ReceiptStore).SetReceipt("0xhash", transaction.Receipt{Status: transaction.StatusSuccess, GasUsed: 21000, Fee: transaction.WeiValue(42)})
+	receipt := rev.Revision(a)
+	if receipt == added {
+		t.Error("Expected the revision to change when a receipt is set")
+	}
+	store.(CategoryStore).SetCategory("0xhash", transaction.CategoryTokenTransfer)
+	if got := rev.Revision(a); got == receipt {
+		t.Error("Expected the revision to change when the category is set")
+	}
+	if got := rev.Revision(b); got != 0 {
+		t.Errorf("Expected the counterparty without records to stay at 0, got %d", got)
+	}
+
+	// Storing again after a purge never reuses an earlier revision.
+	before := rev.Revision(a)
+	store.(Purger).Purge(a)
+	store.AddTransaction(a, tx)
+	if got := rev.Revision(a); got <= before {
+		t.Errorf("Expected a revision past %d after the purge, got %d", before, got)
+	}
+}
+
+func TestMemoryStorage_MaxTransactionsPerAddress(t *testing.T) {
+	store := NewMemoryStorageWithOptions(MemoryOptions{MaxTransactionsPerAddress: 2})
+	const addr = "0xaaa"
+	store.Subscribe(addr)
+	add := func(hash string, block int) {
+		store.AddTransaction(addr, transaction.Transaction{Hash: hash, From: "0xother", To: addr, Block: block, Value: transaction.WeiValue(1), Direction: transaction.DirectionIn})
+	}
+	add("0x10", 10)
+	add("0x11", 11)
+	truncator := store.(Truncator)
+	if _, truncated := truncator.Truncation(addr); truncated {
+		t.Error("Expected no truncation within the cap")
+	}
+	before := store.GetTransactions(addr)
+
+	add("0x12", 12)
+	got := store.GetTransactions(addr)
+	if len(got) != 2 || got[0].Hash != "0x11" || got[1].Hash != "0x12" {
+		t.Errorf("Expected the oldest transaction to be dropped, got %+v", got)
+	}
+	if earliest, truncated := truncator.Truncation(addr); !truncated || earliest != 11 {
+		t.Errorf("Expected truncation with earliest block 11, got %d %v", earliest, truncated)
+	}
+	if len(before) != 2 || before[0].Hash != "0x10" {
+		t.Errorf("Expected earlier reads not to change, got %+v", before)
+	}
+	if _, ok := store.GetTransactionByHash("0x10"); ok {
+		t.Error("Expected the dropped transaction to be gone from hash lookups")
+	}
+
+	// An older transaction found by a backward scan doesn't displace newer
+	// ones, nor does it come back on a rescan once dropped.
+	add("0x05", 5)
+	add("0x10", 10)
+	if got := store.GetTransactions(addr); len(got) != 2 || got[0].Hash != "0x11" {
+		t.Errorf("Expected older transactions to be dropped, got %+v", got)
+	}
+	// Totals still account for the dropped transaction, but not for the
+	// older ones, which were never stored.
+	if n := store.(Aggregator).Totals(addr).Transactions; n != 3 {
+		t.Errorf("Expected totals of 3 transactions, got %d", n)
+	}
+
+	store.(Purger).Purge(addr)
+	if _, truncated := truncator.Truncation(addr); truncated {
+		t.Error("Expected purging to clear the truncation")
+	}
+}
+
+func TestMemoryStorage_MaxTransactionsPerAddress_Batches(t *testing.T) {
+	store := NewMemoryStorageWithOptions(MemoryOptions{MaxTransactionsPerAddress: 100}).(*MemoryStorage)
+	const addr = "0xaaa"
+	store.Subscribe(addr)
+	for block := 1; block <= 101; block++ {
+		store.AddTransaction(addr, transaction.Transaction{Hash: fmt.Sprintf("0x%d", block), From: "0xother", To: addr, Block: block, Direction: transaction.DirectionIn})
+	}
+	if got := store.GetTransactions(addr); len(got) != 90 || got[0].Block != 12 {
+		t.Errorf("Expected the 90 newest transactions to be kept, got %d from block %d", len(got), got[0].Block)
+	}
+	if earliest, _ := store.Truncation(addr); earliest != 12 {
+		t.Errorf("Expected earliest block 12, got %d", earliest)
+	}
+	if len(store.seen) != 90 {
+		t.Errorf("Expected the keys of dropped transactions to be released, got %d keys", len(store.seen))
+	}
+
+	// No more are dropped until the cap is reached again.
+	store.AddTransaction(addr, transaction.Transaction{Hash: "0x102", From: "0xother", To: addr, Block: 102, Direction: transaction.DirectionIn})
+	if got := store.GetTransactions(addr); len(got) != 91 {
+		t.Errorf("Expected 91 transactions, got %d", len(got))
+	}
+}
+
+func TestMemoryStorage_Purge(t *testing.T) {
+	store := NewMemoryStorage()
+	a, b := "0xaaa", "0xbbb"
+	store.Subscribe(a)
+	store.Subscribe(b)
+	shared := transaction.Transaction{Hash: "0xshared", From: a, To: b, Direction: transaction.DirectionOut}
+	own := transaction.Transaction{Hash: "0xown", From: "0xother", To: a, Direction: transaction.DirectionIn}
+	store.AddTransaction(a, shared)
+	store.AddTransaction(a, own)
+	sharedIn := shared
+	sharedIn.Direction = transaction.DirectionIn
+	store.AddTransaction(b, sharedIn)
+	store.AddTokenTransfer(a, transaction.TokenTransfer{Hash: "0xtoken", To: a, Direction: transaction.DirectionIn})
+
+	if n := store.(Purger).Purge(a); n != 3 {
+		t.Errorf("Expected 3 records purged, got %d", n)
+	}
+	if txs := store.GetTransactions(a); len(txs) != 0 {
+		t.Errorf("Expected no transactions after purge, got %d", len(txs))
+	}
+	if tts := store.GetTokenTransfers(a); len(tts) != 0 {
+		t.Errorf("Expected no token transfers after purge, got %d", len(tts))
+	}
+	if _, ok := store.GetTransactionByHash("0xown"); ok {
+		t.Error("Expected the purged transaction to be gone")
+	}
+	if _, ok := store.GetTransactionByHash("0xshared"); !ok {
+		t.Error("Expected a transaction still stored for another address to be kept")
+	}
+
+	// Purged transactions are stored again if they are seen again.
+	store.AddTransaction(a, own)
+	if txs := store.GetTransactions(a); len(txs) != 1 {
+		t.Errorf("Expected the transaction to be stored again, got %d", len(txs))
+	}
+}
+
+func TestMemoryStorage_Compact(t *testing.T) {
+	store := NewMemoryStorage()
+	addr := "0xaaa"
+	store.Subscribe(addr)
+	// A backward scan appends older blocks after newer ones.
+	for _, block := range []int{30, 10, 20} {
+		store.AddTransaction(addr, transaction.Transaction{Hash: fmt.Sprintf("0x%d", block), Block: block, Direction: transaction.DirectionIn})
+	}
+	m := store.(*MemoryStorage)
+	m.txs[addr] = append(m.txs[addr], m.txs[addr][0])
+	before := store.GetTransactions(addr)
+
+	stats := store.(Compactor).Compact()
+	if stats.Lists != 1 || stats.Duplicates != 1 || stats.ReclaimedBytes <= 0 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	txs := store.GetTransactions(addr)
+	if len(txs) != 3 || txs[0].Block != 10 || txs[1].Block != 20 || txs[2].Block != 30 || cap(txs) != 3 {
+		t.Errorf("Expected 3 transactions in block order without spare capacity, got %+v (cap %d)", txs, cap(txs))
+	}
+	if len(before) != 4 || before[0].Block != 30 {
+		t.Errorf("Expected earlier reads to be left intact, got %+v", before)
+	}
+
+	if stats := store.(Compactor).Compact(); stats != (CompactionStats{}) {
+		t.Errorf("Expected nothing left to compact, got %+v", stats)
+	}
+}
+
+func TestMemoryStorage_CollectGarbage(t *testing.T) {
+	store := NewMemoryStorage()
+	m := store.(*MemoryStorage)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	tx := transaction.Transaction{Hash: "0xold", From: "0xstale", To: "0xsub", Block: 1}
+	out, in := tx, tx
+	out.Direction, in.Direction = transaction.DirectionOut, transaction.DirectionIn
+	store.AddTransaction("0xstale", out)
+	store.AddTransaction("0xsub", in)
+	store.AddTokenTransfer("0xformer", transaction.TokenTransfer{Hash: "0xtoken", To: "0xformer", Direction: transaction.DirectionIn})
+	store.Subscribe("0xsub")
+	store.Subscribe("0xformer")
+	store.Unsubscribe("0xformer")
+	now = now.Add(2 * time.Hour)
+	store.AddTransaction("0xfresh", transaction.Transaction{Hash: "0xnew", From: "0xfresh", Block: 2, Direction: transaction.DirectionOut})
+
+	addrs, records := store.(Collector).CollectGarbage(time.Hour)
+	if addrs != 1 || records != 1 {
+		t.Errorf("Expected only the idle, never subscribed address to be collected, got %d addresses and %d records", addrs, records)
+	}
+	if _, ok := m.txs["0xstale"]; ok {
+		t.Error("Expected the stale address to be dropped")
+	}
+	if _, ok := store.GetTransactionByHash("0xold"); !ok {
+		t.Error("Expected a transaction still stored for a subscribed address to be kept")
+	}
+	store.Subscribe("0xfresh")
+	if txs := store.GetTransactions("0xfresh"); len(txs) != 1 {
+		t.Errorf("Expected recent backfill to be kept, got %d transactions", len(txs))
+	}
+	if _, ok := m.tokens["0xformer"]; !ok {
+		t.Error("Expected an address that was subscribed before to be kept")
+	}
+}
+
+func TestMemoryStorage_Totals(t *testing.T) {
+	store := NewMemoryStorage()
+	agg := store.(Aggregator)
+	addr := "0xaaa"
+	in := transaction.Transaction{Hash: "0x1", To: addr, Value: transaction.WeiValue(100), Direction: transaction.DirectionIn}
+	out := transaction.Transaction{Hash: "0x2", From: addr, Value: transaction.WeiValue(40), Direction: transaction.DirectionOut}
+	store.AddTransaction(addr, in)
+	store.AddTransaction(addr, in) // duplicates aren't counted twice
+	store.AddTransaction(addr, out)
+	tt := transaction.TokenTransfer{Hash: "0x3", Contract: "0xtoken", Amount: transaction.WeiValue(7), Direction: transaction.DirectionIn}
+	store.AddTokenTransfer(addr, tt)
+	store.AddTokenTransfer(addr, tt)
+
+	if totals := agg.Totals(addr); totals.Transactions != 0 || totals.Tokens == nil {
+		t.Errorf("Expected empty totals for an unsubscribed address, got %+v", totals)
+	}
+	store.Subscribe(addr)
+	totals := agg.Totals(addr)
+	if totals.Transactions != 2 || totals.In.String() != "100" || totals.Out.String() != "40" {
+		t.Errorf("Unexpected native totals %+v", totals)
+	}
+	if len(totals.Tokens) != 1 || totals.Tokens[0].Transfers != 1 || totals.Tokens[0].In.String() != "7" {
+		t.Errorf("Unexpected token totals %+v", totals.Tokens)
+	}
+
+	store.(Purger).Purge(addr)
+	if totals := agg.Totals(addr); totals.Transactions != 0 || len(totals.Tokens) != 0 {
+		t.Errorf("Expected purge to reset the totals, got %+v", totals)
+	}
+}
+
+func TestMemoryStorage_BlockTimes(t *testing.T) {
+	times := NewMemoryStorage().(BlockTimes)
+	if _, ok := times.BlockTime(1234); ok {
+		t.Error("Expected no time for an unprocessed block")
+	}
+	mined := time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)
+	times.SetBlockTime(1234, mined)
+	if got, ok := times.BlockTime(1234); !ok || !got.Equal(mined) {
+		t.Errorf("Expected %v, got %v %v", mined, got, ok)
+	}
+}
+
+func TestMemoryStorage_TransactionsInRange(t *testing.T) {
+	store := NewMemoryStorage()
+	a, b, unsubscribed := "0xaaa", "0xbbb", "0xccc"
+	store.Subscribe(a)
+	store.Subscribe(b)
+	for _, block := range []int{12, 10, 11, 20} {
+		store.AddTransaction(a, transaction.Transaction{Hash: fmt.Sprintf("0xa%d", block), Block: block, Direction: transaction.DirectionIn})
+	}
+	store.AddTransaction(b, transaction.Transaction{Hash: "0xb30", Block: 30, Direction: transaction.DirectionIn})
+	store.AddTransaction(unsubscribed, transaction.Transaction{Hash: "0xc11", Block: 11, Direction: transaction.DirectionIn})
+
+	got := store.(RangeReader).TransactionsInRange(10, 12)
+	if len(got) != 1 {
+		t.Fatalf("Expected only %s to have transactions in range, got %v", a, got)
+	}
+	var blocks []int
+	for _, tx := range got[a] {
+		blocks = append(blocks, tx.Block)
+	}
+	if fmt.Sprint(blocks) != "[10 11 12]" {
+		t.Errorf("Expected blocks [10 11 12] in order, got %v", blocks)
+	}
+}
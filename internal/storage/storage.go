@@ -1,16 +1,212 @@
 // Package storage defines the storage interfaces.
 package storage
 
-import "github.com/danieloluwadare/tw-txparser/pkg/transaction"
+import (
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
 
 // Storage abstracts subscriptions and per-address transactions.
 type Storage interface {
 	// Subscribe registers an address and returns false if it already existed.
 	Subscribe(address string) bool
-	// AddTransaction appends a transaction for the given address.
+	// Unsubscribe removes an address and returns false if it wasn't registered.
+	// Stored transactions are kept.
+	Unsubscribe(address string) bool
+	// AddTransaction appends a transaction for the given address, unless
+	// one with the same idempotency key, see
+	// transaction.Transaction.IdempotencyKey, is already stored. Records
+	// are delivered at least once, so this is what keeps them unique.
 	AddTransaction(addr string, tx transaction.Transaction)
 	// GetTransactions returns transactions associated with address.
 	GetTransactions(address string) []transaction.Transaction
+	// GetTransactionByHash looks up a stored transaction by its hash.
+	GetTransactionByHash(hash string) (transaction.Transaction, bool)
 	// IsSubscribed indicates whether address is registered.
 	IsSubscribed(addr string) bool
+	// AddTokenTransfer appends a token transfer for the given address,
+	// unless one with the same idempotency key is already stored.
+	AddTokenTransfer(addr string, tt transaction.TokenTransfer)
+	// GetTokenTransfers returns token transfers associated with address.
+	GetTokenTransfers(address string) []transaction.TokenTransfer
+}
+
+// Flusher is implemented by storages that buffer writes. Flush is called
+// during shutdown, after the poller has stopped, to persist pending data.
+type Flusher interface {
+	Flush() error
+}
+
+// Purger is implemented by storages that can delete the data of an address,
+// e.g. when its subscription expires.
+type Purger interface {
+	// Purge deletes the transactions and token transfers stored for address
+	// and returns the number of records removed.
+	Purge(address string) int
+}
+
+// Collector is implemented by storages that can drop the data of addresses
+// nobody subscribed to, bounding the cost of storing every address.
+type Collector interface {
+	// CollectGarbage deletes the records of addresses that were never
+	// subscribed and had nothing stored for longer than idle, and returns
+	// the number of addresses and records removed.
+	CollectGarbage(idle time.Duration) (addresses, records int)
+}
+
+// Truncator is implemented by storages that cap the transactions kept per
+// address, dropping those of the oldest blocks.
+type Truncator interface {
+	// Truncation reports whether transactions of address were dropped to
+	// stay within the cap and, if so, the earliest block still retained.
+	Truncation(address string) (earliestBlock int, truncated bool)
+}
+
+// Reverter is implemented by storages that can remove the records of a
+// block orphaned by a chain reorganization.
+type Reverter interface {
+	// RevertBlock removes the transactions and token transfers stored for
+	// address from block, adjusting its totals, and returns the
+	// transactions removed.
+	RevertBlock(address string, block int) []transaction.Transaction
+}
+
+// Revisioner is implemented by storages that track when the transactions
+// of an address change, e.g. so that clients can tell whether their copy is
+// current.
+type Revisioner interface {
+	// Revision returns a value that changes whenever a transaction of
+	// address is added or updated in place, e.g. with its receipt or
+	// category, and 0 if none was ever stored.
+	Revision(address string) uint64
+}
+
+// Aggregator is implemented by storages that keep running value totals per
+// address as records are added.
+type Aggregator interface {
+	// Totals returns the totals of the records stored for address, or zero
+	// totals if it isn't subscribed.
+	Totals(address string) transaction.Totals
+}
+
+// BlockTimes is implemented by storages that index the timestamps of
+// processed blocks, so stored records can be queried by time.
+type BlockTimes interface {
+	// SetBlockTime records when block was mined.
+	SetBlockTime(block int, t time.Time)
+	// BlockTime returns when block was mined, if it was recorded.
+	BlockTime(block int) (time.Time, bool)
+}
+
+// RangeReader is implemented by storages that can list the transactions of
+// all subscribed addresses within a block range.
+type RangeReader interface {
+	// TransactionsInRange returns the transactions stored for subscribed
+	// addresses in blocks from through to, by address and ordered by block.
+	// Addresses without transactions in the range are left out.
+	TransactionsInRange(from, to int) map[string][]transaction.Transaction
+}
+
+// Lister is implemented by storages that can list subscribed addresses.
+type Lister interface {
+	// Subscriptions returns the subscribed addresses in sorted order.
+	Subscriptions() []string
+}
+
+// Counter is implemented by storages that can count the records of an
+// address without reading them.
+type Counter interface {
+	// Counts returns the number of transactions and token transfers stored
+	// for addr.
+	Counts(addr string) (txs, tokens int)
+}
+
+// AllowanceStore is implemented by storages that keep the token allowances
+// granted by addresses.
+type AllowanceStore interface {
+	// SetAllowance records a for its owner, replacing the allowance of the
+	// same token and spender unless that was set by a later event. A zero
+	// amount revokes the allowance.
+	SetAllowance(a transaction.Allowance)
+	// Allowances returns the allowances granted by owner, ordered by token
+	// and spender, or none if it isn't subscribed.
+	Allowances(owner string) []transaction.Allowance
+}
+
+// EventStore is implemented by storages that keep contract event
+// subscriptions and the logs matching them.
+type EventStore interface {
+	// SubscribeEvents registers sub and returns false if it already
+	// existed.
+	SubscribeEvents(sub transaction.EventSubscription) bool
+	// UnsubscribeEvents removes sub and returns false if it wasn't
+	// registered. Stored logs are kept.
+	UnsubscribeEvents(sub transaction.EventSubscription) bool
+	// EventSubscriptions returns the registered subscriptions ordered by
+	// contract and topic0.
+	EventSubscriptions() []transaction.EventSubscription
+	// AddLog appends a log for the subscription it matches.
+	AddLog(l transaction.Log)
+	// GetLogs returns the logs stored for sub, or none if it isn't
+	// registered.
+	GetLogs(sub transaction.EventSubscription) []transaction.Log
+}
+
+// ContractWatchStore is implemented by storages that keep contract watches
+// and the calls made to watched contracts.
+type ContractWatchStore interface {
+	// WatchContract registers contract and returns false if it was already
+	// watched.
+	WatchContract(contract string) bool
+	// UnwatchContract removes contract and returns false if it wasn't
+	// watched. Stored calls are kept.
+	UnwatchContract(contract string) bool
+	// WatchedContracts returns the watched contracts in sorted order.
+	WatchedContracts() []string
+	// IsWatched reports whether contract is watched.
+	IsWatched(contract string) bool
+	// AddContractCall appends tx, a transaction sent to contract.
+	AddContractCall(contract string, tx transaction.Transaction)
+	// GetContractCalls returns the calls stored for contract, or none if
+	// it isn't watched.
+	GetContractCalls(contract string) []transaction.Transaction
+}
+
+// ReceiptStore is implemented by storages that can fill in the receipt
+// fields of transactions after they were stored.
+type ReceiptStore interface {
+	// SetReceipt sets the status, gas used and fee of every record of the
+	// transaction hash, including contract watch calls, and returns the
+	// number of records updated.
+	SetReceipt(hash string, r transaction.Receipt) int
+}
+
+// CategoryStore is implemented by storages that can change the category of
+// transactions after they were stored, e.g. once their token transfers are
+// indexed.
+type CategoryStore interface {
+	// SetCategory sets the category of every record of the transaction
+	// hash, including contract watch calls, and returns the number of
+	// records updated.
+	SetCategory(hash string, c transaction.Category) int
+}
+
+// Compactor is implemented by storages whose record lists accumulate slack
+// as they grow, and can be compacted in the background.
+type Compactor interface {
+	// Compact deduplicates the records stored per address, sorts them by
+	// block and trims spare capacity, and reports what it reclaimed.
+	Compact() CompactionStats
+}
+
+// CompactionStats reports the outcome of a compaction pass.
+type CompactionStats struct {
+	// Lists is the number of record lists rewritten.
+	Lists int `json:"lists"`
+	// Duplicates is the number of duplicate records removed.
+	Duplicates int `json:"duplicates"`
+	// ReclaimedBytes estimates the memory freed from the lists' backing
+	// arrays, not counting what their records point to.
+	ReclaimedBytes int64 `json:"reclaimed_bytes"`
 }
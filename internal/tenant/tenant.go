@@ -0,0 +1,171 @@
+// Package tenant scopes subscriptions to the API keys that created them, so
+// that several teams can share one deployment without seeing each other's
+// addresses.
+package tenant
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Registry authenticates API keys and tracks which tenants own which
+// subscribed addresses, event subscriptions and contract watches, the
+// latter two under the keys returned by EventKey and ContractKey. Each stays
+// in the parser while any tenant owns it or it is pinned. It is safe for
+// concurrent use.
+type Registry struct {
+	// keys maps the SHA-256 of each API key to its tenant, so lookups don't
+	// compare secrets byte by byte.
+	keys map[[sha256.Size]byte]string
+
+	mu     sync.Mutex
+	owners map[string]map[string]bool // address -> tenants
+	pinned map[string]bool
+}
+
+// New creates a Registry from API keys by tenant name. Names and keys must
+// be non-empty and keys unique.
+func New(keys map[string]string) (*Registry, error) {
+	r := &Registry{
+		keys:   make(map[[sha256.Size]byte]string, len(keys)),
+		owners: make(map[string]map[string]bool),
+		pinned: make(map[string]bool),
+	}
+	for name, key := range keys {
+		if name == "" || key == "" {
+			return nil, errors.New("tenant: API keys need a tenant name and a key")
+		}
+		sum := sha256.Sum256([]byte(key))
+		if other, ok := r.keys[sum]; ok {
+			return nil, fmt.Errorf("tenant: %s and %s share an API key", other, name)
+		}
+		r.keys[sum] = name
+	}
+	return r, nil
+}
+
+// ParseKeys parses comma-separated tenant:key pairs, e.g.
+// "payments:k1,risk:k2", as accepted by New.
+func ParseKeys(s string) (map[string]string, error) {
+	keys := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		name, key, ok := strings.Cut(pair, ":")
+		name, key = strings.TrimSpace(name), strings.TrimSpace(key)
+		if !ok || name == "" || key == "" {
+			return nil, fmt.Errorf("tenant: invalid API key entry %q: expected tenant:key", redact(pair))
+		}
+		if _, dup := keys[name]; dup {
+			return nil, fmt.Errorf("tenant: duplicate tenant %s", name)
+		}
+		keys[name] = key
+	}
+	return keys, nil
+}
+
+// redact hides the key part of an API key entry for error messages.
+func redact(pair string) string {
+	if name, _, ok := strings.Cut(pair, ":"); ok {
+		return name + ":***"
+	}
+	return "***"
+}
+
+// Authenticate returns the tenant owning key.
+func (r *Registry) Authenticate(key string) (string, bool) {
+	name, ok := r.keys[sha256.Sum256([]byte(key))]
+	return name, ok
+}
+
+// Tenants returns the configured tenant names in sorted order.
+func (r *Registry) Tenants() []string {
+	names := make([]string, 0, len(r.keys))
+	for _, name := range r.keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Claim records that tenant owns addr and reports whether it didn't
+// already.
+func (r *Registry) Claim(tenant, addr string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	owners := r.owners[addr]
+	if owners[tenant] {
+		return false
+	}
+	if owners == nil {
+		owners = make(map[string]bool)
+		r.owners[addr] = owners
+	}
+	owners[tenant] = true
+	return true
+}
+
+// Release drops tenant's ownership of addr. released reports whether tenant
+// owned it; orphaned whether the address is now neither owned nor pinned,
+// in which case it should be unsubscribed.
+func (r *Registry) Release(tenant, addr string) (released, orphaned bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	owners := r.owners[addr]
+	if !owners[tenant] {
+		return false, false
+	}
+	delete(owners, tenant)
+	if len(owners) > 0 {
+		return true, false
+	}
+	delete(r.owners, addr)
+	return true, !r.pinned[addr]
+}
+
+// Owns reports whether tenant owns addr.
+func (r *Registry) Owns(tenant, addr string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.owners[addr][tenant]
+}
+
+// EventKey returns the key under which tenants own the event subscription
+// of contract and topic0.
+func EventKey(contract, topic0 string) string {
+	return "event:" + contract + ":" + topic0
+}
+
+// ContractKey returns the key under which tenants own the watch of contract.
+func ContractKey(contract string) string {
+	return "contract:" + contract
+}
+
+// Owned returns the addresses tenant owns in sorted order, leaving out its
+// event subscriptions and contract watches.
+func (r *Registry) Owned(tenant string) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []string
+	for addr, owners := range r.owners {
+		if owners[tenant] && !strings.Contains(addr, ":") {
+			out = append(out, addr)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Pin keeps addr subscribed when its last tenant releases it. It is used
+// for addresses subscribed by the operator, such as those of configured
+// sinks and webhooks.
+func (r *Registry) Pin(addr string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pinned[addr] = true
+}
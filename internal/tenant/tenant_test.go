@@ -0,0 +1,117 @@
+package tenant
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNew_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		keys map[string]string
+	}{
+		{name: "empty key", keys: map[string]string{"payments": ""}},
+		{name: "empty name", keys: map[string]string{"": "secret"}},
+		{name: "shared key", keys: map[string]string{"payments": "secret", "risk": "secret"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.keys); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestRegistry_Authenticate(t *testing.T) {
+	r, err := New(map[string]string{"payments": "pk", "risk": "rk"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, ok := r.Authenticate("rk"); !ok || name != "risk" {
+		t.Errorf("Expected risk, got %q %v", name, ok)
+	}
+	if _, ok := r.Authenticate("nope"); ok {
+		t.Error("Expected an unknown key to be rejected")
+	}
+	if got := r.Tenants(); !reflect.DeepEqual(got, []string{"payments", "risk"}) {
+		t.Errorf("Unexpected tenants %v", got)
+	}
+}
+
+func TestRegistry_Ownership(t *testing.T) {
+	r, _ := New(map[string]string{"payments": "pk", "risk": "rk"})
+	const addr = "0xaaa"
+
+	if !r.Claim("payments", addr) || r.Claim("payments", addr) {
+		t.Fatal("Expected only the first claim to succeed")
+	}
+	r.Claim("risk", addr)
+	r.Claim("risk", "0x000")
+	if !r.Owns("risk", addr) || r.Owns("ops", addr) {
+		t.Error("Unexpected ownership")
+	}
+	if got := r.Owned("risk"); !reflect.DeepEqual(got, []string{"0x000", addr}) {
+		t.Errorf("Expected risk to own [0x000 %s], got %v", addr, got)
+	}
+	if got := r.Owned("ops"); len(got) != 0 {
+		t.Errorf("Expected ops to own nothing, got %v", got)
+	}
+
+	if released, orphaned := r.Release("ops", addr); released || orphaned {
+		t.Error("Expected releasing an unowned address to be a no-op")
+	}
+	if released, orphaned := r.Release("payments", addr); !released || orphaned {
+		t.Errorf("Expected the address to stay owned by risk, got %v %v", released, orphaned)
+	}
+	if r.Owns("payments", addr) {
+		t.Error("Expected payments to lose ownership")
+	}
+	if released, orphaned := r.Release("risk", addr); !released || !orphaned {
+		t.Errorf("Expected the last release to orphan the address, got %v %v", released, orphaned)
+	}
+
+	// Event subscriptions and contract watches are owned alongside
+	// addresses but not listed with them.
+	r.Claim("risk", EventKey("0xccc", "0xddf2"))
+	r.Claim("risk", ContractKey("0xccc"))
+	if !r.Owns("risk", ContractKey("0xccc")) || r.Owns("risk", ContractKey("0xddd")) {
+		t.Error("Unexpected contract watch ownership")
+	}
+	if got := r.Owned("risk"); !reflect.DeepEqual(got, []string{"0x000"}) {
+		t.Errorf("Expected risk to own [0x000], got %v", got)
+	}
+
+	// Pinned addresses are never orphaned.
+	r.Pin(addr)
+	r.Claim("risk", addr)
+	if _, orphaned := r.Release("risk", addr); orphaned {
+		t.Error("Expected a pinned address not to be orphaned")
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]string
+		wantErr  bool
+	}{
+		{name: "pairs", input: " payments:k1, risk : k2 ,", expected: map[string]string{"payments": "k1", "risk": "k2"}},
+		{name: "empty", input: "", expected: map[string]string{}},
+		{name: "missing key", input: "payments:", wantErr: true},
+		{name: "missing separator", input: "secret", wantErr: true},
+		{name: "duplicate tenant", input: "payments:k1,payments:k2", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseKeys(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+// Package tracing configures OpenTelemetry tracing. Instrumented packages use
+// the global tracer provider, so spans are free no-ops until Setup installs an
+// exporter.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Options configures trace export.
+type Options struct {
+	// Endpoint is the OTLP/HTTP collector URL, e.g. http://localhost:4318.
+	// Spans are not exported when empty.
+	Endpoint string
+	// ServiceName and ServiceVersion identify this process in traces.
+	ServiceName    string
+	ServiceVersion string
+	// SampleRatio is the fraction of new traces recorded, 0 to 1. Traces
+	// started by a caller follow the caller's sampling decision.
+	SampleRatio float64
+}
+
+// Setup installs the W3C trace-context propagator and, when an endpoint is
+// configured, a tracer provider exporting spans over OTLP/HTTP. The returned
+// function flushes pending spans and must be called on shutdown.
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if opts.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(opts.Endpoint))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+	res := resource.NewSchemaless(
+		attribute.String("service.name", opts.ServiceName),
+		attribute.String("service.version", opts.ServiceVersion),
+	)
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
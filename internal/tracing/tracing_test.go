@@ -0,0 +1,37 @@
+package tracing
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetup_WithoutEndpoint(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Options{ServiceName: "txparser"})
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown failed: %v", err)
+	}
+	// The composite propagator doesn't return its fields in a fixed order.
+	fields := otel.GetTextMapPropagator().Fields()
+	for _, want := range []string{"traceparent", "tracestate", "baggage"} {
+		if !slices.Contains(fields, want) {
+			t.Errorf("Expected the %s field to be propagated, got fields %v", want, fields)
+		}
+	}
+}
+
+func TestSetup_WithEndpoint(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Options{Endpoint: "http://localhost:4318", ServiceName: "txparser", SampleRatio: 1})
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	// Nothing was recorded, so shutting down doesn't contact the collector.
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown failed: %v", err)
+	}
+}
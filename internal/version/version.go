@@ -0,0 +1,63 @@
+// Package version reports build metadata for the running binary.
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Build metadata, overridable at link time:
+//
+//	go build -ldflags "-X github.com/danieloluwadare/tw-txparser/internal/version.Version=v1.2.3 \
+//	  -X github.com/danieloluwadare/tw-txparser/internal/version.Commit=$(git rev-parse HEAD) \
+//	  -X github.com/danieloluwadare/tw-txparser/internal/version.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// Info describes the running build.
+type Info struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	// CommitTime is when Commit was made, from the VCS metadata embedded by
+	// the Go toolchain.
+	CommitTime string `json:"commit_time,omitempty"`
+	// BuildTime is only known when set via ldflags.
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+	Modified  bool   `json:"modified,omitempty"` // working tree had uncommitted changes
+}
+
+// Get returns the build info, falling back to the VCS metadata embedded by
+// the Go toolchain when the version and commit were not set via ldflags.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	if info.Version == "dev" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		info.Version = bi.Main.Version
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = s.Value
+			}
+		case "vcs.time":
+			info.CommitTime = s.Value
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		}
+	}
+	return info
+}
@@ -0,0 +1,37 @@
+package version
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestGet_LinkerOverrides(t *testing.T) {
+	origVersion, origCommit, origBuildTime := Version, Commit, BuildTime
+	defer func() { Version, Commit, BuildTime = origVersion, origCommit, origBuildTime }()
+
+	Version, Commit, BuildTime = "v1.2.3", "abc123", "2024-01-01T00:00:00Z"
+	info := Get()
+
+	if info.Version != "v1.2.3" {
+		t.Errorf("Expected version v1.2.3, got %s", info.Version)
+	}
+	if info.Commit != "abc123" {
+		t.Errorf("Expected commit abc123, got %s", info.Commit)
+	}
+	if info.BuildTime != "2024-01-01T00:00:00Z" {
+		t.Errorf("Expected build time override, got %s", info.BuildTime)
+	}
+	if info.GoVersion != runtime.Version() {
+		t.Errorf("Expected go version %s, got %s", runtime.Version(), info.GoVersion)
+	}
+}
+
+func TestGet_BuildTimeNotFromCommit(t *testing.T) {
+	origBuildTime := BuildTime
+	defer func() { BuildTime = origBuildTime }()
+
+	BuildTime = ""
+	if info := Get(); info.BuildTime != "" {
+		t.Errorf("Expected no build time without an ldflags override, got %s", info.BuildTime)
+	}
+}
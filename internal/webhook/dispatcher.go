@@ -0,0 +1,369 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/internal/outbox"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// Headers set on every delivery.
+const (
+	// HeaderDelivery carries a unique ID per delivery, stable across retries.
+	HeaderDelivery = "X-Txparser-Delivery"
+	// HeaderIdempotencyKey carries the event's idempotency key, stable across
+	// redeliveries after restarts and from other instances too.
+	HeaderIdempotencyKey = "X-Txparser-Idempotency-Key"
+	// HeaderTimestamp carries the Unix time (seconds) the attempt was signed at.
+	HeaderTimestamp = "X-Txparser-Timestamp"
+	// HeaderSignature carries "sha256=<hex>", see Sign.
+	HeaderSignature = "X-Txparser-Signature"
+)
+
+// Payload is the JSON body POSTed to webhook URLs. Removed and Reorg are
+// set when a chain reorganization removed the transaction.
+type Payload struct {
+	WebhookID   string                  `json:"webhook_id"`
+	Address     string                  `json:"address"`
+	Transaction transaction.Transaction `json:"transaction"`
+	Removed     bool                    `json:"removed,omitempty"`
+	Reorg       *parser.Reorg           `json:"reorg,omitempty"`
+}
+
+// Sign returns the signature header value for a delivery: the hex-encoded
+// HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret, prefixed
+// with "sha256=". Receivers recompute it to authenticate deliveries and
+// should reject stale timestamps to prevent replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// DispatcherOptions configures delivery behavior. Zero values select defaults.
+type DispatcherOptions struct {
+	// QueueSize bounds pending deliveries; events beyond it are dropped,
+	// unless an outbox is used. Defaults to 1024.
+	QueueSize int
+	// Workers is the number of concurrent deliveries. Defaults to 4.
+	Workers int
+	// MaxAttempts is the number of tries per delivery, including the first. Defaults to 5.
+	MaxAttempts int
+	// BaseBackoff is the delay before the first retry; it doubles per attempt. Defaults to 1s.
+	BaseBackoff time.Duration
+	// MaxBackoff caps the delay between retries. Defaults to 1m.
+	MaxBackoff time.Duration
+	// Targets restricts the addresses deliveries may connect to; nil
+	// refuses loopback, private and link-local ones, see Targets.
+	Targets *Targets
+	// HTTPClient performs deliveries. Defaults to a client with a 10s
+	// timeout connecting only to addresses Targets allows; a client given
+	// here isn't restricted.
+	HTTPClient *http.Client
+	// Outbox records each delivery until it succeeds, fails permanently or
+	// runs out of attempts, so that deliveries interrupted by a shutdown or
+	// crash are resumed by the next Run. Deliveries are then never dropped:
+	// a full queue holds up new events instead. Disabled by default.
+	Outbox outbox.ChainOutbox
+}
+
+// outboxSinkPrefix prefixes the webhook ID in the sink name of outbox
+// entries.
+const outboxSinkPrefix = "webhook:"
+
+// delivery is a queued event for a single webhook.
+type delivery struct {
+	id    string
+	hook  Webhook
+	event parser.Event
+}
+
+// Dispatcher posts parser events to matching webhooks through a bounded
+// queue, retrying failures with exponential backoff.
+type Dispatcher struct {
+	registry *Registry
+	opts     DispatcherOptions
+	logger   *slog.Logger
+}
+
+// NewDispatcher creates a Dispatcher delivering to hooks in registry.
+func NewDispatcher(registry *Registry) *Dispatcher {
+	return NewDispatcherWithOptions(registry, DispatcherOptions{})
+}
+
+// NewDispatcherWithOptions creates a Dispatcher with custom delivery settings.
+func NewDispatcherWithOptions(registry *Registry, opts DispatcherOptions) *Dispatcher {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 1024
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = time.Minute
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = opts.Targets.HTTPClient(10 * time.Second)
+	}
+	return &Dispatcher{
+		registry: registry,
+		opts:     opts,
+		logger:   logging.Component("webhook"),
+	}
+}
+
+// Run delivers events until ctx is cancelled or events is closed. When events
+// is closed, queued deliveries are finished before Run returns; when ctx is
+// cancelled, pending retries are abandoned. With an outbox, the deliveries
+// left pending by an earlier run are queued first.
+func (d *Dispatcher) Run(ctx context.Context, events <-chan parser.Event) {
+	queue := make(chan delivery, d.opts.QueueSize)
+	var wg sync.WaitGroup
+	for i := 0; i < d.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				d.deliverWithRetry(ctx, job)
+			}
+		}()
+	}
+	defer wg.Wait()
+	defer close(queue)
+
+	if !d.resume(ctx, queue) {
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			if !d.enqueue(ctx, queue, ev) {
+				return
+			}
+		}
+	}
+}
+
+// resume queues the deliveries pending in the outbox, waiting for room in
+// the queue, and reports whether ctx is still live. Deliveries to webhooks
+// that no longer exist are dropped.
+func (d *Dispatcher) resume(ctx context.Context, queue chan<- delivery) bool {
+	pending := d.opts.Outbox.Pending()
+	if len(pending) > 0 {
+		d.logger.Info("resuming deliveries from outbox", "count", len(pending))
+	}
+	for _, e := range pending {
+		id, ok := strings.CutPrefix(e.Sink, outboxSinkPrefix)
+		if !ok {
+			continue
+		}
+		hook, ok := d.registry.resolve(id, e.Target)
+		if !ok {
+			d.logger.Warn("dropping outbox entry of unknown webhook", "webhook_id", id, "delivery_id", e.ID)
+			d.settle(e.ID)
+			continue
+		}
+		select {
+		case queue <- delivery{id: e.ID, hook: hook, event: e.Event}:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// enqueue schedules ev for every matching webhook and reports whether ctx
+// is still live. Without an outbox, hooks whose delivery would not fit in
+// the queue miss ev. With one, the deliveries are recorded first and
+// enqueue waits for room in the queue, so that each is either made or left
+// pending for the next Run; the delivery ID is that of its outbox entry.
+func (d *Dispatcher) enqueue(ctx context.Context, queue chan<- delivery, ev parser.Event) bool {
+	var jobs []delivery
+	for _, hook := range d.registry.Match(ev.Address) {
+		var id string
+		var err error
+		if d.opts.Outbox.Enabled() {
+			id, err = d.opts.Outbox.Add(outboxSinkPrefix+hook.ID, hook.URL, ev)
+		} else {
+			id, err = newID()
+		}
+		if err != nil && id == "" {
+			d.logger.Error("failed to generate delivery ID", logging.KeyError, err)
+			continue
+		} else if err != nil {
+			d.logger.Error("failed to record delivery in outbox", "webhook_id", hook.ID, logging.KeyError, err)
+		}
+		jobs = append(jobs, delivery{id: id, hook: hook, event: ev})
+	}
+	for _, job := range jobs {
+		if d.opts.Outbox.Enabled() {
+			select {
+			case queue <- job:
+			case <-ctx.Done():
+				return false
+			}
+			continue
+		}
+		select {
+		case queue <- job:
+		default:
+			d.registry.recordDrop(job.hook.ID)
+			d.logger.Warn("delivery queue full, dropping event",
+				"webhook_id", job.hook.ID,
+				logging.KeyAddress, ev.Address,
+				logging.KeyBlock, ev.Transaction.Block,
+			)
+		}
+	}
+	return true
+}
+
+// deliverWithRetry attempts job until it succeeds, fails permanently, runs
+// out of attempts, or ctx is cancelled, and records the outcome. A delivery
+// abandoned because ctx was cancelled stays in the outbox.
+func (d *Dispatcher) deliverWithRetry(ctx context.Context, job delivery) {
+	body, err := json.Marshal(Payload{
+		WebhookID:   job.hook.ID,
+		Address:     job.event.Address,
+		Transaction: job.event.Transaction,
+		Removed:     job.event.Removed,
+		Reorg:       job.event.Reorg,
+	})
+	if err != nil {
+		d.settle(job.id)
+		d.registry.recordResult(job.hook.ID, fmt.Errorf("failed to marshal payload: %w", err))
+		return
+	}
+
+	logger := d.logger.With(
+		"webhook_id", job.hook.ID,
+		"delivery_id", job.id,
+		logging.KeyAddress, job.event.Address,
+		logging.KeyBlock, job.event.Transaction.Block,
+	)
+	for attempt := 1; ; attempt++ {
+		err = d.post(ctx, job, body)
+		if err == nil {
+			d.settle(job.id)
+			d.registry.recordResult(job.hook.ID, nil)
+			return
+		}
+		if ctx.Err() != nil {
+			d.registry.recordResult(job.hook.ID, fmt.Errorf("abandoned after %d attempts: %w", attempt, err))
+			return
+		}
+		if attempt >= d.opts.MaxAttempts || !retryable(err) {
+			break
+		}
+		wait := d.backoff(attempt)
+		logger.Warn("delivery failed, retrying", "attempt", attempt, "retry_in", wait, logging.KeyError, err)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			d.registry.recordResult(job.hook.ID, fmt.Errorf("abandoned after %d attempts: %w", attempt, err))
+			return
+		case <-timer.C:
+		}
+	}
+	d.settle(job.id)
+	d.registry.recordResult(job.hook.ID, err)
+	logger.Error("delivery failed", logging.KeyError, err)
+}
+
+// settle removes the delivery id from the outbox, if it is enabled.
+func (d *Dispatcher) settle(id string) {
+	if err := d.opts.Outbox.Done(id); err != nil {
+		d.logger.Error("failed to update outbox", "delivery_id", id, logging.KeyError, err)
+	}
+}
+
+// backoff returns the delay after the given failed attempt.
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	wait := d.opts.BaseBackoff
+	for i := 1; i < attempt && wait < d.opts.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > d.opts.MaxBackoff {
+		wait = d.opts.MaxBackoff
+	}
+	return wait
+}
+
+// statusError reports a non-2xx response from a webhook endpoint.
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status %d", e.code)
+}
+
+// retryable reports whether a failed delivery may succeed on retry. Network
+// errors, 429 and 5xx responses are retried; other statuses and refused
+// targets are permanent.
+func retryable(err error) bool {
+	if errors.Is(err, ErrForbiddenTarget) {
+		return false
+	}
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code == http.StatusTooManyRequests || se.code >= 500
+	}
+	return true
+}
+
+// post makes a single signed delivery attempt.
+func (d *Dispatcher) post(ctx context.Context, job delivery, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDelivery, job.id)
+	req.Header.Set(HeaderIdempotencyKey, job.event.IdempotencyKey())
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(job.hook.Secret, ts, body))
+
+	resp, err := d.opts.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Drain so the connection can be reused.
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &statusError{code: resp.StatusCode}
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+// Package webhook manages registered callback URLs and delivers matched transactions to them.
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when a webhook ID is unknown.
+var ErrNotFound = errors.New("webhook not found")
+
+// Webhook is a registered callback URL. A webhook without addresses receives
+// events for every subscribed address.
+type Webhook struct {
+	ID        string   `json:"id"`
+	URL       string   `json:"url"`
+	Addresses []string `json:"addresses,omitempty"`
+	// Secret is the HMAC-SHA256 key used to sign deliveries. It is only
+	// returned by Register; List and Get omit it.
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	// Callback marks a webhook created by subscribing an address with a
+	// callback URL. It has exactly one address and is replaced when the
+	// address is subscribed again with another URL, and removed when it is
+	// unsubscribed.
+	Callback bool `json:"callback,omitempty"`
+	// Owner is the tenant that registered or subscribed it, if any. Only
+	// its owner can see or delete it.
+	Owner string `json:"owner,omitempty"`
+	// Status summarizes delivery outcomes; populated by List and Get.
+	Status *DeliveryStatus `json:"status,omitempty"`
+}
+
+// DeliveryStatus tracks the outcome of deliveries to a webhook.
+type DeliveryStatus struct {
+	// Delivered counts events acknowledged with a 2xx response.
+	Delivered int64 `json:"delivered"`
+	// Failed counts events that exhausted their attempts or were rejected.
+	Failed int64 `json:"failed"`
+	// Dropped counts events discarded because the delivery queue was full.
+	Dropped       int64      `json:"dropped"`
+	LastAttemptAt *time.Time `json:"last_attempt_at,omitempty"`
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+}
+
+// matches reports whether the webhook wants events for addr.
+func (w Webhook) matches(addr string) bool {
+	if len(w.Addresses) == 0 {
+		return true
+	}
+	for _, a := range w.Addresses {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// Registry is a thread-safe in-memory store of webhooks and their delivery status.
+type Registry struct {
+	mu     sync.RWMutex
+	hooks  map[string]Webhook
+	status map[string]*DeliveryStatus
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		hooks:  make(map[string]Webhook),
+		status: make(map[string]*DeliveryStatus),
+	}
+}
+
+// Register stores a new webhook of owner and returns it with its generated
+// ID. A signing secret is generated when secret is empty.
+func (r *Registry) Register(owner, url, secret string, addresses []string) (Webhook, error) {
+	w, err := newWebhook(url, secret)
+	if err != nil {
+		return Webhook{}, err
+	}
+	w.Addresses = addresses
+	w.Owner = owner
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.add(w)
+	return w, nil
+}
+
+// RegisterCallback stores the callback webhook of addr subscribed by owner,
+// replacing the previous one, if any. A signing secret is generated when
+// secret is empty.
+func (r *Registry) RegisterCallback(url, secret, addr, owner string) (Webhook, error) {
+	w, err := newWebhook(url, secret)
+	if err != nil {
+		return Webhook{}, err
+	}
+	w.Addresses = []string{addr}
+	w.Callback = true
+	w.Owner = owner
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.removeCallback(addr, owner)
+	r.add(w)
+	return w, nil
+}
+
+// RemoveCallback removes the callback webhook of addr subscribed by owner
+// and reports whether there was one.
+func (r *Registry) RemoveCallback(addr, owner string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.removeCallback(addr, owner)
+}
+
+// removeCallback implements RemoveCallback. Callers must hold r.mu.
+func (r *Registry) removeCallback(addr, owner string) bool {
+	for id, w := range r.hooks {
+		if w.Callback && w.Owner == owner && w.Addresses[0] == addr {
+			delete(r.hooks, id)
+			delete(r.status, id)
+			return true
+		}
+	}
+	return false
+}
+
+// add stores w. Callers must hold r.mu.
+func (r *Registry) add(w Webhook) {
+	r.hooks[w.ID] = w
+	r.status[w.ID] = &DeliveryStatus{}
+}
+
+// newWebhook creates an unregistered webhook with a new ID and secret, unless
+// one is given.
+func newWebhook(url, secret string) (Webhook, error) {
+	id, err := newID()
+	if err != nil {
+		return Webhook{}, err
+	}
+	if secret == "" {
+		if secret, err = newID(); err != nil {
+			return Webhook{}, err
+		}
+	}
+	return Webhook{ID: id, URL: url, Secret: secret, CreatedAt: time.Now().UTC()}, nil
+}
+
+// Get returns the webhook id of owner with its delivery status and without
+// its secret.
+func (r *Registry) Get(owner, id string) (Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	w, ok := r.hooks[id]
+	if !ok || w.Owner != owner {
+		return Webhook{}, ErrNotFound
+	}
+	return r.public(w), nil
+}
+
+// List returns the webhooks of owner ordered by creation time, with their
+// delivery status and without their secrets.
+func (r *Registry) List(owner string) []Webhook {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := []Webhook{}
+	for _, w := range r.hooks {
+		if w.Owner == owner {
+			out = append(out, r.public(w))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+// public returns the externally visible form of w. Callers must hold r.mu.
+func (r *Registry) public(w Webhook) Webhook {
+	w.Secret = ""
+	if st, ok := r.status[w.ID]; ok {
+		cp := *st
+		w.Status = &cp
+	}
+	return w
+}
+
+// Delete removes the webhook id of owner.
+func (r *Registry) Delete(owner, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if w, ok := r.hooks[id]; !ok || w.Owner != owner {
+		return ErrNotFound
+	}
+	delete(r.hooks, id)
+	delete(r.status, id)
+	return nil
+}
+
+// recordResult updates a webhook's status after a delivery finished, with err
+// nil on success. Results for deleted webhooks are ignored.
+func (r *Registry) recordResult(id string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st, ok := r.status[id]
+	if !ok {
+		return
+	}
+	now := time.Now().UTC()
+	st.LastAttemptAt = &now
+	if err != nil {
+		st.Failed++
+		st.LastError = err.Error()
+		return
+	}
+	st.Delivered++
+	st.LastSuccessAt = &now
+}
+
+// recordDrop counts an event discarded before any delivery attempt.
+func (r *Registry) recordDrop(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if st, ok := r.status[id]; ok {
+		st.Dropped++
+	}
+}
+
+// Match returns the webhooks interested in addr, including their secrets.
+func (r *Registry) Match(addr string) []Webhook {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []Webhook
+	for _, w := range r.hooks {
+		if w.matches(addr) {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// resolve returns the webhook with id, including its secret, or else the
+// one posting to url, as when a webhook declared in the config file was
+// registered again under a new ID after a restart.
+func (r *Registry) resolve(id, url string) (Webhook, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if w, ok := r.hooks[id]; ok {
+		return w, true
+	}
+	for _, w := range r.hooks {
+		if w.URL == url {
+			return w, true
+		}
+	}
+	return Webhook{}, false
+}
+
+// newID returns a random 16-byte hex identifier.
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,107 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenTarget is returned for webhook URLs, and deliveries, that
+// would reach a loopback, private, link-local or unspecified address.
+var ErrForbiddenTarget = errors.New("webhook target address not allowed")
+
+// Targets decides which addresses webhooks may be delivered to. Loopback,
+// private (RFC 1918 and unique local), link-local (including the
+// 169.254.169.254 metadata service), multicast and unspecified addresses
+// are refused unless they fall within an allowed network, so that
+// registering a webhook can't make the server post to internal services.
+// A nil *Targets refuses them all.
+type Targets struct {
+	allowed []netip.Prefix
+}
+
+// NewTargets creates Targets allowing the given networks, in CIDR notation
+// or as single addresses, e.g. "127.0.0.0/8" for local testing.
+func NewTargets(allowed []string) (*Targets, error) {
+	t := &Targets{}
+	for _, raw := range allowed {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(raw)
+		if err != nil {
+			addr, aerr := netip.ParseAddr(raw)
+			if aerr != nil {
+				return nil, fmt.Errorf("invalid allowed webhook network %q", raw)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		t.allowed = append(t.allowed, prefix.Masked())
+	}
+	return t, nil
+}
+
+// CheckHost resolves host, the host of a webhook URL, and returns
+// ErrForbiddenTarget if any of its addresses is refused. A host that
+// doesn't resolve is let through: deliveries are checked again when they
+// connect, which also defeats DNS rebinding.
+func (t *Targets) CheckHost(ctx context.Context, host string) error {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return t.check(addr)
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if err := t.check(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// check returns ErrForbiddenTarget if addr is refused.
+func (t *Targets) check(addr netip.Addr) error {
+	addr = addr.Unmap()
+	if !addr.IsLoopback() && !addr.IsPrivate() && !addr.IsLinkLocalUnicast() &&
+		!addr.IsLinkLocalMulticast() && !addr.IsMulticast() && !addr.IsUnspecified() {
+		return nil
+	}
+	if t != nil {
+		for _, p := range t.allowed {
+			if p.Contains(addr) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrForbiddenTarget, addr)
+}
+
+// control refuses connections to addresses check refuses. It runs once the
+// address is resolved, right before connecting.
+func (t *Targets) control(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenTarget, address)
+	}
+	return t.check(addrPort.Addr())
+}
+
+// HTTPClient returns a client with the given timeout that only connects to
+// addresses t allows. Proxies are not used, as they would connect on the
+// client's behalf.
+func (t *Targets) HTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: t.control}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
@@ -0,0 +1,456 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/outbox"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// localTargets allows deliveries to the loopback test servers.
+func localTargets(t *testing.T) *Targets {
+	t.Helper()
+	targets, err := NewTargets([]string{"127.0.0.0/8", "::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return targets
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+
+	all, err := r.Register("", "https://example.com/all", "", nil)
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	one, err := r.Register("", "https://example.com/one", "0123456789abcdef", []string{"0xaaa"})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if all.ID == "" || all.ID == one.ID {
+		t.Errorf("Expected unique IDs, got %q and %q", all.ID, one.ID)
+	}
+	if all.Secret == "" || one.Secret != "0123456789abcdef" {
+		t.Errorf("Expected generated and provided secrets, got %q and %q", all.Secret, one.Secret)
+	}
+	for _, w := range r.List("") {
+		if w.Secret != "" {
+			t.Error("Expected List to omit secrets")
+		}
+		if w.Status == nil {
+			t.Error("Expected List to include delivery status")
+		}
+	}
+	if got, err := r.Get("", one.ID); err != nil || got.URL != one.URL || got.Secret != "" {
+		t.Errorf("Unexpected Get result: %+v, %v", got, err)
+	}
+	if _, err := r.Get("", "missing"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound for unknown ID, got %v", err)
+	}
+
+	if got := len(r.List("")); got != 2 {
+		t.Errorf("Expected 2 webhooks, got %d", got)
+	}
+	if got := len(r.Match("0xaaa")); got != 2 {
+		t.Errorf("Expected 2 matches for 0xaaa, got %d", got)
+	}
+	if got := len(r.Match("0xbbb")); got != 1 {
+		t.Errorf("Expected 1 match for 0xbbb, got %d", got)
+	}
+
+	if err := r.Delete("", one.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := r.Delete("", one.ID); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound on second delete, got %v", err)
+	}
+	if got := len(r.List("")); got != 1 {
+		t.Errorf("Expected 1 webhook after delete, got %d", got)
+	}
+
+	// Webhooks are only visible to their owner.
+	owned, _ := r.Register("payments", "https://example.com/owned", "", nil)
+	if got := r.List("payments"); len(got) != 1 || got[0].ID != owned.ID {
+		t.Errorf("Expected only the tenant's webhook, got %+v", got)
+	}
+	if got := len(r.List("")); got != 1 {
+		t.Errorf("Expected other owners' webhooks to be hidden, got %d", got)
+	}
+	if _, err := r.Get("risk", owned.ID); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound for another owner's webhook, got %v", err)
+	}
+	if err := r.Delete("risk", owned.ID); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound deleting another owner's webhook, got %v", err)
+	}
+}
+
+func TestRegistry_Callbacks(t *testing.T) {
+	r := NewRegistry()
+	plain, _ := r.Register("", "https://example.com/plain", "", []string{"0xaaa"})
+	first, err := r.RegisterCallback("https://example.com/v1", "", "0xaaa", "payments")
+	if err != nil {
+		t.Fatalf("RegisterCallback failed: %v", err)
+	}
+	other, _ := r.RegisterCallback("https://example.com/risk", "", "0xaaa", "risk")
+
+	// Subscribing again replaces the tenant's callback only.
+	second, _ := r.RegisterCallback("https://example.com/v2", "", "0xaaa", "payments")
+	if _, err := r.Get("payments", first.ID); err != ErrNotFound {
+		t.Error("Expected the previous callback to be replaced")
+	}
+	for _, w := range []Webhook{plain, other, second} {
+		if _, err := r.Get(w.Owner, w.ID); err != nil {
+			t.Errorf("Expected webhook %s to be kept: %v", w.ID, err)
+		}
+	}
+	if got := len(r.Match("0xaaa")); got != 3 {
+		t.Errorf("Expected 3 matches for 0xaaa, got %d", got)
+	}
+
+	if !r.RemoveCallback("0xaaa", "payments") || r.RemoveCallback("0xaaa", "payments") {
+		t.Error("Expected the callback to be removed once")
+	}
+	if _, err := r.Get("", plain.ID); err != nil {
+		t.Error("Expected RemoveCallback to keep plain webhooks")
+	}
+}
+
+func TestDispatcher_Run(t *testing.T) {
+	received := make(chan Payload, 1)
+	secret := "0123456789abcdef"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, err := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		if err != nil {
+			t.Errorf("Invalid timestamp header: %v", err)
+		}
+		if got := r.Header.Get(HeaderSignature); got != Sign(secret, ts, body) {
+			t.Errorf("Signature mismatch: %s", got)
+		}
+		if r.Header.Get(HeaderDelivery) == "" {
+			t.Error("Expected delivery ID header")
+		}
+		var p Payload
+		if err := json.Unmarshal(body, &p); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+		want := "0xaaa:0xhash1:in"
+		if p.Removed {
+			want += ":removed:5"
+		}
+		if got := r.Header.Get(HeaderIdempotencyKey); got != want {
+			t.Errorf("Unexpected idempotency key %q", got)
+		}
+		received <- p
+	}))
+	defer ts.Close()
+
+	r := NewRegistry()
+	hook, _ := r.Register("", ts.URL, secret, []string{"0xaaa"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan parser.Event, 2)
+	go NewDispatcherWithOptions(r, DispatcherOptions{Targets: localTargets(t)}).Run(ctx, events)
+
+	events <- parser.Event{Address: "0xbbb", Transaction: transaction.Transaction{Hash: "0xignored"}}
+	events <- parser.Event{Address: "0xaaa", Transaction: transaction.Transaction{Hash: "0xhash1", Block: 5, Direction: transaction.DirectionIn}}
+
+	select {
+	case p := <-received:
+		if p.WebhookID != hook.ID || p.Address != "0xaaa" || p.Transaction.Hash != "0xhash1" || p.Removed || p.Reorg != nil {
+			t.Errorf("Unexpected payload: %+v", p)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for delivery")
+	}
+
+	// A reorg removing the transaction is delivered as well.
+	events <- parser.Event{
+		Address:     "0xaaa",
+		Transaction: transaction.Transaction{Hash: "0xhash1", Block: 5, Direction: transaction.DirectionIn},
+		Removed:     true,
+		Reorg:       &parser.Reorg{Depth: 1, Blocks: []parser.BlockRef{{Number: 5, Hash: "0xb5"}, {Number: 6, Hash: "0xb6"}}},
+	}
+	select {
+	case p := <-received:
+		if !p.Removed || p.Reorg == nil || p.Reorg.Depth != 1 || p.Reorg.Blocks[0].Hash != "0xb5" {
+			t.Errorf("Expected the removal with its reorg, got %+v", p)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for delivery")
+	}
+}
+
+func TestSign(t *testing.T) {
+	// HMAC-SHA256 of "1700000000.{}" keyed with "secret".
+	want := "sha256=b8569b78799ff9e3cbff0fc2d63a33a2b57f3282abd07c37ae5e8e7d79a5f163"
+	if got := Sign("secret", 1700000000, []byte("{}")); got != want {
+		t.Errorf("Sign = %s, expected %s", got, want)
+	}
+	if Sign("other", 1700000000, []byte("{}")) == want {
+		t.Error("Expected different secrets to produce different signatures")
+	}
+}
+
+func TestDispatcher_Retries(t *testing.T) {
+	tests := []struct {
+		name              string
+		statuses          []int
+		expectedCalls     int32
+		expectedDelivered int64
+		expectedFailed    int64
+	}{
+		{name: "recovers after server errors", statuses: []int{500, 503, 200}, expectedCalls: 3, expectedDelivered: 1},
+		{name: "gives up after max attempts", statuses: []int{500, 500, 500, 500}, expectedCalls: 3, expectedFailed: 1},
+		{name: "does not retry client errors", statuses: []int{400}, expectedCalls: 1, expectedFailed: 1},
+		{name: "retries rate limiting", statuses: []int{429, 200}, expectedCalls: 2, expectedDelivered: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&calls, 1)
+				w.WriteHeader(tt.statuses[n-1])
+			}))
+			defer ts.Close()
+
+			r := NewRegistry()
+			hook, _ := r.Register("", ts.URL, "", nil)
+			d := NewDispatcherWithOptions(r, DispatcherOptions{
+				Workers:     1,
+				MaxAttempts: 3,
+				BaseBackoff: time.Millisecond,
+				MaxBackoff:  2 * time.Millisecond,
+				Targets:     localTargets(t),
+			})
+
+			events := make(chan parser.Event, 1)
+			events <- parser.Event{Address: "0xaaa", Transaction: transaction.Transaction{Hash: "0xhash1"}}
+			close(events)
+			d.Run(context.Background(), events) // returns once the queue is drained
+
+			if got := atomic.LoadInt32(&calls); got != tt.expectedCalls {
+				t.Errorf("Expected %d calls, got %d", tt.expectedCalls, got)
+			}
+			status, _ := r.Get("", hook.ID)
+			if status.Status.Delivered != tt.expectedDelivered || status.Status.Failed != tt.expectedFailed {
+				t.Errorf("Unexpected status: %+v", status.Status)
+			}
+			if tt.expectedFailed > 0 && status.Status.LastError == "" {
+				t.Error("Expected last error to be recorded")
+			}
+		})
+	}
+}
+
+func TestDispatcher_DropsWhenQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	r := NewRegistry()
+	hook, _ := r.Register("", ts.URL, "", nil)
+	d := NewDispatcherWithOptions(r, DispatcherOptions{Workers: 1, QueueSize: 1, Targets: localTargets(t)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan parser.Event)
+	go d.Run(ctx, events)
+
+	// One in flight, one queued, the rest dropped.
+	for i := 0; i < 5; i++ {
+		events <- parser.Event{Address: "0xaaa"}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		got, _ := r.Get("", hook.ID)
+		if got.Status.Dropped >= 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected at least 3 dropped events, got %+v", got.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDispatcher_OutboxWaitsWhenQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	var delivered atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		delivered.Add(1)
+	}))
+	defer ts.Close()
+
+	box := outbox.New()
+	r := NewRegistry()
+	hook, _ := r.Register("", ts.URL, "", nil)
+	d := NewDispatcherWithOptions(r, DispatcherOptions{Workers: 1, QueueSize: 1, Outbox: box.Chain("ethereum"), Targets: localTargets(t)})
+
+	events := make(chan parser.Event)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Run(context.Background(), events)
+	}()
+	// One in flight, one queued, and the next one held up, not dropped.
+	for i := 0; i < 3; i++ {
+		events <- parser.Event{Address: "0xaaa"}
+	}
+	select {
+	case events <- parser.Event{Address: "0xaaa"}:
+		t.Fatal("Expected the dispatcher to wait for room in the queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	events <- parser.Event{Address: "0xaaa"}
+	close(events)
+	<-done
+
+	got, _ := r.Get("", hook.ID)
+	if got.Status.Dropped != 0 || delivered.Load() != 4 {
+		t.Errorf("Expected all 4 events to be delivered, got %d and %+v", delivered.Load(), got.Status)
+	}
+	if got := box.Len(); got != 0 {
+		t.Errorf("Expected every delivery to be settled, got %d pending", got)
+	}
+}
+
+func TestDispatcher_ResumesFromOutbox(t *testing.T) {
+	deliveries := make(chan string, 2)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deliveries <- r.Header.Get(HeaderDelivery)
+	}))
+	defer ts.Close()
+
+	box := outbox.New()
+	eth := box.Chain("ethereum")
+	// The webhook got a new ID when the config file was loaded again.
+	pending, _ := eth.Add(outboxSinkPrefix+"old-id", ts.URL, parser.Event{Address: "0xaaa"})
+	eth.Add(outboxSinkPrefix+"deleted", "https://example.com/deleted", parser.Event{Address: "0xaaa"})
+	r := NewRegistry()
+	r.Register("", ts.URL, "", []string{"0xaaa"})
+
+	events := make(chan parser.Event)
+	close(events)
+	NewDispatcherWithOptions(r, DispatcherOptions{Outbox: eth, Targets: localTargets(t)}).Run(context.Background(), events)
+
+	select {
+	case id := <-deliveries:
+		if id != pending {
+			t.Errorf("Expected the delivery ID to be kept, got %q", id)
+		}
+	default:
+		t.Fatal("Expected the pending delivery to be resumed")
+	}
+	if len(deliveries) != 0 {
+		t.Error("Expected no delivery to the deleted webhook")
+	}
+	if got := box.Len(); got != 0 {
+		t.Errorf("Expected every delivery to be settled, got %d pending", got)
+	}
+}
+
+func TestDispatcher_OutboxKeepsAbandoned(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	box := outbox.New()
+	r := NewRegistry()
+	r.Register("", ts.URL, "", nil)
+	d := NewDispatcherWithOptions(r, DispatcherOptions{Outbox: box.Chain("ethereum"), BaseBackoff: time.Hour, Targets: localTargets(t)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan parser.Event)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Run(ctx, events)
+	}()
+	events <- parser.Event{Address: "0xaaa"}
+	deadline := time.Now().Add(2 * time.Second)
+	for box.Len() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the delivery to be recorded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+	if got := box.Len(); got != 1 {
+		t.Errorf("Expected the abandoned delivery to stay pending, got %d", got)
+	}
+}
+
+func TestTargets(t *testing.T) {
+	ctx := context.Background()
+	var refuseAll *Targets
+	for _, host := range []string{"127.0.0.1", "::1", "10.1.2.3", "192.168.0.1", "172.16.0.1", "169.254.169.254", "fe80::1", "fd00::1", "0.0.0.0", "::ffff:127.0.0.1"} {
+		if err := refuseAll.CheckHost(ctx, host); !errors.Is(err, ErrForbiddenTarget) {
+			t.Errorf("Expected %s to be refused, got %v", host, err)
+		}
+	}
+	for _, host := range []string{"93.184.216.34", "2606:2800:220:1::1"} {
+		if err := refuseAll.CheckHost(ctx, host); err != nil {
+			t.Errorf("Expected %s to be allowed, got %v", host, err)
+		}
+	}
+
+	allowed, err := NewTargets([]string{"127.0.0.0/8", " 10.0.0.5 "})
+	if err != nil {
+		t.Fatalf("NewTargets failed: %v", err)
+	}
+	if err := allowed.CheckHost(ctx, "127.0.0.1"); err != nil {
+		t.Errorf("Expected an allowed network to be let through, got %v", err)
+	}
+	if err := allowed.CheckHost(ctx, "10.0.0.5"); err != nil {
+		t.Errorf("Expected an allowed address to be let through, got %v", err)
+	}
+	if err := allowed.CheckHost(ctx, "10.0.0.6"); !errors.Is(err, ErrForbiddenTarget) {
+		t.Errorf("Expected addresses outside the allowlist to be refused, got %v", err)
+	}
+	if _, err := NewTargets([]string{"not-a-network"}); err == nil {
+		t.Error("Expected an invalid network to be rejected")
+	}
+}
+
+func TestDispatcher_RefusesPrivateTargets(t *testing.T) {
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer ts.Close()
+
+	r := NewRegistry()
+	hook, _ := r.Register("", ts.URL, "", nil)
+	events := make(chan parser.Event, 1)
+	events <- parser.Event{Address: "0xaaa"}
+	close(events)
+	NewDispatcher(r).Run(context.Background(), events)
+
+	got, _ := r.Get("", hook.ID)
+	if hits.Load() != 0 || got.Status.Failed != 1 || !strings.Contains(got.Status.LastError, ErrForbiddenTarget.Error()) {
+		t.Errorf("Expected the loopback delivery to be refused, got %d hits and %+v", hits.Load(), got.Status)
+	}
+}
@@ -0,0 +1,207 @@
+// Package abi decodes the input data of contract calls into a method name
+// and arguments, using built-in ERC-20 and ERC-721 methods and
+// user-supplied contract ABIs.
+package abi
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// Param is a function input in a JSON ABI.
+type Param struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Method is a function in a JSON ABI.
+type Method struct {
+	Name   string  `json:"name"`
+	Inputs []Param `json:"inputs"`
+}
+
+// Signature returns the canonical signature the selector of m is derived
+// from, e.g. "transfer(address,uint256)".
+func (m Method) Signature() string {
+	types := make([]string, len(m.Inputs))
+	for i, p := range m.Inputs {
+		types[i] = p.Type
+	}
+	return m.Name + "(" + strings.Join(types, ",") + ")"
+}
+
+// Selector returns the first four bytes of the Keccak-256 of m's signature.
+func (m Method) Selector() [4]byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(m.Signature()))
+	var sel [4]byte
+	copy(sel[:], h.Sum(nil))
+	return sel
+}
+
+// Registry decodes call input data.
+type Registry interface {
+	// Decode decodes hex-encoded input data, reporting false when its
+	// selector is unknown.
+	Decode(input string) (*transaction.Call, bool)
+}
+
+// Chain is a Registry consulting each registry in order; the first match
+// wins, so earlier registries override later ones.
+type Chain []Registry
+
+// Decode decodes input with the first registry that knows its selector.
+func (c Chain) Decode(input string) (*transaction.Call, bool) {
+	for _, r := range c {
+		if call, ok := r.Decode(input); ok {
+			return call, true
+		}
+	}
+	return nil, false
+}
+
+// Decoder is an immutable Registry of methods by selector.
+type Decoder struct {
+	methods map[[4]byte]Method
+}
+
+var (
+	namePattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+	typePattern = regexp.MustCompile(`^(address|bool|string|bytes([1-9]|[12][0-9]|3[0-2])?|u?int(8|16|24|32|40|48|56|64|72|80|88|96|104|112|120|128|136|144|152|160|168|176|184|192|200|208|216|224|232|240|248|256)?)(\[\])?$`)
+)
+
+// NewDecoder validates methods and creates a Decoder. The bare int and uint
+// aliases are canonicalized to int256 and uint256. Of methods sharing a
+// selector, the last one wins.
+func NewDecoder(methods []Method) (*Decoder, error) {
+	d := &Decoder{methods: make(map[[4]byte]Method, len(methods))}
+	for i, m := range methods {
+		if !namePattern.MatchString(m.Name) {
+			return nil, fmt.Errorf("method %d: invalid name %q", i+1, m.Name)
+		}
+		inputs := make([]Param, len(m.Inputs))
+		for j, p := range m.Inputs {
+			if !typePattern.MatchString(p.Type) {
+				return nil, fmt.Errorf("method %d (%s): unsupported type %q", i+1, m.Name, p.Type)
+			}
+			p.Type = canonicalType(p.Type)
+			inputs[j] = p
+		}
+		m.Inputs = inputs
+		d.methods[m.Selector()] = m
+	}
+	return d, nil
+}
+
+// supported reports whether all of m's input types can be decoded.
+func supported(m Method) bool {
+	for _, p := range m.Inputs {
+		if !typePattern.MatchString(p.Type) {
+			return false
+		}
+	}
+	return true
+}
+
+// canonicalType expands the int and uint aliases.
+func canonicalType(t string) string {
+	base, array := strings.CutSuffix(t, "[]")
+	if base == "int" || base == "uint" {
+		base += "256"
+	}
+	if array {
+		return base + "[]"
+	}
+	return base
+}
+
+// Len returns the number of known methods.
+func (d *Decoder) Len() int {
+	return len(d.methods)
+}
+
+// Decode decodes hex-encoded input data. A known selector with arguments
+// that don't decode yields a Call without Args.
+func (d *Decoder) Decode(input string) (*transaction.Call, bool) {
+	input = strings.TrimPrefix(input, "0x")
+	if len(input) < 8 {
+		return nil, false
+	}
+	var sel [4]byte
+	if _, err := hex.Decode(sel[:], []byte(input[:8])); err != nil {
+		return nil, false
+	}
+	m, ok := d.methods[sel]
+	if !ok {
+		return nil, false
+	}
+	call := &transaction.Call{Method: m.Name, Signature: m.Signature()}
+	data, err := hex.DecodeString(input[8:])
+	if err != nil {
+		return call, true
+	}
+	if args, err := decodeArgs(m.Inputs, data); err == nil {
+		call.Args = args
+	}
+	return call, true
+}
+
+//go:embed builtin.json
+var builtinJSON []byte
+
+// Builtin returns the methods shipped with txparser: the ERC-20 and ERC-721
+// transfer and approval functions.
+func Builtin() *Decoder {
+	d, err := ReadJSON(bytes.NewReader(builtinJSON))
+	if err != nil {
+		panic("abi: invalid builtin ABI: " + err.Error())
+	}
+	return d
+}
+
+// LoadFile reads a contract ABI from a JSON file; see ReadJSON.
+func LoadFile(path string) (*Decoder, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ABI file: %w", err)
+	}
+	defer f.Close()
+	d, err := ReadJSON(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ABI file %s: %w", path, err)
+	}
+	return d, nil
+}
+
+// ReadJSON reads a contract ABI as emitted by solc: an array of entries, of
+// which functions are kept and events, errors and constructors skipped.
+// Functions taking tuples or fixed-size arrays aren't supported and are
+// skipped too.
+func ReadJSON(r io.Reader) (*Decoder, error) {
+	var entries []struct {
+		Type string `json:"type"`
+		Method
+	}
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, err
+	}
+	var methods []Method
+	for _, e := range entries {
+		// Entries without a type are functions in older ABIs.
+		if (e.Type == "function" || e.Type == "") && supported(e.Method) {
+			methods = append(methods, e.Method)
+		}
+	}
+	return NewDecoder(methods)
+}
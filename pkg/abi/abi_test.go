@@ -0,0 +1,172 @@
+package abi
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// word left-pads hex to a 32-byte ABI word.
+func word(h string) string {
+	return strings.Repeat("0", 64-len(h)) + h
+}
+
+func TestBuiltin_Selectors(t *testing.T) {
+	d := Builtin()
+	for sel, sig := range map[string]string{
+		"a9059cbb": "transfer(address,uint256)",
+		"095ea7b3": "approve(address,uint256)",
+		"23b872dd": "transferFrom(address,address,uint256)",
+		"42842e0e": "safeTransferFrom(address,address,uint256)",
+		"b88d4fde": "safeTransferFrom(address,address,uint256,bytes)",
+		"a22cb465": "setApprovalForAll(address,bool)",
+		"d0e30db0": "deposit()",
+	} {
+		b, _ := hex.DecodeString(sel)
+		m, ok := d.methods[[4]byte(b)]
+		if !ok || m.Signature() != sig {
+			t.Errorf("Expected selector %s for %s, got %q %v", sel, sig, m.Signature(), ok)
+		}
+	}
+}
+
+func TestDecoder_Decode(t *testing.T) {
+	d := Builtin()
+	const to = "dac17f958d2ee523a2206206994597c13d831ec7"
+	tests := []struct {
+		name  string
+		input string
+		want  *transaction.Call
+	}{
+		{
+			name:  "erc20 transfer",
+			input: "0xa9059cbb" + word(to) + word("de0b6b3a7640000"),
+			want: &transaction.Call{Method: "transfer", Signature: "transfer(address,uint256)", Args: []transaction.Arg{
+				{Name: "to", Type: "address", Value: "0x" + to},
+				{Name: "value", Type: "uint256", Value: "1000000000000000000"},
+			}},
+		},
+		{
+			name:  "bool",
+			input: "0xa22cb465" + word(to) + word("1"),
+			want: &transaction.Call{Method: "setApprovalForAll", Signature: "setApprovalForAll(address,bool)", Args: []transaction.Arg{
+				{Name: "operator", Type: "address", Value: "0x" + to},
+				{Name: "approved", Type: "bool", Value: "true"},
+			}},
+		},
+		{
+			name:  "dynamic bytes",
+			input: "0xb88d4fde" + word(to) + word(to) + word("2a") + word("80") + word("3") + "abcdef" + strings.Repeat("0", 58),
+			want: &transaction.Call{Method: "safeTransferFrom", Signature: "safeTransferFrom(address,address,uint256,bytes)", Args: []transaction.Arg{
+				{Name: "from", Type: "address", Value: "0x" + to},
+				{Name: "to", Type: "address", Value: "0x" + to},
+				{Name: "tokenId", Type: "uint256", Value: "42"},
+				{Name: "data", Type: "bytes", Value: "0xabcdef"},
+			}},
+		},
+		{
+			name:  "no arguments",
+			input: "0xd0e30db0",
+			want:  &transaction.Call{Method: "deposit", Signature: "deposit()", Args: []transaction.Arg{}},
+		},
+		{
+			name:  "truncated",
+			input: "0xa9059cbb" + word(to),
+			want:  &transaction.Call{Method: "transfer", Signature: "transfer(address,uint256)"},
+		},
+		{
+			name:  "offset out of bounds",
+			input: "0xb88d4fde" + word(to) + word(to) + word("2a") + word("ffffff"),
+			want:  &transaction.Call{Method: "safeTransferFrom", Signature: "safeTransferFrom(address,address,uint256,bytes)"},
+		},
+		{name: "unknown selector", input: "0x12345678" + word("1")},
+		{name: "plain transfer", input: "0x"},
+		{name: "not hex", input: "0xzzzzzzzz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := d.Decode(tt.input)
+			if ok != (tt.want != nil) {
+				t.Fatalf("Expected ok=%v, got %v", tt.want != nil, ok)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	abiJSON := `[
+		{"type": "constructor", "inputs": [{"name": "owner", "type": "address"}]},
+		{"type": "event", "name": "Paid", "inputs": [{"name": "amount", "type": "uint256"}]},
+		{"type": "function", "name": "pay", "inputs": [{"name": "ids", "type": "uint[]"}, {"name": "delta", "type": "int8"}, {"name": "memo", "type": "string"}, {"name": "tag", "type": "bytes4"}]},
+		{"type": "function", "name": "swap", "inputs": [{"name": "params", "type": "tuple"}]}
+	]`
+	d, err := ReadJSON(strings.NewReader(abiJSON))
+	if err != nil {
+		t.Fatalf("ReadJSON failed: %v", err)
+	}
+	if d.Len() != 1 {
+		t.Fatalf("Expected only pay to be kept, got %d methods", d.Len())
+	}
+
+	m := Method{Name: "pay", Inputs: []Param{{Type: "uint256[]"}, {Type: "int8"}, {Type: "string"}, {Type: "bytes4"}}}
+	sel := m.Selector()
+	input := hex.EncodeToString(sel[:]) +
+		word("80") + strings.Repeat("f", 64) + word("e0") + "cafebabe" + strings.Repeat("0", 56) +
+		word("2") + word("1") + word("2") +
+		word("2") + hex.EncodeToString([]byte("hi")) + strings.Repeat("0", 60)
+	call, ok := d.Decode(input)
+	if !ok || call.Signature != "pay(uint256[],int8,string,bytes4)" {
+		t.Fatalf("Expected pay to decode, got %+v %v", call, ok)
+	}
+	var values []string
+	for _, a := range call.Args {
+		values = append(values, a.Value)
+	}
+	if want := []string{"[1,2]", "-1", "hi", "0xcafebabe"}; !reflect.DeepEqual(values, want) {
+		t.Errorf("Expected %v, got %v", want, values)
+	}
+
+	if _, err := NewDecoder([]Method{{Name: "bad name"}}); err == nil {
+		t.Error("Expected an error for an invalid method name")
+	}
+	if _, err := NewDecoder([]Method{{Name: "f", Inputs: []Param{{Type: "uint7"}}}}); err == nil {
+		t.Error("Expected an error for an unsupported type")
+	}
+}
+
+func TestChain(t *testing.T) {
+	override, err := NewDecoder([]Method{{Name: "transfer", Inputs: []Param{{Name: "recipient", Type: "address"}, {Name: "amount", Type: "uint"}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := Chain{override, Builtin()}
+	call, ok := c.Decode("0xa9059cbb" + word("1") + word("2"))
+	if !ok || len(call.Args) != 2 || call.Args[0].Name != "recipient" {
+		t.Errorf("Expected the first registry to win, got %+v", call)
+	}
+	if call, ok := c.Decode("0x095ea7b3" + word("1") + word("2")); !ok || call.Method != "approve" {
+		t.Errorf("Expected a fallback to later registries, got %+v", call)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.json")
+	if err := os.WriteFile(path, []byte(`[{"type":"function","name":"mint","inputs":[{"name":"to","type":"address"}]}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	d, err := LoadFile(path)
+	if err != nil || d.Len() != 1 {
+		t.Fatalf("Expected one method, got %v", err)
+	}
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
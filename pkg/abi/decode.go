@@ -0,0 +1,147 @@
+package abi
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+const wordSize = 32
+
+// maxArrayLen bounds the arrays decoded from a single argument, so that a
+// crafted length can't make decoding allocate without limit.
+const maxArrayLen = 1024
+
+var errMalformed = errors.New("abi: malformed input data")
+
+// decodeArgs decodes the ABI-encoded arguments in data: a head of one word
+// per argument, holding static values inline and the offsets of dynamic
+// ones, followed by the dynamic values.
+func decodeArgs(params []Param, data []byte) ([]transaction.Arg, error) {
+	args := make([]transaction.Arg, len(params))
+	for i, p := range params {
+		word, err := wordAt(data, i*wordSize)
+		if err != nil {
+			return nil, err
+		}
+		var v string
+		if isDynamic(p.Type) {
+			offset, err := wordInt(word)
+			if err != nil {
+				return nil, err
+			}
+			v, err = decodeDynamic(p.Type, data, offset)
+			if err != nil {
+				return nil, err
+			}
+		} else if v, err = decodeStatic(p.Type, word); err != nil {
+			return nil, err
+		}
+		args[i] = transaction.Arg{Name: p.Name, Type: p.Type, Value: v}
+	}
+	return args, nil
+}
+
+func isDynamic(typ string) bool {
+	return typ == "bytes" || typ == "string" || strings.HasSuffix(typ, "[]")
+}
+
+// decodeDynamic decodes the dynamic value of type typ at offset in data: a
+// length word followed by the bytes or, for arrays, the elements.
+func decodeDynamic(typ string, data []byte, offset int) (string, error) {
+	lenWord, err := wordAt(data, offset)
+	if err != nil {
+		return "", err
+	}
+	n, err := wordInt(lenWord)
+	if err != nil {
+		return "", err
+	}
+	start := offset + wordSize
+
+	elem, isArray := strings.CutSuffix(typ, "[]")
+	if !isArray {
+		if n > len(data)-start {
+			return "", errMalformed
+		}
+		b := data[start : start+n]
+		if typ == "string" && utf8.Valid(b) {
+			return string(b), nil
+		}
+		return "0x" + hex.EncodeToString(b), nil
+	}
+
+	// Arrays of dynamic elements would need nested offsets; the type
+	// pattern only admits arrays of static ones.
+	if n > maxArrayLen {
+		return "", errMalformed
+	}
+	vals := make([]string, n)
+	for i := range vals {
+		word, err := wordAt(data, start+i*wordSize)
+		if err != nil {
+			return "", err
+		}
+		if vals[i], err = decodeStatic(elem, word); err != nil {
+			return "", err
+		}
+	}
+	return "[" + strings.Join(vals, ",") + "]", nil
+}
+
+// decodeStatic decodes a word holding a value of static type typ.
+func decodeStatic(typ string, word []byte) (string, error) {
+	switch {
+	case typ == "address":
+		return "0x" + hex.EncodeToString(word[12:]), nil
+	case typ == "bool":
+		if v := new(big.Int).SetBytes(word); v.IsInt64() && v.Int64() <= 1 {
+			return strconv.FormatBool(v.Int64() == 1), nil
+		}
+		return "", errMalformed
+	case strings.HasPrefix(typ, "uint"):
+		return new(big.Int).SetBytes(word).String(), nil
+	case strings.HasPrefix(typ, "int"):
+		v := new(big.Int).SetBytes(word)
+		if word[0]&0x80 != 0 {
+			// Two's complement of a negative value.
+			v.Sub(v, new(big.Int).Lsh(big.NewInt(1), 8*wordSize))
+		}
+		return v.String(), nil
+	case strings.HasPrefix(typ, "bytes"):
+		n, err := strconv.Atoi(strings.TrimPrefix(typ, "bytes"))
+		if err != nil {
+			return "", errMalformed
+		}
+		return "0x" + hex.EncodeToString(word[:n]), nil
+	}
+	return "", errMalformed
+}
+
+// wordAt returns the word at offset in data.
+func wordAt(data []byte, offset int) ([]byte, error) {
+	if offset < 0 || offset > len(data)-wordSize {
+		return nil, errMalformed
+	}
+	return data[offset : offset+wordSize], nil
+}
+
+// wordInt decodes a word holding an offset or length, which must fit in an
+// int32 to be plausible.
+func wordInt(word []byte) (int, error) {
+	for _, b := range word[:28] {
+		if b != 0 {
+			return 0, errMalformed
+		}
+	}
+	n := int(word[28])<<24 | int(word[29])<<16 | int(word[30])<<8 | int(word[31])
+	if n < 0 || n > 1<<31-1 {
+		return 0, errMalformed
+	}
+	return n, nil
+}
@@ -0,0 +1,71 @@
+// Package address validates and normalizes Ethereum addresses.
+package address
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+var (
+	// ErrInvalidFormat is returned when a value is not a 0x-prefixed 20-byte hex string.
+	ErrInvalidFormat = errors.New("invalid address: expected 0x-prefixed 40 character hex string")
+	// ErrBadChecksum is returned when a mixed-case address fails EIP-55 validation.
+	ErrBadChecksum = errors.New("invalid address: EIP-55 checksum mismatch")
+)
+
+// Validate reports whether s is a well-formed address.
+// All-lowercase and all-uppercase addresses are accepted as-is; mixed-case
+// addresses must carry a valid EIP-55 checksum.
+func Validate(s string) error {
+	if len(s) != 42 || !strings.HasPrefix(s, "0x") {
+		return ErrInvalidFormat
+	}
+	body := s[2:]
+	if _, err := hex.DecodeString(body); err != nil {
+		return ErrInvalidFormat
+	}
+	if body == strings.ToLower(body) || body == strings.ToUpper(body) {
+		return nil
+	}
+	if Checksum(s) != s {
+		return ErrBadChecksum
+	}
+	return nil
+}
+
+// Normalize validates s and returns its canonical lowercase form, which is
+// how addresses are keyed in storage.
+func Normalize(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if err := Validate(s); err != nil {
+		return "", err
+	}
+	return strings.ToLower(s), nil
+}
+
+// Checksum returns the EIP-55 mixed-case encoding of a well-formed address.
+func Checksum(s string) string {
+	lower := strings.ToLower(strings.TrimPrefix(s, "0x"))
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(lower))
+	digest := h.Sum(nil)
+
+	out := []byte(lower)
+	for i, c := range out {
+		if c < 'a' || c > 'f' {
+			continue
+		}
+		// Each hex character maps to one nibble of the hash.
+		nibble := digest[i/2]
+		if i%2 == 0 {
+			nibble >>= 4
+		}
+		if nibble&0x0f >= 8 {
+			out[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(out)
+}
@@ -0,0 +1,94 @@
+package address
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr error
+	}{
+		{
+			name:    "lowercase",
+			input:   "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+			wantErr: nil,
+		},
+		{
+			name:    "uppercase",
+			input:   "0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED",
+			wantErr: nil,
+		},
+		{
+			name:    "valid checksum",
+			input:   "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+			wantErr: nil,
+		},
+		{
+			name:    "bad checksum",
+			input:   "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD",
+			wantErr: ErrBadChecksum,
+		},
+		{
+			name:    "missing prefix",
+			input:   "5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+			wantErr: ErrInvalidFormat,
+		},
+		{
+			name:    "too short",
+			input:   "0x1234567890abcdef",
+			wantErr: ErrInvalidFormat,
+		},
+		{
+			name:    "non-hex characters",
+			input:   "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beazz",
+			wantErr: ErrInvalidFormat,
+		},
+		{
+			name:    "empty",
+			input:   "",
+			wantErr: ErrInvalidFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate(%q) = %v, expected %v", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	got, err := Normalize(" 0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed ")
+	if err != nil {
+		t.Fatalf("Normalize returned error: %v", err)
+	}
+	if got != "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed" {
+		t.Errorf("Expected lowercase address, got %s", got)
+	}
+
+	if _, err := Normalize("not-an-address"); err == nil {
+		t.Error("Expected error for garbage input")
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	// Test vectors from EIP-55.
+	vectors := []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	}
+
+	for _, v := range vectors {
+		if got := Checksum(v); got != v {
+			t.Errorf("Checksum(%s) = %s", v, got)
+		}
+	}
+}
@@ -0,0 +1,541 @@
+// Package app assembles the whole indexer, the parsers, stores, notification
+// sinks and HTTP API of every configured chain, so that Go services can run
+// it in-process instead of shelling out to the txparser binary, which is a
+// thin wrapper around it.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/audit"
+	"github.com/danieloluwadare/tw-txparser/internal/config"
+	"github.com/danieloluwadare/tw-txparser/internal/deadletter"
+	"github.com/danieloluwadare/tw-txparser/internal/group"
+	"github.com/danieloluwadare/tw-txparser/internal/leader"
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/internal/outbox"
+	"github.com/danieloluwadare/tw-txparser/internal/server"
+	"github.com/danieloluwadare/tw-txparser/internal/storage"
+	"github.com/danieloluwadare/tw-txparser/internal/tenant"
+	"github.com/danieloluwadare/tw-txparser/internal/tracing"
+	"github.com/danieloluwadare/tw-txparser/internal/version"
+	"github.com/danieloluwadare/tw-txparser/internal/webhook"
+	"github.com/danieloluwadare/tw-txparser/pkg/abi"
+	"github.com/danieloluwadare/tw-txparser/pkg/etherscan"
+	"github.com/danieloluwadare/tw-txparser/pkg/labels"
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics/prometheus"
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics/statsd"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+)
+
+// Config configures an App. Its fields are documented along with the
+// environment variables setting them.
+type Config = config.Config
+
+// DefaultConfig returns the built-in configuration.
+func DefaultConfig() Config {
+	return config.Default()
+}
+
+// ConfigFromEnv returns the built-in configuration overridden by the
+// environment, as the txparser binary reads it.
+func ConfigFromEnv() Config {
+	return config.FromEnv()
+}
+
+// App is an assembled indexer. It runs once: the files and connections
+// acquired by New are released when Run returns.
+type App struct {
+	cfg    Config
+	chains []*Chain
+	server *server.Server
+	// elector is nil unless leader election is enabled.
+	elector *leader.Elector
+
+	auditLog     *audit.Log
+	deadLetters  *deadletter.Queue
+	outbox       *outbox.Outbox // nil unless cfg.OutboxFile is set
+	closeMetrics func() error
+	logger       *slog.Logger
+
+	// ctx is the root context of parsers and dispatchers, cancelled by
+	// shutdown.
+	ctx    context.Context
+	cancel context.CancelFunc
+	ran    atomic.Bool
+}
+
+// Run assembles the indexer of cfg and runs it until ctx is cancelled.
+func Run(ctx context.Context, cfg Config) error {
+	a, err := New(cfg)
+	if err != nil {
+		return err
+	}
+	return a.Run(ctx)
+}
+
+// New assembles the indexer of cfg without starting it. With
+// cfg.ConfigFile set, the notification sinks it declares are loaded too.
+func New(cfg Config) (_ *App, err error) {
+	var file config.File
+	if cfg.ConfigFile != "" {
+		if file, err = config.LoadFile(cfg.ConfigFile); err != nil {
+			return nil, err
+		}
+	}
+	if err := config.CheckShard(cfg.ShardIndex, cfg.ShardCount); err != nil {
+		return nil, fmt.Errorf("SHARD_INDEX: %w", err)
+	}
+	if len(cfg.Chains) == 0 {
+		return nil, errors.New("no chain configured")
+	}
+
+	a := &App{cfg: cfg, logger: logging.Component("serve")}
+	a.ctx, a.cancel = context.WithCancel(context.Background())
+	// Whatever was acquired is released if assembly fails.
+	defer func() {
+		if err != nil {
+			a.release()
+		}
+	}()
+
+	rec, metricsHandler, closeMetrics, err := newMetrics(cfg)
+	if err != nil {
+		return nil, err
+	}
+	a.closeMetrics = closeMetrics
+	a.auditLog = audit.New()
+	if cfg.AuditLogFile != "" {
+		if a.auditLog, err = audit.Open(cfg.AuditLogFile); err != nil {
+			return nil, err
+		}
+	}
+	deadLetters := deadletter.New()
+	if cfg.DeadLetterFile != "" {
+		if deadLetters, err = deadletter.Open(cfg.DeadLetterFile); err != nil {
+			return nil, err
+		}
+	}
+	a.deadLetters = deadLetters
+	if cfg.OutboxFile != "" {
+		if a.outbox, err = outbox.Open(cfg.OutboxFile); err != nil {
+			return nil, err
+		}
+	}
+	// Backfill the history of new subscriptions from an Etherscan-compatible
+	// API, sharing its rate limit across chains; replays stay offline
+	var history *etherscan.Client
+	if cfg.EtherscanURL != "" && cfg.ReplayDir == "" {
+		history, err = etherscan.New(cfg.EtherscanURL, etherscan.Options{APIKey: cfg.EtherscanAPIKey, RateLimit: cfg.EtherscanRateLimit})
+		if err != nil {
+			return nil, err
+		}
+	}
+	targets, err := webhook.NewTargets(strings.Split(cfg.WebhookAllowedNetworks, ","))
+	if err != nil {
+		return nil, fmt.Errorf("WEBHOOK_ALLOWED_NETWORKS: %w", err)
+	}
+	labelRegistry, err := newLabels(cfg)
+	if err != nil {
+		return nil, err
+	}
+	decoder, err := newABI(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if a.elector, err = newElector(cfg, rec); err != nil {
+		return nil, err
+	}
+
+	// One parser, store and webhook registry per chain, each mounted under
+	// /v1/{chain}/. The first chain is also served on the unscoped routes.
+	mounted := make(map[string]*server.Server, len(cfg.Chains))
+	var root server.Options
+	for i, ch := range cfg.Chains {
+		c, err := newChain(a.ctx, cfg, file, ch, decoder, deadLetters, a.outbox, history, targets, rec, a.logger)
+		if err != nil {
+			return nil, err
+		}
+		a.chains = append(a.chains, c)
+
+		tenants, err := newTenants(cfg, c.watched)
+		if err != nil {
+			return nil, err
+		}
+		if tenants != nil && i == 0 {
+			a.logger.Info("API keys enabled", "tenants", tenants.Tenants())
+		}
+
+		// Admin endpoints are enabled only when a token is configured
+		opts := server.Options{
+			AdminToken:          cfg.AdminToken,
+			Chain:               ch.Name,
+			ChainID:             ch.ChainID,
+			BackwardScanEnabled: ch.BackwardScanEnabled,
+			BackwardScanDepth:   ch.BackwardScanDepth,
+			Webhooks:            c.hooks,
+			WebhookTargets:      targets,
+			Sinks:               c.sinks,
+			ENS:                 c.ens,
+			Labels:              labelRegistry,
+			Audit:               a.auditLog,
+			DeadLetters:         deadLetters,
+			Tenants:             tenants,
+			Expiry:              c.expiry,
+			Groups:              group.New(),
+			Providers:           c.providers,
+		}
+		if a.elector != nil {
+			opts.Leader = a.elector.IsLeader
+		}
+		mounted[ch.Name] = server.NewWithOptions(c.Parser, opts)
+		if i == 0 {
+			root = opts
+		}
+	}
+	root.Chains = mounted
+	root.Metrics = rec
+	root.MetricsHandler = metricsHandler
+	root.Snapshot = func() interface{} { return a.Snapshot() }
+	root.LogControl = true
+	a.server = server.NewWithOptions(a.chains[0].Parser, root)
+	return a, nil
+}
+
+// Chains returns the indexed chains in configuration order; the first is
+// the one served on the unscoped routes.
+func (a *App) Chains() []*Chain {
+	return a.chains
+}
+
+// Chain returns the chain with the given name.
+func (a *App) Chain(name string) (*Chain, bool) {
+	for _, c := range a.chains {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// Handler returns the HTTP API, for services serving it from their own
+// server rather than on cfg.ListenAddr.
+func (a *App) Handler() http.Handler {
+	return a.server.Handler()
+}
+
+// Run starts polling and, unless cfg.ListenAddr is empty, serves the HTTP
+// API on it. It blocks until ctx is cancelled or the server fails, then
+// shuts down gracefully within cfg.ShutdownTimeout. The error of a failed
+// server or shutdown is returned; a cancelled ctx is not an error.
+func (a *App) Run(ctx context.Context) error {
+	if !a.ran.CompareAndSwap(false, true) {
+		return errors.New("app already ran")
+	}
+	defer a.release()
+	cfg, logger := a.cfg, a.logger
+
+	info := version.Get()
+	logger.Info("starting txparser",
+		"version", info.Version,
+		"commit", info.Commit,
+		"commit_time", info.CommitTime,
+		"build_time", info.BuildTime,
+		"chains", len(cfg.Chains),
+	)
+	if cfg.DryRun {
+		logger.Warn("dry run: transactions are parsed but neither stored nor delivered")
+	}
+	if cfg.ReplayDir != "" {
+		logger.Warn("replay: blocks are served from recorded fixtures, not the RPC endpoint", "dir", cfg.ReplayDir)
+	}
+
+	shutdownTracing, err := tracing.Setup(a.ctx, tracing.Options{
+		Endpoint:       cfg.TracingEndpoint,
+		ServiceName:    "txparser",
+		ServiceVersion: info.Version,
+		SampleRatio:    cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		return err
+	}
+
+	// With leader election, chains only start polling once this instance
+	// is elected; until then it serves the API as a standby.
+	for _, c := range a.chains {
+		c.start(a.ctx, a.elector == nil)
+	}
+	if a.elector != nil {
+		pollers := make([]parser.Poller, len(a.chains))
+		for i, c := range a.chains {
+			pollers[i] = c.poller
+		}
+		go lead(a.ctx, a.elector, pollers, cfg.LeaderRetryInterval, logger)
+	}
+
+	serveErr := make(chan error, 1)
+	if cfg.ListenAddr != "" {
+		go func() {
+			logger.Info("starting server", "addr", cfg.ListenAddr)
+			serveErr <- a.server.Start(cfg.ListenAddr)
+		}()
+	}
+
+	// Shut down once ctx is cancelled, or if the server stops on its own
+	var runErr error
+	select {
+	case <-ctx.Done():
+		logger.Info("shutting down", "timeout", cfg.ShutdownTimeout)
+	case err := <-serveErr:
+		if err == nil {
+			err = errors.New("server stopped unexpectedly")
+		}
+		logger.Error("server failed, shutting down", logging.KeyError, err)
+		runErr = err
+	}
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancelShutdown()
+	err = shutdown(shutdownCtx, a.server, a.cancel, a.chains, logger)
+	// Leadership is handed over only once the pollers have stopped writing.
+	if a.elector != nil {
+		if rerr := a.elector.Resign(); rerr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to release leader lock: %w", rerr))
+		}
+	}
+	// Spans from the shutdown itself are exported too.
+	if terr := shutdownTracing(shutdownCtx); terr != nil {
+		err = errors.Join(err, fmt.Errorf("failed to flush traces: %w", terr))
+	}
+	if err != nil {
+		return errors.Join(runErr, err)
+	}
+	logger.Info("shutdown complete")
+	return runErr
+}
+
+// lead starts pollers whenever this instance is elected, until ctx is
+// cancelled. If a poller stops on its own while leading, e.g. because the
+// node couldn't be reached at startup, the others are stopped and the lock
+// is released so that a standby can take over. This instance campaigns
+// again after twice retry, the interval at which standbys try to take the
+// lock, so that they get the chance first. On shutdown the lock is released
+// by the caller once the pollers have stopped.
+func lead(ctx context.Context, e *leader.Elector, pollers []parser.Poller, retry time.Duration, logger *slog.Logger) {
+	for {
+		elected := false
+		e.Run(ctx, func() { elected = true })
+		if !elected {
+			return
+		}
+		term, cancel := context.WithCancel(ctx)
+		stopped := make(chan struct{}, len(pollers))
+		for _, p := range pollers {
+			p.Start(term)
+			if w, ok := p.(parser.PollWatcher); ok {
+				go func(done <-chan struct{}) {
+					select {
+					case <-done:
+						stopped <- struct{}{}
+					case <-term.Done():
+					}
+				}(w.PollDone())
+			}
+		}
+		select {
+		case <-ctx.Done():
+			cancel()
+			return
+		case <-stopped:
+		}
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Error("poller stopped while leading, handing over leadership")
+		for _, p := range pollers {
+			p.Stop()
+		}
+		if err := e.Resign(); err != nil {
+			logger.Error("failed to release leader lock", logging.KeyError, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(2 * retry):
+		}
+	}
+}
+
+// release cancels the root context and closes the audit log, the outbox
+// and metrics.
+func (a *App) release() {
+	a.cancel()
+	if a.auditLog != nil {
+		a.auditLog.Close()
+	}
+	if a.outbox != nil {
+		a.outbox.Close()
+	}
+	if a.closeMetrics != nil {
+		a.closeMetrics()
+	}
+}
+
+// newMetrics returns the recorder selected by cfg.MetricsBackend, the
+// handler serving /metrics for Prometheus, and a function flushing buffered
+// metrics on exit.
+func newMetrics(cfg config.Config) (metrics.Recorder, http.Handler, func() error, error) {
+	noop := func() error { return nil }
+	switch cfg.MetricsBackend {
+	case "prometheus":
+		p := prometheus.New(prometheus.Options{Namespace: "txparser"})
+		return p, p.Handler(), noop, nil
+	case "statsd":
+		c, err := statsd.New(statsd.Options{Addr: cfg.StatsDAddr, Prefix: "txparser.", Tags: cfg.StatsDTags})
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return c, nil, c.Close, nil
+	default:
+		return metrics.Nop, nil, noop, nil
+	}
+}
+
+// newTenants builds the API key registry of a chain from cfg.APIKeys,
+// pinning the addresses subscribed by configuration. It returns nil when API
+// keys are disabled. Ownership is tracked per chain, as the same address may
+// be watched by different teams on different networks.
+func newTenants(cfg config.Config, pinned []string) (*tenant.Registry, error) {
+	if cfg.APIKeys == "" {
+		return nil, nil
+	}
+	keys, err := tenant.ParseKeys(cfg.APIKeys)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("API_KEYS is set but lists no keys")
+	}
+	tenants, err := tenant.New(keys)
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range pinned {
+		tenants.Pin(addr)
+	}
+	return tenants, nil
+}
+
+// newLabels builds the address label registry from the configured file and
+// the built-in labels, with file labels taking precedence. It returns nil
+// when neither is enabled.
+func newLabels(cfg config.Config) (labels.Registry, error) {
+	var chain labels.Chain
+	if cfg.LabelsFile != "" {
+		file, err := labels.LoadFile(cfg.LabelsFile)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, file)
+	}
+	if cfg.LabelsBuiltin {
+		chain = append(chain, labels.Builtin())
+	}
+	if len(chain) == 0 {
+		return nil, nil
+	}
+	return chain, nil
+}
+
+// newABI builds the call decoder from the configured contract ABIs and the
+// built-in methods, with file methods taking precedence. It returns nil when
+// decoding is disabled.
+func newABI(cfg config.Config) (abi.Registry, error) {
+	if !cfg.ABIDecoding && len(cfg.ABIFiles) == 0 {
+		return nil, nil
+	}
+	var chain abi.Chain
+	for _, path := range cfg.ABIFiles {
+		d, err := abi.LoadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, d)
+	}
+	return append(chain, abi.Builtin()), nil
+}
+
+// shutdownServer is the part of server.Server that shutdown needs.
+type shutdownServer interface {
+	Shutdown(ctx context.Context) error
+}
+
+// shutdown stops the service in dependency order, all bounded by ctx:
+//  1. the HTTP server stops accepting connections and drains in-flight requests;
+//  2. stopParsers cancels the parsers and dispatchers, and the pollers are awaited;
+//     pending subscription expiries are cancelled so stores don't change anymore;
+//  3. notification sinks finish in-flight deliveries and flush buffered state;
+//  4. stores that buffer writes are flushed.
+//
+// Every step runs even if an earlier one fails so that as much state as
+// possible is persisted; the errors are returned joined.
+func shutdown(ctx context.Context, srv shutdownServer, stopParsers context.CancelFunc, chains []*Chain, logger *slog.Logger) error {
+	var errs []error
+
+	start := time.Now()
+	if err := srv.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to drain HTTP server: %w", err))
+	}
+	logger.Info("http server stopped", "elapsed", time.Since(start))
+
+	stopParsers()
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for _, ch := range chains {
+			ch.poller.Stop()
+		}
+	}()
+	select {
+	case <-stopped:
+		logger.Info("pollers stopped", "elapsed", time.Since(start))
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("timed out waiting for pollers: %w", ctx.Err()))
+	}
+	for _, ch := range chains {
+		if ch.expiry != nil {
+			ch.expiry.Stop()
+		}
+	}
+
+	for _, ch := range chains {
+		if ch.sinksDone == nil {
+			continue
+		}
+		select {
+		case <-ch.sinksDone:
+		case <-ctx.Done():
+			errs = append(errs, fmt.Errorf("timed out waiting for %s sinks: %w", ch.Name, ctx.Err()))
+		}
+	}
+
+	for _, ch := range chains {
+		f, ok := ch.Storage.(storage.Flusher)
+		if !ok {
+			continue
+		}
+		if err := f.Flush(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to flush %s storage: %w", ch.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,352 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/config"
+	"github.com/danieloluwadare/tw-txparser/internal/leader"
+	"github.com/danieloluwadare/tw-txparser/internal/storage"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// recorder collects the order in which shutdown steps run.
+type recorder struct {
+	steps []string
+}
+
+type fakeServer struct {
+	rec *recorder
+	err error
+}
+
+func (f *fakeServer) Shutdown(ctx context.Context) error {
+	f.rec.steps = append(f.rec.steps, "http")
+	return f.err
+}
+
+type fakePoller struct {
+	rec   *recorder
+	block chan struct{} // Stop waits on block when non-nil
+}
+
+func (f *fakePoller) Start(ctx context.Context) {}
+
+func (f *fakePoller) Stop() {
+	if f.block != nil {
+		<-f.block
+	}
+	f.rec.steps = append(f.rec.steps, "poller")
+}
+
+type flushingStorage struct {
+	storage.Storage
+	rec *recorder
+}
+
+func (f *flushingStorage) Flush() error {
+	f.rec.steps = append(f.rec.steps, "flush")
+	return nil
+}
+
+func TestShutdown_Order(t *testing.T) {
+	rec := &recorder{}
+	cancelled := false
+	sinksDone := make(chan struct{})
+	close(sinksDone)
+	chains := []*Chain{
+		{Name: "ethereum", poller: &fakePoller{rec: rec}, Storage: &flushingStorage{Storage: storage.NewMemoryStorage(), rec: rec}, sinksDone: sinksDone},
+		{Name: "sepolia", poller: &fakePoller{rec: rec}, Storage: storage.NewMemoryStorage()},
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	err := shutdown(context.Background(), &fakeServer{rec: rec}, func() { cancelled = true }, chains, logger)
+	if err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+	if !cancelled {
+		t.Error("Expected parsers to be cancelled")
+	}
+	want := []string{"http", "poller", "poller", "flush"}
+	if len(rec.steps) != len(want) {
+		t.Fatalf("Expected steps %v, got %v", want, rec.steps)
+	}
+	for i := range want {
+		if rec.steps[i] != want[i] {
+			t.Errorf("Expected steps %v, got %v", want, rec.steps)
+			break
+		}
+	}
+}
+
+func TestShutdown_Deadline(t *testing.T) {
+	rec := &recorder{}
+	block := make(chan struct{})
+	defer close(block)
+	chains := []*Chain{
+		{Name: "ethereum", poller: &fakePoller{rec: rec, block: block}, Storage: &flushingStorage{Storage: storage.NewMemoryStorage(), rec: rec}},
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	drainErr := errors.New("drain failed")
+	err := shutdown(ctx, &fakeServer{rec: rec, err: drainErr}, func() {}, chains, logger)
+	if !errors.Is(err, drainErr) {
+		t.Errorf("Expected drain error to be reported, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline error for stuck poller, got %v", err)
+	}
+	// Storage is still flushed even though the pollers timed out.
+	if rec.steps[len(rec.steps)-1] != "flush" {
+		t.Errorf("Expected flush to run, got steps %v", rec.steps)
+	}
+}
+
+func TestShutdown_StuckSinks(t *testing.T) {
+	rec := &recorder{}
+	chains := []*Chain{
+		{Name: "ethereum", poller: &fakePoller{rec: rec}, Storage: &flushingStorage{Storage: storage.NewMemoryStorage(), rec: rec}, sinksDone: make(chan struct{})},
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := shutdown(ctx, &fakeServer{rec: rec}, func() {}, chains, logger)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline error for stuck sinks, got %v", err)
+	}
+	if rec.steps[len(rec.steps)-1] != "flush" {
+		t.Errorf("Expected flush to run, got steps %v", rec.steps)
+	}
+}
+
+// fakeNode is a node at block 0x10 whose blocks have no transactions. It
+// counts the blocks fetched in fetched.
+func fakeNode(t *testing.T, fetched *atomic.Int32) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     int           `json:"id"`
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var result interface{} = "0x10"
+		if req.Method == "eth_getBlockByNumber" {
+			fetched.Add(1)
+			result = map[string]interface{}{"number": req.Params[0], "hash": "0xblock", "transactions": []interface{}{}}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestApp_Run(t *testing.T) {
+	var fetched atomic.Int32
+	cfg := DefaultConfig()
+	cfg.ListenAddr = "" // served through Handler
+	cfg.MetricsBackend = ""
+	cfg.Chains[0].RPCURL = fakeNode(t, &fetched).URL
+	cfg.Chains[0].PollInterval = 10 * time.Millisecond
+	cfg.Chains[0].BackwardScanEnabled = false
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	chain, ok := a.Chain("ethereum")
+	if !ok || chain != a.Chains()[0] {
+		t.Fatalf("Expected the ethereum chain, got %v", a.Chains())
+	}
+	if fetched.Load() != 0 {
+		t.Error("Expected New not to start polling")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- a.Run(ctx) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for fetched.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the chain to be polled")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	api := httptest.NewServer(a.Handler())
+	defer api.Close()
+	resp, err := http.Post(api.URL+"/v1/subscribe", "application/json", strings.NewReader(`{"address":"0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if !chain.Storage.IsSubscribed("0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6") {
+		t.Error("Expected the API to serve the chain")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Expected a clean shutdown, got %v", err)
+	}
+	if err := a.Run(context.Background()); err == nil {
+		t.Error("Expected an App to run once")
+	}
+}
+
+func TestNew_InvalidConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShardIndex = 2
+	if _, err := New(cfg); err == nil {
+		t.Error("Expected a shard index past the shard count to be rejected")
+	}
+}
+
+func TestApp_Dump(t *testing.T) {
+	var fetched atomic.Int32
+	cfg := DefaultConfig()
+	cfg.MetricsBackend = ""
+	cfg.DumpDir = t.TempDir()
+	cfg.Chains[0].RPCURL = fakeNode(t, &fetched).URL
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	store := a.Chains()[0].Storage
+	for _, addr := range []string{"0xaaa", "0xbbb", "0xccc"} {
+		store.Subscribe(addr)
+	}
+	store.AddTransaction("0xbbb", transaction.Transaction{Hash: "0x1"})
+	store.AddTransaction("0xbbb", transaction.Transaction{Hash: "0x2"})
+	store.AddTransaction("0xccc", transaction.Transaction{Hash: "0x3"})
+
+	path, err := a.Dump()
+	if err != nil {
+		t.Fatalf("Dump failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		t.Fatalf("Expected a JSON snapshot, got %s: %v", data, err)
+	}
+	if snap.Goroutines == 0 || snap.Leader != nil || len(snap.Chains) != 1 {
+		t.Fatalf("Unexpected snapshot %+v", snap)
+	}
+	chain := snap.Chains[0]
+	if chain.Name != "ethereum" || chain.Subscriptions != 3 {
+		t.Errorf("Unexpected chain %+v", chain)
+	}
+	if len(chain.Addresses) != 3 || chain.Addresses[0].Address != "0xbbb" || chain.Addresses[0].Transactions != 2 || chain.Addresses[2].Address != "0xaaa" {
+		t.Errorf("Expected the addresses largest first, got %+v", chain.Addresses)
+	}
+}
+
+type countingLock struct {
+	unlocks atomic.Int32
+}
+
+func (l *countingLock) TryLock() (bool, error) { return true, nil }
+
+func (l *countingLock) Unlock() error {
+	l.unlocks.Add(1)
+	return nil
+}
+
+// failingPoller stops polling right after it starts, as when the node
+// can't be reached.
+type failingPoller struct {
+	starts, stops atomic.Int32
+	done          chan struct{}
+}
+
+func (f *failingPoller) Start(ctx context.Context) {
+	f.starts.Add(1)
+	f.done = make(chan struct{})
+	close(f.done)
+}
+
+func (f *failingPoller) Stop() { f.stops.Add(1) }
+
+func (f *failingPoller) PollDone() <-chan struct{} { return f.done }
+
+func TestLead_HandsOverWhenPollingStops(t *testing.T) {
+	lock := &countingLock{}
+	e := leader.New(lock, leader.Options{RetryInterval: time.Millisecond})
+	failing, healthy := &failingPoller{}, &fakePoller{rec: &recorder{}}
+	ctx, cancel := context.WithCancel(context.Background())
+	finished := make(chan struct{})
+	go func() {
+		lead(ctx, e, []parser.Poller{failing, healthy}, time.Millisecond, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		close(finished)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for failing.starts.Load() < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected this instance to campaign again after handing over")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-finished
+	if lock.unlocks.Load() == 0 {
+		t.Error("Expected the leader lock to be released when polling stopped")
+	}
+	if failing.stops.Load() == 0 || len(healthy.rec.steps) == 0 {
+		t.Error("Expected every poller to be stopped before handing over")
+	}
+}
+
+func TestLagHandler_AlertsInOrder(t *testing.T) {
+	statuses := make(chan string, 4)
+	var first atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !first.Swap(true) {
+			// A slow first alert must not let the recovery overtake it.
+			time.Sleep(50 * time.Millisecond)
+		}
+		var body struct {
+			Status string `json:"status"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		statuses <- body.Status
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	onLag, err := newLagHandler(ctx, config.Config{MaxBlockLag: 10, LagAlertURL: srv.URL}, "ethereum")
+	if err != nil {
+		t.Fatalf("newLagHandler failed: %v", err)
+	}
+	onLag(parser.LagStatus{Lagging: true, Lag: 20, MaxLag: 10})
+	onLag(parser.LagStatus{Lagging: false, Lag: 0, MaxLag: 10})
+
+	for _, want := range []string{"lagging", "recovered"} {
+		select {
+		case got := <-statuses:
+			if got != want {
+				t.Errorf("Expected %s alert, got %s", want, got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expected %s alert", want)
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+
+	"github.com/danieloluwadare/tw-txparser/internal/config"
+	"github.com/danieloluwadare/tw-txparser/internal/deadletter"
+	"github.com/danieloluwadare/tw-txparser/internal/expiry"
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/internal/notify"
+	"github.com/danieloluwadare/tw-txparser/internal/outbox"
+	"github.com/danieloluwadare/tw-txparser/internal/server"
+	"github.com/danieloluwadare/tw-txparser/internal/storage"
+	"github.com/danieloluwadare/tw-txparser/internal/webhook"
+	"github.com/danieloluwadare/tw-txparser/pkg/abi"
+	"github.com/danieloluwadare/tw-txparser/pkg/ens"
+	"github.com/danieloluwadare/tw-txparser/pkg/etherscan"
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+	"github.com/danieloluwadare/tw-txparser/pkg/tokens"
+)
+
+// Chain is one indexed chain of an App.
+type Chain struct {
+	Name string
+	// Parser tracks the chain's subscriptions and serves its transactions.
+	Parser parser.Parser
+	// Storage holds what Parser indexed.
+	Storage storage.Storage
+
+	cfg    Config
+	rpcURL string
+	client rpc.RPCClient
+	poller parser.Poller
+	hooks  *webhook.Registry
+	// targets restricts the addresses webhooks are delivered to.
+	targets *webhook.Targets
+	sinks   *notify.Dispatcher // nil without sinks
+	// outbox records the chain's deliveries; disabled unless configured.
+	outbox outbox.ChainOutbox
+	// ws streams live token transfers; nil unless configured.
+	ws  *rpc.WSClient
+	ens server.NameResolver // nil unless ENS resolution is enabled
+	// expiry removes subscriptions created with a TTL; it is shared by the
+	// chain's scoped and unscoped routes.
+	expiry *expiry.Scheduler
+	// watched lists the addresses subscribed for configured sinks and
+	// webhooks, which API key holders must not be able to unsubscribe.
+	watched []string
+	// sinksDone is closed once the sinks have drained; nil without sinks.
+	sinksDone chan struct{}
+	// providers reports the health of the chain's RPC providers; nil
+	// unless it has several.
+	providers func() []rpc.ProviderStatus
+	logger    *slog.Logger
+}
+
+// newChain wires the parser for a single chain, along with the webhook
+// registry and any configured sinks. Nothing runs until start. Lag alerts
+// are sent with ctx. The history of new subscriptions is backfilled from es
+// unless it is nil. Webhooks are only delivered to addresses targets allows.
+func newChain(ctx context.Context, cfg Config, file config.File, ch config.ChainConfig, decoder abi.Registry, deadLetters *deadletter.Queue, box *outbox.Outbox, es *etherscan.Client, targets *webhook.Targets, rec metrics.Recorder, logger *slog.Logger) (*Chain, error) {
+	rec = metrics.With(rec, metrics.L("chain", ch.Name))
+	client, err := newChainClient(cfg, ch, rec)
+	if err != nil {
+		return nil, err
+	}
+
+	// In-memory storage
+	store := storage.NewMemoryStorageWithOptions(storage.MemoryOptions{Metrics: rec, MaxTransactionsPerAddress: cfg.MaxTransactionsPerAddress})
+
+	onLag, err := newLagHandler(ctx, cfg, ch.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata parser.TokenMetadata
+	var tokenBalances parser.TokenBalances
+	var ws *rpc.WSClient
+	var liveLogs rpc.LogSubscriber
+	if cfg.IndexTokens {
+		resolver := tokens.New(client, tokens.Options{CacheTTL: cfg.TokenMetadataTTL})
+		metadata, tokenBalances = resolver, resolver
+		if ch.WSURL != "" {
+			ws = rpc.NewWSClient(ch.WSURL)
+			liveLogs = ws
+		}
+	}
+
+	var history parser.HistoryProvider
+	if es != nil {
+		history = es.ForChain(ch.ChainID)
+	}
+
+	// Parser with options
+	p := parser.NewParserWithInterval(client, store, ch.PollInterval, parser.Options{
+		BackwardScanEnabled: ch.BackwardScanEnabled,
+		BackwardScanDepth:   ch.BackwardScanDepth,
+		Logger:              logging.Component("parser").With("chain", ch.Name),
+		Metrics:             rec,
+		MaxLag:              cfg.MaxBlockLag,
+		OnLag:               onLag,
+		FetchReceipts:       cfg.FetchReceipts,
+		EnrichReceipts:      cfg.EnrichReceipts,
+		ReceiptWorkers:      cfg.ReceiptWorkers,
+		ReceiptBatchSize:    cfg.ReceiptBatchSize,
+		TrackBalances:       cfg.TrackBalances,
+		BlockCacheSize:      cfg.BlockCacheSize,
+		ReorgDepth:          cfg.ReorgDepth,
+		ActivitySize:        cfg.ActivityFeedSize,
+		CatchUpWorkers:      cfg.CatchUpWorkers,
+		CatchUpThreshold:    cfg.CatchUpThreshold,
+		CatchUpQueueSize:    cfg.CatchUpQueueSize,
+		Shards:              cfg.ShardCount,
+		Shard:               cfg.ShardIndex,
+		Ignore:              append(append([]string(nil), cfg.IgnoreAddresses...), file.Ignore...),
+		SkipZeroValueCalls:  cfg.SkipZeroValueCalls,
+		DryRun:              cfg.DryRun,
+		ABI:                 decoder,
+		StoreInput:          cfg.StoreInput,
+		MaxInputBytes:       cfg.MaxInputBytes,
+		IndexTokens:         cfg.IndexTokens,
+		LiveLogs:            liveLogs,
+		TokenMetadata:       metadata,
+		TokenBalances:       tokenBalances,
+		GasCacheTTL:         cfg.GasCacheTTL,
+		BlockRetries:        cfg.BlockRetries,
+		BlockTimeout:        cfg.BlockTimeout,
+		DeadLetters:         deadLetters.Chain(ch.Name),
+		History:             history,
+	})
+
+	// Cast parserImpl back to Poller
+	poller, ok := p.(parser.Poller)
+	if !ok {
+		return nil, errors.New("parser does not implement Poller")
+	}
+
+	// Deliver matched transactions to registered webhooks
+	hooks := webhook.NewRegistry()
+	var pinned []string
+	for _, sc := range file.Sinks {
+		if sc.Type != config.SinkWebhook || !sc.AppliesTo(ch.Name) {
+			continue
+		}
+		if u, err := url.Parse(sc.URL); err == nil {
+			if err := targets.CheckHost(ctx, u.Hostname()); err != nil {
+				return nil, fmt.Errorf("webhook %s: %w", sc.Name, err)
+			}
+		}
+		if _, err := hooks.Register("", sc.URL, sc.Secret, sc.Filter.Addresses); err != nil {
+			return nil, fmt.Errorf("failed to register webhook %s: %w", sc.Name, err)
+		}
+		for _, addr := range sc.Filter.Addresses {
+			p.Subscribe(addr)
+		}
+		pinned = append(pinned, sc.Filter.Addresses...)
+	}
+
+	// Fan matched transactions out to the configured notification sinks
+	sinks, watched, err := newSinks(cfg, file, ch.Name)
+	if err != nil {
+		return nil, err
+	}
+	for _, addr := range watched {
+		p.Subscribe(addr)
+	}
+	c := &Chain{
+		Name:    ch.Name,
+		Parser:  p,
+		Storage: store,
+		cfg:     cfg,
+		rpcURL:  ch.RPCURL,
+		client:  client,
+		poller:  poller,
+		hooks:   hooks,
+		targets: targets,
+		outbox:  box.Chain(ch.Name),
+		ws:      ws,
+		expiry:  expiry.New(),
+		watched: append(pinned, watched...),
+		logger:  logger.With("chain", ch.Name),
+	}
+	if balancer, ok := client.(*rpc.Balancer); ok {
+		c.providers = balancer.Providers
+	}
+	if cfg.ENSResolution {
+		c.ens = ens.New(client, ens.Options{CacheTTL: cfg.ENSCacheTTL})
+	}
+	if sinks.Len() > 0 {
+		sinks.UseOutbox(c.outbox)
+		c.sinks = sinks
+		c.sinksDone = make(chan struct{})
+	}
+	return c, nil
+}
+
+// start runs the chain's deliveries and housekeeping until ctx is
+// cancelled, and starts polling if poll is set; otherwise the instance has
+// to be elected first.
+func (c *Chain) start(ctx context.Context, poll bool) {
+	c.logger.Info("starting chain", "rpc_url", c.rpcURL)
+	if c.ws != nil {
+		go func() {
+			<-ctx.Done()
+			c.ws.Close()
+		}()
+	}
+	if balancer, ok := c.client.(*rpc.Balancer); ok {
+		balancer.Start(ctx)
+	}
+	go webhook.NewDispatcherWithOptions(c.hooks, webhook.DispatcherOptions{Outbox: c.outbox, Targets: c.targets}).Run(ctx, c.deliveries(ctx))
+	if c.sinks != nil {
+		go func() {
+			defer close(c.sinksDone)
+			c.sinks.Run(ctx, c.deliveries(ctx))
+		}()
+	}
+
+	if poll {
+		c.poller.Start(ctx)
+	}
+	go reportDryRun(ctx, c.Parser, c.logger, dryRunReportInterval)
+	go compactStorage(ctx, c.Storage, c.logger, c.cfg.CompactionInterval)
+	go collectGarbage(ctx, c.Storage, c.logger, c.cfg.UnsubscribedRetention, c.cfg.GCInterval)
+}
+
+// deliveries streams the chain's events to a delivery dispatcher. With an
+// outbox, polling waits for dispatchers that fall behind rather than have
+// them miss events.
+func (c *Chain) deliveries(ctx context.Context) <-chan parser.Event {
+	if f, ok := c.Parser.(parser.Follower); ok && c.outbox.Enabled() {
+		return f.Follow(ctx)
+	}
+	return c.Parser.Watch(ctx)
+}
+
+// rpcTransport returns the HTTP transport settings of RPC clients.
+func rpcTransport(cfg config.Config) rpc.Transport {
+	keepAlive := cfg.RPCKeepAlive
+	if keepAlive == 0 {
+		keepAlive = -1 // disabled rather than defaulted
+	}
+	return rpc.Transport{
+		MaxIdleConnsPerHost: cfg.RPCMaxIdleConnsPerHost,
+		KeepAlive:           keepAlive,
+		DialTimeout:         cfg.RPCDialTimeout,
+		TLSHandshakeTimeout: cfg.RPCTLSHandshakeTimeout,
+		DisableHTTP2:        !cfg.RPCHTTP2,
+	}
+}
+
+// newChainClient returns the RPC client of ch: one for its endpoint or, in
+// replay mode, one serving the recorded blocks of the chain.
+func newChainClient(cfg config.Config, ch config.ChainConfig, rec metrics.Recorder) (rpc.RPCClient, error) {
+	if cfg.ReplayDir == "" {
+		endpoints, err := rpc.ParseEndpoints(ch.RPCURL)
+		if err != nil {
+			return nil, fmt.Errorf("chain %s: %w", ch.Name, err)
+		}
+		if len(endpoints) == 1 {
+			return rpc.NewClientWithOptions(endpoints[0].URL, rpc.ClientOptions{Metrics: rec, Transport: rpcTransport(cfg)}), nil
+		}
+		balancer, err := rpc.NewBalancer(endpoints, rpc.BalancerOptions{
+			Strategy:       rpc.Strategy(cfg.RPCStrategy),
+			Metrics:        rec,
+			HealthInterval: cfg.RPCHealthInterval,
+			MaxLag:         cfg.RPCMaxLag,
+			Transport:      rpcTransport(cfg),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("chain %s: %w", ch.Name, err)
+		}
+		return balancer, nil
+	}
+	dir := cfg.ReplayDir
+	if len(cfg.Chains) > 1 {
+		dir = filepath.Join(dir, ch.Name)
+	}
+	replay, err := rpc.NewReplay(dir)
+	if err != nil {
+		return nil, fmt.Errorf("chain %s: %w", ch.Name, err)
+	}
+	return replay, nil
+}
+
+// lagAlertQueueSize bounds the lag alerts waiting to be posted. Alerts
+// alternate between lagging and recovered, so only a dead endpoint fills it.
+const lagAlertQueueSize = 16
+
+// newLagHandler returns the parser's OnLag callback for chain. Lag changes
+// are logged and, with LagAlertURL set, posted in order by a single
+// background goroutine until ctx is cancelled, so that a slow alert endpoint
+// never delays polling nor reorders a recovery before its alert.
+func newLagHandler(ctx context.Context, cfg config.Config, chain string) (func(parser.LagStatus), error) {
+	if cfg.MaxBlockLag <= 0 {
+		return nil, nil
+	}
+	logger := logging.Component("lag").With("chain", chain)
+	var alerts chan parser.LagStatus
+	if cfg.LagAlertURL != "" {
+		alerter, err := notify.NewLagAlerter(cfg.LagAlertURL, chain)
+		if err != nil {
+			return nil, err
+		}
+		alerts = make(chan parser.LagStatus, lagAlertQueueSize)
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case s := <-alerts:
+					if err := alerter.Alert(ctx, s); err != nil {
+						logger.Error("failed to send lag alert", logging.KeyError, err)
+					}
+				}
+			}
+		}()
+	}
+	return func(s parser.LagStatus) {
+		if s.Lagging {
+			logger.Warn("block lag exceeds threshold", "lag", s.Lag, "max_lag", s.MaxLag, "head", s.Head, logging.KeyBlock, s.Block)
+		} else {
+			logger.Info("block lag recovered", "lag", s.Lag, "max_lag", s.MaxLag, "head", s.Head, logging.KeyBlock, s.Block)
+		}
+		if alerts == nil {
+			return
+		}
+		select {
+		case alerts <- s:
+		default:
+			logger.Error("lag alert queue full, dropping alert", "lagging", s.Lagging)
+		}
+	}, nil
+}
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/storage"
+)
+
+// compactStorage compacts store every interval until ctx is cancelled,
+// logging what each pass reclaimed. It returns immediately if interval is
+// 0 or store can't be compacted.
+func compactStorage(ctx context.Context, store storage.Storage, logger *slog.Logger, interval time.Duration) {
+	c, ok := store.(storage.Compactor)
+	if !ok || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			start := time.Now()
+			s := c.Compact()
+			if s.Lists == 0 {
+				continue
+			}
+			logger.Info("storage compacted",
+				"lists", s.Lists,
+				"duplicates", s.Duplicates,
+				"reclaimed_bytes", s.ReclaimedBytes,
+				"duration", time.Since(start),
+			)
+		}
+	}
+}
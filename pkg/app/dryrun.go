@@ -0,0 +1,51 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+)
+
+// dryRunReportInterval is how often an App logs the stats of parsers in
+// dry-run mode.
+const dryRunReportInterval = time.Minute
+
+// reportDryRun logs p's dry-run stats every interval, and once more when ctx
+// is cancelled. It returns immediately unless p is in dry-run mode.
+func reportDryRun(ctx context.Context, p parser.Parser, logger *slog.Logger, interval time.Duration) {
+	runner, ok := p.(parser.DryRunner)
+	if !ok {
+		return
+	}
+	if _, ok := runner.DryRunStats(); !ok {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			logDryRunStats(runner, logger, "dry run finished")
+			return
+		case <-ticker.C:
+			logDryRunStats(runner, logger, "dry run stats")
+		}
+	}
+}
+
+// logDryRunStats logs runner's current stats with msg.
+func logDryRunStats(runner parser.DryRunner, logger *slog.Logger, msg string) {
+	s, _ := runner.DryRunStats()
+	logger.Info(msg,
+		"blocks", s.Blocks,
+		"failed_blocks", s.FailedBlocks,
+		"transactions", s.Transactions,
+		"ignored", s.Ignored,
+		"records", s.Records,
+		"matched", s.Matched,
+		"blocks_per_second", s.BlocksPerSecond(),
+		"elapsed", s.Elapsed.Round(time.Second),
+	)
+}
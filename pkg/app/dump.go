@@ -0,0 +1,125 @@
+package app
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/storage"
+	"github.com/danieloluwadare/tw-txparser/internal/version"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+)
+
+// maxDumpAddresses caps the addresses listed per chain in a Snapshot.
+const maxDumpAddresses = 100
+
+// Snapshot is a diagnostic view of a running App, for debugging wedged
+// instances offline.
+type Snapshot struct {
+	Time       time.Time `json:"time"`
+	Version    string    `json:"version"`
+	Goroutines int       `json:"goroutines"`
+	// Leader reports whether this instance is the elected leader; nil
+	// without leader election.
+	Leader *bool           `json:"leader,omitempty"`
+	Chains []ChainSnapshot `json:"chains"`
+}
+
+// ChainSnapshot is the diagnostic view of one chain.
+type ChainSnapshot struct {
+	Name          string        `json:"name"`
+	Status        parser.Status `json:"status"`
+	Subscriptions int           `json:"subscriptions"`
+	// Addresses lists the subscribed addresses storing the most records,
+	// largest first, up to 100. It is left out unless the storage can count
+	// records.
+	Addresses   []AddressSize `json:"addresses,omitempty"`
+	DeadLetters int           `json:"dead_letters"`
+	// Outbox counts the deliveries not settled yet when OUTBOX_FILE is set.
+	Outbox int `json:"outbox,omitempty"`
+	// Providers reports the health of the chain's RPC providers when it has
+	// several.
+	Providers []rpc.ProviderStatus `json:"providers,omitempty"`
+}
+
+// AddressSize is the number of records stored for an address.
+type AddressSize struct {
+	Address        string `json:"address"`
+	Transactions   int    `json:"transactions"`
+	TokenTransfers int    `json:"token_transfers"`
+}
+
+// Snapshot returns the current diagnostic view of the App.
+func (a *App) Snapshot() Snapshot {
+	snap := Snapshot{
+		Time:       time.Now().UTC(),
+		Version:    version.Get().Version,
+		Goroutines: runtime.NumGoroutine(),
+	}
+	if a.elector != nil {
+		leader := a.elector.IsLeader()
+		snap.Leader = &leader
+	}
+	for _, c := range a.chains {
+		cs := ChainSnapshot{Name: c.Name, DeadLetters: len(a.deadLetters.List(c.Name))}
+		if sr, ok := c.Parser.(parser.StatusReporter); ok {
+			cs.Status = sr.Status()
+		} else {
+			cs.Status.Block = c.Parser.GetCurrentBlock()
+		}
+		if lister, ok := c.Storage.(storage.Lister); ok {
+			addrs := lister.Subscriptions()
+			cs.Subscriptions = len(addrs)
+			if counter, ok := c.Storage.(storage.Counter); ok {
+				cs.Addresses = addressSizes(counter, addrs)
+			}
+		}
+		cs.Outbox = len(c.outbox.Pending())
+		if c.providers != nil {
+			cs.Providers = c.providers()
+		}
+		snap.Chains = append(snap.Chains, cs)
+	}
+	return snap
+}
+
+// addressSizes returns the sizes of the largest of addrs in store. Records
+// are counted rather than read, so a dump of a busy instance neither copies
+// them nor holds up the poller for long.
+func addressSizes(store storage.Counter, addrs []string) []AddressSize {
+	sizes := make([]AddressSize, len(addrs))
+	for i, addr := range addrs {
+		txs, tokens := store.Counts(addr)
+		sizes[i] = AddressSize{Address: addr, Transactions: txs, TokenTransfers: tokens}
+	}
+	slices.SortStableFunc(sizes, func(x, y AddressSize) int {
+		return cmp.Compare(y.Transactions+y.TokenTransfers, x.Transactions+x.TokenTransfers)
+	})
+	return sizes[:min(len(sizes), maxDumpAddresses)]
+}
+
+// Dump writes a Snapshot to a timestamped JSON file in cfg.DumpDir, or
+// logs it if that is empty. It returns the file written, if any.
+func (a *App) Dump() (string, error) {
+	snap := a.Snapshot()
+	if a.cfg.DumpDir == "" {
+		a.logger.Info("state dump", "snapshot", snap)
+		return "", nil
+	}
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode state dump: %w", err)
+	}
+	path := filepath.Join(a.cfg.DumpDir, "txparser-dump-"+snap.Time.Format("20060102T150405.000Z")+".json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write state dump: %w", err)
+	}
+	a.logger.Info("state dumped", "path", path)
+	return path, nil
+}
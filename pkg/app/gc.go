@@ -0,0 +1,31 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/storage"
+)
+
+// collectGarbage drops the records of never-subscribed addresses idle for
+// longer than retention every interval until ctx is cancelled. It returns
+// immediately if retention is 0 or store can't collect garbage.
+func collectGarbage(ctx context.Context, store storage.Storage, logger *slog.Logger, retention, interval time.Duration) {
+	c, ok := store.(storage.Collector)
+	if !ok || retention <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if addrs, records := c.CollectGarbage(retention); addrs > 0 {
+				logger.Info("collected unsubscribed addresses", "addresses", addrs, "records", records)
+			}
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package app
+
+import (
+	"github.com/danieloluwadare/tw-txparser/internal/config"
+	"github.com/danieloluwadare/tw-txparser/internal/leader"
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
+)
+
+// newElector returns the leader elector campaigning for cfg.LeaderLockFile,
+// or nil when leader election is disabled and this instance always polls.
+func newElector(cfg config.Config, rec metrics.Recorder) (*leader.Elector, error) {
+	if cfg.LeaderLockFile == "" {
+		return nil, nil
+	}
+	lock, err := leader.NewFileLock(cfg.LeaderLockFile)
+	if err != nil {
+		return nil, err
+	}
+	return leader.New(lock, leader.Options{RetryInterval: cfg.LeaderRetryInterval, Metrics: rec}), nil
+}
@@ -0,0 +1,208 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/config"
+	"github.com/danieloluwadare/tw-txparser/internal/notify"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// newSinks builds a dispatcher with every notification sink configured in cfg
+// and file. It also returns the addresses the sinks need subscribed so that
+// their activity is indexed.
+func newSinks(cfg config.Config, file config.File, chain string) (*notify.Dispatcher, []string, error) {
+	d := notify.NewDispatcher()
+	var watched []string
+
+	// Publish to NATS when configured
+	if cfg.NATSURL != "" {
+		pub, err := notify.NewNATSPublisher(notify.NATSOptions{
+			URL:           cfg.NATSURL,
+			Chain:         chain,
+			SubjectPrefix: cfg.NATSSubjectPrefix,
+			JetStream:     cfg.NATSJetStream,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		d.Add("nats", pub)
+	}
+
+	// Publish to an MQTT broker when configured
+	if cfg.MQTTURL != "" {
+		pub, err := notify.NewMQTTPublisher(notify.MQTTOptions{
+			URL:      cfg.MQTTURL,
+			Chain:    chain,
+			Topic:    cfg.MQTTTopic,
+			QoS:      byte(cfg.MQTTQoS),
+			Username: cfg.MQTTUsername,
+			Password: cfg.MQTTPassword,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		d.Add("mqtt", pub)
+	}
+
+	// Insert transactions into ClickHouse when configured
+	if cfg.ClickHouseURL != "" {
+		w, err := notify.NewClickHouseWriter(notify.ClickHouseOptions{
+			URL:           cfg.ClickHouseURL,
+			Table:         cfg.ClickHouseTable,
+			Username:      cfg.ClickHouseUsername,
+			Password:      cfg.ClickHousePassword,
+			Chain:         chain,
+			BatchSize:     cfg.ClickHouseBatchSize,
+			FlushInterval: cfg.ClickHouseFlushInterval,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		d.Add("clickhouse", w)
+	}
+
+	// Post large inbound transfers to Slack/Discord when configured
+	if cfg.ChatWebhookURL != "" {
+		minValue, err := transaction.ParseEther(cfg.ChatMinValue)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid CHAT_MIN_VALUE: %w", err)
+		}
+		chat, err := notify.NewChatNotifier(notify.ChatOptions{
+			WebhookURL: cfg.ChatWebhookURL,
+			Chain:      chain,
+			MinValue:   minValue,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		d.Add("chat", chat)
+	}
+
+	// Email address activity when SMTP and recipients are configured
+	if cfg.SMTPHost != "" && cfg.EmailRecipients != "" {
+		email, err := newEmailNotifier(cfg, chain)
+		if err != nil {
+			return nil, nil, err
+		}
+		for addr := range email.Recipients() {
+			if addr != notify.AnyAddress {
+				watched = append(watched, addr)
+			}
+		}
+		d.Add("email", email)
+	}
+
+	// Sinks declared in the config file; webhooks are registered separately
+	for _, sc := range file.Sinks {
+		if sc.Type == config.SinkWebhook || !sc.AppliesTo(chain) {
+			continue
+		}
+		n, err := newFileSink(sc, chain)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create sink %s: %w", sc.Name, err)
+		}
+		filter := notify.Filter{Addresses: sc.Filter.Addresses, Direction: transaction.Direction(sc.Filter.Direction)}
+		if sc.Filter.MinValue != "" {
+			if filter.MinValue, err = transaction.ParseEther(sc.Filter.MinValue); err != nil {
+				return nil, nil, fmt.Errorf("invalid min_value for sink %s: %w", sc.Name, err)
+			}
+		}
+		watched = append(watched, sc.Filter.Addresses...)
+		for addr := range sc.Recipients {
+			if addr != notify.AnyAddress {
+				watched = append(watched, addr)
+			}
+		}
+		d.Add(sc.Name, notify.WithFilter(n, filter))
+	}
+	return d, watched, nil
+}
+
+// newFileSink creates the notifier for a sink declared in the config file.
+func newFileSink(sc config.SinkConfig, chain string) (notify.Notifier, error) {
+	switch sc.Type {
+	case config.SinkNATS:
+		return notify.NewNATSPublisher(notify.NATSOptions{
+			URL:           sc.URL,
+			Chain:         chain,
+			SubjectPrefix: sc.SubjectPrefix,
+			JetStream:     sc.JetStream,
+		})
+	case config.SinkMQTT:
+		qos := 1
+		if sc.QoS != nil {
+			qos = *sc.QoS
+		}
+		return notify.NewMQTTPublisher(notify.MQTTOptions{
+			URL:      sc.URL,
+			Chain:    chain,
+			Topic:    sc.Topic,
+			QoS:      byte(qos),
+			Username: sc.Username,
+			Password: sc.Password,
+		})
+	case config.SinkChat:
+		return notify.NewChatNotifier(notify.ChatOptions{WebhookURL: sc.URL, Chain: chain})
+	case config.SinkClickHouse:
+		// The interval was validated when the file was loaded.
+		interval, _ := time.ParseDuration(sc.FlushInterval)
+		return notify.NewClickHouseWriter(notify.ClickHouseOptions{
+			URL:           sc.URL,
+			Table:         sc.Table,
+			Username:      sc.Username,
+			Password:      sc.Password,
+			Chain:         chain,
+			BatchSize:     sc.BatchSize,
+			FlushInterval: interval,
+		})
+	case config.SinkEmail:
+		var tmpl string
+		if sc.Template != "" {
+			var err error
+			if tmpl, err = notify.LoadTemplate(sc.Template); err != nil {
+				return nil, err
+			}
+		}
+		// The window was validated when the file was loaded.
+		window, _ := time.ParseDuration(sc.BatchWindow)
+		return notify.NewEmailNotifier(notify.EmailOptions{
+			Host:        sc.Host,
+			Port:        sc.Port,
+			Username:    sc.Username,
+			Password:    sc.Password,
+			From:        sc.From,
+			Recipients:  sc.Recipients,
+			Chain:       chain,
+			BatchWindow: window,
+			Template:    tmpl,
+		})
+	}
+	return nil, fmt.Errorf("unsupported sink type %q", sc.Type)
+}
+
+// newEmailNotifier builds the email notifier for a chain from cfg.
+func newEmailNotifier(cfg config.Config, chain string) (*notify.EmailNotifier, error) {
+	recipients, err := notify.ParseRecipients(cfg.EmailRecipients)
+	if err != nil {
+		return nil, fmt.Errorf("invalid EMAIL_RECIPIENTS: %w", err)
+	}
+	var tmpl string
+	if cfg.EmailTemplate != "" {
+		if tmpl, err = notify.LoadTemplate(cfg.EmailTemplate); err != nil {
+			return nil, err
+		}
+	}
+	return notify.NewEmailNotifier(notify.EmailOptions{
+		Host:        cfg.SMTPHost,
+		Port:        cfg.SMTPPort,
+		Username:    cfg.SMTPUsername,
+		Password:    cfg.SMTPPassword,
+		From:        cfg.EmailFrom,
+		Recipients:  recipients,
+		Chain:       chain,
+		BatchWindow: cfg.EmailBatchWindow,
+		Template:    tmpl,
+	})
+}
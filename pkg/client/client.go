@@ -0,0 +1,248 @@
+// Package client is a Go client for the txparser HTTP API, so programs
+// don't have to hand-roll requests against it. Failed requests are retried
+// with backoff where retrying is safe, and paginated listings can be
+// iterated as a whole.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+)
+
+// apiKeyHeader carries the API key of requests.
+const apiKeyHeader = "X-API-Key"
+
+// Options configures a Client.
+type Options struct {
+	// HTTPClient sends the requests. Defaults to a client with a 30s
+	// timeout; streams started by Watch are exempt from it.
+	HTTPClient *http.Client
+	// APIKey authenticates requests to servers with API keys enabled.
+	APIKey string
+	// Chain scopes requests to one chain of a multi-chain server, e.g.
+	// "base-sepolia". The server's default chain is used when empty.
+	Chain string
+	// Retries is how many more times a request is tried after a network
+	// error or a 429, 502, 503 or 504 response. Defaults to 3; negative
+	// disables retries.
+	Retries int
+	// RetryBackoff is the wait before the first retry, doubling with each
+	// further one unless the server asks for longer with Retry-After.
+	// Defaults to 500ms.
+	RetryBackoff time.Duration
+}
+
+// Client calls the txparser HTTP API. It is safe for concurrent use.
+type Client struct {
+	base *url.URL // including the /v1 and chain prefix
+	http *http.Client
+	sse  *http.Client // http without its timeout, for Watch
+	opts Options
+}
+
+// New creates a Client of the server at baseURL, e.g.
+// "http://localhost:8080".
+func New(baseURL string, opts Options) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q", baseURL)
+	}
+	u = u.JoinPath("v1")
+	if opts.Chain != "" {
+		u = u.JoinPath(opts.Chain)
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	if opts.Retries == 0 {
+		opts.Retries = 3
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = 500 * time.Millisecond
+	}
+	sse := *opts.HTTPClient
+	sse.Timeout = 0
+	return &Client{base: u, http: opts.HTTPClient, sse: &sse, opts: opts}, nil
+}
+
+// StatusError is returned for requests the server answered with an error
+// status.
+type StatusError struct {
+	StatusCode int
+	// Message is the server's error message.
+	Message string
+}
+
+// Error satisfies the error interface.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("txparser API responded %d: %s", e.StatusCode, e.Message)
+}
+
+// retryable reports whether a request answered with status may succeed if
+// tried again.
+func retryable(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// SubscribeOptions are optional settings of a subscription.
+type SubscribeOptions struct {
+	// TTL unsubscribes the address once it elapses, and PurgeOnExpiry then
+	// also deletes its stored data. 0 makes the subscription permanent.
+	TTL           time.Duration
+	PurgeOnExpiry bool
+	// CallbackURL registers a webhook receiving the address's
+	// transactions, signed with CallbackSecret if set.
+	CallbackURL    string
+	CallbackSecret string
+}
+
+// Subscription is the outcome of subscribing an address.
+type Subscription struct {
+	// Subscribed is false if the address was subscribed already.
+	Subscribed bool `json:"subscribed"`
+	// ExpiresAt is when a subscription with a TTL ends.
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+	// Webhook is the callback webhook, if one was registered.
+	Webhook *Webhook `json:"webhook,omitempty"`
+}
+
+// Webhook is a registered callback webhook.
+type Webhook struct {
+	ID        string   `json:"id"`
+	URL       string   `json:"url"`
+	Addresses []string `json:"addresses,omitempty"`
+	// Secret signs deliveries with HMAC-SHA256.
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Subscribe starts tracking address.
+func (c *Client) Subscribe(ctx context.Context, address string, opts SubscribeOptions) (Subscription, error) {
+	body := map[string]interface{}{"address": address}
+	if opts.TTL > 0 {
+		body["ttl"] = opts.TTL.String()
+		body["purge_on_expiry"] = opts.PurgeOnExpiry
+	}
+	if opts.CallbackURL != "" {
+		body["callback_url"] = opts.CallbackURL
+		body["callback_secret"] = opts.CallbackSecret
+	}
+	var sub Subscription
+	_, err := c.do(ctx, http.MethodPost, "subscribe", nil, body, &sub)
+	return sub, err
+}
+
+// Unsubscribe stops tracking address, reporting false if it wasn't
+// subscribed. Transactions already stored for it are kept.
+func (c *Client) Unsubscribe(ctx context.Context, address string) (bool, error) {
+	var resp struct {
+		Unsubscribed bool `json:"unsubscribed"`
+	}
+	_, err := c.do(ctx, http.MethodPost, "unsubscribe", nil, map[string]string{"address": address}, &resp)
+	return resp.Unsubscribed, err
+}
+
+// CurrentBlock reports the last processed block, the node's head and the
+// progress of the backward scan.
+func (c *Client) CurrentBlock(ctx context.Context) (parser.Status, error) {
+	var status parser.Status
+	_, err := c.do(ctx, http.MethodGet, "current", nil, nil, &status)
+	return status, err
+}
+
+// do sends a request to the endpoint at path with query and, unless nil,
+// body encoded as JSON, and decodes the JSON response into out. Retryable
+// failures are retried with backoff.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) (http.Header, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+	backoff := c.opts.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		header, wait, err := c.send(ctx, method, path, query, payload, out)
+		if err == nil || wait < 0 || attempt >= c.opts.Retries || ctx.Err() != nil {
+			return header, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(max(backoff, wait)):
+		}
+		backoff *= 2
+	}
+}
+
+// send makes one attempt at a request. A failed attempt is retryable
+// unless wait is negative; a positive wait is the server's Retry-After.
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, payload []byte, out interface{}) (header http.Header, wait time.Duration, err error) {
+	resp, err := c.request(ctx, c.http, method, path, query, payload)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		if !retryable(resp.StatusCode) {
+			return nil, -1, err
+		}
+		secs, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return nil, time.Duration(secs) * time.Second, err
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return nil, -1, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return resp.Header, 0, nil
+}
+
+// request sends one request with hc.
+func (c *Client) request(ctx context.Context, hc *http.Client, method, path string, query url.Values, payload []byte) (*http.Response, error) {
+	u := c.base.JoinPath(path)
+	u.RawQuery = query.Encode()
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.opts.APIKey != "" {
+		req.Header.Set(apiKeyHeader, c.opts.APIKey)
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", path, err)
+	}
+	return resp, nil
+}
+
+// checkStatus returns a *StatusError for error responses.
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode < 300 {
+		return nil
+	}
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return &StatusError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(msg))}
+}
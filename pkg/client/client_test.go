@@ -0,0 +1,218 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+const testAddress = "0x1111111111111111111111111111111111111111"
+
+// newTestClient returns a Client of a server running handler, retrying
+// without delay.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c, err := New(srv.URL, Options{APIKey: "key", Chain: "base", RetryBackoff: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestNew_InvalidURL(t *testing.T) {
+	for _, raw := range []string{"", "localhost:8080", "ftp://example.com", "http://"} {
+		if _, err := New(raw, Options{}); err == nil {
+			t.Errorf("Expected %q to be rejected", raw)
+		}
+	}
+}
+
+func TestClient_Subscribe(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/base/subscribe" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("X-API-Key"); got != "key" {
+			t.Errorf("Expected the API key, got %q", got)
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["address"] != testAddress || body["ttl"] != "1h0m0s" || body["callback_url"] != "https://example.com/hook" {
+			t.Errorf("Unexpected body %v", body)
+		}
+		fmt.Fprint(w, `{"subscribed":true,"expires_at":"2026-01-01T00:00:00Z","webhook":{"id":"wh1","url":"https://example.com/hook","secret":"s"}}`)
+	})
+
+	sub, err := c.Subscribe(context.Background(), testAddress, SubscribeOptions{TTL: time.Hour, CallbackURL: "https://example.com/hook"})
+	if err != nil {
+		t.Fatalf("Subscribe failed: %v", err)
+	}
+	if !sub.Subscribed || sub.ExpiresAt.IsZero() || sub.Webhook == nil || sub.Webhook.ID != "wh1" {
+		t.Errorf("Unexpected subscription %+v", sub)
+	}
+}
+
+func TestClient_Retries(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		wantErr  bool
+		attempts int32
+	}{
+		{"retryable status", http.StatusServiceUnavailable, false, 3},
+		{"rate limited", http.StatusTooManyRequests, false, 3},
+		{"client error", http.StatusBadRequest, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if attempts.Add(1) < 3 {
+					http.Error(w, "try again", tt.status)
+					return
+				}
+				fmt.Fprint(w, `{"head":120,"block":100,"lag":20}`)
+			})
+
+			status, err := c.CurrentBlock(context.Background())
+			if tt.wantErr {
+				var statusErr *StatusError
+				if !errors.As(err, &statusErr) || statusErr.StatusCode != tt.status || statusErr.Message != "try again" {
+					t.Errorf("Expected a StatusError, got %v", err)
+				}
+			} else if err != nil || status.Block != 100 || status.Head != 120 {
+				t.Errorf("Expected the retried request to succeed, got %+v, %v", status, err)
+			}
+			if got := attempts.Load(); got != tt.attempts {
+				t.Errorf("Expected %d attempts, got %d", tt.attempts, got)
+			}
+		})
+	}
+}
+
+// transactions returns n transactions indexed a second apart.
+func transactions(n int) []transaction.Transaction {
+	txs := make([]transaction.Transaction, n)
+	for i := range txs {
+		txs[i] = transaction.Transaction{Hash: fmt.Sprintf("0x%d", i), Block: i, IndexedAt: time.Unix(int64(i+1), 0).UTC()}
+	}
+	return txs
+}
+
+// servePage serves the page of txs selected by the request's limit and
+// offset the way the server does.
+func servePage(w http.ResponseWriter, r *http.Request, txs []transaction.Transaction) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	if limit > 0 {
+		w.Header().Set("X-Total-Count", strconv.Itoa(len(txs)))
+		end := min(offset+limit, len(txs))
+		if end < len(txs) {
+			w.Header().Set("X-Next-Offset", strconv.Itoa(end))
+		}
+		txs = txs[min(offset, len(txs)):end]
+	}
+	json.NewEncoder(w).Encode(txs)
+}
+
+func TestClient_AllTransactions(t *testing.T) {
+	all := transactions(5)
+	var requests []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RawQuery)
+		servePage(w, r, all)
+	})
+
+	page, err := c.Transactions(context.Background(), testAddress, TransactionsOptions{Limit: 2})
+	if err != nil {
+		t.Fatalf("Transactions failed: %v", err)
+	}
+	if len(page.Transactions) != 2 || page.Total != 5 || page.NextOffset != 2 {
+		t.Errorf("Unexpected page %+v", page)
+	}
+
+	requests = nil
+	var hashes []string
+	for tx, err := range c.AllTransactions(context.Background(), testAddress, TransactionsOptions{Categories: []transaction.Category{transaction.CategoryTransfer}, Limit: 2}) {
+		if err != nil {
+			t.Fatalf("AllTransactions failed: %v", err)
+		}
+		hashes = append(hashes, tx.Hash)
+	}
+	if len(hashes) != 5 || hashes[4] != "0x4" {
+		t.Errorf("Expected all 5 transactions in order, got %v", hashes)
+	}
+	want := []string{
+		"address=" + testAddress + "&category=transfer&limit=2",
+		"address=" + testAddress + "&category=transfer&limit=2&offset=2",
+		"address=" + testAddress + "&category=transfer&limit=2&offset=4",
+	}
+	if fmt.Sprint(requests) != fmt.Sprint(want) {
+		t.Errorf("Expected requests %v, got %v", want, requests)
+	}
+}
+
+func TestClient_Watch(t *testing.T) {
+	all := transactions(3)
+	var streams atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/base/events":
+			w.Header().Set("Content-Type", "text/event-stream")
+			// The first stream sends a transaction and drops; the second
+			// idles until the client leaves.
+			if streams.Add(1) == 1 {
+				data, _ := json.Marshal(all[0])
+				fmt.Fprintf(w, ": keep-alive\n\nevent: transaction\nid: %s\ndata: %s\n\n", all[0].Hash, data)
+				return
+			}
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		case "/v1/base/transactions":
+			// The transactions indexed while the stream was down.
+			if got := r.URL.Query().Get("indexed_since"); got != all[0].IndexedAt.Format(time.RFC3339Nano) {
+				t.Errorf("Expected to catch up from the last transaction, got indexed_since=%q", got)
+			}
+			servePage(w, r, all[1:])
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var hashes []string
+	err := c.Watch(ctx, testAddress, func(tx transaction.Transaction) {
+		hashes = append(hashes, tx.Hash)
+		if len(hashes) == len(all) {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected Watch to end with its context, got %v", err)
+	}
+	if fmt.Sprint(hashes) != "[0x0 0x1 0x2]" {
+		t.Errorf("Expected each transaction once, got %v", hashes)
+	}
+}
+
+func TestClient_WatchRejected(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid API key", http.StatusUnauthorized)
+	})
+
+	err := c.Watch(context.Background(), testAddress, func(transaction.Transaction) {})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected the rejection, got %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// defaultPageSize is the page size AllTransactions uses when none is set.
+const defaultPageSize = 100
+
+// TransactionsOptions filters and paginates a transaction listing. The
+// zero value lists every stored transaction.
+type TransactionsOptions struct {
+	// Categories keeps the transactions of these categories only.
+	Categories []transaction.Category
+	// IndexedSince keeps the transactions indexed strictly after it, for
+	// polling for new ones.
+	IndexedSince time.Time
+	// Since and Until keep the transactions of blocks mined in
+	// [Since, Until).
+	Since, Until time.Time
+	// Limit is the page size, at most 1000; 0 doesn't paginate. Offset is
+	// the index of the page's first transaction.
+	Limit  int
+	Offset int
+}
+
+// Page is one page of a transaction listing.
+type Page struct {
+	Transactions []transaction.Transaction
+	// Total is the number of transactions across all pages, known when
+	// paginating.
+	Total int
+	// NextOffset is the Offset of the next page, or 0 on the last one.
+	NextOffset int
+}
+
+// Transactions lists the stored transactions of address.
+func (c *Client) Transactions(ctx context.Context, address string, opts TransactionsOptions) (Page, error) {
+	var txs []transaction.Transaction
+	header, err := c.do(ctx, http.MethodGet, "transactions", opts.query(address), nil, &txs)
+	if err != nil {
+		return Page{}, err
+	}
+	page := Page{Transactions: txs}
+	page.Total, _ = strconv.Atoi(header.Get("X-Total-Count"))
+	page.NextOffset, _ = strconv.Atoi(header.Get("X-Next-Offset"))
+	return page, nil
+}
+
+// AllTransactions iterates over the stored transactions of address page by
+// page, starting at opts.Offset with pages of opts.Limit, or 100 if unset.
+// Iteration ends after yielding the first error.
+func (c *Client) AllTransactions(ctx context.Context, address string, opts TransactionsOptions) iter.Seq2[transaction.Transaction, error] {
+	if opts.Limit == 0 {
+		opts.Limit = defaultPageSize
+	}
+	return func(yield func(transaction.Transaction, error) bool) {
+		for {
+			page, err := c.Transactions(ctx, address, opts)
+			if err != nil {
+				yield(transaction.Transaction{}, err)
+				return
+			}
+			for _, tx := range page.Transactions {
+				if !yield(tx, nil) {
+					return
+				}
+			}
+			if page.NextOffset == 0 {
+				return
+			}
+			opts.Offset = page.NextOffset
+		}
+	}
+}
+
+// query encodes opts as the query parameters of listing address.
+func (opts TransactionsOptions) query(address string) url.Values {
+	q := url.Values{"address": {address}}
+	if len(opts.Categories) > 0 {
+		names := make([]string, len(opts.Categories))
+		for i, c := range opts.Categories {
+			names[i] = string(c)
+		}
+		q.Set("category", strings.Join(names, ","))
+	}
+	if !opts.IndexedSince.IsZero() {
+		q.Set("indexed_since", opts.IndexedSince.Format(time.RFC3339Nano))
+	}
+	if !opts.Since.IsZero() {
+		q.Set("since", opts.Since.Format(time.RFC3339Nano))
+	}
+	if !opts.Until.IsZero() {
+		q.Set("until", opts.Until.Format(time.RFC3339Nano))
+	}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+		if opts.Offset > 0 {
+			q.Set("offset", strconv.Itoa(opts.Offset))
+		}
+	}
+	return q
+}
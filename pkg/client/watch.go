@@ -0,0 +1,109 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// maxWatchBackoff caps the wait between reconnections of Watch.
+const maxWatchBackoff = 30 * time.Second
+
+// Watch passes fn the new transactions of address as the server indexes
+// them, until ctx is cancelled or the server rejects the stream, as for an
+// invalid address or API key. The transactions are streamed over the
+// server's Server-Sent Events endpoint. A dropped stream is reconnected
+// with backoff, and the transactions indexed meanwhile are fetched so that
+// none are missed.
+func (c *Client) Watch(ctx context.Context, address string, fn func(transaction.Transaction)) error {
+	var last time.Time // IndexedAt of the last transaction passed to fn
+	deliver := func(tx transaction.Transaction) {
+		if tx.IndexedAt.After(last) {
+			last = tx.IndexedAt
+		}
+		fn(tx)
+	}
+	backoff := c.opts.RetryBackoff
+	for {
+		connected, err := c.stream(ctx, address, func() error {
+			// The stream is open, so fetching what was missed before it
+			// can't miss anything either.
+			if last.IsZero() {
+				return nil
+			}
+			page, err := c.Transactions(ctx, address, TransactionsOptions{IndexedSince: last})
+			for _, tx := range page.Transactions {
+				deliver(tx)
+			}
+			return err
+		}, deliver)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && !retryable(statusErr.StatusCode) {
+			return err
+		}
+		if connected {
+			backoff = c.opts.RetryBackoff
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxWatchBackoff)
+	}
+}
+
+// stream reads one event stream of address, calling opened once it is
+// open and fn with each transaction, until it ends. It reports whether the
+// stream was opened.
+func (c *Client) stream(ctx context.Context, address string, opened func() error, fn func(transaction.Transaction)) (bool, error) {
+	resp, err := c.request(ctx, c.sse, http.MethodGet, "events", url.Values{"address": {address}}, nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return false, err
+	}
+	if err := opened(); err != nil {
+		return true, err
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var event, data string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			// A blank line dispatches the event read so far.
+			if event == "transaction" && data != "" {
+				var tx transaction.Transaction
+				if err := json.Unmarshal([]byte(data), &tx); err == nil {
+					fn(tx)
+				}
+			}
+			event, data = "", ""
+		case strings.HasPrefix(line, ":"):
+			// A comment, sent to keep the stream alive.
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data += strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " ")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return true, err
+	}
+	return true, errors.New("event stream ended")
+}
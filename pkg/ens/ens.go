@@ -0,0 +1,284 @@
+// Package ens resolves Ethereum Name Service names to addresses and back
+// through a node's eth_call, caching the results.
+package ens
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// DefaultRegistry is the ENS registry address on mainnet and the public
+// testnets.
+const DefaultRegistry = "0x00000000000c2e074ec69a0dfb2997ba6c7d2e1e"
+
+// ErrNotFound is returned when a name has no address, or an address has no
+// primary name.
+var ErrNotFound = errors.New("ens: not found")
+
+// Function selectors of the registry and resolver calls used.
+const (
+	selectorResolver = "0178b8bf" // resolver(bytes32)
+	selectorAddr     = "3b3b57de" // addr(bytes32)
+	selectorName     = "691f3431" // name(bytes32)
+)
+
+// Caller performs JSON-RPC calls; *rpc.Client implements it.
+type Caller interface {
+	Call(ctx context.Context, method string, params []interface{}, result interface{}) error
+}
+
+// Options configures a Resolver.
+type Options struct {
+	// Registry is the ENS registry contract. Defaults to DefaultRegistry.
+	Registry string
+	// CacheTTL is how long results, including misses, are cached. Defaults
+	// to 10m.
+	CacheTTL time.Duration
+	// CacheSize bounds the number of cached names and addresses. Defaults to
+	// 10000.
+	CacheSize int
+}
+
+// Resolver resolves ENS names via eth_call. It is safe for concurrent use.
+type Resolver struct {
+	client Caller
+	opts   Options
+	now    func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry // "name:<name>" and "addr:<address>" keys
+}
+
+type cacheEntry struct {
+	value   string // empty for a cached miss
+	expires time.Time
+}
+
+// New creates a Resolver using client for contract calls.
+func New(client Caller, opts Options) *Resolver {
+	if opts.Registry == "" {
+		opts.Registry = DefaultRegistry
+	}
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = 10 * time.Minute
+	}
+	if opts.CacheSize <= 0 {
+		opts.CacheSize = 10000
+	}
+	return &Resolver{client: client, opts: opts, now: time.Now, cache: make(map[string]cacheEntry)}
+}
+
+// IsName reports whether s looks like an ENS name rather than an address,
+// e.g. "vitalik.eth".
+func IsName(s string) bool {
+	if !strings.Contains(s, ".") || strings.ContainsAny(s, " \t\n/") {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if label == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Normalize lowercases and trims name. Full ENSIP-15 normalization is not
+// applied, so names with non-ASCII characters may not resolve.
+func Normalize(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Namehash returns the ENS node of name as defined by EIP-137.
+func Namehash(name string) [32]byte {
+	var node [32]byte
+	if name == "" {
+		return node
+	}
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		label := keccak([]byte(labels[i]))
+		copy(node[:], keccak(node[:], label))
+	}
+	return node
+}
+
+// Resolve returns the lowercase address name points to, or ErrNotFound.
+func (r *Resolver) Resolve(ctx context.Context, name string) (string, error) {
+	name = Normalize(name)
+	return r.cached("name:"+name, func() (string, error) {
+		node := Namehash(name)
+		resolver, err := r.resolver(ctx, node)
+		if err != nil || resolver == "" {
+			return "", err
+		}
+		out, err := r.call(ctx, resolver, selectorAddr, node)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve %s: %w", name, err)
+		}
+		return decodeAddress(out), nil
+	})
+}
+
+// Lookup returns the primary name of addr, or ErrNotFound. The name is only
+// returned if it resolves back to addr, as anyone can claim any name in
+// their reverse record.
+func (r *Resolver) Lookup(ctx context.Context, addr string) (string, error) {
+	addr = strings.ToLower(addr)
+	name, err := r.cached("addr:"+addr, func() (string, error) {
+		node := Namehash(strings.TrimPrefix(addr, "0x") + ".addr.reverse")
+		resolver, err := r.resolver(ctx, node)
+		if err != nil || resolver == "" {
+			return "", err
+		}
+		out, err := r.call(ctx, resolver, selectorName, node)
+		if err != nil {
+			return "", fmt.Errorf("failed to look up name of %s: %w", addr, err)
+		}
+		return Normalize(decodeString(out)), nil
+	})
+	if err != nil {
+		return "", err
+	}
+	forward, err := r.Resolve(ctx, name)
+	if errors.Is(err, ErrNotFound) || (err == nil && forward != addr) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// cached returns the cached value for key or computes it with fetch. Hits
+// and misses are cached; errors are not. An empty value is reported as
+// ErrNotFound.
+func (r *Resolver) cached(key string, fetch func() (string, error)) (string, error) {
+	r.mu.Lock()
+	e, ok := r.cache[key]
+	r.mu.Unlock()
+	if !ok || !r.now().Before(e.expires) {
+		v, err := fetch()
+		if err != nil {
+			return "", err
+		}
+		e = cacheEntry{value: v, expires: r.now().Add(r.opts.CacheTTL)}
+		r.store(key, e)
+	}
+	if e.value == "" {
+		return "", ErrNotFound
+	}
+	return e.value, nil
+}
+
+// store adds e to the cache, evicting expired entries, or failing that an
+// arbitrary one, when it is full.
+func (r *Resolver) store(key string, e cacheEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.cache) >= r.opts.CacheSize {
+		now := r.now()
+		for k, old := range r.cache {
+			if !now.Before(old.expires) {
+				delete(r.cache, k)
+			}
+		}
+		for k := range r.cache {
+			if len(r.cache) < r.opts.CacheSize {
+				break
+			}
+			delete(r.cache, k)
+		}
+	}
+	r.cache[key] = e
+}
+
+// resolver returns the resolver contract of node, or "" if it has none.
+func (r *Resolver) resolver(ctx context.Context, node [32]byte) (string, error) {
+	out, err := r.call(ctx, r.opts.Registry, selectorResolver, node)
+	if err != nil {
+		return "", fmt.Errorf("failed to query ENS registry: %w", err)
+	}
+	return decodeAddress(out), nil
+}
+
+// call invokes a contract function taking a single bytes32 argument and
+// returns the raw result.
+func (r *Resolver) call(ctx context.Context, to, selector string, node [32]byte) ([]byte, error) {
+	msg := map[string]string{
+		"to":   to,
+		"data": "0x" + selector + hex.EncodeToString(node[:]),
+	}
+	var result string
+	if err := r.client.Call(ctx, "eth_call", []interface{}{msg, "latest"}, &result); err != nil {
+		return nil, err
+	}
+	out, err := hex.DecodeString(strings.TrimPrefix(result, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid eth_call result: %w", err)
+	}
+	return out, nil
+}
+
+// decodeAddress decodes an ABI-encoded address, returning "" for the zero
+// address or a short result, such as from an account without code.
+func decodeAddress(out []byte) string {
+	if len(out) < 32 {
+		return ""
+	}
+	addr := "0x" + hex.EncodeToString(out[12:32])
+	if addr == "0x0000000000000000000000000000000000000000" {
+		return ""
+	}
+	return addr
+}
+
+// decodeString decodes an ABI-encoded string, returning "" if out is
+// malformed.
+func decodeString(out []byte) string {
+	if len(out) < 64 {
+		return ""
+	}
+	offset := wordInt(out[:32])
+	if offset < 0 || offset+32 > len(out) {
+		return ""
+	}
+	n := wordInt(out[offset : offset+32])
+	start := offset + 32
+	if n < 0 || start+n > len(out) {
+		return ""
+	}
+	return string(out[start : start+n])
+}
+
+// wordInt decodes a 32-byte big-endian word, returning -1 if it doesn't fit
+// in an int.
+func wordInt(word []byte) int {
+	for _, b := range word[:24] {
+		if b != 0 {
+			return -1
+		}
+	}
+	var n uint64
+	for _, b := range word[24:] {
+		n = n<<8 | uint64(b)
+	}
+	if n > 1<<31 {
+		return -1
+	}
+	return int(n)
+}
+
+func keccak(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
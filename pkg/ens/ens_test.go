@@ -0,0 +1,203 @@
+package ens
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeNode answers eth_call from a table keyed by contract and call data.
+type fakeNode struct {
+	results map[string]string // "<to> <data>" -> hex result
+	calls   int
+	err     error
+}
+
+func (f *fakeNode) Call(_ context.Context, method string, params []interface{}, result interface{}) error {
+	f.calls++
+	if f.err != nil {
+		return f.err
+	}
+	if method != "eth_call" {
+		return fmt.Errorf("unexpected method %s", method)
+	}
+	msg := params[0].(map[string]string)
+	out, ok := f.results[msg["to"]+" "+msg["data"]]
+	if !ok {
+		out = "0x" // no code at the address
+	}
+	*result.(*string) = out
+	return nil
+}
+
+func (f *fakeNode) set(to, selector, name, result string) {
+	node := Namehash(name)
+	f.results[to+" 0x"+selector+hex.EncodeToString(node[:])] = result
+}
+
+func encodeAddress(addr string) string {
+	return "0x" + strings.Repeat("0", 24) + strings.TrimPrefix(addr, "0x")
+}
+
+func encodeString(s string) string {
+	word := func(n int) string { return fmt.Sprintf("%064x", n) }
+	data := hex.EncodeToString([]byte(s))
+	if pad := len(data) % 64; pad != 0 {
+		data += strings.Repeat("0", 64-pad)
+	}
+	return "0x" + word(32) + word(len(s)) + data
+}
+
+const (
+	resolverAddr = "0x4976fb03c32e5b8cfe2b6ccb31c09ba78ebaba41"
+	vitalik      = "0xd8da6bf26964af9d7eed9e03e53415d37aa96045"
+)
+
+func newFakeNode() *fakeNode {
+	f := &fakeNode{results: make(map[string]string)}
+	f.set(DefaultRegistry, selectorResolver, "vitalik.eth", encodeAddress(resolverAddr))
+	f.set(resolverAddr, selectorAddr, "vitalik.eth", encodeAddress(vitalik))
+	reverse := strings.TrimPrefix(vitalik, "0x") + ".addr.reverse"
+	f.set(DefaultRegistry, selectorResolver, reverse, encodeAddress(resolverAddr))
+	f.set(resolverAddr, selectorName, reverse, encodeString("vitalik.eth"))
+	return f
+}
+
+func TestNamehash(t *testing.T) {
+	tests := map[string]string{
+		"":        "0000000000000000000000000000000000000000000000000000000000000000",
+		"eth":     "93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae",
+		"foo.eth": "de9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f",
+	}
+	for name, want := range tests {
+		node := Namehash(name)
+		if got := hex.EncodeToString(node[:]); got != want {
+			t.Errorf("Namehash(%q) = %s, expected %s", name, got, want)
+		}
+	}
+}
+
+func TestSelectors(t *testing.T) {
+	for sig, want := range map[string]string{
+		"resolver(bytes32)": selectorResolver,
+		"addr(bytes32)":     selectorAddr,
+		"name(bytes32)":     selectorName,
+	} {
+		if got := hex.EncodeToString(keccak([]byte(sig))[:4]); got != want {
+			t.Errorf("Selector of %s = %s, expected %s", sig, got, want)
+		}
+	}
+}
+
+func TestIsName(t *testing.T) {
+	tests := map[string]bool{
+		"vitalik.eth":     true,
+		"pay.vitalik.eth": true,
+		"Vitalik.ETH":     true,
+		"0xd8da6bf26964af9d7eed9e03e53415d37aa96045": false,
+		"eth":          false,
+		"vitalik..eth": false,
+		".eth":         false,
+		"a b.eth":      false,
+	}
+	for in, want := range tests {
+		if got := IsName(in); got != want {
+			t.Errorf("IsName(%q) = %v, expected %v", in, got, want)
+		}
+	}
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	node := newFakeNode()
+	r := New(node, Options{})
+
+	got, err := r.Resolve(context.Background(), "Vitalik.eth")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if got != vitalik {
+		t.Errorf("Expected %s, got %s", vitalik, got)
+	}
+
+	if _, err := r.Resolve(context.Background(), "nobody.eth"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestResolver_Lookup(t *testing.T) {
+	node := newFakeNode()
+	r := New(node, Options{})
+
+	got, err := r.Lookup(context.Background(), "0xD8dA6BF26964aF9D7eEd9e03E53415D37aA96045")
+	if err != nil {
+		t.Fatalf("Lookup failed: %v", err)
+	}
+	if got != "vitalik.eth" {
+		t.Errorf("Expected vitalik.eth, got %s", got)
+	}
+
+	// A reverse record claiming a name that resolves elsewhere is ignored.
+	impostor := "0x1111111111111111111111111111111111111111"
+	reverse := strings.TrimPrefix(impostor, "0x") + ".addr.reverse"
+	node.set(DefaultRegistry, selectorResolver, reverse, encodeAddress(resolverAddr))
+	node.set(resolverAddr, selectorName, reverse, encodeString("vitalik.eth"))
+	if _, err := r.Lookup(context.Background(), impostor); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for unverified name, got %v", err)
+	}
+
+	if _, err := r.Lookup(context.Background(), "0x2222222222222222222222222222222222222222"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound without reverse record, got %v", err)
+	}
+}
+
+func TestResolver_Cache(t *testing.T) {
+	node := newFakeNode()
+	r := New(node, Options{CacheTTL: time.Minute})
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	r.Resolve(ctx, "vitalik.eth")
+	r.Resolve(ctx, "nobody.eth")
+	calls := node.calls
+	r.Resolve(ctx, "vitalik.eth")
+	r.Resolve(ctx, "nobody.eth")
+	if node.calls != calls {
+		t.Errorf("Expected hits and misses to be cached, got %d more calls", node.calls-calls)
+	}
+
+	now = now.Add(2 * time.Minute)
+	r.Resolve(ctx, "vitalik.eth")
+	if node.calls == calls {
+		t.Error("Expected expired entries to be refreshed")
+	}
+}
+
+func TestResolver_Errors(t *testing.T) {
+	node := newFakeNode()
+	node.err = errors.New("connection refused")
+	r := New(node, Options{})
+	if _, err := r.Resolve(context.Background(), "vitalik.eth"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected RPC error, got %v", err)
+	}
+
+	// Errors are not cached.
+	node.err = nil
+	if _, err := r.Resolve(context.Background(), "vitalik.eth"); err != nil {
+		t.Errorf("Expected success after RPC recovered, got %v", err)
+	}
+}
+
+func TestResolver_CacheSize(t *testing.T) {
+	r := New(newFakeNode(), Options{CacheSize: 2})
+	for _, name := range []string{"a.eth", "b.eth", "c.eth"} {
+		r.Resolve(context.Background(), name)
+	}
+	if len(r.cache) > 2 {
+		t.Errorf("Expected at most 2 cached entries, got %d", len(r.cache))
+	}
+}
@@ -0,0 +1,413 @@
+// Package etherscan fetches the history of an address from an
+// Etherscan-compatible API, such as Etherscan or Blockscout, normalized into
+// the transaction model. Its txlist and tokentx actions return an address's
+// whole history in a few requests, where finding it on chain means scanning
+// every block.
+package etherscan
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// maxPageSize is the most records Etherscan returns for one query, however
+// it is paginated.
+const maxPageSize = 10000
+
+// Options configures a Client.
+type Options struct {
+	// HTTPClient sends the requests. Defaults to a client with a 30s
+	// timeout.
+	HTTPClient *http.Client
+	// APIKey authenticates requests. Blockscout and local instances accept
+	// requests without one, at a lower rate.
+	APIKey string
+	// ChainID selects the chain on multi-chain APIs such as Etherscan's v2
+	// API. It isn't sent when 0.
+	ChainID int64
+	// RateLimit is how many requests are made per second at most. Defaults
+	// to 5, the limit of Etherscan's free tier.
+	RateLimit int
+	// PageSize is how many records are requested at once. Defaults to and
+	// can't exceed 10000.
+	PageSize int
+	// Retries is how many more times a request is tried after the API
+	// reports its rate limit was reached, or fails with a 429 or 5xx
+	// status. Defaults to 3; negative disables retries.
+	Retries int
+}
+
+// Client queries an Etherscan-compatible API. It is safe for concurrent
+// use; requests are spaced out to stay within the rate limit.
+type Client struct {
+	base    *url.URL
+	opts    Options
+	limit   *limiter      // shared with the clients returned by ForChain
+	backoff time.Duration // before the first retry, doubling with each
+}
+
+// limiter spaces out requests.
+type limiter struct {
+	mu       sync.Mutex
+	next     time.Time // when the next request may be sent
+	interval time.Duration
+	now      func() time.Time
+}
+
+// New creates a Client of the API at baseURL, e.g.
+// "https://api.etherscan.io/v2/api".
+func New(baseURL string, opts Options) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid Etherscan URL %q", baseURL)
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	if opts.RateLimit <= 0 {
+		opts.RateLimit = 5
+	}
+	if opts.PageSize <= 0 || opts.PageSize > maxPageSize {
+		opts.PageSize = maxPageSize
+	}
+	if opts.Retries == 0 {
+		opts.Retries = 3
+	}
+	limit := &limiter{interval: time.Second / time.Duration(opts.RateLimit), now: time.Now}
+	return &Client{base: u, opts: opts, limit: limit, backoff: time.Second}, nil
+}
+
+// ForChain returns a Client of the same API for the chain with the given
+// ID, sharing c's rate limit, as multi-chain APIs rate limit an API key
+// across chains.
+func (c *Client) ForChain(chainID int64) *Client {
+	cc := *c
+	cc.opts.ChainID = chainID
+	return &cc
+}
+
+// response is the envelope of every API response. Result is a list of
+// records on success and an error message otherwise.
+type response struct {
+	Status  string          `json:"status"`
+	Message string          `json:"message"`
+	Result  json.RawMessage `json:"result"`
+}
+
+// txRecord is a transaction as listed by the txlist action.
+type txRecord struct {
+	BlockNumber string `json:"blockNumber"`
+	Hash        string `json:"hash"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Value       string `json:"value"`
+	Input       string `json:"input"`
+	IsError     string `json:"isError"`
+	GasUsed     string `json:"gasUsed"`
+	GasPrice    string `json:"gasPrice"`
+}
+
+// tokenRecord is an ERC-20 transfer as listed by the tokentx action.
+// LogIndex is only reported by some APIs, such as Blockscout.
+type tokenRecord struct {
+	BlockNumber     string `json:"blockNumber"`
+	Hash            string `json:"hash"`
+	LogIndex        string `json:"logIndex"`
+	From            string `json:"from"`
+	To              string `json:"to"`
+	Value           string `json:"value"`
+	ContractAddress string `json:"contractAddress"`
+	TokenName       string `json:"tokenName"`
+	TokenSymbol     string `json:"tokenSymbol"`
+	TokenDecimal    string `json:"tokenDecimal"`
+}
+
+// Transactions returns the transactions sent and received by address,
+// oldest first, with their direction relative to it. Their status, gas used
+// and fee are filled in, and Input holds the whole input data.
+func (c *Client) Transactions(ctx context.Context, address string) ([]transaction.Transaction, error) {
+	address = strings.ToLower(address)
+	records, err := list(ctx, c, "txlist", address, func(r txRecord) string { return r.BlockNumber })
+	if err != nil {
+		return nil, err
+	}
+	out := make([]transaction.Transaction, 0, len(records))
+	for _, r := range records {
+		tx, err := r.normalize(address)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, tx)
+	}
+	return out, nil
+}
+
+// TokenTransfers returns the ERC-20 transfers sent and received by
+// address, oldest first, with their direction relative to it and the
+// symbol, name and decimals of their token. Transfers are numbered by their
+// position in their transaction when the API doesn't report their log
+// index, so their idempotency key may differ from that of the same transfer
+// indexed from its block.
+func (c *Client) TokenTransfers(ctx context.Context, address string) ([]transaction.TokenTransfer, error) {
+	address = strings.ToLower(address)
+	records, err := list(ctx, c, "tokentx", address, func(r tokenRecord) string { return r.BlockNumber })
+	if err != nil {
+		return nil, err
+	}
+	out := make([]transaction.TokenTransfer, 0, len(records))
+	seen := make(map[string]int) // transfers per hash, for missing log indexes
+	for _, r := range records {
+		tt, err := r.normalize(address, seen[r.Hash])
+		if err != nil {
+			return nil, err
+		}
+		seen[r.Hash]++
+		out = append(out, tt)
+	}
+	return out, nil
+}
+
+// list pages through the records of action for address in ascending block
+// order; blockOf returns the block number of a record. Etherscan caps the
+// records of a query however it is paginated, so each page starts at the
+// last block of the previous one instead, dropping that block's records
+// from the previous page as they are fetched again in full.
+func list[R any](ctx context.Context, c *Client, action, address string, blockOf func(R) string) ([]R, error) {
+	var out []R
+	start := 0
+	for {
+		var page []R
+		if err := c.query(ctx, action, address, start, &page); err != nil {
+			return nil, err
+		}
+		if len(page) < c.opts.PageSize {
+			return append(out, page...), nil
+		}
+		first, err := strconv.Atoi(blockOf(page[0]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid block number %q in %s", blockOf(page[0]), action)
+		}
+		last, err := strconv.Atoi(blockOf(page[len(page)-1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid block number %q in %s", blockOf(page[len(page)-1]), action)
+		}
+		if first == last {
+			// A whole page in one block: what doesn't fit is out of reach.
+			out = append(out, page...)
+			start = last + 1
+			continue
+		}
+		kept := len(page)
+		for kept > 0 && blockOf(page[kept-1]) == blockOf(page[len(page)-1]) {
+			kept--
+		}
+		out = append(out, page[:kept]...)
+		start = last
+	}
+}
+
+// query fetches the page of records of action for address starting at
+// block start into result. endblock is left out so that the API reads up to
+// its latest block, whatever the chain's height.
+func (c *Client) query(ctx context.Context, action, address string, start int, result any) error {
+	q := c.base.Query()
+	q.Set("module", "account")
+	q.Set("action", action)
+	q.Set("address", address)
+	q.Set("startblock", strconv.Itoa(start))
+	q.Set("page", "1")
+	q.Set("offset", strconv.Itoa(c.opts.PageSize))
+	q.Set("sort", "asc")
+	if c.opts.ChainID != 0 {
+		q.Set("chainid", strconv.FormatInt(c.opts.ChainID, 10))
+	}
+	if c.opts.APIKey != "" {
+		q.Set("apikey", c.opts.APIKey)
+	}
+	u := *c.base
+	u.RawQuery = q.Encode()
+
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		resp, retry, err := c.get(ctx, u.String())
+		if err == nil {
+			return decode(resp, result)
+		}
+		if !retry || attempt >= c.opts.Retries {
+			return fmt.Errorf("failed to list %s of %s: %w", action, address, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// get sends a request once the rate limit allows it and returns the
+// decoded envelope, reporting whether a failure is worth retrying.
+func (c *Client) get(ctx context.Context, u string) (response, bool, error) {
+	if err := c.limit.wait(ctx); err != nil {
+		return response{}, false, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return response{}, false, err
+	}
+	resp, err := c.opts.HTTPClient.Do(req)
+	if err != nil {
+		// The URL holds the API key.
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return response{}, ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return response{}, retry, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var env response
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return response{}, false, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if env.Status != "1" && !strings.HasPrefix(env.Message, "No ") {
+		// Failures carry their reason in result, e.g. "Invalid API Key".
+		var reason string
+		if json.Unmarshal(env.Result, &reason) != nil || reason == "" {
+			reason = env.Message
+		}
+		return response{}, strings.Contains(strings.ToLower(reason), "rate limit"), errors.New(reason)
+	}
+	return env, false, nil
+}
+
+// decode unmarshals the records of env into result. Empty results, such as
+// "No transactions found", decode to no records.
+func decode(env response, result any) error {
+	if env.Status != "1" {
+		return nil
+	}
+	if err := json.Unmarshal(env.Result, result); err != nil {
+		return fmt.Errorf("failed to decode result: %w", err)
+	}
+	return nil
+}
+
+// wait blocks until the next request may be sent.
+func (l *limiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := l.now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	if d := at.Sub(now); d > 0 {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+	return nil
+}
+
+// normalize converts r into a Transaction stored for address.
+func (r txRecord) normalize(address string) (transaction.Transaction, error) {
+	block, err := strconv.Atoi(r.BlockNumber)
+	if err != nil {
+		return transaction.Transaction{}, fmt.Errorf("invalid block number %q of %s", r.BlockNumber, r.Hash)
+	}
+	value, err := transaction.ParseValue(r.Value)
+	if err != nil {
+		return transaction.Transaction{}, fmt.Errorf("transaction %s: %w", r.Hash, err)
+	}
+	tx := transaction.Transaction{
+		Hash:      strings.ToLower(r.Hash),
+		From:      strings.ToLower(r.From),
+		To:        strings.ToLower(r.To),
+		Value:     value,
+		Block:     block,
+		Direction: direction(address, r.From, r.To),
+		Status:    transaction.StatusSuccess,
+		Category:  transaction.Categorize(r.To, r.Input),
+		Input:     r.Input,
+	}
+	if r.IsError == "1" {
+		tx.Status = transaction.StatusReverted
+	}
+	if gasUsed, err := strconv.ParseUint(r.GasUsed, 10, 64); err == nil {
+		tx.GasUsed = gasUsed
+		if price, ok := new(big.Int).SetString(r.GasPrice, 10); ok {
+			fee := transaction.NewValue(price.Mul(price, new(big.Int).SetUint64(gasUsed)))
+			tx.Fee = &fee
+		}
+	}
+	return tx, nil
+}
+
+// normalize converts r into a TokenTransfer stored for address, numbered
+// ordinal within its transaction if the API doesn't report its log index.
+func (r tokenRecord) normalize(address string, ordinal int) (transaction.TokenTransfer, error) {
+	block, err := strconv.Atoi(r.BlockNumber)
+	if err != nil {
+		return transaction.TokenTransfer{}, fmt.Errorf("invalid block number %q of %s", r.BlockNumber, r.Hash)
+	}
+	amount, err := transaction.ParseValue(r.Value)
+	if err != nil {
+		return transaction.TokenTransfer{}, fmt.Errorf("token transfer %s: %w", r.Hash, err)
+	}
+	logIndex, err := strconv.Atoi(r.LogIndex)
+	if err != nil {
+		logIndex = ordinal
+	}
+	decimals, _ := strconv.Atoi(r.TokenDecimal)
+	return transaction.TokenTransfer{
+		Hash:      strings.ToLower(r.Hash),
+		LogIndex:  logIndex,
+		Block:     block,
+		Contract:  strings.ToLower(r.ContractAddress),
+		Standard:  transaction.StandardERC20,
+		Symbol:    r.TokenSymbol,
+		Name:      r.TokenName,
+		Decimals:  decimals,
+		From:      strings.ToLower(r.From),
+		To:        strings.ToLower(r.To),
+		Amount:    amount,
+		Direction: direction(address, r.From, r.To),
+	}, nil
+}
+
+// direction returns the direction of a record from from to to relative to
+// address.
+func direction(address, from, to string) transaction.Direction {
+	from, to = strings.ToLower(from), strings.ToLower(to)
+	switch {
+	case from == address && to == address:
+		return transaction.DirectionSelf
+	case from == address:
+		return transaction.DirectionOut
+	default:
+		return transaction.DirectionIn
+	}
+}
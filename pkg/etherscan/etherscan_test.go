@@ -0,0 +1,177 @@
+package etherscan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+const me = "0x00000000000000000000000000000000000000aa"
+
+// serve answers each action with the records at or after startblock, and
+// up to endblock if set, oldest first, cut to offset.
+func serve(t *testing.T, records map[string][]map[string]string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("apikey") != "key" || q.Get("chainid") != "1" || q.Get("address") != me || q.Get("sort") != "asc" {
+			t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+		}
+		start, _ := strconv.Atoi(q.Get("startblock"))
+		end := math.MaxInt
+		if q.Has("endblock") {
+			end, _ = strconv.Atoi(q.Get("endblock"))
+		}
+		offset, _ := strconv.Atoi(q.Get("offset"))
+		var page []map[string]string
+		for _, rec := range records[q.Get("action")] {
+			if n, _ := strconv.Atoi(rec["blockNumber"]); n >= start && n <= end && len(page) < offset {
+				page = append(page, rec)
+			}
+		}
+		if len(page) == 0 {
+			json.NewEncoder(w).Encode(map[string]any{"status": "0", "message": "No transactions found", "result": []any{}})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"status": "1", "message": "OK", "result": page})
+	}))
+}
+
+func TestClient_Transactions(t *testing.T) {
+	var txs []map[string]string
+	// Arbitrum and other fast chains are past block 100,000,000.
+	for i, block := range []int{1, 2, 2, 3, 250_000_000} {
+		txs = append(txs, map[string]string{
+			"blockNumber": strconv.Itoa(block), "hash": fmt.Sprintf("0x%d", i), "from": strings.ToUpper(me[:2]) + me[2:], "to": "0xbb",
+			"value": "1000", "input": "0x", "isError": "0", "gasUsed": "21000", "gasPrice": "2",
+		})
+	}
+	txs[4]["from"], txs[4]["to"], txs[4]["isError"], txs[4]["input"] = "0xbb", me, "1", "0xa9059cbb"
+	srv := serve(t, map[string][]map[string]string{"txlist": txs})
+	defer srv.Close()
+
+	// A page size of 2 cuts the history between the transactions of block 2.
+	c, err := New(srv.URL+"/api", Options{APIKey: "key", PageSize: 2, RateLimit: 1000})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	got, err := c.ForChain(1).Transactions(context.Background(), me)
+	if err != nil {
+		t.Fatalf("Transactions failed: %v", err)
+	}
+
+	var hashes []string
+	for _, tx := range got {
+		hashes = append(hashes, tx.Hash)
+	}
+	if strings.Join(hashes, ",") != "0x0,0x1,0x2,0x3,0x4" {
+		t.Fatalf("Expected every transaction once, oldest first, got %v", hashes)
+	}
+	out := got[0]
+	if out.Direction != transaction.DirectionOut || out.From != me || out.Value.String() != "1000" || out.Block != 1 {
+		t.Errorf("Unexpected outgoing transaction: %+v", out)
+	}
+	if out.Status != transaction.StatusSuccess || out.GasUsed != 21000 || out.Fee == nil || out.Fee.String() != "42000" || out.Category != transaction.CategoryTransfer {
+		t.Errorf("Expected a successful transfer with a 42000 wei fee, got %+v", out)
+	}
+	in := got[4]
+	if in.Direction != transaction.DirectionIn || in.Status != transaction.StatusReverted || in.Category != transaction.CategoryTokenTransfer {
+		t.Errorf("Expected a reverted incoming token transfer, got %+v", in)
+	}
+}
+
+func TestClient_TokenTransfers(t *testing.T) {
+	srv := serve(t, map[string][]map[string]string{"tokentx": {
+		{"blockNumber": "7", "hash": "0xt", "from": "0xbb", "to": me, "value": "1500000", "contractAddress": "0xUSDC", "tokenName": "USD Coin", "tokenSymbol": "USDC", "tokenDecimal": "6"},
+		{"blockNumber": "7", "hash": "0xt", "from": me, "to": me, "value": "1", "contractAddress": "0xusdc", "tokenName": "USD Coin", "tokenSymbol": "USDC", "tokenDecimal": "6"},
+		{"blockNumber": "8", "hash": "0xu", "logIndex": "12", "from": me, "to": "0xbb", "value": "1", "contractAddress": "0xusdc", "tokenDecimal": "6"},
+	}})
+	defer srv.Close()
+
+	c, err := New(srv.URL, Options{APIKey: "key", ChainID: 1, RateLimit: 1000})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	got, err := c.TokenTransfers(context.Background(), me)
+	if err != nil {
+		t.Fatalf("TokenTransfers failed: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 transfers, got %+v", got)
+	}
+	first := got[0]
+	if first.Contract != "0xusdc" || first.Symbol != "USDC" || first.NormalizedAmount() != "1.5" || first.Direction != transaction.DirectionIn || first.Standard != transaction.StandardERC20 {
+		t.Errorf("Unexpected transfer: %+v", first)
+	}
+	if got[0].LogIndex != 0 || got[1].LogIndex != 1 || got[1].Direction != transaction.DirectionSelf || got[2].LogIndex != 12 {
+		t.Errorf("Expected log indexes 0, 1 and 12, got %d, %d and %d", got[0].LogIndex, got[1].LogIndex, got[2].LogIndex)
+	}
+}
+
+func TestClient_InvalidBlockNumber(t *testing.T) {
+	srv := serve(t, map[string][]map[string]string{"txlist": {
+		{"blockNumber": "latest", "hash": "0x1", "from": me, "to": "0xbb", "value": "1"},
+		{"blockNumber": "2", "hash": "0x2", "from": me, "to": "0xbb", "value": "1"},
+	}})
+	defer srv.Close()
+	c, _ := New(srv.URL, Options{APIKey: "key", ChainID: 1, PageSize: 2, RateLimit: 1000})
+	if _, err := c.Transactions(context.Background(), me); err == nil || !strings.Contains(err.Error(), `invalid block number "latest"`) {
+		t.Errorf("Expected an invalid block number error, got %v", err)
+	}
+}
+
+func TestClient_Empty(t *testing.T) {
+	srv := serve(t, nil)
+	defer srv.Close()
+	c, _ := New(srv.URL, Options{APIKey: "key", ChainID: 1, RateLimit: 1000})
+	got, err := c.Transactions(context.Background(), me)
+	if err != nil || len(got) != 0 {
+		t.Errorf("Expected no transactions, got %+v, %v", got, err)
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			json.NewEncoder(w).Encode(map[string]any{"status": "0", "message": "NOTOK", "result": "Max rate limit reached"})
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			json.NewEncoder(w).Encode(map[string]any{"status": "0", "message": "NOTOK", "result": "Invalid API Key"})
+		}
+	}))
+	defer srv.Close()
+
+	c, _ := New(srv.URL, Options{APIKey: "secret", RateLimit: 1000})
+	c.backoff = time.Millisecond
+	_, err := c.Transactions(context.Background(), me)
+	if err == nil || !strings.Contains(err.Error(), "Invalid API Key") {
+		t.Fatalf("Expected the API's error after retrying, got %v", err)
+	}
+	if strings.Contains(err.Error(), "secret") {
+		t.Errorf("Expected the API key to stay out of errors, got %v", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("Expected 3 requests, got %d", n)
+	}
+}
+
+func TestNew_InvalidURL(t *testing.T) {
+	for _, u := range []string{"", "api.etherscan.io", "ftp://example.com"} {
+		if _, err := New(u, Options{}); err == nil {
+			t.Errorf("Expected an error for %q", u)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Package labels maps well-known addresses, such as exchange hot wallets,
+// bridges and mixers, to human-readable names.
+package labels
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/address"
+)
+
+// Common categories. Files may use any other category.
+const (
+	CategoryExchange = "exchange"
+	CategoryBridge   = "bridge"
+	CategoryMixer    = "mixer"
+)
+
+// Label names an address.
+type Label struct {
+	Address  string `json:"address,omitempty"`
+	Name     string `json:"name"`
+	Category string `json:"category,omitempty"`
+}
+
+// Registry looks up the label of an address.
+type Registry interface {
+	// Lookup returns the label of a lowercase address.
+	Lookup(address string) (Label, bool)
+}
+
+// Chain is a Registry consulting each registry in order; the first match
+// wins, so earlier registries override later ones.
+type Chain []Registry
+
+// Lookup returns the first label found for address.
+func (c Chain) Lookup(address string) (Label, bool) {
+	for _, r := range c {
+		if l, ok := r.Lookup(address); ok {
+			return l, true
+		}
+	}
+	return Label{}, false
+}
+
+// Static is an immutable in-memory Registry.
+type Static struct {
+	labels map[string]Label
+}
+
+// NewStatic validates labels and creates a Static registry. Addresses are
+// normalized; a later label for the same address replaces an earlier one.
+func NewStatic(labels []Label) (*Static, error) {
+	s := &Static{labels: make(map[string]Label, len(labels))}
+	for i, l := range labels {
+		addr, err := address.Normalize(l.Address)
+		if err != nil {
+			return nil, fmt.Errorf("label %d: %w", i+1, err)
+		}
+		if strings.TrimSpace(l.Name) == "" {
+			return nil, fmt.Errorf("label %d (%s): missing name", i+1, addr)
+		}
+		l.Address = addr
+		l.Category = strings.ToLower(strings.TrimSpace(l.Category))
+		s.labels[addr] = l
+	}
+	return s, nil
+}
+
+// Lookup returns the label of address.
+func (s *Static) Lookup(address string) (Label, bool) {
+	l, ok := s.labels[address]
+	return l, ok
+}
+
+// Len returns the number of labelled addresses.
+func (s *Static) Len() int {
+	return len(s.labels)
+}
+
+//go:embed builtin.json
+var builtinJSON []byte
+
+// Builtin returns the labels shipped with txparser: major exchange wallets,
+// bridges and mixers on Ethereum mainnet.
+func Builtin() *Static {
+	s, err := ReadJSON(bytes.NewReader(builtinJSON))
+	if err != nil {
+		panic("labels: invalid builtin labels: " + err.Error())
+	}
+	return s
+}
+
+// LoadFile reads labels from a .json or .csv file; see ReadJSON and ReadCSV.
+func LoadFile(path string) (*Static, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open labels file: %w", err)
+	}
+	defer f.Close()
+
+	var s *Static
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		s, err = ReadJSON(f)
+	case ".csv":
+		s, err = ReadCSV(f)
+	default:
+		return nil, fmt.Errorf("unsupported labels file %s: expected .json or .csv", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read labels file %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// ReadJSON reads an array of {"address","name","category"} objects.
+func ReadJSON(r io.Reader) (*Static, error) {
+	var labels []Label
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&labels); err != nil {
+		return nil, err
+	}
+	return NewStatic(labels)
+}
+
+// ReadCSV reads address,name,category rows. The category column is optional
+// and a header row starting with "address" is skipped.
+func ReadCSV(r io.Reader) (*Static, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.Comment = '#'
+	cr.TrimLeadingSpace = true
+	var labels []Label
+	for row := 1; ; row++ { // row counts records, not lines
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if row == 1 && strings.EqualFold(strings.TrimSpace(rec[0]), "address") {
+			continue
+		}
+		if len(rec) < 2 || len(rec) > 3 {
+			return nil, fmt.Errorf("record %d: expected address,name[,category]", row)
+		}
+		l := Label{Address: strings.TrimSpace(rec[0]), Name: strings.TrimSpace(rec[1])}
+		if len(rec) == 3 {
+			l.Category = rec[2]
+		}
+		labels = append(labels, l)
+	}
+	return NewStatic(labels)
+}
@@ -0,0 +1,151 @@
+package labels
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	binance = "0x28c6c06298d514db089934071355e5743bf21d60"
+	other   = "0x1111111111111111111111111111111111111111"
+)
+
+func TestBuiltin(t *testing.T) {
+	b := Builtin()
+	if b.Len() == 0 {
+		t.Fatal("Expected builtin labels")
+	}
+	l, ok := b.Lookup(binance)
+	if !ok || l.Category != CategoryExchange {
+		t.Errorf("Expected %s to be labelled as an exchange, got %+v %v", binance, l, ok)
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{name: "valid", input: `[{"address":"0x28C6c06298d514Db089934071355E5743bf21d60","name":"Binance 14","category":"Exchange"}]`},
+		{name: "empty", input: `[]`},
+		{name: "invalid address", input: `[{"address":"0x1234","name":"x"}]`, wantErr: true},
+		{name: "missing name", input: `[{"address":"` + binance + `"}]`, wantErr: true},
+		{name: "unknown field", input: `[{"address":"` + binance + `","name":"x","tag":"y"}]`, wantErr: true},
+		{name: "not an array", input: `{}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ReadJSON(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil || s.Len() == 0 {
+				return
+			}
+			l, ok := s.Lookup(binance)
+			if !ok || l.Name != "Binance 14" || l.Category != CategoryExchange || l.Address != binance {
+				t.Errorf("Unexpected label: %+v %v", l, ok)
+			}
+		})
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    map[string]Label
+		wantErr bool
+	}{
+		{
+			name:  "header and comments",
+			input: "address,name,category\n# exchanges\n" + binance + ",Binance 14,exchange\n" + other + ", Treasury\n",
+			want: map[string]Label{
+				binance: {Address: binance, Name: "Binance 14", Category: CategoryExchange},
+				other:   {Address: other, Name: "Treasury"},
+			},
+		},
+		{
+			name:  "no header",
+			input: binance + ",\"Binance, hot wallet\"\n",
+			want:  map[string]Label{binance: {Address: binance, Name: "Binance, hot wallet"}},
+		},
+		{name: "too many fields", input: binance + ",a,b,c\n", wantErr: true},
+		{name: "too few fields", input: binance + "\n", wantErr: true},
+		{name: "invalid address", input: "nope,Binance\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ReadCSV(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if s.Len() != len(tt.want) {
+				t.Errorf("Expected %d labels, got %d", len(tt.want), s.Len())
+			}
+			for addr, want := range tt.want {
+				if got, ok := s.Lookup(addr); !ok || got != want {
+					t.Errorf("Expected %+v for %s, got %+v", want, addr, got)
+				}
+			}
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	override, err := NewStatic([]Label{{Address: binance, Name: "Our deposit wallet"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := Chain{override, Builtin()}
+	if l, _ := c.Lookup(binance); l.Name != "Our deposit wallet" {
+		t.Errorf("Expected the first registry to win, got %+v", l)
+	}
+	if l, ok := c.Lookup("0x12d66f87a04a9e220743712ce6d9bb1b5616b8fc"); !ok || l.Category != CategoryMixer {
+		t.Errorf("Expected fallback to later registries, got %+v %v", l, ok)
+	}
+	if _, ok := c.Lookup(other); ok {
+		t.Error("Expected no label for an unknown address")
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{name: "json", path: write("labels.json", `[{"address":"`+binance+`","name":"Binance 14"}]`)},
+		{name: "csv", path: write("labels.CSV", binance+",Binance 14\n")},
+		{name: "unsupported extension", path: write("labels.txt", binance+",Binance 14\n"), wantErr: true},
+		{name: "missing", path: filepath.Join(dir, "missing.json"), wantErr: true},
+		{name: "malformed", path: write("bad.json", `[`), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := LoadFile(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if err == nil {
+				if l, ok := s.Lookup(binance); !ok || l.Name != "Binance 14" {
+					t.Errorf("Unexpected label: %+v %v", l, ok)
+				}
+			}
+		})
+	}
+}
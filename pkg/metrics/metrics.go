@@ -0,0 +1,168 @@
+// Package metrics defines the small recording interface used by the parser,
+// storage, RPC client and HTTP server. Embedders plug in their own metrics
+// stack by implementing Recorder; a Prometheus implementation lives in the
+// prometheus subpackage so that importing this package adds no dependencies.
+package metrics
+
+import "time"
+
+// Metric names recorded by txparser components.
+const (
+	// RPCRequests counts JSON-RPC calls by method and result ("ok" or "error").
+	RPCRequests = "rpc_requests_total"
+	// RPCDuration observes JSON-RPC call latency in seconds by method.
+	RPCDuration = "rpc_request_duration_seconds"
+	// RPCFailovers counts calls retried at another provider after failing
+	// at the one labeled provider.
+	RPCFailovers = "rpc_failovers_total"
+
+	// BlocksProcessed counts processed blocks by result ("ok" or "error").
+	BlocksProcessed = "parser_blocks_processed_total"
+	// BlockDuration observes the time to fetch and store one block, in seconds.
+	BlockDuration = "parser_block_duration_seconds"
+	// BlockPhaseDuration observes the time spent on one block's transactions
+	// by phase: "fetch" from the node, including receipts, "parse" and
+	// "store", in seconds.
+	BlockPhaseDuration = "parser_block_phase_duration_seconds"
+	// CurrentBlock is the last processed block number.
+	CurrentBlock = "parser_current_block"
+	// HeadBlock is the latest block number reported by the node.
+	HeadBlock = "parser_head_block"
+	// BlockLag is how many blocks the parser is behind the node's head.
+	BlockLag = "parser_block_lag"
+	// TransactionsProcessed counts transactions in processed blocks.
+	TransactionsProcessed = "parser_transactions_processed_total"
+	// TransactionsIgnored counts transactions skipped because they involve
+	// an ignored address.
+	TransactionsIgnored = "parser_transactions_ignored_total"
+	// TransactionsSkipped counts zero-value contract calls dropped because
+	// their sender isn't subscribed.
+	TransactionsSkipped = "parser_transactions_skipped_total"
+	// DryRunRecords counts the records a parser in dry-run mode would have
+	// stored, labeled by whether their address is subscribed.
+	DryRunRecords = "parser_dry_run_records_total"
+	// BlockCacheRequests counts block cache lookups by result ("hit" or
+	// "miss").
+	BlockCacheRequests = "parser_block_cache_requests_total"
+	// CatchUpQueueDepth is the number of blocks fetched or being fetched
+	// ahead of being stored during catch-up.
+	CatchUpQueueDepth = "parser_catchup_queue_depth"
+	// Reorgs counts the chain reorganizations detected while following the
+	// head, and OrphanedBlocks the blocks they replaced.
+	Reorgs         = "parser_reorgs_total"
+	OrphanedBlocks = "parser_orphaned_blocks_total"
+	// BlocksDeadLettered counts blocks given up on after their retries
+	// and moved to the dead-letter queue.
+	BlocksDeadLettered = "parser_blocks_dead_lettered_total"
+	// ReceiptsEnriched counts transactions handled by receipt enrichment by
+	// result ("ok", "missing", "error" or "dropped").
+	ReceiptsEnriched = "parser_receipts_enriched_total"
+
+	// TransactionsStored counts transactions added to storage, excluding duplicates.
+	TransactionsStored = "storage_transactions_stored_total"
+	// Subscriptions is the number of subscribed addresses.
+	Subscriptions = "storage_subscriptions"
+	// StoredAddresses is the number of addresses with stored records.
+	StoredAddresses = "storage_addresses"
+	// StoredTransactions is the number of stored transaction records.
+	StoredTransactions = "storage_transactions"
+	// StoredBytes estimates the memory held by stored transaction and token
+	// transfer records.
+	StoredBytes = "storage_bytes"
+	// CompactionReclaimed counts the bytes freed by storage compaction.
+	CompactionReclaimed = "storage_compaction_reclaimed_bytes_total"
+	// GarbageCollected counts the records of never-subscribed addresses
+	// dropped after going idle.
+	GarbageCollected = "storage_gc_records_total"
+
+	// Leader is 1 while this instance holds the leader lock, and 0 while it
+	// stands by.
+	Leader = "leader"
+
+	// HTTPRequests counts API requests by method, route and status code.
+	HTTPRequests = "http_requests_total"
+	// HTTPDuration observes API request latency in seconds by method, route
+	// and status code.
+	HTTPDuration = "http_request_duration_seconds"
+	// HTTPInFlight is the number of API requests being served, by route.
+	HTTPInFlight = "http_requests_in_flight"
+)
+
+// Label is a metric dimension.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// L is shorthand for constructing a Label.
+func L(name, value string) Label {
+	return Label{Name: name, Value: value}
+}
+
+// Recorder receives measurements. Implementations must be safe for
+// concurrent use. A metric is always recorded with the same label names.
+type Recorder interface {
+	// Add increments a counter by delta.
+	Add(name string, delta float64, labels ...Label)
+	// Set sets a gauge to value.
+	Set(name string, value float64, labels ...Label)
+	// Observe records value in a histogram or summary.
+	Observe(name string, value float64, labels ...Label)
+}
+
+// Nop discards all measurements.
+var Nop Recorder = nop{}
+
+type nop struct{}
+
+func (nop) Add(string, float64, ...Label)     {}
+func (nop) Set(string, float64, ...Label)     {}
+func (nop) Observe(string, float64, ...Label) {}
+
+// Result returns the "result" label for err: "ok" or "error".
+func Result(err error) Label {
+	if err != nil {
+		return L("result", "error")
+	}
+	return L("result", "ok")
+}
+
+// ObserveSince records the seconds elapsed since start.
+func ObserveSince(r Recorder, name string, start time.Time, labels ...Label) {
+	r.Observe(name, time.Since(start).Seconds(), labels...)
+}
+
+// With returns a Recorder that adds labels to every measurement, e.g. to tag
+// all metrics of one chain.
+func With(r Recorder, labels ...Label) Recorder {
+	return &labeled{next: r, labels: labels}
+}
+
+type labeled struct {
+	next   Recorder
+	labels []Label
+}
+
+func (l *labeled) with(labels []Label) []Label {
+	return append(append(make([]Label, 0, len(l.labels)+len(labels)), l.labels...), labels...)
+}
+
+func (l *labeled) Add(name string, delta float64, labels ...Label) {
+	l.next.Add(name, delta, l.with(labels)...)
+}
+
+func (l *labeled) Set(name string, value float64, labels ...Label) {
+	l.next.Set(name, value, l.with(labels)...)
+}
+
+func (l *labeled) Observe(name string, value float64, labels ...Label) {
+	l.next.Observe(name, value, l.with(labels)...)
+}
+
+// OrNop returns r, or Nop when r is nil, for applying option defaults.
+func OrNop(r Recorder) Recorder {
+	if r == nil {
+		return Nop
+	}
+	return r
+}
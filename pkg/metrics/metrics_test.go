@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+)
+
+// captured is a single measurement seen by captureRecorder.
+type captured struct {
+	kind   string
+	name   string
+	value  float64
+	labels []Label
+}
+
+type captureRecorder struct {
+	got []captured
+}
+
+func (c *captureRecorder) Add(name string, v float64, labels ...Label) {
+	c.got = append(c.got, captured{"add", name, v, labels})
+}
+
+func (c *captureRecorder) Set(name string, v float64, labels ...Label) {
+	c.got = append(c.got, captured{"set", name, v, labels})
+}
+
+func (c *captureRecorder) Observe(name string, v float64, labels ...Label) {
+	c.got = append(c.got, captured{"observe", name, v, labels})
+}
+
+func TestWith(t *testing.T) {
+	base := &captureRecorder{}
+	r := With(base, L("chain", "ethereum"))
+
+	r.Add(BlocksProcessed, 1, Result(nil))
+	r.Set(CurrentBlock, 42)
+	r.Observe(BlockDuration, 0.5, Result(errors.New("boom")))
+
+	if len(base.got) != 3 {
+		t.Fatalf("Expected 3 measurements, got %d", len(base.got))
+	}
+	want := [][]Label{
+		{L("chain", "ethereum"), L("result", "ok")},
+		{L("chain", "ethereum")},
+		{L("chain", "ethereum"), L("result", "error")},
+	}
+	for i, m := range base.got {
+		if len(m.labels) != len(want[i]) {
+			t.Fatalf("Unexpected labels for %s: %v", m.name, m.labels)
+		}
+		for j := range want[i] {
+			if m.labels[j] != want[i][j] {
+				t.Errorf("Unexpected labels for %s: %v", m.name, m.labels)
+			}
+		}
+	}
+}
+
+func TestOrNop(t *testing.T) {
+	if OrNop(nil) != Nop {
+		t.Error("Expected nil to default to Nop")
+	}
+	r := &captureRecorder{}
+	if OrNop(r) != r {
+		t.Error("Expected a recorder to be kept")
+	}
+}
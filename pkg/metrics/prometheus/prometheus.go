@@ -0,0 +1,183 @@
+// Package prometheus implements metrics.Recorder on top of the Prometheus
+// client library.
+package prometheus
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
+)
+
+// help describes the metrics recorded by txparser components.
+var help = map[string]string{
+	metrics.RPCRequests:           "JSON-RPC calls by method and result.",
+	metrics.RPCDuration:           "JSON-RPC call latency in seconds.",
+	metrics.RPCFailovers:          "JSON-RPC calls retried at another provider, by failed provider.",
+	metrics.BlocksProcessed:       "Processed blocks by result.",
+	metrics.BlockDuration:         "Time to fetch and store one block in seconds.",
+	metrics.BlockPhaseDuration:    "Time spent on one block's transactions by phase in seconds.",
+	metrics.CurrentBlock:          "Last processed block number.",
+	metrics.HeadBlock:             "Latest block number reported by the node.",
+	metrics.BlockLag:              "Blocks between the node's head and the last processed block.",
+	metrics.TransactionsProcessed: "Transactions in processed blocks.",
+	metrics.BlockCacheRequests:    "Block cache lookups by result.",
+	metrics.CatchUpQueueDepth:     "Blocks fetched ahead of being stored during catch-up.",
+	metrics.Reorgs:                "Chain reorganizations detected while following the head.",
+	metrics.OrphanedBlocks:        "Blocks replaced by detected chain reorganizations.",
+	metrics.BlocksDeadLettered:    "Blocks moved to the dead-letter queue after their retries.",
+	metrics.ReceiptsEnriched:      "Transactions handled by receipt enrichment by result.",
+	metrics.TransactionsStored:    "Transactions added to storage, excluding duplicates.",
+	metrics.Subscriptions:         "Number of subscribed addresses.",
+	metrics.StoredAddresses:       "Number of addresses with stored records.",
+	metrics.StoredTransactions:    "Number of stored transaction records.",
+	metrics.StoredBytes:           "Estimated bytes held by stored transaction and token transfer records.",
+	metrics.Leader:                "1 while this instance is the elected leader, 0 on standby.",
+	metrics.HTTPRequests:          "API requests by method, route and status code.",
+	metrics.HTTPDuration:          "API request latency in seconds.",
+	metrics.HTTPInFlight:          "API requests being served.",
+}
+
+// Options configures a Recorder.
+type Options struct {
+	// Namespace prefixes every metric name, e.g. "txparser".
+	Namespace string
+	// Registry collects the metrics. Defaults to a new registry that also
+	// exports Go runtime and process metrics.
+	Registry *prom.Registry
+	// Buckets are the histogram buckets. Defaults to prometheus.DefBuckets.
+	Buckets []float64
+}
+
+// Recorder creates Prometheus collectors on first use of each metric name.
+// The label names of the first use define the metric; later calls with
+// different label names are dropped.
+type Recorder struct {
+	opts Options
+
+	mu         sync.Mutex
+	counters   map[string]*prom.CounterVec
+	gauges     map[string]*prom.GaugeVec
+	histograms map[string]*prom.HistogramVec
+}
+
+var _ metrics.Recorder = (*Recorder)(nil)
+
+// New creates a Recorder.
+func New(opts Options) *Recorder {
+	if opts.Registry == nil {
+		opts.Registry = prom.NewRegistry()
+		opts.Registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	}
+	if len(opts.Buckets) == 0 {
+		opts.Buckets = prom.DefBuckets
+	}
+	return &Recorder{
+		opts:       opts,
+		counters:   make(map[string]*prom.CounterVec),
+		gauges:     make(map[string]*prom.GaugeVec),
+		histograms: make(map[string]*prom.HistogramVec),
+	}
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func (r *Recorder) Handler() http.Handler {
+	return promhttp.HandlerFor(r.opts.Registry, promhttp.HandlerOpts{})
+}
+
+// Add increments a counter.
+func (r *Recorder) Add(name string, delta float64, labels ...metrics.Label) {
+	r.mu.Lock()
+	vec, ok := r.counters[name]
+	if !ok {
+		vec = prom.NewCounterVec(prom.CounterOpts{Namespace: r.opts.Namespace, Name: name, Help: helpFor(name)}, labelNames(labels))
+		if !r.register(vec) {
+			vec = nil
+		}
+		r.counters[name] = vec
+	}
+	r.mu.Unlock()
+	if vec == nil {
+		return
+	}
+	if c, err := vec.GetMetricWith(labelValues(labels)); err == nil {
+		c.Add(delta)
+	}
+}
+
+// Set sets a gauge.
+func (r *Recorder) Set(name string, value float64, labels ...metrics.Label) {
+	r.mu.Lock()
+	vec, ok := r.gauges[name]
+	if !ok {
+		vec = prom.NewGaugeVec(prom.GaugeOpts{Namespace: r.opts.Namespace, Name: name, Help: helpFor(name)}, labelNames(labels))
+		if !r.register(vec) {
+			vec = nil
+		}
+		r.gauges[name] = vec
+	}
+	r.mu.Unlock()
+	if vec == nil {
+		return
+	}
+	if g, err := vec.GetMetricWith(labelValues(labels)); err == nil {
+		g.Set(value)
+	}
+}
+
+// Observe records a histogram sample.
+func (r *Recorder) Observe(name string, value float64, labels ...metrics.Label) {
+	r.mu.Lock()
+	vec, ok := r.histograms[name]
+	if !ok {
+		vec = prom.NewHistogramVec(prom.HistogramOpts{Namespace: r.opts.Namespace, Name: name, Help: helpFor(name), Buckets: r.opts.Buckets}, labelNames(labels))
+		if !r.register(vec) {
+			vec = nil
+		}
+		r.histograms[name] = vec
+	}
+	r.mu.Unlock()
+	if vec == nil {
+		return
+	}
+	if h, err := vec.GetMetricWith(labelValues(labels)); err == nil {
+		h.Observe(value)
+	}
+}
+
+// register adds c to the registry, reporting false if the name is invalid
+// or already used by another metric type.
+func (r *Recorder) register(c prom.Collector) bool {
+	return r.opts.Registry.Register(c) == nil
+}
+
+// helpFor returns the help text for a metric name.
+func helpFor(name string) string {
+	if h, ok := help[name]; ok {
+		return h
+	}
+	return strings.ReplaceAll(name, "_", " ") + "."
+}
+
+func labelNames(labels []metrics.Label) []string {
+	names := make([]string, len(labels))
+	for i, l := range labels {
+		names[i] = l.Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+func labelValues(labels []metrics.Label) prom.Labels {
+	values := make(prom.Labels, len(labels))
+	for _, l := range labels {
+		values[l.Name] = l.Value
+	}
+	return values
+}
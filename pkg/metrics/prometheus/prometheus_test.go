@@ -0,0 +1,50 @@
+package prometheus
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
+)
+
+func TestRecorder(t *testing.T) {
+	r := New(Options{Namespace: "txparser", Buckets: []float64{0.1, 1}})
+
+	r.Add(metrics.RPCRequests, 1, metrics.L("method", "eth_blockNumber"), metrics.L("result", "ok"))
+	r.Add(metrics.RPCRequests, 2, metrics.L("result", "ok"), metrics.L("method", "eth_blockNumber"))
+	r.Set(metrics.CurrentBlock, 42, metrics.L("chain", "ethereum"))
+	r.Observe(metrics.RPCDuration, 0.05, metrics.L("method", "eth_blockNumber"))
+	// Different label names than the first use are dropped instead of panicking.
+	r.Add(metrics.RPCRequests, 1, metrics.L("method", "eth_blockNumber"))
+
+	w := httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(w.Body)
+
+	for _, want := range []string{
+		`txparser_rpc_requests_total{method="eth_blockNumber",result="ok"} 3`,
+		`txparser_parser_current_block{chain="ethereum"} 42`,
+		`txparser_rpc_request_duration_seconds_bucket{method="eth_blockNumber",le="0.1"} 1`,
+		`# HELP txparser_rpc_requests_total JSON-RPC calls by method and result.`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("Expected exposition to contain %q", want)
+		}
+	}
+}
+
+func TestRecorder_TypeConflict(t *testing.T) {
+	r := New(Options{})
+	r.Add("events_total", 1)
+	// Registering the same name as a gauge fails and is ignored.
+	r.Set("events_total", 5)
+
+	w := httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.Contains(w.Body.String(), "events_total 1") {
+		t.Errorf("Expected the counter to be kept, got:\n%s", w.Body.String())
+	}
+}
@@ -0,0 +1,188 @@
+// Package statsd implements metrics.Recorder by emitting StatsD packets with
+// DogStatsD tags, for Datadog agents and other tag-aware StatsD servers.
+package statsd
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
+)
+
+// Options configures a Client.
+type Options struct {
+	// Addr is the UDP address of the StatsD server. Defaults to 127.0.0.1:8125.
+	Addr string
+	// Prefix is prepended to every metric name, e.g. "txparser.".
+	Prefix string
+	// Tags are added to every metric, e.g. "env:prod".
+	Tags []string
+	// FlushInterval is how often buffered metrics are sent. Defaults to 1s.
+	FlushInterval time.Duration
+	// MaxPacketSize bounds a single UDP payload. Defaults to 1432 bytes,
+	// which fits a standard Ethernet MTU.
+	MaxPacketSize int
+}
+
+// Client buffers metrics and sends them to a StatsD server over UDP.
+// Counters are sent as "c", gauges as "g" and observations as histograms
+// ("h"). Labels become tags. Send errors are ignored, as is usual for
+// StatsD, so a missing agent never affects the caller.
+type Client struct {
+	opts Options
+	conn net.Conn
+	tags string // global tags, pre-formatted
+
+	mu  sync.Mutex
+	buf []byte
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+var _ metrics.Recorder = (*Client)(nil)
+
+// New creates a Client and starts flushing in the background. Close stops
+// it and sends any buffered metrics.
+func New(opts Options) (*Client, error) {
+	if opts.Addr == "" {
+		opts.Addr = "127.0.0.1:8125"
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	if opts.MaxPacketSize <= 0 {
+		opts.MaxPacketSize = 1432
+	}
+	conn, err := net.Dial("udp", opts.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial statsd server %s: %w", opts.Addr, err)
+	}
+	tags := make([]string, len(opts.Tags))
+	for i, t := range opts.Tags {
+		tags[i] = sanitizeTag(t)
+	}
+	c := &Client{
+		opts: opts,
+		conn: conn,
+		tags: strings.Join(tags, ","),
+		buf:  make([]byte, 0, opts.MaxPacketSize),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go c.flushLoop()
+	return c, nil
+}
+
+// Add sends a counter increment.
+func (c *Client) Add(name string, delta float64, labels ...metrics.Label) {
+	c.write(name, delta, "c", labels)
+}
+
+// Set sends a gauge value.
+func (c *Client) Set(name string, value float64, labels ...metrics.Label) {
+	c.write(name, value, "g", labels)
+}
+
+// Observe sends a histogram sample.
+func (c *Client) Observe(name string, value float64, labels ...metrics.Label) {
+	c.write(name, value, "h", labels)
+}
+
+// Close flushes buffered metrics and closes the connection.
+func (c *Client) Close() error {
+	close(c.stop)
+	<-c.done
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flushLocked()
+	return c.conn.Close()
+}
+
+func (c *Client) flushLoop() {
+	defer close(c.done)
+	ticker := time.NewTicker(c.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.mu.Lock()
+			c.flushLocked()
+			c.mu.Unlock()
+		}
+	}
+}
+
+// write appends one metric line, flushing first if it wouldn't fit.
+func (c *Client) write(name string, value float64, kind string, labels []metrics.Label) {
+	line := c.format(name, value, kind, labels)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.buf) > 0 && len(c.buf)+1+len(line) > c.opts.MaxPacketSize {
+		c.flushLocked()
+	}
+	if len(c.buf) > 0 {
+		c.buf = append(c.buf, '\n')
+	}
+	c.buf = append(c.buf, line...)
+}
+
+// format renders a metric in the DogStatsD format:
+// <prefix><name>:<value>|<kind>|#<tag>:<value>,...
+func (c *Client) format(name string, value float64, kind string, labels []metrics.Label) string {
+	var b strings.Builder
+	b.WriteString(sanitizeName(c.opts.Prefix + name))
+	b.WriteByte(':')
+	b.WriteString(strconv.FormatFloat(value, 'f', -1, 64))
+	b.WriteByte('|')
+	b.WriteString(kind)
+	if c.tags == "" && len(labels) == 0 {
+		return b.String()
+	}
+	b.WriteString("|#")
+	b.WriteString(c.tags)
+	for i, l := range labels {
+		if i > 0 || c.tags != "" {
+			b.WriteByte(',')
+		}
+		b.WriteString(sanitizeName(l.Name))
+		b.WriteByte(':')
+		b.WriteString(sanitizeTag(l.Value))
+	}
+	return b.String()
+}
+
+func (c *Client) flushLocked() {
+	if len(c.buf) == 0 {
+		return
+	}
+	c.conn.Write(c.buf)
+	c.buf = c.buf[:0]
+}
+
+// sanitizeName replaces characters that are significant in the StatsD line
+// format in metric and tag names.
+func sanitizeName(s string) string {
+	return replace(s, ":|@,#\n")
+}
+
+// sanitizeTag is like sanitizeName but keeps colons, which separate tag
+// names from values.
+func sanitizeTag(s string) string {
+	return replace(s, "|@,#\n")
+}
+
+func replace(s, chars string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(chars, r) {
+			return '_'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,101 @@
+package statsd
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
+)
+
+// listen starts a UDP server and returns its address and received packets.
+func listen(t *testing.T) (string, <-chan string) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	packets := make(chan string, 16)
+	go func() {
+		buf := make([]byte, 65536)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			packets <- string(buf[:n])
+		}
+	}()
+	return conn.LocalAddr().String(), packets
+}
+
+func receive(t *testing.T, packets <-chan string) string {
+	t.Helper()
+	select {
+	case p := <-packets:
+		return p
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for packet")
+		return ""
+	}
+}
+
+func TestClient(t *testing.T) {
+	addr, packets := listen(t)
+	c, err := New(Options{Addr: addr, Prefix: "txparser.", Tags: []string{"env:prod"}, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	c.Add(metrics.RPCRequests, 1, metrics.L("method", "eth_blockNumber"), metrics.L("result", "error"))
+	c.Set(metrics.BlockLag, 3, metrics.L("chain", "ethereum"))
+	c.Observe(metrics.RPCDuration, 0.25)
+	c.Add("weird|name", 1, metrics.L("path", "/a,b"))
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	expected := strings.Join([]string{
+		"txparser.rpc_requests_total:1|c|#env:prod,method:eth_blockNumber,result:error",
+		"txparser.parser_block_lag:3|g|#env:prod,chain:ethereum",
+		"txparser.rpc_request_duration_seconds:0.25|h|#env:prod",
+		"txparser.weird_name:1|c|#env:prod,path:/a_b",
+	}, "\n")
+	if got := receive(t, packets); got != expected {
+		t.Errorf("Unexpected packet:\n%s\nexpected:\n%s", got, expected)
+	}
+}
+
+func TestClient_NoTags(t *testing.T) {
+	addr, packets := listen(t)
+	c, err := New(Options{Addr: addr, FlushInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer c.Close()
+
+	c.Set(metrics.CurrentBlock, 42)
+	// Sent by the background flush.
+	if got := receive(t, packets); got != "parser_current_block:42|g" {
+		t.Errorf("Unexpected packet: %s", got)
+	}
+}
+
+func TestClient_SplitsPackets(t *testing.T) {
+	addr, packets := listen(t)
+	c, err := New(Options{Addr: addr, FlushInterval: time.Hour, MaxPacketSize: 40})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		c.Add("blocks_total", 1) // 16 bytes each
+	}
+	c.Close()
+
+	first, second := receive(t, packets), receive(t, packets)
+	if first != "blocks_total:1|c\nblocks_total:1|c" || second != "blocks_total:1|c" {
+		t.Errorf("Unexpected packets: %q %q", first, second)
+	}
+}
@@ -0,0 +1,98 @@
+package parser
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// activityFeed holds the latest events of subscribed addresses across all of
+// them, up to a fixed capacity. Events are kept ordered by block rather than
+// by arrival, as the backward scan, rescans and retries record older blocks
+// after newer ones, and an event already in the feed isn't added again. A
+// nil *activityFeed is disabled.
+type activityFeed struct {
+	mu       sync.Mutex
+	capacity int
+	events   []Event             // oldest first
+	keys     map[string]struct{} // idempotency keys of events
+}
+
+// newActivityFeed creates a feed holding up to capacity events, or returns
+// nil if capacity is not positive.
+func newActivityFeed(capacity int) *activityFeed {
+	if capacity <= 0 {
+		return nil
+	}
+	return &activityFeed{
+		capacity: capacity,
+		events:   make([]Event, 0, capacity),
+		keys:     make(map[string]struct{}, capacity),
+	}
+}
+
+// compareEvents orders events by block, then by hash and address so that
+// the order within a block is stable.
+func compareEvents(a, b Event) int {
+	return cmp.Or(
+		cmp.Compare(a.Transaction.Block, b.Transaction.Block),
+		cmp.Compare(a.Transaction.Hash, b.Transaction.Hash),
+		cmp.Compare(a.Address, b.Address),
+	)
+}
+
+// add inserts e in block order, dropping the oldest event when full. Events
+// already in the feed, and those older than all of a full feed, are ignored.
+func (f *activityFeed) add(e Event) {
+	if f == nil {
+		return
+	}
+	key := e.Transaction.IdempotencyKey(e.Address)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.keys[key]; ok {
+		return
+	}
+	if len(f.events) == f.capacity {
+		if compareEvents(e, f.events[0]) < 0 {
+			return
+		}
+		oldest := f.events[0]
+		delete(f.keys, oldest.Transaction.IdempotencyKey(oldest.Address))
+		f.events = slices.Delete(f.events, 0, 1)
+	}
+	i, _ := slices.BinarySearchFunc(f.events, e, compareEvents)
+	f.events = slices.Insert(f.events, i, e)
+	f.keys[key] = struct{}{}
+}
+
+// remove drops the event announcing tx for addr, e.g. once a reorg removed
+// it.
+func (f *activityFeed) remove(addr string, tx transaction.Transaction) {
+	if f == nil {
+		return
+	}
+	key := tx.IdempotencyKey(addr)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.keys[key]; !ok {
+		return
+	}
+	delete(f.keys, key)
+	f.events = slices.DeleteFunc(f.events, func(e Event) bool {
+		return e.Transaction.IdempotencyKey(e.Address) == key
+	})
+}
+
+// recent returns the events in the feed, newest first.
+func (f *activityFeed) recent() []Event {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]Event, len(f.events))
+	for i, e := range f.events {
+		out[len(out)-1-i] = e
+	}
+	return out
+}
@@ -0,0 +1,112 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/storage"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+func TestActivityFeed(t *testing.T) {
+	f := newActivityFeed(3)
+	if got := f.recent(); len(got) != 0 {
+		t.Fatalf("Expected an empty feed, got %+v", got)
+	}
+	for _, hash := range []string{"0x1", "0x2", "0x3", "0x4"} {
+		f.add(Event{Transaction: transaction.Transaction{Hash: hash}})
+	}
+
+	got := f.recent()
+	var hashes []string
+	for _, e := range got {
+		hashes = append(hashes, e.Transaction.Hash)
+	}
+	if len(hashes) != 3 || hashes[0] != "0x4" || hashes[2] != "0x2" {
+		t.Errorf("Expected the 3 newest events, newest first, got %v", hashes)
+	}
+
+	// Older blocks, e.g. from the backward scan, go behind newer ones, and
+	// events already in the feed aren't added again.
+	f = newActivityFeed(3)
+	for _, block := range []int{10, 11, 5, 12, 11, 4} {
+		f.add(Event{Address: "0xaaa", Transaction: transaction.Transaction{Hash: fmt.Sprintf("0x%d", block), Block: block}})
+	}
+	var blocks []int
+	for _, e := range f.recent() {
+		blocks = append(blocks, e.Transaction.Block)
+	}
+	if !slices.Equal(blocks, []int{12, 11, 10}) {
+		t.Errorf("Expected blocks [12 11 10], got %v", blocks)
+	}
+
+	// Removed events leave room for the event removed again.
+	f.remove("0xaaa", transaction.Transaction{Hash: "0x11", Block: 11})
+	f.add(Event{Address: "0xaaa", Transaction: transaction.Transaction{Hash: "0x11", Block: 13}})
+	if got := f.recent(); len(got) != 3 || got[0].Transaction.Block != 13 || got[1].Transaction.Block != 12 {
+		t.Errorf("Expected the removed event to be added again, got %+v", got)
+	}
+
+	if newActivityFeed(0) != nil {
+		t.Error("Expected a nil feed for zero capacity")
+	}
+}
+
+func TestParser_RecentActivity(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	store.Subscribe("0xto1")
+	p := NewParserWithInterval(NewMockRPCClient(), store, time.Second, Options{ActivitySize: 10}).(*parserImpl)
+
+	if err := p.processBlock(context.Background(), 1234); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+	events, err := p.RecentActivity()
+	if err != nil {
+		t.Fatalf("RecentActivity failed: %v", err)
+	}
+	if len(events) != 1 || events[0].Address != "0xto1" || events[0].Transaction.Hash != "0xhash1" {
+		t.Errorf("Expected only the subscribed address's transaction, got %+v", events)
+	}
+
+	disabled := NewParserWithInterval(NewMockRPCClient(), store, time.Second, Options{}).(ActivityReader)
+	if _, err := disabled.RecentActivity(); !errors.Is(err, ErrActivityDisabled) {
+		t.Errorf("Expected ErrActivityDisabled, got %v", err)
+	}
+}
+
+func TestParser_RecentActivity_BackwardScan(t *testing.T) {
+	client := &rangeClient{MockRPCClient: NewMockRPCClient(), head: "0x96"}
+	store := storage.NewMemoryStorage()
+	store.Subscribe("0xfrom")
+	p := NewParserWithInterval(client, store, time.Second, Options{ActivitySize: 10}).(*parserImpl)
+	p.setBlock(100)
+	ctx := context.Background()
+
+	// The backward scan runs alongside forward polling of blocks 101-150.
+	p.wg.Add(1)
+	go p.scanBackward(ctx, 100, 51)
+	if err := p.checkForNewBlocks(ctx); err != nil {
+		t.Fatalf("checkForNewBlocks failed: %v", err)
+	}
+	p.wg.Wait()
+	// A rescan records the newest block again.
+	if err := p.processBlock(ctx, 150); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+
+	events, err := p.RecentActivity()
+	if err != nil {
+		t.Fatalf("RecentActivity failed: %v", err)
+	}
+	var blocks []int
+	for _, e := range events {
+		blocks = append(blocks, e.Transaction.Block)
+	}
+	if !slices.Equal(blocks, []int{150, 149, 148, 147, 146, 145, 144, 143, 142, 141}) {
+		t.Errorf("Expected the 10 newest blocks, newest first, got %v", blocks)
+	}
+}
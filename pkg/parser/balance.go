@@ -0,0 +1,233 @@
+// Package parser contains the block poller and parsing logic.
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+var (
+	// ErrBalancesDisabled is returned by Balance when balance tracking is
+	// off or the client can't fetch balances.
+	ErrBalancesDisabled = errors.New("balance tracking is disabled")
+	// ErrNotSubscribed is returned by Balance for addresses that aren't
+	// subscribed.
+	ErrNotSubscribed = errors.New("address not subscribed")
+	// ErrNoBlock is returned by Balance until the first block is processed,
+	// as there is no block to seed balances at yet.
+	ErrNoBlock = errors.New("no block processed yet")
+)
+
+// Balance is the native balance of an address.
+type Balance struct {
+	Address string `json:"address"`
+	// Balance is in wei.
+	Balance transaction.Value `json:"balance"`
+	// Block is the last processed block the balance reflects.
+	Block int `json:"block"`
+}
+
+// BalanceTracker is implemented by parsers that keep a running balance of
+// subscribed addresses.
+type BalanceTracker interface {
+	// Balance returns the balance of a subscribed address. The first call
+	// for an address seeds it with eth_getBalance; later ones apply the
+	// values and fees of the address's transactions since. refresh seeds it
+	// again, e.g. to correct drift from transfers the parser can't see.
+	Balance(ctx context.Context, address string, refresh bool) (Balance, error)
+}
+
+// appliedDepth is how many blocks of idempotency keys an account keeps, so
+// that replayed transactions aren't counted twice. Older keys are pruned to
+// bound memory, and a transaction from a pruned block marks the account
+// stale instead, as whether it was counted is no longer known.
+const appliedDepth = 256
+
+// balanceBook holds the running balances of the addresses whose balance was
+// requested. A nil *balanceBook means tracking is disabled.
+type balanceBook struct {
+	client rpc.BalanceFetcher
+
+	mu       sync.Mutex
+	accounts map[string]*account
+}
+
+// account is the running balance of one address: the balance fetched at
+// block seedBlock plus delta, the net effect of the transactions stored for
+// it in later blocks.
+type account struct {
+	seedBlock int
+	seed      *big.Int      // nil until the seed is fetched
+	seeding   chan struct{} // closed once the pending seed fetch ends
+	delta     *big.Int
+	applied   map[string]int // hash/direction keys included in delta, by block
+	pruned    int            // applied holds no keys at or below this block
+	// stale is set when a transaction couldn't be accounted for exactly,
+	// e.g. an outbound one without a fee, so the next read seeds again.
+	stale bool
+}
+
+func newBalanceBook(client rpc.BalanceFetcher) *balanceBook {
+	if client == nil {
+		return nil
+	}
+	return &balanceBook{client: client, accounts: make(map[string]*account)}
+}
+
+// apply adds the effect of tx, stored for addr, to addr's running balance
+// if it is tracked and tx is newer than the balance's seed.
+func (b *balanceBook) apply(addr string, tx transaction.Transaction) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	acct := b.accounts[addr]
+	if acct == nil || tx.Block <= acct.seedBlock {
+		return
+	}
+	if tx.Block <= acct.pruned {
+		acct.stale = true
+		return
+	}
+	key := tx.IdempotencyKey(addr)
+	if _, ok := acct.applied[key]; ok {
+		return
+	}
+	acct.applied[key] = tx.Block
+	acct.prune(tx.Block)
+
+	switch tx.Direction {
+	case transaction.DirectionIn:
+		acct.delta.Add(acct.delta, tx.Value.Wei())
+		return
+	case transaction.DirectionOut:
+		acct.delta.Sub(acct.delta, tx.Value.Wei())
+	}
+	// The sender pays the fee, including on self-transfers, whose value
+	// doesn't change the balance.
+	if tx.Fee == nil {
+		acct.stale = true
+		return
+	}
+	acct.delta.Sub(acct.delta, tx.Fee.Wei())
+}
+
+// prune drops the idempotency keys more than appliedDepth blocks below
+// block. It runs once every appliedDepth blocks, so at most twice that many
+// blocks of keys are kept.
+func (a *account) prune(block int) {
+	floor := block - appliedDepth
+	if floor < a.pruned+appliedDepth {
+		return
+	}
+	for key, b := range a.applied {
+		if b <= floor {
+			delete(a.applied, key)
+		}
+	}
+	a.pruned = floor
+}
+
+// forget stops tracking addr, e.g. once it is unsubscribed.
+func (b *balanceBook) forget(addr string) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.accounts, addr)
+}
+
+// balance returns the running balance of addr, seeding it at block, the
+// last processed block, when it isn't tracked yet, is stale or refresh is
+// set.
+func (b *balanceBook) balance(ctx context.Context, addr string, block int, refresh bool) (Balance, error) {
+	for {
+		b.mu.Lock()
+		acct := b.accounts[addr]
+		if acct != nil && acct.seeding != nil {
+			// Another caller is seeding addr; its seed is as fresh as a
+			// refresh would get.
+			seeding := acct.seeding
+			b.mu.Unlock()
+			select {
+			case <-seeding:
+				refresh = false
+				continue
+			case <-ctx.Done():
+				return Balance{}, ctx.Err()
+			}
+		}
+		if acct == nil || acct.seed == nil || acct.stale || refresh {
+			break
+		}
+		out := acct.current(addr, block)
+		b.mu.Unlock()
+		return out, nil
+	}
+
+	// Transactions in blocks after block accumulate in delta while the seed
+	// is fetched, so none are missed.
+	acct := &account{seedBlock: block, seeding: make(chan struct{}), delta: new(big.Int), applied: make(map[string]int)}
+	b.accounts[addr] = acct
+	b.mu.Unlock()
+
+	seed, err := b.fetch(ctx, addr, block)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	close(acct.seeding)
+	acct.seeding = nil
+	if err != nil {
+		if b.accounts[addr] == acct {
+			delete(b.accounts, addr)
+		}
+		return Balance{}, err
+	}
+	acct.seed = seed
+	return acct.current(addr, block), nil
+}
+
+// current returns the account's balance as of block, the last processed
+// block.
+func (a *account) current(addr string, block int) Balance {
+	return Balance{
+		Address: addr,
+		Balance: transaction.NewValue(new(big.Int).Add(a.seed, a.delta)),
+		Block:   max(block, a.seedBlock),
+	}
+}
+
+// fetch returns the balance of addr at the end of block.
+func (b *balanceBook) fetch(ctx context.Context, addr string, block int) (*big.Int, error) {
+	raw, err := b.client.GetBalance(ctx, addr, block)
+	if err != nil {
+		return nil, err
+	}
+	v, err := transaction.ParseValue(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid balance of %s: %w", addr, err)
+	}
+	return v.Wei(), nil
+}
+
+// Balance returns the running balance of a subscribed address.
+func (p *parserImpl) Balance(ctx context.Context, address string, refresh bool) (Balance, error) {
+	if p.balances == nil {
+		return Balance{}, ErrBalancesDisabled
+	}
+	if !p.store.IsSubscribed(address) {
+		return Balance{}, ErrNotSubscribed
+	}
+	block := p.GetCurrentBlock()
+	if block == 0 {
+		return Balance{}, ErrNoBlock
+	}
+	return p.balances.balance(ctx, address, block, refresh)
+}
@@ -0,0 +1,140 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// balanceClient is a MockRPCClient serving fixed balances.
+type balanceClient struct {
+	*MockRPCClient
+	balances map[string]int64
+	fetches  []int // blocks balances were fetched at
+}
+
+func (c *balanceClient) GetBalance(ctx context.Context, address string, blockNumber int) (string, error) {
+	c.fetches = append(c.fetches, blockNumber)
+	return fmt.Sprintf("0x%x", c.balances[address]), nil
+}
+
+func TestParser_Balance(t *testing.T) {
+	const addr = "0xto1"
+	client := &balanceClient{MockRPCClient: NewMockRPCClient(), balances: map[string]int64{addr: 1_000_000}}
+	store := NewMockStorage()
+	p := NewParserWithInterval(client, store, time.Second, Options{TrackBalances: true}).(*parserImpl)
+	ctx := context.Background()
+
+	if _, err := p.Balance(ctx, addr, false); !errors.Is(err, ErrNotSubscribed) {
+		t.Errorf("Expected ErrNotSubscribed, got %v", err)
+	}
+	store.Subscribe(addr)
+	if _, err := p.Balance(ctx, addr, false); !errors.Is(err, ErrNoBlock) {
+		t.Errorf("Expected ErrNoBlock, got %v", err)
+	}
+
+	p.setBlock(1233)
+	b, err := p.Balance(ctx, addr, false)
+	if err != nil {
+		t.Fatalf("Balance failed: %v", err)
+	}
+	if b.Balance.String() != "1000000" || b.Block != 1233 {
+		t.Errorf("Unexpected seeded balance %+v", b)
+	}
+
+	// Block 1234 sends 0x1000 to addr. Processing it twice, as a retry
+	// would, counts it once.
+	for range 2 {
+		if err := p.processBlock(ctx, 1234); err != nil {
+			t.Fatalf("processBlock failed: %v", err)
+		}
+	}
+	p.setBlock(1234)
+	if b, _ = p.Balance(ctx, addr, false); b.Balance.String() != "1004096" || b.Block != 1234 {
+		t.Errorf("Expected the inbound value to be added, got %+v", b)
+	}
+
+	// Older blocks, e.g. from the backward scan, are already in the seed.
+	p.record(addr, transaction.Transaction{Hash: "0xold", To: addr, Value: transaction.WeiValue(5), Block: 1200, Direction: transaction.DirectionIn})
+
+	// Fees are subtracted from outgoing transactions.
+	fee := transaction.WeiValue(100)
+	p.record(addr, transaction.Transaction{Hash: "0xout", From: addr, Value: transaction.WeiValue(4000), Block: 1235, Direction: transaction.DirectionOut, Fee: &fee})
+	p.record(addr, transaction.Transaction{Hash: "0xself", From: addr, To: addr, Value: transaction.WeiValue(7), Block: 1235, Direction: transaction.DirectionSelf, Fee: &fee})
+	if b, _ = p.Balance(ctx, addr, false); b.Balance.String() != "999896" {
+		t.Errorf("Expected value and fees to be subtracted, got %s", b.Balance)
+	}
+	if len(client.fetches) != 1 {
+		t.Errorf("Expected a single balance fetch, got %v", client.fetches)
+	}
+
+	// Without a fee the balance can't be kept exact and is fetched again.
+	p.record(addr, transaction.Transaction{Hash: "0xnofee", From: addr, Value: transaction.WeiValue(1), Block: 1235, Direction: transaction.DirectionOut})
+	client.balances[addr] = 42
+	if b, _ = p.Balance(ctx, addr, false); b.Balance.String() != "42" || len(client.fetches) != 2 || client.fetches[1] != 1234 {
+		t.Errorf("Expected the balance to be fetched again, got %+v after %v", b, client.fetches)
+	}
+	client.balances[addr] = 43
+	if b, _ = p.Balance(ctx, addr, true); b.Balance.String() != "43" {
+		t.Errorf("Expected refresh to fetch the balance again, got %s", b.Balance)
+	}
+
+	// Unsubscribing forgets the balance.
+	p.Unsubscribe(addr)
+	if len(p.balances.accounts) != 0 {
+		t.Error("Expected the balance to be forgotten")
+	}
+}
+
+func TestParser_Balance_PrunesAppliedKeys(t *testing.T) {
+	const addr = "0xto1"
+	client := &balanceClient{MockRPCClient: NewMockRPCClient(), balances: map[string]int64{addr: 0}}
+	store := NewMockStorage()
+	store.Subscribe(addr)
+	p := NewParserWithInterval(client, store, time.Second, Options{TrackBalances: true}).(*parserImpl)
+	ctx := context.Background()
+	p.setBlock(1000)
+	if _, err := p.Balance(ctx, addr, false); err != nil {
+		t.Fatalf("Balance failed: %v", err)
+	}
+
+	in := func(block int) transaction.Transaction {
+		return transaction.Transaction{Hash: fmt.Sprintf("0x%d", block), To: addr, Value: transaction.WeiValue(1), Block: block, Direction: transaction.DirectionIn}
+	}
+	for block := 1001; block <= 3000; block++ {
+		p.record(addr, in(block))
+	}
+	// A recent transaction replayed is still counted once.
+	p.record(addr, in(3000))
+	p.setBlock(3000)
+	if b, _ := p.Balance(ctx, addr, false); b.Balance.String() != "2000" {
+		t.Errorf("Expected every transaction to be counted once, got %s", b.Balance)
+	}
+	if n := len(p.balances.accounts[addr].applied); n > 2*appliedDepth {
+		t.Errorf("Expected at most %d idempotency keys, got %d", 2*appliedDepth, n)
+	}
+
+	// Whether a transaction from a pruned block was counted is unknown, so
+	// the balance is fetched again.
+	p.record(addr, in(1001))
+	if _, err := p.Balance(ctx, addr, false); err != nil || len(client.fetches) != 2 {
+		t.Errorf("Expected the balance to be fetched again, got %v after %v", err, client.fetches)
+	}
+}
+
+func TestParser_Balance_Disabled(t *testing.T) {
+	client := &balanceClient{MockRPCClient: NewMockRPCClient()}
+	for _, p := range []Parser{
+		NewParserWithInterval(client, NewMockStorage(), time.Second, Options{}),
+		// The client can't fetch balances.
+		NewParserWithInterval(NewMockRPCClient(), NewMockStorage(), time.Second, Options{TrackBalances: true}),
+	} {
+		if _, err := p.(BalanceTracker).Balance(context.Background(), "0xaaa", false); !errors.Is(err, ErrBalancesDisabled) {
+			t.Errorf("Expected ErrBalancesDisabled, got %v", err)
+		}
+	}
+}
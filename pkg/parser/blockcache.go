@@ -0,0 +1,93 @@
+// Package parser contains the block poller and parsing logic.
+package parser
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+)
+
+// blockCache is a fixed-capacity LRU cache of the transactions of recently
+// fetched blocks, sparing retries and overlapping scans a refetch. A nil
+// *blockCache is a disabled cache. Entries reflect the chain when the block
+// was fetched; blocks orphaned by a detected reorg are removed.
+type blockCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[int]*list.Element
+	order    *list.List // of *cachedBlock, most recently used first
+}
+
+type cachedBlock struct {
+	number int
+	txs    []rpc.Transaction
+}
+
+// newBlockCache creates a cache holding up to capacity blocks, or returns nil
+// if capacity is not positive.
+func newBlockCache(capacity int) *blockCache {
+	if capacity <= 0 {
+		return nil
+	}
+	return &blockCache{capacity: capacity, entries: make(map[int]*list.Element), order: list.New()}
+}
+
+// get returns the cached transactions of block number.
+func (c *blockCache) get(number int) ([]rpc.Transaction, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[number]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*cachedBlock).txs, true
+}
+
+// add caches the transactions of block number, evicting the least recently
+// used block when full.
+func (c *blockCache) add(number int, txs []rpc.Transaction) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[number]; ok {
+		e.Value.(*cachedBlock).txs = txs
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[number] = c.order.PushFront(&cachedBlock{number: number, txs: txs})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedBlock).number)
+	}
+}
+
+// remove drops block number from the cache.
+func (c *blockCache) remove(number int) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[number]; ok {
+		c.order.Remove(e)
+		delete(c.entries, number)
+	}
+}
+
+// len returns the number of cached blocks.
+func (c *blockCache) len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
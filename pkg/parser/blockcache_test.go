@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+)
+
+func TestBlockCache(t *testing.T) {
+	c := newBlockCache(2)
+	c.add(1, []rpc.Transaction{{Hash: "0x1"}})
+	c.add(2, []rpc.Transaction{{Hash: "0x2"}})
+
+	// Reading 1 makes 2 the least recently used block.
+	if txs, ok := c.get(1); !ok || txs[0].Hash != "0x1" {
+		t.Fatalf("Expected block 1 to be cached, got %v %v", txs, ok)
+	}
+	c.add(3, nil)
+	if _, ok := c.get(2); ok {
+		t.Error("Expected block 2 to be evicted")
+	}
+	if _, ok := c.get(1); !ok {
+		t.Error("Expected block 1 to be kept")
+	}
+	if txs, ok := c.get(3); !ok || len(txs) != 0 {
+		t.Errorf("Expected empty block 3 to be cached, got %v %v", txs, ok)
+	}
+
+	// Re-adding replaces the entry without growing the cache.
+	c.add(3, []rpc.Transaction{{Hash: "0x3"}})
+	if txs, _ := c.get(3); len(txs) != 1 || c.len() != 2 {
+		t.Errorf("Expected block 3 to be replaced, got %v with %d entries", txs, c.len())
+	}
+
+	c.remove(3)
+	if _, ok := c.get(3); ok || c.len() != 1 {
+		t.Errorf("Expected block 3 to be removed, got %d entries", c.len())
+	}
+}
+
+func TestBlockCache_Disabled(t *testing.T) {
+	c := newBlockCache(0)
+	if c != nil {
+		t.Fatal("Expected a nil cache for zero capacity")
+	}
+	c.add(1, nil)
+	c.remove(1)
+	if _, ok := c.get(1); ok || c.len() != 0 {
+		t.Error("Expected a disabled cache to hold nothing")
+	}
+}
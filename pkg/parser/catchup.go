@@ -0,0 +1,107 @@
+// Package parser contains the block poller and parsing logic.
+package parser
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+)
+
+// prefetched is a block's transactions fetched ahead of being stored. done
+// is closed once the fetch finished.
+type prefetched struct {
+	number int
+	txs    []rpc.Transaction
+	err    error
+	done   chan struct{}
+}
+
+// catchUp processes blocks from through to, fetching up to p.catchUpWorkers
+// of them concurrently. Fetched blocks pass through a queue of
+// p.catchUpQueue blocks to be stored and the current block advanced in
+// order, so the current block never moves past a block that hasn't been
+// stored. Fetching pauses while the queue is full, so slow storage holds
+// back the node instead of blocks piling up in memory.
+func (p *parserImpl) catchUp(ctx context.Context, from, to int) {
+	logger := p.logger.With("scan", "forward")
+	logger.Info("catching up", "from", from, "to", to, "workers", p.catchUpWorkers, "queue", p.catchUpQueue)
+	ctx, cancel := context.WithCancel(ctx)
+	queue := make(chan *prefetched, p.catchUpQueue)
+	go p.fetchAhead(ctx, from, to, queue)
+	defer func() {
+		// Stop fetching and wait for fetches in flight before returning.
+		cancel()
+		for range queue {
+		}
+		p.metrics.Set(metrics.CatchUpQueueDepth, 0)
+	}()
+
+	for b := range queue {
+		p.metrics.Set(metrics.CatchUpQueueDepth, float64(len(queue)))
+		<-b.done
+		if ctx.Err() != nil {
+			return
+		}
+		err := p.storeBlock(ctx, b.number, func(ctx context.Context, fn func(rpc.Transaction)) error {
+			trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("block.prefetched", true))
+			for _, tx := range b.txs {
+				fn(tx)
+			}
+			return b.err
+		})
+		if err != nil {
+			err = p.retryBlock(ctx, b.number, err)
+		}
+		if err != nil {
+			logger.Error("failed to process block", logging.KeyBlock, b.number, logging.KeyError, err)
+		} else {
+			logger.Info("processed block", logging.KeyBlock, b.number)
+			p.checkReorg(ctx, b.number)
+		}
+		p.setBlock(b.number)
+	}
+	logger.Info("caught up", logging.KeyBlock, to)
+}
+
+// fetchAhead fetches blocks from through to, with up to p.catchUpWorkers
+// requests at a time, and queues them in block order as their fetches
+// start. It stops when ctx is done and closes queue once its fetches have
+// finished.
+func (p *parserImpl) fetchAhead(ctx context.Context, from, to int, queue chan<- *prefetched) {
+	var wg sync.WaitGroup
+	defer func() {
+		wg.Wait()
+		close(queue)
+	}()
+	workers := make(chan struct{}, p.catchUpWorkers)
+	for number := from; number <= to; number++ {
+		select {
+		case workers <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		b := &prefetched{number: number, done: make(chan struct{})}
+		select {
+		case queue <- b:
+		case <-ctx.Done():
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-workers }()
+			defer close(b.done)
+			ctx, cancel := p.blockContext(ctx)
+			defer cancel()
+			b.err = p.blockTransactions(ctx, b.number, func(tx rpc.Transaction) {
+				b.txs = append(b.txs, tx)
+			})
+		}()
+	}
+}
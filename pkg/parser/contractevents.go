@@ -0,0 +1,115 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// indexContractEvents stores the logs of block number matching an event
+// subscription and delivers them to log watchers.
+func (p *parserImpl) indexContractEvents(ctx context.Context, number int) error {
+	subs := p.eventStore.EventSubscriptions()
+	if len(subs) == 0 {
+		return nil
+	}
+	wanted := make(map[transaction.EventSubscription]bool, len(subs))
+	var contracts, topics []string
+	seen := make(map[string]bool)
+	for _, sub := range subs {
+		wanted[sub] = true
+		if !seen[sub.Contract] {
+			seen[sub.Contract] = true
+			contracts = append(contracts, sub.Contract)
+		}
+		if !seen[sub.Topic0] {
+			seen[sub.Topic0] = true
+			topics = append(topics, sub.Topic0)
+		}
+	}
+
+	// The filter matches every subscribed topic of every subscribed
+	// contract, so pairs nobody subscribed to are dropped below.
+	logs, err := p.logs.GetLogs(ctx, rpc.LogFilter{
+		FromBlock: number,
+		ToBlock:   number,
+		Addresses: contracts,
+		Topics:    [][]string{topics},
+	})
+	if err != nil {
+		return fmt.Errorf("contract events: %w", err)
+	}
+	for _, l := range logs {
+		stored := transaction.Log{
+			Contract: strings.ToLower(l.Address),
+			Topics:   l.Topics,
+			Data:     l.Data,
+			Block:    number,
+			Hash:     l.TransactionHash,
+			LogIndex: hexToInt(l.LogIndex),
+		}
+		if l.Removed || !wanted[stored.Subscription()] {
+			continue
+		}
+		if len(p.ignored) > 0 && p.ignored[stored.Contract] {
+			p.metrics.Add(metrics.TransactionsIgnored, 1)
+			continue
+		}
+		if p.dryRun != nil {
+			continue
+		}
+		p.eventStore.AddLog(stored)
+		if p.logEvents.active() {
+			p.logEvents.publish(stored.Contract, stored)
+		}
+	}
+	return nil
+}
+
+// SubscribeEvents registers an event subscription with the underlying
+// storage.
+func (p *parserImpl) SubscribeEvents(contract, topic0 string) (bool, error) {
+	if p.eventStore == nil {
+		return false, ErrEventsUnsupported
+	}
+	return p.eventStore.SubscribeEvents(transaction.EventSubscription{Contract: contract, Topic0: topic0}), nil
+}
+
+// UnsubscribeEvents removes an event subscription from the underlying
+// storage.
+func (p *parserImpl) UnsubscribeEvents(contract, topic0 string) (bool, error) {
+	if p.eventStore == nil {
+		return false, ErrEventsUnsupported
+	}
+	return p.eventStore.UnsubscribeEvents(transaction.EventSubscription{Contract: contract, Topic0: topic0}), nil
+}
+
+// EventSubscriptions returns the event subscriptions from the underlying
+// storage.
+func (p *parserImpl) EventSubscriptions() ([]transaction.EventSubscription, error) {
+	if p.eventStore == nil {
+		return nil, ErrEventsUnsupported
+	}
+	return p.eventStore.EventSubscriptions(), nil
+}
+
+// GetLogs returns the logs stored for an event subscription.
+func (p *parserImpl) GetLogs(contract, topic0 string) ([]transaction.Log, error) {
+	if p.eventStore == nil {
+		return nil, ErrEventsUnsupported
+	}
+	return p.eventStore.GetLogs(transaction.EventSubscription{Contract: contract, Topic0: topic0}), nil
+}
+
+// WatchLogs streams newly stored logs of the given contracts.
+func (p *parserImpl) WatchLogs(ctx context.Context, contracts ...string) <-chan transaction.Log {
+	lower := make([]string, len(contracts))
+	for i, c := range contracts {
+		lower[i] = strings.ToLower(c)
+	}
+	return p.logEvents.watch(ctx, false, lower)
+}
@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/storage"
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+)
+
+const (
+	eventPool = "0x88e6a0c2ddd26feeb64f039a2c41296fcb3f5640"
+	swapTopic = "0xc42079f94a6350d7e6235f29174924f928cc2ac818eb64fed8004e115fbcca67"
+	mintTopic = "0x7a53080ba414158be7ec69b987b5fb7d07dee101fe85488f0853ae16239d0bde"
+)
+
+func TestParser_ContractEvents(t *testing.T) {
+	client := &logClient{MockRPCClient: NewMockRPCClient(), logs: []rpc.Log{
+		{Address: strings.ToUpper(eventPool[:2]) + eventPool[2:], Topics: []string{swapTopic, topic(tokenFrom)}, Data: "0x01", TransactionHash: "0xswap", LogIndex: "0x4"},
+		// A subscribed topic of another subscribed contract.
+		{Address: tokenUSDC, Topics: []string{swapTopic}, TransactionHash: "0xother", LogIndex: "0x5"},
+		{Address: eventPool, Topics: []string{swapTopic}, TransactionHash: "0xremoved", LogIndex: "0x6", Removed: true},
+	}}
+	store := storage.NewMemoryStorage()
+	p := NewParserWithInterval(client, store, time.Second, Options{}).(*parserImpl)
+
+	// Without subscriptions no logs are fetched.
+	if err := p.processBlock(context.Background(), 1234); err != nil || len(client.filters) != 0 {
+		t.Fatalf("Expected no logs to be fetched, got %v %+v", err, client.filters)
+	}
+
+	if ok, err := p.SubscribeEvents(strings.ToUpper(eventPool), swapTopic); err != nil || !ok {
+		t.Fatalf("SubscribeEvents failed: %v %v", ok, err)
+	}
+	p.SubscribeEvents(tokenUSDC, mintTopic)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watch := p.WatchLogs(ctx, eventPool)
+
+	if err := p.processBlock(context.Background(), 1234); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+	f := client.filters[0]
+	if len(f.Addresses) != 2 || len(f.Topics) != 1 || len(f.Topics[0]) != 2 {
+		t.Errorf("Expected one filter over both contracts and topics, got %+v", f)
+	}
+
+	logs, err := p.GetLogs(eventPool, swapTopic)
+	if err != nil || len(logs) != 1 {
+		t.Fatalf("Expected the swap to be stored, got %+v %v", logs, err)
+	}
+	if l := logs[0]; l.Contract != eventPool || l.Block != 1234 || l.LogIndex != 4 || l.Data != "0x01" || len(l.Topics) != 2 {
+		t.Errorf("Unexpected log %+v", l)
+	}
+	if logs, _ := p.GetLogs(tokenUSDC, mintTopic); len(logs) != 0 {
+		t.Errorf("Expected unsubscribed pairs to be dropped, got %+v", logs)
+	}
+	select {
+	case l := <-watch:
+		if l.Hash != "0xswap" {
+			t.Errorf("Unexpected watched log %+v", l)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected the swap to be delivered to watchers")
+	}
+
+	if ok, _ := p.UnsubscribeEvents(eventPool, swapTopic); !ok {
+		t.Error("Expected the subscription to be removed")
+	}
+	if subs, _ := p.EventSubscriptions(); len(subs) != 1 || subs[0].Topic0 != mintTopic {
+		t.Errorf("Unexpected subscriptions %+v", subs)
+	}
+
+	// Failing to fetch logs fails the block.
+	client.err = errors.New("boom")
+	if err := p.processBlock(context.Background(), 1235); err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestParser_ContractEvents_Unsupported(t *testing.T) {
+	// The mock storage keeps no logs.
+	p := NewParserWithInterval(&logClient{MockRPCClient: NewMockRPCClient()}, NewMockStorage(), time.Second, Options{}).(*parserImpl)
+	if _, err := p.SubscribeEvents(eventPool, swapTopic); !errors.Is(err, ErrEventsUnsupported) {
+		t.Errorf("Expected ErrEventsUnsupported, got %v", err)
+	}
+	// The client can't fetch logs.
+	p = NewParserWithInterval(NewMockRPCClient(), storage.NewMemoryStorage(), time.Second, Options{}).(*parserImpl)
+	if _, err := p.GetLogs(eventPool, swapTopic); !errors.Is(err, ErrEventsUnsupported) {
+		t.Errorf("Expected ErrEventsUnsupported, got %v", err)
+	}
+}
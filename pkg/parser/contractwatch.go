@@ -0,0 +1,77 @@
+package parser
+
+import (
+	"strings"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// recordContractCall stores tx, sent to contract with input data input, as
+// a call of the contract if it is watched. The call keeps its method
+// selector even when the ABI doesn't know the method. In dry-run mode
+// nothing is stored.
+func (p *parserImpl) recordContractCall(contract string, tx transaction.Transaction, input string) {
+	if p.dryRun != nil || !p.contractCalls.IsWatched(contract) {
+		return
+	}
+	if sel, ok := methodSelector(input); ok {
+		call := transaction.Call{Selector: sel}
+		if tx.Call != nil {
+			call = *tx.Call
+			call.Selector = sel
+		}
+		tx.Call = &call
+	}
+	tx.Direction = transaction.DirectionIn
+	tx.IndexedAt = time.Now().UTC()
+	p.contractCalls.AddContractCall(contract, tx)
+}
+
+// methodSelector returns the lowercase 0x-prefixed method selector of
+// input, reporting false if input is too short to hold one, as for plain
+// value transfers.
+func methodSelector(input string) (string, bool) {
+	input = strings.TrimPrefix(input, "0x")
+	if len(input) < 8 {
+		return "", false
+	}
+	sel := strings.ToLower(input[:8])
+	if strings.Trim(sel, "0123456789abcdef") != "" {
+		return "", false
+	}
+	return "0x" + sel, true
+}
+
+// WatchContract starts recording the calls to a contract.
+func (p *parserImpl) WatchContract(contract string) (bool, error) {
+	if p.contractCalls == nil {
+		return false, ErrContractWatchUnsupported
+	}
+	return p.contractCalls.WatchContract(strings.ToLower(contract)), nil
+}
+
+// UnwatchContract stops recording the calls to a contract.
+func (p *parserImpl) UnwatchContract(contract string) (bool, error) {
+	if p.contractCalls == nil {
+		return false, ErrContractWatchUnsupported
+	}
+	return p.contractCalls.UnwatchContract(strings.ToLower(contract)), nil
+}
+
+// WatchedContracts returns the watched contracts from the underlying
+// storage.
+func (p *parserImpl) WatchedContracts() ([]string, error) {
+	if p.contractCalls == nil {
+		return nil, ErrContractWatchUnsupported
+	}
+	return p.contractCalls.WatchedContracts(), nil
+}
+
+// ContractCalls returns the calls stored for a watched contract.
+func (p *parserImpl) ContractCalls(contract string) ([]transaction.Transaction, error) {
+	if p.contractCalls == nil {
+		return nil, ErrContractWatchUnsupported
+	}
+	return p.contractCalls.GetContractCalls(strings.ToLower(contract)), nil
+}
@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/storage"
+	"github.com/danieloluwadare/tw-txparser/pkg/abi"
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+)
+
+func TestParser_ContractWatch(t *testing.T) {
+	const pool = "0xb4e16d0168e52d35cacd2c6185b44281ec28c9dc"
+	client := NewMockRPCClient()
+	client.blockResponse.Transactions = []rpc.Transaction{
+		// transfer(address,uint256), known to the builtin ABI
+		{Hash: "0xcall1", From: "0xuser", To: pool[:2] + strings.ToUpper(pool[2:6]) + pool[6:], Value: "0x0", Input: "0xa9059cbb" + strings.Repeat("0", 24) + strings.Repeat("1", 40) + strings.Repeat("0", 63) + "1"},
+		// swap(uint256,uint256,address,bytes), unknown
+		{Hash: "0xcall2", From: "0xuser", To: pool, Value: "0x0", Input: "0x022C0D9F"},
+		// A plain value transfer has no selector.
+		{Hash: "0xcall3", From: "0xuser", To: pool, Value: "0x10", Input: "0x"},
+		{Hash: "0xother", From: "0xuser", To: "0xto1", Value: "0x0", Input: "0xa9059cbb"},
+	}
+	store := storage.NewMemoryStorage()
+	p := NewParserWithInterval(client, store, time.Second, Options{ABI: abi.Builtin()}).(*parserImpl)
+	if ok, err := p.WatchContract(pool); !ok || err != nil {
+		t.Fatalf("WatchContract failed: %v %v", ok, err)
+	}
+	if err := p.processBlock(context.Background(), 1234); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+
+	calls, err := p.ContractCalls(pool)
+	if err != nil {
+		t.Fatalf("ContractCalls failed: %v", err)
+	}
+	if len(calls) != 3 {
+		t.Fatalf("Expected 3 calls, got %+v", calls)
+	}
+	if c := calls[0].Call; c == nil || c.Selector != "0xa9059cbb" || c.Method != "transfer" || len(c.Args) != 2 {
+		t.Errorf("Expected a decoded transfer call, got %+v", c)
+	}
+	if c := calls[1].Call; c == nil || c.Selector != "0x022c0d9f" || c.Method != "" {
+		t.Errorf("Expected only the selector of the unknown call, got %+v", c)
+	}
+	if calls[2].Call != nil || calls[2].Block != 1234 || calls[2].IndexedAt.IsZero() {
+		t.Errorf("Unexpected value transfer %+v", calls[2])
+	}
+	// The watch doesn't subscribe the contract.
+	if store.IsSubscribed(pool) {
+		t.Error("Expected the contract not to be subscribed")
+	}
+
+	if ok, _ := p.UnwatchContract(pool); !ok {
+		t.Error("Expected the contract to be unwatched")
+	}
+	if got, _ := p.WatchedContracts(); len(got) != 0 {
+		t.Errorf("Expected no watched contracts, got %v", got)
+	}
+
+	// Storages without contract watches don't support them.
+	p = NewParserWithInterval(client, NewMockStorage(), time.Second, Options{}).(*parserImpl)
+	if _, err := p.WatchContract(pool); !errors.Is(err, ErrContractWatchUnsupported) {
+		t.Errorf("Expected ErrContractWatchUnsupported, got %v", err)
+	}
+}
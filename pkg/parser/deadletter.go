@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
+)
+
+// DeadLetters records blocks that still fail once their retries are used
+// up, e.g. a deadletter.ChainQueue.
+type DeadLetters interface {
+	AddBlock(block, attempts int, err error) error
+}
+
+// processBlockWithRetries processes block number, retrying it if it fails.
+func (p *parserImpl) processBlockWithRetries(ctx context.Context, number int) error {
+	if err := p.processBlock(ctx, number); err != nil {
+		return p.retryBlock(ctx, number, err)
+	}
+	return nil
+}
+
+// retryBlock retries block number, whose first attempt failed with err, up
+// to the retry budget with exponential backoff. A block still failing then
+// is reported to OnError and moved to the dead-letter queue, unless ctx was
+// cancelled meanwhile.
+// The error of the last attempt is returned.
+func (p *parserImpl) retryBlock(ctx context.Context, number int, err error) error {
+	attempts := 1
+	for ; attempts <= p.blockRetries; attempts++ {
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(p.retryBackoff << (attempts - 1)):
+		}
+		p.logger.Warn("retrying block", logging.KeyBlock, number, "attempt", attempts+1, logging.KeyError, err)
+		if err = p.processBlock(ctx, number); err == nil {
+			return nil
+		}
+	}
+	p.reportError(ctx, err)
+	if p.deadLetters == nil || ctx.Err() != nil {
+		return err
+	}
+	p.metrics.Add(metrics.BlocksDeadLettered, 1)
+	p.logger.Error("moving block to the dead-letter queue", logging.KeyBlock, number, "attempts", attempts, logging.KeyError, err)
+	if dlErr := p.deadLetters.AddBlock(number, attempts, err); dlErr != nil {
+		p.logger.Error("failed to persist dead-lettered block", logging.KeyBlock, number, logging.KeyError, dlErr)
+		p.reportError(ctx, fmt.Errorf("%w: dead-lettered block %d: %w", ErrStorageWrite, number, dlErr))
+	}
+	return err
+}
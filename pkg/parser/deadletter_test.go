@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+)
+
+// flakyClient fails the first failures block fetches.
+type flakyClient struct {
+	*MockRPCClient
+	failures int
+	fetches  int
+}
+
+func (c *flakyClient) GetBlockByNumberInt(ctx context.Context, blockNumber int, includeTransactions bool) (*rpc.Block, error) {
+	c.fetches++
+	if c.fetches <= c.failures {
+		return nil, errors.New("connection reset")
+	}
+	return c.MockRPCClient.GetBlockByNumberInt(ctx, blockNumber, includeTransactions)
+}
+
+// deadLetterRecorder records dead-lettered blocks.
+type deadLetterRecorder struct {
+	blocks   []int
+	attempts []int
+}
+
+func (d *deadLetterRecorder) AddBlock(block, attempts int, _ error) error {
+	d.blocks = append(d.blocks, block)
+	d.attempts = append(d.attempts, attempts)
+	return nil
+}
+
+func TestParser_BlockRetries(t *testing.T) {
+	client := &flakyClient{MockRPCClient: NewMockRPCClient(), failures: 2}
+	dead := &deadLetterRecorder{}
+	store := NewMockStorage()
+	p := NewParserWithInterval(client, store, time.Second, Options{BlockRetries: 2, DeadLetters: dead}).(*parserImpl)
+	p.retryBackoff = time.Millisecond
+
+	if err := p.processBlockWithRetries(context.Background(), 1234); err != nil {
+		t.Fatalf("Expected the block to succeed on its last retry, got %v", err)
+	}
+	if client.fetches != 3 || len(dead.blocks) != 0 {
+		t.Errorf("Expected 3 fetches and no dead letter, got %d fetches and %v", client.fetches, dead.blocks)
+	}
+	if txs := store.GetTransactions("0xfrom1"); len(txs) != 1 {
+		t.Errorf("Expected the block to be stored, got %+v", txs)
+	}
+
+	client.fetches, client.failures = 0, 10
+	if err := p.processBlockWithRetries(context.Background(), 1235); err == nil {
+		t.Fatal("Expected the block to fail")
+	}
+	if len(dead.blocks) != 1 || dead.blocks[0] != 1235 || dead.attempts[0] != 3 {
+		t.Errorf("Expected block 1235 to be dead-lettered after 3 attempts, got %v %v", dead.blocks, dead.attempts)
+	}
+
+	// A cancelled scan doesn't dead-letter the block it was on.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p.processBlockWithRetries(ctx, 1236)
+	if len(dead.blocks) != 1 {
+		t.Errorf("Expected no dead letter after cancellation, got %v", dead.blocks)
+	}
+}
+
+// hangingClient never answers block fetches until their context is done.
+type hangingClient struct {
+	*MockRPCClient
+}
+
+func (c *hangingClient) GetBlockByNumberInt(ctx context.Context, blockNumber int, includeTransactions bool) (*rpc.Block, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestParser_BlockTimeout(t *testing.T) {
+	client := &hangingClient{MockRPCClient: NewMockRPCClient()}
+	p := NewParserWithInterval(client, NewMockStorage(), time.Second, Options{BlockTimeout: 20 * time.Millisecond}).(*parserImpl)
+
+	start := time.Now()
+	err := p.processBlockWithRetries(context.Background(), 1234)
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, ErrRPCUnavailable) {
+		t.Errorf("Expected a hung block to time out as unavailable, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the block to give up after its timeout, took %v", elapsed)
+	}
+}
@@ -0,0 +1,79 @@
+// Package parser contains the block poller and parsing logic.
+package parser
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// DryRunStats summarizes what a parser in dry-run mode processed since it
+// was created.
+type DryRunStats struct {
+	// Blocks counts processed blocks and FailedBlocks failed attempts to
+	// process one, e.g. because the node couldn't serve it.
+	Blocks       int64 `json:"blocks"`
+	FailedBlocks int64 `json:"failed_blocks"`
+	// Transactions counts the transactions in processed blocks and Ignored
+	// those skipped because they involve an ignored address.
+	Transactions int64 `json:"transactions"`
+	Ignored      int64 `json:"ignored"`
+	// Records counts the per-address records that would have been stored
+	// and Matched those for subscribed addresses, which would have been
+	// delivered to watchers.
+	Records int64 `json:"records"`
+	Matched int64 `json:"matched"`
+	// Elapsed is the time since the parser was created.
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// BlocksPerSecond returns the average rate at which blocks were processed.
+func (s DryRunStats) BlocksPerSecond() float64 {
+	if s.Elapsed <= 0 {
+		return 0
+	}
+	return float64(s.Blocks+s.FailedBlocks) / s.Elapsed.Seconds()
+}
+
+// DryRunner is implemented by parsers that can run in dry-run mode, where
+// blocks are fetched and parsed but nothing is stored or delivered.
+type DryRunner interface {
+	// DryRunStats returns the dry run's counters, and false if the parser
+	// isn't in dry-run mode.
+	DryRunStats() (DryRunStats, bool)
+}
+
+// dryRunCounters accumulates DryRunStats. A nil *dryRunCounters means
+// dry-run mode is off.
+type dryRunCounters struct {
+	started      time.Time
+	blocks       atomic.Int64
+	failedBlocks atomic.Int64
+	transactions atomic.Int64
+	ignored      atomic.Int64
+	records      atomic.Int64
+	matched      atomic.Int64
+}
+
+func newDryRunCounters(enabled bool) *dryRunCounters {
+	if !enabled {
+		return nil
+	}
+	return &dryRunCounters{started: time.Now()}
+}
+
+// DryRunStats returns the counters of a parser in dry-run mode.
+func (p *parserImpl) DryRunStats() (DryRunStats, bool) {
+	c := p.dryRun
+	if c == nil {
+		return DryRunStats{}, false
+	}
+	return DryRunStats{
+		Blocks:       c.blocks.Load(),
+		FailedBlocks: c.failedBlocks.Load(),
+		Transactions: c.transactions.Load(),
+		Ignored:      c.ignored.Load(),
+		Records:      c.records.Load(),
+		Matched:      c.matched.Load(),
+		Elapsed:      time.Since(c.started),
+	}, true
+}
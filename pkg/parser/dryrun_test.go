@@ -0,0 +1,50 @@
+package parser
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/storage"
+)
+
+func TestParser_DryRun(t *testing.T) {
+	client := NewMockRPCClient()
+	store := storage.NewMemoryStorage()
+	store.Subscribe("0xto1")
+	p := NewParserWithInterval(client, store, time.Second, Options{DryRun: true, Ignore: []string{"0xfrom2"}}).(*parserImpl)
+	events := p.Watch(context.Background())
+
+	if err := p.processBlock(context.Background(), 1234); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+	client.callError = context.DeadlineExceeded
+	if err := p.processBlock(context.Background(), 1235); err == nil {
+		t.Fatal("Expected the second block to fail")
+	}
+
+	if _, ok := store.GetTransactionByHash("0xhash1"); ok {
+		t.Error("Expected nothing to be stored")
+	}
+	select {
+	case e := <-events:
+		t.Errorf("Expected no events, got %+v", e)
+	default:
+	}
+
+	stats, ok := p.DryRunStats()
+	if !ok {
+		t.Fatal("Expected dry-run stats")
+	}
+	// 0xhash1 would be stored for both sides, 0xhash2 is ignored.
+	if stats.Blocks != 1 || stats.FailedBlocks != 1 || stats.Transactions != 2 || stats.Ignored != 1 || stats.Records != 2 || stats.Matched != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	if stats.Elapsed <= 0 || stats.BlocksPerSecond() <= 0 {
+		t.Errorf("Expected a positive rate, got %+v", stats)
+	}
+
+	if _, ok := NewParserWithInterval(client, store, time.Second, Options{}).(DryRunner).DryRunStats(); ok {
+		t.Error("Expected no dry-run stats outside dry-run mode")
+	}
+}
@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+)
+
+// ErrRPCUnavailable is wrapped by errors caused by the node being
+// unreachable or not answering with a JSON-RPC response, as opposed to the
+// node rejecting a request. Such failures usually clear up on their own.
+var ErrRPCUnavailable = errors.New("rpc unavailable")
+
+// ErrStorageWrite is wrapped by errors persisting the parser's state, such
+// as a block moved to the dead-letter queue.
+var ErrStorageWrite = errors.New("storage write failed")
+
+// BlockFetchError reports that block Block could not be fetched, or its
+// token or contract events could not be. Cause wraps ErrRPCUnavailable if
+// the node could not be reached.
+type BlockFetchError struct {
+	Block int
+	Cause error
+}
+
+// Error satisfies the error interface.
+func (e *BlockFetchError) Error() string {
+	return fmt.Sprintf("failed to fetch block %d: %v", e.Block, e.Cause)
+}
+
+// Unwrap returns the cause.
+func (e *BlockFetchError) Unwrap() error {
+	return e.Cause
+}
+
+// unavailable wraps err, an error of a call to the node, in
+// ErrRPCUnavailable unless the node answered it with an error, or the call
+// failed because ctx was cancelled.
+func unavailable(ctx context.Context, err error) error {
+	var rpcErr *rpc.RPCError
+	if err == nil || ctx.Err() != nil || errors.As(err, &rpcErr) || errors.Is(err, rpc.ErrNotFound) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrRPCUnavailable, err)
+}
+
+// reportError passes err to OnError if it is set. Errors of cancelled calls
+// are shutdown, not failures, and aren't reported.
+func (p *parserImpl) reportError(ctx context.Context, err error) {
+	if p.onError != nil && ctx.Err() == nil {
+		p.onError(err)
+	}
+}
@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+)
+
+// failingDeadLetters fails to persist every block.
+type failingDeadLetters struct{}
+
+func (failingDeadLetters) AddBlock(block, attempts int, _ error) error {
+	return errors.New("disk full")
+}
+
+func TestParser_OnError(t *testing.T) {
+	var reported []error
+	client := NewMockRPCClient()
+	p := NewParserWithInterval(client, NewMockStorage(), time.Second, Options{
+		DeadLetters: failingDeadLetters{},
+		OnError:     func(err error) { reported = append(reported, err) },
+	}).(*parserImpl)
+
+	client.callError = errors.New("connection refused")
+	err := p.processBlockWithRetries(context.Background(), 1234)
+	var fetchErr *BlockFetchError
+	if !errors.As(err, &fetchErr) || fetchErr.Block != 1234 {
+		t.Fatalf("Expected a BlockFetchError for block 1234, got %v", err)
+	}
+	if !errors.Is(err, ErrRPCUnavailable) {
+		t.Errorf("Expected a transport failure to wrap ErrRPCUnavailable, got %v", err)
+	}
+	if len(reported) != 2 || reported[0] != err || !errors.Is(reported[1], ErrStorageWrite) {
+		t.Errorf("Expected the block failure and the dead-letter failure to be reported, got %v", reported)
+	}
+
+	// The node rejecting a request isn't unavailability.
+	reported = nil
+	client.callError = &rpc.RPCError{Code: -32000, Message: "header not found"}
+	err = p.processBlockWithRetries(context.Background(), 1235)
+	if !errors.As(err, &fetchErr) || errors.Is(err, ErrRPCUnavailable) {
+		t.Errorf("Expected a BlockFetchError not wrapping ErrRPCUnavailable, got %v", err)
+	}
+
+	client.callError = errors.New("connection refused")
+	if err := p.checkForNewBlocks(context.Background()); !errors.Is(err, ErrRPCUnavailable) {
+		t.Errorf("Expected a failed head poll to wrap ErrRPCUnavailable, got %v", err)
+	}
+
+	// Cancellation is shutdown and isn't reported.
+	reported = nil
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = p.processBlockWithRetries(ctx, 1236)
+	if errors.Is(err, ErrRPCUnavailable) || len(reported) != 0 {
+		t.Errorf("Expected a cancelled block to be neither unavailable nor reported, got %v and %v", err, reported)
+	}
+}
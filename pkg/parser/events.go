@@ -0,0 +1,138 @@
+// Package parser contains the block poller and parsing logic.
+package parser
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// watcherBuffer is the per-watcher channel capacity. Watchers that fall
+// further behind than this miss events instead of stalling block processing,
+// unless they follow the events (see Follower).
+const watcherBuffer = 64
+
+// Event is emitted whenever a transaction is stored for a subscribed
+// address, and again with Removed set when a chain reorganization orphans
+// the block it was stored from.
+type Event struct {
+	Address     string                  `json:"address"`
+	Transaction transaction.Transaction `json:"transaction"`
+	// Removed marks the transaction as no longer on the chain. A
+	// transaction mined again in a block of the new chain is announced
+	// again, without Removed, afterwards.
+	Removed bool `json:"removed,omitempty"`
+	// Reorg describes the reorganization that removed the transaction.
+	Reorg *Reorg `json:"reorg,omitempty"`
+}
+
+// Reorg describes a chain reorganization.
+type Reorg struct {
+	// Depth is the number of blocks replaced.
+	Depth int `json:"depth"`
+	// Blocks are the new canonical blocks from the first replaced one up to
+	// the block that revealed the reorganization, oldest first.
+	Blocks []BlockRef `json:"blocks"`
+}
+
+// BlockRef identifies a block.
+type BlockRef struct {
+	Number int    `json:"number"`
+	Hash   string `json:"hash"`
+}
+
+// IdempotencyKey identifies the stored record the event announces, or its
+// removal. Events are delivered at least once, so consumers should
+// deduplicate on it, and forget the key of a removed record so that it can
+// be announced again.
+func (e Event) IdempotencyKey() string {
+	key := e.Transaction.IdempotencyKey(e.Address)
+	if e.Removed {
+		return key + ":removed:" + strconv.Itoa(e.Transaction.Block)
+	}
+	return key
+}
+
+// watcher is a single consumer registered with the eventHub.
+type watcher[T any] struct {
+	addrs map[string]bool // nil means all addresses
+	ch    chan T
+	// done is set for watchers that hold up publish while their channel is
+	// full, and closed once they are gone.
+	done <-chan struct{}
+}
+
+// eventHub fans events of type T out to registered watchers, keyed by the
+// address they concern: the subscribed address for transactions and the
+// contract for logs.
+type eventHub[T any] struct {
+	mu       sync.RWMutex
+	watchers map[*watcher[T]]struct{}
+}
+
+func newEventHub[T any]() *eventHub[T] {
+	return &eventHub[T]{watchers: make(map[*watcher[T]]struct{})}
+}
+
+// watch registers a watcher for the given addresses (all if none are given).
+// The returned channel is closed once ctx is cancelled. A blocking watcher
+// never misses events: publish waits for room in its channel.
+func (h *eventHub[T]) watch(ctx context.Context, blocking bool, addresses []string) <-chan T {
+	w := &watcher[T]{ch: make(chan T, watcherBuffer)}
+	if blocking {
+		w.done = ctx.Done()
+	}
+	if len(addresses) > 0 {
+		w.addrs = make(map[string]bool, len(addresses))
+		for _, a := range addresses {
+			w.addrs[a] = true
+		}
+	}
+
+	h.mu.Lock()
+	h.watchers[w] = struct{}{}
+	h.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		h.mu.Lock()
+		delete(h.watchers, w)
+		close(w.ch)
+		h.mu.Unlock()
+	}()
+	return w.ch
+}
+
+// active reports whether anyone is listening, letting callers skip work.
+func (h *eventHub[T]) active() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.watchers) > 0
+}
+
+// publish delivers ev, concerning addr, to every interested watcher. It
+// only blocks on blocking watchers whose channel is full, until they catch
+// up or go away.
+func (h *eventHub[T]) publish(addr string, ev T) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for w := range h.watchers {
+		if w.addrs != nil && !w.addrs[addr] {
+			continue
+		}
+		if w.done != nil {
+			select {
+			case w.ch <- ev:
+			case <-w.done:
+			}
+			continue
+		}
+		select {
+		case w.ch <- ev:
+		default:
+			// Drop rather than block the poller on a slow consumer.
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// ErrGasUnsupported is returned by GasStats when the client can't fetch
+// fee history.
+var ErrGasUnsupported = errors.New("gas statistics are not supported by the node client")
+
+// gasHistoryBlocks is how many recent blocks gas statistics cover.
+const gasHistoryBlocks = 20
+
+// gasPercentiles are the percentiles of priority fees reported, from a fee
+// likely to wait a few blocks to one likely to be included in the next.
+var gasPercentiles = []float64{10, 50, 90}
+
+// GasStats is fee guidance derived from the latest blocks.
+type GasStats struct {
+	// Block is the newest block the statistics cover.
+	Block int `json:"block"`
+	// Blocks is how many blocks they cover.
+	Blocks int `json:"blocks"`
+	// BaseFee is the base fee per gas of Block and NextBaseFee that of the
+	// block after it, in wei.
+	BaseFee     transaction.Value `json:"base_fee"`
+	NextBaseFee transaction.Value `json:"next_base_fee"`
+	// GasUsedRatio is the average fraction of the gas limit used.
+	GasUsedRatio float64 `json:"gas_used_ratio"`
+	// PriorityFees are the priority fees per gas paid at each percentile,
+	// the median over the blocks covered.
+	PriorityFees []PriorityFee `json:"priority_fees"`
+	// UpdatedAt is when the statistics were fetched from the node.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PriorityFee is the priority fee per gas, in wei, paid at a percentile of
+// the transactions of a block, weighted by gas used.
+type PriorityFee struct {
+	Percentile float64           `json:"percentile"`
+	Fee        transaction.Value `json:"fee"`
+}
+
+// GasOracle is implemented by parsers that can report fee statistics.
+type GasOracle interface {
+	// GasStats returns the base fee and priority fee percentiles of the
+	// latest blocks. Results are cached briefly, so calls within the cache
+	// TTL don't reach the node.
+	GasStats(ctx context.Context) (GasStats, error)
+}
+
+// gasCache caches the GasStats computed from eth_feeHistory. A nil
+// *gasCache means the client can't fetch fee history.
+type gasCache struct {
+	client rpc.FeeHistoryFetcher
+	ttl    time.Duration
+	now    func() time.Time
+
+	// mu is held while fetching, so concurrent requests share one call.
+	mu      sync.Mutex
+	stats   GasStats
+	expires time.Time
+}
+
+func newGasCache(client rpc.FeeHistoryFetcher, ttl time.Duration) *gasCache {
+	if client == nil {
+		return nil
+	}
+	return &gasCache{client: client, ttl: ttl, now: time.Now}
+}
+
+// GasStats returns the fee statistics of the latest blocks.
+func (p *parserImpl) GasStats(ctx context.Context) (GasStats, error) {
+	if p.gas == nil {
+		return GasStats{}, ErrGasUnsupported
+	}
+	return p.gas.get(ctx)
+}
+
+// get returns the cached statistics, fetching them again once expired.
+func (c *gasCache) get(ctx context.Context) (GasStats, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.now().Before(c.expires) {
+		return c.stats, nil
+	}
+	h, err := c.client.GetFeeHistory(ctx, gasHistoryBlocks, gasPercentiles)
+	if err != nil {
+		return GasStats{}, err
+	}
+	stats, err := gasStats(h)
+	if err != nil {
+		return GasStats{}, err
+	}
+	stats.UpdatedAt = c.now().UTC()
+	c.stats, c.expires = stats, c.now().Add(c.ttl)
+	return stats, nil
+}
+
+// gasStats summarizes a fee history.
+func gasStats(h *rpc.FeeHistory) (GasStats, error) {
+	n := len(h.GasUsedRatio)
+	if n == 0 || len(h.BaseFeePerGas) != n+1 {
+		return GasStats{}, fmt.Errorf("invalid fee history: %d base fees for %d blocks", len(h.BaseFeePerGas), n)
+	}
+	stats := GasStats{
+		Block:        hexToInt(h.OldestBlock) + n - 1,
+		Blocks:       n,
+		BaseFee:      hexToValue(h.BaseFeePerGas[n-1]),
+		NextBaseFee:  hexToValue(h.BaseFeePerGas[n]),
+		PriorityFees: make([]PriorityFee, 0, len(gasPercentiles)),
+	}
+	for _, r := range h.GasUsedRatio {
+		stats.GasUsedRatio += r
+	}
+	stats.GasUsedRatio /= float64(n)
+
+	for i, pct := range gasPercentiles {
+		fees := make([]transaction.Value, 0, len(h.Reward))
+		for _, block := range h.Reward {
+			if i < len(block) {
+				fees = append(fees, hexToValue(block[i]))
+			}
+		}
+		stats.PriorityFees = append(stats.PriorityFees, PriorityFee{Percentile: pct, Fee: median(fees)})
+	}
+	return stats, nil
+}
+
+// median returns the median of values, the lower one of the middle two for
+// an even count, or zero if there are none.
+func median(values []transaction.Value) transaction.Value {
+	if len(values) == 0 {
+		return transaction.WeiValue(0)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].Cmp(values[j]) < 0 })
+	return values[(len(values)-1)/2]
+}
@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+)
+
+// feeClient adds fee history to MockRPCClient.
+type feeClient struct {
+	*MockRPCClient
+	history *rpc.FeeHistory
+	err     error
+	calls   int
+}
+
+func (c *feeClient) GetFeeHistory(_ context.Context, _ int, _ []float64) (*rpc.FeeHistory, error) {
+	c.calls++
+	return c.history, c.err
+}
+
+func TestParser_GasStats(t *testing.T) {
+	client := &feeClient{MockRPCClient: NewMockRPCClient(), history: &rpc.FeeHistory{
+		OldestBlock:   "0x1232",
+		BaseFeePerGas: []string{"0x64", "0x6e", "0x78", "0x82"},
+		GasUsedRatio:  []float64{0.4, 0.5, 0.9},
+		Reward: [][]string{
+			{"0x1", "0xa", "0x64"},
+			{"0x3", "0x14", "0xc8"},
+			{"0x2", "0x1e", "0x12c"},
+		},
+	}}
+	p := NewParserWithInterval(client, NewMockStorage(), time.Second, Options{GasCacheTTL: time.Minute}).(*parserImpl)
+	now := time.Now()
+	p.gas.now = func() time.Time { return now }
+
+	stats, err := p.GasStats(context.Background())
+	if err != nil {
+		t.Fatalf("GasStats failed: %v", err)
+	}
+	if stats.Block != 0x1234 || stats.Blocks != 3 || stats.BaseFee.String() != "120" || stats.NextBaseFee.String() != "130" {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	if stats.GasUsedRatio < 0.59 || stats.GasUsedRatio > 0.61 {
+		t.Errorf("Expected an average gas used ratio of 0.6, got %f", stats.GasUsedRatio)
+	}
+	want := []string{"2", "20", "200"}
+	for i, fee := range stats.PriorityFees {
+		if fee.Percentile != gasPercentiles[i] || fee.Fee.String() != want[i] {
+			t.Errorf("Expected a median fee of %s at p%.0f, got %+v", want[i], gasPercentiles[i], fee)
+		}
+	}
+
+	// Cached until the TTL elapses.
+	p.GasStats(context.Background())
+	if client.calls != 1 {
+		t.Errorf("Expected the stats to be cached, got %d calls", client.calls)
+	}
+	now = now.Add(2 * time.Minute)
+	client.err = errors.New("boom")
+	if _, err := p.GasStats(context.Background()); err == nil || client.calls != 2 {
+		t.Errorf("Expected expired stats to be fetched again, got %v after %d calls", err, client.calls)
+	}
+
+	client.err = nil
+	client.history = &rpc.FeeHistory{OldestBlock: "0x1", BaseFeePerGas: []string{"0x1"}, GasUsedRatio: []float64{0.5}}
+	if _, err := p.GasStats(context.Background()); err == nil {
+		t.Error("Expected an error for a malformed fee history")
+	}
+
+	p = NewParserWithInterval(NewMockRPCClient(), NewMockStorage(), time.Second, Options{}).(*parserImpl)
+	if _, err := p.GasStats(context.Background()); !errors.Is(err, ErrGasUnsupported) {
+		t.Errorf("Expected ErrGasUnsupported, got %v", err)
+	}
+}
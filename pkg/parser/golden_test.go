@@ -0,0 +1,127 @@
+package parser
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/blockfixtures"
+	"github.com/danieloluwadare/tw-txparser/internal/storage"
+	"github.com/danieloluwadare/tw-txparser/pkg/abi"
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// Accounts in the golden block fixtures.
+const (
+	goldenAlice    = "0x2bd806c97f0e00af1a1fc3328fa763a9269723c8"
+	goldenBob      = "0x81b637d8fcd2c6da6359e6963113a1170de795e4"
+	goldenCarol    = "0x4c26d9074c27d89ede59270c0ac14b71e071b152"
+	goldenDeployer = "0xeeb9b5c0c28d22e56a7489caabab44c3fe349d0b"
+	goldenWhale    = "0xa5d2ae286d0d9e45c0621a6fc7c18119940dd737"
+	goldenExchange = "0xab27b729d9cc4cb1c00960700446924159e9298d"
+	goldenRollup   = "0x45fb8b4d67311a0905e8bcffe1f5a72eb4e62bca"
+)
+
+func TestParser_GoldenBlocks(t *testing.T) {
+	client := blockfixtures.Client(t)
+	for _, tt := range []struct {
+		name   string
+		client rpc.RPCClient
+	}{
+		{name: "streamed", client: client},
+		// Hiding StreamBlockByNumber makes the parser decode whole blocks.
+		{name: "decoded", client: struct{ rpc.RPCClient }{client}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStorage()
+			for _, addr := range []string{goldenAlice, goldenBob, goldenDeployer, goldenWhale, goldenExchange, goldenRollup, ""} {
+				store.Subscribe(addr)
+			}
+			p := NewParserWithInterval(tt.client, store, time.Second, Options{}).(*parserImpl)
+			total := 0
+			for _, name := range blockfixtures.Names() {
+				b := blockfixtures.MustLoad(t, name)
+				total += len(b.Transactions)
+				if err := p.processBlock(context.Background(), hexToInt(b.Number)); err != nil {
+					t.Fatalf("processBlock(%s) failed: %v", name, err)
+				}
+			}
+			if total != 7 {
+				t.Fatalf("Expected 7 fixture transactions, got %d", total)
+			}
+
+			expect := func(addr string, want ...transaction.Direction) []transaction.Transaction {
+				t.Helper()
+				txs := store.GetTransactions(addr)
+				if len(txs) != len(want) {
+					t.Fatalf("Expected %d transactions for %s, got %+v", len(want), addr, txs)
+				}
+				for i, tx := range txs {
+					if tx.Direction != want[i] {
+						t.Errorf("Expected %s transaction %d to be %s, got %s", addr, i, want[i], tx.Direction)
+					}
+				}
+				return txs
+			}
+
+			// Legacy transfer out, then a self-transfer stored once.
+			txs := expect(goldenAlice, transaction.DirectionOut, transaction.DirectionSelf)
+			if txs[0].Value.String() != "10000000000000000" || txs[0].Block != 18500000 {
+				t.Errorf("Unexpected legacy transfer %+v", txs[0])
+			}
+			expect(goldenBob, transaction.DirectionIn)
+
+			// A contract creation has no receiver to store it for.
+			txs = expect(goldenDeployer, transaction.DirectionOut)
+			if txs[0].To != "" {
+				t.Errorf("Expected a contract creation without a receiver, got %q", txs[0].To)
+			}
+			expect("")
+
+			// 100M ether doesn't fit in 64 bits.
+			txs = expect(goldenExchange, transaction.DirectionIn)
+			if txs[0].Value.String() != "100000000000000000000000000" {
+				t.Errorf("Expected the exact value beyond 64 bits, got %s", txs[0].Value)
+			}
+			expect(goldenWhale, transaction.DirectionOut)
+
+			// Blob transactions are stored like any other.
+			txs = expect(goldenRollup, transaction.DirectionOut)
+			if txs[0].Block != 18500001 || txs[0].Value.String() != "0" {
+				t.Errorf("Unexpected blob transaction %+v", txs[0])
+			}
+		})
+	}
+}
+
+func TestParser_GoldenBlocks_ABI(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	store.Subscribe(goldenCarol)
+	store.Subscribe(goldenAlice)
+	store.Subscribe(goldenDeployer)
+	p := NewParserWithInterval(blockfixtures.Client(t), store, time.Second, Options{ABI: abi.Builtin()}).(*parserImpl)
+	for _, n := range []int{18500000, 18500001} {
+		if err := p.processBlock(context.Background(), n); err != nil {
+			t.Fatalf("processBlock(%d) failed: %v", n, err)
+		}
+	}
+
+	txs := store.GetTransactions(goldenCarol)
+	if len(txs) != 1 || txs[0].Call == nil {
+		t.Fatalf("Expected carol's USDT call to be decoded, got %+v", txs)
+	}
+	call := txs[0].Call
+	if call.Method != "transfer" || len(call.Args) != 2 || call.Args[0].Value != goldenBob || call.Args[1].Value != "2000000000" {
+		t.Errorf("Unexpected call %+v", call)
+	}
+
+	// Plain transfers and contract creations have no call.
+	for _, addr := range []string{goldenAlice, goldenDeployer} {
+		for _, tx := range store.GetTransactions(addr) {
+			if tx.Call != nil {
+				t.Errorf("Expected no call for %s, got %+v", tx.Hash, tx.Call)
+			}
+		}
+	}
+}
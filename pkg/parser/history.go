@@ -0,0 +1,114 @@
+package parser
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// historyQueueSize bounds the subscriptions waiting for their history to
+// be backfilled.
+const historyQueueSize = 1024
+
+// HistoryProvider fetches the whole history of an address from an indexer,
+// far faster than scanning blocks for it; *etherscan.Client implements it.
+// Records are returned oldest first, with their direction relative to the
+// address.
+type HistoryProvider interface {
+	Transactions(ctx context.Context, address string) ([]transaction.Transaction, error)
+	TokenTransfers(ctx context.Context, address string) ([]transaction.TokenTransfer, error)
+}
+
+// queueBackfill queues the history of a newly subscribed address to be
+// backfilled, unless backfilling is disabled. Addresses that don't fit in
+// the queue are skipped.
+func (p *parserImpl) queueBackfill(address string) {
+	if p.history == nil {
+		return
+	}
+	select {
+	case p.backfills <- address:
+	default:
+		p.logger.Warn("history backfill queue is full, skipping address", "address", address)
+	}
+}
+
+// backfillHistory backfills the history of queued addresses one at a time
+// until ctx is cancelled.
+func (p *parserImpl) backfillHistory(ctx context.Context) {
+	defer p.wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case addr := <-p.backfills:
+			p.backfill(ctx, addr)
+		}
+	}
+}
+
+// backfill stores the history of addr from the history provider, following
+// the same rules as transactions indexed from blocks. Records already
+// stored are skipped by the storage, and none are delivered to watchers.
+func (p *parserImpl) backfill(ctx context.Context, addr string) {
+	if !p.store.IsSubscribed(addr) {
+		return
+	}
+	logger := p.logger.With("scan", "history", "address", addr)
+	start := time.Now()
+	txs, err := p.history.Transactions(ctx, addr)
+	if err != nil {
+		logger.Warn("failed to fetch transaction history", logging.KeyError, err)
+		return
+	}
+	stored := 0
+	for _, tx := range txs {
+		if p.storeHistorical(addr, tx) {
+			stored++
+		}
+	}
+
+	tokens := 0
+	if p.indexTokens {
+		tts, err := p.history.TokenTransfers(ctx, addr)
+		if err != nil {
+			logger.Warn("failed to fetch token transfer history", logging.KeyError, err)
+		}
+		for _, tt := range tts {
+			if p.ignored[strings.ToLower(tt.From)] || p.ignored[strings.ToLower(tt.To)] {
+				continue
+			}
+			p.recordToken(addr, tt)
+			p.markTokenTransfer(tt.Hash)
+			tokens++
+		}
+	}
+	logger.Info("backfilled history", "transactions", stored, "token_transfers", tokens, "duration", time.Since(start))
+}
+
+// storeHistorical stores tx from the history of addr like processTransaction
+// would have, and reports whether it was kept.
+func (p *parserImpl) storeHistorical(addr string, tx transaction.Transaction) bool {
+	if p.ignored[strings.ToLower(tx.From)] || p.ignored[strings.ToLower(tx.To)] {
+		return false
+	}
+	if p.skipZeroValueCalls && isZeroValueCall(rpc.Transaction{To: tx.To, Input: tx.Input}, tx.Value) && !p.store.IsSubscribed(tx.From) {
+		return false
+	}
+	if p.abi != nil && tx.To != "" {
+		tx.Call, _ = p.abi.Decode(tx.Input)
+	}
+	input := tx.Input
+	tx.Input, tx.InputTruncated = "", false
+	if p.maxInputBytes > 0 {
+		tx.Input, tx.InputTruncated = truncateInput(input, p.maxInputBytes)
+	}
+	tx.IndexedAt = time.Now().UTC()
+	p.store.AddTransaction(addr, tx)
+	p.balances.apply(addr, tx)
+	return true
+}
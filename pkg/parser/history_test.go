@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/storage"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+type fakeHistory struct {
+	txs    []transaction.Transaction
+	tokens []transaction.TokenTransfer
+	err    error
+}
+
+func (h *fakeHistory) Transactions(context.Context, string) ([]transaction.Transaction, error) {
+	return h.txs, h.err
+}
+
+func (h *fakeHistory) TokenTransfers(context.Context, string) ([]transaction.TokenTransfer, error) {
+	return h.tokens, h.err
+}
+
+func TestParser_Backfill(t *testing.T) {
+	history := &fakeHistory{
+		txs: []transaction.Transaction{
+			{Hash: "0xa", From: "0xsender", To: "0xme", Value: transaction.WeiValue(5), Block: 10, Direction: transaction.DirectionIn, Input: "0x"},
+			{Hash: "0xb", From: "0xme", To: "0xcontract", Block: 11, Direction: transaction.DirectionOut, Input: "0xdeadbeefcafe"},
+			{Hash: "0xc", From: "0xspam", To: "0xme", Block: 12, Direction: transaction.DirectionIn},
+		},
+		tokens: []transaction.TokenTransfer{
+			{Hash: "0xb", Contract: "0xtoken", From: "0xme", To: "0xother", Amount: transaction.WeiValue(1), Block: 11, Direction: transaction.DirectionOut},
+		},
+	}
+	store := storage.NewMemoryStorage()
+	p := NewParserWithInterval(NewMockRPCClient(), store, time.Second, Options{History: history, Ignore: []string{"0xspam"}, StoreInput: true, MaxInputBytes: 2}).(*parserImpl)
+	p.indexTokens = true
+
+	if !p.Subscribe("0xme") {
+		t.Fatal("Expected the subscription to be new")
+	}
+	if len(p.backfills) != 1 {
+		t.Fatalf("Expected the address to be queued for backfill, got %d queued", len(p.backfills))
+	}
+	p.backfill(context.Background(), <-p.backfills)
+
+	txs := store.GetTransactions("0xme")
+	if len(txs) != 2 || txs[0].Hash != "0xa" || txs[1].Hash != "0xb" {
+		t.Fatalf("Expected the history without ignored counterparties, got %+v", txs)
+	}
+	if txs[1].Input != "0xdead" || !txs[1].InputTruncated {
+		t.Errorf("Expected input cut to 2 bytes, got %q (truncated %v)", txs[1].Input, txs[1].InputTruncated)
+	}
+	if txs[0].IndexedAt.IsZero() {
+		t.Error("Expected backfilled transactions to be stamped with the indexing time")
+	}
+	if tts := store.GetTokenTransfers("0xme"); len(tts) != 1 || tts[0].Contract != "0xtoken" {
+		t.Errorf("Expected the token transfer history, got %+v", tts)
+	}
+
+	// Backfilling again stores nothing twice.
+	p.backfill(context.Background(), "0xme")
+	if got := len(store.GetTransactions("0xme")); got != 2 {
+		t.Errorf("Expected 2 transactions after a second backfill, got %d", got)
+	}
+}
+
+func TestParser_Backfill_Error(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	p := NewParserWithInterval(NewMockRPCClient(), store, time.Second, Options{History: &fakeHistory{err: errors.New("boom")}}).(*parserImpl)
+	p.Subscribe("0xme")
+	p.backfill(context.Background(), <-p.backfills)
+	if got := store.GetTransactions("0xme"); len(got) != 0 {
+		t.Errorf("Expected nothing stored, got %+v", got)
+	}
+}
+
+func TestParser_Backfill_Disabled(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"no provider", Options{}},
+		{"dry run", Options{History: &fakeHistory{}, DryRun: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParserWithInterval(NewMockRPCClient(), storage.NewMemoryStorage(), time.Second, tt.opts).(*parserImpl)
+			p.Subscribe("0xme")
+			if p.history != nil || len(p.backfills) != 0 {
+				t.Errorf("Expected no backfill, got %d queued", len(p.backfills))
+			}
+		})
+	}
+}
+
+func TestParser_Backfill_Start(t *testing.T) {
+	history := &fakeHistory{txs: []transaction.Transaction{{Hash: "0xa", From: "0xsender", To: "0xme", Block: 10, Direction: transaction.DirectionIn}}}
+	store := storage.NewMemoryStorage()
+	p := NewParserWithInterval(NewMockRPCClient(), store, time.Hour, Options{History: history})
+	p.Subscribe("0xme")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	poller := p.(Poller)
+	poller.Start(ctx)
+	defer func() {
+		cancel()
+		poller.Stop()
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(store.GetTransactions("0xme")) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the history to be backfilled once the parser started")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
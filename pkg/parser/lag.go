@@ -0,0 +1,31 @@
+package parser
+
+// LagStatus is reported to Options.OnLag when the parser falls more than
+// Options.MaxLag blocks behind the node's head, and again once it catches up.
+type LagStatus struct {
+	// Lagging is true when the threshold was exceeded and false on recovery.
+	Lagging bool
+	// Lag is the number of blocks between Head and Block.
+	Lag int
+	// Head is the latest block reported by the node.
+	Head int
+	// Block is the last processed block.
+	Block int
+	// MaxLag is the configured threshold.
+	MaxLag int
+}
+
+// checkLag fires OnLag when the lag crosses MaxLag in either direction.
+// It runs on the polling goroutine, so OnLag should return quickly.
+func (p *parserImpl) checkLag() {
+	if p.maxLag <= 0 || p.onLag == nil || p.block == 0 {
+		return
+	}
+	lag := max(p.head-p.block, 0)
+	lagging := lag > p.maxLag
+	if lagging == p.lagging {
+		return
+	}
+	p.lagging = lagging
+	p.onLag(LagStatus{Lagging: lagging, Lag: lag, Head: p.head, Block: p.block, MaxLag: p.maxLag})
+}
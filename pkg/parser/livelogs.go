@@ -0,0 +1,128 @@
+package parser
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/internal/storage"
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+)
+
+// unsubscribeTimeout bounds ending a live subscription that is replaced.
+const unsubscribeTimeout = 5 * time.Second
+
+// notifyWatchlist wakes followLogs up to subscribe to a changed watchlist.
+func (p *parserImpl) notifyWatchlist() {
+	select {
+	case p.watchlistChanged <- struct{}{}:
+	default:
+	}
+}
+
+// followLogs keeps live subscriptions to the Transfer events sent and
+// received by subscribed addresses, indexing them as soon as the node
+// sees them rather than when their block is polled. Polling indexes them
+// again, which storage deduplicates, so notifications missed while
+// resubscribing cost latency only. The subscriptions follow the watchlist
+// as addresses subscribe and unsubscribe, and are made again if the
+// connection drops.
+func (p *parserImpl) followLogs(ctx context.Context) {
+	defer p.wg.Done()
+	logger := p.logger.With("scan", "live")
+	ticker := time.NewTicker(p.pollInterval)
+	defer ticker.Stop()
+
+	var subs []rpc.Subscription
+	var watched []string
+	for {
+		addrs := p.watchlist()
+		if !slices.Equal(addrs, watched) || ended(subs) {
+			p.unsubscribeLogs(subs)
+			subs, watched = nil, nil
+			if len(addrs) > 0 {
+				var err error
+				if subs, err = p.subscribeLogs(ctx, addrs); err != nil {
+					if ctx.Err() == nil {
+						logger.Warn("failed to subscribe to live token transfers", logging.KeyError, err)
+					}
+				} else {
+					watched = addrs
+					logger.Debug("subscribed to live token transfers", "addresses", len(addrs))
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.watchlistChanged:
+		case <-ticker.C:
+		}
+	}
+}
+
+// watchlist returns the subscribed addresses, sorted.
+func (p *parserImpl) watchlist() []string {
+	return p.store.(storage.Lister).Subscriptions()
+}
+
+// subscribeLogs subscribes to the Transfer events sent by and sent to addrs.
+// A topic position can only match one side, so each side takes a
+// subscription.
+func (p *parserImpl) subscribeLogs(ctx context.Context, addrs []string) ([]rpc.Subscription, error) {
+	topics := make([]string, len(addrs))
+	for i, addr := range addrs {
+		topics[i] = addressTopic(addr)
+	}
+	handle := func(l rpc.Log) {
+		p.indexTransfer(ctx, hexToInt(l.BlockNumber), l)
+	}
+	var subs []rpc.Subscription
+	for _, filter := range [][][]string{
+		{{transferTopic}, topics},
+		{{transferTopic}, nil, topics},
+	} {
+		sub, err := p.liveLogs.SubscribeLogs(ctx, rpc.LogFilter{Topics: filter}, handle)
+		if err != nil {
+			p.unsubscribeLogs(subs)
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, nil
+}
+
+// unsubscribeLogs ends subs, which are being replaced.
+func (p *parserImpl) unsubscribeLogs(subs []rpc.Subscription) {
+	for _, sub := range subs {
+		select {
+		case <-sub.Done():
+			continue
+		default:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), unsubscribeTimeout)
+		if err := sub.Unsubscribe(ctx); err != nil {
+			p.logger.Warn("failed to end live subscription", logging.KeyError, err)
+		}
+		cancel()
+	}
+}
+
+// ended reports whether any of subs ended.
+func ended(subs []rpc.Subscription) bool {
+	for _, sub := range subs {
+		select {
+		case <-sub.Done():
+			return true
+		default:
+		}
+	}
+	return false
+}
+
+// addressTopic returns addr as an indexed event argument.
+func addressTopic(addr string) string {
+	return "0x" + strings.Repeat("0", 24) + strings.TrimPrefix(strings.ToLower(addr), "0x")
+}
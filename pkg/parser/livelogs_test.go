@@ -0,0 +1,120 @@
+package parser
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/storage"
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+)
+
+// fakeSubscriber records log subscriptions and lets tests push logs to
+// them.
+type fakeSubscriber struct {
+	mu   sync.Mutex
+	subs []*fakeSubscription
+}
+
+type fakeSubscription struct {
+	f      *fakeSubscriber
+	filter rpc.LogFilter
+	fn     func(rpc.Log)
+	done   chan struct{}
+	ended  bool
+}
+
+func (s *fakeSubscription) Done() <-chan struct{} { return s.done }
+
+func (s *fakeSubscription) Unsubscribe(context.Context) error {
+	s.f.mu.Lock()
+	defer s.f.mu.Unlock()
+	s.ended = true
+	return nil
+}
+
+func (f *fakeSubscriber) SubscribeLogs(_ context.Context, filter rpc.LogFilter, fn func(rpc.Log)) (rpc.Subscription, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sub := &fakeSubscription{f: f, filter: filter, fn: fn, done: make(chan struct{})}
+	f.subs = append(f.subs, sub)
+	return sub, nil
+}
+
+// active returns the subscriptions not ended once total subscriptions
+// were made and want of them are active.
+func (f *fakeSubscriber) active(t *testing.T, total, want int) []*fakeSubscription {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		f.mu.Lock()
+		var active []*fakeSubscription
+		for _, sub := range f.subs {
+			if !sub.ended {
+				active = append(active, sub)
+			}
+		}
+		made := len(f.subs)
+		f.mu.Unlock()
+		if (made == total && len(active) == want) || time.Now().After(deadline) {
+			if made != total || len(active) != want {
+				t.Fatalf("Expected %d subscriptions with %d active, got %d with %d active", total, want, made, len(active))
+			}
+			return active
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestParser_LiveLogs(t *testing.T) {
+	live := &fakeSubscriber{}
+	store := storage.NewMemoryStorage()
+	p := NewParserWithInterval(newLogClient(), store, time.Hour, Options{IndexTokens: true, LiveLogs: live}).(*parserImpl)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.wg.Add(1)
+	go p.followLogs(ctx)
+
+	// Nothing is subscribed to while nothing is watched.
+	time.Sleep(20 * time.Millisecond)
+	live.active(t, 0, 0)
+
+	p.Subscribe(tokenTo)
+	subs := live.active(t, 2, 2)
+	if got := subs[0].filter.Topics; len(got) != 2 || got[0][0] != transferTopic || got[1][0] != topic(tokenTo) {
+		t.Errorf("Expected transfers sent by the watched address, got %v", got)
+	}
+	if got := subs[1].filter.Topics; len(got) != 3 || got[1] != nil || got[2][0] != topic(tokenTo) {
+		t.Errorf("Expected transfers received by the watched address, got %v", got)
+	}
+
+	// 1.5 USDC arriving ahead of its block
+	subs[1].fn(rpc.Log{Address: tokenUSDC, Topics: []string{transferTopic, topic(tokenFrom), topic(tokenTo)}, Data: "0x" + strings.Repeat("0", 58) + "16e360", BlockNumber: "0x64", TransactionHash: "0xtoken1", LogIndex: "0x2"})
+	transfers := store.GetTokenTransfers(tokenTo)
+	if len(transfers) != 1 || transfers[0].Block != 100 || transfers[0].Amount.String() != "1500000" {
+		t.Fatalf("Expected the live transfer to be stored, got %+v", transfers)
+	}
+	// Polling the block indexes it again without duplicating it.
+	if err := p.indexTokenEvents(ctx, 100); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(store.GetTokenTransfers(tokenTo)); n != 2 {
+		t.Errorf("Expected the polled NFT transfer next to the live one, got %d transfers", n)
+	}
+
+	// The subscriptions follow the watchlist.
+	p.Subscribe(tokenFrom)
+	subs = live.active(t, 4, 2)
+	if got := subs[0].filter.Topics[1]; len(got) != 2 {
+		t.Errorf("Expected both watched addresses, got %v", got)
+	}
+	p.Unsubscribe(tokenFrom)
+	live.active(t, 6, 2)
+	p.Unsubscribe(tokenTo)
+	live.active(t, 6, 0)
+
+	cancel()
+	p.wg.Wait()
+}
@@ -3,18 +3,187 @@ package parser
 
 import (
 	"context"
+	"errors"
 
 	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
 )
 
+// ErrTransactionNotFound is returned when a transaction is neither stored nor known to the node.
+var ErrTransactionNotFound = errors.New("transaction not found")
+
 // Parser exposes read APIs and subscription management.
 type Parser interface {
 	// GetCurrentBlock returns the last processed block number.
 	GetCurrentBlock() int
 	// Subscribe registers an address to track.
 	Subscribe(address string) bool
+	// Unsubscribe stops tracking an address.
+	Unsubscribe(address string) bool
 	// GetTransactions lists transactions associated with the address.
 	GetTransactions(address string) []transaction.Transaction
+	// GetTokenTransfers lists token transfers associated with the address.
+	GetTokenTransfers(address string) []transaction.TokenTransfer
+	// GetTransaction returns a transaction by hash, consulting the node if it is not stored.
+	GetTransaction(ctx context.Context, hash string) (transaction.Transaction, error)
+	// Rescan re-processes the inclusive block range in the background.
+	Rescan(from, to int) error
+	// Watch streams events for the given addresses (all subscribed addresses
+	// if none are given) until ctx is cancelled.
+	Watch(ctx context.Context, addresses ...string) <-chan Event
+}
+
+// Follower streams events to consumers that mustn't miss any, such as
+// deliveries backed by an outbox.
+type Follower interface {
+	// Follow is like Watch, but block processing waits for the consumer
+	// when it falls behind instead of dropping its events.
+	Follow(ctx context.Context, addresses ...string) <-chan Event
+}
+
+// RangeScanner processes an explicit block range synchronously, e.g. for
+// one-shot backfills.
+type RangeScanner interface {
+	ScanRange(ctx context.Context, from, to int) error
+}
+
+// Lister lists subscribed addresses, e.g. to export a watchlist.
+type Lister interface {
+	// Subscriptions returns the subscribed addresses in sorted order.
+	Subscriptions() ([]string, error)
+}
+
+// ErrListUnsupported is returned by Subscriptions when the storage can't
+// list subscribed addresses.
+var ErrListUnsupported = errors.New("storage does not support listing subscriptions")
+
+// RangeReader lists the stored transactions of all subscribed addresses
+// within a block range, e.g. for reconciliation jobs that verify coverage
+// block by block.
+type RangeReader interface {
+	// TransactionsInRange returns the transactions in blocks from through to
+	// by subscribed address.
+	TransactionsInRange(from, to int) (map[string][]transaction.Transaction, error)
+}
+
+// ErrRangeQueryUnsupported is returned by TransactionsInRange when the
+// storage can't list transactions by block.
+var ErrRangeQueryUnsupported = errors.New("storage does not support block range queries")
+
+// Purger deletes the data stored for an address, e.g. once its
+// subscription expires.
+type Purger interface {
+	// Purge deletes the address's transactions and token transfers and
+	// returns the number of records removed.
+	Purge(address string) (int, error)
+}
+
+// ErrPurgeUnsupported is returned by Purge when the storage can't delete
+// data.
+var ErrPurgeUnsupported = errors.New("storage does not support purging")
+
+// AllowanceReader lists the token allowances granted by an address, e.g. to
+// flag unlimited approvals.
+type AllowanceReader interface {
+	// Allowances returns the current allowances granted by a subscribed
+	// address, ordered by token and spender.
+	Allowances(address string) ([]transaction.Allowance, error)
+}
+
+// ErrAllowancesUnsupported is returned by Allowances when the storage
+// doesn't keep allowances.
+var ErrAllowancesUnsupported = errors.New("storage does not keep allowances")
+
+// ActivityReader reports the latest activity across all subscribed
+// addresses, e.g. for a dashboard's "latest activity" view.
+type ActivityReader interface {
+	// RecentActivity returns the latest transactions stored for subscribed
+	// addresses, newest first, as many as the parser keeps.
+	RecentActivity() ([]Event, error)
+}
+
+// ErrActivityDisabled is returned by RecentActivity when the parser keeps no
+// activity feed.
+var ErrActivityDisabled = errors.New("activity feed is disabled")
+
+// EventIndexer indexes the events of contracts by (contract, topic0)
+// subscriptions, turning the parser into a general event indexer.
+type EventIndexer interface {
+	// SubscribeEvents starts indexing the events of contract with topic0
+	// and reports whether the subscription is new.
+	SubscribeEvents(contract, topic0 string) (bool, error)
+	// UnsubscribeEvents stops indexing them and reports whether the
+	// subscription existed. Stored logs are kept.
+	UnsubscribeEvents(contract, topic0 string) (bool, error)
+	// EventSubscriptions returns the subscriptions ordered by contract and
+	// topic0.
+	EventSubscriptions() ([]transaction.EventSubscription, error)
+	// GetLogs returns the logs stored for a subscription.
+	GetLogs(contract, topic0 string) ([]transaction.Log, error)
+	// WatchLogs streams newly stored logs of the given contracts (all if
+	// none are given) until ctx is cancelled.
+	WatchLogs(ctx context.Context, contracts ...string) <-chan transaction.Log
+}
+
+// ErrEventsUnsupported is returned by EventIndexer methods unless the node
+// client can fetch logs and the storage can keep them.
+var ErrEventsUnsupported = errors.New("event subscriptions are not supported by the node client or storage")
+
+// ContractWatcher records every transaction sent to watched contracts, so
+// protocol teams can monitor how their deployed contracts are used.
+type ContractWatcher interface {
+	// WatchContract starts recording the calls to contract and reports
+	// whether it wasn't watched yet.
+	WatchContract(contract string) (bool, error)
+	// UnwatchContract stops recording them and reports whether contract
+	// was watched. Stored calls are kept.
+	UnwatchContract(contract string) (bool, error)
+	// WatchedContracts returns the watched contracts in sorted order.
+	WatchedContracts() ([]string, error)
+	// ContractCalls returns the transactions sent to a watched contract,
+	// each with its method selector and, if known, the decoded call.
+	ContractCalls(contract string) ([]transaction.Transaction, error)
+}
+
+// ErrContractWatchUnsupported is returned by ContractWatcher methods when
+// the storage doesn't keep contract watches.
+var ErrContractWatchUnsupported = errors.New("storage does not keep contract watches")
+
+// Aggregator returns the running value totals of an address.
+type Aggregator interface {
+	Totals(address string) (transaction.Totals, error)
+}
+
+// ErrTotalsUnsupported is returned by Totals when the storage doesn't keep
+// value totals.
+var ErrTotalsUnsupported = errors.New("storage does not keep value totals")
+
+// Truncation tells whether the stored history of an address is incomplete.
+type Truncation struct {
+	// Truncated is set once transactions of the oldest blocks were dropped
+	// to stay within the storage's per-address cap.
+	Truncated bool `json:"truncated"`
+	// EarliestBlock is the earliest block still retained when Truncated.
+	EarliestBlock int `json:"earliest_block,omitempty"`
+}
+
+// TruncationReporter is implemented by parsers that can tell whether the
+// stored history of an address was truncated.
+type TruncationReporter interface {
+	Truncation(address string) Truncation
+}
+
+// RevisionReporter is implemented by parsers that can tell when the stored
+// transactions of an address change, including updates to stored ones.
+type RevisionReporter interface {
+	// Revision returns a value that changes whenever a transaction of
+	// address is added or updated.
+	Revision(address string) uint64
+}
+
+// StatusReporter is implemented by parsers that can report the node's head
+// and the progress of their scans along with the last processed block.
+type StatusReporter interface {
+	Status() Status
 }
 
 // Poller drives continuous block polling until the context is cancelled.
@@ -22,3 +191,13 @@ type Poller interface {
 	Start(ctx context.Context)
 	Stop() // Gracefully stops all goroutines and waits for them to complete
 }
+
+// PollWatcher is implemented by pollers that report when polling stops,
+// e.g. so that a leader whose poller failed to start hands over to a
+// standby.
+type PollWatcher interface {
+	// PollDone returns a channel closed once the polling started by the
+	// last Start stops, because its context was cancelled or because it
+	// failed, e.g. to reach the node. It is nil before Start.
+	PollDone() <-chan struct{}
+}
@@ -2,33 +2,278 @@
 package parser
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
 	"github.com/danieloluwadare/tw-txparser/internal/storage"
+	"github.com/danieloluwadare/tw-txparser/pkg/abi"
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
 	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
 	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
 )
 
 // parserImpl implements Parser and Poller using an RPC client and Storage.
 type parserImpl struct {
-	client           rpc.RPCClient
-	store            storage.Storage
+	client rpc.RPCClient
+	store  storage.Storage
+	// block is the last processed block and head the latest block reported
+	// by the node. Only the polling goroutine writes them, under statusMu,
+	// so other goroutines must hold statusMu to read them.
 	block            int
+	head             int
 	pollingStarted   bool
 	pollingStartedMu sync.Mutex
+	ctx              context.Context // context passed to Start, guarded by pollingStartedMu
+	pollDone         chan struct{}   // closed when pollLoop returns, guarded by pollingStartedMu
 	pollInterval     time.Duration
 	// goroutine management
 	wg sync.WaitGroup
+	// events fans out newly stored transactions for subscribed addresses
+	events  *eventHub[Event]
+	logger  *slog.Logger
+	metrics metrics.Recorder
+	// lag alerting
+	maxLag  int
+	onLag   func(LagStatus)
+	lagging bool
+	// receipts fetches fees of subscribed senders' transactions; nil when
+	// disabled
+	receipts rpc.ReceiptFetcher
+	// enrich fetches receipts in the background; nil when disabled
+	enrich *receiptStage
+	// failed blocks are retried blockRetries times, the first retry after
+	// retryBackoff, before being handed to deadLetters if set
+	blockRetries int
+	retryBackoff time.Duration
+	deadLetters  DeadLetters
+	// blockTimeout bounds each attempt at a block; 0 when unbounded
+	blockTimeout time.Duration
+	// onError receives errors the parser gives up on; nil when unset
+	onError func(error)
+	// blocks caches recently fetched blocks; nil when disabled
+	blocks *blockCache
+	// activity holds the latest events of subscribed addresses; nil when
+	// disabled
+	activity *activityFeed
+	// chain tracks the latest blocks to detect reorgs, whose orphaned
+	// records reverter removes; nil when disabled
+	chain    *canonicalChain
+	reverter storage.Reverter
+	// parallel catch-up; disabled when catchUpWorkers < 2
+	catchUpWorkers   int
+	catchUpThreshold int
+	catchUpQueue     int
+	// ignored holds the lowercase addresses whose transactions are skipped
+	ignored map[string]bool
+	// skipZeroValueCalls drops zero-value contract calls from unsubscribed
+	// senders
+	skipZeroValueCalls bool
+	// balances keeps running balances; nil when disabled
+	balances *balanceBook
+	// dryRun counts what would have been stored; nil unless in dry-run
+	// mode
+	dryRun *dryRunCounters
+	// abi decodes the input data of contract calls; nil when disabled
+	abi abi.Registry
+	// maxInputBytes caps the input data kept on transactions; 0 when input
+	// isn't stored
+	maxInputBytes int
+	// logs fetches event logs; nil unless the client implements
+	// rpc.LogFetcher
+	logs        rpc.LogFetcher
+	indexTokens bool
+	// liveLogs streams the Transfer events of subscribed addresses; nil
+	// unless set, token indexing is enabled and the storage lists
+	// subscriptions
+	liveLogs         rpc.LogSubscriber
+	watchlistChanged chan struct{}
+	tokenMetadata    TokenMetadata
+	tokenBalances    TokenBalances
+	// allowances stores approvals; nil unless token indexing is enabled
+	// and the storage keeps allowances
+	allowances storage.AllowanceStore
+	// categories recategorizes contract calls emitting token transfers;
+	// nil unless token indexing is enabled and the storage supports it
+	categories storage.CategoryStore
+	// eventStore keeps contract event subscriptions; nil unless the client
+	// fetches logs and the storage keeps them
+	eventStore storage.EventStore
+	logEvents  *eventHub[transaction.Log]
+	// contractCalls keeps contract watches; nil unless the storage keeps
+	// them
+	contractCalls storage.ContractWatchStore
+	// gas caches fee statistics; nil unless the client fetches fee history
+	gas *gasCache
+	// history backfills the history of the addresses queued on backfills;
+	// nil when disabled
+	history   HistoryProvider
+	backfills chan string
 	// configuration
 	backwardScanEnabled bool
 	backwardScanDepth   int
+	// shards and shard select the blocks backfilled by this instance
+	shards, shard int
+	// backward reports the progress of the backward scan; nil until one
+	// starts
+	statusMu sync.Mutex
+	backward *ScanProgress // guarded by statusMu
 }
 
 // Options configures parserImpl behavior.
 type Options struct {
 	BackwardScanEnabled bool
 	BackwardScanDepth   int
+	// Logger receives parser logs; defaults to the "parser" component logger.
+	Logger *slog.Logger
+	// Metrics records block processing; defaults to metrics.Nop.
+	Metrics metrics.Recorder
+	// MaxLag is the largest acceptable number of blocks behind the node's
+	// head. OnLag is called when the lag exceeds it and when it recovers.
+	// Alerting is disabled when either is unset.
+	MaxLag int
+	OnLag  func(LagStatus)
+	// FetchReceipts fetches the receipt of every transaction sent by a
+	// subscribed address to record its fee. It is ignored unless the client
+	// implements rpc.ReceiptFetcher.
+	FetchReceipts bool
+	// EnrichReceipts fetches the receipts of transactions involving a
+	// subscribed address in the background once they are stored, instead
+	// of with the block as FetchReceipts does,
+	// and fills in their status and gas used as well as their fee. Block
+	// ingestion doesn't wait for receipts then, but transactions are
+	// delivered to watchers without them. It is ignored unless the client
+	// implements rpc.ReceiptFetcher and the storage storage.ReceiptStore.
+	EnrichReceipts bool
+	// ReceiptWorkers is how many receipt requests enrichment makes
+	// concurrently, and ReceiptBatchSize how many receipts each fetches if
+	// the client implements rpc.ReceiptBatchFetcher. They default to 4 and
+	// 50.
+	ReceiptWorkers   int
+	ReceiptBatchSize int
+	// BlockRetries is how many more times a block that failed to process is
+	// tried, with exponential backoff starting at 1s, before it is given up
+	// on. Blocks given up on are recorded in DeadLetters if it is set, so
+	// they can be re-driven with Rescan.
+	BlockRetries int
+	DeadLetters  DeadLetters
+	// BlockTimeout bounds each attempt at processing a block, so that a
+	// hung RPC response can't stall polling. A block that runs out of time
+	// fails and is retried like any other. 0 leaves blocks unbounded.
+	BlockTimeout time.Duration
+	// OnError is called with the errors the parser gives up on, so that
+	// embedders can branch on their class with errors.Is and errors.As: a
+	// block still failing after its retries, usually a *BlockFetchError; a
+	// failed poll of the node's head; and a failure to persist a
+	// dead-lettered block, wrapping ErrStorageWrite. Errors wrap
+	// ErrRPCUnavailable when the node could not be reached. It may be
+	// called from several goroutines at once and should return quickly.
+	OnError func(error)
+	// BlockCacheSize is how many recently fetched blocks are kept in memory
+	// so that retries and overlapping scans don't fetch them again. 0
+	// disables the cache.
+	BlockCacheSize int
+	// ActivitySize is how many of the transactions of subscribed addresses
+	// in the latest blocks, across all of them, are kept for
+	// RecentActivity. 0 disables the feed.
+	ActivitySize int
+	// ReorgDepth is how many of the latest blocks have their hashes and
+	// the addresses of their records tracked, so that a reorganization of
+	// up to that many blocks is detected while following the head: their
+	// records are removed, announced as removed to watchers, and the new
+	// blocks processed. It needs a storage implementing storage.Reverter. 0
+	// disables detection.
+	ReorgDepth int
+	// CatchUpWorkers is how many blocks are fetched concurrently when the
+	// parser finds itself more than CatchUpThreshold blocks behind the head,
+	// as after downtime. Blocks are still stored in order. Values below 2
+	// keep catch-up serial. CatchUpThreshold defaults to 32.
+	CatchUpWorkers   int
+	CatchUpThreshold int
+	// CatchUpQueueSize is how many blocks catch-up fetches ahead of storing
+	// them. Fetching pauses while that many wait, so slow storage holds back
+	// the node rather than blocks piling up in memory. Values below
+	// CatchUpWorkers also limit concurrent fetches. It defaults to twice
+	// CatchUpWorkers.
+	CatchUpQueueSize int
+	// Shards and Shard split backfills across instances: with Shards above
+	// 1, the backward scan and range scans only process the blocks whose
+	// number modulo Shards is Shard, so that Shards instances numbered 0 to
+	// Shards-1 cover a range together. Following the head is unaffected. An
+	// out-of-range Shard disables sharding.
+	Shards int
+	Shard  int
+	// Ignore lists addresses, such as the zero address or known spam
+	// airdroppers, whose transactions are neither stored nor delivered to
+	// watchers, whichever side of the transaction they are on.
+	Ignore []string
+	// SkipZeroValueCalls drops contract calls that move no ether unless
+	// their sender is subscribed. They make up most of a block and don't
+	// affect native balances; calls to watched contracts are still
+	// recorded, and token transfers are still indexed from their logs.
+	SkipZeroValueCalls bool
+	// TrackBalances keeps a running native balance of subscribed addresses,
+	// see BalanceTracker. It is ignored unless the client implements
+	// rpc.BalanceFetcher. Balances are only exact with FetchReceipts, as
+	// outgoing transactions without a fee make the parser fetch the
+	// balance again on the next read.
+	TrackBalances bool
+	// DryRun fetches and parses blocks without storing transactions or
+	// delivering them to watchers, counting them instead, see DryRunner.
+	// It is meant for validating RPC endpoints, filters and throughput
+	// before going live.
+	DryRun bool
+	// ABI decodes the input data of contract calls into the method name and
+	// arguments stored on the transaction, e.g. abi.Builtin() for ERC-20
+	// and ERC-721 calls. Decoding is disabled when it is nil.
+	ABI abi.Registry
+	// StoreInput keeps the hex input data of transactions on them, for
+	// compliance and debugging workflows that need the calldata. Input
+	// longer than MaxInputBytes bytes, 4096 by default, is cut and flagged
+	// as truncated.
+	StoreInput    bool
+	MaxInputBytes int
+	// IndexTokens indexes ERC-20 and ERC-721 Transfer events into token
+	// transfers for their sender and receiver, and, if the storage
+	// implements storage.AllowanceStore, ERC-20 Approval events into the
+	// allowances granted by subscribed addresses. Contract calls emitting
+	// Transfer events are recategorized as token transfers if the storage
+	// implements storage.CategoryStore. It is ignored unless the client
+	// implements rpc.LogFetcher.
+	IndexTokens bool
+	// LiveLogs, e.g. an *rpc.WSClient, streams the Transfer events sent and
+	// received by subscribed addresses as the node sees them, so that their
+	// token transfers are stored within a second instead of when their
+	// block is polled. The subscriptions follow the watchlist. It is
+	// ignored unless token indexing is enabled and the storage implements
+	// storage.Lister.
+	LiveLogs rpc.LogSubscriber
+	// TokenMetadata attaches the symbol, name and decimals of the token to
+	// indexed transfers, e.g. a *tokens.Resolver. Transfers are stored with
+	// the raw amount only when it is nil.
+	TokenMetadata TokenMetadata
+	// TokenBalances looks up token balances for Portfolio, e.g. a
+	// *tokens.Resolver. Cached balances are invalidated as transfers
+	// changing them are indexed.
+	TokenBalances TokenBalances
+	// GasCacheTTL is how long GasStats results are cached, which bounds
+	// the eth_feeHistory calls made however often fees are requested.
+	// Defaults to 10s, under the block time.
+	GasCacheTTL time.Duration
+	// History, e.g. an *etherscan.Client, backfills the whole history of
+	// newly subscribed addresses in the background once the parser starts,
+	// rather than leaving it to block scans. Token transfers are backfilled
+	// too if token indexing is enabled. Backfilled records aren't delivered
+	// to watchers. It is ignored in dry-run mode.
+	History HistoryProvider
 }
 
 // NewParserWithInterval constructs a parser with a polling interval.
@@ -43,6 +288,87 @@ func NewParserWithInterval(c rpc.RPCClient, s storage.Storage, interval time.Dur
 	if !opts.BackwardScanEnabled {
 		enabled = false
 	}
+	if opts.CatchUpThreshold <= 0 {
+		opts.CatchUpThreshold = 32
+	}
+	if opts.CatchUpQueueSize <= 0 {
+		opts.CatchUpQueueSize = 2 * max(opts.CatchUpWorkers, 1)
+	}
+	if opts.Shards < 1 || opts.Shard < 0 || opts.Shard >= opts.Shards {
+		opts.Shards, opts.Shard = 1, 0
+	}
+	if opts.GasCacheTTL <= 0 {
+		opts.GasCacheTTL = 10 * time.Second
+	}
+	if opts.MaxInputBytes <= 0 {
+		opts.MaxInputBytes = 4096
+	}
+	maxInputBytes := 0
+	if opts.StoreInput {
+		maxInputBytes = opts.MaxInputBytes
+	}
+	if opts.ReceiptWorkers <= 0 {
+		opts.ReceiptWorkers = 4
+	}
+	if opts.ReceiptBatchSize <= 0 {
+		opts.ReceiptBatchSize = 50
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = logging.Component("parser")
+	}
+	ignored := make(map[string]bool, len(opts.Ignore))
+	for _, addr := range opts.Ignore {
+		ignored[strings.ToLower(addr)] = true
+	}
+	var balances rpc.BalanceFetcher
+	if opts.TrackBalances {
+		balances, _ = c.(rpc.BalanceFetcher)
+	}
+	logs, _ := c.(rpc.LogFetcher)
+	indexTokens := opts.IndexTokens && logs != nil
+	var allowances storage.AllowanceStore
+	var categories storage.CategoryStore
+	if indexTokens {
+		allowances, _ = s.(storage.AllowanceStore)
+		categories, _ = s.(storage.CategoryStore)
+	}
+	var liveLogs rpc.LogSubscriber
+	if _, ok := s.(storage.Lister); ok && indexTokens {
+		liveLogs = opts.LiveLogs
+	}
+	var eventStore storage.EventStore
+	if logs != nil {
+		eventStore, _ = s.(storage.EventStore)
+	}
+	contractCalls, _ := s.(storage.ContractWatchStore)
+	feeHistory, _ := c.(rpc.FeeHistoryFetcher)
+	receiptClient, _ := c.(rpc.ReceiptFetcher)
+	var receipts rpc.ReceiptFetcher
+	if opts.FetchReceipts {
+		receipts = receiptClient
+	}
+	var enrich *receiptStage
+	if opts.EnrichReceipts && !opts.DryRun {
+		receiptStore, _ := s.(storage.ReceiptStore)
+		enrich = newReceiptStage(receiptClient, receiptStore, opts.ReceiptWorkers, opts.ReceiptBatchSize, logger, metrics.OrNop(opts.Metrics))
+	}
+	if enrich != nil {
+		// Fees are filled in by enrichment rather than with the block.
+		receipts = nil
+	}
+	reverter, _ := s.(storage.Reverter)
+	var chain *canonicalChain
+	if reverter != nil && !opts.DryRun {
+		chain = newCanonicalChain(opts.ReorgDepth)
+	}
+	var backfills chan string
+	if opts.DryRun {
+		opts.History = nil
+	}
+	if opts.History != nil {
+		backfills = make(chan string, historyQueueSize)
+	}
 
 	return &parserImpl{
 		client:              c,
@@ -51,20 +377,208 @@ func NewParserWithInterval(c rpc.RPCClient, s storage.Storage, interval time.Dur
 		pollInterval:        interval,
 		backwardScanEnabled: enabled,
 		backwardScanDepth:   opts.BackwardScanDepth,
+		shards:              opts.Shards,
+		shard:               opts.Shard,
+		events:              newEventHub[Event](),
+		logger:              logger,
+		metrics:             metrics.OrNop(opts.Metrics),
+		maxLag:              opts.MaxLag,
+		onLag:               opts.OnLag,
+		receipts:            receipts,
+		enrich:              enrich,
+		blockRetries:        opts.BlockRetries,
+		retryBackoff:        time.Second,
+		deadLetters:         opts.DeadLetters,
+		onError:             opts.OnError,
+		blockTimeout:        opts.BlockTimeout,
+		blocks:              newBlockCache(opts.BlockCacheSize),
+		activity:            newActivityFeed(opts.ActivitySize),
+		chain:               chain,
+		reverter:            reverter,
+		catchUpWorkers:      opts.CatchUpWorkers,
+		catchUpThreshold:    opts.CatchUpThreshold,
+		catchUpQueue:        opts.CatchUpQueueSize,
+		ignored:             ignored,
+		skipZeroValueCalls:  opts.SkipZeroValueCalls,
+		balances:            newBalanceBook(balances),
+		dryRun:              newDryRunCounters(opts.DryRun),
+		abi:                 opts.ABI,
+		maxInputBytes:       maxInputBytes,
+		logs:                logs,
+		indexTokens:         indexTokens,
+		liveLogs:            liveLogs,
+		watchlistChanged:    make(chan struct{}, 1),
+		tokenMetadata:       opts.TokenMetadata,
+		tokenBalances:       opts.TokenBalances,
+		allowances:          allowances,
+		categories:          categories,
+		eventStore:          eventStore,
+		logEvents:           newEventHub[transaction.Log](),
+		contractCalls:       contractCalls,
+		gas:                 newGasCache(feeHistory, opts.GasCacheTTL),
+		history:             opts.History,
+		backfills:           backfills,
 	}
 }
 
 // GetCurrentBlock returns the last processed block number.
 func (p *parserImpl) GetCurrentBlock() int {
+	p.statusMu.Lock()
+	defer p.statusMu.Unlock()
 	return p.block
 }
 
 // Subscribe registers an address with the underlying storage.
 func (p *parserImpl) Subscribe(address string) bool {
-	return p.store.Subscribe(address)
+	added := p.store.Subscribe(address)
+	if added {
+		p.notifyWatchlist()
+		p.queueBackfill(address)
+	}
+	return added
+}
+
+// Unsubscribe removes an address from the underlying storage.
+func (p *parserImpl) Unsubscribe(address string) bool {
+	p.balances.forget(address)
+	removed := p.store.Unsubscribe(address)
+	if removed {
+		p.notifyWatchlist()
+	}
+	return removed
+}
+
+// Purge deletes an address's data from the underlying storage, if it
+// implements storage.Purger.
+func (p *parserImpl) Purge(address string) (int, error) {
+	purger, ok := p.store.(storage.Purger)
+	if !ok {
+		return 0, ErrPurgeUnsupported
+	}
+	return purger.Purge(address), nil
+}
+
+// Allowances returns the allowances granted by an address from the
+// underlying storage, if it implements storage.AllowanceStore.
+func (p *parserImpl) Allowances(address string) ([]transaction.Allowance, error) {
+	store, ok := p.store.(storage.AllowanceStore)
+	if !ok {
+		return nil, ErrAllowancesUnsupported
+	}
+	return store.Allowances(address), nil
+}
+
+// RecentActivity returns the latest transactions stored for subscribed
+// addresses, newest first.
+func (p *parserImpl) RecentActivity() ([]Event, error) {
+	if p.activity == nil {
+		return nil, ErrActivityDisabled
+	}
+	return p.activity.recent(), nil
+}
+
+// Subscriptions returns the subscribed addresses from the underlying
+// storage, if it implements storage.Lister.
+func (p *parserImpl) Subscriptions() ([]string, error) {
+	lister, ok := p.store.(storage.Lister)
+	if !ok {
+		return nil, ErrListUnsupported
+	}
+	return lister.Subscriptions(), nil
+}
+
+// TransactionsInRange returns the transactions of subscribed addresses in
+// blocks from through to, if the underlying storage implements
+// storage.RangeReader.
+func (p *parserImpl) TransactionsInRange(from, to int) (map[string][]transaction.Transaction, error) {
+	if from < 1 || to < from {
+		return nil, fmt.Errorf("%w: from=%d to=%d", ErrInvalidRange, from, to)
+	}
+	reader, ok := p.store.(storage.RangeReader)
+	if !ok {
+		return nil, ErrRangeQueryUnsupported
+	}
+	return reader.TransactionsInRange(from, to), nil
+}
+
+// Totals returns an address's value totals from the underlying storage, if
+// it implements storage.Aggregator.
+func (p *parserImpl) Totals(address string) (transaction.Totals, error) {
+	agg, ok := p.store.(storage.Aggregator)
+	if !ok {
+		return transaction.Totals{}, ErrTotalsUnsupported
+	}
+	return agg.Totals(address), nil
+}
+
+// Truncation reports whether the underlying storage dropped transactions of
+// an address to stay within its cap. Storages that don't implement
+// storage.Truncator never do.
+func (p *parserImpl) Truncation(address string) Truncation {
+	truncator, ok := p.store.(storage.Truncator)
+	if !ok {
+		return Truncation{}
+	}
+	earliest, truncated := truncator.Truncation(address)
+	return Truncation{Truncated: truncated, EarliestBlock: earliest}
+}
+
+// Revision returns the revision of an address's transactions from the
+// underlying storage, if it implements storage.Revisioner, and 0 otherwise.
+func (p *parserImpl) Revision(address string) uint64 {
+	revisioner, ok := p.store.(storage.Revisioner)
+	if !ok {
+		return 0
+	}
+	return revisioner.Revision(address)
 }
 
 // GetTransactions returns transactions from the underlying storage.
 func (p *parserImpl) GetTransactions(address string) []transaction.Transaction {
 	return p.store.GetTransactions(address)
 }
+
+// GetTokenTransfers returns token transfers from the underlying storage.
+func (p *parserImpl) GetTokenTransfers(address string) []transaction.TokenTransfer {
+	return p.store.GetTokenTransfers(address)
+}
+
+// GetTransaction returns the stored transaction with the given hash. Unknown
+// hashes are looked up on demand via eth_getTransactionByHash.
+func (p *parserImpl) GetTransaction(ctx context.Context, hash string) (_ transaction.Transaction, err error) {
+	ctx, span := tracer.Start(ctx, "parser.GetTransaction", trace.WithAttributes(attribute.String("tx.hash", hash)))
+	defer func() { endSpan(span, err) }()
+
+	_, storeSpan := tracer.Start(ctx, "storage.GetTransactionByHash")
+	tx, ok := p.store.GetTransactionByHash(hash)
+	storeSpan.End()
+	if ok {
+		return tx, nil
+	}
+	rtx, err := p.client.GetTransactionByHash(ctx, hash)
+	if errors.Is(err, rpc.ErrNotFound) {
+		span.SetAttributes(attribute.Bool("tx.found", false))
+		return transaction.Transaction{}, ErrTransactionNotFound
+	}
+	if err != nil {
+		return transaction.Transaction{}, fmt.Errorf("failed to look up transaction %s: %w", hash, err)
+	}
+	return transaction.Transaction{
+		Hash:  rtx.Hash,
+		From:  rtx.From,
+		To:    rtx.To,
+		Value: hexToValue(rtx.Value),
+		Block: hexToInt(rtx.BlockNumber),
+	}, nil
+}
+
+// Watch streams events for newly stored transactions of subscribed addresses.
+func (p *parserImpl) Watch(ctx context.Context, addresses ...string) <-chan Event {
+	return p.events.watch(ctx, false, addresses)
+}
+
+// Follow is like Watch, but holds up block processing while the channel is
+// full instead of dropping events.
+func (p *parserImpl) Follow(ctx context.Context, addresses ...string) <-chan Event {
+	return p.events.watch(ctx, true, addresses)
+}
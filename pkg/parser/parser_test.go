@@ -2,10 +2,16 @@ package parser
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/danieloluwadare/tw-txparser/internal/storage"
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
 	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
 	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
 )
@@ -14,12 +20,14 @@ import (
 type MockStorage struct {
 	subscriptions map[string]bool
 	transactions  map[string][]transaction.Transaction
+	tokens        map[string][]transaction.TokenTransfer
 }
 
 func NewMockStorage() *MockStorage {
 	return &MockStorage{
 		subscriptions: make(map[string]bool),
 		transactions:  make(map[string][]transaction.Transaction),
+		tokens:        make(map[string][]transaction.TokenTransfer),
 	}
 }
 
@@ -31,6 +39,14 @@ func (m *MockStorage) Subscribe(address string) bool {
 	return true
 }
 
+func (m *MockStorage) Unsubscribe(address string) bool {
+	if !m.subscriptions[address] {
+		return false
+	}
+	delete(m.subscriptions, address)
+	return true
+}
+
 func (m *MockStorage) AddTransaction(addr string, tx transaction.Transaction) {
 	m.transactions[addr] = append(m.transactions[addr], tx)
 }
@@ -39,10 +55,29 @@ func (m *MockStorage) GetTransactions(address string) []transaction.Transaction
 	return m.transactions[address]
 }
 
+func (m *MockStorage) GetTransactionByHash(hash string) (transaction.Transaction, bool) {
+	for _, txs := range m.transactions {
+		for _, tx := range txs {
+			if tx.Hash == hash {
+				return tx, true
+			}
+		}
+	}
+	return transaction.Transaction{}, false
+}
+
 func (m *MockStorage) IsSubscribed(addr string) bool {
 	return m.subscriptions[addr]
 }
 
+func (m *MockStorage) AddTokenTransfer(addr string, tt transaction.TokenTransfer) {
+	m.tokens[addr] = append(m.tokens[addr], tt)
+}
+
+func (m *MockStorage) GetTokenTransfers(address string) []transaction.TokenTransfer {
+	return m.tokens[address]
+}
+
 // MockRPCClient implements a mock RPC client for testing
 type MockRPCClient struct {
 	blockNumberResponse string
@@ -124,6 +159,19 @@ func (m *MockRPCClient) GetBlockByNumberInt(ctx context.Context, blockNumber int
 	return &m.blockResponse, nil
 }
 
+func (m *MockRPCClient) GetTransactionByHash(ctx context.Context, hash string) (*rpc.Transaction, error) {
+	if m.callError != nil {
+		return nil, m.callError
+	}
+	for _, tx := range m.blockResponse.Transactions {
+		if tx.Hash == hash {
+			tx.BlockNumber = m.blockResponse.Number
+			return &tx, nil
+		}
+	}
+	return nil, rpc.ErrNotFound
+}
+
 func TestNewParserWithInterval(t *testing.T) {
 	client := NewMockRPCClient()
 	store := NewMockStorage()
@@ -174,6 +222,75 @@ func TestParser_Subscribe(t *testing.T) {
 	}
 }
 
+func TestParser_Purge(t *testing.T) {
+	parser := NewParserWithInterval(NewMockRPCClient(), NewMockStorage(), 5*time.Second, Options{}).(Purger)
+	if _, err := parser.Purge("0xaaa"); !errors.Is(err, ErrPurgeUnsupported) {
+		t.Errorf("Expected ErrPurgeUnsupported, got %v", err)
+	}
+
+	store := storage.NewMemoryStorage()
+	store.Subscribe("0xaaa")
+	store.AddTransaction("0xaaa", transaction.Transaction{Hash: "0xhash1", To: "0xaaa", Direction: transaction.DirectionIn})
+	parser = NewParserWithInterval(NewMockRPCClient(), store, 5*time.Second, Options{}).(Purger)
+	if n, err := parser.Purge("0xaaa"); err != nil || n != 1 {
+		t.Errorf("Expected 1 record purged, got %d %v", n, err)
+	}
+	if txs := store.GetTransactions("0xaaa"); len(txs) != 0 {
+		t.Errorf("Expected no transactions after purge, got %d", len(txs))
+	}
+}
+
+func TestParser_Totals(t *testing.T) {
+	parser := NewParserWithInterval(NewMockRPCClient(), NewMockStorage(), 5*time.Second, Options{}).(Aggregator)
+	if _, err := parser.Totals("0xaaa"); !errors.Is(err, ErrTotalsUnsupported) {
+		t.Errorf("Expected ErrTotalsUnsupported, got %v", err)
+	}
+
+	store := storage.NewMemoryStorage()
+	store.Subscribe("0xaaa")
+	store.AddTransaction("0xaaa", transaction.Transaction{Hash: "0xhash1", To: "0xaaa", Value: transaction.WeiValue(10), Direction: transaction.DirectionIn})
+	parser = NewParserWithInterval(NewMockRPCClient(), store, 5*time.Second, Options{}).(Aggregator)
+	if totals, err := parser.Totals("0xaaa"); err != nil || totals.In.String() != "10" {
+		t.Errorf("Expected 10 wei received, got %+v %v", totals, err)
+	}
+}
+
+func TestParser_Subscriptions(t *testing.T) {
+	parser := NewParserWithInterval(NewMockRPCClient(), NewMockStorage(), 5*time.Second, Options{}).(Lister)
+	if _, err := parser.Subscriptions(); !errors.Is(err, ErrListUnsupported) {
+		t.Errorf("Expected ErrListUnsupported, got %v", err)
+	}
+
+	store := storage.NewMemoryStorage()
+	store.Subscribe("0xbbb")
+	store.Subscribe("0xaaa")
+	parser = NewParserWithInterval(NewMockRPCClient(), store, 5*time.Second, Options{}).(Lister)
+	if subs, err := parser.Subscriptions(); err != nil || len(subs) != 2 || subs[0] != "0xaaa" {
+		t.Errorf("Expected [0xaaa 0xbbb], got %v %v", subs, err)
+	}
+}
+
+func TestParser_TransactionsInRange(t *testing.T) {
+	parser := NewParserWithInterval(NewMockRPCClient(), NewMockStorage(), 5*time.Second, Options{}).(RangeReader)
+	if _, err := parser.TransactionsInRange(1, 10); !errors.Is(err, ErrRangeQueryUnsupported) {
+		t.Errorf("Expected ErrRangeQueryUnsupported, got %v", err)
+	}
+
+	store := storage.NewMemoryStorage()
+	store.Subscribe("0xaaa")
+	store.AddTransaction("0xaaa", transaction.Transaction{Hash: "0xhash1", To: "0xaaa", Block: 5, Direction: transaction.DirectionIn})
+	store.AddTransaction("0xaaa", transaction.Transaction{Hash: "0xhash2", To: "0xaaa", Block: 11, Direction: transaction.DirectionIn})
+	parser = NewParserWithInterval(NewMockRPCClient(), store, 5*time.Second, Options{}).(RangeReader)
+	if got, err := parser.TransactionsInRange(1, 10); err != nil || len(got["0xaaa"]) != 1 || got["0xaaa"][0].Hash != "0xhash1" {
+		t.Errorf("Expected 0xhash1 in range, got %v %v", got, err)
+	}
+	for _, r := range [][2]int{{0, 10}, {10, 9}} {
+		if _, err := parser.TransactionsInRange(r[0], r[1]); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("Expected ErrInvalidRange for %v, got %v", r, err)
+		}
+	}
+}
+
 func TestParser_GetTransactions(t *testing.T) {
 	client := NewMockRPCClient()
 	store := NewMockStorage()
@@ -188,8 +305,8 @@ func TestParser_GetTransactions(t *testing.T) {
 	}
 
 	// Add some transactions directly to storage
-	tx1 := transaction.Transaction{Hash: "0xhash1", From: "0xfrom1", To: address, Value: "1000", Block: 1, Inbound: true}
-	tx2 := transaction.Transaction{Hash: "0xhash2", From: "0xfrom2", To: address, Value: "2000", Block: 2, Inbound: true}
+	tx1 := transaction.Transaction{Hash: "0xhash1", From: "0xfrom1", To: address, Value: transaction.WeiValue(1000), Block: 1, Direction: transaction.DirectionIn}
+	tx2 := transaction.Transaction{Hash: "0xhash2", From: "0xfrom2", To: address, Value: transaction.WeiValue(2000), Block: 2, Direction: transaction.DirectionIn}
 
 	store.AddTransaction(address, tx1)
 	store.AddTransaction(address, tx2)
@@ -269,6 +386,23 @@ func TestParser_Start_MultipleCalls(t *testing.T) {
 	}
 }
 
+func TestParser_PollDone(t *testing.T) {
+	client := NewMockRPCClient()
+	client.callError = errors.New("connection refused")
+	p := NewParserWithInterval(client, NewMockStorage(), time.Second, Options{}).(*parserImpl)
+	if p.PollDone() != nil {
+		t.Error("Expected no channel before Start")
+	}
+
+	p.Start(context.Background())
+	select {
+	case <-p.PollDone():
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected PollDone to be closed when polling fails to start")
+	}
+	p.Stop()
+}
+
 func TestParser_Stop(t *testing.T) {
 	client := NewMockRPCClient()
 	store := NewMockStorage()
@@ -346,6 +480,7 @@ func TestProcessBlock(t *testing.T) {
 	}
 
 	// Process a block - all transactions are stored regardless of subscription status
+	before := time.Now()
 	err := parserImpl.processBlock(context.Background(), 1234)
 	if err != nil {
 		t.Fatalf("processBlock failed: %v", err)
@@ -379,11 +514,14 @@ func TestProcessBlock(t *testing.T) {
 	if tx.Block != 1234 {
 		t.Errorf("Expected block 1234, got %d", tx.Block)
 	}
-	if tx.Value != "4096" { // 0x1000 in decimal
+	if tx.Value.String() != "4096" { // 0x1000 in decimal
 		t.Errorf("Expected value 4096, got %s", tx.Value)
 	}
-	if tx.Inbound != false {
-		t.Errorf("Expected Inbound=false for from1 transaction, got %t", tx.Inbound)
+	if tx.Direction != transaction.DirectionOut {
+		t.Errorf("Expected direction out for from1 transaction, got %s", tx.Direction)
+	}
+	if tx.IndexedAt.Before(before) || tx.IndexedAt.After(time.Now()) {
+		t.Errorf("Expected indexed_at to be the processing time, got %v", tx.IndexedAt)
 	}
 
 	// Verify transaction details for to1 (inbound transaction)
@@ -394,11 +532,130 @@ func TestProcessBlock(t *testing.T) {
 	if tx.Block != 1234 {
 		t.Errorf("Expected block 1234, got %d", tx.Block)
 	}
-	if tx.Value != "4096" { // 0x1000 in decimal
+	if tx.Value.String() != "4096" { // 0x1000 in decimal
 		t.Errorf("Expected value 4096, got %s", tx.Value)
 	}
-	if tx.Inbound != true {
-		t.Errorf("Expected Inbound=true for to1 transaction, got %t", tx.Inbound)
+	if tx.Direction != transaction.DirectionIn {
+		t.Errorf("Expected direction in for to1 transaction, got %s", tx.Direction)
+	}
+}
+
+func TestProcessBlock_SelfTransfer(t *testing.T) {
+	client := NewMockRPCClient()
+	client.blockResponse.Transactions = []rpc.Transaction{{Hash: "0xself", From: "0xaaa", To: "0xaaa", Value: "0x1"}}
+	store := NewMockStorage()
+	p := NewParserWithInterval(client, store, time.Second, Options{}).(*parserImpl)
+
+	if err := p.processBlock(context.Background(), 1234); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+	txs := store.GetTransactions("0xaaa")
+	if len(txs) != 1 {
+		t.Fatalf("Expected self-transfer to be stored once, got %d", len(txs))
+	}
+	if txs[0].Direction != transaction.DirectionSelf {
+		t.Errorf("Expected direction self, got %s", txs[0].Direction)
+	}
+}
+
+func TestProcessBlock_SkipZeroValueCalls(t *testing.T) {
+	client := NewMockRPCClient()
+	client.blockResponse.Transactions = []rpc.Transaction{
+		{Hash: "0xswap", From: "0xaaa", To: "0xdex", Value: "0x0", Input: "0x38ed1739"},
+		{Hash: "0xmine", From: "0xsub", To: "0xdex", Value: "0x0", Input: "0x38ed1739"},
+		{Hash: "0xpaid", From: "0xaaa", To: "0xdex", Value: "0x1", Input: "0x38ed1739"},
+		{Hash: "0xplain", From: "0xaaa", To: "0xbbb", Value: "0x0", Input: "0x"},
+	}
+	store := NewMockStorage()
+	store.Subscribe("0xsub")
+	p := NewParserWithInterval(client, store, time.Second, Options{SkipZeroValueCalls: true}).(*parserImpl)
+	if err := p.processBlock(context.Background(), 1234); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+
+	var got []string
+	for _, tx := range store.GetTransactions("0xdex") {
+		got = append(got, tx.Hash)
+	}
+	if want := []string{"0xmine", "0xpaid"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected %v stored for the contract, got %v", want, got)
+	}
+	if txs := store.GetTransactions("0xbbb"); len(txs) != 1 {
+		t.Errorf("Expected zero-value transfers to be kept, got %d", len(txs))
+	}
+}
+
+func TestProcessBlock_StoreInput(t *testing.T) {
+	client := NewMockRPCClient()
+	client.blockResponse.Transactions = []rpc.Transaction{
+		{Hash: "0xcall", From: "0xaaa", To: "0xbbb", Value: "0x0", Input: "0xa9059cbb" + strings.Repeat("00", 64)},
+		{Hash: "0xplain", From: "0xaaa", To: "0xccc", Value: "0x1", Input: "0x"},
+	}
+	for _, tt := range []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{name: "disabled", opts: Options{}, want: ""},
+		{name: "truncated", opts: Options{StoreInput: true, MaxInputBytes: 4}, want: "0xa9059cbb"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMockStorage()
+			p := NewParserWithInterval(client, store, time.Second, tt.opts).(*parserImpl)
+			if err := p.processBlock(context.Background(), 1234); err != nil {
+				t.Fatalf("processBlock failed: %v", err)
+			}
+			txs := store.GetTransactions("0xaaa")
+			if len(txs) != 2 {
+				t.Fatalf("Expected 2 transactions, got %d", len(txs))
+			}
+			if txs[0].Input != tt.want || txs[0].InputTruncated != (tt.want != "") {
+				t.Errorf("Unexpected input %q truncated=%v", txs[0].Input, txs[0].InputTruncated)
+			}
+			if txs[1].Input != "" || txs[1].InputTruncated {
+				t.Errorf("Expected no input for a plain transfer, got %q", txs[1].Input)
+			}
+		})
+	}
+}
+
+func TestProcessBlock_Ignore(t *testing.T) {
+	client := NewMockRPCClient()
+	client.blockResponse.Transactions = append(client.blockResponse.Transactions,
+		rpc.Transaction{Hash: "0xspam", From: "0xairdropper", To: "0xto1", Value: "0x1"})
+	store := NewMockStorage()
+	store.Subscribe("0xto1")
+	rec := &gaugeRecorder{gauges: map[string][]float64{}, counters: map[string]float64{}}
+	p := NewParserWithInterval(client, store, time.Second, Options{Metrics: rec, Ignore: []string{"0xAIRDROPPER", "0xto2"}}).(*parserImpl)
+	events := p.Watch(context.Background(), "0xto1")
+
+	if err := p.processBlock(context.Background(), 1234); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+	// Both sides of a transaction involving an ignored address are skipped.
+	for _, addr := range []string{"0xairdropper", "0xfrom2", "0xto2"} {
+		if txs := store.GetTransactions(addr); len(txs) != 0 {
+			t.Errorf("Expected no transactions for %s, got %d", addr, len(txs))
+		}
+	}
+	if txs := store.GetTransactions("0xto1"); len(txs) != 1 || txs[0].Hash != "0xhash1" {
+		t.Errorf("Expected only 0xhash1 for 0xto1, got %+v", txs)
+	}
+	select {
+	case ev := <-events:
+		if ev.Transaction.Hash != "0xhash1" {
+			t.Errorf("Expected an event for 0xhash1, got %s", ev.Transaction.Hash)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected an event for 0xhash1")
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("Unexpected event for %s", ev.Transaction.Hash)
+	default:
+	}
+	if got := rec.counters[metrics.TransactionsIgnored]; got != 2 {
+		t.Errorf("Expected 2 ignored transactions, got %v", got)
 	}
 }
 
@@ -425,3 +682,579 @@ func TestProcessBlock_Error(t *testing.T) {
 		t.Errorf("Expected 0 transactions for from1 due to error, got %d", len(from1Txs))
 	}
 }
+
+// gaugeRecorder keeps every value set per gauge and sums counters.
+type gaugeRecorder struct {
+	gauges   map[string][]float64
+	counters map[string]float64
+}
+
+func (r *gaugeRecorder) Add(name string, delta float64, _ ...metrics.Label) {
+	r.counters[name] += delta
+}
+
+func (r *gaugeRecorder) Set(name string, value float64, _ ...metrics.Label) {
+	r.gauges[name] = append(r.gauges[name], value)
+}
+
+func (r *gaugeRecorder) Observe(string, float64, ...metrics.Label) {}
+
+func TestParser_Metrics(t *testing.T) {
+	rec := &gaugeRecorder{gauges: map[string][]float64{}, counters: map[string]float64{}}
+	p := NewParserWithInterval(NewMockRPCClient(), NewMockStorage(), time.Second, Options{Metrics: rec}).(*parserImpl)
+	p.setBlock(0x1234)
+
+	// The mock node reports 0x1235 as its head.
+	if err := p.checkForNewBlocks(context.Background()); err != nil {
+		t.Fatalf("checkForNewBlocks failed: %v", err)
+	}
+
+	if got := rec.gauges[metrics.HeadBlock]; len(got) != 1 || got[0] != 0x1235 {
+		t.Errorf("Unexpected head block gauge: %v", got)
+	}
+	// Lag is 1 while catching up and 0 once the block is processed.
+	if got := rec.gauges[metrics.BlockLag]; len(got) != 3 || got[1] != 1 || got[2] != 0 {
+		t.Errorf("Unexpected block lag gauge: %v", got)
+	}
+	if got := rec.counters[metrics.BlocksProcessed]; got != 1 {
+		t.Errorf("Expected 1 processed block, got %v", got)
+	}
+	if got := rec.counters[metrics.TransactionsProcessed]; got != 2 {
+		t.Errorf("Expected 2 processed transactions, got %v", got)
+	}
+}
+
+func TestParser_LagAlert(t *testing.T) {
+	var alerts []LagStatus
+	p := NewParserWithInterval(NewMockRPCClient(), NewMockStorage(), time.Second, Options{
+		MaxLag: 2,
+		OnLag:  func(s LagStatus) { alerts = append(alerts, s) },
+	}).(*parserImpl)
+	p.setBlock(0x1230)
+
+	// The mock node reports 0x1235: 5 blocks behind, recovering at 0x1233.
+	if err := p.checkForNewBlocks(context.Background()); err != nil {
+		t.Fatalf("checkForNewBlocks failed: %v", err)
+	}
+
+	expected := []LagStatus{
+		{Lagging: true, Lag: 5, Head: 0x1235, Block: 0x1230, MaxLag: 2},
+		{Lagging: false, Lag: 2, Head: 0x1235, Block: 0x1233, MaxLag: 2},
+	}
+	if len(alerts) != len(expected) {
+		t.Fatalf("Expected %d alerts, got %+v", len(expected), alerts)
+	}
+	for i, want := range expected {
+		if alerts[i] != want {
+			t.Errorf("Alert %d: expected %+v, got %+v", i, want, alerts[i])
+		}
+	}
+	if p.GetCurrentBlock() != 0x1235 {
+		t.Errorf("Expected current block 0x1235, got %#x", p.GetCurrentBlock())
+	}
+}
+
+func TestParser_Watch(t *testing.T) {
+	client := NewMockRPCClient()
+	store := NewMockStorage()
+	parser := NewParserWithInterval(client, store, 5*time.Second, Options{BackwardScanEnabled: false})
+
+	parserImpl, ok := parser.(*parserImpl)
+	if !ok {
+		t.Fatal("Expected parser to be of type *parserImpl")
+	}
+
+	store.Subscribe("0xto1")
+	ctx, cancel := context.WithCancel(context.Background())
+	events := parser.Watch(ctx, "0xto1")
+
+	if err := parserImpl.processBlock(context.Background(), 1234); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+
+	select {
+	case ev := <-events:
+		if ev.Address != "0xto1" || ev.Transaction.Hash != "0xhash1" {
+			t.Errorf("Unexpected event: %+v", ev)
+		}
+		if ev.Transaction.Direction != transaction.DirectionIn {
+			t.Error("Expected inbound event for receiver")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected an event for subscribed address")
+	}
+
+	// Unsubscribed addresses (0xfrom1, 0xto2, ...) must not produce events.
+	select {
+	case ev := <-events:
+		t.Errorf("Unexpected extra event: %+v", ev)
+	default:
+	}
+
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("Expected channel to be closed after cancel")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected channel to close after cancel")
+	}
+}
+
+func TestEventHub_Follow(t *testing.T) {
+	h := newEventHub[int]()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watched := h.watch(ctx, false, nil)
+	followed := h.watch(ctx, true, nil)
+
+	n := watcherBuffer + 5
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		for i := 0; i < n; i++ {
+			h.publish("0xaaa", i)
+		}
+	}()
+	select {
+	case <-published:
+		t.Fatal("Expected publish to wait for the follower")
+	case <-time.After(50 * time.Millisecond):
+	}
+	for i := 0; i < n; i++ {
+		if got := <-followed; got != i {
+			t.Fatalf("Expected event %d, got %d", i, got)
+		}
+	}
+	<-published
+	if got := len(watched); got != watcherBuffer {
+		t.Errorf("Expected the plain watcher to miss events beyond its buffer, got %d", got)
+	}
+
+	// A follower that goes away no longer holds up publish.
+	for i := 0; i < watcherBuffer; i++ {
+		h.publish("0xaaa", i)
+	}
+	cancel()
+	h.publish("0xaaa", 0)
+}
+
+func TestParser_GetTransaction(t *testing.T) {
+	client := NewMockRPCClient()
+	store := NewMockStorage()
+	parser := NewParserWithInterval(client, store, 5*time.Second, Options{BackwardScanEnabled: false})
+
+	// Stored transactions are served from storage.
+	store.AddTransaction("0xfrom9", transaction.Transaction{Hash: "0xstored", From: "0xfrom9", To: "0xto9", Value: transaction.WeiValue(1), Block: 7})
+	tx, err := parser.GetTransaction(context.Background(), "0xstored")
+	if err != nil {
+		t.Fatalf("GetTransaction failed: %v", err)
+	}
+	if tx.Block != 7 {
+		t.Errorf("Expected stored block 7, got %d", tx.Block)
+	}
+
+	// Unknown transactions fall back to the node.
+	tx, err = parser.GetTransaction(context.Background(), "0xhash2")
+	if err != nil {
+		t.Fatalf("GetTransaction fallback failed: %v", err)
+	}
+	if tx.Block != 0x1234 || tx.Value.String() != "8192" {
+		t.Errorf("Unexpected fallback transaction: %+v", tx)
+	}
+
+	// Transactions unknown to the node are reported as not found.
+	if _, err := parser.GetTransaction(context.Background(), "0xmissing"); !errors.Is(err, ErrTransactionNotFound) {
+		t.Errorf("Expected ErrTransactionNotFound, got %v", err)
+	}
+}
+
+func TestParser_Rescan(t *testing.T) {
+	client := NewMockRPCClient()
+	store := NewMockStorage()
+	parser := NewParserWithInterval(client, store, time.Hour, Options{BackwardScanEnabled: false})
+
+	parserImpl, ok := parser.(*parserImpl)
+	if !ok {
+		t.Fatal("Expected parser to be of type *parserImpl")
+	}
+
+	if err := parser.Rescan(1, 2); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("Expected ErrInvalidRange before any block is processed, got %v", err)
+	}
+
+	parserImpl.block = 100
+	if err := parser.Rescan(1, 2); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("Expected ErrNotRunning, got %v", err)
+	}
+	if err := parser.Rescan(10, 5); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("Expected ErrInvalidRange for reversed range, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	parserImpl.pollingStartedMu.Lock()
+	parserImpl.pollingStarted = true
+	parserImpl.ctx = ctx
+	parserImpl.pollingStartedMu.Unlock()
+
+	if err := parser.Rescan(10, 12); err != nil {
+		t.Fatalf("Rescan failed: %v", err)
+	}
+	parserImpl.wg.Wait()
+	cancel()
+
+	if len(store.GetTransactions("0xfrom1")) == 0 {
+		t.Error("Expected rescan to store transactions")
+	}
+}
+
+// receiptClient adds receipts to MockRPCClient.
+type receiptClient struct {
+	*MockRPCClient
+	receipts map[string]*rpc.Receipt
+}
+
+func (c *receiptClient) GetTransactionReceipt(_ context.Context, hash string) (*rpc.Receipt, error) {
+	if r, ok := c.receipts[hash]; ok {
+		return r, nil
+	}
+	return nil, rpc.ErrNotFound
+}
+
+func TestProcessBlock_Fees(t *testing.T) {
+	client := &receiptClient{
+		MockRPCClient: NewMockRPCClient(),
+		receipts: map[string]*rpc.Receipt{
+			"0xhash1": {TransactionHash: "0xhash1", Status: "0x1", GasUsed: "0x5208", EffectiveGasPrice: "0x3b9aca00"},
+			"0xhash2": {TransactionHash: "0xhash2", Status: "0x1", GasUsed: "0x5208", EffectiveGasPrice: "0x3b9aca00"},
+		},
+	}
+	client.blockResponse.Transactions = append(client.blockResponse.Transactions,
+		rpc.Transaction{Hash: "0xnoreceipt", From: "0xfrom1", To: "0xto3", Value: "0x1"})
+	store := NewMockStorage()
+	store.Subscribe("0xfrom1")
+	p := NewParserWithInterval(client, store, time.Second, Options{FetchReceipts: true}).(*parserImpl)
+
+	if err := p.processBlock(context.Background(), 1234); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+	for _, addr := range []string{"0xfrom1", "0xto1"} {
+		txs := store.GetTransactions(addr)
+		if len(txs) == 0 || txs[0].Fee == nil || txs[0].Fee.String() != "21000000000000" {
+			t.Errorf("Expected fee of 0xhash1 to be recorded for %s, got %+v", addr, txs)
+		}
+	}
+	if txs := store.GetTransactions("0xfrom2"); len(txs) != 1 || txs[0].Fee != nil {
+		t.Errorf("Expected no fee for an unsubscribed sender, got %+v", txs)
+	}
+	if txs := store.GetTransactions("0xto3"); len(txs) != 1 || txs[0].Fee != nil {
+		t.Errorf("Expected transaction without a receipt to be stored without a fee, got %+v", txs)
+	}
+
+	// Disabled by default.
+	store = NewMockStorage()
+	store.Subscribe("0xfrom1")
+	p = NewParserWithInterval(client, store, time.Second, Options{}).(*parserImpl)
+	if err := p.processBlock(context.Background(), 1234); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+	if txs := store.GetTransactions("0xfrom1"); txs[0].Fee != nil {
+		t.Errorf("Expected no fee without FetchReceipts, got %s", txs[0].Fee)
+	}
+}
+
+// streamingClient adds block streaming to MockRPCClient, failing after
+// failAfter transactions when set.
+type streamingClient struct {
+	*MockRPCClient
+	streamed  int
+	failAfter int
+}
+
+func (c *streamingClient) StreamBlockByNumber(_ context.Context, _ int, fn func(rpc.Transaction) error) (*rpc.Block, error) {
+	for i, tx := range c.blockResponse.Transactions {
+		if c.failAfter > 0 && i == c.failAfter {
+			return nil, errors.New("connection reset")
+		}
+		c.streamed++
+		if err := fn(tx); err != nil {
+			return nil, err
+		}
+	}
+	return &rpc.Block{Number: c.blockResponse.Number}, nil
+}
+
+func TestProcessBlock_Streaming(t *testing.T) {
+	client := &streamingClient{MockRPCClient: NewMockRPCClient()}
+	store := NewMockStorage()
+	p := NewParserWithInterval(client, store, time.Second, Options{}).(*parserImpl)
+
+	if err := p.processBlock(context.Background(), 1234); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+	if client.streamed != 2 {
+		t.Errorf("Expected the block to be streamed, got %d transactions", client.streamed)
+	}
+	if len(store.GetTransactions("0xfrom1")) != 1 || len(store.GetTransactions("0xto2")) != 1 {
+		t.Errorf("Expected streamed transactions to be stored, got %v", store.transactions)
+	}
+
+	// A failure mid-block fails the block, keeping what was already stored.
+	client = &streamingClient{MockRPCClient: NewMockRPCClient(), failAfter: 1}
+	store = NewMockStorage()
+	p = NewParserWithInterval(client, store, time.Second, Options{}).(*parserImpl)
+	if err := p.processBlock(context.Background(), 1234); err == nil {
+		t.Fatal("Expected processBlock to fail")
+	}
+	if len(store.GetTransactions("0xfrom1")) != 1 || len(store.GetTransactions("0xfrom2")) != 0 {
+		t.Errorf("Unexpected stored transactions: %v", store.transactions)
+	}
+}
+
+// countingClient counts block fetches of MockRPCClient.
+type countingClient struct {
+	*MockRPCClient
+	fetches int
+}
+
+func (c *countingClient) GetBlockByNumberInt(ctx context.Context, blockNumber int, includeTransactions bool) (*rpc.Block, error) {
+	c.fetches++
+	return c.MockRPCClient.GetBlockByNumberInt(ctx, blockNumber, includeTransactions)
+}
+
+func TestProcessBlock_Cache(t *testing.T) {
+	client := &countingClient{MockRPCClient: NewMockRPCClient()}
+	rec := &gaugeRecorder{gauges: map[string][]float64{}, counters: map[string]float64{}}
+	store := NewMockStorage()
+	p := NewParserWithInterval(client, store, time.Second, Options{BlockCacheSize: 4, Metrics: rec}).(*parserImpl)
+
+	for range 2 {
+		if err := p.processBlock(context.Background(), 1234); err != nil {
+			t.Fatalf("processBlock failed: %v", err)
+		}
+	}
+	if client.fetches != 1 {
+		t.Errorf("Expected the block to be fetched once, got %d fetches", client.fetches)
+	}
+	if got := rec.counters[metrics.BlockCacheRequests]; got != 2 {
+		t.Errorf("Expected 2 cache lookups, got %v", got)
+	}
+	if got := rec.counters[metrics.TransactionsProcessed]; got != 4 {
+		t.Errorf("Expected cached transactions to be processed again, got %v", got)
+	}
+
+	// Failed fetches aren't cached.
+	client = &countingClient{MockRPCClient: NewMockRPCClient()}
+	client.callError = errors.New("node down")
+	p = NewParserWithInterval(client, NewMockStorage(), time.Second, Options{BlockCacheSize: 4}).(*parserImpl)
+	_ = p.processBlock(context.Background(), 1234)
+	client.callError = nil
+	if err := p.processBlock(context.Background(), 1234); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+	if client.fetches != 2 || p.blocks.len() != 1 {
+		t.Errorf("Expected a refetch after a failure, got %d fetches and %d cached blocks", client.fetches, p.blocks.len())
+	}
+}
+
+// rangeClient serves block n with a single transaction 0x<n> from 0xfrom,
+// answering later blocks faster to shuffle completion order.
+type rangeClient struct {
+	*MockRPCClient
+	head string
+
+	mu             sync.Mutex
+	inFlight, peak int
+}
+
+func (c *rangeClient) GetBlockNumber(ctx context.Context) (string, error) {
+	return c.head, nil
+}
+
+func (c *rangeClient) GetBlockByNumberInt(ctx context.Context, blockNumber int, includeTransactions bool) (*rpc.Block, error) {
+	c.mu.Lock()
+	c.inFlight++
+	c.peak = max(c.peak, c.inFlight)
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.inFlight--
+		c.mu.Unlock()
+	}()
+
+	time.Sleep(time.Duration(200-blockNumber) * 100 * time.Microsecond)
+	hash := fmt.Sprintf("0x%x", blockNumber)
+	return &rpc.Block{Number: hash, Transactions: []rpc.Transaction{{Hash: hash, From: "0xfrom", To: "0xto", Value: "0x1"}}}, nil
+}
+
+func TestCatchUp_Backpressure(t *testing.T) {
+	client := &rangeClient{MockRPCClient: NewMockRPCClient()}
+	p := NewParserWithInterval(client, NewMockStorage(), time.Second, Options{CatchUpWorkers: 4, CatchUpQueueSize: 3}).(*parserImpl)
+	queue := make(chan *prefetched, p.catchUpQueue)
+	finished := make(chan struct{})
+	go func() {
+		p.fetchAhead(context.Background(), 101, 150, queue)
+		close(finished)
+	}()
+
+	// Nothing is stored, so fetching stops once the queue is full.
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case <-finished:
+		t.Fatal("Expected fetching to wait for the queue to drain")
+	default:
+	}
+	if len(queue) != 3 {
+		t.Errorf("Expected 3 queued blocks, got %d", len(queue))
+	}
+
+	next := 101
+	for b := range queue {
+		<-b.done
+		if b.number != next || b.err != nil || len(b.txs) != 1 {
+			t.Fatalf("Expected block %d, got %d with %d transactions and error %v", next, b.number, len(b.txs), b.err)
+		}
+		next++
+	}
+	if next != 151 {
+		t.Errorf("Expected blocks through 150, got through %d", next-1)
+	}
+}
+
+func TestScan_Shards(t *testing.T) {
+	tests := []struct {
+		name         string
+		shards       int
+		shard        int
+		backward     bool
+		from, to     int
+		expectedTxes []string
+	}{
+		{name: "unsharded", shards: 1, from: 1, to: 4, expectedTxes: []string{"0x1", "0x2", "0x3", "0x4"}},
+		{name: "range", shards: 3, shard: 1, from: 1, to: 10, expectedTxes: []string{"0x1", "0x4", "0x7", "0xa"}},
+		{name: "range starting past the shard", shards: 3, shard: 0, from: 4, to: 10, expectedTxes: []string{"0x6", "0x9"}},
+		{name: "backward", shards: 3, shard: 2, backward: true, from: 20, to: 11, expectedTxes: []string{"0x14", "0x11", "0xe", "0xb"}},
+		{name: "invalid shard disables sharding", shards: 2, shard: 2, from: 1, to: 3, expectedTxes: []string{"0x1", "0x2", "0x3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMockStorage()
+			store.Subscribe("0xfrom")
+			p := NewParserWithInterval(&rangeClient{MockRPCClient: NewMockRPCClient()}, store, time.Second, Options{Shards: tt.shards, Shard: tt.shard}).(*parserImpl)
+			if tt.backward {
+				p.wg.Add(1)
+				p.scanBackward(context.Background(), tt.from, tt.to)
+			} else if err := p.ScanRange(context.Background(), tt.from, tt.to); err != nil {
+				t.Fatalf("ScanRange failed: %v", err)
+			}
+			var got []string
+			for _, tx := range store.GetTransactions("0xfrom") {
+				got = append(got, tx.Hash)
+			}
+			if !slices.Equal(got, tt.expectedTxes) {
+				t.Errorf("Expected %v, got %v", tt.expectedTxes, got)
+			}
+		})
+	}
+}
+
+func TestParser_Status(t *testing.T) {
+	p := NewParserWithInterval(&rangeClient{MockRPCClient: NewMockRPCClient()}, NewMockStorage(), time.Second, Options{Shards: 3, Shard: 2}).(*parserImpl)
+	if s := p.Status(); s.Lag != 0 || s.BackwardScan != nil {
+		t.Errorf("Expected no lag nor scan before polling, got %+v", s)
+	}
+
+	p.setHead(30)
+	p.setBlock(25)
+	p.startBackwardProgress(20, 11)
+	s := p.Status()
+	if s.Head != 30 || s.Block != 25 || s.Lag != 5 {
+		t.Errorf("Unexpected status %+v", s)
+	}
+	if s.BackwardScan.Remaining != 4 || s.BackwardScan.Done {
+		t.Errorf("Expected 4 blocks of the shard to scan, got %+v", s.BackwardScan)
+	}
+
+	p.wg.Add(1)
+	p.scanBackward(context.Background(), 20, 11)
+	if got := *p.Status().BackwardScan; got != (ScanProgress{From: 20, To: 11, Block: 11, Done: true}) {
+		t.Errorf("Unexpected progress after the scan %+v", got)
+	}
+}
+
+// TestParser_Status_Concurrent reads the status while blocks are polled, for
+// the race detector.
+func TestParser_Status_Concurrent(t *testing.T) {
+	client := &rangeClient{MockRPCClient: NewMockRPCClient(), head: "0x96"}
+	p := NewParserWithInterval(client, storage.NewMemoryStorage(), time.Second, Options{CatchUpWorkers: 4, CatchUpThreshold: 20}).(*parserImpl)
+	p.setBlock(100)
+
+	done := make(chan error)
+	go func() { done <- p.checkForNewBlocks(context.Background()) }()
+	for {
+		s := p.Status()
+		if s.Block < 100 || s.Block > 150 || s.Lag < 0 {
+			t.Fatalf("Unexpected status %+v", s)
+		}
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("checkForNewBlocks failed: %v", err)
+			}
+			if s := p.Status(); s.Head != 150 || s.Block != 150 || s.Lag != 0 {
+				t.Errorf("Expected to be caught up at block 150, got %+v", s)
+			}
+			return
+		default:
+			p.GetCurrentBlock()
+		}
+	}
+}
+
+func TestCheckForNewBlocks_CatchUp(t *testing.T) {
+	tests := []struct {
+		name     string
+		head     int
+		wantPeak int
+	}{
+		{name: "below threshold", head: 110, wantPeak: 1},
+		{name: "parallel", head: 150, wantPeak: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &rangeClient{MockRPCClient: NewMockRPCClient(), head: fmt.Sprintf("0x%x", tt.head)}
+			rec := &gaugeRecorder{gauges: map[string][]float64{}, counters: map[string]float64{}}
+			store := NewMockStorage()
+			p := NewParserWithInterval(client, store, time.Second, Options{Metrics: rec, CatchUpWorkers: 4, CatchUpThreshold: 20}).(*parserImpl)
+			p.setBlock(100)
+
+			if err := p.checkForNewBlocks(context.Background()); err != nil {
+				t.Fatalf("checkForNewBlocks failed: %v", err)
+			}
+			if p.GetCurrentBlock() != tt.head {
+				t.Errorf("Expected current block %d, got %d", tt.head, p.GetCurrentBlock())
+			}
+			if client.peak != tt.wantPeak {
+				t.Errorf("Expected at most %d concurrent fetches, got %d", tt.wantPeak, client.peak)
+			}
+
+			// Blocks are committed in order despite finishing out of order.
+			txs := store.GetTransactions("0xfrom")
+			if len(txs) != tt.head-100 {
+				t.Fatalf("Expected %d transactions, got %d", tt.head-100, len(txs))
+			}
+			for i, tx := range txs {
+				if tx.Block != 101+i {
+					t.Fatalf("Expected block %d at position %d, got %d", 101+i, i, tx.Block)
+				}
+			}
+			current := rec.gauges[metrics.CurrentBlock]
+			for i := 1; i < len(current); i++ {
+				if current[i] != current[i-1]+1 {
+					t.Fatalf("Expected the current block to advance one at a time, got %v", current)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package parser
+
+import (
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
+)
+
+// blockPhases accumulates the time spent on one block's transactions in
+// work that is interleaved while blocks are streamed: handling the
+// transactions as a whole, and fetching receipts and storing records
+// within that.
+type blockPhases struct {
+	handle  time.Duration
+	receipt time.Duration
+	store   time.Duration
+}
+
+// since adds the time elapsed since start to *d.
+func since(d *time.Duration, start time.Time) {
+	*d += time.Since(start)
+}
+
+// observe records the block's phases given the total time its source ran.
+// Time outside transaction handling is fetching and decoding the block, and
+// receipt lookups are fetching too; the rest of handling is parsing.
+func (ph *blockPhases) observe(r metrics.Recorder, source time.Duration) {
+	fetch := source - ph.handle + ph.receipt
+	parse := ph.handle - ph.receipt - ph.store
+	r.Observe(metrics.BlockPhaseDuration, max(fetch, 0).Seconds(), metrics.L("phase", "fetch"))
+	r.Observe(metrics.BlockPhaseDuration, max(parse, 0).Seconds(), metrics.L("phase", "parse"))
+	r.Observe(metrics.BlockPhaseDuration, ph.store.Seconds(), metrics.L("phase", "store"))
+}
@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// phaseRecorder keeps the observed block phase durations by phase.
+type phaseRecorder struct {
+	mu     sync.Mutex
+	phases map[string]float64
+}
+
+func (r *phaseRecorder) Add(string, float64, ...metrics.Label) {}
+func (r *phaseRecorder) Set(string, float64, ...metrics.Label) {}
+
+func (r *phaseRecorder) Observe(name string, value float64, labels ...metrics.Label) {
+	if name != metrics.BlockPhaseDuration {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.phases[labels[0].Value] += value
+}
+
+// slowClient takes fetch to return a block.
+type slowClient struct {
+	*MockRPCClient
+	fetch time.Duration
+}
+
+func (c *slowClient) GetBlockByNumberInt(ctx context.Context, blockNumber int, includeTransactions bool) (*rpc.Block, error) {
+	time.Sleep(c.fetch)
+	return c.MockRPCClient.GetBlockByNumberInt(ctx, blockNumber, includeTransactions)
+}
+
+// slowStorage takes write to store a transaction.
+type slowStorage struct {
+	*MockStorage
+	write time.Duration
+}
+
+func (s *slowStorage) AddTransaction(addr string, tx transaction.Transaction) {
+	time.Sleep(s.write)
+	s.MockStorage.AddTransaction(addr, tx)
+}
+
+func TestProcessBlock_Phases(t *testing.T) {
+	rec := &phaseRecorder{phases: map[string]float64{}}
+	client := &slowClient{MockRPCClient: NewMockRPCClient(), fetch: 40 * time.Millisecond}
+	store := &slowStorage{MockStorage: NewMockStorage(), write: 10 * time.Millisecond}
+	p := NewParserWithInterval(client, store, time.Second, Options{Metrics: rec}).(*parserImpl)
+
+	if err := p.processBlock(context.Background(), 1234); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+	// The mock block holds two transactions, each stored for both sides.
+	if got := rec.phases["fetch"]; got < 0.04 {
+		t.Errorf("Expected at least 40ms fetching, got %v", got)
+	}
+	if got := rec.phases["store"]; got < 0.04 {
+		t.Errorf("Expected at least 40ms storing, got %v", got)
+	}
+	if got, ok := rec.phases["parse"]; !ok || got >= 0.04 {
+		t.Errorf("Expected parsing to take less than the slow phases, got %v", got)
+	}
+}
@@ -4,10 +4,17 @@ package parser
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"strconv"
+	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
 	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
 )
 
@@ -19,25 +26,52 @@ func (p *parserImpl) Start(ctx context.Context) {
 		return
 	}
 	p.pollingStarted = true
+	p.ctx = ctx
+	p.pollDone = make(chan struct{})
 
 	p.wg.Add(1)
-	go p.pollLoop(ctx)
+	go p.pollLoop(ctx, p.pollDone)
+	if p.enrich != nil {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.enrich.run(ctx)
+		}()
+	}
+	if p.liveLogs != nil {
+		p.wg.Add(1)
+		go p.followLogs(ctx)
+	}
+	if p.history != nil {
+		p.wg.Add(1)
+		go p.backfillHistory(ctx)
+	}
+}
+
+// PollDone returns a channel closed once the polling started by the last
+// Start stops.
+func (p *parserImpl) PollDone() <-chan struct{} {
+	p.pollingStartedMu.Lock()
+	defer p.pollingStartedMu.Unlock()
+	return p.pollDone
 }
 
 // Stop gracefully stops all goroutines and waits for them to complete.
 func (p *parserImpl) Stop() {
-	log.Println("[parser] stopping parser and waiting for goroutines to complete...")
+	p.logger.Info("stopping parser and waiting for goroutines to complete")
 	p.wg.Wait()
-	log.Println("[parser] all goroutines stopped")
+	p.logger.Info("all goroutines stopped")
 }
 
-// pollLoop initializes the current block, kicks off scans, and runs forward scanning until cancelled.
-func (p *parserImpl) pollLoop(ctx context.Context) {
+// pollLoop initializes the current block, kicks off scans, and runs forward
+// scanning until cancelled. It closes done when it returns.
+func (p *parserImpl) pollLoop(ctx context.Context, done chan struct{}) {
 	// Ensure pollingStarted flag is reset and WaitGroup is decremented when we exit
 	defer func() {
 		p.pollingStartedMu.Lock()
 		p.pollingStarted = false
 		p.pollingStartedMu.Unlock()
+		close(done)
 		p.wg.Done()
 	}()
 	ticker := time.NewTicker(p.pollInterval)
@@ -46,16 +80,19 @@ func (p *parserImpl) pollLoop(ctx context.Context) {
 	// --- Step 1: Initialize current block ---
 	blockHex, err := p.client.GetBlockNumber(ctx)
 	if err != nil {
-		log.Printf("[poll] failed to init current block: %v", err)
+		err = fmt.Errorf("failed to get latest block number: %w", unavailable(ctx, err))
+		p.logger.Error("failed to init current block", logging.KeyError, err)
+		p.reportError(ctx, err)
 		return
 	}
 	latestBlock := hexToInt(blockHex)
-	log.Printf("[poll] initialized at block %d", latestBlock)
+	p.setHead(latestBlock)
+	p.logger.Info("initialized current block", logging.KeyBlock, latestBlock)
 	// --- Step 2: Process the latest block immediately ---
-	if err := p.processBlock(ctx, latestBlock); err != nil {
-		log.Printf("[poll] failed to process initial block %d: %v", latestBlock, err)
+	if err := p.processBlockWithRetries(ctx, latestBlock); err != nil {
+		p.logger.Error("failed to process initial block", logging.KeyBlock, latestBlock, logging.KeyError, err)
 	}
-	p.block = latestBlock
+	p.setBlock(latestBlock)
 
 	// --- Step 3: Optionally start bounded backward scan in a goroutine ---
 	if p.backwardScanEnabled {
@@ -71,38 +108,45 @@ func (p *parserImpl) pollLoop(ctx context.Context) {
 	p.scanForward(ctx, ticker)
 }
 
-// scanBackward iterates from `from` down to `stopAt` (inclusive), processing each block.
+// scanBackward iterates from `from` down to `stopAt` (inclusive), processing
+// each block of this instance's shard.
 func (p *parserImpl) scanBackward(ctx context.Context, from int, stopAt int) {
 	defer p.wg.Done()
-	log.Printf("[backward] starting scan from %d -> %d", from, stopAt)
-	for i := from; i >= stopAt; i-- {
+	logger := p.logger.With("scan", "backward")
+	logger.Info("starting scan", "from", from, "to", stopAt, "shard", p.shard, "shards", p.shards)
+	p.startBackwardProgress(from, stopAt)
+	for i := from - p.shardOffset(from); i >= stopAt; i -= p.shards {
 		select {
 		case <-ctx.Done():
-			log.Println("[backward] stopping backward scan")
+			logger.Info("stopping scan")
 			return
 		default:
-			if err := p.processBlock(ctx, i); err != nil {
-				log.Printf("[backward] failed to process block %d: %v", i, err)
+			if err := p.processBlockWithRetries(ctx, i); err != nil {
+				logger.Error("failed to process block", logging.KeyBlock, i, logging.KeyError, err)
 			}
-			if i%1000 == 0 {
-				log.Printf("[backward] scanned down to block %d", i)
+			p.advanceBackwardProgress(i)
+			if i%1000 < p.shards {
+				logger.Info("scan progress", logging.KeyBlock, i)
 			}
 		}
 	}
-	log.Println("[backward] completed bounded historical scan")
+	p.finishBackwardProgress()
+	logger.Info("completed bounded historical scan")
 }
 
 // scanForward periodically checks for new blocks and processes them.
 func (p *parserImpl) scanForward(ctx context.Context, ticker *time.Ticker) {
-	log.Printf("[Forward] starting scan from %d ", p.block)
+	logger := p.logger.With("scan", "forward")
+	logger.Info("starting scan", "from", p.block)
 	for {
 		select {
 		case <-ctx.Done():
-			log.Println("[forward] stopping forward scan")
+			logger.Info("stopping scan")
 			return
 		case <-ticker.C:
 			if err := p.checkForNewBlocks(ctx); err != nil {
-				log.Printf("[forward] error checking new blocks: %v", err)
+				logger.Error("error checking new blocks", logging.KeyError, err)
+				p.reportError(ctx, err)
 			}
 		}
 	}
@@ -112,56 +156,292 @@ func (p *parserImpl) scanForward(ctx context.Context, ticker *time.Ticker) {
 func (p *parserImpl) checkForNewBlocks(ctx context.Context) error {
 	blockHex, err := p.client.GetBlockNumber(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get latest block number: %w", err)
+		return fmt.Errorf("failed to get latest block number: %w", unavailable(ctx, err))
 	}
 	latestBlock := hexToInt(blockHex)
+	p.setHead(latestBlock)
 
+	if p.catchUpWorkers > 1 && latestBlock-p.block > p.catchUpThreshold {
+		p.catchUp(ctx, p.block+1, latestBlock)
+		return nil
+	}
 	if latestBlock > p.block {
+		// The current block advances per block, failed or not, so that lag
+		// shrinks while catching up.
 		for i := p.block + 1; i <= latestBlock; i++ {
-			if err := p.processBlock(ctx, i); err != nil {
-				log.Printf("[forward] failed to process block %d: %v", i, err)
+			if err := p.processBlockWithRetries(ctx, i); err != nil {
+				p.logger.Error("failed to process block", "scan", "forward", logging.KeyBlock, i, logging.KeyError, err)
 			} else {
-				log.Printf("[forward] processed block %d", i)
+				p.logger.Info("processed block", "scan", "forward", logging.KeyBlock, i)
+				p.checkReorg(ctx, i)
 			}
+			p.setBlock(i)
 		}
-		p.block = latestBlock
 	}
 	return nil
 }
 
+// setBlock records the last processed block.
+func (p *parserImpl) setBlock(n int) {
+	p.statusMu.Lock()
+	p.block = n
+	p.statusMu.Unlock()
+	p.metrics.Set(metrics.CurrentBlock, float64(n))
+	p.metrics.Set(metrics.BlockLag, float64(max(p.head-n, 0)))
+	p.checkLag()
+}
+
+// setHead records the latest block reported by the node.
+func (p *parserImpl) setHead(n int) {
+	p.statusMu.Lock()
+	p.head = n
+	p.statusMu.Unlock()
+	p.metrics.Set(metrics.HeadBlock, float64(n))
+	if p.block > 0 { // no lag until the first block is processed
+		p.metrics.Set(metrics.BlockLag, float64(max(n-p.block, 0)))
+	}
+	p.checkLag()
+}
+
 // processBlock fetches a block by number and stores all transactions.
 // Transactions are stored for both sender and receiver addresses, regardless of subscription status.
 // This ensures no historical data is lost when addresses subscribe later.
 func (p *parserImpl) processBlock(ctx context.Context, number int) error {
-	block, err := p.client.GetBlockByNumberInt(ctx, number, true)
+	return p.storeBlock(ctx, number, func(ctx context.Context, fn func(rpc.Transaction)) error {
+		return p.blockTransactions(ctx, number, fn)
+	})
+}
+
+// storeBlock stores the transactions source passes to fn as block number,
+// recording the block's span and metrics. source runs in the block's span.
+func (p *parserImpl) storeBlock(ctx context.Context, number int, source func(context.Context, func(rpc.Transaction)) error) (err error) {
+	// Errors are classified against the caller's ctx, so that a block
+	// running out of time counts as the node being unavailable.
+	parent := ctx
+	ctx, cancel := p.blockContext(ctx)
+	defer cancel()
+	ctx, span := tracer.Start(ctx, "parser.processBlock", trace.WithAttributes(attribute.Int("block.number", number)))
+	start := time.Now()
+	defer func() {
+		endSpan(span, err)
+		p.metrics.Add(metrics.BlocksProcessed, 1, metrics.Result(err))
+		metrics.ObserveSince(p.metrics, metrics.BlockDuration, start)
+	}()
+
+	count := 0
+	var phases blockPhases
+	sourceStart := time.Now()
+	err = source(ctx, func(tx rpc.Transaction) {
+		count++
+		defer since(&phases.handle, time.Now())
+		p.processTransaction(ctx, number, tx, &phases)
+	})
+	phases.observe(p.metrics, time.Since(sourceStart))
+	span.SetAttributes(attribute.Int("block.transactions", count))
+	p.metrics.Add(metrics.TransactionsProcessed, float64(count))
+	if p.dryRun != nil {
+		p.dryRun.transactions.Add(int64(count))
+		if err != nil {
+			p.dryRun.failedBlocks.Add(1)
+		} else {
+			p.dryRun.blocks.Add(1)
+		}
+	}
 	if err != nil {
-		return fmt.Errorf("failed to fetch block %d: %w", number, err)
+		return &BlockFetchError{Block: number, Cause: unavailable(parent, err)}
 	}
+	if p.indexTokens {
+		if err := p.indexTokenEvents(ctx, number); err != nil {
+			return &BlockFetchError{Block: number, Cause: unavailable(parent, err)}
+		}
+	}
+	if p.eventStore != nil {
+		if err := p.indexContractEvents(ctx, number); err != nil {
+			return &BlockFetchError{Block: number, Cause: unavailable(parent, err)}
+		}
+	}
+	return nil
+}
 
-	for _, tx := range block.Transactions {
-		log.Printf("to address: %s and from address: %s", tx.To, tx.From)
-
-		// Store transaction for sender address (outbound from sender's perspective)
-		p.store.AddTransaction(tx.From, transaction.Transaction{
-			Hash:    tx.Hash,
-			From:    tx.From,
-			To:      tx.To,
-			Value:   hexToBigIntString(tx.Value),
-			Block:   number,
-			Inbound: false, // Outbound transaction (from sender's perspective)
-		})
+// blockContext returns ctx bounded by the block timeout, if any.
+func (p *parserImpl) blockContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.blockTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, p.blockTimeout)
+}
+
+// blockTransactions passes the transactions of block number to fn, from the
+// block cache if possible. Fetched blocks are added to the cache.
+func (p *parserImpl) blockTransactions(ctx context.Context, number int, fn func(rpc.Transaction)) error {
+	if txs, ok := p.blocks.get(number); ok {
+		p.metrics.Add(metrics.BlockCacheRequests, 1, metrics.L("result", "hit"))
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("block.cached", true))
+		for _, tx := range txs {
+			fn(tx)
+		}
+		return nil
+	}
+	if p.blocks != nil {
+		p.metrics.Add(metrics.BlockCacheRequests, 1, metrics.L("result", "miss"))
+	}
+	var fetched []rpc.Transaction // kept for the cache
+	block, err := p.fetchBlock(ctx, number, func(tx rpc.Transaction) {
+		if p.blocks != nil {
+			fetched = append(fetched, tx)
+		}
+		fn(tx)
+	})
+	// Blocks the node doesn't know yet come back empty and aren't cached.
+	// Cached blocks had their timestamp and hashes recorded when they were
+	// fetched.
+	if err == nil && block.Number != "" {
+		p.blocks.add(number, fetched)
+		p.recordBlockTime(number, block)
+		p.chain.observe(number, block.Hash, block.ParentHash)
+	}
+	return err
+}
 
-		// Store transaction for receiver address (inbound from receiver's perspective)
-		p.store.AddTransaction(tx.To, transaction.Transaction{
-			Hash:    tx.Hash,
-			From:    tx.From,
-			To:      tx.To,
-			Value:   hexToBigIntString(tx.Value),
-			Block:   number,
-			Inbound: true, // Inbound transaction (to receiver's perspective)
+// fetchBlock fetches block number from the node and passes each of its
+// transactions to fn, streaming them when the client supports it. The block
+// is returned without its transactions.
+func (p *parserImpl) fetchBlock(ctx context.Context, number int, fn func(rpc.Transaction)) (*rpc.Block, error) {
+	if streamer, ok := p.client.(rpc.BlockStreamer); ok {
+		// Transactions are handled as they are decoded. A retry after a
+		// partial failure handles some of them again, which storage ignores.
+		return streamer.StreamBlockByNumber(ctx, number, func(tx rpc.Transaction) error {
+			fn(tx)
+			return nil
 		})
 	}
-	return nil
+	block, err := p.client.GetBlockByNumberInt(ctx, number, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, tx := range block.Transactions {
+		fn(tx)
+	}
+	return &rpc.Block{Number: block.Number, Timestamp: block.Timestamp, Hash: block.Hash, ParentHash: block.ParentHash}, nil
+}
+
+// processTransaction stores tx from block number for its sender and
+// receiver, unless either of them is ignored or tx is a skipped zero-value
+// contract call. The time spent fetching its receipt and storing it is added
+// to phases, and storing it is traced as a child of the block's span. Its
+// debug log entry is sampled as set by logging.SetTxSampling.
+func (p *parserImpl) processTransaction(ctx context.Context, number int, tx rpc.Transaction, phases *blockPhases) {
+	if p.logger.Enabled(ctx, slog.LevelDebug) && logging.SampleTx(func() bool {
+		return p.store.IsSubscribed(tx.From) || p.store.IsSubscribed(tx.To)
+	}) {
+		p.logger.Debug("processing transaction", logging.KeyBlock, number, "hash", tx.Hash, "from", tx.From, "to", tx.To)
+	}
+	if len(p.ignored) > 0 && (p.ignored[strings.ToLower(tx.From)] || p.ignored[strings.ToLower(tx.To)]) {
+		p.metrics.Add(metrics.TransactionsIgnored, 1)
+		if p.dryRun != nil {
+			p.dryRun.ignored.Add(1)
+		}
+		return
+	}
+
+	stored := transaction.Transaction{
+		Hash:     tx.Hash,
+		From:     tx.From,
+		To:       tx.To,
+		Value:    hexToValue(tx.Value),
+		Block:    number,
+		Category: transaction.Categorize(tx.To, tx.Input),
+	}
+	if p.receipts != nil && p.store.IsSubscribed(tx.From) {
+		start := time.Now()
+		stored.Fee = p.fetchFee(ctx, tx)
+		since(&phases.receipt, start)
+	}
+	if p.maxInputBytes > 0 {
+		stored.Input, stored.InputTruncated = truncateInput(tx.Input, p.maxInputBytes)
+	}
+	if p.abi != nil && tx.To != "" {
+		stored.Call, _ = p.abi.Decode(tx.Input)
+	}
+	if p.contractCalls != nil && tx.To != "" {
+		start := time.Now()
+		p.recordContractCall(strings.ToLower(tx.To), stored, tx.Input)
+		since(&phases.store, start)
+	}
+
+	if p.skipZeroValueCalls && isZeroValueCall(tx, stored.Value) && !p.store.IsSubscribed(tx.From) {
+		p.metrics.Add(metrics.TransactionsSkipped, 1)
+		return
+	}
+
+	if p.enrich != nil && (p.store.IsSubscribed(tx.From) || p.store.IsSubscribed(tx.To)) {
+		// Queued once the records are stored, so there is something to update.
+		defer p.enrich.enqueue(tx)
+	}
+
+	defer since(&phases.store, time.Now())
+	_, storeSpan := tracer.Start(ctx, "storage.AddTransaction", trace.WithAttributes(attribute.String("tx.hash", tx.Hash)))
+	defer storeSpan.End()
+	// A self-transfer is stored once for the address
+	if tx.From == tx.To {
+		stored.Direction = transaction.DirectionSelf
+		p.record(tx.From, stored)
+		return
+	}
+
+	// Store transaction for sender address (outbound from sender's perspective)
+	stored.Direction = transaction.DirectionOut
+	p.record(tx.From, stored)
+
+	// A contract creation has a null to and no receiver to store it for
+	if tx.To == "" {
+		return
+	}
+
+	// Store transaction for receiver address (inbound from receiver's perspective)
+	stored.Direction = transaction.DirectionIn
+	p.record(tx.To, stored)
+}
+
+// fetchFee returns the fee paid for tx from its receipt, or nil if the
+// receipt can't be fetched. A missing fee doesn't hold up the block.
+func (p *parserImpl) fetchFee(ctx context.Context, tx rpc.Transaction) *transaction.Value {
+	r, err := p.receipts.GetTransactionReceipt(ctx, tx.Hash)
+	if err != nil {
+		p.logger.Warn("failed to fetch receipt", "hash", tx.Hash, logging.KeyError, err)
+		return nil
+	}
+	fee, err := receiptFee(r, tx)
+	if err != nil {
+		p.logger.Warn("invalid receipt", "hash", tx.Hash, logging.KeyError, err)
+		return nil
+	}
+	return &fee
+}
+
+// record stamps tx with the indexing time, stores it for addr and, if addr
+// is subscribed, adds it to the activity feed and notifies watchers. In dry-run mode it only counts tx.
+func (p *parserImpl) record(addr string, tx transaction.Transaction) {
+	if p.dryRun != nil {
+		subscribed := p.store.IsSubscribed(addr)
+		p.dryRun.records.Add(1)
+		if subscribed {
+			p.dryRun.matched.Add(1)
+		}
+		p.metrics.Add(metrics.DryRunRecords, 1, metrics.L("subscribed", strconv.FormatBool(subscribed)))
+		return
+	}
+	tx.IndexedAt = time.Now().UTC()
+	p.store.AddTransaction(addr, tx)
+	p.chain.touch(tx.Block, addr)
+	p.balances.apply(addr, tx)
+	if (p.activity != nil || p.events.active()) && p.store.IsSubscribed(addr) {
+		e := Event{Address: addr, Transaction: tx}
+		p.activity.add(e)
+		if p.events.active() {
+			p.events.publish(addr, e)
+		}
+	}
 }
 
 // formatBlockNum converts a decimal block number into a 0x-prefixed hex string.
@@ -0,0 +1,94 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// TokenBalances looks up the token balances of addresses, caching them
+// until Invalidate is called for a transfer changing them;
+// *tokens.Resolver implements it.
+type TokenBalances interface {
+	BalanceOf(ctx context.Context, contract, holder string) (transaction.Value, error)
+	Invalidate(contract, holder string)
+}
+
+// TokenBalance is the balance of an address in one token.
+type TokenBalance struct {
+	Contract string                    `json:"contract"`
+	Standard transaction.TokenStandard `json:"standard"`
+	Symbol   string                    `json:"symbol,omitempty"`
+	Name     string                    `json:"name,omitempty"`
+	Decimals int                       `json:"decimals"`
+	// Balance is the raw balance in the token's smallest unit, or the
+	// number of tokens held for NFTs.
+	Balance transaction.Value `json:"balance"`
+	// NormalizedBalance is Balance scaled by Decimals, e.g. "1.5" for 1.5
+	// USDC.
+	NormalizedBalance string `json:"normalized_balance"`
+}
+
+// Portfolio is the native balance of an address along with its balances in
+// the tokens it has sent or received.
+type Portfolio struct {
+	Balance
+	Tokens []TokenBalance `json:"tokens"`
+}
+
+// PortfolioTracker is implemented by parsers that can report the token
+// balances of subscribed addresses next to their native balance.
+type PortfolioTracker interface {
+	// Portfolio returns the native balance of a subscribed address, as
+	// BalanceTracker does, and its current balance in every ERC-20 and
+	// ERC-721 token among its stored token transfers. refresh also fetches
+	// the token balances again.
+	Portfolio(ctx context.Context, address string, refresh bool) (Portfolio, error)
+}
+
+// Portfolio returns the native and token balances of a subscribed address.
+// Token balances are only listed when token transfers are indexed with
+// TokenBalances set.
+func (p *parserImpl) Portfolio(ctx context.Context, address string, refresh bool) (Portfolio, error) {
+	native, err := p.Balance(ctx, address, refresh)
+	if err != nil {
+		return Portfolio{}, err
+	}
+	out := Portfolio{Balance: native, Tokens: []TokenBalance{}}
+	if p.tokenBalances == nil {
+		return out, nil
+	}
+
+	// One entry per token, in the order it was first seen, with the latest
+	// metadata.
+	index := make(map[string]int)
+	for _, tt := range p.store.GetTokenTransfers(address) {
+		if tt.Standard != transaction.StandardERC20 && tt.Standard != transaction.StandardERC721 {
+			continue
+		}
+		i, ok := index[tt.Contract]
+		if !ok {
+			i = len(out.Tokens)
+			index[tt.Contract] = i
+			out.Tokens = append(out.Tokens, TokenBalance{Contract: tt.Contract, Standard: tt.Standard})
+		}
+		if tt.Symbol != "" || tt.Name != "" {
+			out.Tokens[i].Symbol, out.Tokens[i].Name, out.Tokens[i].Decimals = tt.Symbol, tt.Name, tt.Decimals
+		}
+	}
+
+	for i := range out.Tokens {
+		tb := &out.Tokens[i]
+		if refresh {
+			p.tokenBalances.Invalidate(tb.Contract, address)
+		}
+		b, err := p.tokenBalances.BalanceOf(ctx, tb.Contract, address)
+		if err != nil {
+			return Portfolio{}, fmt.Errorf("failed to get token balances of %s: %w", address, err)
+		}
+		tb.Balance = b
+		tb.NormalizedBalance = b.Decimal(tb.Decimals)
+	}
+	return out, nil
+}
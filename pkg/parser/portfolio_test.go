@@ -0,0 +1,90 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// portfolioClient adds fixed native balances to logClient.
+type portfolioClient struct {
+	*logClient
+	balances map[string]int64
+}
+
+func (c *portfolioClient) GetBalance(ctx context.Context, address string, blockNumber int) (string, error) {
+	return fmt.Sprintf("0x%x", c.balances[address]), nil
+}
+
+// fixedTokenBalances serves fixed token balances, recording invalidations.
+type fixedTokenBalances struct {
+	balances    map[string]int64
+	err         error
+	invalidated []string
+}
+
+func (f *fixedTokenBalances) BalanceOf(_ context.Context, contract, holder string) (transaction.Value, error) {
+	if f.err != nil {
+		return transaction.Value{}, f.err
+	}
+	return transaction.WeiValue(f.balances[contract]), nil
+}
+
+func (f *fixedTokenBalances) Invalidate(contract, holder string) {
+	f.invalidated = append(f.invalidated, contract+"|"+holder)
+}
+
+func TestParser_Portfolio(t *testing.T) {
+	client := &portfolioClient{logClient: newLogClient(), balances: map[string]int64{tokenFrom: 1_000}}
+	balances := &fixedTokenBalances{balances: map[string]int64{tokenUSDC: 2_500_000, tokenNFT: 3}}
+	store := NewMockStorage()
+	store.Subscribe(tokenFrom)
+	p := NewParserWithInterval(client, store, time.Second, Options{
+		TrackBalances: true,
+		IndexTokens:   true,
+		TokenMetadata: staticMetadata{tokenUSDC: {Symbol: "USDC", Name: "USD Coin", Decimals: 6}},
+		TokenBalances: balances,
+	}).(*parserImpl)
+	ctx := context.Background()
+	if err := p.processBlock(ctx, 1234); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+	p.setBlock(1234)
+	if len(balances.invalidated) != 4 {
+		t.Errorf("Expected cached balances to be invalidated for both sides of both transfers, got %v", balances.invalidated)
+	}
+
+	pf, err := p.Portfolio(ctx, tokenFrom, false)
+	if err != nil {
+		t.Fatalf("Portfolio failed: %v", err)
+	}
+	if pf.Address != tokenFrom || pf.Balance.Balance.String() != "1000" {
+		t.Errorf("Unexpected native balance %+v", pf.Balance)
+	}
+	if len(pf.Tokens) != 2 {
+		t.Fatalf("Expected one entry per token, got %+v", pf.Tokens)
+	}
+	if usdc := pf.Tokens[0]; usdc.Contract != tokenUSDC || usdc.Symbol != "USDC" || usdc.NormalizedBalance != "2.5" {
+		t.Errorf("Unexpected USDC balance %+v", usdc)
+	}
+	if nft := pf.Tokens[1]; nft.Standard != transaction.StandardERC721 || nft.Balance.String() != "3" || nft.NormalizedBalance != "3" {
+		t.Errorf("Unexpected NFT balance %+v", nft)
+	}
+
+	balances.invalidated = nil
+	if _, err := p.Portfolio(ctx, tokenFrom, true); err != nil || len(balances.invalidated) != 2 {
+		t.Errorf("Expected refresh to invalidate token balances, got %v %v", err, balances.invalidated)
+	}
+
+	balances.err = errors.New("connection refused")
+	if _, err := p.Portfolio(ctx, tokenFrom, false); err == nil {
+		t.Error("Expected token balance errors to be returned")
+	}
+	if _, err := p.Portfolio(ctx, tokenTo, false); !errors.Is(err, ErrNotSubscribed) {
+		t.Errorf("Expected ErrNotSubscribed, got %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package parser
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/internal/storage"
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// receiptQueueSize bounds the transactions waiting for their receipt.
+// Further ones are dropped rather than holding up block ingestion.
+const receiptQueueSize = 10000
+
+// receiptStage fetches the receipts of stored transactions in the
+// background and fills in their status, gas used and fee. A nil
+// *receiptStage means enrichment is disabled.
+type receiptStage struct {
+	client rpc.ReceiptFetcher
+	// batch fetches several receipts per request; nil if the client can't
+	batch     rpc.ReceiptBatchFetcher
+	store     storage.ReceiptStore
+	queue     chan rpc.Transaction
+	workers   int
+	batchSize int
+	logger    *slog.Logger
+	metrics   metrics.Recorder
+}
+
+func newReceiptStage(client rpc.ReceiptFetcher, store storage.ReceiptStore, workers, batchSize int, logger *slog.Logger, rec metrics.Recorder) *receiptStage {
+	if client == nil || store == nil {
+		return nil
+	}
+	batch, _ := client.(rpc.ReceiptBatchFetcher)
+	return &receiptStage{
+		client:    client,
+		batch:     batch,
+		store:     store,
+		queue:     make(chan rpc.Transaction, receiptQueueSize),
+		workers:   workers,
+		batchSize: batchSize,
+		logger:    logger,
+		metrics:   rec,
+	}
+}
+
+// enqueue schedules the receipt of tx to be fetched, dropping it if the
+// queue is full.
+func (s *receiptStage) enqueue(tx rpc.Transaction) {
+	select {
+	case s.queue <- rpc.Transaction{Hash: tx.Hash, GasPrice: tx.GasPrice}:
+	default:
+		s.metrics.Add(metrics.ReceiptsEnriched, 1, metrics.L("result", "dropped"))
+		s.logger.Warn("receipt queue full, dropping transaction", "hash", tx.Hash)
+	}
+}
+
+// run groups queued transactions into batches and fetches them with up to
+// workers concurrent requests until ctx is done. Transactions still queued
+// then are left without their receipt.
+func (s *receiptStage) run(ctx context.Context) {
+	batches := make(chan []rpc.Transaction)
+	done := make(chan struct{})
+	for i := 0; i < s.workers; i++ {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for batch := range batches {
+				s.fetch(ctx, batch)
+			}
+		}()
+	}
+	defer func() {
+		close(batches)
+		for i := 0; i < s.workers; i++ {
+			<-done
+		}
+	}()
+
+	for {
+		var batch []rpc.Transaction
+		select {
+		case <-ctx.Done():
+			return
+		case tx := <-s.queue:
+			batch = append(batch, tx)
+		}
+		// Whatever queued up meanwhile joins the batch.
+	fill:
+		for len(batch) < s.batchSize {
+			select {
+			case tx := <-s.queue:
+				batch = append(batch, tx)
+			default:
+				break fill
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case batches <- batch:
+		}
+	}
+}
+
+// fetch fetches the receipts of batch and stores their outcome.
+func (s *receiptStage) fetch(ctx context.Context, batch []rpc.Transaction) {
+	receipts := make([]*rpc.Receipt, len(batch))
+	if s.batch != nil && len(batch) > 1 {
+		hashes := make([]string, len(batch))
+		for i, tx := range batch {
+			hashes[i] = tx.Hash
+		}
+		var err error
+		if receipts, err = s.batch.GetTransactionReceipts(ctx, hashes); err != nil {
+			s.metrics.Add(metrics.ReceiptsEnriched, float64(len(batch)), metrics.L("result", "error"))
+			s.logger.Warn("failed to fetch receipts", "count", len(batch), logging.KeyError, err)
+			return
+		}
+	} else {
+		for i, tx := range batch {
+			r, err := s.client.GetTransactionReceipt(ctx, tx.Hash)
+			if err != nil {
+				s.logger.Warn("failed to fetch receipt", "hash", tx.Hash, logging.KeyError, err)
+			}
+			receipts[i] = r
+		}
+	}
+
+	for i, tx := range batch {
+		r := receipts[i]
+		if r == nil {
+			s.metrics.Add(metrics.ReceiptsEnriched, 1, metrics.L("result", "missing"))
+			continue
+		}
+		out, err := receiptOutcome(r, tx)
+		if err != nil {
+			s.metrics.Add(metrics.ReceiptsEnriched, 1, metrics.L("result", "error"))
+			s.logger.Warn("invalid receipt", "hash", tx.Hash, logging.KeyError, err)
+			continue
+		}
+		s.store.SetReceipt(tx.Hash, out)
+		s.metrics.Add(metrics.ReceiptsEnriched, 1, metrics.L("result", "ok"))
+	}
+}
+
+// receiptOutcome converts r, the receipt of tx, into the fields stored on
+// its records.
+func receiptOutcome(r *rpc.Receipt, tx rpc.Transaction) (transaction.Receipt, error) {
+	fee, err := receiptFee(r, tx)
+	if err != nil {
+		return transaction.Receipt{}, err
+	}
+	out := transaction.Receipt{Fee: fee}
+	out.GasUsed, _ = strconv.ParseUint(strings.TrimPrefix(r.GasUsed, "0x"), 16, 64)
+	switch r.Status {
+	case "0x1":
+		out.Status = transaction.StatusSuccess
+	case "0x0":
+		out.Status = transaction.StatusReverted
+	}
+	return out, nil
+}
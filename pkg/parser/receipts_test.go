@@ -0,0 +1,112 @@
+package parser
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/internal/storage"
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// batchReceiptClient adds batched receipts to receiptClient.
+type batchReceiptClient struct {
+	*receiptClient
+	mu      sync.Mutex
+	batches [][]string
+}
+
+func (c *batchReceiptClient) GetTransactionReceipts(_ context.Context, hashes []string) ([]*rpc.Receipt, error) {
+	c.mu.Lock()
+	c.batches = append(c.batches, hashes)
+	c.mu.Unlock()
+	out := make([]*rpc.Receipt, len(hashes))
+	for i, hash := range hashes {
+		out[i] = c.receipts[hash]
+	}
+	return out, nil
+}
+
+func TestParser_EnrichReceipts(t *testing.T) {
+	client := &batchReceiptClient{receiptClient: &receiptClient{
+		MockRPCClient: NewMockRPCClient(),
+		receipts: map[string]*rpc.Receipt{
+			"0xhash1": {TransactionHash: "0xhash1", Status: "0x0", GasUsed: "0x5208", EffectiveGasPrice: "0x3b9aca00"},
+			"0xhash2": {TransactionHash: "0xhash2", Status: "0x1", GasUsed: "0x5208", EffectiveGasPrice: "0x3b9aca00"},
+		},
+	}}
+	client.blockResponse.Transactions = append(client.blockResponse.Transactions,
+		rpc.Transaction{Hash: "0xnoreceipt", From: "0xfrom3", To: "0xto1", Value: "0x1"})
+	store := storage.NewMemoryStorage()
+	store.Subscribe("0xfrom1")
+	store.Subscribe("0xto1")
+	p := NewParserWithInterval(client, store, time.Second, Options{EnrichReceipts: true, FetchReceipts: true}).(*parserImpl)
+
+	if err := p.processBlock(context.Background(), 1234); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+	// Receipts aren't fetched with the block.
+	if txs := store.GetTransactions("0xto1"); len(txs) != 2 || txs[0].Fee != nil || txs[0].Status != "" {
+		t.Fatalf("Expected transactions without receipts, got %+v", txs)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		p.enrich.run(ctx)
+		close(done)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if txs := store.GetTransactions("0xto1"); txs[0].Status != "" || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	txs := store.GetTransactions("0xto1")
+	got := txs[0]
+	if got.Status != transaction.StatusReverted || got.GasUsed != 21000 || got.Fee == nil || got.Fee.String() != "21000000000000" {
+		t.Errorf("Unexpected enriched transaction %+v", got)
+	}
+	if txs[1].Status != "" || txs[1].Fee != nil {
+		t.Errorf("Expected a transaction without a receipt to stay as is, got %+v", txs[1])
+	}
+	if sent := store.GetTransactions("0xfrom1"); sent[0].Status != transaction.StatusReverted {
+		t.Errorf("Expected the sender's record to be enriched too, got %+v", sent[0])
+	}
+	// Transactions of unsubscribed addresses aren't enriched.
+	if len(client.batches) != 1 || len(client.batches[0]) != 2 || client.batches[0][0] != "0xhash1" {
+		t.Errorf("Expected one batch of the subscribed transactions, got %v", client.batches)
+	}
+}
+
+func TestParser_EnrichReceipts_Unsupported(t *testing.T) {
+	// Without a ReceiptStore, fees are only fetched with the block as
+	// FetchReceipts does.
+	client := &receiptClient{MockRPCClient: NewMockRPCClient(), receipts: map[string]*rpc.Receipt{}}
+	p := NewParserWithInterval(client, NewMockStorage(), time.Second, Options{EnrichReceipts: true}).(*parserImpl)
+	if p.enrich != nil || p.receipts != nil {
+		t.Error("Expected no receipts to be fetched without a ReceiptStore or FetchReceipts")
+	}
+	p = NewParserWithInterval(client, NewMockStorage(), time.Second, Options{EnrichReceipts: true, FetchReceipts: true}).(*parserImpl)
+	if p.receipts == nil {
+		t.Error("Expected fees to be fetched with the block")
+	}
+}
+
+func TestReceiptStage_Dropped(t *testing.T) {
+	s := newReceiptStage(&receiptClient{MockRPCClient: NewMockRPCClient()}, storage.NewMemoryStorage().(storage.ReceiptStore), 1, 1, logging.Component("parser"), metrics.Nop)
+	for i := 0; i < receiptQueueSize+1; i++ {
+		s.enqueue(rpc.Transaction{Hash: "0xhash"})
+	}
+	if len(s.queue) != receiptQueueSize {
+		t.Errorf("Expected the queue to stay bounded, got %d", len(s.queue))
+	}
+}
@@ -0,0 +1,199 @@
+// Package parser contains the block poller and parsing logic.
+package parser
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
+)
+
+// canonicalChain tracks the hashes of the latest processed blocks and the
+// addresses records were stored for from each, so that a block whose parent
+// hash doesn't match reveals a reorganization and the records it orphaned.
+// A nil *canonicalChain is disabled.
+type canonicalChain struct {
+	mu     sync.Mutex
+	depth  int
+	latest int
+	blocks map[int]*canonicalBlock
+}
+
+type canonicalBlock struct {
+	hash       string
+	parentHash string
+	addrs      map[string]struct{}
+}
+
+// newCanonicalChain creates a tracker of the latest depth blocks, or
+// returns nil if depth is not positive.
+func newCanonicalChain(depth int) *canonicalChain {
+	if depth <= 0 {
+		return nil
+	}
+	return &canonicalChain{depth: depth, blocks: make(map[int]*canonicalBlock)}
+}
+
+// entry returns block number, tracking it unless it is older than the
+// latest depth blocks, in which case it returns nil. c.mu must be held.
+func (c *canonicalChain) entry(number int) *canonicalBlock {
+	if number <= c.latest-c.depth {
+		return nil
+	}
+	b := c.blocks[number]
+	if b != nil {
+		return b
+	}
+	b = &canonicalBlock{addrs: make(map[string]struct{})}
+	c.blocks[number] = b
+	if number > c.latest {
+		c.latest = number
+		for n := range c.blocks {
+			if n <= c.latest-c.depth {
+				delete(c.blocks, n)
+			}
+		}
+	}
+	return b
+}
+
+// observe records the hash and parent hash of block number as fetched.
+func (c *canonicalChain) observe(number int, hash, parentHash string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if b := c.entry(number); b != nil {
+		b.hash, b.parentHash = hash, parentHash
+	}
+}
+
+// touch records that records were stored for addr from block number.
+func (c *canonicalChain) touch(number int, addr string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if b := c.entry(number); b != nil {
+		b.addrs[addr] = struct{}{}
+	}
+}
+
+// hashes returns the recorded hash and parent hash of block number, which
+// are empty if it isn't tracked or its hashes aren't known.
+func (c *canonicalChain) hashes(number int) (hash, parentHash string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if b := c.blocks[number]; b != nil {
+		return b.hash, b.parentHash
+	}
+	return "", ""
+}
+
+// orphan stops tracking block number and returns the addresses records
+// were stored for from it.
+func (c *canonicalChain) orphan(number int) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b := c.blocks[number]
+	if b == nil {
+		return nil
+	}
+	delete(c.blocks, number)
+	addrs := make([]string, 0, len(b.addrs))
+	for addr := range b.addrs {
+		addrs = append(addrs, addr)
+	}
+	slices.Sort(addrs)
+	return addrs
+}
+
+// checkReorg compares the parent hash of block number, just processed
+// going forward, with the hash recorded for the block before it, and
+// handles the reorganization a mismatch reveals.
+func (p *parserImpl) checkReorg(ctx context.Context, number int) {
+	if p.chain == nil {
+		return
+	}
+	_, parent := p.chain.hashes(number)
+	prev, _ := p.chain.hashes(number - 1)
+	if parent == "" || prev == "" || parent == prev {
+		return
+	}
+	if err := p.handleReorg(ctx, number); err != nil {
+		p.logger.Error("failed to handle reorg", logging.KeyBlock, number, logging.KeyError, err)
+		p.reportError(ctx, err)
+	}
+}
+
+// handleReorg walks back from the block before number, which doesn't link
+// to it, to the last tracked block the node still has. The blocks after it
+// are orphaned: their records are removed, with an Event marked Removed
+// for each one of a subscribed address, and the new blocks replacing them
+// are processed, along with block number for the transactions they moved
+// there.
+func (p *parserImpl) handleReorg(ctx context.Context, number int) error {
+	var replaced []BlockRef // newest first
+	for n := number - 1; ; n-- {
+		recorded, _ := p.chain.hashes(n)
+		if recorded == "" {
+			// Past the tracked blocks; older records stay.
+			break
+		}
+		block, err := p.client.GetBlockByNumberInt(ctx, n, false)
+		if err != nil {
+			return fmt.Errorf("failed to fetch block %d: %w", n, unavailable(ctx, err))
+		}
+		if block.Hash == recorded {
+			break
+		}
+		replaced = append(replaced, BlockRef{Number: n, Hash: block.Hash})
+	}
+	if len(replaced) == 0 {
+		// Block number itself was replaced since; the next block tells.
+		return nil
+	}
+	slices.Reverse(replaced)
+	hash, _ := p.chain.hashes(number)
+	reorg := &Reorg{Depth: len(replaced), Blocks: append(replaced, BlockRef{Number: number, Hash: hash})}
+	p.logger.Warn("chain reorganization detected", logging.KeyBlock, replaced[0].Number, "depth", reorg.Depth)
+	p.metrics.Add(metrics.Reorgs, 1)
+	p.metrics.Add(metrics.OrphanedBlocks, float64(reorg.Depth))
+
+	for _, b := range replaced {
+		p.revertBlock(b.Number, reorg)
+	}
+	for _, b := range reorg.Blocks {
+		if err := p.processBlockWithRetries(ctx, b.Number); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revertBlock removes the records stored from orphaned block number and
+// announces the removal of those of subscribed addresses.
+func (p *parserImpl) revertBlock(number int, reorg *Reorg) {
+	p.blocks.remove(number)
+	for _, addr := range p.chain.orphan(number) {
+		removed := p.reverter.RevertBlock(addr, number)
+		if len(removed) == 0 {
+			continue
+		}
+		p.balances.forget(addr)
+		if !p.store.IsSubscribed(addr) {
+			continue
+		}
+		for _, tx := range removed {
+			p.activity.remove(addr, tx)
+			if p.events.active() {
+				p.events.publish(addr, Event{Address: addr, Transaction: tx, Removed: true, Reorg: reorg})
+			}
+		}
+	}
+}
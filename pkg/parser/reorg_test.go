@@ -0,0 +1,125 @@
+package parser
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/storage"
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc/chaintest"
+)
+
+func TestCanonicalChain(t *testing.T) {
+	c := newCanonicalChain(2)
+	c.touch(10, "0xaaa")
+	c.observe(10, "0xh10", "0xh9")
+	c.observe(11, "0xh11", "0xh10")
+	if hash, parent := c.hashes(10); hash != "0xh10" || parent != "0xh9" {
+		t.Errorf("Expected the hashes of block 10, got %s %s", hash, parent)
+	}
+	c.observe(12, "0xh12", "0xh11")
+	if hash, _ := c.hashes(10); hash != "" {
+		t.Errorf("Expected block 10 to no longer be tracked, got %s", hash)
+	}
+	c.touch(10, "0xaaa")
+	c.touch(12, "0xbbb")
+	c.touch(12, "0xaaa")
+	if addrs := c.orphan(12); !slices.Equal(addrs, []string{"0xaaa", "0xbbb"}) {
+		t.Errorf("Expected the addresses of block 12, got %v", addrs)
+	}
+	if hash, _ := c.hashes(12); hash != "" {
+		t.Errorf("Expected an orphaned block to no longer be tracked, got %s", hash)
+	}
+
+	var disabled *canonicalChain
+	disabled.observe(1, "0x1", "0x0")
+	disabled.touch(1, "0xaaa")
+	if newCanonicalChain(0) != nil {
+		t.Error("Expected a nil tracker for zero depth")
+	}
+}
+
+func TestParser_Reorg(t *testing.T) {
+	const alice, bob, carol = "0xa11ce", "0xb0b", "0xca201"
+	c := chaintest.New(chaintest.Options{Generator: chaintest.Transfers(3, []string{bob, carol}, 2)})
+	payment := c.Include(rpc.Transaction{From: bob, To: alice, Value: "0x10"})
+	c.Mine(3)
+
+	store := storage.NewMemoryStorage()
+	store.Subscribe(alice)
+	store.Subscribe(bob)
+	p := NewParserWithInterval(c, store, time.Second, Options{
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+		ReorgDepth:   16,
+		ActivitySize: 100,
+	}).(*parserImpl)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := p.Watch(ctx, alice)
+
+	if err := p.processBlock(ctx, 1); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+	p.setBlock(1)
+	if err := p.checkForNewBlocks(ctx); err != nil {
+		t.Fatalf("checkForNewBlocks failed: %v", err)
+	}
+	if orphaned := p.GetTransactions(bob); len(orphaned) != 9 {
+		t.Fatalf("Expected bob's 9 transactions, got %d", len(orphaned))
+	}
+
+	// The payment is mined again in the new block 2; the block after the
+	// new head reveals the reorg.
+	c.Reorg(3)
+	c.Mine(1)
+	if err := p.checkForNewBlocks(ctx); err != nil {
+		t.Fatalf("checkForNewBlocks failed: %v", err)
+	}
+
+	txs := p.GetTransactions(bob)
+	for _, tx := range txs {
+		if _, err := c.GetTransactionByHash(ctx, tx.Hash); err != nil {
+			t.Errorf("Expected only transactions of the new chain, got %s in block %d: %v", tx.Hash, tx.Block, err)
+		}
+	}
+	if len(txs) != 11 {
+		t.Errorf("Expected bob's 11 transactions of the new chain, got %d", len(txs))
+	}
+	if totals := p.store.(storage.Aggregator).Totals(bob); totals.Transactions != len(txs) {
+		t.Errorf("Expected totals of %d transactions, got %d", len(txs), totals.Transactions)
+	}
+	if got := p.GetTransactions(alice); len(got) != 1 || got[0].Hash != payment || got[0].Block != 2 {
+		t.Errorf("Expected the payment to alice in the new block 2, got %+v", got)
+	}
+
+	var got []Event
+	for len(events) > 0 {
+		got = append(got, <-events)
+	}
+	if len(got) != 3 || got[0].Removed || !got[1].Removed || got[2].Removed {
+		t.Fatalf("Expected the payment, its removal and the payment again, got %+v", got)
+	}
+	reorg := got[1].Reorg
+	if reorg == nil || reorg.Depth != 3 || len(reorg.Blocks) != 4 || reorg.Blocks[0].Number != 2 || reorg.Blocks[3].Number != 5 {
+		t.Fatalf("Unexpected reorg %+v", reorg)
+	}
+	if b, _ := c.Block(2); reorg.Blocks[0].Hash != b.Hash {
+		t.Errorf("Expected the new hash of block 2 %s, got %s", b.Hash, reorg.Blocks[0].Hash)
+	}
+	if got[1].IdempotencyKey() == got[0].IdempotencyKey() {
+		t.Error("Expected the removal to have an idempotency key of its own")
+	}
+	activity, _ := p.RecentActivity()
+	if len(activity) != len(txs)+1 {
+		t.Errorf("Expected the transactions of the new chain in the activity feed, got %d events", len(activity))
+	}
+	for _, e := range activity {
+		if _, err := c.GetTransactionByHash(ctx, e.Transaction.Hash); err != nil {
+			t.Errorf("Expected no orphaned transaction in the activity feed, got %s", e.Transaction.Hash)
+		}
+	}
+}
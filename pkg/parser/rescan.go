@@ -0,0 +1,82 @@
+// Package parser contains the block poller and parsing logic.
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+)
+
+var (
+	// ErrInvalidRange is returned when a rescan range is empty or out of bounds.
+	ErrInvalidRange = errors.New("invalid block range")
+	// ErrNotRunning is returned when an operation needs the poller to be started.
+	ErrNotRunning = errors.New("parser is not running")
+)
+
+// Rescan re-processes blocks from..to (inclusive) in the background, repairing
+// gaps or re-indexing after configuration changes. The scan is bound to the
+// context passed to Start and is awaited by Stop.
+func (p *parserImpl) Rescan(from, to int) error {
+	if from < 1 || to < from {
+		return fmt.Errorf("%w: from=%d to=%d", ErrInvalidRange, from, to)
+	}
+	if current := p.GetCurrentBlock(); to > current {
+		return fmt.Errorf("%w: to=%d is beyond current block %d", ErrInvalidRange, to, current)
+	}
+
+	p.pollingStartedMu.Lock()
+	defer p.pollingStartedMu.Unlock()
+	if !p.pollingStarted {
+		return ErrNotRunning
+	}
+	ctx := p.ctx
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		if err := p.ScanRange(ctx, from, to); err != nil {
+			p.logger.Error("rescan finished with errors", "scan", "rescan", logging.KeyError, err)
+		}
+	}()
+	return nil
+}
+
+// ScanRange synchronously processes the blocks of this instance's shard
+// from..to (inclusive) in ascending order. Blocks that fail are logged and
+// skipped; an error summarizing the failures is returned once the range is
+// done.
+func (p *parserImpl) ScanRange(ctx context.Context, from, to int) error {
+	if from < 1 || to < from {
+		return fmt.Errorf("%w: from=%d to=%d", ErrInvalidRange, from, to)
+	}
+	logger := p.logger.With("scan", "rescan")
+	logger.Info("starting scan", "from", from, "to", to, "shard", p.shard, "shards", p.shards)
+	failed, total := 0, 0
+	for i := from + (p.shards-p.shardOffset(from))%p.shards; i <= to; i += p.shards {
+		total++
+		select {
+		case <-ctx.Done():
+			logger.Info("stopping scan")
+			return ctx.Err()
+		default:
+			if err := p.processBlockWithRetries(ctx, i); err != nil {
+				failed++
+				logger.Error("failed to process block", logging.KeyBlock, i, logging.KeyError, err)
+			}
+		}
+	}
+	logger.Info("completed scan", "from", from, "to", to)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d blocks failed", failed, total)
+	}
+	return nil
+}
+
+// shardOffset returns how far block n is past the closest block at or below
+// it that belongs to this instance's shard.
+func (p *parserImpl) shardOffset(n int) int {
+	return ((n-p.shard)%p.shards + p.shards) % p.shards
+}
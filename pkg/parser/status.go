@@ -0,0 +1,75 @@
+package parser
+
+// Status reports how far indexing has progressed, answering whether the
+// indexer is caught up in one call.
+type Status struct {
+	// Head is the latest block reported by the node, 0 until it is polled.
+	Head int `json:"head"`
+	// Block is the last processed block.
+	Block int `json:"block"`
+	// Lag is the number of blocks between Head and Block, 0 until the
+	// first block is processed.
+	Lag int `json:"lag"`
+	// BackwardScan reports the historical scan started along with polling;
+	// nil unless one was started.
+	BackwardScan *ScanProgress `json:"backward_scan,omitempty"`
+}
+
+// ScanProgress reports a backward scan from From down to To, inclusive.
+type ScanProgress struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+	// Block is the block the scan reached last, 0 before the first.
+	Block int `json:"block"`
+	// Remaining counts the blocks left to process. With sharding it only
+	// counts the blocks of this instance's shard.
+	Remaining int `json:"remaining"`
+	// Done is set once the scan has processed every block. A scan stopped
+	// by shutdown is never done.
+	Done bool `json:"done"`
+}
+
+// Status reports the node's head, the last processed block and the
+// progress of the backward scan.
+func (p *parserImpl) Status() Status {
+	p.statusMu.Lock()
+	defer p.statusMu.Unlock()
+	s := Status{Head: p.head, Block: p.block}
+	if s.Block > 0 {
+		s.Lag = max(s.Head-s.Block, 0)
+	}
+	if p.backward != nil {
+		progress := *p.backward
+		s.BackwardScan = &progress
+	}
+	return s
+}
+
+// startBackwardProgress starts reporting a backward scan from `from` down
+// to stopAt of the blocks of this instance's shard.
+func (p *parserImpl) startBackwardProgress(from, stopAt int) {
+	first := from - p.shardOffset(from)
+	progress := &ScanProgress{From: from, To: stopAt}
+	if first >= stopAt {
+		progress.Remaining = (first-stopAt)/p.shards + 1
+	}
+	p.statusMu.Lock()
+	p.backward = progress
+	p.statusMu.Unlock()
+}
+
+// advanceBackwardProgress records that the backward scan processed block.
+func (p *parserImpl) advanceBackwardProgress(block int) {
+	p.statusMu.Lock()
+	defer p.statusMu.Unlock()
+	p.backward.Block = block
+	p.backward.Remaining--
+}
+
+// finishBackwardProgress marks the backward scan done.
+func (p *parserImpl) finishBackwardProgress() {
+	p.statusMu.Lock()
+	defer p.statusMu.Unlock()
+	p.backward.Remaining = 0
+	p.backward.Done = true
+}
@@ -0,0 +1,54 @@
+// Package parser contains the block poller and parsing logic.
+package parser
+
+import (
+	"errors"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/storage"
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// ErrTimeRangeUnsupported is returned by TransactionsBetween when the
+// storage doesn't index block timestamps.
+var ErrTimeRangeUnsupported = errors.New("storage does not index block timestamps")
+
+// TimeRanger is implemented by parsers that can filter an address's
+// transactions by the time their blocks were mined.
+type TimeRanger interface {
+	// TransactionsBetween returns the transactions of address mined at or
+	// after since and before until. A zero since or until leaves that end
+	// of the range open. Transactions from blocks whose timestamp wasn't
+	// recorded are left out.
+	TransactionsBetween(address string, since, until time.Time) ([]transaction.Transaction, error)
+}
+
+// TransactionsBetween filters the transactions of address by the block
+// timestamps indexed in the underlying storage, if it implements
+// storage.BlockTimes.
+func (p *parserImpl) TransactionsBetween(address string, since, until time.Time) ([]transaction.Transaction, error) {
+	times, ok := p.store.(storage.BlockTimes)
+	if !ok {
+		return nil, ErrTimeRangeUnsupported
+	}
+	out := []transaction.Transaction{}
+	for _, tx := range p.store.GetTransactions(address) {
+		t, ok := times.BlockTime(tx.Block)
+		if !ok || t.Before(since) || (!until.IsZero() && !t.Before(until)) {
+			continue
+		}
+		out = append(out, tx)
+	}
+	return out, nil
+}
+
+// recordBlockTime indexes the timestamp of block number, fetched as block,
+// if the storage supports it.
+func (p *parserImpl) recordBlockTime(number int, block *rpc.Block) {
+	times, ok := p.store.(storage.BlockTimes)
+	if !ok || block.Timestamp == "" {
+		return
+	}
+	times.SetBlockTime(number, time.Unix(int64(hexToInt(block.Timestamp)), 0).UTC())
+}
@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/storage"
+)
+
+func TestParser_TransactionsBetween(t *testing.T) {
+	client := NewMockRPCClient()
+	store := storage.NewMemoryStorage()
+	store.Subscribe("0xto1")
+	p := NewParserWithInterval(client, store, time.Second, Options{}).(*parserImpl)
+	ctx := context.Background()
+
+	// Blocks mined a day apart each send a transaction to 0xto1.
+	day := time.Date(2024, 5, 31, 12, 0, 0, 0, time.UTC)
+	for i, number := range []int{100, 101, 102} {
+		client.blockResponse.Number = formatBlockNum(number)
+		client.blockResponse.Transactions[0].Hash = formatBlockNum(number)
+		client.blockResponse.Timestamp = formatBlockNum(int(day.AddDate(0, 0, i).Unix()))
+		if err := p.processBlock(ctx, number); err != nil {
+			t.Fatalf("processBlock failed: %v", err)
+		}
+	}
+	// Blocks without a recorded timestamp are left out of time ranges.
+	client.blockResponse.Timestamp = ""
+	client.blockResponse.Transactions[0].Hash = formatBlockNum(103)
+	if err := p.processBlock(ctx, 103); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+
+	june := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		since, until time.Time
+		want         []int
+	}{
+		{name: "open", want: []int{100, 101, 102}},
+		{name: "since", since: june, want: []int{101, 102}},
+		{name: "until is exclusive", until: day.AddDate(0, 0, 1), want: []int{100}},
+		{name: "since is inclusive", since: day.AddDate(0, 0, 2), until: day.AddDate(0, 0, 3), want: []int{102}},
+		{name: "empty", since: june.AddDate(0, 1, 0), want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			txs, err := p.TransactionsBetween("0xto1", tt.since, tt.until)
+			if err != nil {
+				t.Fatalf("TransactionsBetween failed: %v", err)
+			}
+			var blocks []int
+			for _, tx := range txs {
+				blocks = append(blocks, tx.Block)
+			}
+			if len(blocks) != len(tt.want) {
+				t.Fatalf("Expected blocks %v, got %v", tt.want, blocks)
+			}
+			for i := range blocks {
+				if blocks[i] != tt.want[i] {
+					t.Errorf("Expected blocks %v, got %v", tt.want, blocks)
+				}
+			}
+		})
+	}
+}
+
+func TestParser_TransactionsBetween_Unsupported(t *testing.T) {
+	p := NewParserWithInterval(NewMockRPCClient(), NewMockStorage(), time.Second, Options{}).(TimeRanger)
+	if _, err := p.TransactionsBetween("0xto1", time.Time{}, time.Time{}); !errors.Is(err, ErrTimeRangeUnsupported) {
+		t.Errorf("Expected ErrTimeRangeUnsupported, got %v", err)
+	}
+}
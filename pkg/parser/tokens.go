@@ -0,0 +1,213 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+	"github.com/danieloluwadare/tw-txparser/pkg/tokens"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// transferTopic is the topic of Transfer(address,address,uint256), shared by
+// ERC-20 and ERC-721. ERC-721 indexes the token ID, so its events have four
+// topics instead of three.
+const transferTopic = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+
+// approvalTopic is the topic of Approval(address,address,uint256). As with
+// transfers, ERC-721 approvals have a fourth topic and aren't allowances.
+const approvalTopic = "0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925"
+
+// TokenMetadata looks up the symbol, name and decimals of token contracts;
+// *tokens.Resolver implements it.
+type TokenMetadata interface {
+	Lookup(ctx context.Context, contract string) (tokens.Metadata, error)
+}
+
+// indexTokenEvents stores the token transfers of block number for their
+// sender and receiver, following the same rules as transactions, and the
+// allowances approved by subscribed addresses.
+func (p *parserImpl) indexTokenEvents(ctx context.Context, number int) error {
+	topics := []string{transferTopic}
+	if p.allowances != nil {
+		topics = append(topics, approvalTopic)
+	}
+	logs, err := p.logs.GetLogs(ctx, rpc.LogFilter{
+		FromBlock: number,
+		ToBlock:   number,
+		Topics:    [][]string{topics},
+	})
+	if err != nil {
+		return fmt.Errorf("token events: %w", err)
+	}
+	for _, l := range logs {
+		if len(l.Topics) > 0 && strings.EqualFold(l.Topics[0], approvalTopic) {
+			p.indexApproval(ctx, number, l)
+			continue
+		}
+		p.indexTransfer(ctx, number, l)
+	}
+	return nil
+}
+
+// indexTransfer stores the token transfer of a Transfer event log of block
+// number for its sender and receiver. Other logs are skipped.
+func (p *parserImpl) indexTransfer(ctx context.Context, number int, l rpc.Log) {
+	tt, ok := decodeTransfer(l)
+	if !ok {
+		return
+	}
+	p.markTokenTransfer(tt.Hash)
+	if len(p.ignored) > 0 && (p.ignored[tt.Contract] || p.ignored[tt.From] || p.ignored[tt.To]) {
+		p.metrics.Add(metrics.TransactionsIgnored, 1)
+		return
+	}
+	tt.Block = number
+	p.attachMetadata(ctx, &tt)
+
+	if tt.From == tt.To {
+		tt.Direction = transaction.DirectionSelf
+		p.recordToken(tt.From, tt)
+		return
+	}
+	tt.Direction = transaction.DirectionOut
+	p.recordToken(tt.From, tt)
+	tt.Direction = transaction.DirectionIn
+	p.recordToken(tt.To, tt)
+}
+
+// markTokenTransfer recategorizes the stored transaction hash as a token
+// transfer if it was stored as a contract call, as it emitted a Transfer
+// event.
+func (p *parserImpl) markTokenTransfer(hash string) {
+	if p.categories == nil || p.dryRun != nil {
+		return
+	}
+	if tx, ok := p.store.GetTransactionByHash(hash); ok && tx.Category == transaction.CategoryContractCall {
+		p.categories.SetCategory(hash, transaction.CategoryTokenTransfer)
+	}
+}
+
+// indexApproval stores the allowance set by an ERC-20 Approval event if its
+// owner is subscribed.
+func (p *parserImpl) indexApproval(ctx context.Context, number int, l rpc.Log) {
+	a, ok := decodeApproval(l)
+	if !ok || p.dryRun != nil || !p.store.IsSubscribed(a.Owner) {
+		return
+	}
+	if len(p.ignored) > 0 && (p.ignored[a.Contract] || p.ignored[a.Spender]) {
+		return
+	}
+	a.Block = number
+	if p.tokenMetadata != nil {
+		meta, err := p.tokenMetadata.Lookup(ctx, a.Contract)
+		if err != nil {
+			p.logger.Warn("failed to look up token metadata", "contract", a.Contract, logging.KeyError, err)
+		}
+		a.Symbol, a.Decimals = meta.Symbol, meta.Decimals
+	}
+	p.allowances.SetAllowance(a)
+}
+
+// attachMetadata sets the symbol, name and decimals of tt's token. A failed
+// lookup leaves them empty rather than holding up the block.
+func (p *parserImpl) attachMetadata(ctx context.Context, tt *transaction.TokenTransfer) {
+	if p.tokenMetadata == nil {
+		return
+	}
+	meta, err := p.tokenMetadata.Lookup(ctx, tt.Contract)
+	if err != nil {
+		p.logger.Warn("failed to look up token metadata", "contract", tt.Contract, logging.KeyError, err)
+		return
+	}
+	tt.Symbol = meta.Symbol
+	tt.Name = meta.Name
+	if tt.Standard == transaction.StandardERC20 {
+		tt.Decimals = meta.Decimals
+	}
+}
+
+// recordToken stores tt for addr and invalidates addr's cached balance of
+// the token. In dry-run mode nothing is stored.
+func (p *parserImpl) recordToken(addr string, tt transaction.TokenTransfer) {
+	if p.dryRun != nil {
+		return
+	}
+	p.store.AddTokenTransfer(addr, tt)
+	p.chain.touch(tt.Block, addr)
+	if p.tokenBalances != nil {
+		p.tokenBalances.Invalidate(tt.Contract, addr)
+	}
+}
+
+// decodeTransfer decodes a Transfer event log, reporting false for logs that
+// aren't ERC-20 or ERC-721 transfers or were removed by a reorg.
+func decodeTransfer(l rpc.Log) (transaction.TokenTransfer, bool) {
+	if l.Removed || len(l.Topics) < 3 || !strings.EqualFold(l.Topics[0], transferTopic) {
+		return transaction.TokenTransfer{}, false
+	}
+	from, ok1 := topicAddress(l.Topics[1])
+	to, ok2 := topicAddress(l.Topics[2])
+	if !ok1 || !ok2 {
+		return transaction.TokenTransfer{}, false
+	}
+	tt := transaction.TokenTransfer{
+		Hash:     l.TransactionHash,
+		LogIndex: hexToInt(l.LogIndex),
+		Contract: strings.ToLower(l.Address),
+		From:     from,
+		To:       to,
+	}
+	switch len(l.Topics) {
+	case 3:
+		// The amount is the only, unindexed argument.
+		data := strings.TrimPrefix(l.Data, "0x")
+		if len(data) != 64 {
+			return transaction.TokenTransfer{}, false
+		}
+		tt.Standard = transaction.StandardERC20
+		tt.Amount = hexToValue(data)
+	case 4:
+		id := hexToValue(l.Topics[3])
+		tt.Standard = transaction.StandardERC721
+		tt.TokenID = id.String()
+		tt.Amount = transaction.WeiValue(1)
+	default:
+		return transaction.TokenTransfer{}, false
+	}
+	return tt, true
+}
+
+// decodeApproval decodes an ERC-20 Approval event log, reporting false for
+// other logs and those removed by a reorg.
+func decodeApproval(l rpc.Log) (transaction.Allowance, bool) {
+	if l.Removed || len(l.Topics) != 3 || !strings.EqualFold(l.Topics[0], approvalTopic) {
+		return transaction.Allowance{}, false
+	}
+	owner, ok1 := topicAddress(l.Topics[1])
+	spender, ok2 := topicAddress(l.Topics[2])
+	data := strings.TrimPrefix(l.Data, "0x")
+	if !ok1 || !ok2 || len(data) != 64 {
+		return transaction.Allowance{}, false
+	}
+	return transaction.Allowance{
+		Owner:    owner,
+		Spender:  spender,
+		Contract: strings.ToLower(l.Address),
+		Amount:   hexToValue(data),
+		Hash:     l.TransactionHash,
+		LogIndex: hexToInt(l.LogIndex),
+	}, true
+}
+
+// topicAddress decodes an address indexed in a 32-byte topic.
+func topicAddress(topic string) (string, bool) {
+	topic = strings.TrimPrefix(topic, "0x")
+	if len(topic) != 64 || strings.Trim(topic[:24], "0") != "" {
+		return "", false
+	}
+	return "0x" + strings.ToLower(topic[24:]), true
+}
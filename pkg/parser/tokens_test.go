@@ -0,0 +1,198 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/storage"
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+	"github.com/danieloluwadare/tw-txparser/pkg/tokens"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+const (
+	tokenUSDC = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+	tokenNFT  = "0xbc4ca0eda7647a8ab7c2061c2e118a18a936f13d"
+	tokenFrom = "0x1111111111111111111111111111111111111111"
+	tokenTo   = "0x2222222222222222222222222222222222222222"
+)
+
+func topic(addr string) string {
+	return "0x" + strings.Repeat("0", 24) + strings.TrimPrefix(addr, "0x")
+}
+
+// logClient adds logs to MockRPCClient.
+type logClient struct {
+	*MockRPCClient
+	logs    []rpc.Log
+	err     error
+	filters []rpc.LogFilter
+}
+
+func (c *logClient) GetLogs(_ context.Context, filter rpc.LogFilter) ([]rpc.Log, error) {
+	c.filters = append(c.filters, filter)
+	return c.logs, c.err
+}
+
+// staticMetadata serves fixed token metadata.
+type staticMetadata map[string]tokens.Metadata
+
+func (m staticMetadata) Lookup(_ context.Context, contract string) (tokens.Metadata, error) {
+	if meta, ok := m[contract]; ok {
+		return meta, nil
+	}
+	return tokens.Metadata{}, errors.New("lookup failed")
+}
+
+func newLogClient() *logClient {
+	return &logClient{MockRPCClient: NewMockRPCClient(), logs: []rpc.Log{
+		// 1.5 USDC
+		{Address: tokenUSDC, Topics: []string{transferTopic, topic(tokenFrom), topic(tokenTo)}, Data: "0x" + strings.Repeat("0", 58) + "16e360", TransactionHash: "0xtoken1", LogIndex: "0x2"},
+		// NFT #42 with the token ID indexed
+		{Address: tokenNFT, Topics: []string{transferTopic, topic(tokenTo), topic(tokenFrom), "0x" + strings.Repeat("0", 62) + "2a"}, Data: "0x", TransactionHash: "0xtoken2", LogIndex: "0x5"},
+		// Removed by a reorg
+		{Address: tokenUSDC, Topics: []string{transferTopic, topic(tokenFrom), topic(tokenTo)}, Data: "0x" + strings.Repeat("0", 63) + "1", TransactionHash: "0xtoken3", Removed: true},
+		// An ERC-20 transfer with a malformed amount
+		{Address: tokenUSDC, Topics: []string{transferTopic, topic(tokenFrom), topic(tokenTo)}, Data: "0x01", TransactionHash: "0xtoken4"},
+	}}
+}
+
+func TestParser_IndexTokens(t *testing.T) {
+	client := newLogClient()
+	store := NewMockStorage()
+	p := NewParserWithInterval(client, store, time.Second, Options{
+		IndexTokens:   true,
+		TokenMetadata: staticMetadata{tokenUSDC: {Symbol: "USDC", Name: "USD Coin", Decimals: 6}},
+	}).(*parserImpl)
+	if err := p.processBlock(context.Background(), 1234); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+	if len(client.filters) != 1 || client.filters[0].FromBlock != 1234 || client.filters[0].ToBlock != 1234 {
+		t.Errorf("Expected the logs of block 1234 to be fetched, got %+v", client.filters)
+	}
+
+	out := store.GetTokenTransfers(tokenFrom)
+	if len(out) != 2 {
+		t.Fatalf("Expected 2 token transfers for the sender, got %+v", out)
+	}
+	usdc := out[0]
+	if usdc.Standard != transaction.StandardERC20 || usdc.Direction != transaction.DirectionOut || usdc.Block != 1234 || usdc.LogIndex != 2 {
+		t.Errorf("Unexpected ERC-20 transfer %+v", usdc)
+	}
+	if usdc.Symbol != "USDC" || usdc.Name != "USD Coin" || usdc.NormalizedAmount() != "1.5" {
+		t.Errorf("Expected 1.5 USDC, got %s %s", usdc.NormalizedAmount(), usdc.Symbol)
+	}
+	// The NFT's metadata lookup fails, so it's stored without.
+	nft := out[1]
+	if nft.Standard != transaction.StandardERC721 || nft.Direction != transaction.DirectionIn || nft.TokenID != "42" || nft.Amount.String() != "1" || nft.Symbol != "" {
+		t.Errorf("Unexpected ERC-721 transfer %+v", nft)
+	}
+	if in := store.GetTokenTransfers(tokenTo); len(in) != 2 || in[0].Direction != transaction.DirectionIn {
+		t.Errorf("Expected 2 token transfers for the receiver, got %+v", in)
+	}
+}
+
+func TestParser_IndexTokens_Ignore(t *testing.T) {
+	store := NewMockStorage()
+	p := NewParserWithInterval(newLogClient(), store, time.Second, Options{IndexTokens: true, Ignore: []string{tokenUSDC}}).(*parserImpl)
+	if err := p.processBlock(context.Background(), 1234); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+	if out := store.GetTokenTransfers(tokenFrom); len(out) != 1 || out[0].Contract != tokenNFT {
+		t.Errorf("Expected transfers of the ignored token to be skipped, got %+v", out)
+	}
+}
+
+func TestParser_IndexTokens_Error(t *testing.T) {
+	client := newLogClient()
+	client.err = errors.New("boom")
+	p := NewParserWithInterval(client, NewMockStorage(), time.Second, Options{IndexTokens: true}).(*parserImpl)
+	if err := p.processBlock(context.Background(), 1234); err == nil {
+		t.Error("Expected the block to fail when its logs can't be fetched")
+	}
+
+	// Disabled by default.
+	client.err = nil
+	client.filters = nil
+	p = NewParserWithInterval(client, NewMockStorage(), time.Second, Options{}).(*parserImpl)
+	if err := p.processBlock(context.Background(), 1234); err != nil || len(client.filters) != 0 {
+		t.Errorf("Expected no logs to be fetched without IndexTokens, got %v %d", err, len(client.filters))
+	}
+}
+
+func TestParser_IndexTokens_Approvals(t *testing.T) {
+	const spender = "0x3333333333333333333333333333333333333333"
+	unlimited := "0x" + strings.Repeat("f", 64)
+	client := newLogClient()
+	client.logs = []rpc.Log{
+		{Address: tokenUSDC, Topics: []string{approvalTopic, topic(tokenFrom), topic(spender)}, Data: unlimited, TransactionHash: "0xapprove1", LogIndex: "0x1"},
+		// An ERC-721 approval of a single token isn't an allowance.
+		{Address: tokenNFT, Topics: []string{approvalTopic, topic(tokenFrom), topic(spender), topic("0x2a")}, Data: "0x", TransactionHash: "0xapprove2", LogIndex: "0x2"},
+		// The owner isn't subscribed.
+		{Address: tokenUSDC, Topics: []string{approvalTopic, topic(tokenTo), topic(spender)}, Data: unlimited, TransactionHash: "0xapprove3", LogIndex: "0x3"},
+	}
+	store := storage.NewMemoryStorage()
+	store.Subscribe(tokenFrom)
+	p := NewParserWithInterval(client, store, time.Second, Options{
+		IndexTokens:   true,
+		TokenMetadata: staticMetadata{tokenUSDC: {Symbol: "USDC", Decimals: 6}},
+	}).(*parserImpl)
+	if err := p.processBlock(context.Background(), 1234); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+	if topics := client.filters[0].Topics; len(topics) != 1 || len(topics[0]) != 2 {
+		t.Errorf("Expected transfers and approvals to be fetched together, got %v", topics)
+	}
+
+	got, err := p.Allowances(tokenFrom)
+	if err != nil {
+		t.Fatalf("Allowances failed: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("Expected one allowance, got %+v", got)
+	}
+	a := got[0]
+	if a.Contract != tokenUSDC || a.Spender != spender || a.Symbol != "USDC" || a.Block != 1234 || !a.Unlimited() {
+		t.Errorf("Unexpected allowance %+v", a)
+	}
+	store.Subscribe(tokenTo)
+	if got, _ := p.Allowances(tokenTo); len(got) != 0 {
+		t.Errorf("Expected approvals by unsubscribed owners to be skipped, got %+v", got)
+	}
+
+	// Storages without allowances don't support them.
+	p = NewParserWithInterval(client, NewMockStorage(), time.Second, Options{IndexTokens: true}).(*parserImpl)
+	if _, err := p.Allowances(tokenFrom); !errors.Is(err, ErrAllowancesUnsupported) {
+		t.Errorf("Expected ErrAllowancesUnsupported, got %v", err)
+	}
+}
+
+func TestParser_IndexTokens_Categories(t *testing.T) {
+	client := newLogClient()
+	client.blockResponse.Transactions = []rpc.Transaction{
+		// An unknown method whose Transfer event makes it a token transfer
+		{Hash: "0xtoken1", From: tokenFrom, To: "0xrouter", Value: "0x0", Input: "0x7ff36ab5"},
+		{Hash: "0xplain", From: tokenFrom, To: tokenTo, Value: "0x1", Input: "0x"},
+		{Hash: "0xdeploy", From: tokenFrom, Value: "0x0", Input: "0x6080"},
+	}
+	store := storage.NewMemoryStorage()
+	store.Subscribe(tokenFrom)
+	p := NewParserWithInterval(client, store, time.Second, Options{IndexTokens: true}).(*parserImpl)
+	if err := p.processBlock(context.Background(), 1234); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+
+	want := map[string]transaction.Category{
+		"0xtoken1": transaction.CategoryTokenTransfer,
+		"0xplain":  transaction.CategoryTransfer,
+		"0xdeploy": transaction.CategoryDeployment,
+	}
+	for _, tx := range store.GetTransactions(tokenFrom) {
+		if tx.Category != want[tx.Hash] {
+			t.Errorf("Expected %s to be categorized as %s, got %s", tx.Hash, want[tx.Hash], tx.Category)
+		}
+	}
+}
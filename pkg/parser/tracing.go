@@ -0,0 +1,23 @@
+// Package parser contains the block poller and parsing logic.
+package parser
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer creates spans for block processing and parser calls.
+var tracer = otel.Tracer("github.com/danieloluwadare/tw-txparser/pkg/parser")
+
+// endSpan records err on span, if any, and ends it. Not-found lookups are
+// expected outcomes and are not marked as errors.
+func endSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, ErrTransactionNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
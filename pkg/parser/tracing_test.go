@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/danieloluwadare/tw-txparser/internal/storage"
+)
+
+var (
+	spansOnce sync.Once
+	spans     *tracetest.SpanRecorder
+)
+
+// recordSpans installs a global tracer provider recording ended spans. The
+// package tracer only ever delegates to the first provider installed, so
+// it is installed once and shared by tests.
+func recordSpans() *tracetest.SpanRecorder {
+	spansOnce.Do(func() {
+		spans = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)))
+	})
+	return spans
+}
+
+func TestParser_ProcessBlockSpans(t *testing.T) {
+	recorder := recordSpans()
+	ctx, root := otel.Tracer("test").Start(context.Background(), "test")
+
+	store := storage.NewMemoryStorage()
+	store.Subscribe("0xto1")
+	p := NewParserWithInterval(NewMockRPCClient(), store, time.Second, Options{}).(*parserImpl)
+	if err := p.processBlock(ctx, 0x1234); err != nil {
+		t.Fatalf("processBlock failed: %v", err)
+	}
+	root.End()
+
+	byName := make(map[string]sdktrace.ReadOnlySpan)
+	for _, s := range recorder.Ended() {
+		if s.SpanContext().TraceID() == root.SpanContext().TraceID() {
+			byName[s.Name()] = s
+		}
+	}
+	block := byName["parser.processBlock"]
+	if block == nil {
+		t.Fatalf("Expected a block span, got %v", byName)
+	}
+	if _, ok := byName["rpc.GetBlock"]; ok {
+		t.Error("Expected the fetch to be left to the RPC client's span")
+	}
+	stored := 0
+	for _, s := range recorder.Ended() {
+		if s.Name() != "storage.AddTransaction" || s.SpanContext().TraceID() != root.SpanContext().TraceID() {
+			continue
+		}
+		stored++
+		if s.Parent().SpanID() != block.SpanContext().SpanID() {
+			t.Error("Expected storage spans to be children of the block span")
+		}
+		if s.EndTime().Before(s.StartTime()) || s.StartTime().Before(block.StartTime()) || s.EndTime().After(block.EndTime()) {
+			t.Errorf("Expected the storage span to lie within the block span, got %v-%v", s.StartTime(), s.EndTime())
+		}
+	}
+	if stored == 0 {
+		t.Error("Expected a storage span per stored transaction")
+	}
+}
@@ -3,9 +3,13 @@ package parser
 
 import (
 	"encoding/hex"
+	"fmt"
 	"math/big"
 	"strconv"
 	"strings"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
 )
 
 // hexToInt parses a hex string (with or without 0x prefix) into int.
@@ -41,19 +45,54 @@ func decodeHex(hexStr string) (int, error) {
 	return int(b[0]), nil
 }
 
-// hexToBigIntString converts hex string "0x..." to decimal string.
-// Returns "0" if parsing fails.
-func hexToBigIntString(h string) string {
-	hi := strings.TrimPrefix(h, "0x")
-	if hi == "" {
-		return "0"
-	}
-	b := new(big.Int)
-	_, ok := b.SetString(hi, 16)
-	if !ok {
-		// Return "0" for invalid hex strings rather than failing
+// hexToValue converts a hex wei amount "0x..." to a Value.
+// Returns zero if parsing fails.
+func hexToValue(h string) transaction.Value {
+	if !strings.HasPrefix(h, "0x") {
+		h = "0x" + h
+	}
+	v, err := transaction.ParseValue(h)
+	if err != nil {
+		// Return zero for invalid hex strings rather than failing
 		// This ensures the parser continues even with malformed data
-		return "0"
+		return transaction.Value{}
+	}
+	return v
+}
+
+// isZeroValueCall reports whether tx calls a contract, carrying input data
+// to an existing address, without transferring any ether.
+func isZeroValueCall(tx rpc.Transaction, value transaction.Value) bool {
+	return tx.To != "" && tx.Input != "" && tx.Input != "0x" && value.Sign() == 0
+}
+
+// truncateInput returns the hex input data cut to at most maxBytes bytes,
+// and whether it was cut. Empty input ("0x") is returned as "".
+func truncateInput(input string, maxBytes int) (string, bool) {
+	digits := strings.TrimPrefix(strings.ToLower(input), "0x")
+	if digits == "" {
+		return "", false
+	}
+	if len(digits) <= 2*maxBytes {
+		return "0x" + digits, false
+	}
+	return "0x" + digits[:2*maxBytes], true
+}
+
+// receiptFee computes gasUsed × effectiveGasPrice from r, falling back to the
+// transaction's gasPrice for receipts without an effective price.
+func receiptFee(r *rpc.Receipt, tx rpc.Transaction) (transaction.Value, error) {
+	gasUsed, err := transaction.ParseValue(r.GasUsed)
+	if err != nil {
+		return transaction.Value{}, fmt.Errorf("invalid gasUsed %q", r.GasUsed)
+	}
+	price := r.EffectiveGasPrice
+	if price == "" {
+		price = tx.GasPrice
+	}
+	gasPrice, err := transaction.ParseValue(price)
+	if err != nil {
+		return transaction.Value{}, fmt.Errorf("invalid gas price %q", price)
 	}
-	return b.String()
+	return transaction.NewValue(new(big.Int).Mul(gasUsed.Wei(), gasPrice.Wei())), nil
 }
@@ -2,6 +2,8 @@ package parser
 
 import (
 	"testing"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
 )
 
 func TestHexToInt(t *testing.T) {
@@ -122,7 +124,7 @@ func TestDecodeHex(t *testing.T) {
 	}
 }
 
-func TestHexToBigIntString(t *testing.T) {
+func TestHexToValue(t *testing.T) {
 	tests := []struct {
 		name     string
 		hexStr   string
@@ -172,9 +174,57 @@ func TestHexToBigIntString(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := hexToBigIntString(tt.hexStr)
+			result := hexToValue(tt.hexStr).String()
 			if result != tt.expected {
-				t.Errorf("hexToBigIntString(%s) = %s, expected %s", tt.hexStr, result, tt.expected)
+				t.Errorf("hexToValue(%s) = %s, expected %s", tt.hexStr, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestReceiptFee(t *testing.T) {
+	tests := []struct {
+		name     string
+		receipt  rpc.Receipt
+		gasPrice string
+		expected string
+		wantErr  bool
+	}{
+		{name: "effective price", receipt: rpc.Receipt{GasUsed: "0x5208", EffectiveGasPrice: "0x3b9aca00"}, gasPrice: "0x1", expected: "21000000000000"},
+		{name: "legacy receipt", receipt: rpc.Receipt{GasUsed: "0x5208"}, gasPrice: "0x2", expected: "42000"},
+		{name: "no price", receipt: rpc.Receipt{GasUsed: "0x5208"}, wantErr: true},
+		{name: "invalid gas used", receipt: rpc.Receipt{GasUsed: "0xzz", EffectiveGasPrice: "0x1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fee, err := receiptFee(&tt.receipt, rpc.Transaction{GasPrice: tt.gasPrice})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if err == nil && fee.String() != tt.expected {
+				t.Errorf("Expected fee %s, got %s", tt.expected, fee)
+			}
+		})
+	}
+}
+
+func TestTruncateInput(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		expected  string
+		truncated bool
+	}{
+		{name: "empty", input: "0x", expected: ""},
+		{name: "within cap", input: "0xA9059CBB", expected: "0xa9059cbb"},
+		{name: "at cap", input: "0xa9059cbb00", expected: "0xa9059cbb00"},
+		{name: "over cap", input: "0xa9059cbb0000", expected: "0xa9059cbb00", truncated: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, truncated := truncateInput(tt.input, 5)
+			if got != tt.expected || truncated != tt.truncated {
+				t.Errorf("Expected %q %v, got %q %v", tt.expected, tt.truncated, got, truncated)
 			}
 		})
 	}
@@ -0,0 +1,353 @@
+// Package txparserv1 holds the Go types generated from
+// proto/txparser/v1/txparser.proto, along with converters to and from the
+// txparser models.
+package txparserv1
+
+//go:generate protoc -I ../../../proto --go_out=../../.. --go_opt=module=github.com/danieloluwadare/tw-txparser txparser/v1/txparser.proto
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+var directions = map[transaction.Direction]Direction{
+	"":                        Direction_DIRECTION_UNSPECIFIED,
+	transaction.DirectionIn:   Direction_DIRECTION_IN,
+	transaction.DirectionOut:  Direction_DIRECTION_OUT,
+	transaction.DirectionSelf: Direction_DIRECTION_SELF,
+}
+
+var statuses = map[transaction.Status]Status{
+	"":                         Status_STATUS_UNSPECIFIED,
+	transaction.StatusSuccess:  Status_STATUS_SUCCESS,
+	transaction.StatusReverted: Status_STATUS_REVERTED,
+}
+
+var categories = map[transaction.Category]Category{
+	"":                                Category_CATEGORY_UNSPECIFIED,
+	transaction.CategoryTransfer:      Category_CATEGORY_TRANSFER,
+	transaction.CategoryContractCall:  Category_CATEGORY_CONTRACT_CALL,
+	transaction.CategoryDeployment:    Category_CATEGORY_DEPLOYMENT,
+	transaction.CategoryTokenTransfer: Category_CATEGORY_TOKEN_TRANSFER,
+}
+
+var standards = map[transaction.TokenStandard]TokenStandard{
+	"":                          TokenStandard_TOKEN_STANDARD_UNSPECIFIED,
+	transaction.StandardERC20:   TokenStandard_TOKEN_STANDARD_ERC20,
+	transaction.StandardERC721:  TokenStandard_TOKEN_STANDARD_ERC721,
+	transaction.StandardERC1155: TokenStandard_TOKEN_STANDARD_ERC1155,
+}
+
+// FromDirection converts d, mapping unknown directions to unspecified.
+func FromDirection(d transaction.Direction) Direction {
+	return directions[d]
+}
+
+// ToModel converts d, returning an error for unknown values.
+func (d Direction) ToModel() (transaction.Direction, error) {
+	for k, v := range directions {
+		if v == d {
+			return k, nil
+		}
+	}
+	return "", fmt.Errorf("unknown direction %d", d)
+}
+
+// FromStatus converts s, mapping unknown statuses to unspecified.
+func FromStatus(s transaction.Status) Status {
+	return statuses[s]
+}
+
+// ToModel converts s, returning an error for unknown values.
+func (s Status) ToModel() (transaction.Status, error) {
+	for k, v := range statuses {
+		if v == s {
+			return k, nil
+		}
+	}
+	return "", fmt.Errorf("unknown status %d", s)
+}
+
+// FromCategory converts c, mapping unknown categories to unspecified.
+func FromCategory(c transaction.Category) Category {
+	return categories[c]
+}
+
+// ToModel converts c, returning an error for unknown values.
+func (c Category) ToModel() (transaction.Category, error) {
+	for k, v := range categories {
+		if v == c {
+			return k, nil
+		}
+	}
+	return "", fmt.Errorf("unknown category %d", c)
+}
+
+// FromTokenStandard converts s, mapping unknown standards to unspecified.
+func FromTokenStandard(s transaction.TokenStandard) TokenStandard {
+	return standards[s]
+}
+
+// ToModel converts s, returning an error for unknown values.
+func (s TokenStandard) ToModel() (transaction.TokenStandard, error) {
+	for k, v := range standards {
+		if v == s {
+			return k, nil
+		}
+	}
+	return "", fmt.Errorf("unknown token standard %d", s)
+}
+
+// FromTransaction converts tx.
+func FromTransaction(tx transaction.Transaction) *Transaction {
+	out := &Transaction{
+		Hash:           tx.Hash,
+		From:           tx.From,
+		To:             tx.To,
+		Value:          tx.Value.String(),
+		Block:          uint64(tx.Block),
+		Direction:      FromDirection(tx.Direction),
+		Status:         FromStatus(tx.Status),
+		GasUsed:        tx.GasUsed,
+		Category:       FromCategory(tx.Category),
+		Input:          tx.Input,
+		InputTruncated: tx.InputTruncated,
+	}
+	if !tx.IndexedAt.IsZero() {
+		out.IndexedAt = timestamppb.New(tx.IndexedAt)
+	}
+	if tx.Fee != nil {
+		out.Fee = tx.Fee.String()
+	}
+	if tx.Call != nil {
+		out.Call = FromCall(*tx.Call)
+	}
+	return out
+}
+
+// ToModel converts x, validating its amounts, enums and timestamp.
+func (x *Transaction) ToModel() (transaction.Transaction, error) {
+	value, err := parseAmount(x.GetValue())
+	if err != nil {
+		return transaction.Transaction{}, err
+	}
+	dir, err := x.GetDirection().ToModel()
+	if err != nil {
+		return transaction.Transaction{}, err
+	}
+	status, err := x.GetStatus().ToModel()
+	if err != nil {
+		return transaction.Transaction{}, err
+	}
+	category, err := x.GetCategory().ToModel()
+	if err != nil {
+		return transaction.Transaction{}, err
+	}
+	tx := transaction.Transaction{
+		Hash:           x.GetHash(),
+		From:           x.GetFrom(),
+		To:             x.GetTo(),
+		Value:          value,
+		Block:          int(x.GetBlock()),
+		Direction:      dir,
+		Status:         status,
+		GasUsed:        x.GetGasUsed(),
+		Category:       category,
+		Input:          x.GetInput(),
+		InputTruncated: x.GetInputTruncated(),
+	}
+	if ts := x.GetIndexedAt(); ts != nil {
+		if err := ts.CheckValid(); err != nil {
+			return transaction.Transaction{}, fmt.Errorf("invalid indexed_at: %w", err)
+		}
+		tx.IndexedAt = ts.AsTime()
+	}
+	if x.GetFee() != "" {
+		fee, err := parseAmount(x.GetFee())
+		if err != nil {
+			return transaction.Transaction{}, fmt.Errorf("invalid fee: %w", err)
+		}
+		tx.Fee = &fee
+	}
+	if x.GetCall() != nil {
+		call := x.GetCall().ToModel()
+		tx.Call = &call
+	}
+	return tx, nil
+}
+
+// FromCall converts c.
+func FromCall(c transaction.Call) *Call {
+	out := &Call{Selector: c.Selector, Method: c.Method, Signature: c.Signature}
+	for _, a := range c.Args {
+		out.Args = append(out.Args, &Arg{Name: a.Name, Type: a.Type, Value: a.Value})
+	}
+	return out
+}
+
+// ToModel converts x.
+func (x *Call) ToModel() transaction.Call {
+	c := transaction.Call{Selector: x.GetSelector(), Method: x.GetMethod(), Signature: x.GetSignature()}
+	for _, a := range x.GetArgs() {
+		c.Args = append(c.Args, transaction.Arg{Name: a.GetName(), Type: a.GetType(), Value: a.GetValue()})
+	}
+	return c
+}
+
+// FromTokenTransfer converts tt.
+func FromTokenTransfer(tt transaction.TokenTransfer) *TokenTransfer {
+	return &TokenTransfer{
+		Hash:      tt.Hash,
+		LogIndex:  uint32(tt.LogIndex),
+		Block:     uint64(tt.Block),
+		Contract:  tt.Contract,
+		Standard:  FromTokenStandard(tt.Standard),
+		Symbol:    tt.Symbol,
+		Decimals:  uint32(tt.Decimals),
+		From:      tt.From,
+		To:        tt.To,
+		TokenId:   tt.TokenID,
+		Amount:    tt.Amount.String(),
+		Direction: FromDirection(tt.Direction),
+	}
+}
+
+// ToModel converts x, validating its amount, standard and direction.
+func (x *TokenTransfer) ToModel() (transaction.TokenTransfer, error) {
+	amount, err := parseAmount(x.GetAmount())
+	if err != nil {
+		return transaction.TokenTransfer{}, err
+	}
+	standard, err := x.GetStandard().ToModel()
+	if err != nil {
+		return transaction.TokenTransfer{}, err
+	}
+	dir, err := x.GetDirection().ToModel()
+	if err != nil {
+		return transaction.TokenTransfer{}, err
+	}
+	return transaction.TokenTransfer{
+		Hash:      x.GetHash(),
+		LogIndex:  int(x.GetLogIndex()),
+		Block:     int(x.GetBlock()),
+		Contract:  x.GetContract(),
+		Standard:  standard,
+		Symbol:    x.GetSymbol(),
+		Decimals:  int(x.GetDecimals()),
+		From:      x.GetFrom(),
+		To:        x.GetTo(),
+		TokenID:   x.GetTokenId(),
+		Amount:    amount,
+		Direction: dir,
+	}, nil
+}
+
+// FromRPCBlock converts a block as returned by the node, decoding its hex
+// quantities. Transactions get the block's number and no direction.
+func FromRPCBlock(b *rpc.Block) (*Block, error) {
+	number, err := strconv.ParseUint(strings.TrimPrefix(b.Number, "0x"), 16, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid block number %q: %w", b.Number, err)
+	}
+	out := &Block{Number: number, Transactions: make([]*Transaction, 0, len(b.Transactions))}
+	for _, tx := range b.Transactions {
+		value, err := transaction.ParseValue(tx.Value)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %s: %w", tx.Hash, err)
+		}
+		out.Transactions = append(out.Transactions, &Transaction{
+			Hash:  tx.Hash,
+			From:  tx.From,
+			To:    tx.To,
+			Value: value.String(),
+			Block: number,
+		})
+	}
+	return out, nil
+}
+
+// ToRPC converts x to the node's representation, encoding quantities as hex.
+func (x *Block) ToRPC() (*rpc.Block, error) {
+	number := "0x" + strconv.FormatUint(x.GetNumber(), 16)
+	out := &rpc.Block{Number: number, Transactions: make([]rpc.Transaction, 0, len(x.GetTransactions()))}
+	for _, tx := range x.GetTransactions() {
+		value, err := parseAmount(tx.GetValue())
+		if err != nil {
+			return nil, fmt.Errorf("transaction %s: %w", tx.GetHash(), err)
+		}
+		out.Transactions = append(out.Transactions, rpc.Transaction{
+			Hash:        tx.GetHash(),
+			From:        tx.GetFrom(),
+			To:          tx.GetTo(),
+			Value:       "0x" + value.Wei().Text(16),
+			BlockNumber: number,
+		})
+	}
+	return out, nil
+}
+
+// FromEvent converts ev, tagging it with chain, which may be empty.
+func FromEvent(chain string, ev parser.Event) *Event {
+	return &Event{
+		Chain:       chain,
+		Address:     ev.Address,
+		Transaction: FromTransaction(ev.Transaction),
+		Removed:     ev.Removed,
+		Reorg:       FromReorg(ev.Reorg),
+	}
+}
+
+// FromReorg converts r, returning nil for a nil r.
+func FromReorg(r *parser.Reorg) *Reorg {
+	if r == nil {
+		return nil
+	}
+	out := &Reorg{Depth: uint32(r.Depth), Blocks: make([]*BlockRef, len(r.Blocks))}
+	for i, b := range r.Blocks {
+		out.Blocks[i] = &BlockRef{Number: uint64(b.Number), Hash: b.Hash}
+	}
+	return out
+}
+
+// ToModel converts x, returning nil for a nil x.
+func (x *Reorg) ToModel() *parser.Reorg {
+	if x == nil {
+		return nil
+	}
+	out := &parser.Reorg{Depth: int(x.GetDepth()), Blocks: make([]parser.BlockRef, len(x.GetBlocks()))}
+	for i, b := range x.GetBlocks() {
+		out.Blocks[i] = parser.BlockRef{Number: int(b.GetNumber()), Hash: b.GetHash()}
+	}
+	return out
+}
+
+// ToModel converts x. The chain is not part of parser.Event and is dropped.
+func (x *Event) ToModel() (parser.Event, error) {
+	if x.GetTransaction() == nil {
+		return parser.Event{}, fmt.Errorf("event for %s has no transaction", x.GetAddress())
+	}
+	tx, err := x.GetTransaction().ToModel()
+	if err != nil {
+		return parser.Event{}, err
+	}
+	return parser.Event{Address: x.GetAddress(), Transaction: tx, Removed: x.GetRemoved(), Reorg: x.GetReorg().ToModel()}, nil
+}
+
+// parseAmount parses a decimal amount; the empty string proto3 uses for
+// unset fields means zero.
+func parseAmount(s string) (transaction.Value, error) {
+	if s == "" {
+		return transaction.Value{}, nil
+	}
+	if strings.HasPrefix(s, "0x") {
+		return transaction.Value{}, fmt.Errorf("invalid amount %q: expected a decimal string", s)
+	}
+	return transaction.ParseValue(s)
+}
@@ -0,0 +1,185 @@
+package txparserv1
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// roundTrip marshals m and unmarshals it into a fresh message of the same type.
+func roundTrip[M proto.Message](t *testing.T, m M) M {
+	t.Helper()
+	b, err := proto.Marshal(m)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	out := m.ProtoReflect().New().Interface().(M)
+	if err := proto.Unmarshal(b, out); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	return out
+}
+
+func TestTransaction_RoundTrip(t *testing.T) {
+	fee := transaction.WeiValue(21000000000000)
+	for _, dir := range []transaction.Direction{"", transaction.DirectionIn, transaction.DirectionOut, transaction.DirectionSelf} {
+		tx := transaction.Transaction{
+			Hash:           "0xhash",
+			From:           "0xfrom",
+			To:             "0xto",
+			Value:          transaction.MustParseValue("123456789012345678901234567890"),
+			Block:          18500000,
+			Direction:      dir,
+			IndexedAt:      time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC),
+			Fee:            &fee,
+			Status:         transaction.StatusReverted,
+			GasUsed:        21000,
+			Category:       transaction.CategoryTokenTransfer,
+			Input:          "0xa9059cbb",
+			InputTruncated: true,
+			Call: &transaction.Call{
+				Method:    "transfer",
+				Signature: "transfer(address,uint256)",
+				Args: []transaction.Arg{
+					{Name: "to", Type: "address", Value: "0xto"},
+					{Name: "amount", Type: "uint256", Value: "5"},
+				},
+			},
+		}
+		got, err := roundTrip(t, FromTransaction(tx)).ToModel()
+		if err != nil {
+			t.Fatalf("ToModel failed: %v", err)
+		}
+		if got.Value.Cmp(tx.Value) != 0 {
+			t.Errorf("Expected value %s, got %s", tx.Value, got.Value)
+		}
+		if got.Fee == nil || got.Fee.Cmp(fee) != 0 {
+			t.Errorf("Expected fee %s, got %v", fee, got.Fee)
+		}
+		got.Value, got.Fee = tx.Value, tx.Fee
+		if !reflect.DeepEqual(got, tx) {
+			t.Errorf("Expected %+v, got %+v", tx, got)
+		}
+	}
+}
+
+func TestTransaction_ToModelInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		tx   *Transaction
+	}{
+		{name: "hex value", tx: &Transaction{Value: "0x10"}},
+		{name: "negative value", tx: &Transaction{Value: "-1"}},
+		{name: "unknown direction", tx: &Transaction{Direction: Direction(42)}},
+		{name: "unknown status", tx: &Transaction{Status: Status(42)}},
+		{name: "unknown category", tx: &Transaction{Category: Category(42)}},
+		{name: "invalid fee", tx: &Transaction{Fee: "lots"}},
+		{name: "invalid indexed_at", tx: &Transaction{IndexedAt: &timestamppb.Timestamp{Nanos: -1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.tx.ToModel(); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+	// Unset fields are zero values.
+	tx, err := (&Transaction{}).ToModel()
+	if err != nil || tx.Value.Sign() != 0 || !tx.IndexedAt.IsZero() || tx.Fee != nil || tx.Call != nil {
+		t.Errorf("Expected a zero transaction, got %+v %v", tx, err)
+	}
+}
+
+func TestTokenTransfer_RoundTrip(t *testing.T) {
+	tt := transaction.TokenTransfer{
+		Hash:      "0xhash",
+		LogIndex:  7,
+		Block:     100,
+		Contract:  "0xtoken",
+		Standard:  transaction.StandardERC1155,
+		Symbol:    "ITEM",
+		From:      "0xfrom",
+		To:        "0xto",
+		TokenID:   "42",
+		Amount:    transaction.WeiValue(3),
+		Direction: transaction.DirectionIn,
+	}
+	got, err := roundTrip(t, FromTokenTransfer(tt)).ToModel()
+	if err != nil {
+		t.Fatalf("ToModel failed: %v", err)
+	}
+	if got.Amount.Cmp(tt.Amount) != 0 {
+		t.Errorf("Expected amount %s, got %s", tt.Amount, got.Amount)
+	}
+	got.Amount = tt.Amount
+	if !reflect.DeepEqual(got, tt) {
+		t.Errorf("Expected %+v, got %+v", tt, got)
+	}
+}
+
+func TestBlock_RoundTrip(t *testing.T) {
+	b := &rpc.Block{
+		Number: "0x11a49a0",
+		Transactions: []rpc.Transaction{
+			{Hash: "0xa", From: "0xfrom", To: "0xto", Value: "0xde0b6b3a7640000", BlockNumber: "0x11a49a0"},
+			{Hash: "0xb", From: "0xfrom", Value: "0x0", BlockNumber: "0x11a49a0"},
+		},
+	}
+	pb, err := FromRPCBlock(b)
+	if err != nil {
+		t.Fatalf("FromRPCBlock failed: %v", err)
+	}
+	if pb.GetNumber() != 18500000 || pb.GetTransactions()[0].GetValue() != "1000000000000000000" {
+		t.Errorf("Unexpected block: %v", pb)
+	}
+	got, err := roundTrip(t, pb).ToRPC()
+	if err != nil {
+		t.Fatalf("ToRPC failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, b) {
+		t.Errorf("Expected %+v, got %+v", b, got)
+	}
+
+	if _, err := FromRPCBlock(&rpc.Block{Number: "latest"}); err == nil {
+		t.Error("Expected an error for an invalid block number")
+	}
+}
+
+func TestEvent_RoundTrip(t *testing.T) {
+	ev := parser.Event{
+		Address:     "0xto",
+		Transaction: transaction.Transaction{Hash: "0xhash", From: "0xfrom", To: "0xto", Value: transaction.WeiValue(1), Block: 1, Direction: transaction.DirectionIn},
+	}
+	pb := roundTrip(t, FromEvent("sepolia", ev))
+	if pb.GetChain() != "sepolia" {
+		t.Errorf("Expected chain sepolia, got %q", pb.GetChain())
+	}
+	got, err := pb.ToModel()
+	if err != nil {
+		t.Fatalf("ToModel failed: %v", err)
+	}
+	if got.Address != ev.Address || got.Transaction.Hash != ev.Transaction.Hash || got.Transaction.Direction != ev.Transaction.Direction || got.Removed || got.Reorg != nil {
+		t.Errorf("Expected %+v, got %+v", ev, got)
+	}
+
+	ev.Removed = true
+	ev.Reorg = &parser.Reorg{Depth: 1, Blocks: []parser.BlockRef{{Number: 1, Hash: "0xb1"}, {Number: 2, Hash: "0xb2"}}}
+	got, err = roundTrip(t, FromEvent("sepolia", ev)).ToModel()
+	if err != nil {
+		t.Fatalf("ToModel failed: %v", err)
+	}
+	if !got.Removed || !reflect.DeepEqual(got.Reorg, ev.Reorg) {
+		t.Errorf("Expected the removal with %+v, got %+v", ev.Reorg, got)
+	}
+
+	if _, err := (&Event{Address: "0xto"}).ToModel(); err == nil {
+		t.Error("Expected an error for an event without a transaction")
+	}
+}
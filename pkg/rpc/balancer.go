@@ -0,0 +1,357 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
+)
+
+// Strategy selects how a Balancer spreads calls across providers.
+type Strategy string
+
+const (
+	// StrategyFailover sends every call to the first available provider,
+	// moving on to the next ones only when it fails.
+	StrategyFailover Strategy = "failover"
+	// StrategyRoundRobin spreads calls across the available providers in
+	// proportion to their weights, failing over the same way.
+	StrategyRoundRobin Strategy = "round-robin"
+)
+
+// ParseStrategy validates s as a Strategy.
+func ParseStrategy(s string) (Strategy, error) {
+	switch st := Strategy(s); st {
+	case StrategyFailover, StrategyRoundRobin:
+		return st, nil
+	}
+	return "", fmt.Errorf("invalid strategy %q: expected failover or round-robin", s)
+}
+
+// Endpoint is a JSON-RPC provider along with its share of calls under
+// StrategyRoundRobin.
+type Endpoint struct {
+	URL    string
+	Weight int
+}
+
+// ParseEndpoints parses a comma-separated list of endpoint URLs, each
+// optionally followed by |<weight>, e.g. "https://a.example|3,https://b.example".
+// Weights default to 1.
+func ParseEndpoints(s string) ([]Endpoint, error) {
+	var out []Endpoint
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		e := Endpoint{URL: part, Weight: 1}
+		if u, w, ok := strings.Cut(part, "|"); ok {
+			n, err := strconv.Atoi(strings.TrimSpace(w))
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid weight %q of endpoint %s: expected a positive integer", w, u)
+			}
+			e = Endpoint{URL: strings.TrimSpace(u), Weight: n}
+		}
+		if u, err := url.Parse(e.URL); err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid endpoint URL %q", e.URL)
+		}
+		out = append(out, e)
+	}
+	if len(out) == 0 {
+		return nil, errors.New("no endpoints")
+	}
+	return out, nil
+}
+
+var (
+	// errBlockUnknown reports a block the provider doesn't have yet, so
+	// another provider further ahead is tried.
+	errBlockUnknown = errors.New("block unknown to provider")
+	// errCallback reports that a streaming callback failed, which no
+	// other provider can fix.
+	errCallback = errors.New("callback failed")
+)
+
+// BalancerOptions configures a Balancer.
+type BalancerOptions struct {
+	// Strategy defaults to StrategyFailover.
+	Strategy Strategy
+	// Cooldown is how long a provider that failed a call is taken out of
+	// rotation. Providers still get calls while all of them are cooling
+	// down. Defaults to 30s.
+	Cooldown time.Duration
+	// Metrics records the calls of every provider and the failovers away
+	// from each. Defaults to metrics.Nop.
+	Metrics metrics.Recorder
+	// HealthInterval is how often Start probes the providers, see
+	// CheckHealth. Defaults to 15s.
+	HealthInterval time.Duration
+	// MaxLag is how many blocks a provider's head may trail the highest
+	// head among the providers before it is evicted. Defaults to 5.
+	MaxLag int
+	// Transport configures the HTTP connections to every provider.
+	Transport Transport
+}
+
+// Balancer is a client spreading calls across several providers of the
+// same chain. A call failing at one provider, other than with an error
+// returned by the node itself, is retried at the next, and a block one
+// provider doesn't have yet is looked up at the others. Balancer implements
+// the same optional interfaces as Client and is safe for concurrent use.
+type Balancer struct {
+	providers []*provider
+	strategy  Strategy
+	cooldown  time.Duration
+	metrics   metrics.Recorder
+	now       func() time.Time
+	// health checks
+	interval time.Duration
+	maxLag   int
+
+	mu sync.Mutex // guards the providers' rotation and health state
+}
+
+// provider is one endpoint of a Balancer.
+type provider struct {
+	name   string // the URL's host, which unlike the URL holds no API key
+	client *Client
+	weight int
+	// current is the provider's smooth weighted round-robin score.
+	current   int
+	downUntil time.Time
+	// evicted is why the last health check took the provider out of
+	// rotation; empty while it is healthy.
+	evicted string
+	health  ProviderStatus
+}
+
+// NewBalancer creates a Balancer over endpoints, in order of preference.
+func NewBalancer(endpoints []Endpoint, opts BalancerOptions) (*Balancer, error) {
+	if len(endpoints) == 0 {
+		return nil, errors.New("no endpoints")
+	}
+	if opts.Strategy == "" {
+		opts.Strategy = StrategyFailover
+	}
+	if _, err := ParseStrategy(string(opts.Strategy)); err != nil {
+		return nil, err
+	}
+	if opts.Cooldown <= 0 {
+		opts.Cooldown = 30 * time.Second
+	}
+	if opts.HealthInterval <= 0 {
+		opts.HealthInterval = 15 * time.Second
+	}
+	if opts.MaxLag <= 0 {
+		opts.MaxLag = 5
+	}
+	rec := metrics.OrNop(opts.Metrics)
+	b := &Balancer{
+		strategy: opts.Strategy,
+		cooldown: opts.Cooldown,
+		metrics:  rec,
+		now:      time.Now,
+		interval: opts.HealthInterval,
+		maxLag:   opts.MaxLag,
+	}
+	for _, e := range endpoints {
+		u, err := url.Parse(e.URL)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid endpoint URL %q", e.URL)
+		}
+		b.providers = append(b.providers, &provider{
+			name:   u.Host,
+			client: NewClientWithOptions(e.URL, ClientOptions{Metrics: rec, Transport: opts.Transport}),
+			weight: max(e.Weight, 1),
+		})
+	}
+	return b, nil
+}
+
+// order returns the providers in the order a call tries them: the next one
+// in rotation first, then the other available ones by preference, then
+// those cooling down or evicted.
+func (b *Balancer) order() []*provider {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	var up, down []*provider
+	for _, p := range b.providers {
+		if now.Before(p.downUntil) || p.evicted != "" {
+			down = append(down, p)
+		} else {
+			up = append(up, p)
+		}
+	}
+	if b.strategy == StrategyRoundRobin && len(up) > 1 {
+		// Smooth weighted round-robin: every provider gains its weight and
+		// the one with the highest score is picked and loses the total, so
+		// picks interleave in proportion to weights.
+		total, best := 0, 0
+		for i, p := range up {
+			p.current += p.weight
+			total += p.weight
+			if p.current > up[best].current {
+				best = i
+			}
+		}
+		up[best].current -= total
+		up = append(append([]*provider{up[best]}, up[:best]...), up[best+1:]...)
+	}
+	return append(up, down...)
+}
+
+// setDown takes p out of rotation for the cooldown, or puts it back.
+func (b *Balancer) setDown(p *provider, down bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if down {
+		p.downUntil = b.now().Add(b.cooldown)
+	} else {
+		p.downUntil = time.Time{}
+	}
+}
+
+// retryable reports whether a call failing with err may succeed at another
+// provider. Errors returned by the node, such as a reverted eth_call, and
+// cancellation are final.
+func retryable(ctx context.Context, err error) bool {
+	var rpcErr *RPCError
+	return ctx.Err() == nil && !errors.As(err, &rpcErr) && !errors.Is(err, ErrNotFound) && !errors.Is(err, errCallback)
+}
+
+// try runs fn against each provider in turn until one succeeds or fails
+// with an error that isn't retryable.
+func try[T any](ctx context.Context, b *Balancer, fn func(*Client) (T, error)) (T, error) {
+	var out T
+	var err error
+	providers := b.order()
+	for i, p := range providers {
+		out, err = fn(p.client)
+		if errors.Is(err, errBlockUnknown) {
+			continue
+		}
+		if err == nil || !retryable(ctx, err) {
+			if err == nil {
+				b.setDown(p, false)
+			}
+			return out, err
+		}
+		b.setDown(p, true)
+		if i < len(providers)-1 {
+			b.metrics.Add(metrics.RPCFailovers, 1, metrics.L("provider", p.name))
+		}
+	}
+	if errors.Is(err, errBlockUnknown) {
+		// No provider has the block yet, which is no error.
+		return out, nil
+	}
+	return out, err
+}
+
+// Call performs a JSON-RPC request at the first provider that succeeds.
+func (b *Balancer) Call(ctx context.Context, method string, params []interface{}, result interface{}) error {
+	_, err := try(ctx, b, func(c *Client) (struct{}, error) {
+		return struct{}{}, c.Call(ctx, method, params, result)
+	})
+	return err
+}
+
+// GetBlockNumber returns the latest block number as a hex string.
+func (b *Balancer) GetBlockNumber(ctx context.Context) (string, error) {
+	return try(ctx, b, func(c *Client) (string, error) {
+		return c.GetBlockNumber(ctx)
+	})
+}
+
+// GetBlockByNumber returns block details for the given block number, from
+// the first provider that has the block.
+func (b *Balancer) GetBlockByNumber(ctx context.Context, blockNumber string, includeTransactions bool) (*Block, error) {
+	return try(ctx, b, func(c *Client) (*Block, error) {
+		block, err := c.GetBlockByNumber(ctx, blockNumber, includeTransactions)
+		if err == nil && block.Number == "" {
+			return block, errBlockUnknown
+		}
+		return block, err
+	})
+}
+
+// GetBlockByNumberInt returns block details for the given block number as an integer.
+func (b *Balancer) GetBlockByNumberInt(ctx context.Context, blockNumber int, includeTransactions bool) (*Block, error) {
+	return b.GetBlockByNumber(ctx, fmt.Sprintf("0x%x", blockNumber), includeTransactions)
+}
+
+// StreamBlockByNumber streams the transactions of a block from the first
+// provider that has it. As with Client, fn may see some transactions again
+// when a provider fails midway.
+func (b *Balancer) StreamBlockByNumber(ctx context.Context, blockNumber int, fn func(Transaction) error) (*Block, error) {
+	var fnErr error
+	block, err := try(ctx, b, func(c *Client) (*Block, error) {
+		block, err := c.StreamBlockByNumber(ctx, blockNumber, func(tx Transaction) error {
+			fnErr = fn(tx)
+			return fnErr
+		})
+		if fnErr != nil {
+			return nil, errCallback
+		}
+		if err == nil && block.Number == "" {
+			return block, errBlockUnknown
+		}
+		return block, err
+	})
+	if fnErr != nil {
+		return nil, fnErr
+	}
+	return block, err
+}
+
+// GetTransactionByHash returns the transaction with the given hash.
+func (b *Balancer) GetTransactionByHash(ctx context.Context, hash string) (*Transaction, error) {
+	return try(ctx, b, func(c *Client) (*Transaction, error) {
+		return c.GetTransactionByHash(ctx, hash)
+	})
+}
+
+// GetBalance returns the balance of address in wei as of the end of the
+// given block, as a hex quantity.
+func (b *Balancer) GetBalance(ctx context.Context, address string, blockNumber int) (string, error) {
+	return try(ctx, b, func(c *Client) (string, error) {
+		return c.GetBalance(ctx, address, blockNumber)
+	})
+}
+
+// GetTransactionReceipt returns the receipt of a mined transaction.
+func (b *Balancer) GetTransactionReceipt(ctx context.Context, hash string) (*Receipt, error) {
+	return try(ctx, b, func(c *Client) (*Receipt, error) {
+		return c.GetTransactionReceipt(ctx, hash)
+	})
+}
+
+// GetTransactionReceipts returns the receipts of hashes, fetched in one
+// batch request.
+func (b *Balancer) GetTransactionReceipts(ctx context.Context, hashes []string) ([]*Receipt, error) {
+	return try(ctx, b, func(c *Client) ([]*Receipt, error) {
+		return c.GetTransactionReceipts(ctx, hashes)
+	})
+}
+
+// GetFeeHistory returns the fee history of the latest blocks.
+func (b *Balancer) GetFeeHistory(ctx context.Context, blocks int, percentiles []float64) (*FeeHistory, error) {
+	return try(ctx, b, func(c *Client) (*FeeHistory, error) {
+		return c.GetFeeHistory(ctx, blocks, percentiles)
+	})
+}
+
+// GetLogs returns the logs matching filter.
+func (b *Balancer) GetLogs(ctx context.Context, filter LogFilter) ([]Log, error) {
+	return try(ctx, b, func(c *Client) ([]Log, error) {
+		return c.GetLogs(ctx, filter)
+	})
+}
@@ -0,0 +1,148 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeProvider is a node answering every call with a fixed response body,
+// or with a 503 while failing is set.
+type fakeProvider struct {
+	*httptest.Server
+	body    atomic.Value // string
+	failing atomic.Bool
+	calls   atomic.Int32
+}
+
+func newFakeProvider(t *testing.T, body string) *fakeProvider {
+	f := &fakeProvider{}
+	f.body.Store(body)
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.calls.Add(1)
+		if f.failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(f.body.Load().(string)))
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+const blockNumberResult = `{"jsonrpc":"2.0","id":1,"result":"0x10"}`
+
+func TestBalancer_RoundRobin(t *testing.T) {
+	a := newFakeProvider(t, blockNumberResult)
+	b := newFakeProvider(t, blockNumberResult)
+	bal, err := NewBalancer([]Endpoint{{URL: a.URL, Weight: 3}, {URL: b.URL, Weight: 1}}, BalancerOptions{Strategy: StrategyRoundRobin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 8; i++ {
+		if n, err := bal.GetBlockNumber(context.Background()); err != nil || n != "0x10" {
+			t.Fatalf("GetBlockNumber = %s, %v", n, err)
+		}
+	}
+	if a.calls.Load() != 6 || b.calls.Load() != 2 {
+		t.Errorf("Expected calls split 6:2 by weight, got %d:%d", a.calls.Load(), b.calls.Load())
+	}
+}
+
+func TestBalancer_Failover(t *testing.T) {
+	a := newFakeProvider(t, blockNumberResult)
+	b := newFakeProvider(t, blockNumberResult)
+	bal, _ := NewBalancer([]Endpoint{{URL: a.URL}, {URL: b.URL}}, BalancerOptions{Cooldown: time.Minute})
+	now := time.Now()
+	bal.now = func() time.Time { return now }
+
+	a.failing.Store(true)
+	if _, err := bal.GetBlockNumber(context.Background()); err != nil {
+		t.Fatalf("Expected the call to fail over, got %v", err)
+	}
+	// The failed provider cools down instead of being tried first.
+	bal.GetBlockNumber(context.Background())
+	if a.calls.Load() != 1 || b.calls.Load() != 2 {
+		t.Errorf("Expected the failed provider to be skipped, got %d:%d calls", a.calls.Load(), b.calls.Load())
+	}
+
+	a.failing.Store(false)
+	now = now.Add(2 * time.Minute)
+	bal.GetBlockNumber(context.Background())
+	if a.calls.Load() != 2 {
+		t.Errorf("Expected the preferred provider back after the cooldown, got %d calls", a.calls.Load())
+	}
+
+	a.failing.Store(true)
+	b.failing.Store(true)
+	if _, err := bal.GetBlockNumber(context.Background()); err == nil {
+		t.Error("Expected an error when every provider fails")
+	}
+}
+
+func TestBalancer_NodeErrorsAreFinal(t *testing.T) {
+	a := newFakeProvider(t, `{"jsonrpc":"2.0","id":1,"error":{"code":3,"message":"execution reverted"}}`)
+	b := newFakeProvider(t, `{"jsonrpc":"2.0","id":1,"result":"0x"}`)
+	bal, _ := NewBalancer([]Endpoint{{URL: a.URL}, {URL: b.URL}}, BalancerOptions{})
+
+	var out string
+	err := bal.Call(context.Background(), "eth_call", []interface{}{}, &out)
+	var rpcErr *RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != 3 {
+		t.Errorf("Expected the node's error, got %v", err)
+	}
+	if b.calls.Load() != 0 {
+		t.Error("Expected a node error not to fail over")
+	}
+}
+
+func TestBalancer_UnknownBlock(t *testing.T) {
+	behind := newFakeProvider(t, `{"jsonrpc":"2.0","id":1,"result":null}`)
+	ahead := newFakeProvider(t, `{"jsonrpc":"2.0","id":1,"result":{"number":"0x10","transactions":[{"hash":"0xhash1"}]}}`)
+	bal, _ := NewBalancer([]Endpoint{{URL: behind.URL}, {URL: ahead.URL}}, BalancerOptions{})
+
+	var hashes []string
+	block, err := bal.StreamBlockByNumber(context.Background(), 16, func(tx Transaction) error {
+		hashes = append(hashes, tx.Hash)
+		return nil
+	})
+	if err != nil || block.Number != "0x10" || len(hashes) != 1 {
+		t.Fatalf("Expected the block from the provider ahead, got %+v %v %v", block, hashes, err)
+	}
+
+	// A block no provider has comes back empty, as with a single client.
+	ahead.body.Store(`{"jsonrpc":"2.0","id":1,"result":null}`)
+	if block, err := bal.GetBlockByNumberInt(context.Background(), 17, true); err != nil || block.Number != "" {
+		t.Errorf("Expected an empty block, got %+v %v", block, err)
+	}
+
+	// Callback errors are returned as is without trying other providers.
+	ahead.body.Store(`{"jsonrpc":"2.0","id":1,"result":{"number":"0x10","transactions":[{"hash":"0xhash1"}]}}`)
+	boom := errors.New("boom")
+	calls := ahead.calls.Load()
+	if _, err := bal.StreamBlockByNumber(context.Background(), 16, func(Transaction) error { return boom }); err != boom {
+		t.Errorf("Expected the callback's error, got %v", err)
+	}
+	if ahead.calls.Load() != calls+1 {
+		t.Error("Expected a callback error not to be retried")
+	}
+}
+
+func TestParseEndpoints(t *testing.T) {
+	got, err := ParseEndpoints(" https://a.example/v3/key|3, http://localhost:8545 ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != (Endpoint{URL: "https://a.example/v3/key", Weight: 3}) || got[1] != (Endpoint{URL: "http://localhost:8545", Weight: 1}) {
+		t.Errorf("Unexpected endpoints %+v", got)
+	}
+	for _, s := range []string{"", "https://a.example|0", "https://a.example|x", "not a url"} {
+		if _, err := ParseEndpoints(s); err == nil {
+			t.Errorf("Expected an error for %q", s)
+		}
+	}
+}
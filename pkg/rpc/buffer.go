@@ -0,0 +1,52 @@
+package rpc
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxPooledBuffer is the capacity above which buffers are left to the
+// garbage collector instead of being pooled, so that one unusually large
+// block doesn't pin its memory for the life of the process.
+const maxPooledBuffer = 8 << 20
+
+// buffers pools the buffers requests are encoded into and responses are read
+// into. During catch-up the client makes thousands of calls per minute, and
+// allocating fresh buffers for each showed up as GC pressure.
+var buffers = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	return buffers.Get().(*bytes.Buffer)
+}
+
+// putBuffer resets buf and returns it to the pool. buf must not be used
+// afterwards.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	buf.Reset()
+	buffers.Put(buf)
+}
+
+// pooledBody is a request body reading from a pooled buffer. The transport
+// closes the body once it is done with it, which may be after the round trip
+// returns, so the buffer is only returned to the pool on Close.
+type pooledBody struct {
+	*bytes.Reader
+	buf  *bytes.Buffer
+	once sync.Once
+}
+
+func newPooledBody(buf *bytes.Buffer) *pooledBody {
+	return &pooledBody{Reader: bytes.NewReader(buf.Bytes()), buf: buf}
+}
+
+// Close returns the buffer to the pool. It is safe to call more than once.
+func (b *pooledBody) Close() error {
+	b.once.Do(func() { putBuffer(b.buf) })
+	return nil
+}
@@ -0,0 +1,46 @@
+package rpc
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestPutBuffer(t *testing.T) {
+	buf := getBuffer()
+	buf.WriteString("stale")
+	putBuffer(buf)
+	if got := getBuffer(); got.Len() != 0 {
+		t.Errorf("Expected pooled buffers to be empty, got %q", got.String())
+	}
+
+	// Oversized buffers are dropped rather than reset.
+	big := bytes.NewBuffer(make([]byte, 0, maxPooledBuffer+1))
+	big.WriteString("large")
+	putBuffer(big)
+	if big.String() != "large" {
+		t.Error("Expected an oversized buffer to be left alone")
+	}
+}
+
+func TestPooledBody(t *testing.T) {
+	buf := getBuffer()
+	buf.WriteString(`{"jsonrpc":"2.0"}`)
+	body := newPooledBody(buf)
+	data, err := io.ReadAll(body)
+	if err != nil || string(data) != `{"jsonrpc":"2.0"}` {
+		t.Fatalf("Unexpected body %q: %v", data, err)
+	}
+	if err := body.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Error("Expected Close to return the buffer to the pool")
+	}
+	// A second Close must not put the buffer in the pool twice, or it would
+	// be handed out to two callers at once.
+	_ = body.Close()
+	if a, b := getBuffer(), getBuffer(); a == b {
+		t.Error("Expected a second Close to be a no-op")
+	}
+}
@@ -0,0 +1,465 @@
+// Package chaintest simulates a growing chain behind rpc.RPCClient, with
+// generated transactions, reorgs and injected RPC errors, so that
+// integration tests can exercise realistic scenarios without a node.
+package chaintest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+)
+
+// ErrInjected is returned by calls failed with FailNext without an error of
+// their own.
+var ErrInjected = errors.New("chaintest: injected error")
+
+// defaultBlockTime spaces block timestamps when Options.BlockTime is zero.
+const defaultBlockTime = 12 * time.Second
+
+// Generator returns the transactions of newly mined block number. The Chain
+// fills in their hashes, unless set, and block numbers.
+type Generator func(number int) []rpc.Transaction
+
+// Options configures a Chain.
+type Options struct {
+	// Start is the number of the first block; it defaults to 1.
+	Start int
+	// Genesis is the timestamp of the first block; it defaults to
+	// 2024-01-01 UTC.
+	Genesis time.Time
+	// BlockTime, if positive, mines a block every BlockTime of clock time;
+	// pending blocks are mined when the chain is next called. Without it
+	// blocks are only mined by Mine and their timestamps are 12s apart.
+	BlockTime time.Duration
+	// Now is the clock BlockTime is measured against; it defaults to
+	// time.Now.
+	Now func() time.Time
+	// Generator fills mined blocks; without it blocks only hold the
+	// transactions added with Include.
+	Generator Generator
+}
+
+// Chain is a simulated chain implementing rpc.RPCClient, along with
+// rpc.ReceiptFetcher for the transactions it mined. It starts with its first
+// block mined. It is safe for concurrent use.
+type Chain struct {
+	opts Options
+
+	mu      sync.Mutex
+	blocks  []block // blocks[i] is block Start+i
+	pending []rpc.Transaction
+	seq     int // hashes handed out so far
+	mined   int // blocks mined so far, including replaced ones
+	started time.Time
+	ticks   int // blocks mined by the clock
+	fail    map[string]*failure
+	calls   map[string]int
+}
+
+// block is a mined block. Each mined block has a hash of its own, so
+// blocks replaced by a reorg are told apart from their replacements.
+type block struct {
+	number     string
+	timestamp  string
+	hash       string
+	parentHash string
+	txs        []minedTx
+}
+
+// minedTx is a transaction in a mined block. generated tells those of the
+// Generator from those added with Include.
+type minedTx struct {
+	rpc.Transaction
+	generated bool
+}
+
+// rpcBlock returns b as served over RPC.
+func (b *block) rpcBlock(includeTransactions bool) rpc.Block {
+	out := rpc.Block{Number: b.number, Timestamp: b.timestamp, Hash: b.hash, ParentHash: b.parentHash}
+	if includeTransactions {
+		out.Transactions = make([]rpc.Transaction, len(b.txs))
+		for i, tx := range b.txs {
+			out.Transactions[i] = tx.Transaction
+		}
+	}
+	return out
+}
+
+// failure is an error injected into the next n calls of a method.
+type failure struct {
+	n   int
+	err error
+}
+
+// New creates a Chain.
+func New(opts Options) *Chain {
+	if opts.Start <= 0 {
+		opts.Start = 1
+	}
+	if opts.Genesis.IsZero() {
+		opts.Genesis = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	c := &Chain{
+		opts:    opts,
+		started: opts.Now(),
+		fail:    make(map[string]*failure),
+		calls:   make(map[string]int),
+	}
+	c.mine(1)
+	return c
+}
+
+// Transfers returns a Generator of perBlock value transfers between random
+// pairs of accounts. Its output only depends on seed and the order blocks
+// are mined in, so runs are reproducible.
+func Transfers(seed uint64, accounts []string, perBlock int) Generator {
+	rng := rand.New(rand.NewPCG(seed, seed))
+	var mu sync.Mutex
+	return func(number int) []rpc.Transaction {
+		if len(accounts) < 2 {
+			return nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		txs := make([]rpc.Transaction, perBlock)
+		for i := range txs {
+			from := rng.IntN(len(accounts))
+			to := (from + 1 + rng.IntN(len(accounts)-1)) % len(accounts)
+			txs[i] = rpc.Transaction{
+				From:     accounts[from],
+				To:       accounts[to],
+				Value:    fmt.Sprintf("0x%x", 1+rng.Uint64N(1e18)),
+				GasPrice: fmt.Sprintf("0x%x", 1e9+rng.Uint64N(1e11)),
+			}
+		}
+		return txs
+	}
+}
+
+// Combine returns a Generator of the transactions of every gen in order.
+func Combine(gens ...Generator) Generator {
+	return func(number int) []rpc.Transaction {
+		var txs []rpc.Transaction
+		for _, gen := range gens {
+			txs = append(txs, gen(number)...)
+		}
+		return txs
+	}
+}
+
+// Head returns the number of the latest block.
+func (c *Chain) Head() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tick()
+	return c.head()
+}
+
+func (c *Chain) head() int {
+	return c.opts.Start + len(c.blocks) - 1
+}
+
+// Block returns block number on the current chain.
+func (c *Chain) Block(number int) (rpc.Block, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tick()
+	b := c.block(number)
+	if b == nil {
+		return rpc.Block{}, false
+	}
+	return b.rpcBlock(true), true
+}
+
+func (c *Chain) block(number int) *block {
+	i := number - c.opts.Start
+	if i < 0 || i >= len(c.blocks) {
+		return nil
+	}
+	return &c.blocks[i]
+}
+
+// Mine mines n blocks now.
+func (c *Chain) Mine(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tick()
+	c.mine(n)
+}
+
+// Include adds tx to the next mined block and returns its hash, which is
+// generated unless tx has one.
+func (c *Chain) Include(tx rpc.Transaction) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if tx.Hash == "" {
+		tx.Hash = c.nextHash()
+	}
+	c.pending = append(c.pending, tx)
+	return tx.Hash
+}
+
+// Reorg replaces the latest depth blocks, but never the first one, with
+// newly mined blocks of the same numbers. Transactions added with Include
+// that were in the replaced blocks move to the first new block; generated
+// ones are generated again.
+func (c *Chain) Reorg(depth int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tick()
+	depth = min(depth, len(c.blocks)-1)
+	if depth <= 0 {
+		return
+	}
+	dropped := c.blocks[len(c.blocks)-depth:]
+	c.blocks = c.blocks[:len(c.blocks)-depth]
+	var included []rpc.Transaction
+	for _, b := range dropped {
+		for _, tx := range b.txs {
+			if !tx.generated {
+				tx.BlockNumber = ""
+				included = append(included, tx.Transaction)
+			}
+		}
+	}
+	c.pending = append(included, c.pending...)
+	c.mine(depth)
+}
+
+// FailNext fails the next n calls of the JSON-RPC method, e.g.
+// "eth_getBlockByNumber", with err, or ErrInjected if err is nil. An empty
+// method fails calls of any method.
+func (c *Chain) FailNext(method string, n int, err error) {
+	if err == nil {
+		err = ErrInjected
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fail[method] = &failure{n: n, err: err}
+}
+
+// Calls returns how many times the JSON-RPC method was called, including
+// failed calls.
+func (c *Chain) Calls(method string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls[method]
+}
+
+// tick mines the blocks due by the clock.
+func (c *Chain) tick() {
+	if c.opts.BlockTime <= 0 {
+		return
+	}
+	due := int(c.opts.Now().Sub(c.started) / c.opts.BlockTime)
+	if due > c.ticks {
+		c.mine(due - c.ticks)
+		c.ticks = due
+	}
+}
+
+// mine appends n blocks, the first holding the pending transactions.
+func (c *Chain) mine(n int) {
+	interval := c.opts.BlockTime
+	if interval <= 0 {
+		interval = defaultBlockTime
+	}
+	for range n {
+		number := c.head() + 1
+		var txs []minedTx
+		for _, tx := range c.pending {
+			txs = append(txs, minedTx{Transaction: tx})
+		}
+		c.pending = nil
+		if c.opts.Generator != nil {
+			for _, tx := range c.opts.Generator(number) {
+				if tx.Hash == "" {
+					tx.Hash = c.nextHash()
+				}
+				txs = append(txs, minedTx{Transaction: tx, generated: true})
+			}
+		}
+		hexNumber := fmt.Sprintf("0x%x", number)
+		for i := range txs {
+			txs[i].BlockNumber = hexNumber
+		}
+		at := c.opts.Genesis.Add(time.Duration(number-c.opts.Start) * interval)
+		parent := fmt.Sprintf("0x%064x", 0)
+		if len(c.blocks) > 0 {
+			parent = c.blocks[len(c.blocks)-1].hash
+		}
+		c.mined++
+		c.blocks = append(c.blocks, block{
+			number:     hexNumber,
+			timestamp:  fmt.Sprintf("0x%x", at.Unix()),
+			hash:       fmt.Sprintf("0xb%063x", c.mined),
+			parentHash: parent,
+			txs:        txs,
+		})
+	}
+}
+
+func (c *Chain) nextHash() string {
+	c.seq++
+	return fmt.Sprintf("0x%064x", c.seq)
+}
+
+// call records a call of method and returns the error injected into it, if
+// any. It also mines the blocks due by the clock.
+func (c *Chain) call(method string) error {
+	c.calls[method]++
+	c.tick()
+	for _, key := range []string{method, ""} {
+		if f := c.fail[key]; f != nil && f.n > 0 {
+			f.n--
+			return fmt.Errorf("%s: %w", method, f.err)
+		}
+	}
+	return nil
+}
+
+// Call serves eth_blockNumber, eth_getBlockByNumber,
+// eth_getTransactionByHash and eth_getTransactionReceipt.
+func (c *Chain) Call(ctx context.Context, method string, params []interface{}, result interface{}) error {
+	param := func(i int) string {
+		if i < len(params) {
+			s, _ := params[i].(string)
+			return s
+		}
+		return ""
+	}
+	var (
+		v   interface{}
+		err error
+	)
+	switch method {
+	case "eth_blockNumber":
+		v, err = c.GetBlockNumber(ctx)
+	case "eth_getBlockByNumber":
+		full := len(params) > 1 && params[1] == true
+		v, err = c.GetBlockByNumber(ctx, param(0), full)
+	case "eth_getTransactionByHash":
+		v, err = c.GetTransactionByHash(ctx, param(0))
+	case "eth_getTransactionReceipt":
+		v, err = c.GetTransactionReceipt(ctx, param(0))
+	default:
+		return fmt.Errorf("chaintest: unsupported method %s", method)
+	}
+	if errors.Is(err, rpc.ErrNotFound) {
+		// Nodes answer null for unknown transactions.
+		v, err = nil, nil
+	}
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, result)
+}
+
+// GetBlockNumber returns the head as a hex string.
+func (c *Chain) GetBlockNumber(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("eth_blockNumber"); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("0x%x", c.head()), nil
+}
+
+// GetBlockByNumber returns a block by hex number or "latest".
+func (c *Chain) GetBlockByNumber(ctx context.Context, blockNumber string, includeTransactions bool) (*rpc.Block, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("eth_getBlockByNumber"); err != nil {
+		return nil, err
+	}
+	number := c.head()
+	if blockNumber != "latest" {
+		n, err := strconv.ParseInt(strings.TrimPrefix(blockNumber, "0x"), 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("chaintest: invalid block number %q", blockNumber)
+		}
+		number = int(n)
+	}
+	return c.copyBlock(number, includeTransactions), nil
+}
+
+// GetBlockByNumberInt returns a block. Like a node, it returns an empty
+// block for blocks that haven't been mined.
+func (c *Chain) GetBlockByNumberInt(ctx context.Context, blockNumber int, includeTransactions bool) (*rpc.Block, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("eth_getBlockByNumber"); err != nil {
+		return nil, err
+	}
+	return c.copyBlock(blockNumber, includeTransactions), nil
+}
+
+func (c *Chain) copyBlock(number int, includeTransactions bool) *rpc.Block {
+	b := c.block(number)
+	if b == nil {
+		return &rpc.Block{}
+	}
+	out := b.rpcBlock(includeTransactions)
+	return &out
+}
+
+// GetTransactionByHash returns a transaction mined on the current chain.
+func (c *Chain) GetTransactionByHash(ctx context.Context, hash string) (*rpc.Transaction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("eth_getTransactionByHash"); err != nil {
+		return nil, err
+	}
+	tx := c.find(hash)
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %s: %w", hash, rpc.ErrNotFound)
+	}
+	out := *tx
+	return &out, nil
+}
+
+// GetTransactionReceipt returns a successful receipt for a plain transfer
+// mined on the current chain.
+func (c *Chain) GetTransactionReceipt(ctx context.Context, hash string) (*rpc.Receipt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.call("eth_getTransactionReceipt"); err != nil {
+		return nil, err
+	}
+	tx := c.find(hash)
+	if tx == nil {
+		return nil, fmt.Errorf("receipt of %s: %w", hash, rpc.ErrNotFound)
+	}
+	return &rpc.Receipt{
+		TransactionHash:   tx.Hash,
+		Status:            "0x1",
+		GasUsed:           "0x5208",
+		EffectiveGasPrice: tx.GasPrice,
+	}, nil
+}
+
+func (c *Chain) find(hash string) *rpc.Transaction {
+	for i := range c.blocks {
+		for j := range c.blocks[i].txs {
+			if tx := &c.blocks[i].txs[j].Transaction; tx.Hash == hash {
+				return tx
+			}
+		}
+	}
+	return nil
+}
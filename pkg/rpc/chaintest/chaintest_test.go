@@ -0,0 +1,157 @@
+package chaintest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/storage"
+	"github.com/danieloluwadare/tw-txparser/pkg/parser"
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+)
+
+const (
+	alice = "0x00000000000000000000000000000000000a11ce"
+	bob   = "0x0000000000000000000000000000000000000b0b"
+	carol = "0x00000000000000000000000000000000000ca201"
+)
+
+// fakeClock is a clock advanced by hand.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestChain_BlockTime(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1_700_000_000, 0)}
+	c := New(Options{Start: 100, BlockTime: 2 * time.Second, Now: clock.Now})
+	ctx := context.Background()
+
+	if head, _ := c.GetBlockNumber(ctx); head != "0x64" {
+		t.Fatalf("Expected head 0x64, got %s", head)
+	}
+	clock.Advance(5 * time.Second)
+	if head := c.Head(); head != 102 {
+		t.Fatalf("Expected two blocks to be mined, got head %d", head)
+	}
+	b, _ := c.GetBlockByNumberInt(ctx, 102, false)
+	first, _ := c.GetBlockByNumberInt(ctx, 100, false)
+	if b.Number != "0x66" || b.Timestamp == first.Timestamp {
+		t.Errorf("Unexpected block %+v after %+v", b, first)
+	}
+	if b, _ := c.GetBlockByNumberInt(ctx, 103, true); b.Number != "" {
+		t.Errorf("Expected an empty block beyond the head, got %+v", b)
+	}
+}
+
+func TestChain_IncludeAndReorg(t *testing.T) {
+	c := New(Options{Generator: Transfers(1, []string{alice, bob, carol}, 3)})
+	ctx := context.Background()
+
+	hash := c.Include(rpc.Transaction{From: alice, To: bob, Value: "0x10"})
+	c.Mine(2)
+	if c.Head() != 3 {
+		t.Fatalf("Expected head 3, got %d", c.Head())
+	}
+	b, _ := c.GetBlockByNumber(ctx, "0x2", true)
+	if len(b.Transactions) != 4 || b.Transactions[0].Hash != hash {
+		t.Fatalf("Expected the included transaction first among 4, got %+v", b.Transactions)
+	}
+	generated := b.Transactions[1].Hash
+	replaced := b.Hash
+	if parent, _ := c.GetBlockByNumber(ctx, "0x1", false); b.ParentHash != parent.Hash {
+		t.Errorf("Expected block 2 to link to block 1 %s, got %s", parent.Hash, b.ParentHash)
+	}
+
+	c.Reorg(2)
+	if c.Head() != 3 {
+		t.Errorf("Expected a reorg to keep the head at 3, got %d", c.Head())
+	}
+	b, _ = c.GetBlockByNumber(ctx, "0x2", true)
+	if b.Hash == replaced {
+		t.Errorf("Expected the new block 2 to have a new hash, got %s", b.Hash)
+	}
+	if next, _ := c.GetBlockByNumber(ctx, "0x3", false); next.ParentHash != b.Hash {
+		t.Errorf("Expected block 3 to link to the new block 2 %s, got %s", b.Hash, next.ParentHash)
+	}
+	if len(b.Transactions) != 4 || b.Transactions[0].Hash != hash || b.Transactions[1].Hash == generated {
+		t.Errorf("Expected the included transaction to be mined again with new transfers, got %+v", b.Transactions)
+	}
+	if _, err := c.GetTransactionByHash(ctx, generated); !errors.Is(err, rpc.ErrNotFound) {
+		t.Errorf("Expected the reorged-out transaction to be gone, got %v", err)
+	}
+	tx, err := c.GetTransactionByHash(ctx, hash)
+	if err != nil || tx.BlockNumber != "0x2" {
+		t.Errorf("Expected %s in block 0x2, got %+v %v", hash, tx, err)
+	}
+	if r, err := c.GetTransactionReceipt(ctx, hash); err != nil || r.Status != "0x1" {
+		t.Errorf("Expected a successful receipt, got %+v %v", r, err)
+	}
+
+	var viaCall *rpc.Transaction
+	if err := c.Call(ctx, "eth_getTransactionByHash", []interface{}{generated}, &viaCall); err != nil || viaCall != nil {
+		t.Errorf("Expected null for an unknown transaction, got %+v %v", viaCall, err)
+	}
+}
+
+func TestChain_FailNext(t *testing.T) {
+	c := New(Options{})
+	ctx := context.Background()
+	boom := errors.New("boom")
+
+	c.FailNext("eth_getBlockByNumber", 2, boom)
+	for range 2 {
+		if _, err := c.GetBlockByNumberInt(ctx, 1, true); !errors.Is(err, boom) {
+			t.Errorf("Expected the injected error, got %v", err)
+		}
+	}
+	if _, err := c.GetBlockByNumberInt(ctx, 1, true); err != nil {
+		t.Errorf("Expected the third call to succeed, got %v", err)
+	}
+	if _, err := c.GetBlockNumber(ctx); err != nil {
+		t.Errorf("Expected other methods to succeed, got %v", err)
+	}
+
+	c.FailNext("", 1, nil)
+	if _, err := c.GetBlockNumber(ctx); !errors.Is(err, ErrInjected) {
+		t.Errorf("Expected ErrInjected, got %v", err)
+	}
+	if n := c.Calls("eth_getBlockByNumber"); n != 3 {
+		t.Errorf("Expected 3 block calls, got %d", n)
+	}
+}
+
+func TestChain_Parser(t *testing.T) {
+	c := New(Options{Generator: Transfers(7, []string{bob, carol}, 5)})
+	hash := c.Include(rpc.Transaction{From: bob, To: alice, Value: "0xde0b6b3a7640000"})
+	c.Mine(9)
+
+	store := storage.NewMemoryStorage()
+	store.Subscribe(alice)
+	p := parser.NewParserWithInterval(c, store, time.Second, parser.Options{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err := p.(parser.RangeScanner).ScanRange(context.Background(), 1, c.Head()); err != nil {
+		t.Fatalf("ScanRange failed: %v", err)
+	}
+	txs := p.GetTransactions(alice)
+	if len(txs) != 1 || txs[0].Hash != hash || txs[0].Block != 2 {
+		t.Errorf("Expected the payment to alice in block 2, got %+v", txs)
+	}
+}
@@ -2,65 +2,164 @@
 package rpc
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+	"github.com/danieloluwadare/tw-txparser/pkg/metrics"
 )
 
+// tracer creates a client span per JSON-RPC call.
+var tracer = otel.Tracer("github.com/danieloluwadare/tw-txparser/pkg/rpc")
+
 // Client is a simple JSON-RPC HTTP client.
 type Client struct {
 	endpoint   string
 	httpClient *http.Client
+	metrics    metrics.Recorder
+}
+
+// ClientOptions configures optional Client behavior.
+type ClientOptions struct {
+	// Metrics records call counts and latency per method. Defaults to metrics.Nop.
+	Metrics metrics.Recorder
+	// Transport configures the client's HTTP connections.
+	Transport Transport
 }
 
 // NewClient creates a Client targeting the given RPC endpoint URL.
 func NewClient(endpoint string) *Client {
+	return NewClientWithOptions(endpoint, ClientOptions{})
+}
+
+// NewClientWithOptions creates a Client with the provided options.
+func NewClientWithOptions(endpoint string, opts ClientOptions) *Client {
 	return &Client{
 		endpoint: endpoint,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: opts.Transport.RoundTripper(),
 		},
+		metrics: metrics.OrNop(opts.Metrics),
 	}
 }
 
 // Call performs a JSON-RPC request and unmarshals the result into result.
+// Each call is traced as a client span and the trace context is forwarded
+// to the node in the request headers.
 func (c *Client) Call(ctx context.Context, method string, params []interface{}, result interface{}) error {
-	req := JSONRPCRequest{JSONRPC: "2.0", Method: method, Params: params, ID: 1}
-	body, err := json.Marshal(req)
-	if err != nil {
+	return c.call(ctx, method, JSONRPCRequest{JSONRPC: "2.0", Method: method, Params: params, ID: 1}, func(body io.Reader) error {
+		// The response is read into a pooled buffer; unmarshaling copies
+		// what it keeps, so the buffer can be reused once decoding is done.
+		buf := getBuffer()
+		defer putBuffer(buf)
+		if _, err := buf.ReadFrom(body); err != nil {
+			return fmt.Errorf("failed to read JSON-RPC response for method %s: %w", method, err)
+		}
+		var rpcResp JSONRPCResponse
+		if err := json.Unmarshal(buf.Bytes(), &rpcResp); err != nil {
+			return fmt.Errorf("failed to decode JSON-RPC response for method %s: %w", method, err)
+		}
+		if rpcResp.Error != nil {
+			return rpcError(method, rpcResp.Error)
+		}
+		if err := json.Unmarshal(rpcResp.Result, result); err != nil {
+			return fmt.Errorf("failed to unmarshal result for method %s: %w", method, err)
+		}
+		return nil
+	})
+}
+
+// call sends req, a JSON-RPC request or batch of method calls, and hands
+// the response body to decode. It records the span and metrics of the
+// whole exchange, including decoding.
+//
+// When ctx carries a request ID (see logging.WithRequestID), it is forwarded
+// to the node as X-Request-ID, and a failed call is logged with it and
+// returns an error naming it, so an API failure can be matched with the
+// upstream calls it triggered.
+func (c *Client) call(ctx context.Context, method string, req interface{}, decode func(io.Reader) error) (err error) {
+	ctx, span := tracer.Start(ctx, method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("rpc.system", "jsonrpc"),
+		attribute.String("rpc.method", method),
+	))
+	start := time.Now()
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			err = c.failed(ctx, method, err)
+		}
+		span.End()
+		c.metrics.Add(metrics.RPCRequests, 1, metrics.L("method", method), metrics.Result(err))
+		metrics.ObserveSince(c.metrics, metrics.RPCDuration, start, metrics.L("method", method))
+	}()
+
+	buf := getBuffer()
+	if err := json.NewEncoder(buf).Encode(req); err != nil {
+		putBuffer(buf)
 		return fmt.Errorf("failed to marshal JSON-RPC request: %w", err)
 	}
+	body := newPooledBody(buf)
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.endpoint, bytes.NewReader(body))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.endpoint, body)
 	if err != nil {
+		body.Close()
 		return fmt.Errorf("failed to create HTTP request: %w", err)
 	}
+	// The body's type hides its length from NewRequest. It is left without
+	// GetBody, as replaying it could read a buffer already back in the pool.
+	httpReq.ContentLength = int64(buf.Len())
 	httpReq.Header.Set("Content-Type", "application/json")
+	if id := logging.RequestID(ctx); id != "" {
+		httpReq.Header.Set("X-Request-ID", id)
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
 
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
 		return fmt.Errorf("RPC call failed for method %s: %w", method, err)
 	}
 	defer resp.Body.Close()
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
 
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("RPC call failed with status %d for method %s", resp.StatusCode, method)
 	}
+	return decode(resp.Body)
+}
 
-	var rpcResp JSONRPCResponse
-	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
-		return fmt.Errorf("failed to decode JSON-RPC response for method %s: %w", method, err)
-	}
-	if rpcResp.Error != nil {
-		return fmt.Errorf("RPC error for method %s (code %d): %s", method, rpcResp.Error.Code, rpcResp.Error.Message)
+// failed logs the failure of a call on behalf of an API request and tags
+// err with the request's ID. Failed calls made by the poller are left to
+// the parser to report, and are only logged at debug level.
+func (c *Client) failed(ctx context.Context, method string, err error) error {
+	id := logging.RequestID(ctx)
+	level := slog.LevelDebug
+	if id != "" && ctx.Err() == nil {
+		level = slog.LevelWarn
 	}
-	if err := json.Unmarshal(rpcResp.Result, result); err != nil {
-		return fmt.Errorf("failed to unmarshal result for method %s: %w", method, err)
+	logging.FromContext(ctx, logging.Component("rpc")).Log(ctx, level, "RPC call failed",
+		"method", method, logging.KeyError, err)
+	if id == "" {
+		return err
 	}
-	return nil
+	return fmt.Errorf("%w (request %s)", err, id)
+}
+
+// rpcError wraps an error object returned by the node.
+func rpcError(method string, e *RPCError) error {
+	return fmt.Errorf("RPC error for method %s (code %d): %w", method, e.Code, e)
 }
 
 // GetBlockNumber returns the latest block number as a hex string.
@@ -91,3 +190,119 @@ func (c *Client) GetBlockByNumberInt(ctx context.Context, blockNumber int, inclu
 	hexBlockNumber := fmt.Sprintf("0x%x", blockNumber)
 	return c.GetBlockByNumber(ctx, hexBlockNumber, includeTransactions)
 }
+
+// GetTransactionByHash returns the transaction with the given hash.
+// ErrNotFound is returned if the node does not know the transaction.
+func (c *Client) GetTransactionByHash(ctx context.Context, hash string) (*Transaction, error) {
+	var tx *Transaction
+	err := c.Call(ctx, "eth_getTransactionByHash", []interface{}{hash}, &tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction %s: %w", hash, err)
+	}
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %s: %w", hash, ErrNotFound)
+	}
+	return tx, nil
+}
+
+// GetBalance returns the balance of address in wei as of the end of the
+// given block, as a hex quantity.
+func (c *Client) GetBalance(ctx context.Context, address string, blockNumber int) (string, error) {
+	var balance string
+	err := c.Call(ctx, "eth_getBalance", []interface{}{address, fmt.Sprintf("0x%x", blockNumber)}, &balance)
+	if err != nil {
+		return "", fmt.Errorf("failed to get balance of %s: %w", address, err)
+	}
+	return balance, nil
+}
+
+// GetTransactionReceipt returns the receipt of a mined transaction.
+// ErrNotFound is returned if the node has no receipt for it.
+func (c *Client) GetTransactionReceipt(ctx context.Context, hash string) (*Receipt, error) {
+	var r *Receipt
+	err := c.Call(ctx, "eth_getTransactionReceipt", []interface{}{hash}, &r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receipt of %s: %w", hash, err)
+	}
+	if r == nil {
+		return nil, fmt.Errorf("receipt of %s: %w", hash, ErrNotFound)
+	}
+	return r, nil
+}
+
+// GetTransactionReceipts returns the receipts of hashes, fetched in one
+// JSON-RPC batch request. The result has an entry per hash, nil where the
+// node has no receipt or answered with an error; only a failure of the
+// whole batch is returned as an error.
+func (c *Client) GetTransactionReceipts(ctx context.Context, hashes []string) ([]*Receipt, error) {
+	const method = "eth_getTransactionReceipt"
+	out := make([]*Receipt, len(hashes))
+	if len(hashes) == 0 {
+		return out, nil
+	}
+	reqs := make([]JSONRPCRequest, len(hashes))
+	for i, hash := range hashes {
+		reqs[i] = JSONRPCRequest{JSONRPC: "2.0", Method: method, Params: []interface{}{hash}, ID: i}
+	}
+	err := c.call(ctx, method, reqs, func(body io.Reader) error {
+		var resps []JSONRPCResponse
+		if err := json.NewDecoder(body).Decode(&resps); err != nil {
+			return fmt.Errorf("failed to decode JSON-RPC batch response for method %s: %w", method, err)
+		}
+		for _, resp := range resps {
+			if resp.ID < 0 || resp.ID >= len(out) || resp.Error != nil {
+				continue
+			}
+			if err := json.Unmarshal(resp.Result, &out[resp.ID]); err != nil {
+				return fmt.Errorf("failed to unmarshal result for method %s: %w", method, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %d receipts: %w", len(hashes), err)
+	}
+	return out, nil
+}
+
+// GetFeeHistory returns the fee history of the latest blocks, with the
+// priority fees paid at each of percentiles.
+func (c *Client) GetFeeHistory(ctx context.Context, blocks int, percentiles []float64) (*FeeHistory, error) {
+	var h FeeHistory
+	err := c.Call(ctx, "eth_feeHistory", []interface{}{fmt.Sprintf("0x%x", blocks), "latest", percentiles}, &h)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fee history: %w", err)
+	}
+	return &h, nil
+}
+
+// GetLogs returns the logs matching filter.
+func (c *Client) GetLogs(ctx context.Context, filter LogFilter) ([]Log, error) {
+	params := map[string]interface{}{
+		"fromBlock": fmt.Sprintf("0x%x", filter.FromBlock),
+		"toBlock":   fmt.Sprintf("0x%x", filter.ToBlock),
+	}
+	addFilterParams(params, filter)
+	var logs []Log
+	if err := c.Call(ctx, "eth_getLogs", []interface{}{params}, &logs); err != nil {
+		return nil, fmt.Errorf("failed to get logs of blocks %d-%d: %w", filter.FromBlock, filter.ToBlock, err)
+	}
+	return logs, nil
+}
+
+// addFilterParams adds the addresses and topics of filter to the params of
+// a log filter.
+func addFilterParams(params map[string]interface{}, filter LogFilter) {
+	if len(filter.Addresses) > 0 {
+		params["address"] = filter.Addresses
+	}
+	if len(filter.Topics) > 0 {
+		topics := make([]interface{}, len(filter.Topics))
+		for i, t := range filter.Topics {
+			if len(t) > 0 {
+				topics[i] = t
+			}
+		}
+		params["topics"] = topics
+	}
+}
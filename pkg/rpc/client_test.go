@@ -3,9 +3,20 @@ package rpc
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
 )
 
 func TestClient_Call(t *testing.T) {
@@ -145,6 +156,32 @@ func TestClient_Call_NetworkError(t *testing.T) {
 	}
 }
 
+func TestClient_Call_RequestID(t *testing.T) {
+	var forwarded string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r.Header.Get("X-Request-ID")
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	client := NewClient(server.URL)
+
+	var result string
+	err := client.Call(logging.WithRequestID(context.Background(), "req-42"), "eth_blockNumber", []interface{}{}, &result)
+	if forwarded != "req-42" {
+		t.Errorf("Expected the request ID to be forwarded, got %q", forwarded)
+	}
+	expectedError := "RPC call failed with status 502 for method eth_blockNumber (request req-42)"
+	if err == nil || err.Error() != expectedError {
+		t.Errorf("Expected '%s', got %v", expectedError, err)
+	}
+
+	// Calls made outside an API request are left untagged.
+	err = client.Call(context.Background(), "eth_blockNumber", []interface{}{}, &result)
+	if err == nil || strings.Contains(err.Error(), "request") || forwarded != "" {
+		t.Errorf("Expected an untagged error and no forwarded ID, got %v (%q)", err, forwarded)
+	}
+}
+
 func TestClient_Call_InvalidJSON(t *testing.T) {
 	// Create a mock server that returns invalid JSON
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -318,3 +355,245 @@ func TestClient_GetBlockByNumberInt(t *testing.T) {
 		t.Errorf("Expected block number 0x1234, got %s", block.Number)
 	}
 }
+
+func TestClient_GetTransactionByHash(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req JSONRPCRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		if req.Params[0] == "0xunknown" {
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":null}`))
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"hash":"0xhash1","from":"0xfrom1","to":"0xto1","value":"0x1000","blockNumber":"0x1234"}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+
+	tx, err := client.GetTransactionByHash(context.Background(), "0xhash1")
+	if err != nil {
+		t.Fatalf("GetTransactionByHash failed: %v", err)
+	}
+	if tx.Hash != "0xhash1" || tx.BlockNumber != "0x1234" {
+		t.Errorf("Unexpected transaction: %+v", tx)
+	}
+
+	_, err = client.GetTransactionByHash(context.Background(), "0xunknown")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestClient_GetTransactionReceipt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req JSONRPCRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Content-Type", "application/json")
+		if req.Method != "eth_getTransactionReceipt" || req.Params[0] == "0xpending" {
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":null}`))
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"transactionHash":"0xhash1","status":"0x1","gasUsed":"0x5208","effectiveGasPrice":"0x3b9aca00"}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+
+	r, err := client.GetTransactionReceipt(context.Background(), "0xhash1")
+	if err != nil {
+		t.Fatalf("GetTransactionReceipt failed: %v", err)
+	}
+	if r.GasUsed != "0x5208" || r.EffectiveGasPrice != "0x3b9aca00" || r.Status != "0x1" {
+		t.Errorf("Unexpected receipt: %+v", r)
+	}
+
+	_, err = client.GetTransactionReceipt(context.Background(), "0xpending")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestClient_GetBalance(t *testing.T) {
+	var params []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req JSONRPCRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Method != "eth_getBalance" {
+			t.Errorf("Unexpected method %s", req.Method)
+		}
+		params = req.Params
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0xde0b6b3a7640000"}`))
+	}))
+	defer server.Close()
+
+	balance, err := NewClient(server.URL).GetBalance(context.Background(), "0xaaa", 0x1234)
+	if err != nil {
+		t.Fatalf("GetBalance failed: %v", err)
+	}
+	if balance != "0xde0b6b3a7640000" {
+		t.Errorf("Unexpected balance %s", balance)
+	}
+	if len(params) != 2 || params[0] != "0xaaa" || params[1] != "0x1234" {
+		t.Errorf("Unexpected params %v", params)
+	}
+}
+
+func TestClient_GetLogs(t *testing.T) {
+	var filter map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req JSONRPCRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Method != "eth_getLogs" {
+			t.Errorf("Unexpected method %s", req.Method)
+		}
+		filter, _ = req.Params[0].(map[string]interface{})
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":[{"address":"0xtoken","topics":["0xddf2","0xfrom","0xto"],"data":"0x01","blockNumber":"0x1234","transactionHash":"0xhash1","logIndex":"0x3"}]}`))
+	}))
+	defer server.Close()
+
+	logs, err := NewClient(server.URL).GetLogs(context.Background(), LogFilter{
+		FromBlock: 0x1234,
+		ToBlock:   0x1234,
+		Topics:    [][]string{{"0xddf2"}, nil, {"0xto"}},
+	})
+	if err != nil {
+		t.Fatalf("GetLogs failed: %v", err)
+	}
+	if len(logs) != 1 || logs[0].Address != "0xtoken" || len(logs[0].Topics) != 3 || logs[0].LogIndex != "0x3" {
+		t.Errorf("Unexpected logs %+v", logs)
+	}
+	if filter["fromBlock"] != "0x1234" || filter["toBlock"] != "0x1234" || filter["address"] != nil {
+		t.Errorf("Unexpected filter %v", filter)
+	}
+	topics, _ := filter["topics"].([]interface{})
+	if len(topics) != 3 || topics[1] != nil {
+		t.Errorf("Expected a null wildcard topic, got %v", filter["topics"])
+	}
+}
+
+func TestClient_GetFeeHistory(t *testing.T) {
+	var params []interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req JSONRPCRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Method != "eth_feeHistory" {
+			t.Errorf("Unexpected method %s", req.Method)
+		}
+		params = req.Params
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"oldestBlock":"0x1233","baseFeePerGas":["0x3b9aca00","0x3b9aca01","0x3b9aca02"],"gasUsedRatio":[0.5,0.6],"reward":[["0x1","0x2"],["0x3","0x4"]]}}`))
+	}))
+	defer server.Close()
+
+	h, err := NewClient(server.URL).GetFeeHistory(context.Background(), 2, []float64{10, 90})
+	if err != nil {
+		t.Fatalf("GetFeeHistory failed: %v", err)
+	}
+	if h.OldestBlock != "0x1233" || len(h.BaseFeePerGas) != 3 || len(h.GasUsedRatio) != 2 || h.Reward[1][1] != "0x4" {
+		t.Errorf("Unexpected fee history %+v", h)
+	}
+	if len(params) != 3 || params[0] != "0x2" || params[1] != "latest" {
+		t.Errorf("Unexpected params %v", params)
+	}
+}
+
+func TestClient_GetTransactionReceipts(t *testing.T) {
+	var reqs []JSONRPCRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&reqs)
+		// Out of order, with no receipt for the second hash and an error
+		// for the third.
+		w.Write([]byte(`[{"jsonrpc":"2.0","id":2,"error":{"code":-32000,"message":"boom"}},{"jsonrpc":"2.0","id":1,"result":null},{"jsonrpc":"2.0","id":0,"result":{"transactionHash":"0xa","status":"0x1","gasUsed":"0x5208"}}]`))
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL)
+	out, err := c.GetTransactionReceipts(context.Background(), []string{"0xa", "0xb", "0xc"})
+	if err != nil {
+		t.Fatalf("GetTransactionReceipts failed: %v", err)
+	}
+	if len(reqs) != 3 || reqs[1].Method != "eth_getTransactionReceipt" || reqs[1].Params[0] != "0xb" || reqs[1].ID != 1 {
+		t.Errorf("Unexpected batch %+v", reqs)
+	}
+	if len(out) != 3 || out[0] == nil || out[0].GasUsed != "0x5208" || out[1] != nil || out[2] != nil {
+		t.Errorf("Unexpected receipts %+v", out)
+	}
+
+	// A node rejecting the batch fails it as a whole.
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"batch too large"}}`))
+	})
+	if _, err := c.GetTransactionReceipts(context.Background(), []string{"0xa"}); err == nil {
+		t.Error("Expected an error for a rejected batch")
+	}
+}
+
+func TestClient_CallTracing(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		json.NewEncoder(w).Encode(JSONRPCResponse{JSONRPC: "2.0", ID: 1, Result: json.RawMessage(`"0x10"`)})
+	}))
+	defer server.Close()
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	if _, err := NewClient(server.URL).GetBlockNumber(ctx); err != nil {
+		t.Fatalf("GetBlockNumber failed: %v", err)
+	}
+	parent.End()
+
+	ended := spans.Ended()
+	if len(ended) != 2 || ended[0].Name() != "eth_blockNumber" {
+		t.Fatalf("Expected an eth_blockNumber span, got %d spans", len(ended))
+	}
+	call := ended[0]
+	if call.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("Expected the RPC span to be a child of the caller's span")
+	}
+	want := "00-" + call.SpanContext().TraceID().String() + "-" + call.SpanContext().SpanID().String() + "-01"
+	if traceparent != want {
+		t.Errorf("Expected traceparent %s, got %s", want, traceparent)
+	}
+}
+
+func TestClient_Call_Concurrent(t *testing.T) {
+	// Each call echoes its parameter, so a pooled buffer shared between
+	// calls would show up as a mismatched result.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req JSONRPCRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+			return
+		}
+		result, _ := json.Marshal(req.Params[0])
+		_ = json.NewEncoder(w).Encode(JSONRPCResponse{JSONRPC: "2.0", ID: 1, Result: result})
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			want := fmt.Sprintf("0x%x", i)
+			for range 10 {
+				var got string
+				if err := client.Call(context.Background(), "echo", []interface{}{want}, &got); err != nil {
+					t.Errorf("Call failed: %v", err)
+					return
+				}
+				if got != want {
+					t.Errorf("Expected %s, got %s", want, got)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,169 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/internal/logging"
+)
+
+// probeTimeout bounds each health probe of a provider.
+const probeTimeout = 5 * time.Second
+
+// ProviderStatus is the health of one provider of a Balancer.
+type ProviderStatus struct {
+	// Provider is the host of the provider's URL.
+	Provider string `json:"provider"`
+	// Healthy is false while the provider is out of rotation, with Reason
+	// saying why.
+	Healthy bool   `json:"healthy"`
+	Reason  string `json:"reason,omitempty"`
+	// Head, ChainID and Latency are from the last health check; Latency is
+	// that of its eth_blockNumber call.
+	Head      int       `json:"head,omitempty"`
+	ChainID   int64     `json:"chain_id,omitempty"`
+	LatencyMS int64     `json:"latency_ms,omitempty"`
+	CheckedAt time.Time `json:"checked_at,omitzero"`
+}
+
+// Start probes the providers every HealthInterval until ctx is cancelled,
+// starting right away.
+func (b *Balancer) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(b.interval)
+		defer ticker.Stop()
+		for {
+			b.CheckHealth(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// probe is the outcome of probing one provider.
+type probe struct {
+	head    int
+	chainID int64
+	latency time.Duration
+	err     error
+}
+
+// CheckHealth probes the head, chain ID and latency of every provider
+// concurrently and takes out of rotation those failing the probe, serving
+// another chain than most providers, or more than MaxLag blocks behind the
+// highest head. Healthy providers are put back.
+func (b *Balancer) CheckHealth(ctx context.Context) {
+	probes := make([]probe, len(b.providers))
+	var wg sync.WaitGroup
+	for i, p := range b.providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			probes[i] = probeProvider(ctx, p.client)
+		}()
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return
+	}
+
+	// The chain is the one most providers serve, ties going to the
+	// preferred ones.
+	votes := make(map[int64]int)
+	var chainID int64
+	for _, pr := range probes {
+		if pr.err != nil {
+			continue
+		}
+		votes[pr.chainID]++
+		if votes[pr.chainID] > votes[chainID] {
+			chainID = pr.chainID
+		}
+	}
+	best := 0
+	for _, pr := range probes {
+		if pr.err == nil && pr.chainID == chainID {
+			best = max(best, pr.head)
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	for i, p := range b.providers {
+		pr := probes[i]
+		status := ProviderStatus{Provider: p.name, CheckedAt: now.UTC()}
+		was := p.evicted
+		switch {
+		case pr.err != nil:
+			p.evicted = "probe failed: " + pr.err.Error()
+		case pr.chainID != chainID:
+			p.evicted = fmt.Sprintf("serves chain %d instead of %d", pr.chainID, chainID)
+		case best-pr.head > b.maxLag:
+			p.evicted = fmt.Sprintf("%d blocks behind", best-pr.head)
+		default:
+			p.evicted = ""
+		}
+		if pr.err == nil {
+			status.Head, status.ChainID, status.LatencyMS = pr.head, pr.chainID, pr.latency.Milliseconds()
+		}
+		if was == "" && p.evicted != "" {
+			logging.Component("rpc").Warn("evicted RPC provider", "provider", p.name, "reason", p.evicted)
+		} else if was != "" && p.evicted == "" {
+			logging.Component("rpc").Info("RPC provider back in rotation", "provider", p.name)
+		}
+		p.health = status
+		p.health.Reason = p.evicted
+	}
+}
+
+// probeProvider fetches the head and chain ID of a provider.
+func probeProvider(ctx context.Context, c *Client) probe {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	start := time.Now()
+	head, err := c.GetBlockNumber(ctx)
+	if err != nil {
+		return probe{err: err}
+	}
+	latency := time.Since(start)
+	var id string
+	if err := c.Call(ctx, "eth_chainId", []interface{}{}, &id); err != nil {
+		return probe{err: fmt.Errorf("failed to get chain ID: %w", err)}
+	}
+	n, err := strconv.ParseInt(strings.TrimPrefix(head, "0x"), 16, 64)
+	if err != nil {
+		return probe{err: fmt.Errorf("invalid block number %q", head)}
+	}
+	chainID, err := strconv.ParseInt(strings.TrimPrefix(id, "0x"), 16, 64)
+	if err != nil {
+		return probe{err: fmt.Errorf("invalid chain ID %q", id)}
+	}
+	return probe{head: int(n), chainID: chainID, latency: latency}
+}
+
+// Providers reports the health of every provider, in order of preference.
+// Providers cooling down after a failed call are reported unhealthy too.
+func (b *Balancer) Providers() []ProviderStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	out := make([]ProviderStatus, len(b.providers))
+	for i, p := range b.providers {
+		s := p.health
+		s.Provider = p.name
+		if s.Reason == "" && now.Before(p.downUntil) {
+			s.Reason = "cooling down after a failed call"
+		}
+		s.Healthy = s.Reason == ""
+		out[i] = s
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeNode answers eth_blockNumber and eth_chainId with its head and chain
+// ID, counting the eth_blockNumber calls.
+type fakeNode struct {
+	*httptest.Server
+	head    atomic.Int64
+	chainID int64
+	calls   atomic.Int32
+}
+
+func newFakeNode(t *testing.T, head, chainID int64) *fakeNode {
+	n := &fakeNode{chainID: chainID}
+	n.head.Store(head)
+	n.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req JSONRPCRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		result := fmt.Sprintf("0x%x", n.chainID)
+		if req.Method == "eth_blockNumber" {
+			n.calls.Add(1)
+			result = fmt.Sprintf("0x%x", n.head.Load())
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":%q}`, result)
+	}))
+	t.Cleanup(n.Close)
+	return n
+}
+
+func TestBalancer_CheckHealth(t *testing.T) {
+	ok := newFakeNode(t, 100, 1)
+	lagging := newFakeNode(t, 90, 1)
+	wrongChain := newFakeNode(t, 100, 5)
+	down := newFakeNode(t, 100, 1)
+	down.Close()
+	bal, err := NewBalancer([]Endpoint{{URL: lagging.URL}, {URL: ok.URL}, {URL: wrongChain.URL}, {URL: down.URL}}, BalancerOptions{Strategy: StrategyRoundRobin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bal.CheckHealth(context.Background())
+
+	status := bal.Providers()
+	if len(status) != 4 {
+		t.Fatalf("Expected 4 providers, got %+v", status)
+	}
+	if s := status[0]; s.Healthy || s.Reason != "10 blocks behind" || s.Head != 90 {
+		t.Errorf("Expected the lagging provider to be evicted, got %+v", s)
+	}
+	if s := status[1]; !s.Healthy || s.Head != 100 || s.ChainID != 1 || s.CheckedAt.IsZero() {
+		t.Errorf("Expected a healthy provider, got %+v", s)
+	}
+	if s := status[2]; s.Healthy || !strings.Contains(s.Reason, "chain 5") {
+		t.Errorf("Expected the wrong-chain provider to be evicted, got %+v", s)
+	}
+	if s := status[3]; s.Healthy || !strings.Contains(s.Reason, "probe failed") {
+		t.Errorf("Expected the unreachable provider to be evicted, got %+v", s)
+	}
+
+	before := lagging.calls.Load()
+	for i := 0; i < 3; i++ {
+		bal.GetBlockNumber(context.Background())
+	}
+	if lagging.calls.Load() != before || wrongChain.calls.Load() != 1 {
+		t.Error("Expected evicted providers to get no calls")
+	}
+
+	lagging.head.Store(98)
+	bal.CheckHealth(context.Background())
+	if s := bal.Providers()[0]; !s.Healthy {
+		t.Errorf("Expected the provider to be back once caught up, got %+v", s)
+	}
+}
@@ -4,8 +4,12 @@ package rpc
 import (
 	"context"
 	"encoding/json"
+	"errors"
 )
 
+// ErrNotFound is returned when the node has no record of the requested object.
+var ErrNotFound = errors.New("not found")
+
 // RPCClient abstracts a JSON-RPC caller.
 type RPCClient interface {
 	Call(ctx context.Context, method string, params []interface{}, result interface{}) error
@@ -13,6 +17,7 @@ type RPCClient interface {
 	GetBlockNumber(ctx context.Context) (string, error)
 	GetBlockByNumber(ctx context.Context, blockNumber string, includeTransactions bool) (*Block, error)
 	GetBlockByNumberInt(ctx context.Context, blockNumber int, includeTransactions bool) (*Block, error)
+	GetTransactionByHash(ctx context.Context, hash string) (*Transaction, error)
 }
 
 // JSONRPCRequest is the wire format for requests.
@@ -44,14 +49,103 @@ func (e *RPCError) Error() string {
 
 // Block describes an Ethereum block with basic fields used by this app.
 type Block struct {
-	Number       string        `json:"number"`
+	Number string `json:"number"`
+	// Timestamp is the block's Unix time in seconds, as a hex string.
+	Timestamp string `json:"timestamp,omitempty"`
+	// Hash and ParentHash link the block to its parent, revealing
+	// reorganizations.
+	Hash         string        `json:"hash,omitempty"`
+	ParentHash   string        `json:"parentHash,omitempty"`
 	Transactions []Transaction `json:"transactions"`
 }
 
 // Transaction describes an Ethereum transaction in RPC responses.
 type Transaction struct {
-	Hash  string `json:"hash"`
-	From  string `json:"from"`
-	To    string `json:"to"`
-	Value string `json:"value"`
+	Hash        string `json:"hash"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Value       string `json:"value"`
+	BlockNumber string `json:"blockNumber,omitempty"` // null while pending
+	GasPrice    string `json:"gasPrice,omitempty"`
+	// Input is the call data; contract creations carry the init code.
+	Input string `json:"input,omitempty"`
+}
+
+// Receipt describes the outcome of an executed transaction.
+type Receipt struct {
+	TransactionHash string `json:"transactionHash"`
+	Status          string `json:"status"` // 0x1 on success, 0x0 if reverted
+	GasUsed         string `json:"gasUsed"`
+	// EffectiveGasPrice is the price per gas actually paid. Nodes that
+	// predate EIP-1559 omit it; the transaction's gasPrice applies then.
+	EffectiveGasPrice string `json:"effectiveGasPrice,omitempty"`
+}
+
+// ReceiptFetcher is implemented by clients that can fetch transaction
+// receipts; *Client does.
+type ReceiptFetcher interface {
+	GetTransactionReceipt(ctx context.Context, hash string) (*Receipt, error)
+}
+
+// ReceiptBatchFetcher is implemented by clients that can fetch several
+// receipts in one JSON-RPC batch request; *Client does.
+type ReceiptBatchFetcher interface {
+	// GetTransactionReceipts returns one receipt per hash, nil for those
+	// the node has no receipt for or failed to look up.
+	GetTransactionReceipts(ctx context.Context, hashes []string) ([]*Receipt, error)
+}
+
+// BalanceFetcher is implemented by clients that can fetch account balances;
+// *Client does.
+type BalanceFetcher interface {
+	GetBalance(ctx context.Context, address string, blockNumber int) (string, error)
+}
+
+// Log is an event emitted by a contract, as returned by eth_getLogs.
+type Log struct {
+	Address         string   `json:"address"`
+	Topics          []string `json:"topics"`
+	Data            string   `json:"data"`
+	BlockNumber     string   `json:"blockNumber"`
+	TransactionHash string   `json:"transactionHash"`
+	LogIndex        string   `json:"logIndex"`
+	// Removed is set for logs dropped by a reorg.
+	Removed bool `json:"removed,omitempty"`
+}
+
+// LogFilter selects the logs of blocks FromBlock..ToBlock (inclusive).
+// Addresses, if any, restricts them to these contracts. Topics matches by
+// position, each position being any of the listed topics; an empty position
+// matches anything.
+type LogFilter struct {
+	FromBlock int
+	ToBlock   int
+	Addresses []string
+	Topics    [][]string
+}
+
+// LogFetcher is implemented by clients that can fetch logs; *Client does.
+type LogFetcher interface {
+	GetLogs(ctx context.Context, filter LogFilter) ([]Log, error)
+}
+
+// FeeHistory is the fee market history of a range of blocks, as returned
+// by eth_feeHistory.
+type FeeHistory struct {
+	// OldestBlock is the first block of the range.
+	OldestBlock string `json:"oldestBlock"`
+	// BaseFeePerGas holds the base fee of each block, followed by that of
+	// the block after the range.
+	BaseFeePerGas []string `json:"baseFeePerGas"`
+	// GasUsedRatio holds the fraction of each block's gas limit used.
+	GasUsedRatio []float64 `json:"gasUsedRatio"`
+	// Reward holds, per block, the priority fee at each requested
+	// percentile of the block's transactions, weighted by gas used.
+	Reward [][]string `json:"reward,omitempty"`
+}
+
+// FeeHistoryFetcher is implemented by clients that can fetch fee history;
+// *Client does.
+type FeeHistoryFetcher interface {
+	GetFeeHistory(ctx context.Context, blocks int, percentiles []float64) (*FeeHistory, error)
 }
@@ -0,0 +1,202 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Replay is an RPCClient serving blocks recorded on disk instead of a node,
+// so that a run can be reproduced deterministically offline.
+//
+// The recorded blocks form a timeline in file name order, and each
+// GetBlockNumber call reveals the next one, as if the node had just seen
+// it; the head is the number of the last revealed block. A block whose
+// number is at or below the head is a reorg: it replaces the earlier block
+// of that number, drops the blocks above it and becomes the head. Once the
+// timeline is exhausted the head stays put. It is safe for concurrent use.
+type Replay struct {
+	mu       sync.Mutex
+	timeline []Block
+	next     int            // index in timeline of the next block to reveal
+	blocks   map[int]*Block // revealed blocks on the current chain
+	head     int
+}
+
+// NewReplay creates a Replay serving the block fixtures in dir, as read by
+// LoadBlockFixtures.
+func NewReplay(dir string) (*Replay, error) {
+	timeline, err := LoadBlockFixtures(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range timeline {
+		if _, err := parseQuantity(b.Number); err != nil {
+			return nil, fmt.Errorf("replay: invalid block number %q: %w", b.Number, err)
+		}
+	}
+	return &Replay{timeline: timeline, blocks: make(map[int]*Block)}, nil
+}
+
+// LoadBlockFixtures reads the *.json files in dir in file name order, each
+// holding a block as returned by eth_getBlockByNumber with full
+// transactions, either bare or wrapped in its JSON-RPC response.
+func LoadBlockFixtures(dir string) ([]Block, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no *.json block fixtures in %s", dir)
+	}
+	blocks := make([]Block, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		b, err := DecodeBlockFixture(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid block fixture %s: %w", path, err)
+		}
+		blocks = append(blocks, b)
+	}
+	return blocks, nil
+}
+
+// DecodeBlockFixture decodes a block as returned by eth_getBlockByNumber,
+// either bare or wrapped in its JSON-RPC response.
+func DecodeBlockFixture(data []byte) (Block, error) {
+	var resp JSONRPCResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return Block{}, err
+	}
+	if resp.Result != nil {
+		data = resp.Result
+	}
+	var b Block
+	err := json.Unmarshal(data, &b)
+	return b, err
+}
+
+// parseQuantity decodes a hex quantity such as a block number.
+func parseQuantity(s string) (int, error) {
+	n, err := strconv.ParseInt(strings.TrimPrefix(s, "0x"), 16, 64)
+	return int(n), err
+}
+
+// Done reports whether every recorded block has been revealed.
+func (r *Replay) Done() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.next == len(r.timeline)
+}
+
+// Call serves eth_blockNumber, eth_getBlockByNumber and
+// eth_getTransactionByHash from the recorded blocks.
+func (r *Replay) Call(ctx context.Context, method string, params []interface{}, result interface{}) error {
+	var (
+		v   interface{}
+		err error
+	)
+	switch method {
+	case "eth_blockNumber":
+		v, err = r.GetBlockNumber(ctx)
+	case "eth_getBlockByNumber":
+		number, _ := paramAt(params, 0).(string)
+		v, err = r.GetBlockByNumber(ctx, number, true)
+	case "eth_getTransactionByHash":
+		hash, _ := paramAt(params, 0).(string)
+		v, err = r.GetTransactionByHash(ctx, hash)
+	default:
+		return fmt.Errorf("replay: unsupported method %s", method)
+	}
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, result)
+}
+
+func paramAt(params []interface{}, i int) interface{} {
+	if i < len(params) {
+		return params[i]
+	}
+	return nil
+}
+
+// GetBlockNumber reveals the next recorded block, if any, and returns the
+// head.
+func (r *Replay) GetBlockNumber(ctx context.Context) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.next < len(r.timeline) {
+		b := &r.timeline[r.next]
+		r.next++
+		number, _ := parseQuantity(b.Number)
+		for n := number + 1; n <= r.head; n++ {
+			delete(r.blocks, n)
+		}
+		r.blocks[number] = b
+		r.head = number
+	}
+	return fmt.Sprintf("0x%x", r.head), nil
+}
+
+// GetBlockByNumber returns a revealed block by hex number or "latest".
+func (r *Replay) GetBlockByNumber(ctx context.Context, blockNumber string, includeTransactions bool) (*Block, error) {
+	if blockNumber == "latest" {
+		r.mu.Lock()
+		head := r.head
+		r.mu.Unlock()
+		return r.GetBlockByNumberInt(ctx, head, includeTransactions)
+	}
+	number, err := parseQuantity(blockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("replay: invalid block number %q: %w", blockNumber, err)
+	}
+	return r.GetBlockByNumberInt(ctx, number, includeTransactions)
+}
+
+// GetBlockByNumberInt returns a revealed block. Like a node that hasn't seen
+// a block yet, it returns an empty one for blocks that weren't recorded or
+// revealed.
+func (r *Replay) GetBlockByNumberInt(ctx context.Context, blockNumber int, includeTransactions bool) (*Block, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.blocks[blockNumber]
+	if !ok {
+		return &Block{}, nil
+	}
+	out := *b
+	if !includeTransactions {
+		out.Transactions = nil
+	}
+	return &out, nil
+}
+
+// GetTransactionByHash returns a transaction from a revealed block on the
+// current chain.
+func (r *Replay) GetTransactionByHash(ctx context.Context, hash string) (*Transaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, b := range r.blocks {
+		for _, tx := range b.Transactions {
+			if tx.Hash == hash {
+				if tx.BlockNumber == "" {
+					tx.BlockNumber = b.Number
+				}
+				return &tx, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("transaction %s: %w", hash, ErrNotFound)
+}
@@ -0,0 +1,93 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFixtures writes block fixtures to a temporary directory by file name.
+func writeFixtures(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestReplay(t *testing.T) {
+	dir := writeFixtures(t, map[string]string{
+		"01.json": `{"number":"0x10","transactions":[{"hash":"0xa","from":"0x1","to":"0x2","value":"0x1"}]}`,
+		"02.json": `{"jsonrpc":"2.0","id":1,"result":{"number":"0x11","transactions":[{"hash":"0xb","from":"0x1","to":"0x3","value":"0x2"}]}}`,
+		"03.json": `{"number":"0x12","transactions":[]}`,
+		// Block 0x11 is replaced, dropping 0x12 with it.
+		"04.json": `{"number":"0x11","transactions":[{"hash":"0xc","from":"0x1","to":"0x4","value":"0x3"}]}`,
+		"notes":   `ignored`,
+	})
+	r, err := NewReplay(dir)
+	if err != nil {
+		t.Fatalf("NewReplay failed: %v", err)
+	}
+	ctx := context.Background()
+
+	if b, _ := r.GetBlockByNumberInt(ctx, 0x10, true); b.Number != "" {
+		t.Errorf("Expected no block before the first poll, got %+v", b)
+	}
+	for _, want := range []string{"0x10", "0x11", "0x12"} {
+		if head, _ := r.GetBlockNumber(ctx); head != want {
+			t.Fatalf("Expected head %s, got %s", want, head)
+		}
+	}
+	if b, _ := r.GetBlockByNumberInt(ctx, 0x11, true); len(b.Transactions) != 1 || b.Transactions[0].Hash != "0xb" {
+		t.Errorf("Unexpected block 0x11 before the reorg: %+v", b)
+	}
+	if tx, err := r.GetTransactionByHash(ctx, "0xb"); err != nil || tx.BlockNumber != "0x11" {
+		t.Errorf("Expected 0xb in block 0x11, got %+v %v", tx, err)
+	}
+
+	if head, _ := r.GetBlockNumber(ctx); head != "0x11" {
+		t.Fatalf("Expected the reorg to reset the head to 0x11, got %s", head)
+	}
+	if b, _ := r.GetBlockByNumber(ctx, "latest", true); len(b.Transactions) != 1 || b.Transactions[0].Hash != "0xc" {
+		t.Errorf("Expected the reorged block 0x11, got %+v", b)
+	}
+	if b, _ := r.GetBlockByNumberInt(ctx, 0x12, true); b.Number != "" {
+		t.Errorf("Expected block 0x12 to be dropped, got %+v", b)
+	}
+	if _, err := r.GetTransactionByHash(ctx, "0xb"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the reorged-out transaction to be gone, got %v", err)
+	}
+	if !r.Done() {
+		t.Error("Expected the timeline to be exhausted")
+	}
+	if head, _ := r.GetBlockNumber(ctx); head != "0x11" {
+		t.Errorf("Expected the head to stay at 0x11, got %s", head)
+	}
+
+	var head string
+	if err := r.Call(ctx, "eth_blockNumber", nil, &head); err != nil || head != "0x11" {
+		t.Errorf("Expected eth_blockNumber 0x11, got %s %v", head, err)
+	}
+	var b Block
+	if err := r.Call(ctx, "eth_getBlockByNumber", []interface{}{"0x10", true}, &b); err != nil || b.Number != "0x10" {
+		t.Errorf("Expected eth_getBlockByNumber to return 0x10, got %+v %v", b, err)
+	}
+	if err := r.Call(ctx, "eth_call", nil, &b); err == nil {
+		t.Error("Expected an error for an unsupported method")
+	}
+}
+
+func TestNewReplay_Invalid(t *testing.T) {
+	if _, err := NewReplay(t.TempDir()); err == nil {
+		t.Error("Expected an error for a directory without fixtures")
+	}
+	dir := writeFixtures(t, map[string]string{"01.json": `{"number":"latest","transactions":[]}`})
+	if _, err := NewReplay(dir); err == nil {
+		t.Error("Expected an error for a fixture without a block number")
+	}
+}
@@ -0,0 +1,174 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// BlockStreamer is implemented by clients that can decode a block's
+// transactions one at a time; *Client does.
+type BlockStreamer interface {
+	StreamBlockByNumber(ctx context.Context, blockNumber int, fn func(Transaction) error) (*Block, error)
+}
+
+// StreamBlockByNumber fetches a block with its full transactions and passes
+// each transaction to fn as soon as it is decoded, so the block is never held
+// in memory as a whole. It returns the block without its transactions. An
+// error from fn stops decoding and is returned as is.
+//
+// fn may already have been called for some transactions when an error is
+// returned, so callers retrying the block must tolerate seeing them again.
+// As with GetBlockByNumber, a block unknown to the node yields an empty
+// Block.
+func (c *Client) StreamBlockByNumber(ctx context.Context, blockNumber int, fn func(Transaction) error) (*Block, error) {
+	const method = "eth_getBlockByNumber"
+	hexBlockNumber := fmt.Sprintf("0x%x", blockNumber)
+	var block Block
+	var fnErr error
+	req := JSONRPCRequest{JSONRPC: "2.0", Method: method, Params: []interface{}{hexBlockNumber, true}, ID: 1}
+	err := c.call(ctx, method, req, func(body io.Reader) error {
+		err := decodeBlockResponse(json.NewDecoder(body), &block, func(tx Transaction) error {
+			fnErr = fn(tx)
+			return fnErr
+		})
+		if err != nil && err != fnErr {
+			return fmt.Errorf("failed to decode JSON-RPC response for method %s: %w", method, err)
+		}
+		return err
+	})
+	if fnErr != nil {
+		return nil, fnErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block %s: %w", hexBlockNumber, err)
+	}
+	return &block, nil
+}
+
+// decodeBlockResponse decodes a JSON-RPC response envelope whose result is a
+// block, streaming its transactions to fn.
+func decodeBlockResponse(dec *json.Decoder, block *Block, fn func(Transaction) error) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "result":
+			if err := decodeBlock(dec, block, fn); err != nil {
+				return err
+			}
+		case "error":
+			var e *RPCError
+			if err := dec.Decode(&e); err != nil {
+				return err
+			}
+			if e != nil {
+				return rpcError("eth_getBlockByNumber", e)
+			}
+		default:
+			if err := skipValue(dec); err != nil {
+				return err
+			}
+		}
+	}
+	return expectDelim(dec, '}')
+}
+
+// decodeBlock decodes a block object, or null, passing each element of its
+// transactions array to fn and skipping fields Block doesn't have.
+func decodeBlock(dec *json.Decoder, block *Block, fn func(Transaction) error) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if tok != json.Delim('{') {
+		return fmt.Errorf("unexpected %v in place of a block", tok)
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "number":
+			if err := dec.Decode(&block.Number); err != nil {
+				return err
+			}
+		case "timestamp":
+			if err := dec.Decode(&block.Timestamp); err != nil {
+				return err
+			}
+		case "hash":
+			if err := dec.Decode(&block.Hash); err != nil {
+				return err
+			}
+		case "parentHash":
+			if err := dec.Decode(&block.ParentHash); err != nil {
+				return err
+			}
+		case "transactions":
+			if err := expectDelim(dec, '['); err != nil {
+				return err
+			}
+			for dec.More() {
+				var tx Transaction
+				if err := dec.Decode(&tx); err != nil {
+					return err
+				}
+				if err := fn(tx); err != nil {
+					return err
+				}
+			}
+			if err := expectDelim(dec, ']'); err != nil {
+				return err
+			}
+		default:
+			if err := skipValue(dec); err != nil {
+				return err
+			}
+		}
+	}
+	return expectDelim(dec, '}')
+}
+
+// expectDelim consumes the next token, which must be delim.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("expected %v, got %v", delim, tok)
+	}
+	return nil
+}
+
+// skipValue consumes the next value, however deeply nested, without
+// decoding it.
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
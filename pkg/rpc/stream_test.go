@@ -0,0 +1,112 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const streamedBlock = `{"jsonrpc":"2.0","id":1,"result":{
+	"baseFeePerGas":"0x7",
+	"number":"0x1234",
+	"hash":"0xb1234",
+	"parentHash":"0xb1233",
+	"uncles":[],
+	"withdrawals":[{"index":"0x1","amount":"0x2"}],
+	"transactions":[
+		{"hash":"0xhash1","from":"0xfrom1","to":"0xto1","value":"0x1000","blockNumber":"0x1234","accessList":[{"address":"0xa","storageKeys":["0x0"]}]},
+		{"hash":"0xhash2","from":"0xfrom2","to":null,"value":"0x0","blockNumber":"0x1234","input":"0x6080"}
+	],
+	"logsBloom":"0x00",
+	"timestamp":"0x6553f100"
+}}`
+
+func TestClient_StreamBlockByNumber(t *testing.T) {
+	tests := []struct {
+		name           string
+		response       string
+		status         int
+		fnErr          error
+		expectedHashes []string
+		expectedNumber string
+		expectedTime   string
+		wantErr        bool
+	}{
+		{name: "block", response: streamedBlock, expectedHashes: []string{"0xhash1", "0xhash2"}, expectedNumber: "0x1234", expectedTime: "0x6553f100"},
+		{name: "unknown block", response: `{"jsonrpc":"2.0","id":1,"result":null}`},
+		{name: "rpc error", response: `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"header not found"}}`, wantErr: true},
+		{name: "http error", response: `oops`, status: http.StatusBadGateway, wantErr: true},
+		{name: "truncated", response: streamedBlock[:strings.Index(streamedBlock, `{"hash":"0xhash2"`)+20], expectedHashes: []string{"0xhash1"}, wantErr: true},
+		{name: "callback error", response: streamedBlock, fnErr: errors.New("stop"), expectedHashes: []string{"0xhash1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			var hashes []string
+			block, err := NewClient(server.URL).StreamBlockByNumber(context.Background(), 0x1234, func(tx Transaction) error {
+				hashes = append(hashes, tx.Hash)
+				return tt.fnErr
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.fnErr != nil && err != tt.fnErr {
+				t.Errorf("Expected the callback's error to be returned as is, got %v", err)
+			}
+			if strings.Join(hashes, ",") != strings.Join(tt.expectedHashes, ",") {
+				t.Errorf("Expected transactions %v, got %v", tt.expectedHashes, hashes)
+			}
+			if err != nil {
+				return
+			}
+			if block.Number != tt.expectedNumber || block.Timestamp != tt.expectedTime || block.Transactions != nil {
+				t.Errorf("Unexpected block: %+v", block)
+			}
+			if tt.expectedNumber != "" && (block.Hash != "0xb1234" || block.ParentHash != "0xb1233") {
+				t.Errorf("Unexpected block: %+v", block)
+			}
+		})
+	}
+}
+
+func TestClient_StreamBlockByNumber_MatchesGetBlock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(streamedBlock))
+	}))
+	defer server.Close()
+	client := NewClient(server.URL)
+
+	full, err := client.GetBlockByNumberInt(context.Background(), 0x1234, true)
+	if err != nil {
+		t.Fatalf("GetBlockByNumberInt failed: %v", err)
+	}
+	var streamed []Transaction
+	block, err := client.StreamBlockByNumber(context.Background(), 0x1234, func(tx Transaction) error {
+		streamed = append(streamed, tx)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamBlockByNumber failed: %v", err)
+	}
+	if block.Timestamp != full.Timestamp || block.Hash != full.Hash || block.ParentHash != full.ParentHash {
+		t.Errorf("Expected the block of %+v, got %+v", full, block)
+	}
+	if len(streamed) != len(full.Transactions) {
+		t.Fatalf("Expected %d transactions, got %d", len(full.Transactions), len(streamed))
+	}
+	for i := range streamed {
+		if streamed[i] != full.Transactions[i] {
+			t.Errorf("Transaction %d: expected %+v, got %+v", i, full.Transactions[i], streamed[i])
+		}
+	}
+}
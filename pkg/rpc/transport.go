@@ -0,0 +1,76 @@
+package rpc
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// Transport configures the HTTP connections a Client makes to its node.
+// Zero fields take the defaults noted on them, which keep enough idle
+// connections for concurrent fetching against a single provider; those of
+// http.DefaultTransport keep only 2 per host, so concurrent calls past that
+// open and close a connection each.
+type Transport struct {
+	// MaxIdleConnsPerHost is how many idle connections are kept open for
+	// reuse. Defaults to 64.
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost caps the connections open at once, queueing calls
+	// past it. 0 leaves them unlimited.
+	MaxConnsPerHost int
+	// IdleConnTimeout is how long an idle connection is kept open.
+	// Defaults to 90s.
+	IdleConnTimeout time.Duration
+	// KeepAlive is the interval of TCP keep-alive probes. Defaults to 30s;
+	// negative disables them.
+	KeepAlive time.Duration
+	// DisableKeepAlives closes each connection after one call.
+	DisableKeepAlives bool
+	// DialTimeout bounds establishing a connection. Defaults to 10s.
+	DialTimeout time.Duration
+	// TLSHandshakeTimeout bounds the TLS handshake. Defaults to 10s.
+	TLSHandshakeTimeout time.Duration
+	// DisableHTTP2 keeps calls on HTTP/1.1, which HTTPS endpoints otherwise
+	// upgrade to HTTP/2, multiplexing calls over one connection.
+	DisableHTTP2 bool
+	// HTTP2PingTimeout is how long an HTTP/2 connection may go without
+	// receiving a frame before it is health-checked with a ping, so dead
+	// connections are dropped rather than hanging calls. 0 disables the
+	// checks.
+	HTTP2PingTimeout time.Duration
+}
+
+// RoundTripper returns an http.Transport configured by t.
+func (t Transport) RoundTripper() *http.Transport {
+	if t.MaxIdleConnsPerHost <= 0 {
+		t.MaxIdleConnsPerHost = 64
+	}
+	if t.IdleConnTimeout <= 0 {
+		t.IdleConnTimeout = 90 * time.Second
+	}
+	if t.KeepAlive == 0 {
+		t.KeepAlive = 30 * time.Second
+	}
+	if t.DialTimeout <= 0 {
+		t.DialTimeout = 10 * time.Second
+	}
+	if t.TLSHandshakeTimeout <= 0 {
+		t.TLSHandshakeTimeout = 10 * time.Second
+	}
+	dialer := &net.Dialer{Timeout: t.DialTimeout, KeepAlive: t.KeepAlive}
+	rt := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		MaxIdleConnsPerHost:   t.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       t.MaxConnsPerHost,
+		IdleConnTimeout:       t.IdleConnTimeout,
+		DisableKeepAlives:     t.DisableKeepAlives,
+		TLSHandshakeTimeout:   t.TLSHandshakeTimeout,
+		ExpectContinueTimeout: time.Second,
+		ForceAttemptHTTP2:     !t.DisableHTTP2,
+	}
+	if !t.DisableHTTP2 && t.HTTP2PingTimeout > 0 {
+		rt.HTTP2 = &http.HTTP2Config{SendPingTimeout: t.HTTP2PingTimeout}
+	}
+	return rt
+}
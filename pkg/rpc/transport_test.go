@@ -0,0 +1,47 @@
+package rpc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTransport_RoundTripper(t *testing.T) {
+	tests := []struct {
+		name      string
+		transport Transport
+		idle      int
+		http2     bool
+		ping      time.Duration
+	}{
+		{name: "defaults", idle: 64, http2: true},
+		{
+			name:      "overrides",
+			transport: Transport{MaxIdleConnsPerHost: 8, DialTimeout: time.Second, HTTP2PingTimeout: 15 * time.Second},
+			idle:      8,
+			http2:     true,
+			ping:      15 * time.Second,
+		},
+		{name: "http/1.1 only", transport: Transport{DisableHTTP2: true, HTTP2PingTimeout: time.Second}, idle: 64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := tt.transport.RoundTripper()
+			if rt.MaxIdleConnsPerHost != tt.idle {
+				t.Errorf("Expected %d idle connections per host, got %d", tt.idle, rt.MaxIdleConnsPerHost)
+			}
+			if rt.TLSHandshakeTimeout != 10*time.Second || rt.IdleConnTimeout != 90*time.Second {
+				t.Errorf("Unexpected default timeouts: %v %v", rt.TLSHandshakeTimeout, rt.IdleConnTimeout)
+			}
+			if rt.ForceAttemptHTTP2 != tt.http2 {
+				t.Errorf("Expected HTTP/2 %t, got %t", tt.http2, rt.ForceAttemptHTTP2)
+			}
+			var ping time.Duration
+			if rt.HTTP2 != nil {
+				ping = rt.HTTP2.SendPingTimeout
+			}
+			if ping != tt.ping {
+				t.Errorf("Expected ping timeout %v, got %v", tt.ping, ping)
+			}
+		})
+	}
+}
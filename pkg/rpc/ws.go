@@ -0,0 +1,259 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// LogSubscriber is implemented by clients that can stream logs as the node
+// sees them; *WSClient does.
+type LogSubscriber interface {
+	// SubscribeLogs passes fn the logs of new blocks matching filter, whose
+	// block range is ignored, until the subscription ends.
+	SubscribeLogs(ctx context.Context, filter LogFilter, fn func(Log)) (Subscription, error)
+}
+
+// Subscription is a live subscription to a node.
+type Subscription interface {
+	// Done is closed when the subscription ends other than by
+	// Unsubscribe, as when the connection drops. It has to be made again.
+	Done() <-chan struct{}
+	// Unsubscribe ends the subscription.
+	Unsubscribe(ctx context.Context) error
+}
+
+// wsWriteTimeout bounds sending a request, so that a stalled connection
+// fails calls instead of hanging them.
+const wsWriteTimeout = 10 * time.Second
+
+// WSClient calls a node over a WebSocket connection, which unlike HTTP lets
+// the node push subscription notifications. It connects on first use and
+// again on the next call after the connection drops, which ends the
+// subscriptions made over it.
+type WSClient struct {
+	endpoint string
+
+	mu   sync.Mutex
+	conn *wsConn // nil until connected
+}
+
+// NewWSClient creates a WSClient targeting the given ws:// or wss:// URL.
+func NewWSClient(endpoint string) *WSClient {
+	return &WSClient{endpoint: endpoint}
+}
+
+// SubscribeLogs subscribes to logs with eth_subscribe. fn runs on the
+// goroutine reading the connection, so it should return quickly.
+func (c *WSClient) SubscribeLogs(ctx context.Context, filter LogFilter, fn func(Log)) (Subscription, error) {
+	conn, err := c.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	params := make(map[string]interface{})
+	addFilterParams(params, filter)
+	sub := &wsSubscription{conn: conn}
+	// The subscription is registered by the reader as it reads the reply,
+	// so that notifications right behind it aren't dropped.
+	register := func(result json.RawMessage) {
+		if json.Unmarshal(result, &sub.id) == nil {
+			conn.subs[sub.id] = func(raw json.RawMessage) {
+				var l Log
+				if json.Unmarshal(raw, &l) == nil {
+					fn(l)
+				}
+			}
+		}
+	}
+	if err := conn.call(ctx, "eth_subscribe", []interface{}{"logs", params}, nil, register); err != nil {
+		return nil, fmt.Errorf("failed to subscribe to logs: %w", err)
+	}
+	return sub, nil
+}
+
+// Close closes the connection, ending its subscriptions.
+func (c *WSClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	c.conn.close(errors.New("client closed"))
+	c.conn = nil
+	return nil
+}
+
+// connect returns the open connection, dialing a new one if there is none
+// or it dropped.
+func (c *WSClient) connect(ctx context.Context) (*wsConn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != nil {
+		select {
+		case <-c.conn.done:
+		default:
+			return c.conn, nil
+		}
+	}
+	ws, _, err := websocket.DefaultDialer.DialContext(ctx, c.endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to WebSocket endpoint: %w", err)
+	}
+	c.conn = &wsConn{
+		ws:      ws,
+		pending: make(map[int]wsCall),
+		subs:    make(map[string]func(json.RawMessage)),
+		done:    make(chan struct{}),
+	}
+	go c.conn.read()
+	return c.conn, nil
+}
+
+// wsMessage is a reply or a subscription notification read from a node.
+type wsMessage struct {
+	ID     *int            `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *RPCError       `json:"error"`
+	Method string          `json:"method"`
+	Params struct {
+		Subscription string          `json:"subscription"`
+		Result       json.RawMessage `json:"result"`
+	} `json:"params"`
+}
+
+// wsCall is a call awaiting its reply. onResult, if set, is run by the
+// reader with the successful reply's result before it reads on.
+type wsCall struct {
+	reply    chan wsMessage
+	onResult func(json.RawMessage)
+}
+
+// wsConn is one WebSocket connection and the calls and subscriptions made
+// over it.
+type wsConn struct {
+	ws      *websocket.Conn
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  int
+	pending map[int]wsCall
+	subs    map[string]func(json.RawMessage)
+	done    chan struct{} // closed once the connection failed
+	err     error         // why; set before done is closed
+}
+
+// read dispatches replies and notifications until the connection fails.
+func (c *wsConn) read() {
+	for {
+		var msg wsMessage
+		if err := c.ws.ReadJSON(&msg); err != nil {
+			c.close(err)
+			return
+		}
+		if msg.Method == "eth_subscription" {
+			c.mu.Lock()
+			fn := c.subs[msg.Params.Subscription]
+			c.mu.Unlock()
+			if fn != nil {
+				fn(msg.Params.Result)
+			}
+			continue
+		}
+		if msg.ID == nil {
+			continue
+		}
+		c.mu.Lock()
+		call, ok := c.pending[*msg.ID]
+		delete(c.pending, *msg.ID)
+		if ok && call.onResult != nil && msg.Error == nil {
+			call.onResult(msg.Result)
+		}
+		c.mu.Unlock()
+		if ok {
+			call.reply <- msg
+		}
+	}
+}
+
+// close fails the connection with err.
+func (c *wsConn) close(err error) {
+	c.mu.Lock()
+	if c.err == nil {
+		c.err = err
+		close(c.done)
+	}
+	c.mu.Unlock()
+	c.ws.Close()
+}
+
+// call performs a JSON-RPC request and unmarshals the result into result,
+// if not nil.
+func (c *wsConn) call(ctx context.Context, method string, params []interface{}, result interface{}, onResult func(json.RawMessage)) error {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return fmt.Errorf("connection closed: %w", c.err)
+	}
+	c.nextID++
+	id := c.nextID
+	call := wsCall{reply: make(chan wsMessage, 1), onResult: onResult}
+	c.pending[id] = call
+	c.mu.Unlock()
+
+	c.writeMu.Lock()
+	c.ws.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	err := c.ws.WriteJSON(JSONRPCRequest{JSONRPC: "2.0", Method: method, Params: params, ID: id})
+	c.writeMu.Unlock()
+	if err != nil {
+		c.close(err)
+		return fmt.Errorf("RPC call failed for method %s: %w", method, err)
+	}
+
+	select {
+	case msg := <-call.reply:
+		if msg.Error != nil {
+			return fmt.Errorf("RPC error for method %s (code %d): %w", method, msg.Error.Code, msg.Error)
+		}
+		if result != nil {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				return fmt.Errorf("failed to unmarshal result for method %s: %w", method, err)
+			}
+		}
+		return nil
+	case <-c.done:
+		return fmt.Errorf("RPC call failed for method %s: %w", method, c.err)
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// wsSubscription is a Subscription made over a wsConn.
+type wsSubscription struct {
+	conn *wsConn
+	id   string
+}
+
+// Done is closed when the subscription's connection drops.
+func (s *wsSubscription) Done() <-chan struct{} {
+	return s.conn.done
+}
+
+// Unsubscribe stops notifications and ends the subscription at the node.
+func (s *wsSubscription) Unsubscribe(ctx context.Context) error {
+	s.conn.mu.Lock()
+	delete(s.conn.subs, s.id)
+	s.conn.mu.Unlock()
+	var ok bool
+	if err := s.conn.call(ctx, "eth_unsubscribe", []interface{}{s.id}, &ok, nil); err != nil {
+		return fmt.Errorf("failed to unsubscribe %s: %w", s.id, err)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsNode is a node answering eth_subscribe with subscription "0xsub" and
+// pushing a log right behind the reply, and closing the connection on
+// eth_chainId.
+func wsNode(t *testing.T, requests chan<- JSONRPCRequest) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade failed: %v", err)
+			return
+		}
+		defer conn.Close()
+		for {
+			var req JSONRPCRequest
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			requests <- req
+			switch req.Method {
+			case "eth_subscribe":
+				conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": "0xsub"})
+				conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "method": "eth_subscription", "params": map[string]interface{}{
+					"subscription": "0xsub",
+					"result":       Log{Address: "0xtoken", BlockNumber: "0x10", TransactionHash: "0xhash"},
+				}})
+			case "eth_unsubscribe":
+				conn.WriteJSON(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": true})
+			default:
+				return
+			}
+		}
+	}))
+}
+
+func TestWSClient_SubscribeLogs(t *testing.T) {
+	requests := make(chan JSONRPCRequest, 10)
+	srv := wsNode(t, requests)
+	defer srv.Close()
+	c := NewWSClient("ws" + strings.TrimPrefix(srv.URL, "http"))
+	defer c.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	logs := make(chan Log, 1)
+	sub, err := c.SubscribeLogs(ctx, LogFilter{Topics: [][]string{{"0xtopic"}, nil, {"0xaddr"}}}, func(l Log) { logs <- l })
+	if err != nil {
+		t.Fatalf("SubscribeLogs failed: %v", err)
+	}
+	req := <-requests
+	params, _ := json.Marshal(req.Params)
+	if req.Method != "eth_subscribe" || string(params) != `["logs",{"topics":[["0xtopic"],null,["0xaddr"]]}]` {
+		t.Errorf("Unexpected request %s %s", req.Method, params)
+	}
+	select {
+	case l := <-logs:
+		if l.TransactionHash != "0xhash" || l.BlockNumber != "0x10" {
+			t.Errorf("Unexpected log %+v", l)
+		}
+	case <-ctx.Done():
+		t.Fatal("Expected the log sent right behind the reply to be delivered")
+	}
+
+	if err := sub.Unsubscribe(ctx); err != nil {
+		t.Fatalf("Unsubscribe failed: %v", err)
+	}
+	if req := <-requests; req.Method != "eth_unsubscribe" || req.Params[0] != "0xsub" {
+		t.Errorf("Unexpected request %s %v", req.Method, req.Params)
+	}
+
+	// A dropped connection ends the subscriptions made over it, and the
+	// next subscription reconnects.
+	sub, err = c.SubscribeLogs(ctx, LogFilter{}, func(Log) {})
+	if err != nil {
+		t.Fatalf("SubscribeLogs failed: %v", err)
+	}
+	<-requests
+	c.conn.call(ctx, "eth_chainId", nil, nil, nil)
+	select {
+	case <-sub.Done():
+	case <-ctx.Done():
+		t.Fatal("Expected the subscription to end with its connection")
+	}
+	if _, err := c.SubscribeLogs(ctx, LogFilter{}, func(Log) {}); err != nil {
+		t.Errorf("Expected to reconnect, got %v", err)
+	}
+}
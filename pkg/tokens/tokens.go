@@ -0,0 +1,275 @@
+// Package tokens resolves the symbol, name and decimals of ERC-20 token
+// contracts and the token balances of addresses through a node's eth_call,
+// caching the results.
+package tokens
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+	"github.com/danieloluwadare/tw-txparser/pkg/transaction"
+)
+
+// Function selectors of the optional ERC-20 metadata calls.
+const (
+	selectorSymbol   = "95d89b41" // symbol()
+	selectorName     = "06fdde03" // name()
+	selectorDecimals = "313ce567" // decimals()
+	selectorBalance  = "70a08231" // balanceOf(address)
+)
+
+// maxDecimals bounds plausible decimals; ERC-20 declares them as a uint8.
+const maxDecimals = 255
+
+// Caller performs JSON-RPC calls; *rpc.Client implements it.
+type Caller interface {
+	Call(ctx context.Context, method string, params []interface{}, result interface{}) error
+}
+
+// Metadata describes a token contract. Fields the contract doesn't
+// implement are empty.
+type Metadata struct {
+	Symbol   string `json:"symbol,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Decimals int    `json:"decimals"`
+}
+
+// Options configures a Resolver.
+type Options struct {
+	// CacheTTL is how long metadata is cached. Token metadata practically
+	// never changes, so it defaults to 24h.
+	CacheTTL time.Duration
+	// BalanceTTL is how long balances are cached. Balances are invalidated
+	// when the parser indexes a transfer changing them, so the TTL only
+	// bounds drift from changes without a Transfer event, such as rebasing
+	// tokens. Defaults to 5m.
+	BalanceTTL time.Duration
+	// CacheSize bounds the number of cached contracts, and separately of
+	// cached balances. Defaults to 10000.
+	CacheSize int
+}
+
+// Resolver looks up token metadata and balances via eth_call. It is safe
+// for concurrent use.
+type Resolver struct {
+	client Caller
+	opts   Options
+	now    func() time.Time
+
+	mu       sync.Mutex
+	cache    map[string]cacheEntry[Metadata]          // by lowercase contract address
+	balances map[string]cacheEntry[transaction.Value] // by balanceKey
+}
+
+type cacheEntry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+// New creates a Resolver using client for contract calls.
+func New(client Caller, opts Options) *Resolver {
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = 24 * time.Hour
+	}
+	if opts.BalanceTTL <= 0 {
+		opts.BalanceTTL = 5 * time.Minute
+	}
+	if opts.CacheSize <= 0 {
+		opts.CacheSize = 10000
+	}
+	return &Resolver{
+		client:   client,
+		opts:     opts,
+		now:      time.Now,
+		cache:    make(map[string]cacheEntry[Metadata]),
+		balances: make(map[string]cacheEntry[transaction.Value]),
+	}
+}
+
+// Lookup returns the metadata of contract. Functions the contract doesn't
+// implement leave their field empty, and the result is cached either way;
+// failed calls are not cached.
+func (r *Resolver) Lookup(ctx context.Context, contract string) (Metadata, error) {
+	contract = strings.ToLower(contract)
+	r.mu.Lock()
+	e, ok := r.cache[contract]
+	r.mu.Unlock()
+	if ok && r.now().Before(e.expires) {
+		return e.value, nil
+	}
+
+	var meta Metadata
+	out, err := r.call(ctx, contract, "0x"+selectorSymbol)
+	if err != nil {
+		return Metadata{}, fmt.Errorf("failed to look up symbol of %s: %w", contract, err)
+	}
+	meta.Symbol = decodeString(out)
+	if out, err = r.call(ctx, contract, "0x"+selectorName); err != nil {
+		return Metadata{}, fmt.Errorf("failed to look up name of %s: %w", contract, err)
+	}
+	meta.Name = decodeString(out)
+	if out, err = r.call(ctx, contract, "0x"+selectorDecimals); err != nil {
+		return Metadata{}, fmt.Errorf("failed to look up decimals of %s: %w", contract, err)
+	}
+	meta.Decimals = decodeDecimals(out)
+
+	r.mu.Lock()
+	store(r.cache, contract, cacheEntry[Metadata]{value: meta, expires: r.now().Add(r.opts.CacheTTL)}, r.opts.CacheSize, r.now())
+	r.mu.Unlock()
+	return meta, nil
+}
+
+// BalanceOf returns the balance of holder in token contract as of the
+// latest block: the raw amount for ERC-20 tokens and the number of tokens
+// held for ERC-721 ones. A contract without balanceOf yields zero.
+func (r *Resolver) BalanceOf(ctx context.Context, contract, holder string) (transaction.Value, error) {
+	contract, holder = strings.ToLower(contract), strings.ToLower(holder)
+	key := balanceKey(contract, holder)
+	r.mu.Lock()
+	e, ok := r.balances[key]
+	r.mu.Unlock()
+	if ok && r.now().Before(e.expires) {
+		return e.value, nil
+	}
+
+	addr := strings.TrimPrefix(holder, "0x")
+	if len(addr) != 40 {
+		return transaction.Value{}, fmt.Errorf("invalid holder address %q", holder)
+	}
+	out, err := r.call(ctx, contract, "0x"+selectorBalance+strings.Repeat("0", 24)+addr)
+	if err != nil {
+		return transaction.Value{}, fmt.Errorf("failed to get balance of %s in %s: %w", holder, contract, err)
+	}
+	var balance transaction.Value
+	if len(out) >= 32 {
+		balance = transaction.NewValue(new(big.Int).SetBytes(out[:32]))
+	}
+
+	r.mu.Lock()
+	store(r.balances, key, cacheEntry[transaction.Value]{value: balance, expires: r.now().Add(r.opts.BalanceTTL)}, r.opts.CacheSize, r.now())
+	r.mu.Unlock()
+	return balance, nil
+}
+
+// Invalidate drops the cached balance of holder in token contract, e.g.
+// once a transfer changing it is seen.
+func (r *Resolver) Invalidate(contract, holder string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.balances, balanceKey(strings.ToLower(contract), strings.ToLower(holder)))
+}
+
+func balanceKey(contract, holder string) string {
+	return contract + "|" + holder
+}
+
+// store adds e to cache, evicting expired entries, or failing that an
+// arbitrary one, when it holds size entries. The caller holds the
+// Resolver's lock.
+func store[V any](cache map[string]cacheEntry[V], key string, e cacheEntry[V], size int, now time.Time) {
+	if len(cache) >= size {
+		for k, old := range cache {
+			if !now.Before(old.expires) {
+				delete(cache, k)
+			}
+		}
+		for k := range cache {
+			if len(cache) < size {
+				break
+			}
+			delete(cache, k)
+		}
+	}
+	cache[key] = e
+}
+
+// call invokes a contract function with the given call data and returns
+// the raw result. A reverted call, as for a function the contract doesn't
+// implement, returns an empty result rather than an error.
+func (r *Resolver) call(ctx context.Context, to, data string) ([]byte, error) {
+	msg := map[string]string{
+		"to":   to,
+		"data": data,
+	}
+	var result string
+	if err := r.client.Call(ctx, "eth_call", []interface{}{msg, "latest"}, &result); err != nil {
+		var rpcErr *rpc.RPCError
+		if errors.As(err, &rpcErr) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	out, err := hex.DecodeString(strings.TrimPrefix(result, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid eth_call result: %w", err)
+	}
+	return out, nil
+}
+
+// decodeString decodes an ABI-encoded string, or a bytes32 as returned by
+// some early tokens such as MKR, returning "" if out is malformed.
+func decodeString(out []byte) string {
+	if len(out) == 32 {
+		s := strings.TrimRight(string(out), "\x00")
+		if utf8.ValidString(s) && !strings.ContainsRune(s, 0) {
+			return s
+		}
+		return ""
+	}
+	if len(out) < 64 {
+		return ""
+	}
+	offset := wordInt(out[:32])
+	if offset < 0 || offset+32 > len(out) {
+		return ""
+	}
+	n := wordInt(out[offset : offset+32])
+	start := offset + 32
+	if n < 0 || start+n > len(out) {
+		return ""
+	}
+	s := string(out[start : start+n])
+	if !utf8.ValidString(s) {
+		return ""
+	}
+	return s
+}
+
+// decodeDecimals decodes an ABI-encoded uint8, returning 0 if out is
+// malformed.
+func decodeDecimals(out []byte) int {
+	if len(out) < 32 {
+		return 0
+	}
+	n := wordInt(out[:32])
+	if n < 0 || n > maxDecimals {
+		return 0
+	}
+	return n
+}
+
+// wordInt decodes a 32-byte big-endian word, returning -1 if it doesn't fit
+// in an int.
+func wordInt(word []byte) int {
+	for _, b := range word[:24] {
+		if b != 0 {
+			return -1
+		}
+	}
+	var n uint64
+	for _, b := range word[24:] {
+		n = n<<8 | uint64(b)
+	}
+	if n > 1<<31 {
+		return -1
+	}
+	return int(n)
+}
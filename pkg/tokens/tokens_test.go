@@ -0,0 +1,171 @@
+package tokens
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/rpc"
+)
+
+// fakeNode answers eth_call from a table keyed by contract and call data.
+// Calls missing from the table revert.
+type fakeNode struct {
+	results map[string]string // "<to> <data>" -> hex result
+	calls   int
+	err     error
+}
+
+func (f *fakeNode) Call(_ context.Context, method string, params []interface{}, result interface{}) error {
+	f.calls++
+	if f.err != nil {
+		return f.err
+	}
+	if method != "eth_call" {
+		return fmt.Errorf("unexpected method %s", method)
+	}
+	msg := params[0].(map[string]string)
+	out, ok := f.results[msg["to"]+" "+msg["data"]]
+	if !ok {
+		return &rpc.RPCError{Code: 3, Message: "execution reverted"}
+	}
+	*result.(*string) = out
+	return nil
+}
+
+func encodeString(s string) string {
+	word := func(n int) string { return fmt.Sprintf("%064x", n) }
+	data := hex.EncodeToString([]byte(s))
+	if pad := len(data) % 64; pad != 0 {
+		data += strings.Repeat("0", 64-pad)
+	}
+	return "0x" + word(32) + word(len(s)) + data
+}
+
+const (
+	usdc = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+	mkr  = "0x9f8f72aa9304c8b593d555f12ef6589cc3a579a2"
+)
+
+func newFakeNode() *fakeNode {
+	return &fakeNode{results: map[string]string{
+		usdc + " 0x" + selectorSymbol:   encodeString("USDC"),
+		usdc + " 0x" + selectorName:     encodeString("USD Coin"),
+		usdc + " 0x" + selectorDecimals: fmt.Sprintf("0x%064x", 6),
+		// MKR returns its symbol and name as bytes32.
+		mkr + " 0x" + selectorSymbol:   "0x" + hex.EncodeToString([]byte("MKR")) + strings.Repeat("0", 58),
+		mkr + " 0x" + selectorName:     "0x" + hex.EncodeToString([]byte("Maker")) + strings.Repeat("0", 54),
+		mkr + " 0x" + selectorDecimals: fmt.Sprintf("0x%064x", 18),
+	}}
+}
+
+func TestResolver_Lookup(t *testing.T) {
+	r := New(newFakeNode(), Options{})
+	tests := []struct {
+		contract string
+		want     Metadata
+	}{
+		{contract: strings.ToUpper(usdc[:2]) + usdc[2:], want: Metadata{Symbol: "USDC", Name: "USD Coin", Decimals: 6}},
+		{contract: mkr, want: Metadata{Symbol: "MKR", Name: "Maker", Decimals: 18}},
+		// A contract implementing none of the functions.
+		{contract: "0x0000000000000000000000000000000000000001", want: Metadata{}},
+	}
+	for _, tt := range tests {
+		got, err := r.Lookup(context.Background(), tt.contract)
+		if err != nil {
+			t.Fatalf("Lookup(%s) failed: %v", tt.contract, err)
+		}
+		if got != tt.want {
+			t.Errorf("Lookup(%s) = %+v, expected %+v", tt.contract, got, tt.want)
+		}
+	}
+}
+
+func TestResolver_Cache(t *testing.T) {
+	node := newFakeNode()
+	r := New(node, Options{CacheTTL: time.Hour})
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if _, err := r.Lookup(context.Background(), usdc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if node.calls != 3 {
+		t.Errorf("Expected one lookup of three calls, got %d calls", node.calls)
+	}
+
+	now = now.Add(2 * time.Hour)
+	r.Lookup(context.Background(), usdc)
+	if node.calls != 6 {
+		t.Errorf("Expected expired metadata to be looked up again, got %d calls", node.calls)
+	}
+
+	// Failed calls aren't cached.
+	node.err = errors.New("connection refused")
+	if _, err := r.Lookup(context.Background(), mkr); err == nil {
+		t.Fatal("Expected an error")
+	}
+	node.err = nil
+	if meta, err := r.Lookup(context.Background(), mkr); err != nil || meta.Symbol != "MKR" {
+		t.Errorf("Expected a lookup after the error, got %+v %v", meta, err)
+	}
+}
+
+func TestResolver_CacheSize(t *testing.T) {
+	r := New(newFakeNode(), Options{CacheSize: 1})
+	r.Lookup(context.Background(), usdc)
+	r.Lookup(context.Background(), mkr)
+	if len(r.cache) != 1 {
+		t.Errorf("Expected the cache to stay bounded, got %d entries", len(r.cache))
+	}
+}
+
+func TestDecodeString_Malformed(t *testing.T) {
+	for _, out := range []string{"", "00", strings.Repeat("ff", 64), encodeString("x")[2:66]} {
+		b, _ := hex.DecodeString(out)
+		if s := decodeString(b); s != "" {
+			t.Errorf("decodeString(%s) = %q, expected empty", out, s)
+		}
+	}
+}
+
+func TestResolver_BalanceOf(t *testing.T) {
+	const holder = "0x742d35cc6634c0532925a3b8d4c9db96c4b4d8b6"
+	node := newFakeNode()
+	call := usdc + " 0x" + selectorBalance + strings.Repeat("0", 24) + strings.TrimPrefix(holder, "0x")
+	node.results[call] = fmt.Sprintf("0x%064x", 1_500_000)
+	r := New(node, Options{})
+
+	for i := 0; i < 2; i++ {
+		b, err := r.BalanceOf(context.Background(), usdc, strings.ToUpper(holder[:4])+holder[4:])
+		if err != nil {
+			t.Fatalf("BalanceOf failed: %v", err)
+		}
+		if b.String() != "1500000" {
+			t.Errorf("Expected 1500000, got %s", b)
+		}
+	}
+	if node.calls != 1 {
+		t.Errorf("Expected the balance to be cached, got %d calls", node.calls)
+	}
+
+	node.results[call] = fmt.Sprintf("0x%064x", 0)
+	r.Invalidate(usdc, holder)
+	if b, _ := r.BalanceOf(context.Background(), usdc, holder); b.String() != "0" || node.calls != 2 {
+		t.Errorf("Expected a fresh balance after Invalidate, got %s after %d calls", b, node.calls)
+	}
+
+	// A contract without balanceOf reverts.
+	if b, err := r.BalanceOf(context.Background(), mkr, holder); err != nil || b.String() != "0" {
+		t.Errorf("Expected a zero balance, got %s %v", b, err)
+	}
+	if _, err := r.BalanceOf(context.Background(), usdc, "0x1234"); err == nil {
+		t.Error("Expected an error for an invalid holder")
+	}
+}
@@ -0,0 +1,55 @@
+package transaction
+
+import (
+	"encoding/json"
+	"math/big"
+)
+
+// unlimitedAllowance is the smallest amount treated as an unlimited
+// approval. Wallets approve the maximum uint256 for "unlimited", and some
+// contracts count down from it as the allowance is spent.
+var unlimitedAllowance = new(big.Int).Lsh(big.NewInt(1), 255)
+
+// Allowance is the amount of an ERC-20 token an owner approved a spender to
+// transfer on its behalf, as of the owner's latest Approval event for the
+// token and spender. Spending through transferFrom isn't deducted, as most
+// tokens don't emit an Approval event for it.
+type Allowance struct {
+	Owner    string `json:"owner"`
+	Spender  string `json:"spender"`
+	Contract string `json:"contract"`
+	// Symbol and Decimals come from the token contract and are empty when
+	// unknown.
+	Symbol   string `json:"symbol,omitempty"`
+	Decimals int    `json:"decimals"`
+	// Amount is the raw approved amount in the token's smallest unit.
+	Amount Value `json:"amount"`
+	// Block, Hash and LogIndex locate the Approval event that set Amount.
+	Block    int    `json:"block"`
+	Hash     string `json:"hash"`
+	LogIndex int    `json:"log_index"`
+}
+
+// Unlimited reports whether a is an approval for practically any amount.
+func (a Allowance) Unlimited() bool {
+	return a.Amount.Wei().Cmp(unlimitedAllowance) >= 0
+}
+
+// After reports whether a was set by a later event than o.
+func (a Allowance) After(o Allowance) bool {
+	if a.Block != o.Block {
+		return a.Block > o.Block
+	}
+	return a.LogIndex > o.LogIndex
+}
+
+// MarshalJSON encodes a with additional normalized_amount and unlimited
+// fields.
+func (a Allowance) MarshalJSON() ([]byte, error) {
+	type plain Allowance
+	return json.Marshal(struct {
+		plain
+		NormalizedAmount string `json:"normalized_amount"`
+		Unlimited        bool   `json:"unlimited"`
+	}{plain(a), a.Amount.Decimal(a.Decimals), a.Unlimited()})
+}
@@ -0,0 +1,45 @@
+package transaction
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAllowance_Unlimited(t *testing.T) {
+	tests := map[string]bool{
+		"0x" + "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff": true,
+		"0x" + "8000000000000000000000000000000000000000000000000000000000000000": true,
+		"0x" + "7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff": false,
+		"1000000": false,
+		"0":       false,
+	}
+	for amount, want := range tests {
+		if got := (Allowance{Amount: MustParseValue(amount)}).Unlimited(); got != want {
+			t.Errorf("Unlimited(%s) = %v, expected %v", amount, got, want)
+		}
+	}
+}
+
+func TestAllowance_After(t *testing.T) {
+	a := Allowance{Block: 10, LogIndex: 3}
+	if !a.After(Allowance{Block: 9, LogIndex: 5}) || !a.After(Allowance{Block: 10, LogIndex: 2}) {
+		t.Error("Expected a to be after earlier events")
+	}
+	if a.After(a) || a.After(Allowance{Block: 11}) {
+		t.Error("Expected a not to be after itself or later events")
+	}
+}
+
+func TestAllowance_JSON(t *testing.T) {
+	b, err := json.Marshal(Allowance{Owner: "0xowner", Spender: "0xspender", Contract: "0xa0b8", Symbol: "USDC", Decimals: 6, Amount: WeiValue(2_500_000)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out["amount"] != "2500000" || out["normalized_amount"] != "2.5" || out["unlimited"] != false || out["spender"] != "0xspender" {
+		t.Errorf("Unexpected JSON %s", b)
+	}
+}
@@ -0,0 +1,59 @@
+package transaction
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Category classifies what a transaction does.
+type Category string
+
+const (
+	// CategoryTransfer marks a plain value transfer without input data.
+	CategoryTransfer Category = "transfer"
+	// CategoryContractCall marks a call to a contract that isn't known to
+	// move tokens.
+	CategoryContractCall Category = "contract_call"
+	// CategoryDeployment marks a contract creation.
+	CategoryDeployment Category = "deployment"
+	// CategoryTokenTransfer marks a contract call that moves ERC-20 or
+	// ERC-721 tokens, by its method or by the Transfer events it emitted.
+	CategoryTokenTransfer Category = "token_transfer"
+)
+
+// tokenTransferSelectors are the method selectors of ERC-20 and ERC-721
+// transfers: transfer(address,uint256), transferFrom(address,address,uint256)
+// and both safeTransferFrom overloads.
+var tokenTransferSelectors = map[string]bool{
+	"0xa9059cbb": true,
+	"0x23b872dd": true,
+	"0x42842e0e": true,
+	"0xb88d4fde": true,
+}
+
+// ParseCategory validates s as a Category.
+func ParseCategory(s string) (Category, error) {
+	switch c := Category(s); c {
+	case CategoryTransfer, CategoryContractCall, CategoryDeployment, CategoryTokenTransfer:
+		return c, nil
+	}
+	return "", fmt.Errorf("invalid category %q: expected transfer, contract_call, deployment or token_transfer", s)
+}
+
+// Categorize classifies a transaction from its receiver and hex input data.
+// Calls moving tokens without a well-known transfer method are categorized
+// as contract calls; the parser upgrades them once it sees their Transfer
+// events.
+func Categorize(to, input string) Category {
+	if to == "" {
+		return CategoryDeployment
+	}
+	input = strings.ToLower(input)
+	if input == "" || input == "0x" {
+		return CategoryTransfer
+	}
+	if len(input) >= 10 && tokenTransferSelectors[input[:10]] {
+		return CategoryTokenTransfer
+	}
+	return CategoryContractCall
+}
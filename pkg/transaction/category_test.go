@@ -0,0 +1,26 @@
+package transaction
+
+import "testing"
+
+func TestCategorize(t *testing.T) {
+	tests := []struct {
+		to, input string
+		want      Category
+	}{
+		{"", "0x6080", CategoryDeployment},
+		{"0xabc", "0x", CategoryTransfer},
+		{"0xabc", "", CategoryTransfer},
+		{"0xabc", "0xA9059CBB0000", CategoryTokenTransfer},
+		{"0xabc", "0x23b872dd", CategoryTokenTransfer},
+		{"0xabc", "0x095ea7b3", CategoryContractCall},
+		{"0xabc", "0x01", CategoryContractCall},
+	}
+	for _, tt := range tests {
+		if got := Categorize(tt.to, tt.input); got != tt.want {
+			t.Errorf("Categorize(%q, %q) = %q, want %q", tt.to, tt.input, got, tt.want)
+		}
+	}
+	if _, err := ParseCategory("swap"); err == nil {
+		t.Error("Expected an unknown category to be rejected")
+	}
+}
@@ -0,0 +1,37 @@
+package transaction
+
+import "strings"
+
+// EventSubscription selects the events of one contract with one topic0,
+// the Keccak-256 of the event signature. Both are lowercase hex.
+type EventSubscription struct {
+	Contract string `json:"contract"`
+	Topic0   string `json:"topic0"`
+}
+
+// Normalize lowercases the contract and topic0 of s.
+func (s EventSubscription) Normalize() EventSubscription {
+	return EventSubscription{Contract: strings.ToLower(s.Contract), Topic0: strings.ToLower(s.Topic0)}
+}
+
+// Log is a contract event matched by an event subscription.
+type Log struct {
+	Contract string `json:"contract"`
+	// Topics holds topic0 followed by the indexed event arguments.
+	Topics []string `json:"topics"`
+	// Data holds the ABI-encoded unindexed event arguments in hex.
+	Data  string `json:"data"`
+	Block int    `json:"block"`
+	Hash  string `json:"hash"`
+	// LogIndex is the position of the event in its block.
+	LogIndex int `json:"log_index"`
+}
+
+// Subscription returns the subscription l matches.
+func (l Log) Subscription() EventSubscription {
+	var topic0 string
+	if len(l.Topics) > 0 {
+		topic0 = l.Topics[0]
+	}
+	return EventSubscription{Contract: l.Contract, Topic0: topic0}.Normalize()
+}
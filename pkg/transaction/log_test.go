@@ -0,0 +1,13 @@
+package transaction
+
+import "testing"
+
+func TestLog_Subscription(t *testing.T) {
+	l := Log{Contract: "0xA0B8", Topics: []string{"0xDDF2", "0x01"}}
+	if got := l.Subscription(); got != (EventSubscription{Contract: "0xa0b8", Topic0: "0xddf2"}) {
+		t.Errorf("Unexpected subscription %+v", got)
+	}
+	if got := (Log{Contract: "0xa0b8"}).Subscription(); got.Topic0 != "" {
+		t.Errorf("Expected an anonymous event to have no topic0, got %+v", got)
+	}
+}
@@ -1,12 +1,224 @@
 // Package transaction defines shared domain models.
 package transaction
 
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/danieloluwadare/tw-txparser/pkg/labels"
+)
+
+// Direction is a transaction's direction relative to the address it is
+// stored for.
+type Direction string
+
+const (
+	// DirectionIn marks a transaction sent to the address.
+	DirectionIn Direction = "in"
+	// DirectionOut marks a transaction sent from the address.
+	DirectionOut Direction = "out"
+	// DirectionSelf marks a transaction the address sent to itself.
+	DirectionSelf Direction = "self"
+)
+
+// ParseDirection validates s as a Direction.
+func ParseDirection(s string) (Direction, error) {
+	switch d := Direction(s); d {
+	case DirectionIn, DirectionOut, DirectionSelf:
+		return d, nil
+	}
+	return "", fmt.Errorf("invalid direction %q: expected in, out or self", s)
+}
+
+// Status is the outcome of a mined transaction, from its receipt.
+type Status string
+
+const (
+	// StatusSuccess marks a transaction that executed successfully.
+	StatusSuccess Status = "success"
+	// StatusReverted marks a transaction that reverted. Its value wasn't
+	// transferred, but its fee was still paid.
+	StatusReverted Status = "reverted"
+)
+
+// Receipt holds the fields of a transaction filled in from its receipt.
+type Receipt struct {
+	Status  Status
+	GasUsed uint64
+	Fee     Value
+}
+
 // Transaction is a normalized transaction persisted per address.
 type Transaction struct {
-	Hash    string `json:"hash"`
-	From    string `json:"from"`
-	To      string `json:"to"`
-	Value   string `json:"value"`
-	Block   int    `json:"block"`
-	Inbound bool   `json:"inbound"` // true if transaction is TO the subscribed address
+	Hash  string `json:"hash"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Value Value  `json:"value"`
+	Block int    `json:"block"`
+	// Direction is relative to the address the transaction is stored for. It
+	// is empty for transactions not tied to an address, such as ones looked
+	// up from the node by hash.
+	Direction Direction `json:"direction,omitempty"`
+	// IndexedAt is when the transaction was stored for the address, as
+	// opposed to when its block was mined. It lets clients fetch only what
+	// was indexed since their last poll, including backfilled history.
+	IndexedAt time.Time `json:"indexed_at,omitzero"`
+	// Fee is the gas fee the sender paid, gasUsed × effectiveGasPrice, in
+	// wei. It is nil unless the receipt was fetched.
+	Fee *Value `json:"fee,omitempty"`
+	// Status and GasUsed are empty unless the receipt was fetched by
+	// receipt enrichment.
+	Status  Status `json:"status,omitempty"`
+	GasUsed uint64 `json:"gas_used,omitempty"`
+	// Call is the decoded input data of a contract call. It is nil unless
+	// ABI decoding is enabled and the method is known.
+	Call *Call `json:"call,omitempty"`
+	// Category classifies the transaction. It is empty for transactions
+	// not indexed from a block, such as ones looked up by hash.
+	Category Category `json:"category,omitempty"`
+	// Input is the hex input data of the transaction, kept only when input
+	// storage is enabled. InputTruncated marks input cut to the configured
+	// size cap.
+	Input          string `json:"input,omitempty"`
+	InputTruncated bool   `json:"input_truncated,omitempty"`
+}
+
+// Call is a contract call decoded from a transaction's input data.
+type Call struct {
+	// Selector is the 4-byte method selector, e.g. "0xa9059cbb". It is
+	// only set on calls recorded by a contract watch.
+	Selector string `json:"selector,omitempty"`
+	// Method is the function name, e.g. "transfer". It is empty, along
+	// with Signature, on contract watch calls of unknown methods.
+	Method string `json:"method,omitempty"`
+	// Signature is the canonical signature the selector is derived from,
+	// e.g. "transfer(address,uint256)".
+	Signature string `json:"signature,omitempty"`
+	// Args are nil if the arguments couldn't be decoded, e.g. because the
+	// input is truncated.
+	Args []Arg `json:"args,omitempty"`
+}
+
+// Arg is a decoded call argument. Value is rendered as text: addresses and
+// bytes in hex, integers in decimal and arrays as [a,b].
+type Arg struct {
+	Name  string `json:"name,omitempty"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// Inbound reports whether the address received value: the transaction is
+// incoming or a self-transfer.
+func (t Transaction) Inbound() bool {
+	return t.Direction == DirectionIn || t.Direction == DirectionSelf
+}
+
+// Outbound reports whether the address sent the transaction: it is
+// outgoing or a self-transfer.
+func (t Transaction) Outbound() bool {
+	return t.Direction == DirectionOut || t.Direction == DirectionSelf
+}
+
+// IdempotencyKey identifies t as stored for address: storing the same
+// transaction again for the same address and direction, as retries,
+// restarts, rescans and overlapping workers do, is a no-op. The key is
+// "address:hash:direction".
+func (t Transaction) IdempotencyKey(address string) string {
+	return address + ":" + t.Hash + ":" + string(t.Direction)
+}
+
+// jsonTransaction is the wire form of Transaction. The deprecated inbound
+// flag is kept for clients written before direction was introduced.
+type jsonTransaction struct {
+	Hash           string    `json:"hash"`
+	From           string    `json:"from"`
+	To             string    `json:"to"`
+	Value          Value     `json:"value"`
+	Block          int       `json:"block"`
+	Direction      Direction `json:"direction,omitempty"`
+	Inbound        *bool     `json:"inbound,omitempty"`
+	IndexedAt      time.Time `json:"indexed_at,omitzero"`
+	Fee            *Value    `json:"fee,omitempty"`
+	Status         Status    `json:"status,omitempty"`
+	GasUsed        uint64    `json:"gas_used,omitempty"`
+	Call           *Call     `json:"call,omitempty"`
+	Category       Category  `json:"category,omitempty"`
+	Input          string    `json:"input,omitempty"`
+	InputTruncated bool      `json:"input_truncated,omitempty"`
+	// Presentation fields, only set when encoding an Annotated.
+	ValueEther string        `json:"value_ether,omitempty"`
+	FromName   string        `json:"from_name,omitempty"`
+	ToName     string        `json:"to_name,omitempty"`
+	FromLabel  *labels.Label `json:"from_label,omitempty"`
+	ToLabel    *labels.Label `json:"to_label,omitempty"`
+}
+
+// wire converts t to its wire form.
+func (t Transaction) wire() jsonTransaction {
+	inbound := t.Inbound()
+	return jsonTransaction{
+		Hash:           t.Hash,
+		From:           t.From,
+		To:             t.To,
+		Value:          t.Value,
+		Block:          t.Block,
+		Direction:      t.Direction,
+		Inbound:        &inbound,
+		IndexedAt:      t.IndexedAt,
+		Fee:            t.Fee,
+		Status:         t.Status,
+		GasUsed:        t.GasUsed,
+		Call:           t.Call,
+		Category:       t.Category,
+		Input:          t.Input,
+		InputTruncated: t.InputTruncated,
+	}
+}
+
+// MarshalJSON encodes t with both direction and the deprecated inbound flag.
+func (t Transaction) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.wire())
+}
+
+// Annotated is a Transaction with optional fields for display in API
+// responses. Empty fields are omitted from its JSON encoding.
+type Annotated struct {
+	Transaction
+	// ValueEther is the value formatted in ether.
+	ValueEther string
+	// FromName and ToName are the ENS names of the counterparties.
+	FromName string
+	ToName   string
+	// FromLabel and ToLabel identify well-known counterparties.
+	FromLabel *labels.Label
+	ToLabel   *labels.Label
+}
+
+// MarshalJSON encodes a like Transaction plus the non-empty annotations.
+func (a Annotated) MarshalJSON() ([]byte, error) {
+	w := a.Transaction.wire()
+	w.ValueEther = a.ValueEther
+	w.FromName = a.FromName
+	w.ToName = a.ToName
+	w.FromLabel = a.FromLabel
+	w.ToLabel = a.ToLabel
+	return json.Marshal(w)
+}
+
+// UnmarshalJSON decodes t, deriving the direction from the deprecated
+// inbound flag when direction is absent.
+func (t *Transaction) UnmarshalJSON(data []byte) error {
+	var j jsonTransaction
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	*t = Transaction{Hash: j.Hash, From: j.From, To: j.To, Value: j.Value, Block: j.Block, Direction: j.Direction, IndexedAt: j.IndexedAt, Fee: j.Fee, Status: j.Status, GasUsed: j.GasUsed, Call: j.Call, Category: j.Category, Input: j.Input, InputTruncated: j.InputTruncated}
+	if t.Direction == "" && j.Inbound != nil {
+		t.Direction = DirectionOut
+		if *j.Inbound {
+			t.Direction = DirectionIn
+		}
+	}
+	return nil
 }
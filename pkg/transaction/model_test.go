@@ -2,7 +2,10 @@ package transaction
 
 import (
 	"encoding/json"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestTransaction(t *testing.T) {
@@ -10,7 +13,7 @@ func TestTransaction(t *testing.T) {
 		Hash:  "0x1234567890abcdef",
 		From:  "0xfromaddress",
 		To:    "0xtoaddress",
-		Value: "1000000000000000000",
+		Value: WeiValue(1000000000000000000),
 		Block: 12345,
 	}
 
@@ -37,10 +40,186 @@ func TestTransaction(t *testing.T) {
 	if unmarshaledTx.To != tx.To {
 		t.Errorf("To mismatch: got %s, expected %s", unmarshaledTx.To, tx.To)
 	}
-	if unmarshaledTx.Value != tx.Value {
+	if unmarshaledTx.Value.Cmp(tx.Value) != 0 {
 		t.Errorf("Value mismatch: got %s, expected %s", unmarshaledTx.Value, tx.Value)
 	}
 	if unmarshaledTx.Block != tx.Block {
 		t.Errorf("Block mismatch: got %d, expected %d", unmarshaledTx.Block, tx.Block)
 	}
 }
+
+func TestTransaction_DirectionJSON(t *testing.T) {
+	tests := []struct {
+		direction Direction
+		expected  string
+	}{
+		{direction: DirectionIn, expected: `"direction":"in","inbound":true`},
+		{direction: DirectionOut, expected: `"direction":"out","inbound":false`},
+		{direction: DirectionSelf, expected: `"direction":"self","inbound":true`},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(Transaction{Hash: "0xhash1", Direction: tt.direction})
+		if err != nil {
+			t.Fatalf("Failed to marshal transaction: %v", err)
+		}
+		if !strings.Contains(string(data), tt.expected) {
+			t.Errorf("Expected %s in %s", tt.expected, data)
+		}
+		var decoded Transaction
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("Failed to unmarshal transaction: %v", err)
+		}
+		if decoded.Direction != tt.direction {
+			t.Errorf("Expected direction %s after round trip, got %s", tt.direction, decoded.Direction)
+		}
+	}
+}
+
+func TestTransaction_IndexedAtJSON(t *testing.T) {
+	data, err := json.Marshal(Transaction{Hash: "0xhash1"})
+	if err != nil {
+		t.Fatalf("Failed to marshal transaction: %v", err)
+	}
+	if strings.Contains(string(data), "indexed_at") {
+		t.Errorf("Expected no indexed_at for an unset time, got %s", data)
+	}
+
+	indexed := time.Date(2024, 5, 1, 12, 0, 0, 123456789, time.UTC)
+	data, err = json.Marshal(Transaction{Hash: "0xhash1", IndexedAt: indexed})
+	if err != nil {
+		t.Fatalf("Failed to marshal transaction: %v", err)
+	}
+	if !strings.Contains(string(data), `"indexed_at":"2024-05-01T12:00:00.123456789Z"`) {
+		t.Errorf("Expected indexed_at in %s", data)
+	}
+	var decoded Transaction
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal transaction: %v", err)
+	}
+	if !decoded.IndexedAt.Equal(indexed) {
+		t.Errorf("Expected indexed_at %v after round trip, got %v", indexed, decoded.IndexedAt)
+	}
+}
+
+func TestTransaction_FeeJSON(t *testing.T) {
+	data, err := json.Marshal(Transaction{Hash: "0xhash1"})
+	if err != nil {
+		t.Fatalf("Failed to marshal transaction: %v", err)
+	}
+	if strings.Contains(string(data), "fee") {
+		t.Errorf("Expected no fee when unknown, got %s", data)
+	}
+
+	fee := WeiValue(21000000000000)
+	data, err = json.Marshal(Transaction{Hash: "0xhash1", Fee: &fee})
+	if err != nil {
+		t.Fatalf("Failed to marshal transaction: %v", err)
+	}
+	if !strings.Contains(string(data), `"fee":"21000000000000"`) {
+		t.Errorf("Expected fee in %s", data)
+	}
+	var decoded Transaction
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal transaction: %v", err)
+	}
+	if decoded.Fee == nil || decoded.Fee.Cmp(fee) != 0 {
+		t.Errorf("Expected fee %s after round trip, got %v", fee, decoded.Fee)
+	}
+}
+
+func TestTransaction_CallJSON(t *testing.T) {
+	tx := Transaction{Hash: "0xhash1", Call: &Call{
+		Method:    "transfer",
+		Signature: "transfer(address,uint256)",
+		Args:      []Arg{{Name: "to", Type: "address", Value: "0xto1"}, {Name: "value", Type: "uint256", Value: "1000"}},
+	}}
+	data, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatalf("Failed to marshal transaction: %v", err)
+	}
+	if !strings.Contains(string(data), `"call":{"method":"transfer","signature":"transfer(address,uint256)","args":[{"name":"to"`) {
+		t.Errorf("Expected the call in %s", data)
+	}
+	var decoded Transaction
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal transaction: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.Call, tx.Call) {
+		t.Errorf("Expected call %+v after round trip, got %+v", tx.Call, decoded.Call)
+	}
+}
+
+func TestTransaction_LegacyInbound(t *testing.T) {
+	tests := map[string]Direction{
+		`{"hash":"0x1","inbound":true}`:                    DirectionIn,
+		`{"hash":"0x1","inbound":false}`:                   DirectionOut,
+		`{"hash":"0x1","inbound":true,"direction":"self"}`: DirectionSelf,
+		`{"hash":"0x1"}`:                                   "",
+	}
+	for input, want := range tests {
+		var tx Transaction
+		if err := json.Unmarshal([]byte(input), &tx); err != nil {
+			t.Fatalf("Failed to unmarshal %s: %v", input, err)
+		}
+		if tx.Direction != want {
+			t.Errorf("%s: expected direction %q, got %q", input, want, tx.Direction)
+		}
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	tx := Transaction{Hash: "0xabc", Block: 7, Direction: DirectionIn, IndexedAt: time.Now()}
+	if got := tx.IdempotencyKey("0xaaa"); got != "0xaaa:0xabc:in" {
+		t.Errorf("Unexpected key %q", got)
+	}
+	// Redeliveries differ in when they were indexed, not in their key.
+	again := tx
+	again.IndexedAt = tx.IndexedAt.Add(time.Hour)
+	if again.IdempotencyKey("0xaaa") != tx.IdempotencyKey("0xaaa") {
+		t.Error("Expected the key to ignore the indexing time")
+	}
+	tx.Direction = DirectionOut
+	if got := tx.IdempotencyKey("0xaaa"); got != "0xaaa:0xabc:out" {
+		t.Errorf("Unexpected key %q", got)
+	}
+
+	tt := TokenTransfer{Hash: "0xabc", LogIndex: 3, TokenID: "42", Direction: DirectionIn}
+	if got := tt.IdempotencyKey("0xaaa"); got != "0xaaa:0xabc:3:42:in" {
+		t.Errorf("Unexpected token transfer key %q", got)
+	}
+}
+
+func TestParseDirection(t *testing.T) {
+	for _, s := range []string{"in", "out", "self"} {
+		if d, err := ParseDirection(s); err != nil || string(d) != s {
+			t.Errorf("ParseDirection(%q) = %q, %v", s, d, err)
+		}
+	}
+	if _, err := ParseDirection("inbound"); err == nil {
+		t.Error("Expected error for unknown direction")
+	}
+}
+
+func TestAnnotated(t *testing.T) {
+	tx := Transaction{Hash: "0x1", Value: MustParseValue("1500000000000000000"), Direction: DirectionIn}
+	data, err := json.Marshal(Annotated{Transaction: tx, ValueEther: tx.Value.Ether(), FromName: "vitalik.eth"})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["value"] != "1500000000000000000" || got["value_ether"] != "1.5" || got["direction"] != "in" || got["from_name"] != "vitalik.eth" {
+		t.Errorf("Unexpected JSON: %s", data)
+	}
+
+	plain, _ := json.Marshal(tx)
+	var fields map[string]any
+	if err := json.Unmarshal(plain, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fields["value_ether"]; ok {
+		t.Errorf("Expected no value_ether without annotations: %s", plain)
+	}
+}
@@ -0,0 +1,63 @@
+package transaction
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// TokenStandard identifies the token contract interface a transfer came from.
+type TokenStandard string
+
+const (
+	// StandardERC20 marks fungible token transfers.
+	StandardERC20 TokenStandard = "erc20"
+	// StandardERC721 marks NFT transfers; Amount is always 1.
+	StandardERC721 TokenStandard = "erc721"
+	// StandardERC1155 marks multi-token transfers.
+	StandardERC1155 TokenStandard = "erc1155"
+)
+
+// TokenTransfer is a token transfer emitted by a contract, persisted per
+// address separately from native transactions.
+type TokenTransfer struct {
+	Hash string `json:"hash"`
+	// LogIndex is the position of the transfer event in its block.
+	LogIndex int           `json:"log_index"`
+	Block    int           `json:"block"`
+	Contract string        `json:"contract"`
+	Standard TokenStandard `json:"standard"`
+	// Symbol, Name and Decimals come from the token contract and are empty
+	// when unknown. NFTs have no decimals.
+	Symbol   string `json:"symbol,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Decimals int    `json:"decimals"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	// TokenID identifies the token for ERC-721 and ERC-1155 transfers.
+	TokenID string `json:"token_id,omitempty"`
+	// Amount is the raw amount in the token's smallest unit.
+	Amount Value `json:"amount"`
+	// Direction is relative to the address the transfer is stored for.
+	Direction Direction `json:"direction,omitempty"`
+}
+
+// NormalizedAmount renders Amount scaled by Decimals, e.g. "1.5" for 1.5 USDC.
+func (t TokenTransfer) NormalizedAmount() string {
+	return t.Amount.Decimal(t.Decimals)
+}
+
+// IdempotencyKey identifies t as stored for address, like
+// Transaction.IdempotencyKey. A transaction can move several tokens, so the
+// key is "address:hash:log_index:token_id:direction".
+func (t TokenTransfer) IdempotencyKey(address string) string {
+	return address + ":" + t.Hash + ":" + strconv.Itoa(t.LogIndex) + ":" + t.TokenID + ":" + string(t.Direction)
+}
+
+// MarshalJSON encodes t with an additional normalized_amount field.
+func (t TokenTransfer) MarshalJSON() ([]byte, error) {
+	type plain TokenTransfer
+	return json.Marshal(struct {
+		plain
+		NormalizedAmount string `json:"normalized_amount"`
+	}{plain(t), t.NormalizedAmount()})
+}
@@ -0,0 +1,63 @@
+package transaction
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTokenTransfer_NormalizedAmount(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		decimals int
+		expected string
+	}{
+		{name: "usdc", amount: "1500000", decimals: 6, expected: "1.5"},
+		{name: "whole", amount: "2000000000000000000", decimals: 18, expected: "2"},
+		{name: "nft", amount: "1", decimals: 0, expected: "1"},
+		{name: "dust", amount: "5", decimals: 6, expected: "0.000005"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transfer := TokenTransfer{Amount: MustParseValue(tt.amount), Decimals: tt.decimals}
+			if got := transfer.NormalizedAmount(); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestTokenTransfer_JSON(t *testing.T) {
+	in := TokenTransfer{
+		Hash:      "0xhash",
+		LogIndex:  7,
+		Block:     100,
+		Contract:  "0xa0b8",
+		Standard:  StandardERC20,
+		Symbol:    "USDC",
+		Decimals:  6,
+		From:      "0xfrom",
+		To:        "0xto",
+		Amount:    WeiValue(2500000),
+		Direction: DirectionIn,
+	}
+	data, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields["amount"] != "2500000" || fields["normalized_amount"] != "2.5" || fields["standard"] != "erc20" {
+		t.Errorf("Unexpected JSON: %s", data)
+	}
+
+	var out TokenTransfer
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if out.Contract != in.Contract || out.LogIndex != in.LogIndex || out.Amount.Cmp(in.Amount) != 0 || out.Direction != in.Direction {
+		t.Errorf("Round trip mismatch: %+v", out)
+	}
+}
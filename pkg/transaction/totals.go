@@ -0,0 +1,127 @@
+package transaction
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Totals aggregates the value an address received and sent, so clients
+// don't have to sum thousands of records. Self-transfers count as both
+// received and sent.
+type Totals struct {
+	// Transactions is the number of native transactions.
+	Transactions int `json:"transactions"`
+	// In and Out are the native value received and sent, in wei.
+	In  Value `json:"in"`
+	Out Value `json:"out"`
+	// Fees is the gas the address paid for the transactions whose fee is
+	// known.
+	Fees Value `json:"fees"`
+	// Tokens holds per-token totals ordered by contract.
+	Tokens []TokenTotals `json:"tokens"`
+}
+
+// TokenTotals aggregates the transfers of one token contract.
+type TokenTotals struct {
+	Contract string        `json:"contract"`
+	Standard TokenStandard `json:"standard"`
+	Symbol   string        `json:"symbol,omitempty"`
+	Decimals int           `json:"decimals"`
+	// Transfers is the number of transfers.
+	Transfers int `json:"transfers"`
+	// In and Out are raw amounts in the token's smallest unit.
+	In  Value `json:"in"`
+	Out Value `json:"out"`
+}
+
+// Add accounts for tx, stored for the address the totals belong to.
+func (t *Totals) Add(tx Transaction) {
+	t.Transactions++
+	if tx.Inbound() {
+		t.In = t.In.Add(tx.Value)
+	}
+	if tx.Outbound() {
+		t.Out = t.Out.Add(tx.Value)
+		if tx.Fee != nil {
+			t.Fees = t.Fees.Add(*tx.Fee)
+		}
+	}
+}
+
+// Remove stops accounting for tx, which was added before and has since
+// been removed, e.g. by a chain reorganization.
+func (t *Totals) Remove(tx Transaction) {
+	t.Transactions--
+	if tx.Inbound() {
+		t.In = t.In.Sub(tx.Value)
+	}
+	if tx.Outbound() {
+		t.Out = t.Out.Sub(tx.Value)
+		if tx.Fee != nil {
+			t.Fees = t.Fees.Sub(*tx.Fee)
+		}
+	}
+}
+
+// AddTokenTransfer accounts for tt, stored for the address the totals
+// belong to.
+func (t *Totals) AddTokenTransfer(tt TokenTransfer) {
+	i := sort.Search(len(t.Tokens), func(i int) bool { return t.Tokens[i].Contract >= tt.Contract })
+	if i == len(t.Tokens) || t.Tokens[i].Contract != tt.Contract {
+		t.Tokens = append(t.Tokens, TokenTotals{})
+		copy(t.Tokens[i+1:], t.Tokens[i:])
+		t.Tokens[i] = TokenTotals{Contract: tt.Contract, Standard: tt.Standard}
+	}
+	tok := &t.Tokens[i]
+	// Token metadata may only be known for later transfers.
+	if tt.Symbol != "" {
+		tok.Symbol, tok.Decimals = tt.Symbol, tt.Decimals
+	}
+	tok.Transfers++
+	if tt.Direction == DirectionIn || tt.Direction == DirectionSelf {
+		tok.In = tok.In.Add(tt.Amount)
+	}
+	if tt.Direction == DirectionOut || tt.Direction == DirectionSelf {
+		tok.Out = tok.Out.Add(tt.Amount)
+	}
+}
+
+// RemoveTokenTransfer stops accounting for tt, which was added before and
+// has since been removed. The totals of a token left without transfers are
+// dropped.
+func (t *Totals) RemoveTokenTransfer(tt TokenTransfer) {
+	i := sort.Search(len(t.Tokens), func(i int) bool { return t.Tokens[i].Contract >= tt.Contract })
+	if i == len(t.Tokens) || t.Tokens[i].Contract != tt.Contract {
+		return
+	}
+	tok := &t.Tokens[i]
+	tok.Transfers--
+	if tok.Transfers <= 0 {
+		t.Tokens = append(t.Tokens[:i], t.Tokens[i+1:]...)
+		return
+	}
+	if tt.Direction == DirectionIn || tt.Direction == DirectionSelf {
+		tok.In = tok.In.Sub(tt.Amount)
+	}
+	if tt.Direction == DirectionOut || tt.Direction == DirectionSelf {
+		tok.Out = tok.Out.Sub(tt.Amount)
+	}
+}
+
+// Clone returns a copy of t that doesn't share its token totals. Tokens is
+// never nil in the copy, so it encodes as an empty list.
+func (t Totals) Clone() Totals {
+	t.Tokens = append(make([]TokenTotals, 0, len(t.Tokens)), t.Tokens...)
+	return t
+}
+
+// MarshalJSON encodes t with the token totals scaled by their decimals as
+// normalized_in and normalized_out.
+func (t TokenTotals) MarshalJSON() ([]byte, error) {
+	type plain TokenTotals
+	return json.Marshal(struct {
+		plain
+		NormalizedIn  string `json:"normalized_in"`
+		NormalizedOut string `json:"normalized_out"`
+	}{plain(t), t.In.Decimal(t.Decimals), t.Out.Decimal(t.Decimals)})
+}
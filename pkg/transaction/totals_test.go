@@ -0,0 +1,62 @@
+package transaction
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTotals(t *testing.T) {
+	var totals Totals
+	fee := WeiValue(21)
+	totals.Add(Transaction{Hash: "0x1", Value: WeiValue(100), Direction: DirectionIn})
+	totals.Add(Transaction{Hash: "0x2", Value: WeiValue(30), Direction: DirectionOut, Fee: &fee})
+	totals.Add(Transaction{Hash: "0x3", Value: WeiValue(5), Direction: DirectionSelf, Fee: &fee})
+	totals.Add(Transaction{Hash: "0x4", Value: WeiValue(1), Direction: DirectionOut})
+
+	if totals.Transactions != 4 || totals.In.String() != "105" || totals.Out.String() != "36" || totals.Fees.String() != "42" {
+		t.Errorf("Unexpected native totals %+v", totals)
+	}
+
+	const usdc, weth = "0xa0b8", "0xc02a"
+	totals.AddTokenTransfer(TokenTransfer{Contract: weth, Standard: StandardERC20, Amount: WeiValue(3), Direction: DirectionIn})
+	totals.AddTokenTransfer(TokenTransfer{Contract: usdc, Standard: StandardERC20, Amount: WeiValue(2_500_000), Direction: DirectionIn})
+	totals.AddTokenTransfer(TokenTransfer{Contract: usdc, Standard: StandardERC20, Symbol: "USDC", Decimals: 6, Amount: WeiValue(1_000_000), Direction: DirectionOut})
+
+	if len(totals.Tokens) != 2 || totals.Tokens[0].Contract != usdc || totals.Tokens[1].Contract != weth {
+		t.Fatalf("Expected token totals ordered by contract, got %+v", totals.Tokens)
+	}
+	tok := totals.Tokens[0]
+	if tok.Transfers != 2 || tok.In.String() != "2500000" || tok.Out.String() != "1000000" || tok.Symbol != "USDC" || tok.Decimals != 6 {
+		t.Errorf("Unexpected USDC totals %+v", tok)
+	}
+
+	data, err := json.Marshal(tok)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields["in"] != "2500000" || fields["normalized_in"] != "2.5" || fields["normalized_out"] != "1" {
+		t.Errorf("Unexpected JSON: %s", data)
+	}
+
+	totals.Remove(Transaction{Hash: "0x2", Value: WeiValue(30), Direction: DirectionOut, Fee: &fee})
+	if totals.Transactions != 3 || totals.In.String() != "105" || totals.Out.String() != "6" || totals.Fees.String() != "21" {
+		t.Errorf("Unexpected native totals after a removal %+v", totals)
+	}
+	totals.RemoveTokenTransfer(TokenTransfer{Contract: weth, Standard: StandardERC20, Amount: WeiValue(3), Direction: DirectionIn})
+	if len(totals.Tokens) != 1 || totals.Tokens[0].Contract != usdc {
+		t.Errorf("Expected the totals of a token without transfers to be dropped, got %+v", totals.Tokens)
+	}
+
+	clone := totals.Clone()
+	clone.AddTokenTransfer(TokenTransfer{Contract: usdc, Amount: WeiValue(1), Direction: DirectionIn})
+	if totals.Tokens[0].Transfers != 2 {
+		t.Error("Expected the clone not to share token totals")
+	}
+	if empty := (Totals{}).Clone(); empty.Tokens == nil {
+		t.Error("Expected a clone to have non-nil tokens")
+	}
+}
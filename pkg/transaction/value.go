@@ -0,0 +1,176 @@
+package transaction
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Decimals of the units a Value can be rendered in.
+const (
+	gweiDecimals  = 9
+	etherDecimals = 18
+)
+
+// Value is an amount of wei. The zero Value is 0 wei. Values are immutable;
+// the accessors return copies.
+type Value struct {
+	wei *big.Int
+}
+
+// NewValue creates a Value of wei, which must not be negative.
+func NewValue(wei *big.Int) Value {
+	if wei == nil {
+		return Value{}
+	}
+	return Value{wei: new(big.Int).Set(wei)}
+}
+
+// WeiValue creates a Value from a small wei amount.
+func WeiValue(wei int64) Value {
+	return Value{wei: big.NewInt(wei)}
+}
+
+// ParseValue parses a wei amount written in decimal or as 0x-prefixed hex,
+// the form used by JSON-RPC.
+func ParseValue(s string) (Value, error) {
+	digits, base := strings.TrimSpace(s), 10
+	if h, ok := strings.CutPrefix(digits, "0x"); ok {
+		digits, base = h, 16
+		if digits == "" {
+			return Value{}, nil
+		}
+	}
+	v, ok := new(big.Int).SetString(digits, base)
+	if !ok || v.Sign() < 0 {
+		return Value{}, fmt.Errorf("invalid wei amount %q", s)
+	}
+	return Value{wei: v}, nil
+}
+
+// MustParseValue is like ParseValue but panics on invalid input. It is meant
+// for constants and tests.
+func MustParseValue(s string) Value {
+	v, err := ParseValue(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// ParseEther converts a decimal ether amount such as "1.5" to a Value.
+func ParseEther(s string) (Value, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok || r.Sign() < 0 {
+		return Value{}, fmt.Errorf("invalid ether amount %q", s)
+	}
+	r.Mul(r, new(big.Rat).SetInt(pow10(etherDecimals)))
+	if !r.IsInt() {
+		return Value{}, fmt.Errorf("ether amount %q has more than %d decimals", s, etherDecimals)
+	}
+	return Value{wei: new(big.Int).Set(r.Num())}, nil
+}
+
+// Wei returns the amount in wei.
+func (v Value) Wei() *big.Int {
+	if v.wei == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(v.wei)
+}
+
+// Gwei renders the amount in gwei without trailing zeros, e.g. "21.5".
+func (v Value) Gwei() string {
+	return v.Decimal(gweiDecimals)
+}
+
+// Ether renders the amount in ether without trailing zeros, e.g. "1.5".
+func (v Value) Ether() string {
+	return v.Decimal(etherDecimals)
+}
+
+// String renders the amount in wei.
+func (v Value) String() string {
+	if v.wei == nil {
+		return "0"
+	}
+	return v.wei.String()
+}
+
+// Sign returns 0 for a zero amount and 1 otherwise.
+func (v Value) Sign() int {
+	if v.wei == nil {
+		return 0
+	}
+	return v.wei.Sign()
+}
+
+// Add returns the sum of v and o.
+func (v Value) Add(o Value) Value {
+	if o.wei == nil {
+		return v
+	}
+	if v.wei == nil {
+		return o
+	}
+	return Value{wei: new(big.Int).Add(v.wei, o.wei)}
+}
+
+// Sub returns v minus o.
+func (v Value) Sub(o Value) Value {
+	if o.wei == nil {
+		return v
+	}
+	return Value{wei: new(big.Int).Sub(v.Wei(), o.wei)}
+}
+
+// Cmp compares v and o, returning -1, 0 or +1.
+func (v Value) Cmp(o Value) int {
+	return v.Wei().Cmp(o.Wei())
+}
+
+// Decimal renders the amount divided by 10^decimals without trailing zeros,
+// e.g. a token amount scaled by the token's decimals.
+func (v Value) Decimal(decimals int) string {
+	if decimals <= 0 {
+		return v.String()
+	}
+	whole, frac := new(big.Int).QuoRem(v.Wei(), pow10(decimals), new(big.Int))
+	if frac.Sign() == 0 {
+		return whole.String()
+	}
+	f := strings.TrimRight(fmt.Sprintf("%0*s", decimals, frac.String()), "0")
+	return whole.String() + "." + f
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// MarshalJSON encodes v as a decimal wei string, which unlike a JSON number
+// survives clients that parse numbers as float64.
+func (v Value) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.String())
+}
+
+// UnmarshalJSON decodes a wei amount written as a decimal or hex string, or
+// as a JSON number.
+func (v *Value) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		*v = Value{}
+		return nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+	parsed, err := ParseValue(s)
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
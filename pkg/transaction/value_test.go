@@ -0,0 +1,166 @@
+package transaction
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "1000", want: "1000"},
+		{input: "0x1000", want: "4096"},
+		{input: "0x", want: "0"},
+		{input: " 42 ", want: "42"},
+		{input: "-1", wantErr: true},
+		{input: "1.5", wantErr: true},
+		{input: "0xgg", wantErr: true},
+		{input: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseValue(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseValue(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got.String() != tt.want {
+			t.Errorf("ParseValue(%q) = %s, expected %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseEther(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "1", want: "1000000000000000000"},
+		{input: "1.5", want: "1500000000000000000"},
+		{input: "0.000000000000000001", want: "1"},
+		{input: "0", want: "0"},
+		{input: "-1", wantErr: true},
+		{input: "abc", wantErr: true},
+		{input: "0.0000000000000000001", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseEther(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseEther(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got.String() != tt.want {
+			t.Errorf("ParseEther(%q) = %s, expected %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestValue_Units(t *testing.T) {
+	tests := []struct {
+		wei   string
+		gwei  string
+		ether string
+	}{
+		{wei: "1000000000000000000", gwei: "1000000000", ether: "1"},
+		{wei: "1500000000000000000", gwei: "1500000000", ether: "1.5"},
+		{wei: "21500000000", gwei: "21.5", ether: "0.0000000215"},
+		{wei: "1", gwei: "0.000000001", ether: "0.000000000000000001"},
+		{wei: "0", gwei: "0", ether: "0"},
+	}
+	for _, tt := range tests {
+		v := MustParseValue(tt.wei)
+		if got := v.Gwei(); got != tt.gwei {
+			t.Errorf("Gwei(%s) = %q, expected %q", tt.wei, got, tt.gwei)
+		}
+		if got := v.Ether(); got != tt.ether {
+			t.Errorf("Ether(%s) = %q, expected %q", tt.wei, got, tt.ether)
+		}
+		if got := v.Wei().String(); got != tt.wei {
+			t.Errorf("Wei(%s) = %s", tt.wei, got)
+		}
+	}
+}
+
+func TestValue_Zero(t *testing.T) {
+	var v Value
+	if v.String() != "0" || v.Ether() != "0" || v.Sign() != 0 {
+		t.Errorf("Unexpected zero value: %s", v)
+	}
+	if v.Cmp(WeiValue(0)) != 0 || v.Cmp(WeiValue(1)) >= 0 {
+		t.Error("Expected the zero Value to equal 0 wei")
+	}
+}
+
+func TestValue_Immutable(t *testing.T) {
+	wei := big.NewInt(5)
+	v := NewValue(wei)
+	wei.SetInt64(7)
+	v.Wei().SetInt64(9)
+	if v.String() != "5" {
+		t.Errorf("Expected Value to be unaffected by callers, got %s", v)
+	}
+}
+
+func TestValue_Add(t *testing.T) {
+	var zero Value
+	a, b := WeiValue(5), WeiValue(7)
+	if got := a.Add(b); got.String() != "12" {
+		t.Errorf("Expected 12, got %s", got)
+	}
+	if got := zero.Add(a); got.String() != "5" || a.Add(zero).String() != "5" {
+		t.Errorf("Expected adding zero to be a no-op, got %s", got)
+	}
+	if a.String() != "5" || b.String() != "7" {
+		t.Error("Expected Add to leave its operands unchanged")
+	}
+}
+
+func TestValue_Sub(t *testing.T) {
+	var zero Value
+	a, b := WeiValue(12), WeiValue(7)
+	if got := a.Sub(b); got.String() != "5" {
+		t.Errorf("Expected 5, got %s", got)
+	}
+	if got := a.Sub(zero); got.String() != "12" {
+		t.Errorf("Expected subtracting zero to be a no-op, got %s", got)
+	}
+	if a.String() != "12" || b.String() != "7" {
+		t.Error("Expected Sub to leave its operands unchanged")
+	}
+}
+
+func TestValue_JSON(t *testing.T) {
+	data, err := json.Marshal(WeiValue(1000))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if string(data) != `"1000"` {
+		t.Errorf("Expected a decimal string, got %s", data)
+	}
+
+	for input, want := range map[string]string{
+		`"1000"`:                     "1000",
+		`"0x3e8"`:                    "1000",
+		`1000`:                       "1000",
+		`null`:                       "0",
+		`"999999999999999999999999"`: "999999999999999999999999",
+	} {
+		var v Value
+		if err := json.Unmarshal([]byte(input), &v); err != nil {
+			t.Errorf("Unmarshal(%s) failed: %v", input, err)
+			continue
+		}
+		if v.String() != want {
+			t.Errorf("Unmarshal(%s) = %s, expected %s", input, v, want)
+		}
+	}
+
+	var v Value
+	if err := json.Unmarshal([]byte(`"-5"`), &v); err == nil {
+		t.Error("Expected error for a negative amount")
+	}
+}